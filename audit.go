@@ -0,0 +1,399 @@
+package yiigo
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// AuditRecord the record of the audit trail, which is append-only.
+type AuditRecord struct {
+	ID        int64     `db:"id,omitempty" json:"id,omitempty"`
+	Actor     string    `db:"actor" json:"actor"`
+	Action    string    `db:"action" json:"action"`
+	Target    string    `db:"target" json:"target"`
+	Diff      string    `db:"diff" json:"diff,omitempty"` // json
+	TraceID   string    `db:"trace_id" json:"trace_id,omitempty"`
+	RequestID string    `db:"request_id" json:"request_id,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// AuditFilter the filter of `QueryAudit`, the empty conditions are ignored.
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Target string
+	Since  time.Time
+	Until  time.Time
+	Limit  int // default: 100
+	Offset int
+}
+
+func (f *AuditFilter) match(r *AuditRecord) bool {
+	if (len(f.Actor) != 0 && r.Actor != f.Actor) || (len(f.Action) != 0 && r.Action != f.Action) || (len(f.Target) != 0 && r.Target != f.Target) {
+		return false
+	}
+
+	if (!f.Since.IsZero() && r.CreatedAt.Before(f.Since)) || (!f.Until.IsZero() && !r.CreatedAt.Before(f.Until)) {
+		return false
+	}
+
+	return true
+}
+
+func (f *AuditFilter) limit() int {
+	if f.Limit <= 0 {
+		return 100
+	}
+
+	return f.Limit
+}
+
+// AuditSink the sink of the audit records, which only appends the records (no update and delete).
+type AuditSink interface {
+	Append(ctx context.Context, record *AuditRecord) error
+}
+
+// AuditQuerier is implemented by the sink which supports to query the records (in the reverse chronological order).
+type AuditQuerier interface {
+	Query(ctx context.Context, filter *AuditFilter) ([]*AuditRecord, error)
+}
+
+// AuditSinkFunc the function of AuditSink, eg: sends the record to Kafka.
+type AuditSinkFunc func(ctx context.Context, record *AuditRecord) error
+
+// Append calls f(ctx, record).
+func (f AuditSinkFunc) Append(ctx context.Context, record *AuditRecord) error {
+	return f(ctx, record)
+}
+
+var auditSinks []AuditSink
+
+// Audit appends the audit record to the sinks (see `WithAudit`), the diff is marshaled as json (see `AuditDiff`),
+// and the trace_id and request_id are from ctx (see `ContextWithRequestID`), eg:
+//
+//	yiigo.Audit(ctx, "admin", "user.update", "user:1", yiigo.AuditDiff(before, after))
+//
+// The record is appended to all the sinks, and the first error is returned.
+func Audit(ctx context.Context, actor, action, target string, diff any) error {
+	if len(auditSinks) == 0 {
+		return errors.New("no audit sinks (forgotten configure?)")
+	}
+
+	record := &AuditRecord{
+		Actor:     actor,
+		Action:    action,
+		Target:    target,
+		CreatedAt: time.Now(),
+	}
+
+	if diff != nil {
+		b, err := json.Marshal(diff)
+
+		if err != nil {
+			return fmt.Errorf("audit diff: %w", err)
+		}
+
+		record.Diff = string(b)
+	}
+
+	for _, f := range LogFieldsFromCtx(ctx) {
+		switch f.Key {
+		case "trace_id":
+			record.TraceID = f.String
+		case "request_id":
+			record.RequestID = f.String
+		}
+	}
+
+	var err error
+
+	for _, sink := range auditSinks {
+		// each sink appends its own copy
+		r := *record
+
+		if e := sink.Append(ctx, &r); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// QueryAudit queries the records by the first sink which implements AuditQuerier.
+func QueryAudit(ctx context.Context, filter *AuditFilter) ([]*AuditRecord, error) {
+	if filter == nil {
+		filter = new(AuditFilter)
+	}
+
+	for _, sink := range auditSinks {
+		if q, ok := sink.(AuditQuerier); ok {
+			return q.Query(ctx, filter)
+		}
+	}
+
+	return nil, errors.New("no audit sinks support query")
+}
+
+// AuditChange the change of the field.
+type AuditChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// AuditDiff returns the changed fields (by the json keys) of before and after (struct or map), eg:
+// {"name": {"from": "foo", "to": "bar"}}. The nil before (after) means creation (deletion).
+func AuditDiff(before, after any) map[string]*AuditChange {
+	from := auditFields(before)
+	to := auditFields(after)
+
+	diff := make(map[string]*AuditChange)
+
+	for k, v := range from {
+		if nv, ok := to[k]; !ok || !reflect.DeepEqual(v, nv) {
+			diff[k] = &AuditChange{From: v, To: to[k]}
+		}
+	}
+
+	for k, v := range to {
+		if _, ok := from[k]; !ok {
+			diff[k] = &AuditChange{To: v}
+		}
+	}
+
+	return diff
+}
+
+func auditFields(v any) map[string]any {
+	if v == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(v)
+
+	if err != nil {
+		return nil
+	}
+
+	var fields map[string]any
+
+	if err = json.Unmarshal(b, &fields); err != nil {
+		return nil
+	}
+
+	return fields
+}
+
+type auditDBSink struct {
+	builder SQLBuilder
+	db      sqlx.ExtContext
+	table   string
+}
+
+// NewAuditDBSink returns the sink of the db table (default: audit_log) by the SQL builder, eg (MySQL):
+//
+//	CREATE TABLE `audit_log` (
+//		`id` bigint NOT NULL AUTO_INCREMENT,
+//		`actor` varchar(64) NOT NULL,
+//		`action` varchar(64) NOT NULL,
+//		`target` varchar(255) NOT NULL,
+//		`diff` text,
+//		`trace_id` varchar(64) NOT NULL DEFAULT '',
+//		`request_id` varchar(64) NOT NULL DEFAULT '',
+//		`created_at` datetime NOT NULL,
+//		PRIMARY KEY (`id`),
+//		KEY `idx_target` (`target`, `created_at`),
+//		KEY `idx_actor` (`actor`, `created_at`)
+//	);
+//
+// NOTE: revoke the UPDATE and DELETE privileges of the table to make it immutable.
+func NewAuditDBSink(builder SQLBuilder, db sqlx.ExtContext, table ...string) AuditSink {
+	s := &auditDBSink{
+		builder: builder,
+		db:      db,
+		table:   "audit_log",
+	}
+
+	if len(table) != 0 && len(table[0]) != 0 {
+		s.table = table[0]
+	}
+
+	return s
+}
+
+func (s *auditDBSink) Append(ctx context.Context, record *AuditRecord) error {
+	id, err := s.builder.Wrap(Table(s.table)).Insert(ctx, s.db, record)
+
+	if err != nil {
+		return err
+	}
+
+	record.ID = id
+
+	return nil
+}
+
+func (s *auditDBSink) Query(ctx context.Context, filter *AuditFilter) ([]*AuditRecord, error) {
+	conds := make([]*SQLClause, 0, 5)
+
+	if len(filter.Actor) != 0 {
+		conds = append(conds, Clause("actor = ?", filter.Actor))
+	}
+
+	if len(filter.Action) != 0 {
+		conds = append(conds, Clause("action = ?", filter.Action))
+	}
+
+	if len(filter.Target) != 0 {
+		conds = append(conds, Clause("target = ?", filter.Target))
+	}
+
+	if !filter.Since.IsZero() {
+		conds = append(conds, Clause("created_at >= ?", filter.Since))
+	}
+
+	if !filter.Until.IsZero() {
+		conds = append(conds, Clause("created_at < ?", filter.Until))
+	}
+
+	records := make([]*AuditRecord, 0)
+
+	err := s.builder.Wrap(
+		Table(s.table),
+		WhereClause(conds...),
+		OrderBy("id DESC"),
+		Limit(filter.limit()),
+		Offset(filter.Offset),
+	).Select(ctx, s.db, &records)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+type auditFileSink struct {
+	filename string
+	mutex    sync.Mutex
+}
+
+// NewAuditFileSink returns the sink of the file, the records are appended as json lines.
+// NOTE: the query scans the whole file, which is for the small volume.
+func NewAuditFileSink(filename string) AuditSink {
+	return &auditFileSink{filename: filename}
+}
+
+func (s *auditFileSink) Append(ctx context.Context, record *AuditRecord) error {
+	b, err := json.Marshal(record)
+
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	f, err := os.OpenFile(s.filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.Write(append(b, '\n')); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+func (s *auditFileSink) Query(ctx context.Context, filter *AuditFilter) ([]*AuditRecord, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	f, err := os.Open(s.filename)
+
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []*AuditRecord{}, nil
+		}
+
+		return nil, err
+	}
+
+	defer f.Close()
+
+	matched := make([]*AuditRecord, 0)
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64<<10), 10<<20)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if len(line) == 0 {
+			continue
+		}
+
+		record := new(AuditRecord)
+
+		if err = json.Unmarshal([]byte(line), record); err != nil {
+			return nil, err
+		}
+
+		if filter.match(record) {
+			matched = append(matched, record)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// the reverse chronological order
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.After(matched[j].CreatedAt)
+	})
+
+	if filter.Offset >= len(matched) {
+		return []*AuditRecord{}, nil
+	}
+
+	matched = matched[filter.Offset:]
+
+	if len(matched) > filter.limit() {
+		matched = matched[:filter.limit()]
+	}
+
+	return matched, nil
+}
+
+// NewAuditLogSink returns the sink of the logger, eg: the logger with the sink to Kafka (see `LogSink`).
+func NewAuditLogSink(l *zap.Logger) AuditSink {
+	return AuditSinkFunc(func(ctx context.Context, record *AuditRecord) error {
+		l.Info("audit",
+			zap.String("actor", record.Actor),
+			zap.String("action", record.Action),
+			zap.String("target", record.Target),
+			zap.String("diff", record.Diff),
+			zap.String("trace_id", record.TraceID),
+			zap.String("request_id", record.RequestID),
+			zap.Time("created_at", record.CreatedAt),
+		)
+
+		return nil
+	})
+}
@@ -0,0 +1,115 @@
+package yiigo
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditDiff(t *testing.T) {
+	type User struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+		Role string `json:"role,omitempty"`
+	}
+
+	diff := AuditDiff(&User{Name: "foo", Age: 20}, &User{Name: "foo", Age: 21, Role: "admin"})
+
+	assert.Equal(t, map[string]*AuditChange{
+		"age":  {From: float64(20), To: float64(21)},
+		"role": {To: "admin"},
+	}, diff)
+
+	diff = AuditDiff(nil, map[string]any{"name": "foo"})
+
+	assert.Equal(t, map[string]*AuditChange{"name": {To: "foo"}}, diff)
+}
+
+func TestAuditDBSink(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE audit_log (id INTEGER PRIMARY KEY, actor TEXT, action TEXT, target TEXT, diff TEXT, trace_id TEXT, request_id TEXT, created_at DATETIME)")
+	assert.Nil(t, err)
+
+	defer func() { auditSinks = nil }()
+
+	Init(WithAudit(NewAuditDBSink(NewSQLiteBuilder(), db)))
+
+	ctx = ContextWithRequestID(ctx, "req-1")
+
+	assert.Nil(t, Audit(ctx, "admin", "user.update", "user:1", AuditDiff(X{"age": 20}, X{"age": 21})))
+	assert.Nil(t, Audit(ctx, "admin", "user.delete", "user:2", nil))
+	assert.Nil(t, Audit(ctx, "guest", "user.update", "user:3", nil))
+
+	records, err := QueryAudit(ctx, &AuditFilter{Actor: "admin"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, "user.delete", records[0].Action)
+	assert.Equal(t, "user:1", records[1].Target)
+	assert.Equal(t, `{"age":{"from":20,"to":21}}`, records[1].Diff)
+	assert.Equal(t, "req-1", records[1].RequestID)
+
+	records, err = QueryAudit(ctx, &AuditFilter{Action: "user.update", Limit: 1})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, "guest", records[0].Actor)
+}
+
+func TestAuditFileSink(t *testing.T) {
+	ctx := context.TODO()
+
+	var logged []*AuditRecord
+
+	defer func() { auditSinks = nil }()
+
+	Init(WithAudit(
+		NewAuditFileSink(filepath.Join(t.TempDir(), "audit.log")),
+		AuditSinkFunc(func(ctx context.Context, record *AuditRecord) error {
+			logged = append(logged, record)
+
+			return nil
+		}),
+	))
+
+	records, err := QueryAudit(ctx, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(records))
+
+	assert.Nil(t, Audit(ctx, "admin", "order.refund", "order:1", X{"amount": 100}))
+	assert.Nil(t, Audit(ctx, "admin", "order.cancel", "order:2", nil))
+
+	assert.Equal(t, 2, len(logged))
+
+	records, err = QueryAudit(ctx, &AuditFilter{Target: "order:1"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, "order.refund", records[0].Action)
+	assert.Equal(t, `{"amount":100}`, records[0].Diff)
+
+	records, err = QueryAudit(ctx, &AuditFilter{Until: time.Now().Add(-time.Hour)})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(records))
+
+	records, err = QueryAudit(ctx, &AuditFilter{Offset: 1})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, "order.refund", records[0].Action)
+}
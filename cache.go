@@ -0,0 +1,278 @@
+package yiigo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrCacheMiss the key is not cached (or expired).
+var ErrCacheMiss = errors.New("cache miss")
+
+// Codec encodes (decodes) the values of the cache.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	// JSONCodec encodes the values by JSON.
+	JSONCodec Codec = jsonCodec{}
+
+	// MsgpackCodec encodes the values by msgpack, which is more compact and faster than JSON.
+	MsgpackCodec Codec = msgpackCodec{}
+
+	// ProtoCodec encodes the values by protobuf, the values must be proto.Message.
+	ProtoCodec Codec = protoCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+type protoCodec struct{}
+
+func (protoCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+
+	if !ok {
+		return nil, fmt.Errorf("proto codec: %T is not proto.Message", v)
+	}
+
+	return proto.Marshal(m)
+}
+
+func (protoCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+
+	if !ok {
+		return fmt.Errorf("proto codec: %T is not proto.Message", v)
+	}
+
+	return proto.Unmarshal(data, m)
+}
+
+// Cache is the cache of the values encoded by the codec (see `WithCacheCodec`).
+type Cache interface {
+	// Get decodes the value of key into dest, returns ErrCacheMiss if not exists.
+	Get(ctx context.Context, key string, dest any) error
+
+	// Set sets the value of key with ttl (never expires if ttl is 0).
+	Set(ctx context.Context, key string, value any, ttl time.Duration) error
+
+	// Delete deletes the keys.
+	Delete(ctx context.Context, keys ...string) error
+
+	// GetOrLoad decodes the value of key into dest, or loads (and caches with ttl) the value on the cache miss.
+	// The loads of the same key are executed once (single flight), and the failure of the cache falls back to load.
+	// See `WithEarlyRefresh` and `WithBackgroundRefresh` for refreshing the hot keys before expired.
+	GetOrLoad(ctx context.Context, key string, dest any, ttl time.Duration, load func(ctx context.Context) (any, error)) error
+
+	// Tagged returns the Cache which adds the keys of Set and GetOrLoad to the tags (eg: user:42, the entity of the values),
+	// eg: cache.Tagged("user:42").Set(ctx, "profile:42", profile, time.Hour)
+	Tagged(tags ...string) Cache
+
+	// InvalidateTag deletes the keys of the tags.
+	InvalidateTag(ctx context.Context, tags ...string) error
+}
+
+// cacheStore stores the encoded values.
+type cacheStore interface {
+	QueryCache
+
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// CacheOption cache option
+type CacheOption func(c *codecCache)
+
+// WithCacheCodec specifies the codec of the values, default: JSONCodec.
+func WithCacheCodec(codec Codec) CacheOption {
+	return func(c *codecCache) {
+		c.codec = codec
+	}
+}
+
+// WithCachePrefix specifies the prefix of the keys, eg: app:cache:
+func WithCachePrefix(prefix string) CacheOption {
+	return func(c *codecCache) {
+		c.prefix = prefix
+	}
+}
+
+type codecCache struct {
+	store      cacheStore
+	tags       tagStore
+	codec      Codec
+	prefix     string
+	localSize  int
+	localTTL   time.Duration
+	beta       float64
+	background bool
+	flight     singleflight.Group
+}
+
+// NewRedisCache returns the Cache stored in Redis, eg: yiigo.NewRedisCache(yiigo.Redis()).
+func NewRedisCache(pool RedisPool, options ...CacheOption) Cache {
+	c := newCodecCache(&redisQueryCache{pool: pool}, &redisTagStore{pool: pool}, options...)
+
+	if c.localSize > 0 && c.localTTL > 0 {
+		c.store = newTieredStore(pool, c.localSize, c.localTTL)
+	}
+
+	return c
+}
+
+// NewMemCache returns the in-process Cache.
+func NewMemCache(options ...CacheOption) Cache {
+	return newCodecCache(NewMemQueryCache().(*memQueryCache), newMemTagStore(), options...)
+}
+
+func newCodecCache(store cacheStore, tags tagStore, options ...CacheOption) *codecCache {
+	c := &codecCache{
+		store: store,
+		tags:  tags,
+		codec: JSONCodec,
+	}
+
+	for _, f := range options {
+		f(c)
+	}
+
+	return c
+}
+
+func (c *codecCache) Get(ctx context.Context, key string, dest any) error {
+	data, err := c.store.Get(ctx, c.prefix+key)
+
+	if err != nil {
+		return err
+	}
+
+	if data == nil {
+		return ErrCacheMiss
+	}
+
+	value, _, _ := c.unwrap(data)
+
+	return c.codec.Unmarshal(value, dest)
+}
+
+func (c *codecCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	return c.set(ctx, key, value, ttl, nil)
+}
+
+func (c *codecCache) set(ctx context.Context, key string, value any, ttl time.Duration, tags []string) error {
+	data, err := c.codec.Marshal(value)
+
+	if err != nil {
+		return err
+	}
+
+	return c.put(ctx, key, c.wrap(data, ttl, 0), ttl, tags)
+}
+
+func (c *codecCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, 0, len(keys))
+
+	for _, k := range keys {
+		prefixed = append(prefixed, c.prefix+k)
+	}
+
+	return c.store.Delete(ctx, prefixed...)
+}
+
+func (c *codecCache) GetOrLoad(ctx context.Context, key string, dest any, ttl time.Duration, load func(ctx context.Context) (any, error)) error {
+	return c.getOrLoad(ctx, key, dest, ttl, load, nil)
+}
+
+func (c *codecCache) getOrLoad(ctx context.Context, key string, dest any, ttl time.Duration, load func(ctx context.Context) (any, error), tags []string) error {
+	data, err := c.store.Get(ctx, c.prefix+key)
+
+	if err != nil {
+		logger.Warn("err cache get", zap.String("key", c.prefix+key), zap.Error(err))
+	} else if data != nil {
+		value, expireAt, delta := c.unwrap(data)
+
+		if !c.earlyExpired(expireAt, delta) {
+			return c.codec.Unmarshal(value, dest)
+		}
+
+		// serve the current value, and refresh in background
+		if c.background {
+			go func() {
+				if _, err := c.load(context.Background(), key, ttl, load, tags); err != nil {
+					logger.Warn("err cache background refresh", zap.String("key", c.prefix+key), zap.Error(err))
+				}
+			}()
+
+			return c.codec.Unmarshal(value, dest)
+		}
+	}
+
+	value, err := c.load(ctx, key, ttl, load, tags)
+
+	if err != nil {
+		return err
+	}
+
+	return c.codec.Unmarshal(value, dest)
+}
+
+// load loads the value of key and caches it, the loads of the same key are executed once (single flight).
+func (c *codecCache) load(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) (any, error), tags []string) ([]byte, error) {
+	v, err, _ := c.flight.Do(key, func() (any, error) {
+		start := time.Now()
+
+		value, err := load(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := c.codec.Marshal(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err = c.put(ctx, key, c.wrap(data, ttl, time.Since(start)), ttl, tags); err != nil {
+			logger.Warn("err cache set", zap.String("key", c.prefix+key), zap.Error(err))
+		}
+
+		return data, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]byte), nil
+}
@@ -0,0 +1,245 @@
+package yiigo
+
+import (
+	"container/list"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const cacheInvalidateChannel = "yiigo:cache:invalidate"
+
+// WithLocalCache adds the in-process LRU cache (at most size entries, each expires in ttl at most) in front of Redis
+// for the hot keys (only `NewRedisCache`). The writes (Set, Delete) invalidate the local entries of all the instances
+// via Redis pub/sub, and the local entries are purged if the subscription is broken (may miss the invalidations).
+// NOTE: The subscription lives with the process, so create the cache once.
+func WithLocalCache(size int, ttl time.Duration) CacheOption {
+	return func(c *codecCache) {
+		c.localSize = size
+		c.localTTL = ttl
+	}
+}
+
+type lruEntry struct {
+	key      string
+	value    []byte
+	expireAt time.Time
+}
+
+// lruCache is the LRU cache with ttl.
+type lruCache struct {
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+	mutex sync.Mutex
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *lruCache) Get(key string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.items[key]
+
+	if !ok {
+		return nil, false
+	}
+
+	entry := e.Value.(*lruEntry)
+
+	if time.Now().After(entry.expireAt) {
+		c.ll.Remove(e)
+		delete(c.items, key)
+
+		return nil, false
+	}
+
+	c.ll.MoveToFront(e)
+
+	return entry.value, true
+}
+
+func (c *lruCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+
+		entry := e.Value.(*lruEntry)
+		entry.value = value
+		entry.expireAt = time.Now().Add(ttl)
+
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&lruEntry{
+		key:      key,
+		value:    value,
+		expireAt: time.Now().Add(ttl),
+	})
+
+	for c.ll.Len() > c.size {
+		e := c.ll.Back()
+
+		c.ll.Remove(e)
+		delete(c.items, e.Value.(*lruEntry).key)
+	}
+}
+
+func (c *lruCache) Delete(keys ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, k := range keys {
+		if e, ok := c.items[k]; ok {
+			c.ll.Remove(e)
+			delete(c.items, k)
+		}
+	}
+}
+
+func (c *lruCache) Purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+}
+
+// cacheInvalidation the message of invalidating the local entries.
+type cacheInvalidation struct {
+	Node string   `json:"node"`
+	Keys []string `json:"keys"`
+}
+
+// tieredStore is the store of the local LRU cache in front of Redis.
+type tieredStore struct {
+	pool    RedisPool
+	remote  *redisQueryCache
+	local   *lruCache
+	ttl     time.Duration
+	node    string
+	channel string // the invalidation channel namespaced by the key prefix
+}
+
+func newTieredStore(pool RedisPool, size int, ttl time.Duration) *tieredStore {
+	b := make([]byte, 8)
+
+	rand.Read(b)
+
+	s := &tieredStore{
+		pool:    pool,
+		remote:  &redisQueryCache{pool: pool},
+		local:   newLRUCache(size),
+		ttl:     ttl,
+		node:    hex.EncodeToString(b),
+		channel: redisKeyPrefix(pool) + cacheInvalidateChannel,
+	}
+
+	go s.subscribe()
+
+	return s
+}
+
+func (s *tieredStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if v, ok := s.local.Get(key); ok {
+		return v, nil
+	}
+
+	data, err := s.remote.Get(ctx, key)
+
+	if err != nil || data == nil {
+		return data, err
+	}
+
+	s.local.Set(key, data, s.ttl)
+
+	return data, nil
+}
+
+func (s *tieredStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := s.remote.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+
+	s.invalidate(ctx, key)
+
+	localTTL := s.ttl
+
+	if ttl > 0 && ttl < localTTL {
+		localTTL = ttl
+	}
+
+	s.local.Set(key, value, localTTL)
+
+	return nil
+}
+
+func (s *tieredStore) Incr(ctx context.Context, key string) error {
+	if err := s.remote.Incr(ctx, key); err != nil {
+		return err
+	}
+
+	s.local.Delete(key)
+	s.invalidate(ctx, key)
+
+	return nil
+}
+
+func (s *tieredStore) Delete(ctx context.Context, keys ...string) error {
+	if err := s.remote.Delete(ctx, keys...); err != nil {
+		return err
+	}
+
+	s.local.Delete(keys...)
+	s.invalidate(ctx, keys...)
+
+	return nil
+}
+
+// invalidate broadcasts the invalidation of keys to the other instances.
+func (s *tieredStore) invalidate(ctx context.Context, keys ...string) {
+	msg, err := json.Marshal(&cacheInvalidation{Node: s.node, Keys: keys})
+
+	if err != nil {
+		return
+	}
+
+	if _, err = s.pool.Do(ctx, "PUBLISH", s.channel, msg); err != nil {
+		logger.Error("err cache invalidate publish", zap.Strings("keys", keys), zap.Error(err))
+	}
+}
+
+// subscribe receives the invalidations from the other instances.
+func (s *tieredStore) subscribe() {
+	handler := func(ctx context.Context, m *PubSubMessage) {
+		msg := new(cacheInvalidation)
+
+		if err := json.Unmarshal(m.Data, msg); err != nil || msg.Node == s.node {
+			return
+		}
+
+		s.local.Delete(msg.Keys...)
+	}
+
+	// the invalidations may be missed while the subscription is broken
+	purge := func(o *subscribeOptions) {
+		o.pool = s.pool
+		o.onReset = s.local.Purge
+	}
+
+	Subscribe(context.Background(), []string{s.channel}, handler, purge)
+}
@@ -0,0 +1,82 @@
+package yiigo
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// cacheEnvelopeMagic marks the value with the expire time and the load duration (early refresh).
+const cacheEnvelopeMagic = "\xffye"
+
+// WithEarlyRefresh refreshes the value of `GetOrLoad` before expired with the probability, which increases as the expire time
+// approaches and the load duration grows (XFetch, beta > 1 favors earlier refresh, default: 1), so the hot key is refreshed by
+// one caller instead of being loaded by all the callers once expired (cache stampede).
+// NOTE: The values are stored with the header of 19 bytes, so don't share the keys with the caches without it.
+func WithEarlyRefresh(beta float64) CacheOption {
+	return func(c *codecCache) {
+		if beta <= 0 {
+			beta = 1
+		}
+
+		c.beta = beta
+	}
+}
+
+// WithBackgroundRefresh refreshes the value in background when it's early expired (requires WithEarlyRefresh),
+// the callers get the current value without waiting for the load.
+func WithBackgroundRefresh() CacheOption {
+	return func(c *codecCache) {
+		c.background = true
+	}
+}
+
+// wrap prepends the header of the expire time and the load duration (delta) to data if the early refresh is enabled.
+func (c *codecCache) wrap(data []byte, ttl, delta time.Duration) []byte {
+	if c.beta <= 0 {
+		return data
+	}
+
+	var expireAt int64
+
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl).UnixMilli()
+	}
+
+	b := make([]byte, len(cacheEnvelopeMagic)+16+len(data))
+
+	n := copy(b, cacheEnvelopeMagic)
+
+	binary.BigEndian.PutUint64(b[n:], uint64(expireAt))
+	binary.BigEndian.PutUint64(b[n+8:], uint64(delta.Milliseconds()))
+
+	copy(b[n+16:], data)
+
+	return b
+}
+
+// unwrap returns the data, the expire time (unix ms, 0 if never) and the load duration (ms) of the value.
+func (c *codecCache) unwrap(b []byte) (data []byte, expireAt, delta int64) {
+	n := len(cacheEnvelopeMagic)
+
+	if c.beta <= 0 || len(b) < n+16 || string(b[:n]) != cacheEnvelopeMagic {
+		return b, 0, 0
+	}
+
+	expireAt = int64(binary.BigEndian.Uint64(b[n:]))
+	delta = int64(binary.BigEndian.Uint64(b[n+8:]))
+
+	return b[n+16:], expireAt, delta
+}
+
+// earlyExpired reports whether to refresh the value before expired: now - delta * beta * ln(rand) >= expireAt.
+func (c *codecCache) earlyExpired(expireAt, delta int64) bool {
+	if c.beta <= 0 || expireAt == 0 {
+		return false
+	}
+
+	gap := float64(delta) * c.beta * -math.Log(1-rand.Float64())
+
+	return float64(time.Now().UnixMilli())+gap >= float64(expireAt)
+}
@@ -0,0 +1,186 @@
+package yiigo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+var (
+	// KEYS[1]: tag, ARGV[1]: key, ARGV[2]: ttl (ms, 0 if never expires)
+	// the tag expires no earlier than its keys
+	cacheTagAddScript = redis.NewScript(1, `
+local existed = redis.call('EXISTS', KEYS[1])
+redis.call('SADD', KEYS[1], ARGV[1])
+local ttl = tonumber(ARGV[2])
+if ttl == 0 then
+	redis.call('PERSIST', KEYS[1])
+	return 1
+end
+local pttl = redis.call('PTTL', KEYS[1])
+if existed == 0 or (pttl >= 0 and pttl < ttl) then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1`)
+
+	// KEYS[1]: tag, returns the keys of the tag
+	cacheTagPopScript = redis.NewScript(1, `
+local keys = redis.call('SMEMBERS', KEYS[1])
+redis.call('DEL', KEYS[1])
+return keys`)
+)
+
+// tagStore stores the keys of the tags.
+type tagStore interface {
+	// Add adds the key to the tags.
+	Add(ctx context.Context, key string, ttl time.Duration, tags ...string) error
+
+	// Pop removes the tag, and returns its keys.
+	Pop(ctx context.Context, tag string) ([]string, error)
+}
+
+type redisTagStore struct {
+	pool RedisPool
+}
+
+func (s *redisTagStore) Add(ctx context.Context, key string, ttl time.Duration, tags ...string) error {
+	return s.pool.DoFunc(ctx, func(ctx context.Context, conn *RedisConn) error {
+		// one by one, the tags may be in different slots (cluster mode)
+		for _, tag := range tags {
+			if _, err := cacheTagAddScript.Do(conn.Conn, tag, key, ttl.Milliseconds()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *redisTagStore) Pop(ctx context.Context, tag string) ([]string, error) {
+	conn, err := s.pool.Get(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer s.pool.Put(conn)
+
+	return redis.Strings(cacheTagPopScript.Do(conn.Conn, tag))
+}
+
+type memTagStore struct {
+	tags  map[string]map[string]struct{}
+	mutex sync.Mutex
+}
+
+func newMemTagStore() *memTagStore {
+	return &memTagStore{tags: make(map[string]map[string]struct{})}
+}
+
+func (s *memTagStore) Add(ctx context.Context, key string, ttl time.Duration, tags ...string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, tag := range tags {
+		keys, ok := s.tags[tag]
+
+		if !ok {
+			keys = make(map[string]struct{})
+			s.tags[tag] = keys
+		}
+
+		keys[key] = struct{}{}
+	}
+
+	return nil
+}
+
+func (s *memTagStore) Pop(ctx context.Context, tag string) ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	keys := make([]string, 0, len(s.tags[tag]))
+
+	for k := range s.tags[tag] {
+		keys = append(keys, k)
+	}
+
+	delete(s.tags, tag)
+
+	return keys, nil
+}
+
+// taggedCache sets the values with the tags, see `Cache.Tagged`.
+type taggedCache struct {
+	*codecCache
+
+	tags []string
+}
+
+func (c *taggedCache) Tagged(tags ...string) Cache {
+	return &taggedCache{
+		codecCache: c.codecCache,
+		tags:       append(append(make([]string, 0, len(c.tags)+len(tags)), c.tags...), tags...),
+	}
+}
+
+func (c *taggedCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	return c.set(ctx, key, value, ttl, c.tags)
+}
+
+func (c *taggedCache) GetOrLoad(ctx context.Context, key string, dest any, ttl time.Duration, load func(ctx context.Context) (any, error)) error {
+	return c.getOrLoad(ctx, key, dest, ttl, load, c.tags)
+}
+
+func (c *codecCache) Tagged(tags ...string) Cache {
+	return &taggedCache{
+		codecCache: c,
+		tags:       tags,
+	}
+}
+
+func (c *codecCache) InvalidateTag(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		keys, err := c.tags.Pop(ctx, c.tagKey(tag))
+
+		if err != nil {
+			return err
+		}
+
+		if len(keys) == 0 {
+			continue
+		}
+
+		if err = c.store.Delete(ctx, keys...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tagKey returns the key of the tag, eg: {prefix}tag:user:42
+func (c *codecCache) tagKey(tag string) string {
+	return c.prefix + "tag:" + tag
+}
+
+// put stores the encoded value of key, and adds the key to the tags.
+func (c *codecCache) put(ctx context.Context, key string, data []byte, ttl time.Duration, tags []string) error {
+	if err := c.store.Set(ctx, c.prefix+key, data, ttl); err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	tagKeys := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tagKeys = append(tagKeys, c.tagKey(tag))
+	}
+
+	return c.tags.Add(ctx, c.prefix+key, ttl, tagKeys...)
+}
@@ -0,0 +1,265 @@
+package yiigo
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type cacheUser struct {
+	ID   int64  `json:"id" msgpack:"id"`
+	Name string `json:"name" msgpack:"name"`
+}
+
+func TestMemCache(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, codec := range []Codec{JSONCodec, MsgpackCodec} {
+		cache := NewMemCache(WithCacheCodec(codec), WithCachePrefix("test:"))
+
+		user := new(cacheUser)
+
+		assert.ErrorIs(t, cache.Get(ctx, "user:1", user), ErrCacheMiss)
+		assert.Nil(t, cache.Set(ctx, "user:1", &cacheUser{ID: 1, Name: "yiigo"}, time.Minute))
+		assert.Nil(t, cache.Get(ctx, "user:1", user))
+		assert.Equal(t, &cacheUser{ID: 1, Name: "yiigo"}, user)
+
+		assert.Nil(t, cache.Delete(ctx, "user:1"))
+		assert.ErrorIs(t, cache.Get(ctx, "user:1", user), ErrCacheMiss)
+
+		// expired
+		assert.Nil(t, cache.Set(ctx, "user:2", &cacheUser{ID: 2}, time.Millisecond))
+		time.Sleep(5 * time.Millisecond)
+		assert.ErrorIs(t, cache.Get(ctx, "user:2", user), ErrCacheMiss)
+	}
+}
+
+func TestProtoCodec(t *testing.T) {
+	ctx := context.TODO()
+
+	cache := NewMemCache(WithCacheCodec(ProtoCodec))
+
+	assert.Nil(t, cache.Set(ctx, "name", wrapperspb.String("yiigo"), 0))
+
+	v := new(wrapperspb.StringValue)
+
+	assert.Nil(t, cache.Get(ctx, "name", v))
+	assert.Equal(t, "yiigo", v.GetValue())
+
+	assert.NotNil(t, cache.Set(ctx, "name", "yiigo", 0))
+}
+
+func TestRedisCacheGetOrLoad(t *testing.T) {
+	ctx := context.TODO()
+
+	s := miniredis.RunT(t)
+
+	cache := NewRedisCache(newRedisPool(&RedisConfig{Addr: s.Addr()}), WithCachePrefix("test:"))
+
+	var (
+		loads int32
+		wg    sync.WaitGroup
+	)
+
+	load := func(ctx context.Context) (any, error) {
+		atomic.AddInt32(&loads, 1)
+		time.Sleep(20 * time.Millisecond)
+
+		return &cacheUser{ID: 1, Name: "yiigo"}, nil
+	}
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			user := new(cacheUser)
+
+			assert.Nil(t, cache.GetOrLoad(ctx, "user:1", user, time.Minute, load))
+			assert.Equal(t, &cacheUser{ID: 1, Name: "yiigo"}, user)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+	assert.True(t, s.Exists("test:user:1"))
+	assert.Equal(t, time.Minute, s.TTL("test:user:1"))
+
+	// cached
+	user := new(cacheUser)
+
+	assert.Nil(t, cache.GetOrLoad(ctx, "user:1", user, time.Minute, load))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+
+	assert.Nil(t, cache.Delete(ctx, "user:1", "user:2"))
+	assert.False(t, s.Exists("test:user:1"))
+}
+
+func TestLRUCache(t *testing.T) {
+	c := newLRUCache(2)
+
+	c.Set("a", []byte("1"), time.Minute)
+	c.Set("b", []byte("2"), time.Minute)
+
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	// evicts the least recently used
+	c.Set("c", []byte("3"), time.Minute)
+
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), v)
+
+	c.Set("d", []byte("4"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok = c.Get("d")
+	assert.False(t, ok)
+}
+
+func TestTwoLevelCache(t *testing.T) {
+	ctx := context.TODO()
+
+	s := miniredis.RunT(t)
+
+	pool := newRedisPool(&RedisConfig{Addr: s.Addr()})
+
+	// the instances
+	c1 := NewRedisCache(pool, WithLocalCache(100, time.Minute))
+	c2 := NewRedisCache(pool, WithLocalCache(100, time.Minute))
+
+	assert.Eventually(t, func() bool {
+		return s.PubSubNumSub(cacheInvalidateChannel)[cacheInvalidateChannel] == 2
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Nil(t, c1.Set(ctx, "k", "v1", 0))
+
+	var v string
+
+	assert.Nil(t, c2.Get(ctx, "k", &v))
+	assert.Equal(t, "v1", v)
+
+	// hit the local
+	s.Set("k", `"changed"`)
+
+	assert.Nil(t, c2.Get(ctx, "k", &v))
+	assert.Equal(t, "v1", v)
+
+	// invalidated by the other instance
+	assert.Nil(t, c1.Set(ctx, "k", "v2", 0))
+
+	assert.Eventually(t, func() bool {
+		return c2.Get(ctx, "k", &v) == nil && v == "v2"
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Nil(t, c1.Delete(ctx, "k"))
+
+	assert.Eventually(t, func() bool {
+		return c2.Get(ctx, "k", &v) == ErrCacheMiss
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCacheEarlyRefresh(t *testing.T) {
+	ctx := context.TODO()
+
+	cache := NewMemCache(WithEarlyRefresh(1), WithBackgroundRefresh()).(*codecCache)
+
+	now := time.Now().UnixMilli()
+
+	assert.False(t, cache.earlyExpired(0, 1000))
+	assert.False(t, cache.earlyExpired(now+time.Minute.Milliseconds(), 0))
+	assert.True(t, cache.earlyExpired(now-1, 0))
+
+	data, expireAt, delta := cache.unwrap(cache.wrap([]byte(`"v"`), time.Minute, 20*time.Millisecond))
+
+	assert.Equal(t, []byte(`"v"`), data)
+	assert.InDelta(t, now+time.Minute.Milliseconds(), expireAt, 1000)
+	assert.Equal(t, int64(20), delta)
+
+	// the values without the header
+	data, expireAt, _ = cache.unwrap([]byte(`"v"`))
+
+	assert.Equal(t, []byte(`"v"`), data)
+	assert.Equal(t, int64(0), expireAt)
+
+	// about to expire
+	assert.Nil(t, cache.store.Set(ctx, "k", cache.wrap([]byte(`"v1"`), time.Millisecond, time.Hour), time.Minute))
+
+	time.Sleep(2 * time.Millisecond)
+
+	var loads int32
+
+	load := func(ctx context.Context) (any, error) {
+		atomic.AddInt32(&loads, 1)
+
+		return "v2", nil
+	}
+
+	var v string
+
+	// the current value is served, and refreshed in background
+	assert.Nil(t, cache.GetOrLoad(ctx, "k", &v, time.Minute, load))
+	assert.Equal(t, "v1", v)
+
+	assert.Eventually(t, func() bool {
+		return cache.Get(ctx, "k", &v) == nil && v == "v2"
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Nil(t, cache.GetOrLoad(ctx, "k", &v, time.Minute, load))
+	assert.Equal(t, "v2", v)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+}
+
+func TestCacheInvalidateTag(t *testing.T) {
+	ctx := context.TODO()
+
+	s := miniredis.RunT(t)
+
+	caches := []Cache{
+		NewMemCache(WithCachePrefix("test:")),
+		NewRedisCache(newRedisPool(&RedisConfig{Addr: s.Addr()}), WithCachePrefix("test:")),
+	}
+
+	for _, cache := range caches {
+		user := new(cacheUser)
+
+		assert.Nil(t, cache.Tagged("user:1").Set(ctx, "profile:1", &cacheUser{ID: 1}, time.Minute))
+		assert.Nil(t, cache.Tagged("user:1", "user:2").Set(ctx, "friends:1:2", &cacheUser{ID: 2}, 0))
+		assert.Nil(t, cache.Tagged("user:2").GetOrLoad(ctx, "profile:2", user, time.Minute, func(ctx context.Context) (any, error) {
+			return &cacheUser{ID: 2}, nil
+		}))
+		assert.Nil(t, cache.Set(ctx, "config", &cacheUser{ID: 3}, 0))
+
+		assert.Nil(t, cache.InvalidateTag(ctx, "user:1"))
+
+		assert.ErrorIs(t, cache.Get(ctx, "profile:1", user), ErrCacheMiss)
+		assert.ErrorIs(t, cache.Get(ctx, "friends:1:2", user), ErrCacheMiss)
+		assert.Nil(t, cache.Get(ctx, "profile:2", user))
+		assert.Nil(t, cache.Get(ctx, "config", user))
+
+		assert.Nil(t, cache.InvalidateTag(ctx, "user:2", "user:3"))
+
+		assert.ErrorIs(t, cache.Get(ctx, "profile:2", user), ErrCacheMiss)
+		assert.Nil(t, cache.Get(ctx, "config", user))
+	}
+
+	// the tag expires no earlier than its keys
+	assert.False(t, s.Exists("test:tag:user:2"))
+	assert.Nil(t, caches[1].Tagged("user:4").Set(ctx, "a", 1, time.Minute))
+	assert.Nil(t, caches[1].Tagged("user:4").Set(ctx, "b", 1, time.Hour))
+	assert.Equal(t, time.Hour, s.TTL("test:tag:user:4"))
+	assert.Nil(t, caches[1].Tagged("user:4").Set(ctx, "c", 1, 0))
+	assert.Equal(t, time.Duration(0), s.TTL("test:tag:user:4"))
+}
@@ -0,0 +1,99 @@
+// Command yiigo is the toolkit of yiigo.
+//
+// Usage:
+//
+//	yiigo gen -driver mysql -dsn "user:pass@tcp(127.0.0.1:3306)/db?parseTime=true" -pkg model -out model/model.go
+//
+// The flags of `gen`:
+//
+//	-driver   mysql, pgx or sqlite3 (default mysql)
+//	-dsn      the data source name
+//	-schema   the schema, defaults to the current database (MySQL) or public (Postgres)
+//	-tables   the tables separated by comma, defaults to all the tables
+//	-pkg      the package name of the generated code (default model)
+//	-out      the output file, defaults to stdout
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shenghui0779/yiigo"
+	"github.com/shenghui0779/yiigo/gen"
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "gen" {
+		fmt.Fprintln(os.Stderr, "usage: yiigo gen [flags]")
+
+		os.Exit(2)
+	}
+
+	if err := generate(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "yiigo gen:", err)
+
+		os.Exit(1)
+	}
+}
+
+func generate(args []string) error {
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+
+	driver := fs.String("driver", string(yiigo.MySQL), "mysql, pgx or sqlite3")
+	dsn := fs.String("dsn", "", "the data source name")
+	schema := fs.String("schema", "", "the schema, defaults to the current database (MySQL) or public (Postgres)")
+	tables := fs.String("tables", "", "the tables separated by comma, defaults to all the tables")
+	pkg := fs.String("pkg", "model", "the package name of the generated code")
+	out := fs.String("out", "", "the output file, defaults to stdout")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if len(*dsn) == 0 {
+		return fmt.Errorf("-dsn is required")
+	}
+
+	db, err := sqlx.Open(*driver, *dsn)
+
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	var names []string
+
+	if len(*tables) != 0 {
+		names = strings.Split(*tables, ",")
+	}
+
+	result, err := gen.Introspect(context.Background(), db, yiigo.DBDriver(*driver), *schema, names...)
+
+	if err != nil {
+		return err
+	}
+
+	code, err := gen.Generate(*pkg, result)
+
+	if err != nil {
+		return err
+	}
+
+	if len(*out) == 0 {
+		_, err = os.Stdout.Write(code)
+
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(*out), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(*out, code, 0o644)
+}
@@ -0,0 +1,139 @@
+package yiigo
+
+import (
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type condOp int
+
+const (
+	condEq condOp = iota
+	condNeq
+	condGt
+	condGte
+	condLt
+	condLte
+	condIn
+	condNotIn
+	condBetween
+	condIsNull
+	condIsNotNull
+)
+
+// CondExpr the condition expression of the field which compiles to the mongo filter (see `M`),
+// the names are consistent with the SQL conditions of `Col[T]`.
+type CondExpr struct {
+	op     condOp
+	values []any
+}
+
+// Eq equal to the value.
+func Eq(v any) CondExpr {
+	return CondExpr{op: condEq, values: []any{v}}
+}
+
+// Neq not equal to the value.
+func Neq(v any) CondExpr {
+	return CondExpr{op: condNeq, values: []any{v}}
+}
+
+// Gt greater than the value.
+func Gt(v any) CondExpr {
+	return CondExpr{op: condGt, values: []any{v}}
+}
+
+// Gte greater than or equal to the value.
+func Gte(v any) CondExpr {
+	return CondExpr{op: condGte, values: []any{v}}
+}
+
+// Lt less than the value.
+func Lt(v any) CondExpr {
+	return CondExpr{op: condLt, values: []any{v}}
+}
+
+// Lte less than or equal to the value.
+func Lte(v any) CondExpr {
+	return CondExpr{op: condLte, values: []any{v}}
+}
+
+// In in the values of the slice, eg: yiigo.In([]int{1, 2, 3})
+func In(values any) CondExpr {
+	return CondExpr{op: condIn, values: condValues(values)}
+}
+
+// NotIn not in the values of the slice.
+func NotIn(values any) CondExpr {
+	return CondExpr{op: condNotIn, values: condValues(values)}
+}
+
+// Between between the values (inclusive).
+func Between(from, to any) CondExpr {
+	return CondExpr{op: condBetween, values: []any{from, to}}
+}
+
+// IsNull is null or missing.
+func IsNull() CondExpr {
+	return CondExpr{op: condIsNull}
+}
+
+// IsNotNull is not null and exists.
+func IsNotNull() CondExpr {
+	return CondExpr{op: condIsNotNull}
+}
+
+// condValues returns the elements of the slice (or array), or the value itself (empty if nil).
+func condValues(values any) []any {
+	if values == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(values)
+
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return []any{values}
+	}
+
+	// eg: []byte is a value
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		return []any{values}
+	}
+
+	ret := make([]any, 0, v.Len())
+
+	for i := 0; i < v.Len(); i++ {
+		ret = append(ret, v.Index(i).Interface())
+	}
+
+	return ret
+}
+
+// mongo returns the operators of the mongo filter.
+func (e CondExpr) mongo() bson.D {
+	switch e.op {
+	case condEq:
+		return bson.D{{Key: "$eq", Value: e.values[0]}}
+	case condNeq:
+		return bson.D{{Key: "$ne", Value: e.values[0]}}
+	case condGt:
+		return bson.D{{Key: "$gt", Value: e.values[0]}}
+	case condGte:
+		return bson.D{{Key: "$gte", Value: e.values[0]}}
+	case condLt:
+		return bson.D{{Key: "$lt", Value: e.values[0]}}
+	case condLte:
+		return bson.D{{Key: "$lte", Value: e.values[0]}}
+	case condIn:
+		return bson.D{{Key: "$in", Value: append(bson.A{}, e.values...)}}
+	case condNotIn:
+		return bson.D{{Key: "$nin", Value: append(bson.A{}, e.values...)}}
+	case condBetween:
+		return bson.D{{Key: "$gte", Value: e.values[0]}, {Key: "$lte", Value: e.values[1]}}
+	case condIsNull:
+		return bson.D{{Key: "$eq", Value: nil}}
+	default: // condIsNotNull
+		return bson.D{{Key: "$ne", Value: nil}}
+	}
+}
@@ -0,0 +1,30 @@
+package yiigo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCondExpr(t *testing.T) {
+	cases := []struct {
+		expr CondExpr
+		ops  bson.D
+	}{
+		{Eq(1), bson.D{{Key: "$eq", Value: 1}}},
+		{Neq(1), bson.D{{Key: "$ne", Value: 1}}},
+		{Gt(18), bson.D{{Key: "$gt", Value: 18}}},
+		{Lte(60), bson.D{{Key: "$lte", Value: 60}}},
+		{In([]int{1, 2, 3}), bson.D{{Key: "$in", Value: bson.A{1, 2, 3}}}},
+		{NotIn([]string{"a"}), bson.D{{Key: "$nin", Value: bson.A{"a"}}}},
+		{In([]byte("ab")), bson.D{{Key: "$in", Value: bson.A{[]byte("ab")}}}},
+		{Between(18, 60), bson.D{{Key: "$gte", Value: 18}, {Key: "$lte", Value: 60}}},
+		{IsNull(), bson.D{{Key: "$eq", Value: nil}}},
+		{IsNotNull(), bson.D{{Key: "$ne", Value: nil}}},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.ops, c.expr.mongo())
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	entsql "entgo.io/ent/dialect/sql"
@@ -23,16 +24,28 @@ const (
 	MySQL    DBDriver = "mysql"
 	Postgres DBDriver = "pgx"
 	SQLite   DBDriver = "sqlite3"
+
+	// SQLServer is used by the SQL builder only, the driver (eg: github.com/microsoft/go-mssqldb) should be imported by yourself.
+	SQLServer DBDriver = "sqlserver"
 )
 
 var (
-	defaultDB *sqlx.DB
+	defaultDB atomic.Value // *sqlx.DB
 	dbmap     sync.Map
 
-	defaultEntDriver *entsql.Driver
+	defaultEntDriver atomic.Value // *entsql.Driver
 	entmap           sync.Map
+
+	// the settings of the registered dbs for reloading
+	dbcfgs  sync.Map
+	dbmutex sync.Mutex
 )
 
+type dbSetting struct {
+	driver DBDriver
+	cfg    *DBConfig
+}
+
 // DBConfig keeps the settings to setup db connection.
 type DBConfig struct {
 	// DSN data source name
@@ -43,6 +56,9 @@ type DBConfig struct {
 
 	// Options optional settings to setup db connection.
 	Options *DBOptions `json:"options"`
+
+	// Pgx the pgx specific settings of Postgres (eg: the query exec mode), the DSN is parsed by pgx.
+	Pgx *PgxConfig `json:"pgx"`
 }
 
 // DBOptions optional settings to setup db connection.
@@ -103,7 +119,7 @@ func (o *DBOptions) rebuild(opt *DBOptions) {
 }
 
 func initDB(name string, driver DBDriver, cfg *DBConfig) {
-	db, err := sql.Open(string(driver), cfg.DSN)
+	db, err := openDB(driver, cfg)
 
 	if err != nil {
 		logger.Panic(fmt.Sprintf("err db.%s open", name), zap.String("dsn", cfg.DSN), zap.Error(err))
@@ -115,6 +131,13 @@ func initDB(name string, driver DBDriver, cfg *DBConfig) {
 		logger.Panic(fmt.Sprintf("err db.%s ping", name), zap.String("dsn", cfg.DSN), zap.Error(err))
 	}
 
+	setDBOptions(db, cfg.Options)
+	registerDB(name, driver, cfg, db)
+
+	logger.Info(fmt.Sprintf("db.%s is OK", name))
+}
+
+func setDBOptions(db *sql.DB, options *DBOptions) {
 	opt := &DBOptions{
 		MaxOpenConns:    20,
 		MaxIdleConns:    10,
@@ -122,37 +145,52 @@ func initDB(name string, driver DBDriver, cfg *DBConfig) {
 		ConnMaxIdleTime: 5 * time.Minute,
 	}
 
-	if cfg.Options != nil {
-		opt.rebuild(cfg.Options)
+	if options != nil {
+		opt.rebuild(options)
 	}
 
 	db.SetMaxOpenConns(opt.MaxOpenConns)
 	db.SetMaxIdleConns(opt.MaxIdleConns)
 	db.SetConnMaxLifetime(opt.ConnMaxLifetime)
 	db.SetConnMaxIdleTime(opt.ConnMaxIdleTime)
+}
 
+// registerDB registers the db and returns the replaced one (nil if absent).
+func registerDB(name string, driver DBDriver, cfg *DBConfig, db *sql.DB) *sqlx.DB {
 	sqlxDB := sqlx.NewDb(db, string(driver))
 	entDriver := entsql.OpenDB(string(driver), db)
 
+	dbmutex.Lock()
+	defer dbmutex.Unlock()
+
 	if name == Default {
-		defaultDB = sqlxDB
-		defaultEntDriver = entDriver
+		defaultDB.Store(sqlxDB)
+		defaultEntDriver.Store(entDriver)
 	}
 
+	old, _ := dbmap.Load(name)
+
 	dbmap.Store(name, sqlxDB)
 	entmap.Store(name, entDriver)
+	dbcfgs.Store(name, &dbSetting{driver: driver, cfg: cfg})
 
-	logger.Info(fmt.Sprintf("db.%s is OK", name))
+	if old == nil {
+		return nil
+	}
+
+	return old.(*sqlx.DB)
 }
 
 // DB returns a db.
 func DB(name ...string) *sqlx.DB {
 	if len(name) == 0 || name[0] == Default {
-		if defaultDB == nil {
+		db, _ := defaultDB.Load().(*sqlx.DB)
+
+		if db == nil {
 			logger.Panic(fmt.Sprintf("unknown db.%s (forgotten configure?)", Default))
 		}
 
-		return defaultDB
+		return db
 	}
 
 	v, ok := dbmap.Load(name[0])
@@ -167,11 +205,13 @@ func DB(name ...string) *sqlx.DB {
 // EntDriver returns an ent dialect.Driver.
 func EntDriver(name ...string) *entsql.Driver {
 	if len(name) == 0 || name[0] == Default {
-		if defaultEntDriver == nil {
+		driver, _ := defaultEntDriver.Load().(*entsql.Driver)
+
+		if driver == nil {
 			logger.Panic(fmt.Sprintf("unknown db.%s (forgotten configure?)", Default))
 		}
 
-		return defaultEntDriver
+		return driver
 	}
 
 	v, ok := entmap.Load(name[0])
@@ -0,0 +1,493 @@
+package yiigo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// DBTLSConfig the TLS settings of db connection, the files are PEM encoded.
+type DBTLSConfig struct {
+	// CAFile the root certificate to verify the server, the system pool is used if empty.
+	CAFile string `json:"ca_file"`
+
+	// CertFile and KeyFile the client certificate (mutual TLS).
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+
+	// ServerName the server name to verify, default: the host.
+	ServerName string `json:"server_name"`
+
+	// InsecureSkipVerify skips the verification of the server certificate (NOT recommended).
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+}
+
+func (c *DBTLSConfig) build(host string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if len(cfg.ServerName) == 0 {
+		cfg.ServerName = host
+	}
+
+	if len(c.CAFile) != 0 {
+		b, err := os.ReadFile(c.CAFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("invalid ca file: %s", c.CAFile)
+		}
+
+		cfg.RootCAs = pool
+	}
+
+	if len(c.CertFile) != 0 || len(c.KeyFile) != 0 {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// MySQLConfig builds the DSN of MySQL, eg:
+//
+//	cfg, err := (&yiigo.MySQLConfig{
+//		Host:     "localhost",
+//		User:     "root",
+//		Password: "secret",
+//		DBName:   "test",
+//	}).DBConfig()
+//
+//	yiigo.Init(yiigo.WithMySQL(yiigo.Default, cfg))
+type MySQLConfig struct {
+	// Host default: localhost
+	Host string `json:"host"`
+
+	// Port default: 3306
+	Port int `json:"port"`
+
+	User     string `json:"user"`
+	Password string `json:"password"`
+	DBName   string `json:"dbname"`
+
+	// Charset default: utf8mb4
+	Charset string `json:"charset"`
+
+	// Collation default: utf8mb4_general_ci
+	Collation string `json:"collation"`
+
+	// Loc the location of time.Time, default: time.Local
+	Loc *time.Location `json:"-"`
+
+	// Timeout the dial timeout, default: 10s
+	Timeout time.Duration `json:"timeout"`
+
+	// ReadTimeout and WriteTimeout the I/O timeout, default: no timeout
+	ReadTimeout  time.Duration `json:"read_timeout"`
+	WriteTimeout time.Duration `json:"write_timeout"`
+
+	// TLS enables TLS, the config is registered to the driver.
+	TLS *DBTLSConfig `json:"tls"`
+
+	// Params the extra connection params, eg: {"sql_mode": "'STRICT_TRANS_TABLES'"}.
+	Params map[string]string `json:"params"`
+
+	// Options optional settings to setup db connection.
+	Options *DBOptions `json:"options"`
+}
+
+// DSN returns the data source name, eg: root:secret@tcp(localhost:3306)/test?loc=Local&parseTime=true&timeout=10s&charset=utf8mb4
+func (c *MySQLConfig) DSN() (string, error) {
+	cfg := mysql.NewConfig()
+
+	host, port := c.Host, c.Port
+
+	if len(host) == 0 {
+		host = "localhost"
+	}
+
+	if port == 0 {
+		port = 3306
+	}
+
+	cfg.User = c.User
+	cfg.Passwd = c.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(host, strconv.Itoa(port))
+	cfg.DBName = c.DBName
+	cfg.ParseTime = true
+	cfg.Loc = c.Loc
+	cfg.Timeout = c.Timeout
+	cfg.ReadTimeout = c.ReadTimeout
+	cfg.WriteTimeout = c.WriteTimeout
+	cfg.Collation = c.Collation
+	cfg.Params = map[string]string{"charset": c.Charset}
+
+	if cfg.Loc == nil {
+		cfg.Loc = time.Local
+	}
+
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	if len(cfg.Collation) == 0 {
+		cfg.Collation = "utf8mb4_general_ci"
+	}
+
+	if len(cfg.Params["charset"]) == 0 {
+		cfg.Params["charset"] = "utf8mb4"
+	}
+
+	for k, v := range c.Params {
+		cfg.Params[k] = v
+	}
+
+	if c.TLS != nil {
+		tlsCfg, err := c.TLS.build(host)
+
+		if err != nil {
+			return "", err
+		}
+
+		// the name is unique per address and user, so the configs of the dbs don't overwrite each other
+		name := "yiigo_" + strings.NewReplacer(":", "_", ".", "_", "[", "", "]", "").Replace(cfg.Addr) + "_" + url.QueryEscape(c.User)
+
+		if err = mysql.RegisterTLSConfig(name, tlsCfg); err != nil {
+			return "", err
+		}
+
+		cfg.TLSConfig = name
+	}
+
+	return cfg.FormatDSN(), nil
+}
+
+// DBConfig returns the DBConfig with the DSN.
+func (c *MySQLConfig) DBConfig() (*DBConfig, error) {
+	dsn, err := c.DSN()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &DBConfig{
+		DSN:     dsn,
+		Options: c.Options,
+	}, nil
+}
+
+// PostgresConfig builds the DSN of Postgres, eg:
+//
+//	cfg, err := (&yiigo.PostgresConfig{
+//		Host:     "localhost",
+//		User:     "root",
+//		Password: "secret",
+//		DBName:   "test",
+//		SSLMode:  "verify-full",
+//		SSLRootCert: "/etc/ssl/ca.pem",
+//	}).DBConfig()
+//
+//	yiigo.Init(yiigo.WithPostgres(yiigo.Default, cfg))
+type PostgresConfig struct {
+	// Host default: localhost
+	Host string `json:"host"`
+
+	// Port default: 5432
+	Port int `json:"port"`
+
+	User     string `json:"user"`
+	Password string `json:"password"`
+	DBName   string `json:"dbname"`
+
+	// SSLMode disable, allow, prefer, require, verify-ca or verify-full, default: disable
+	SSLMode string `json:"sslmode"`
+
+	// SSLRootCert the root certificate to verify the server.
+	SSLRootCert string `json:"sslrootcert"`
+
+	// SSLCert and SSLKey the client certificate.
+	SSLCert string `json:"sslcert"`
+	SSLKey  string `json:"sslkey"`
+
+	// ConnectTimeout default: 10s
+	ConnectTimeout time.Duration `json:"connect_timeout"`
+
+	// Params the extra connection params, eg: {"application_name": "api", "search_path": "app"}.
+	Params map[string]string `json:"params"`
+
+	// Options optional settings to setup db connection.
+	Options *DBOptions `json:"options"`
+	// Pgx the pgx specific settings, eg: the query exec mode.
+	Pgx *PgxConfig `json:"pgx"`
+}
+
+// DSN returns the data source name, eg: host=localhost port=5432 user=root password=secret dbname=test connect_timeout=10 sslmode=disable
+func (c *PostgresConfig) DSN() (string, error) {
+	params := make(map[string]string, len(c.Params))
+
+	for k, v := range c.Params {
+		params[k] = v
+	}
+
+	host, port, sslmode, timeout := c.Host, c.Port, c.SSLMode, c.ConnectTimeout
+
+	if len(host) == 0 {
+		host = "localhost"
+	}
+
+	if port == 0 {
+		port = 5432
+	}
+
+	if len(sslmode) == 0 {
+		sslmode = "disable"
+	}
+
+	switch sslmode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		return "", fmt.Errorf("invalid sslmode: %s", sslmode)
+	}
+
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	keys := []string{"host", "port", "user", "password", "dbname", "connect_timeout", "sslmode", "sslrootcert", "sslcert", "sslkey"}
+	values := []string{host, strconv.Itoa(port), c.User, c.Password, c.DBName, strconv.Itoa(int(timeout / time.Second)), sslmode, c.SSLRootCert, c.SSLCert, c.SSLKey}
+
+	var builder strings.Builder
+
+	for i, k := range keys {
+		delete(params, k)
+
+		if len(values[i]) == 0 {
+			continue
+		}
+
+		pgParam(&builder, k, values[i])
+	}
+
+	// the extra params are in order for the stable DSN
+	extra := make([]string, 0, len(params))
+
+	for k := range params {
+		extra = append(extra, k)
+	}
+
+	sort.Strings(extra)
+
+	for _, k := range extra {
+		pgParam(&builder, k, params[k])
+	}
+
+	return builder.String(), nil
+}
+
+// DBConfig returns the DBConfig with the DSN.
+func (c *PostgresConfig) DBConfig() (*DBConfig, error) {
+	dsn, err := c.DSN()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &DBConfig{
+		DSN:     dsn,
+		Options: c.Options,
+		Pgx:     c.Pgx,
+	}, nil
+}
+
+// pgParam writes the keyword/value, the value is quoted if it's empty or contains spaces, quotes or backslashes.
+func pgParam(builder *strings.Builder, key, value string) {
+	if builder.Len() != 0 {
+		builder.WriteString(" ")
+	}
+
+	builder.WriteString(key)
+	builder.WriteString("=")
+
+	if len(value) != 0 && !strings.ContainsAny(value, " '\\\t\n") {
+		builder.WriteString(value)
+
+		return
+	}
+
+	builder.WriteString("'")
+	builder.WriteString(strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value))
+	builder.WriteString("'")
+}
+
+// SQLiteConfig builds the DSN of SQLite (github.com/mattn/go-sqlite3), eg:
+//
+//	cfg, err := (&yiigo.SQLiteConfig{
+//		Path:        "app.db",
+//		JournalMode: "WAL",
+//		Synchronous: "NORMAL",
+//		ForeignKeys: true,
+//		TxLock:      "immediate",
+//	}).DBConfig()
+//
+//	yiigo.Init(yiigo.WithSQLite(yiigo.Default, cfg))
+type SQLiteConfig struct {
+	// Path the database file, `:memory:` for the in-memory database.
+	Path string `json:"path"`
+
+	// Mode ro, rw, rwc or memory, default: rwc
+	Mode string `json:"mode"`
+
+	// Cache shared or private, default: private
+	Cache string `json:"cache"`
+
+	// BusyTimeout the timeout of waiting for the lock, default: 5s
+	BusyTimeout time.Duration `json:"busy_timeout"`
+
+	// JournalMode DELETE, TRUNCATE, PERSIST, MEMORY, WAL or OFF.
+	// WAL is recommended for the concurrency, the readers don't block the writer and vice versa.
+	JournalMode string `json:"journal_mode"`
+
+	// Synchronous OFF, NORMAL, FULL or EXTRA, NORMAL is safe and faster in WAL mode.
+	Synchronous string `json:"synchronous"`
+
+	// ForeignKeys enables the foreign key constraints.
+	ForeignKeys bool `json:"foreign_keys"`
+
+	// TxLock the locking behavior of the transaction: deferred (default), immediate or exclusive.
+	// immediate acquires the write lock at BEGIN, which avoids SQLITE_BUSY of upgrading the read lock (busy_timeout doesn't help).
+	TxLock string `json:"txlock"`
+
+	// Params the extra connection params, eg: {"_cache_size": "-20000"}.
+	Params map[string]string `json:"params"`
+
+	// Options optional settings to setup db connection.
+	Options *DBOptions `json:"options"`
+}
+
+// DSN returns the data source name, eg: file:app.db?_busy_timeout=5000&_foreign_keys=1&_journal_mode=WAL&_synchronous=NORMAL,
+// the pragmas are applied by the driver to each connection.
+func (c *SQLiteConfig) DSN() (string, error) {
+	if len(c.Path) == 0 {
+		return "", errors.New("sqlite path is required")
+	}
+
+	query := url.Values{}
+
+	for k, v := range c.Params {
+		query.Set(k, v)
+	}
+
+	if len(c.Mode) != 0 {
+		query.Set("mode", c.Mode)
+	}
+
+	if len(c.Cache) != 0 {
+		query.Set("cache", c.Cache)
+	}
+
+	timeout := c.BusyTimeout
+
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+
+	query.Set("_busy_timeout", strconv.FormatInt(timeout.Milliseconds(), 10))
+
+	if len(c.JournalMode) != 0 {
+		mode := strings.ToUpper(c.JournalMode)
+
+		switch mode {
+		case "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF":
+		default:
+			return "", fmt.Errorf("invalid sqlite journal_mode: %s", c.JournalMode)
+		}
+
+		query.Set("_journal_mode", mode)
+	}
+
+	if len(c.Synchronous) != 0 {
+		sync := strings.ToUpper(c.Synchronous)
+
+		switch sync {
+		case "OFF", "NORMAL", "FULL", "EXTRA":
+		default:
+			return "", fmt.Errorf("invalid sqlite synchronous: %s", c.Synchronous)
+		}
+
+		query.Set("_synchronous", sync)
+	}
+
+	if c.ForeignKeys {
+		query.Set("_foreign_keys", "1")
+	}
+
+	if len(c.TxLock) != 0 {
+		lock := strings.ToLower(c.TxLock)
+
+		switch lock {
+		case "deferred", "immediate", "exclusive":
+		default:
+			return "", fmt.Errorf("invalid sqlite txlock: %s", c.TxLock)
+		}
+
+		query.Set("_txlock", lock)
+	}
+
+	// url.Values.Encode sorts by key
+	return "file:" + c.Path + "?" + query.Encode(), nil
+}
+
+// DBConfig returns the DBConfig with the DSN.
+// The private in-memory database is limited to one connection since each connection is a separate database.
+func (c *SQLiteConfig) DBConfig() (*DBConfig, error) {
+	dsn, err := c.DSN()
+
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &DBConfig{
+		DSN:     dsn,
+		Options: c.Options,
+	}
+
+	if (c.Path == ":memory:" || c.Mode == "memory") && c.Cache != "shared" {
+		opt := DBOptions{}
+
+		if c.Options != nil {
+			opt = *c.Options
+		}
+
+		opt.MaxOpenConns = 1
+		opt.MaxIdleConns = 1
+		opt.ConnMaxLifetime = -1
+		opt.ConnMaxIdleTime = -1
+
+		cfg.Options = &opt
+	}
+
+	return cfg, nil
+}
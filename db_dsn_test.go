@@ -0,0 +1,114 @@
+package yiigo
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMySQLConfig(t *testing.T) {
+	dsn, err := (&MySQLConfig{
+		User:     "root",
+		Password: "secret",
+		DBName:   "test",
+		Loc:      time.UTC,
+		Params:   map[string]string{"sql_mode": "'STRICT_TRANS_TABLES'"},
+	}).DSN()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "root:secret@tcp(localhost:3306)/test?parseTime=true&timeout=10s&charset=utf8mb4&sql_mode=%27STRICT_TRANS_TABLES%27", dsn)
+
+	_, err = (&MySQLConfig{TLS: &DBTLSConfig{CAFile: "testdata/not_exist.pem"}}).DSN()
+	assert.NotNil(t, err)
+}
+
+func TestPostgresConfig(t *testing.T) {
+	cfg, err := (&PostgresConfig{
+		User:     "root",
+		Password: "it's secret",
+		DBName:   "test",
+		Params:   map[string]string{"search_path": "app", "application_name": "api"},
+		Options:  &DBOptions{MaxOpenConns: 50},
+	}).DBConfig()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `host=localhost port=5432 user=root password='it\'s secret' dbname=test connect_timeout=10 sslmode=disable application_name=api search_path=app`, cfg.DSN)
+	assert.Equal(t, &DBOptions{MaxOpenConns: 50}, cfg.Options)
+
+	dsn, err := (&PostgresConfig{Host: "db", SSLMode: "verify-full", SSLRootCert: "/etc/ssl/ca.pem"}).DSN()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "host=db port=5432 connect_timeout=10 sslmode=verify-full sslrootcert=/etc/ssl/ca.pem", dsn)
+
+	_, err = (&PostgresConfig{SSLMode: "on"}).DSN()
+	assert.NotNil(t, err)
+}
+
+func TestSQLiteConfig(t *testing.T) {
+	cfg, err := (&SQLiteConfig{
+		Path:        "app.db",
+		JournalMode: "WAL",
+		ForeignKeys: true,
+	}).DBConfig()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "file:app.db?_busy_timeout=5000&_foreign_keys=1&_journal_mode=WAL", cfg.DSN)
+	assert.Nil(t, cfg.Options)
+
+	cfg, err = (&SQLiteConfig{Path: ":memory:"}).DBConfig()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "file::memory:?_busy_timeout=5000", cfg.DSN)
+	assert.Equal(t, &DBOptions{MaxOpenConns: 1, MaxIdleConns: 1, ConnMaxLifetime: -1, ConnMaxIdleTime: -1}, cfg.Options)
+
+	_, err = (&SQLiteConfig{}).DSN()
+	assert.NotNil(t, err)
+}
+
+func TestSQLitePragmas(t *testing.T) {
+	cfg, err := (&SQLiteConfig{
+		Path:        filepath.Join(t.TempDir(), "app.db"),
+		JournalMode: "wal",
+		Synchronous: "normal",
+		ForeignKeys: true,
+		TxLock:      "immediate",
+		BusyTimeout: 3 * time.Second,
+	}).DBConfig()
+
+	assert.Nil(t, err)
+
+	db, err := sqlx.Open(string(SQLite), cfg.DSN)
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	var (
+		journal string
+		sync    int
+		fk      int
+		timeout int
+	)
+
+	assert.Nil(t, db.Get(&journal, "PRAGMA journal_mode"))
+	assert.Nil(t, db.Get(&sync, "PRAGMA synchronous"))
+	assert.Nil(t, db.Get(&fk, "PRAGMA foreign_keys"))
+	assert.Nil(t, db.Get(&timeout, "PRAGMA busy_timeout"))
+
+	assert.Equal(t, "wal", journal)
+	assert.Equal(t, 1, sync) // NORMAL
+	assert.Equal(t, 1, fk)
+	assert.Equal(t, 3000, timeout)
+
+	_, err = (&SQLiteConfig{Path: "app.db", JournalMode: "fast"}).DSN()
+	assert.NotNil(t, err)
+
+	_, err = (&SQLiteConfig{Path: "app.db", Synchronous: "on"}).DSN()
+	assert.NotNil(t, err)
+
+	_, err = (&SQLiteConfig{Path: "app.db", TxLock: "read"}).DSN()
+	assert.NotNil(t, err)
+}
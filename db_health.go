@@ -0,0 +1,224 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// DBHealth the health status of the db registered by `Init`.
+type DBHealth struct {
+	Name      string    `json:"name"`
+	Healthy   bool      `json:"healthy"`
+	Failures  int       `json:"failures"`   // the consecutive failures
+	LastError string    `json:"last_error"` // the error of the last failed check
+	LastCheck time.Time `json:"last_check"` // zero if never checked
+	OpenConns int       `json:"open_conns"`
+	InUse     int       `json:"in_use"`
+}
+
+type dbHealthRegistry struct {
+	status map[string]*DBHealth
+	mutex  sync.RWMutex
+}
+
+var dbhealth = &dbHealthRegistry{status: make(map[string]*DBHealth)}
+
+// update records the result of the check, and returns the consecutive failures before and after.
+func (r *dbHealthRegistry) update(name string, err error) (prev, failures int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s, ok := r.status[name]
+
+	if !ok {
+		s = &DBHealth{Name: name, Healthy: true}
+		r.status[name] = s
+	}
+
+	prev = s.Failures
+
+	s.LastCheck = time.Now()
+
+	if err != nil {
+		s.Healthy = false
+		s.Failures++
+		s.LastError = err.Error()
+	} else {
+		s.Healthy = true
+		s.Failures = 0
+	}
+
+	return prev, s.Failures
+}
+
+// DBStatus returns the health status (sorted by name) of the dbs registered by `Init`, eg: the readiness probe.
+// The db not checked yet (see `StartDBHealthCheck`) is healthy since it's pinged on registering.
+func DBStatus() []DBHealth {
+	status := make([]DBHealth, 0)
+
+	dbhealth.mutex.RLock()
+	defer dbhealth.mutex.RUnlock()
+
+	dbmap.Range(func(key, value any) bool {
+		name := key.(string)
+
+		s := DBHealth{Name: name, Healthy: true}
+
+		if v, ok := dbhealth.status[name]; ok {
+			s = *v
+		}
+
+		stats := value.(*sqlx.DB).Stats()
+
+		s.OpenConns = stats.OpenConnections
+		s.InUse = stats.InUse
+
+		status = append(status, s)
+
+		return true
+	})
+
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Name < status[j].Name
+	})
+
+	return status
+}
+
+// DBHealthOption db health check option
+type DBHealthOption func(o *dbHealthOptions)
+
+type dbHealthOptions struct {
+	interval   time.Duration
+	timeout    time.Duration
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+// WithHealthInterval specifies the interval of checking the healthy db, default: 10s.
+func WithHealthInterval(d time.Duration) DBHealthOption {
+	return func(o *dbHealthOptions) {
+		o.interval = d
+	}
+}
+
+// WithHealthTimeout specifies the timeout of the ping, default: 3s.
+func WithHealthTimeout(d time.Duration) DBHealthOption {
+	return func(o *dbHealthOptions) {
+		o.timeout = d
+	}
+}
+
+// WithHealthBackoff specifies the backoff of reconnecting the unhealthy db, which is doubled after each failure
+// and capped by max, default: 1s and 30s.
+func WithHealthBackoff(backoff, max time.Duration) DBHealthOption {
+	return func(o *dbHealthOptions) {
+		o.backoff = backoff
+		o.maxBackoff = max
+	}
+}
+
+// StartDBHealthCheck pings the dbs registered by `Init` periodically until ctx is done, the db which fails the ping
+// is marked unhealthy and reconnected (pinged, the pool dials new connections) with backoff until it recovers.
+// The *sqlx.DB returned by `DB` is kept, so the references held by the callers remain valid.
+func StartDBHealthCheck(ctx context.Context, options ...DBHealthOption) {
+	o := &dbHealthOptions{
+		interval:   10 * time.Second,
+		timeout:    3 * time.Second,
+		backoff:    time.Second,
+		maxBackoff: 30 * time.Second,
+	}
+
+	for _, f := range options {
+		f(o)
+	}
+
+	go func() {
+		checkers := make(map[*sqlx.DB]struct{})
+
+		// the dbs registered later (eg: reloaded) are checked from the next round
+		spawn := func() {
+			dbmap.Range(func(key, value any) bool {
+				db := value.(*sqlx.DB)
+
+				if _, ok := checkers[db]; !ok {
+					checkers[db] = struct{}{}
+
+					go checkDB(ctx, key.(string), db, o)
+				}
+
+				return true
+			})
+		}
+
+		spawn()
+
+		ticker := time.NewTicker(o.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				spawn()
+			}
+		}
+	}()
+}
+
+func checkDB(ctx context.Context, name string, db *sqlx.DB, o *dbHealthOptions) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		// the db is replaced or removed
+		if v, ok := dbmap.Load(name); !ok || v.(*sqlx.DB) != db {
+			return
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, o.timeout)
+		err := db.PingContext(pingCtx)
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		prev, failures := dbhealth.update(name, err)
+
+		if err == nil {
+			if prev != 0 {
+				logger.Info(fmt.Sprintf("db.%s is recovered", name), zap.Int("failures", prev))
+			}
+
+			timer.Reset(o.interval)
+
+			continue
+		}
+
+		if failures == 1 {
+			logger.Warn(fmt.Sprintf("db.%s is unhealthy", name), zap.Error(err))
+		}
+
+		backoff := o.backoff << (failures - 1)
+
+		if backoff <= 0 || backoff > o.maxBackoff {
+			backoff = o.maxBackoff
+		}
+
+		timer.Reset(backoff)
+	}
+}
@@ -0,0 +1,59 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBHealthCheck(t *testing.T) {
+	db, err := OpenSQLiteMemory()
+
+	assert.Nil(t, err)
+
+	dbmap.Store("health", db)
+
+	defer func() {
+		dbmap.Delete("health")
+
+		dbhealth.mutex.Lock()
+		delete(dbhealth.status, "health")
+		dbhealth.mutex.Unlock()
+	}()
+
+	status := func() DBHealth {
+		for _, v := range DBStatus() {
+			if v.Name == "health" {
+				return v
+			}
+		}
+
+		return DBHealth{}
+	}
+
+	// not checked yet
+	assert.True(t, status().Healthy)
+	assert.True(t, status().LastCheck.IsZero())
+
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	defer cancel()
+
+	StartDBHealthCheck(ctx, WithHealthInterval(10*time.Millisecond), WithHealthBackoff(5*time.Millisecond, 20*time.Millisecond))
+
+	assert.Eventually(t, func() bool {
+		return !status().LastCheck.IsZero()
+	}, time.Second, 5*time.Millisecond)
+
+	assert.True(t, status().Healthy)
+
+	db.Close()
+
+	assert.Eventually(t, func() bool {
+		s := status()
+
+		return !s.Healthy && s.Failures >= 2 && len(s.LastError) != 0
+	}, time.Second, 5*time.Millisecond)
+}
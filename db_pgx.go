@@ -0,0 +1,100 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+// PgxConfig the pgx (github.com/jackc/pgx/v5/stdlib) specific settings of Postgres.
+type PgxConfig struct {
+	// ExecMode the default query exec mode, default: cache_statement.
+	//   - cache_statement: the binary protocol with the prepared statements cached
+	//   - cache_describe: the binary protocol with the statement descriptions cached
+	//   - describe_exec: the binary protocol without cache
+	//   - exec: the text protocol of the extended protocol
+	//   - simple_protocol: the simple protocol, eg: pgbouncer of transaction pooling
+	ExecMode string `json:"exec_mode"`
+
+	// StatementCacheCapacity the capacity of the prepared statement cache per connection, default: 512.
+	StatementCacheCapacity int `json:"statement_cache_capacity"`
+
+	// AfterConnect is called after the connection is established, eg: registering the custom types.
+	AfterConnect func(ctx context.Context, conn *pgx.Conn) error `json:"-"`
+}
+
+func (c *PgxConfig) connConfig(dsn string) (*pgx.ConnConfig, error) {
+	cfg, err := pgx.ParseConfig(dsn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.ExecMode {
+	case "", "cache_statement":
+		cfg.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
+	case "cache_describe":
+		cfg.DefaultQueryExecMode = pgx.QueryExecModeCacheDescribe
+	case "describe_exec":
+		cfg.DefaultQueryExecMode = pgx.QueryExecModeDescribeExec
+	case "exec":
+		cfg.DefaultQueryExecMode = pgx.QueryExecModeExec
+	case "simple_protocol":
+		cfg.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+	default:
+		return nil, fmt.Errorf("invalid pgx exec mode: %s", c.ExecMode)
+	}
+
+	if c.StatementCacheCapacity > 0 {
+		cfg.StatementCacheCapacity = c.StatementCacheCapacity
+	}
+
+	return cfg, nil
+}
+
+// openDB opens the db, Postgres is opened by the pgx ConnConfig if DBConfig.Pgx is specified.
+func openDB(driver DBDriver, cfg *DBConfig) (*sql.DB, error) {
+	if driver != Postgres || cfg.Pgx == nil {
+		return sql.Open(string(driver), cfg.DSN)
+	}
+
+	connCfg, err := cfg.Pgx.connConfig(cfg.DSN)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var options []stdlib.OptionOpenDB
+
+	if cfg.Pgx.AfterConnect != nil {
+		options = append(options, stdlib.OptionAfterConnect(cfg.Pgx.AfterConnect))
+	}
+
+	return stdlib.OpenDB(*connCfg, options...), nil
+}
+
+// PgxConn calls fn with the underlying pgx connection of the db for the pgx specific features, eg: CopyFrom, SendBatch.
+// The connection is returned to the pool after fn, so fn must not retain it.
+func PgxConn(ctx context.Context, db *sqlx.DB, fn func(conn *pgx.Conn) error) error {
+	conn, err := db.Connx(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(*stdlib.Conn)
+
+		if !ok {
+			return fmt.Errorf("pgx conn: unexpected driver conn %T", driverConn)
+		}
+
+		return fn(c.Conn())
+	})
+}
@@ -0,0 +1,47 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPgxConfig(t *testing.T) {
+	dsn := "host=localhost port=5432 user=root dbname=test sslmode=disable"
+
+	cfg, err := (&PgxConfig{}).connConfig(dsn)
+
+	assert.Nil(t, err)
+	assert.Equal(t, pgx.QueryExecModeCacheStatement, cfg.DefaultQueryExecMode)
+
+	cfg, err = (&PgxConfig{ExecMode: "simple_protocol", StatementCacheCapacity: 100}).connConfig(dsn)
+
+	assert.Nil(t, err)
+	assert.Equal(t, pgx.QueryExecModeSimpleProtocol, cfg.DefaultQueryExecMode)
+	assert.Equal(t, 100, cfg.StatementCacheCapacity)
+
+	_, err = (&PgxConfig{ExecMode: "binary"}).connConfig(dsn)
+	assert.NotNil(t, err)
+
+	// the connection is established lazily
+	db, err := openDB(Postgres, &DBConfig{DSN: dsn, Pgx: &PgxConfig{ExecMode: "exec"}})
+
+	assert.Nil(t, err)
+	assert.Nil(t, db.Close())
+}
+
+func TestPgxConn(t *testing.T) {
+	db, err := OpenSQLiteMemory()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	err = PgxConn(context.TODO(), db, func(conn *pgx.Conn) error {
+		return nil
+	})
+
+	assert.NotNil(t, err)
+}
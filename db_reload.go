@@ -0,0 +1,89 @@
+package yiigo
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+var reloadMutex sync.Mutex
+
+// ReloadDBOption reload db option
+type ReloadDBOption func(o *reloadDBOptions)
+
+type reloadDBOptions struct {
+	grace time.Duration
+}
+
+// WithReloadGrace specifies the grace period before closing the replaced pool, default: 1m.
+func WithReloadGrace(d time.Duration) ReloadDBOption {
+	return func(o *reloadDBOptions) {
+		o.grace = d
+	}
+}
+
+// ReloadDB re-initializes (or adds) the named db at runtime without restarting the process, eg: the config is changed.
+// If only the pool options are changed, they are applied to the current pool. Otherwise, the new pool is opened and pinged
+// (the current one is kept if fails), then replaces the current one, which is closed after the grace period
+// (see `WithReloadGrace`), so the components retaining it (eg: Repo, Seeder, Migrator, StmtCache) have time to be renewed.
+// NOTE: Always get the db by `DB` (`EntDriver`) instead of retaining it, the retained one is closed after reloading.
+func ReloadDB(name string, driver DBDriver, cfg *DBConfig, options ...ReloadDBOption) error {
+	o := &reloadDBOptions{
+		grace: time.Minute,
+	}
+
+	for _, f := range options {
+		f(o)
+	}
+
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	if v, ok := dbcfgs.Load(name); ok {
+		setting := v.(*dbSetting)
+
+		if setting.driver == driver && setting.cfg.DSN == cfg.DSN && reflect.DeepEqual(setting.cfg.Pgx, cfg.Pgx) {
+			if db, ok := dbmap.Load(name); ok {
+				setDBOptions(db.(*sqlx.DB).DB, cfg.Options)
+				dbcfgs.Store(name, &dbSetting{driver: driver, cfg: cfg})
+
+				logger.Info(fmt.Sprintf("db.%s options are reloaded", name))
+
+				return nil
+			}
+		}
+	}
+
+	db, err := openDB(driver, cfg)
+
+	if err != nil {
+		return fmt.Errorf("db.%s open: %w", name, err)
+	}
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+
+		return fmt.Errorf("db.%s ping: %w", name, err)
+	}
+
+	setDBOptions(db, cfg.Options)
+
+	old := registerDB(name, driver, cfg, db)
+
+	logger.Info(fmt.Sprintf("db.%s is reloaded", name))
+
+	if old != nil {
+		// Close waits for the in-flight queries to finish
+		time.AfterFunc(o.grace, func() {
+			if err := old.Close(); err != nil {
+				logger.Error(fmt.Sprintf("err db.%s close the old pool", name), zap.Error(err))
+			}
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package yiigo
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadDB(t *testing.T) {
+	dir := t.TempDir()
+
+	defer func() {
+		if _, ok := dbmap.Load("reload"); ok {
+			DB("reload").Close()
+		}
+
+		dbmap.Delete("reload")
+		entmap.Delete("reload")
+		dbcfgs.Delete("reload")
+	}()
+
+	// add
+	assert.Nil(t, ReloadDB("reload", SQLite, &DBConfig{DSN: "file:" + filepath.Join(dir, "a.db")}))
+
+	db := DB("reload")
+
+	assert.Equal(t, 20, db.Stats().MaxOpenConnections)
+	assert.NotNil(t, EntDriver("reload"))
+
+	// only the options are changed
+	assert.Nil(t, ReloadDB("reload", SQLite, &DBConfig{
+		DSN:     "file:" + filepath.Join(dir, "a.db"),
+		Options: &DBOptions{MaxOpenConns: 5},
+	}))
+
+	assert.Same(t, db, DB("reload"))
+	assert.Equal(t, 5, db.Stats().MaxOpenConnections)
+
+	// new pool
+	assert.Nil(t, ReloadDB("reload", SQLite, &DBConfig{DSN: "file:" + filepath.Join(dir, "b.db")}, WithReloadGrace(100*time.Millisecond)))
+
+	assert.NotSame(t, db, DB("reload"))
+	assert.Nil(t, DB("reload").Ping())
+
+	// the old pool is kept within the grace period
+	assert.Nil(t, db.Ping())
+
+	// the old pool is closed
+	assert.Eventually(t, func() bool {
+		return db.Ping() != nil
+	}, time.Second, 5*time.Millisecond)
+
+	// the current pool is kept if fails
+	current := DB("reload")
+
+	assert.NotNil(t, ReloadDB("reload", SQLite, &DBConfig{DSN: "file:" + filepath.Join(dir, "not_exist", "c.db") + "?mode=ro"}))
+	assert.Same(t, current, DB("reload"))
+}
@@ -1,25 +1,37 @@
 package yiigo
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
 	"runtime/debug"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 )
 
 // EnvOnChangeFunc the function that runs each time env change occurs.
 type EnvOnChangeFunc func(e fsnotify.Event)
 
 type environment struct {
-	path    string
-	watcher bool
-	eventFn EnvOnChangeFunc
+	path     string
+	watcher  bool
+	eventFn  EnvOnChangeFunc
+	source   EnvSource
+	overlays []string
+	flags    []EnvFlag
 }
 
 // EnvOption configures how we set up env file.
@@ -34,7 +46,7 @@ func WithEnvFile(filename string) EnvOption {
 	}
 }
 
-// WithEnvWatcher watching and re-reading env file.
+// WithEnvWatcher watching and re-reading env file (or the remote source, see `WithEnvSource`), fn is called after reloaded (see `OnEnvReload`).
 func WithEnvWatcher(fn EnvOnChangeFunc) EnvOption {
 	return func(e *environment) {
 		e.watcher = true
@@ -44,6 +56,8 @@ func WithEnvWatcher(fn EnvOnChangeFunc) EnvOption {
 
 // LoadEnv will read your env file(s) and load them into ENV for this process.
 // It will default to loading .env in the current path if not specifies the filename.
+// The .yaml (.yml) and .json files are flattened into ENV, the nested keys are joined by `_` in upper case
+// and the scalar arrays are joined by `,`, eg: {db: {host: localhost}, hosts: [a, b]} => DB_HOST=localhost, HOSTS=a,b
 func LoadEnv(options ...EnvOption) {
 	env := &environment{path: ".env"}
 
@@ -57,15 +71,263 @@ func LoadEnv(options ...EnvOption) {
 		logger.Panic("err load env", zap.Error(err))
 	}
 
-	statEnvFile(filename)
+	if len(env.flags) != 0 {
+		// flag.CommandLine exits on the error (or --help)
+		if err := bindEnvFlags(flag.CommandLine, env.flags, os.Args[1:]); err != nil {
+			logger.Panic("err load env", zap.Error(err))
+		}
+	}
 
-	if err := godotenv.Overload(filename); err != nil {
-		logger.Panic("err load env", zap.Error(err))
+	files := envFiles(filename, env.overlays)
+
+	var vars map[string]string
+
+	if env.source != nil {
+		if vars, err = loadEnvSource(env.source); err != nil {
+			logger.Warn("err env source, fallback to the env file", zap.Error(err), zap.String("env_source", env.source.Name()), zap.String("env_file", filename))
+		}
+	}
+
+	// the remote source is unavailable or not specified
+	if vars == nil {
+		statEnvFile(filename)
+
+		if err := loadEnvFile(files...); err != nil {
+			logger.Panic("err load env", zap.Error(err))
+		}
 	}
 
+	applyEnvSettings()
+
 	if env.watcher {
-		go watchEnvFile(filename, env.eventFn)
+		if env.source != nil {
+			go watchEnvSource(context.Background(), env.source, vars, env.eventFn)
+		} else {
+			go watchEnvFile(files, env.eventFn)
+		}
+	}
+}
+
+// The formats of the env content
+const (
+	EnvDotenv = "dotenv"
+	EnvYAML   = "yaml"
+	EnvJSON   = "json"
+)
+
+// envFormat returns the format of the file (key) by the extension, dotenv as default.
+func envFormat(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		return EnvYAML
+	case ".json":
+		return EnvJSON
+	}
+
+	return EnvDotenv
+}
+
+// loadEnvFile loads the env file and the overlays in order (see `WithEnvOverlay`) by the extension of the env file,
+// .env (dotenv) as default. The missing overlays are skipped.
+func loadEnvFile(filenames ...string) error {
+	vars, layers, err := readEnvFiles(filenames)
+
+	if err != nil {
+		return err
+	}
+
+	if err = setEnv(vars); err != nil {
+		return err
 	}
+
+	envLayers.store(layers)
+
+	return nil
+}
+
+// readEnvFiles returns the merged env vars of the files and the layer (filename) of each var.
+func readEnvFiles(filenames []string) (map[string]string, map[string]string, error) {
+	format := envFormat(filenames[0])
+
+	var (
+		data  map[string]any
+		files = make([]string, 0, len(filenames))
+		lvars = make([]map[string]string, 0, len(filenames))
+	)
+
+	vars := make(map[string]string)
+
+	for i, filename := range filenames {
+		b, err := os.ReadFile(filename)
+
+		if err != nil {
+			if i != 0 && errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			return nil, nil, err
+		}
+
+		var v map[string]string
+
+		if format == EnvDotenv {
+			if v, err = godotenv.Unmarshal(string(b)); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", filename, err)
+			}
+
+			for key, value := range v {
+				vars[key] = value
+			}
+		} else {
+			d, err := decodeEnv(b, format)
+
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", filename, err)
+			}
+
+			// deep merge, the arrays and scalars are replaced
+			data = mergeEnv(data, d)
+
+			v = make(map[string]string)
+			flattenEnv(v, "", d)
+		}
+
+		files = append(files, filename)
+		lvars = append(lvars, v)
+	}
+
+	if data != nil {
+		flattenEnv(vars, "", data)
+	}
+
+	layers := make(map[string]string, len(vars))
+
+	for key := range vars {
+		for i := len(lvars) - 1; i >= 0; i-- {
+			if _, ok := lvars[i][key]; ok {
+				layers[key] = files[i]
+
+				break
+			}
+		}
+	}
+
+	return vars, layers, nil
+}
+
+// parseEnv parses the content of the format into the env vars.
+func parseEnv(b []byte, format string) (map[string]string, error) {
+	if format == EnvDotenv {
+		return godotenv.Unmarshal(string(b))
+	}
+
+	data, err := decodeEnv(b, format)
+
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]string)
+
+	flattenEnv(vars, "", data)
+
+	return vars, nil
+}
+
+// decodeEnv decodes the yaml (json) content, nil if empty.
+func decodeEnv(b []byte, format string) (map[string]any, error) {
+	// eg: the created empty file
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil, nil
+	}
+
+	var (
+		data map[string]any
+		err  error
+	)
+
+	if format == EnvJSON {
+		decoder := json.NewDecoder(bytes.NewReader(b))
+		decoder.UseNumber()
+
+		err = decoder.Decode(&data)
+	} else {
+		err = yaml.Unmarshal(b, &data)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// setEnv sets the env vars, then the vars bound to the flags (see `WithEnvFlags`).
+func setEnv(vars map[string]string) error {
+	for k, v := range vars {
+		if err := os.Setenv(k, v); err != nil {
+			return err
+		}
+	}
+
+	return envFlagValues.apply()
+}
+
+// flattenEnv flattens the value into the vars with the key prefix.
+func flattenEnv(vars map[string]string, prefix string, value any) {
+	key := func(k string) string {
+		k = strings.ToUpper(k)
+
+		if len(prefix) == 0 {
+			return k
+		}
+
+		return prefix + "_" + k
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for k, val := range v {
+			flattenEnv(vars, key(k), val)
+		}
+	case map[any]any:
+		for k, val := range v {
+			flattenEnv(vars, key(fmt.Sprint(k)), val)
+		}
+	case []any:
+		scalars := make([]string, 0, len(v))
+
+		for i, val := range v {
+			switch val.(type) {
+			case map[string]any, map[any]any, []any:
+				// eg: SERVERS_0_HOST
+				flattenEnv(vars, key(strconv.Itoa(i)), val)
+			default:
+				scalars = append(scalars, envValue(val))
+			}
+		}
+
+		if len(scalars) != 0 {
+			vars[prefix] = strings.Join(scalars, ",")
+		}
+	default:
+		vars[prefix] = envValue(v)
+	}
+}
+
+func envValue(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	}
+
+	return fmt.Sprint(v)
 }
 
 func statEnvFile(filename string) {
@@ -86,7 +348,10 @@ func statEnvFile(filename string) {
 	f.Close()
 }
 
-func watchEnvFile(filename string, fn EnvOnChangeFunc) {
+// watchEnvFile watches the env file and the overlays (files[1:]).
+func watchEnvFile(files []string, fn EnvOnChangeFunc) {
+	filename := files[0]
+
 	defer func() {
 		if r := recover(); r != nil {
 			logger.Error("env watcher panic", zap.Any("error", r), zap.String("env_file", filename), zap.ByteString("stack", debug.Stack()))
@@ -127,18 +392,12 @@ func watchEnvFile(filename string, fn EnvOnChangeFunc) {
 
 				eventFile := filepath.Clean(event.Name)
 
-				if eventFile == filename {
-					// the env file was created or modified
+				if isEnvFile(files, eventFile) {
+					// the env file (or overlay) was created or modified
 					if event.Op&createOrWriteMask != 0 {
-						if err := godotenv.Overload(filename); err != nil {
-							logger.Error("err env reload", zap.Error(err), zap.String("env_file", filename))
-						}
-
-						if fn != nil {
-							fn(event)
-						}
+						reloadEnv(files, event, fn)
 					} else if event.Op&fsnotify.Remove != 0 {
-						logger.Warn("env file removed", zap.String("env_file", filename))
+						logger.Warn("env file removed", zap.String("env_file", eventFile))
 					}
 				} else {
 					currentEnvFile, _ := filepath.EvalSymlinks(filename)
@@ -147,13 +406,7 @@ func watchEnvFile(filename string, fn EnvOnChangeFunc) {
 					if len(currentEnvFile) != 0 && currentEnvFile != realEnvFile {
 						realEnvFile = currentEnvFile
 
-						if err := godotenv.Overload(filename); err != nil {
-							logger.Error("err env reload", zap.Error(err), zap.String("env_file", filename))
-						}
-
-						if fn != nil {
-							fn(event)
-						}
+						reloadEnv(files, event, fn)
 					}
 				}
 			case err, ok := <-watcher.Errors:
@@ -176,3 +429,13 @@ func watchEnvFile(filename string, fn EnvOnChangeFunc) {
 
 	logger.Error("err env watcher", zap.Error(err), zap.String("env_file", filename))
 }
+
+func isEnvFile(files []string, filename string) bool {
+	for _, v := range files {
+		if v == filename {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,177 @@
+package yiigo
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ApolloSourceConfig Apollo env source config
+type ApolloSourceConfig struct {
+	// Addr the Apollo config service address, eg: http://127.0.0.1:8080
+	Addr string
+
+	// AppID the app id.
+	AppID string
+
+	// Cluster default: default.
+	Cluster string
+
+	// Namespace default: application.
+	// The properties namespace is loaded by the keys (eg: db.host => DB_HOST),
+	// and the content of the yaml (json) namespace is parsed, eg: app.yaml
+	Namespace string
+
+	// Secret the access key secret, optional.
+	Secret string
+}
+
+type apolloSource struct {
+	cfg            *ApolloSourceConfig
+	opts           *envSourceOptions
+	cluster        string
+	namespace      string
+	notificationID int64
+}
+
+// NewApolloEnvSource returns the env source of Apollo, see `WithEnvSource`.
+func NewApolloEnvSource(cfg *ApolloSourceConfig, options ...EnvSourceOption) EnvSource {
+	s := &apolloSource{
+		cfg:            cfg,
+		cluster:        cfg.Cluster,
+		namespace:      cfg.Namespace,
+		notificationID: -1,
+	}
+
+	if len(s.cluster) == 0 {
+		s.cluster = "default"
+	}
+
+	if len(s.namespace) == 0 {
+		s.namespace = "application"
+	}
+
+	s.opts = newEnvSourceOptions(s.namespace, options...)
+
+	return s
+}
+
+func (s *apolloSource) Name() string {
+	return fmt.Sprintf("apollo://%s/%s/%s/%s", strings.TrimPrefix(strings.TrimPrefix(s.cfg.Addr, "http://"), "https://"), s.cfg.AppID, s.cluster, s.namespace)
+}
+
+func (s *apolloSource) Load(ctx context.Context) (map[string]string, error) {
+	path := fmt.Sprintf("/configs/%s/%s/%s", url.PathEscape(s.cfg.AppID), url.PathEscape(s.cluster), url.PathEscape(s.namespace))
+
+	status, _, b, err := s.opts.do(ctx, http.MethodGet, s.url(path), nil, s.headers(path)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if status == http.StatusNotFound {
+		return nil, ErrEnvNotFound
+	}
+
+	var ret struct {
+		Configurations map[string]string `json:"configurations"`
+	}
+
+	if err = json.Unmarshal(b, &ret); err != nil {
+		return nil, err
+	}
+
+	// the properties namespace
+	if s.opts.format == EnvDotenv {
+		vars := make(map[string]string, len(ret.Configurations))
+
+		for k, v := range ret.Configurations {
+			vars[envKey(k)] = v
+		}
+
+		return vars, nil
+	}
+
+	return parseEnv([]byte(ret.Configurations["content"]), s.opts.format)
+}
+
+func (s *apolloSource) Wait(ctx context.Context) error {
+	for {
+		notifications, err := json.Marshal([]map[string]any{
+			{
+				"namespaceName":  s.namespace,
+				"notificationId": s.notificationID,
+			},
+		})
+
+		if err != nil {
+			return err
+		}
+
+		query := url.Values{}
+
+		query.Set("appId", s.cfg.AppID)
+		query.Set("cluster", s.cluster)
+		query.Set("notifications", string(notifications))
+
+		path := "/notifications/v2?" + query.Encode()
+
+		// long polling, 304 if not modified after 60s
+		status, _, b, err := s.opts.do(ctx, http.MethodGet, s.url(path), nil, s.headers(path)...)
+
+		if err != nil {
+			return err
+		}
+
+		if status == http.StatusNotModified {
+			continue
+		}
+
+		if status != http.StatusOK {
+			return fmt.Errorf("unexpected http status %d: %s", status, b)
+		}
+
+		var ret []struct {
+			NotificationID int64 `json:"notificationId"`
+		}
+
+		if err = json.Unmarshal(b, &ret); err != nil {
+			return err
+		}
+
+		for _, v := range ret {
+			s.notificationID = v.NotificationID
+		}
+
+		return nil
+	}
+}
+
+func (s *apolloSource) url(path string) string {
+	return strings.TrimSuffix(s.cfg.Addr, "/") + path
+}
+
+// headers returns the signature headers if the secret is specified.
+func (s *apolloSource) headers(pathWithQuery string) []HTTPOption {
+	if len(s.cfg.Secret) == 0 {
+		return nil
+	}
+
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	h := hmac.New(sha1.New, []byte(s.cfg.Secret))
+	h.Write([]byte(timestamp + "\n" + pathWithQuery))
+
+	return []HTTPOption{
+		WithHTTPHeader("Authorization", fmt.Sprintf("Apollo %s:%s", s.cfg.AppID, base64.StdEncoding.EncodeToString(h.Sum(nil)))),
+		WithHTTPHeader("Timestamp", timestamp),
+	}
+}
@@ -0,0 +1,112 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ConsulSourceConfig Consul env source config
+type ConsulSourceConfig struct {
+	// Addr the Consul http address, eg: http://127.0.0.1:8500
+	Addr string
+
+	// Key the KV key of the config, the format is by the extension of the key (see `WithSourceFormat`), eg: app/config.yaml
+	Key string
+
+	// Token the ACL token, optional.
+	Token string
+
+	// Datacenter optional, default: the datacenter of the agent.
+	Datacenter string
+}
+
+type consulSource struct {
+	cfg   *ConsulSourceConfig
+	opts  *envSourceOptions
+	index uint64
+}
+
+// NewConsulEnvSource returns the env source of Consul KV, see `WithEnvSource`.
+func NewConsulEnvSource(cfg *ConsulSourceConfig, options ...EnvSourceOption) EnvSource {
+	return &consulSource{
+		cfg:  cfg,
+		opts: newEnvSourceOptions(cfg.Key, options...),
+	}
+}
+
+func (s *consulSource) Name() string {
+	return fmt.Sprintf("consul://%s/%s", strings.TrimPrefix(strings.TrimPrefix(s.cfg.Addr, "http://"), "https://"), strings.TrimPrefix(s.cfg.Key, "/"))
+}
+
+func (s *consulSource) Load(ctx context.Context) (map[string]string, error) {
+	status, header, b, err := s.get(ctx, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if status == http.StatusNotFound {
+		return nil, ErrEnvNotFound
+	}
+
+	vars, err := parseEnv(b, s.opts.format)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.index, _ = strconv.ParseUint(header.Get("X-Consul-Index"), 10, 64)
+
+	return vars, nil
+}
+
+func (s *consulSource) Wait(ctx context.Context) error {
+	for {
+		// blocking query, returns once the index changed or timeout
+		_, header, _, err := s.get(ctx, s.index)
+
+		if err != nil {
+			return err
+		}
+
+		index, err := strconv.ParseUint(header.Get("X-Consul-Index"), 10, 64)
+
+		if err != nil {
+			return fmt.Errorf("invalid X-Consul-Index: %w", err)
+		}
+
+		// NOTE: the index may go backwards (eg: the raft snapshot restored)
+		if index != s.index {
+			return nil
+		}
+	}
+}
+
+func (s *consulSource) get(ctx context.Context, index uint64) (int, http.Header, []byte, error) {
+	query := url.Values{}
+
+	query.Set("raw", "")
+
+	if len(s.cfg.Datacenter) != 0 {
+		query.Set("dc", s.cfg.Datacenter)
+	}
+
+	if index != 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", "5m")
+	}
+
+	options := make([]HTTPOption, 0, 1)
+
+	if len(s.cfg.Token) != 0 {
+		options = append(options, WithHTTPHeader("X-Consul-Token", s.cfg.Token))
+	}
+
+	reqURL := fmt.Sprintf("%s/v1/kv/%s?%s", strings.TrimSuffix(s.cfg.Addr, "/"), strings.TrimPrefix(s.cfg.Key, "/"), query.Encode())
+
+	return s.opts.do(ctx, http.MethodGet, reqURL, nil, options...)
+}
@@ -0,0 +1,202 @@
+package yiigo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// EtcdSourceConfig etcd env source config
+type EtcdSourceConfig struct {
+	// Endpoint the etcd (v3) http endpoint, eg: http://127.0.0.1:2379
+	Endpoint string
+
+	// Key the key of the config, the format is by the extension of the key (see `WithSourceFormat`), eg: app/config.yaml
+	Key string
+
+	// Username and Password for the etcd auth, optional.
+	Username string
+	Password string
+}
+
+type etcdSource struct {
+	cfg      *EtcdSourceConfig
+	opts     *envSourceOptions
+	token    string
+	revision int64
+}
+
+// NewEtcdEnvSource returns the env source of etcd (via the v3 http gateway), see `WithEnvSource`.
+func NewEtcdEnvSource(cfg *EtcdSourceConfig, options ...EnvSourceOption) EnvSource {
+	return &etcdSource{
+		cfg:  cfg,
+		opts: newEnvSourceOptions(cfg.Key, options...),
+	}
+}
+
+func (s *etcdSource) Name() string {
+	return fmt.Sprintf("etcd://%s/%s", strings.TrimPrefix(strings.TrimPrefix(s.cfg.Endpoint, "http://"), "https://"), strings.TrimPrefix(s.cfg.Key, "/"))
+}
+
+type etcdHeader struct {
+	Revision string `json:"revision"`
+}
+
+func (s *etcdSource) Load(ctx context.Context) (map[string]string, error) {
+	if len(s.cfg.Username) != 0 {
+		if err := s.auth(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	body, err := json.Marshal(map[string]string{"key": base64.StdEncoding.EncodeToString([]byte(s.cfg.Key))})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ret struct {
+		Header etcdHeader `json:"header"`
+		Kvs    []struct {
+			Value string `json:"value"`
+		} `json:"kvs"`
+	}
+
+	if err = s.post(ctx, "/v3/kv/range", body, &ret); err != nil {
+		return nil, err
+	}
+
+	if len(ret.Kvs) == 0 {
+		return nil, ErrEnvNotFound
+	}
+
+	value, err := base64.StdEncoding.DecodeString(ret.Kvs[0].Value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := parseEnv(value, s.opts.format)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.revision, _ = strconv.ParseInt(ret.Header.Revision, 10, 64)
+
+	return vars, nil
+}
+
+func (s *etcdSource) Wait(ctx context.Context) error {
+	body, err := json.Marshal(map[string]any{
+		"create_request": map[string]any{
+			"key":            base64.StdEncoding.EncodeToString([]byte(s.cfg.Key)),
+			"start_revision": strconv.FormatInt(s.revision+1, 10),
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	resp, err := s.opts.client.Do(ctx, http.MethodPost, strings.TrimSuffix(s.cfg.Endpoint, "/")+"/v3/watch", body, s.headers()...)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected http status %d", resp.StatusCode)
+	}
+
+	// the watch response is the stream of json
+	decoder := json.NewDecoder(resp.Body)
+
+	for {
+		var ret struct {
+			Result struct {
+				Canceled     bool              `json:"canceled"`
+				CancelReason string            `json:"cancel_reason"`
+				Events       []json.RawMessage `json:"events"`
+			} `json:"result"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+
+		if err = decoder.Decode(&ret); err != nil {
+			return err
+		}
+
+		if ret.Error != nil {
+			return errors.New(ret.Error.Message)
+		}
+
+		if ret.Result.Canceled {
+			return fmt.Errorf("etcd watch canceled: %s", ret.Result.CancelReason)
+		}
+
+		if len(ret.Result.Events) != 0 {
+			return nil
+		}
+	}
+}
+
+func (s *etcdSource) auth(ctx context.Context) error {
+	body, err := json.Marshal(map[string]string{
+		"name":     s.cfg.Username,
+		"password": s.cfg.Password,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	s.token = ""
+
+	var ret struct {
+		Token string `json:"token"`
+	}
+
+	if err = s.post(ctx, "/v3/auth/authenticate", body, &ret); err != nil {
+		return err
+	}
+
+	s.token = ret.Token
+
+	return nil
+}
+
+func (s *etcdSource) post(ctx context.Context, path string, body []byte, ret any) error {
+	status, _, b, err := s.opts.do(ctx, http.MethodPost, strings.TrimSuffix(s.cfg.Endpoint, "/")+path, body, s.headers()...)
+
+	if err != nil {
+		return err
+	}
+
+	if status != http.StatusOK {
+		return fmt.Errorf("unexpected http status %d: %s", status, b)
+	}
+
+	return json.Unmarshal(b, ret)
+}
+
+func (s *etcdSource) headers() []HTTPOption {
+	options := []HTTPOption{WithHTTPHeader("Content-Type", "application/json")}
+
+	if len(s.token) != 0 {
+		options = append(options, WithHTTPHeader("Authorization", s.token))
+	}
+
+	return options
+}
@@ -0,0 +1,151 @@
+package yiigo
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// EnvFlagLayer the layer of the env vars bound to the command-line flags, see `EnvLayer`.
+const EnvFlagLayer = "flag"
+
+// EnvFlag the command-line flag bound to the env, see `WithEnvFlags`.
+type EnvFlag struct {
+	// Name the flag name, eg: http-port => --http-port
+	Name string
+
+	// Env the env key, default: the upper case of the name, eg: http-port => HTTP_PORT
+	Env string
+
+	// Value the default value, which is set only if the env is not loaded.
+	Value string
+
+	// Usage the usage in the --help listing.
+	Usage string
+}
+
+func (f EnvFlag) key() string {
+	if len(f.Env) != 0 {
+		return f.Env
+	}
+
+	return envKey(f.Name)
+}
+
+var envFlagValues = &envFlagRegistry{}
+
+type envFlagRegistry struct {
+	values   map[string]string
+	defaults map[string]string
+	mutex    sync.RWMutex
+}
+
+func (r *envFlagRegistry) store(values, defaults map[string]string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.values = values
+	r.defaults = defaults
+}
+
+// apply sets the flag values over the env, and the defaults if the env is not set.
+func (r *envFlagRegistry) apply() error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for k, v := range r.values {
+		if err := os.Setenv(k, v); err != nil {
+			return err
+		}
+	}
+
+	for k, v := range r.defaults {
+		if _, ok := os.LookupEnv(k); ok {
+			continue
+		}
+
+		if err := os.Setenv(k, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bound reports whether the env var is bound to the flag, the default value is only if the var is not loaded.
+func (r *envFlagRegistry) bound(key string, loaded bool) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if _, ok := r.values[key]; ok {
+		return true
+	}
+
+	_, ok := r.defaults[key]
+
+	return ok && !loaded
+}
+
+// WithEnvFlags binds the command-line flags to the env, which override the env file (or the remote source)
+// and are kept after reloaded, eg:
+//
+//	yiigo.LoadEnv(yiigo.WithEnvFile("config.yaml"), yiigo.WithEnvFlags(
+//		yiigo.EnvFlag{Name: "http-port", Value: "8000", Usage: "the http port"}, // --http-port=80 => HTTP_PORT=80
+//		yiigo.EnvFlag{Name: "log-level", Env: yiigo.EnvLogLevel, Usage: "the log level"},
+//	))
+//
+// The flags are defined in flag.CommandLine and parsed with the flags of the app defined before `LoadEnv`,
+// and --help (-h) prints the listing of all the flags and exits.
+func WithEnvFlags(flags ...EnvFlag) EnvOption {
+	return func(e *environment) {
+		e.flags = append(e.flags, flags...)
+	}
+}
+
+// bindEnvFlags defines the flags in the flag set and parses the args, the flags which are already defined are skipped.
+func bindEnvFlags(fs *flag.FlagSet, flags []EnvFlag, args []string) error {
+	keys := make(map[string]string, len(flags))
+
+	for _, f := range flags {
+		keys[f.Name] = f.key()
+
+		if fs.Lookup(f.Name) != nil {
+			continue
+		}
+
+		usage := fmt.Sprintf("env: %s", f.key())
+
+		if len(f.Usage) != 0 {
+			usage = fmt.Sprintf("%s (%s)", f.Usage, usage)
+		}
+
+		fs.String(f.Name, f.Value, usage)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	values := make(map[string]string)
+
+	fs.Visit(func(f *flag.Flag) {
+		if key, ok := keys[f.Name]; ok {
+			values[key] = f.Value.String()
+		}
+	})
+
+	defaults := make(map[string]string)
+
+	for _, f := range flags {
+		key := f.key()
+
+		if _, ok := values[key]; !ok && len(f.Value) != 0 {
+			defaults[key] = f.Value
+		}
+	}
+
+	envFlagValues.store(values, defaults)
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package yiigo
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var envLayers = &envLayerRegistry{}
+
+type envLayerRegistry struct {
+	layers map[string]string
+	mutex  sync.RWMutex
+}
+
+func (r *envLayerRegistry) store(layers map[string]string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.layers = layers
+}
+
+func (r *envLayerRegistry) load(key string) (string, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	layer, ok := r.layers[key]
+
+	return layer, ok
+}
+
+// WithEnvOverlay specifies the overlays of the env file, which are deep merged over the env file in order
+// (the arrays and scalars are replaced), the missing overlays are skipped, eg:
+//
+//	// config.yaml + config.prod.yaml
+//	yiigo.LoadEnv(yiigo.WithEnvFile("config.yaml"), yiigo.WithEnvOverlay(os.Getenv("APP_ENV")))
+//
+// NOTE: the overlay of .env is .env.{name}, eg: .env.prod
+func WithEnvOverlay(names ...string) EnvOption {
+	return func(e *environment) {
+		for _, v := range names {
+			if v = strings.TrimSpace(v); len(v) != 0 {
+				e.overlays = append(e.overlays, v)
+			}
+		}
+	}
+}
+
+// EnvLayer returns the layer which the env var is loaded from by `LoadEnv`, that is the filename of the env file (overlay),
+// the name of the remote source (see `EnvSource`) or `EnvFlagLayer`, false if the var is not loaded (eg: the system env).
+func EnvLayer(key string) (string, bool) {
+	layer, ok := envLayers.load(key)
+
+	if envFlagValues.bound(key, ok) {
+		return EnvFlagLayer, true
+	}
+
+	return layer, ok
+}
+
+// envOverlay returns the overlay filename of the env file, eg: config.yaml => config.prod.yaml, .env => .env.prod
+func envOverlay(filename, name string) string {
+	ext := filepath.Ext(filename)
+
+	if len(ext) == 0 || filepath.Base(filename) == ext {
+		return filename + "." + name
+	}
+
+	return strings.TrimSuffix(filename, ext) + "." + name + ext
+}
+
+// envFiles returns the env file and the overlays.
+func envFiles(filename string, overlays []string) []string {
+	files := make([]string, 0, len(overlays)+1)
+
+	files = append(files, filename)
+
+	for _, v := range overlays {
+		files = append(files, envOverlay(filename, v))
+	}
+
+	return files
+}
+
+// sourceLayers returns the layers of the env vars loaded from the remote source.
+func sourceLayers(src EnvSource, vars map[string]string) map[string]string {
+	layers := make(map[string]string, len(vars))
+
+	for k := range vars {
+		layers[k] = src.Name()
+	}
+
+	return layers
+}
+
+// mergeEnv deep merges src into dst, the maps are merged and the others are replaced.
+func mergeEnv(dst, src map[string]any) map[string]any {
+	if dst == nil {
+		dst = make(map[string]any, len(src))
+	}
+
+	for k, v := range src {
+		sm, ok := v.(map[string]any)
+
+		if !ok {
+			dst[k] = v
+
+			continue
+		}
+
+		dm, _ := dst[k].(map[string]any)
+
+		dst[k] = mergeEnv(dm, sm)
+	}
+
+	return dst
+}
@@ -0,0 +1,171 @@
+package yiigo
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NacosSourceConfig Nacos env source config
+type NacosSourceConfig struct {
+	// Addr the Nacos http address, eg: http://127.0.0.1:8848
+	Addr string
+
+	// Namespace the namespace id (tenant), optional.
+	Namespace string
+
+	// Group default: DEFAULT_GROUP.
+	Group string
+
+	// DataID the data id of the config, the format is by the extension of the data id (see `WithSourceFormat`), eg: app.yaml
+	DataID string
+
+	// Username and Password for the Nacos auth, optional.
+	Username string
+	Password string
+}
+
+type nacosSource struct {
+	cfg   *NacosSourceConfig
+	opts  *envSourceOptions
+	group string
+	token string
+	md5   string
+}
+
+// NewNacosEnvSource returns the env source of Nacos config, see `WithEnvSource`.
+func NewNacosEnvSource(cfg *NacosSourceConfig, options ...EnvSourceOption) EnvSource {
+	s := &nacosSource{
+		cfg:   cfg,
+		opts:  newEnvSourceOptions(cfg.DataID, options...),
+		group: cfg.Group,
+	}
+
+	if len(s.group) == 0 {
+		s.group = "DEFAULT_GROUP"
+	}
+
+	return s
+}
+
+func (s *nacosSource) Name() string {
+	return fmt.Sprintf("nacos://%s/%s/%s", strings.TrimPrefix(strings.TrimPrefix(s.cfg.Addr, "http://"), "https://"), s.group, s.cfg.DataID)
+}
+
+func (s *nacosSource) Load(ctx context.Context) (map[string]string, error) {
+	if len(s.cfg.Username) != 0 {
+		if err := s.login(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	query := s.query()
+
+	query.Set("dataId", s.cfg.DataID)
+	query.Set("group", s.group)
+
+	if len(s.cfg.Namespace) != 0 {
+		query.Set("tenant", s.cfg.Namespace)
+	}
+
+	status, _, b, err := s.opts.do(ctx, http.MethodGet, s.url("/nacos/v1/cs/configs", query), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if status == http.StatusNotFound {
+		return nil, ErrEnvNotFound
+	}
+
+	vars, err := parseEnv(b, s.opts.format)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sum := md5.Sum(b)
+	s.md5 = hex.EncodeToString(sum[:])
+
+	return vars, nil
+}
+
+func (s *nacosSource) Wait(ctx context.Context) error {
+	// dataId^2group^2md5[^2tenant]^1
+	listening := []string{s.cfg.DataID, s.group, s.md5}
+
+	if len(s.cfg.Namespace) != 0 {
+		listening = append(listening, s.cfg.Namespace)
+	}
+
+	form := url.Values{}
+	form.Set("Listening-Configs", strings.Join(listening, "\x02")+"\x01")
+
+	for {
+		// long polling, responses the changed configs or empty after 30s
+		_, _, b, err := s.opts.do(ctx, http.MethodPost, s.url("/nacos/v1/cs/configs/listener", s.query()), []byte(form.Encode()),
+			WithHTTPHeader("Content-Type", "application/x-www-form-urlencoded"),
+			WithHTTPHeader("Long-Pulling-Timeout", "30000"),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		if len(strings.TrimSpace(string(b))) != 0 {
+			return nil
+		}
+	}
+}
+
+func (s *nacosSource) login(ctx context.Context) error {
+	form := url.Values{}
+
+	form.Set("username", s.cfg.Username)
+	form.Set("password", s.cfg.Password)
+
+	s.token = ""
+
+	_, _, b, err := s.opts.do(ctx, http.MethodPost, s.url("/nacos/v1/auth/login", nil), []byte(form.Encode()), WithHTTPHeader("Content-Type", "application/x-www-form-urlencoded"))
+
+	if err != nil {
+		return err
+	}
+
+	var ret struct {
+		AccessToken string `json:"accessToken"`
+	}
+
+	if err = json.Unmarshal(b, &ret); err != nil {
+		return err
+	}
+
+	s.token = ret.AccessToken
+
+	return nil
+}
+
+func (s *nacosSource) query() url.Values {
+	query := url.Values{}
+
+	if len(s.token) != 0 {
+		query.Set("accessToken", s.token)
+	}
+
+	return query
+}
+
+func (s *nacosSource) url(path string, query url.Values) string {
+	reqURL := strings.TrimSuffix(s.cfg.Addr, "/") + path
+
+	if len(query) != 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	return reqURL
+}
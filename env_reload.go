@@ -0,0 +1,214 @@
+package yiigo
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// The env keys of the dynamic settings, which are applied once the env is loaded (reloaded).
+// The {NAME} is the upper case of the registered name, eg: DEFAULT.
+const (
+	// EnvLogLevel the level of all the loggers, eg: info.
+	EnvLogLevel = "YIIGO_LOG_LEVEL"
+
+	// EnvLoggerLevel the level of the logger, which overrides `EnvLogLevel`, eg: YIIGO_LOG_OTHER_LEVEL=debug
+	EnvLoggerLevel = "YIIGO_LOG_{NAME}_LEVEL"
+
+	// EnvDBMaxOpenConns the max open conns of the db, eg: YIIGO_DB_DEFAULT_MAX_OPEN_CONNS=50
+	EnvDBMaxOpenConns = "YIIGO_DB_{NAME}_MAX_OPEN_CONNS"
+
+	// EnvDBMaxIdleConns the max idle conns of the db, eg: YIIGO_DB_DEFAULT_MAX_IDLE_CONNS=10
+	EnvDBMaxIdleConns = "YIIGO_DB_{NAME}_MAX_IDLE_CONNS"
+
+	// EnvRedisPoolSize the pool size of the redis, which can't exceed the configured `PoolSize`, eg: YIIGO_REDIS_DEFAULT_POOL_SIZE=5
+	EnvRedisPoolSize = "YIIGO_REDIS_{NAME}_POOL_SIZE"
+)
+
+var envNameRegexp = regexp.MustCompile(`[^A-Z0-9]+`)
+
+var envReloads = &envReloadRegistry{}
+
+type envReloadRegistry struct {
+	callbacks []EnvOnChangeFunc
+	mutex     sync.RWMutex
+}
+
+func (r *envReloadRegistry) add(fn ...EnvOnChangeFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.callbacks = append(r.callbacks, fn...)
+}
+
+func (r *envReloadRegistry) all() []EnvOnChangeFunc {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return append(make([]EnvOnChangeFunc, 0, len(r.callbacks)), r.callbacks...)
+}
+
+// OnEnvReload registers the callbacks which are called in order each time the env file is reloaded by the watcher
+// (see `WithEnvWatcher`), after the dynamic settings are applied, eg:
+//
+//	yiigo.OnEnvReload(func(e fsnotify.Event) {
+//		yiigo.ReloadDB(yiigo.Default, yiigo.MySQL, &yiigo.DBConfig{DSN: os.Getenv("DB_DSN")})
+//	})
+func OnEnvReload(fn ...EnvOnChangeFunc) {
+	envReloads.add(fn...)
+}
+
+// reloadEnv reloads the env file (and the overlays), then applies the dynamic settings and calls the callbacks.
+func reloadEnv(files []string, event fsnotify.Event, fn EnvOnChangeFunc) {
+	if err := loadEnvFile(files...); err != nil {
+		logger.Error("err env reload", zap.Error(err), zap.String("env_file", files[0]))
+
+		return
+	}
+
+	envReloaded(event, fn)
+}
+
+// envReloaded applies the dynamic settings and calls the callbacks after the env is reloaded.
+func envReloaded(event fsnotify.Event, fn EnvOnChangeFunc) {
+	applyEnvSettings()
+
+	if fn != nil {
+		callEnvReload(fn, event)
+	}
+
+	for _, f := range envReloads.all() {
+		callEnvReload(f, event)
+	}
+}
+
+func callEnvReload(fn EnvOnChangeFunc, event fsnotify.Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("env reload callback panic", zap.Any("error", r), zap.ByteString("stack", debug.Stack()))
+		}
+	}()
+
+	fn(event)
+}
+
+// envName returns the env key of the registered name.
+func envName(key, name string) string {
+	return strings.Replace(key, "{NAME}", envKey(name), 1)
+}
+
+// envKey returns the upper case of s, the non-alphanumeric chars are replaced by `_`, eg: db.max-conns => DB_MAX_CONNS
+func envKey(s string) string {
+	return strings.Trim(envNameRegexp.ReplaceAllString(strings.ToUpper(s), "_"), "_")
+}
+
+// envLevels keeps the log levels in ENV applied last time.
+var envLevels sync.Map
+
+// envLevelChanged reports whether the log level in ENV is changed since applied last time.
+func envLevelChanged(key, level string) bool {
+	if len(level) == 0 {
+		envLevels.Delete(key)
+
+		return false
+	}
+
+	v, ok := envLevels.Load(key)
+
+	envLevels.Store(key, level)
+
+	return !ok || v.(string) != level
+}
+
+// applyEnvSettings applies the dynamic settings in ENV (see `EnvLogLevel`), the unset ones are skipped.
+// The log levels are applied only if changed, so the levels changed at runtime (eg: `SetLoggerLevel`) are kept.
+func applyEnvSettings() {
+	all := envLevelChanged(EnvLogLevel, os.Getenv(EnvLogLevel))
+
+	if all {
+		if err := SetLogLevel(os.Getenv(EnvLogLevel)); err != nil {
+			logger.Error("err env setting", zap.String("key", EnvLogLevel), zap.Error(err))
+		}
+	}
+
+	levelMap.Range(func(key, value any) bool {
+		k := envName(EnvLoggerLevel, key.(string))
+		v := os.Getenv(k)
+
+		// overrides `EnvLogLevel` which is applied to all the loggers
+		if changed := envLevelChanged(k, v); len(v) != 0 && (all || changed) {
+			if err := SetLoggerLevel(key.(string), v); err != nil {
+				logger.Error("err env setting", zap.String("key", k), zap.Error(err))
+			}
+		}
+
+		return true
+	})
+
+	dbmap.Range(func(key, value any) bool {
+		db := value.(*sqlx.DB)
+
+		if n, ok := envInt(envName(EnvDBMaxOpenConns, key.(string))); ok {
+			db.SetMaxOpenConns(n)
+		}
+
+		if n, ok := envInt(envName(EnvDBMaxIdleConns, key.(string))); ok {
+			db.SetMaxIdleConns(n)
+		}
+
+		return true
+	})
+
+	redisMap.Range(func(key, value any) bool {
+		name := key.(string)
+
+		n, ok := envInt(envName(EnvRedisPoolSize, name))
+
+		if !ok {
+			return true
+		}
+
+		pool, ok := value.(*redisResourcePool)
+
+		if !ok {
+			logger.Warn(fmt.Sprintf("redis.%s pool size can't be changed (cluster)", name))
+
+			return true
+		}
+
+		// shrinking waits for the conns to be put back
+		go func() {
+			if err := pool.setPoolSize(n); err != nil {
+				logger.Error(fmt.Sprintf("err redis.%s pool size", name), zap.Int("size", n), zap.Error(err))
+			}
+		}()
+
+		return true
+	})
+}
+
+func envInt(key string) (int, bool) {
+	v := os.Getenv(key)
+
+	if len(v) == 0 {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(v)
+
+	if err != nil {
+		logger.Error("err env setting", zap.String("key", key), zap.Error(err))
+
+		return 0, false
+	}
+
+	return n, true
+}
@@ -0,0 +1,108 @@
+package yiigo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "YIIGO_DB_DEFAULT_MAX_OPEN_CONNS", envName(EnvDBMaxOpenConns, Default))
+	assert.Equal(t, "YIIGO_REDIS_USER_CACHE_POOL_SIZE", envName(EnvRedisPoolSize, "user-cache"))
+}
+
+func TestApplyEnvSettings(t *testing.T) {
+	db, err := OpenSQLiteMemory()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	dbmap.Store("reload", db)
+	defer dbmap.Delete("reload")
+
+	s := miniredis.RunT(t)
+
+	pool := newRedisPool(&RedisConfig{Addr: s.Addr()})
+
+	redisMap.Store("reload", pool)
+	defer redisMap.Delete("reload")
+
+	defer logLevel.SetLevel(zap.DebugLevel)
+
+	t.Setenv(EnvLogLevel, "warn")
+	defer envLevels.Delete(EnvLogLevel)
+	t.Setenv("YIIGO_DB_RELOAD_MAX_OPEN_CONNS", "7")
+	t.Setenv("YIIGO_REDIS_RELOAD_POOL_SIZE", "3")
+
+	applyEnvSettings()
+
+	assert.Equal(t, zap.WarnLevel, logLevel.Level())
+	assert.Equal(t, 7, db.Stats().MaxOpenConnections)
+	assert.Eventually(t, func() bool {
+		return pool.(*redisResourcePool).pool.Capacity() == 3
+	}, time.Second, 10*time.Millisecond)
+
+	// out of range
+	assert.NotNil(t, pool.(*redisResourcePool).setPoolSize(11))
+	assert.NotNil(t, pool.(*redisResourcePool).setPoolSize(0))
+
+	// unchanged, the level changed at runtime is kept
+	assert.Nil(t, SetLogLevel("error"))
+
+	applyEnvSettings()
+
+	assert.Equal(t, zap.ErrorLevel, logLevel.Level())
+
+	// invalid
+	t.Setenv(EnvLogLevel, "verbose")
+
+	applyEnvSettings()
+
+	assert.Equal(t, zap.ErrorLevel, logLevel.Level())
+}
+
+func TestReloadEnv(t *testing.T) {
+	registry := envReloads
+	envReloads = &envReloadRegistry{}
+
+	defer func() {
+		envReloads = registry
+	}()
+
+	filename := filepath.Join(t.TempDir(), "app.yaml")
+
+	assert.Nil(t, os.WriteFile(filename, []byte("yiigo_test_reload: 1\n"), 0644))
+
+	defer os.Unsetenv("YIIGO_TEST_RELOAD")
+
+	calls := make([]string, 0)
+
+	OnEnvReload(func(e fsnotify.Event) {
+		calls = append(calls, "a:"+os.Getenv("YIIGO_TEST_RELOAD"))
+	}, func(e fsnotify.Event) {
+		panic("oops")
+	})
+	OnEnvReload(func(e fsnotify.Event) {
+		calls = append(calls, "b")
+	})
+
+	reloadEnv([]string{filename}, fsnotify.Event{Name: filename, Op: fsnotify.Write}, func(e fsnotify.Event) {
+		calls = append(calls, "watcher")
+	})
+
+	assert.Equal(t, []string{"watcher", "a:1", "b"}, calls)
+
+	// not called if failed
+	assert.Nil(t, os.WriteFile(filename, []byte("yiigo_test_reload: [\n"), 0644))
+
+	reloadEnv([]string{filename}, fsnotify.Event{Name: filename, Op: fsnotify.Write}, nil)
+
+	assert.Equal(t, 3, len(calls))
+}
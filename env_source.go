@@ -0,0 +1,196 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// ErrEnvNotFound the config is not found in the remote source.
+var ErrEnvNotFound = errors.New("env not found")
+
+// EnvSource is the remote source of the env (eg: etcd, Consul, Nacos and Apollo), see `WithEnvSource`.
+type EnvSource interface {
+	// Name returns the name of the source, which is the event name of the reload callbacks.
+	Name() string
+
+	// Load returns the env vars of the source.
+	Load(ctx context.Context) (map[string]string, error)
+
+	// Wait blocks until the source is changed after the last Load (eg: long polling), or returns the error.
+	Wait(ctx context.Context) error
+}
+
+// WithEnvSource loads the env from the remote source, which falls back to the env file if the source is unavailable,
+// and the source is watched instead of the file by `WithEnvWatcher`, eg:
+//
+//	yiigo.LoadEnv(
+//		yiigo.WithEnvSource(yiigo.NewConsulEnvSource(&yiigo.ConsulSourceConfig{Addr: "http://127.0.0.1:8500", Key: "app/config.yaml"})),
+//		yiigo.WithEnvFile("config.yaml"), // the fallback
+//		yiigo.WithEnvWatcher(nil),
+//	)
+func WithEnvSource(src EnvSource) EnvOption {
+	return func(e *environment) {
+		e.source = src
+	}
+}
+
+type envSourceOptions struct {
+	client HTTPClient
+	format string
+}
+
+// EnvSourceOption remote env source option
+type EnvSourceOption func(o *envSourceOptions)
+
+// WithSourceHTTPClient specifies the http client of the source, default: yiigo.NewDefaultHTTPClient().
+func WithSourceHTTPClient(c HTTPClient) EnvSourceOption {
+	return func(o *envSourceOptions) {
+		o.client = c
+	}
+}
+
+// WithSourceFormat specifies the format of the content (EnvDotenv, EnvYAML or EnvJSON), default: by the extension of the key.
+func WithSourceFormat(format string) EnvSourceOption {
+	return func(o *envSourceOptions) {
+		o.format = format
+	}
+}
+
+func newEnvSourceOptions(key string, options ...EnvSourceOption) *envSourceOptions {
+	o := &envSourceOptions{
+		client: defaultHTTP(),
+	}
+
+	for _, f := range options {
+		f(o)
+	}
+
+	if len(o.format) == 0 {
+		o.format = envFormat(key)
+	}
+
+	return o
+}
+
+// do sends the request and returns the status, header and body of the response.
+func (o *envSourceOptions) do(ctx context.Context, method, reqURL string, body []byte, options ...HTTPOption) (int, http.Header, []byte, error) {
+	resp, err := o.client.Do(ctx, method, reqURL, body, options...)
+
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound {
+		return resp.StatusCode, resp.Header, b, fmt.Errorf("unexpected http status %d: %s", resp.StatusCode, b)
+	}
+
+	return resp.StatusCode, resp.Header, b, nil
+}
+
+// loadEnvSource loads the env vars of the source into ENV.
+func loadEnvSource(src EnvSource) (map[string]string, error) {
+	vars, err := fetchEnvSource(context.Background(), src)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = setEnv(vars); err != nil {
+		return nil, err
+	}
+
+	envLayers.store(sourceLayers(src, vars))
+
+	return vars, nil
+}
+
+func fetchEnvSource(ctx context.Context, src EnvSource) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	return src.Load(ctx)
+}
+
+// watchEnvSource reloads the env once the source is changed, and retries (with backoff) on the errors.
+// The vars is the last loaded, nil if the source is unavailable when loading.
+func watchEnvSource(ctx context.Context, src EnvSource, vars map[string]string, fn EnvOnChangeFunc) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("env source watcher panic", zap.Any("error", r), zap.String("env_source", src.Name()), zap.ByteString("stack", debug.Stack()))
+		}
+	}()
+
+	synced := vars != nil
+	backoff := time.Second
+
+	for {
+		var err error
+
+		if !synced {
+			var latest map[string]string
+
+			if latest, err = fetchEnvSource(ctx, src); err == nil {
+				synced = true
+
+				// reloaded only if changed, eg: resynced after the error
+				if !reflect.DeepEqual(latest, vars) {
+					if err = setEnv(latest); err == nil {
+						vars = latest
+
+						envLayers.store(sourceLayers(src, vars))
+
+						envReloaded(fsnotify.Event{Name: src.Name(), Op: fsnotify.Write}, fn)
+					}
+				}
+			}
+		}
+
+		if err == nil {
+			if err = src.Wait(ctx); err == nil {
+				synced = false
+				backoff = time.Second
+
+				continue
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		synced = false
+
+		logger.Error("err env source", zap.Error(err), zap.String("env_source", src.Name()))
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		case <-timer.C:
+		}
+
+		if backoff *= 2; backoff > 30*time.Second {
+			backoff = 30 * time.Second
+		}
+	}
+}
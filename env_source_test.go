@@ -0,0 +1,299 @@
+package yiigo
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockEnvSource struct {
+	vars    map[string]string
+	err     error
+	changed chan struct{}
+	mutex   sync.Mutex
+}
+
+func (s *mockEnvSource) Name() string {
+	return "mock"
+}
+
+func (s *mockEnvSource) Load(ctx context.Context) (map[string]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	vars := make(map[string]string, len(s.vars))
+
+	for k, v := range s.vars {
+		vars[k] = v
+	}
+
+	return vars, nil
+}
+
+func (s *mockEnvSource) Wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.changed:
+		return nil
+	}
+}
+
+func (s *mockEnvSource) set(vars map[string]string) {
+	s.mutex.Lock()
+	s.vars = vars
+	s.mutex.Unlock()
+
+	s.changed <- struct{}{}
+}
+
+func TestLoadEnvSource(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "app.yaml")
+
+	assert.Nil(t, os.WriteFile(filename, []byte("yiigo_test_source: file\n"), 0644))
+
+	defer os.Unsetenv("YIIGO_TEST_SOURCE")
+
+	LoadEnv(WithEnvFile(filename), WithEnvSource(&mockEnvSource{vars: map[string]string{"YIIGO_TEST_SOURCE": "remote"}}))
+
+	assert.Equal(t, "remote", os.Getenv("YIIGO_TEST_SOURCE"))
+
+	layer, _ := EnvLayer("YIIGO_TEST_SOURCE")
+
+	assert.Equal(t, "mock", layer)
+
+	// fallback to the file
+	LoadEnv(WithEnvFile(filename), WithEnvSource(&mockEnvSource{err: errors.New("unavailable")}))
+
+	assert.Equal(t, "file", os.Getenv("YIIGO_TEST_SOURCE"))
+
+	layer, _ = EnvLayer("YIIGO_TEST_SOURCE")
+
+	assert.Equal(t, filename, layer)
+}
+
+func TestWatchEnvSource(t *testing.T) {
+	defer os.Unsetenv("YIIGO_TEST_WATCH")
+
+	src := &mockEnvSource{
+		vars:    map[string]string{"YIIGO_TEST_WATCH": "1"},
+		changed: make(chan struct{}),
+	}
+
+	vars, err := loadEnvSource(src)
+
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		events []string
+		mutex  sync.Mutex
+	)
+
+	go watchEnvSource(ctx, src, vars, func(e fsnotify.Event) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		events = append(events, e.Name+":"+os.Getenv("YIIGO_TEST_WATCH"))
+	})
+
+	// unchanged
+	src.set(map[string]string{"YIIGO_TEST_WATCH": "1"})
+	src.set(map[string]string{"YIIGO_TEST_WATCH": "2"})
+
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return len(events) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{"mock:2"}, events)
+}
+
+func TestEtcdEnvSource(t *testing.T) {
+	value := "YIIGO_TEST_ETCD=1"
+	revision := 5
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+
+		switch r.URL.Path {
+		case "/v3/auth/authenticate":
+			fmt.Fprint(w, `{"token":"t0"}`)
+		case "/v3/kv/range":
+			assert.Equal(t, "t0", r.Header.Get("Authorization"))
+			assert.JSONEq(t, fmt.Sprintf(`{"key":%q}`, base64.StdEncoding.EncodeToString([]byte("app/.env"))), string(b))
+
+			fmt.Fprintf(w, `{"header":{"revision":"%d"},"kvs":[{"value":%q}]}`, revision, base64.StdEncoding.EncodeToString([]byte(value)))
+		case "/v3/watch":
+			assert.Contains(t, string(b), `"start_revision":"6"`)
+
+			fmt.Fprint(w, `{"result":{"created":true}}`)
+			w.(http.Flusher).Flush()
+			fmt.Fprint(w, `{"result":{"events":[{"kv":{}}]}}`)
+		}
+	}))
+
+	defer srv.Close()
+
+	src := NewEtcdEnvSource(&EtcdSourceConfig{Endpoint: srv.URL, Key: "app/.env", Username: "root", Password: "123"})
+
+	vars, err := src.Load(context.TODO())
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"YIIGO_TEST_ETCD": "1"}, vars)
+	assert.Nil(t, src.Wait(context.TODO()))
+}
+
+func TestConsulEnvSource(t *testing.T) {
+	index := 10
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/app/config.json" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		assert.Equal(t, "tk", r.Header.Get("X-Consul-Token"))
+
+		if r.URL.Query().Get("index") == "10" {
+			index++
+		}
+
+		w.Header().Set("X-Consul-Index", fmt.Sprint(index))
+
+		fmt.Fprint(w, `{"db":{"host":"localhost"}}`)
+	}))
+
+	defer srv.Close()
+
+	src := NewConsulEnvSource(&ConsulSourceConfig{Addr: srv.URL, Key: "app/config.json", Token: "tk"})
+
+	vars, err := src.Load(context.TODO())
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"DB_HOST": "localhost"}, vars)
+	assert.Nil(t, src.Wait(context.TODO()))
+	assert.Equal(t, 11, index)
+
+	// not found
+	src = NewConsulEnvSource(&ConsulSourceConfig{Addr: srv.URL, Key: "app/none"})
+
+	_, err = src.Load(context.TODO())
+
+	assert.Equal(t, ErrEnvNotFound, err)
+}
+
+func TestNacosEnvSource(t *testing.T) {
+	content := "db:\n  host: localhost\n"
+	polls := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/nacos/v1/auth/login":
+			fmt.Fprint(w, `{"accessToken":"t0","tokenTtl":18000}`)
+		case "/nacos/v1/cs/configs":
+			assert.Equal(t, "t0", r.URL.Query().Get("accessToken"))
+			assert.Equal(t, "app.yaml", r.URL.Query().Get("dataId"))
+			assert.Equal(t, "DEFAULT_GROUP", r.URL.Query().Get("group"))
+			assert.Equal(t, "dev", r.URL.Query().Get("tenant"))
+
+			fmt.Fprint(w, content)
+		case "/nacos/v1/cs/configs/listener":
+			assert.Equal(t, "30000", r.Header.Get("Long-Pulling-Timeout"))
+			assert.Nil(t, r.ParseForm())
+			assert.Equal(t, fmt.Sprintf("app.yaml\x02DEFAULT_GROUP\x02%x\x02dev\x01", md5.Sum([]byte(content))), r.PostForm.Get("Listening-Configs"))
+
+			// changed after the second polling
+			if polls++; polls > 1 {
+				fmt.Fprint(w, "app.yaml%02DEFAULT_GROUP%02dev%01")
+			}
+		}
+	}))
+
+	defer srv.Close()
+
+	src := NewNacosEnvSource(&NacosSourceConfig{Addr: srv.URL, Namespace: "dev", DataID: "app.yaml", Username: "nacos", Password: "nacos"})
+
+	vars, err := src.Load(context.TODO())
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"DB_HOST": "localhost"}, vars)
+	assert.Nil(t, src.Wait(context.TODO()))
+	assert.Equal(t, 2, polls)
+}
+
+func TestApolloEnvSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.Header.Get("Authorization"), "Apollo demo:")
+		assert.NotEmpty(t, r.Header.Get("Timestamp"))
+
+		switch r.URL.Path {
+		case "/configs/demo/default/application":
+			fmt.Fprint(w, `{"configurations":{"db.host":"localhost","max-conns":"10"}}`)
+		case "/configs/demo/default/app.yaml":
+			fmt.Fprint(w, `{"configurations":{"content":"db:\n  host: 127.0.0.1\n"}}`)
+		case "/notifications/v2":
+			var notifications []map[string]any
+
+			assert.Nil(t, json.Unmarshal([]byte(r.URL.Query().Get("notifications")), &notifications))
+
+			if notifications[0]["notificationId"].(float64) == -1 {
+				w.WriteHeader(http.StatusNotModified)
+
+				return
+			}
+
+			fmt.Fprint(w, `[{"namespaceName":"application","notificationId":3}]`)
+		}
+	}))
+
+	defer srv.Close()
+
+	src := NewApolloEnvSource(&ApolloSourceConfig{Addr: srv.URL, AppID: "demo", Secret: "s"})
+
+	vars, err := src.Load(context.TODO())
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"DB_HOST": "localhost", "MAX_CONNS": "10"}, vars)
+
+	src = NewApolloEnvSource(&ApolloSourceConfig{Addr: srv.URL, AppID: "demo", Namespace: "app.yaml", Secret: "s"})
+
+	vars, err = src.Load(context.TODO())
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"DB_HOST": "127.0.0.1"}, vars)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// not modified
+	assert.NotNil(t, src.Wait(ctx))
+
+	src.(*apolloSource).notificationID = 2
+
+	assert.Nil(t, src.Wait(context.TODO()))
+	assert.Equal(t, int64(3), src.(*apolloSource).notificationID)
+}
@@ -1,10 +1,16 @@
 package yiigo
 
 import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
 )
 
 var (
@@ -32,3 +38,192 @@ func NullIntGTE(fl validator.FieldLevel) bool {
 
 	return fl.Field().Int() >= i
 }
+
+func TestLoadEnvFile(t *testing.T) {
+	dir := t.TempDir()
+
+	yml := filepath.Join(dir, "app.yaml")
+
+	err := os.WriteFile(yml, []byte(`
+yiigo_test:
+  env: dev
+  db:
+    port: 3306
+    ratio: 0.5
+    debug: true
+  hosts: [a, b]
+  servers:
+    - host: s1
+    - host: s2
+  empty:
+`), 0644)
+	assert.Nil(t, err)
+
+	assert.Nil(t, loadEnvFile(yml))
+	assert.Equal(t, "dev", os.Getenv("YIIGO_TEST_ENV"))
+	assert.Equal(t, "3306", os.Getenv("YIIGO_TEST_DB_PORT"))
+	assert.Equal(t, "0.5", os.Getenv("YIIGO_TEST_DB_RATIO"))
+	assert.Equal(t, "true", os.Getenv("YIIGO_TEST_DB_DEBUG"))
+	assert.Equal(t, "a,b", os.Getenv("YIIGO_TEST_HOSTS"))
+	assert.Equal(t, "s2", os.Getenv("YIIGO_TEST_SERVERS_1_HOST"))
+
+	v, ok := os.LookupEnv("YIIGO_TEST_EMPTY")
+	assert.True(t, ok)
+	assert.Empty(t, v)
+
+	js := filepath.Join(dir, "app.json")
+
+	err = os.WriteFile(js, []byte(`{"yiigo_test": {"env": "prod", "db": {"port": 12345678901234567890}}}`), 0644)
+	assert.Nil(t, err)
+
+	assert.Nil(t, loadEnvFile(js))
+	assert.Equal(t, "prod", os.Getenv("YIIGO_TEST_ENV"))
+	assert.Equal(t, "12345678901234567890", os.Getenv("YIIGO_TEST_DB_PORT"))
+
+	// the created empty file
+	empty := filepath.Join(dir, "empty.json")
+
+	assert.Nil(t, os.WriteFile(empty, nil, 0644))
+	assert.Nil(t, loadEnvFile(empty))
+
+	// invalid
+	assert.Nil(t, os.WriteFile(js, []byte(`{"yiigo_test"`), 0644))
+	assert.NotNil(t, loadEnvFile(js))
+
+	// dotenv
+	dotenv := filepath.Join(dir, ".env")
+
+	assert.Nil(t, os.WriteFile(dotenv, []byte("YIIGO_TEST_ENV=test\n"), 0644))
+	assert.Nil(t, loadEnvFile(dotenv))
+	assert.Equal(t, "test", os.Getenv("YIIGO_TEST_ENV"))
+
+	for _, v := range os.Environ() {
+		if strings.HasPrefix(v, "YIIGO_TEST_") {
+			os.Unsetenv(strings.SplitN(v, "=", 2)[0])
+		}
+	}
+}
+
+func TestLoadEnvOverlay(t *testing.T) {
+	dir := t.TempDir()
+
+	base := filepath.Join(dir, "config.yaml")
+
+	err := os.WriteFile(base, []byte(`
+yiigo_test:
+  env: dev
+  db:
+    host: localhost
+    port: 3306
+  servers:
+    - host: s1
+    - host: s2
+`), 0644)
+	assert.Nil(t, err)
+
+	err = os.WriteFile(filepath.Join(dir, "config.prod.yaml"), []byte(`
+yiigo_test:
+  env: prod
+  db:
+    host: 10.0.0.1
+  servers:
+    - host: p1
+`), 0644)
+	assert.Nil(t, err)
+
+	// the missing overlay is skipped
+	files := envFiles(base, []string{"prod", "local"})
+
+	assert.Equal(t, filepath.Join(dir, "config.local.yaml"), files[2])
+
+	vars, layers, err := readEnvFiles(files)
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"YIIGO_TEST_ENV":            "prod",
+		"YIIGO_TEST_DB_HOST":        "10.0.0.1",
+		"YIIGO_TEST_DB_PORT":        "3306",
+		"YIIGO_TEST_SERVERS_0_HOST": "p1",
+	}, vars)
+	assert.Equal(t, files[1], layers["YIIGO_TEST_DB_HOST"])
+	assert.Equal(t, base, layers["YIIGO_TEST_DB_PORT"])
+
+	assert.Nil(t, loadEnvFile(files...))
+
+	layer, ok := EnvLayer("YIIGO_TEST_ENV")
+
+	assert.True(t, ok)
+	assert.Equal(t, files[1], layer)
+
+	_, ok = EnvLayer("PATH")
+
+	assert.False(t, ok)
+
+	// dotenv
+	dotenv := filepath.Join(dir, ".env")
+
+	assert.Equal(t, filepath.Join(dir, ".env.prod"), envOverlay(dotenv, "prod"))
+	assert.Nil(t, os.WriteFile(dotenv, []byte("YIIGO_TEST_ENV=dev\nYIIGO_TEST_DEBUG=true\n"), 0644))
+	assert.Nil(t, os.WriteFile(envOverlay(dotenv, "prod"), []byte("YIIGO_TEST_ENV=prod\n"), 0644))
+
+	vars, layers, err = readEnvFiles(envFiles(dotenv, []string{"prod"}))
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"YIIGO_TEST_ENV": "prod", "YIIGO_TEST_DEBUG": "true"}, vars)
+	assert.Equal(t, dotenv, layers["YIIGO_TEST_DEBUG"])
+
+	// the env file is required
+	_, _, err = readEnvFiles([]string{filepath.Join(dir, "none.yaml")})
+
+	assert.NotNil(t, err)
+
+	for _, v := range os.Environ() {
+		if strings.HasPrefix(v, "YIIGO_TEST_") {
+			os.Unsetenv(strings.SplitN(v, "=", 2)[0])
+		}
+	}
+}
+
+func TestBindEnvFlags(t *testing.T) {
+	defer envFlagValues.store(nil, nil)
+
+	flags := []EnvFlag{
+		{Name: "http-port", Value: "8000", Usage: "the http port"},
+		{Name: "log-level", Env: "YIIGO_TEST_LOG_LEVEL"},
+		{Name: "yiigo-test-debug", Value: "false"},
+	}
+
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	assert.Nil(t, bindEnvFlags(fs, flags, []string{"--http-port=80", "-log-level", "warn"}))
+	assert.Equal(t, "the http port (env: HTTP_PORT)", fs.Lookup("http-port").Usage)
+
+	filename := filepath.Join(t.TempDir(), "app.yaml")
+
+	assert.Nil(t, os.WriteFile(filename, []byte("http_port: 8080\nyiigo_test_log_level: info\n"), 0644))
+
+	defer os.Unsetenv("HTTP_PORT")
+
+	// the flags override the env file
+	assert.Nil(t, loadEnvFile(filename))
+	assert.Equal(t, "80", os.Getenv("HTTP_PORT"))
+	assert.Equal(t, "warn", os.Getenv("YIIGO_TEST_LOG_LEVEL"))
+	assert.Equal(t, "false", os.Getenv("YIIGO_TEST_DEBUG"))
+
+	layer, _ := EnvLayer("HTTP_PORT")
+
+	assert.Equal(t, EnvFlagLayer, layer)
+
+	// --help
+	fs = flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	assert.Equal(t, flag.ErrHelp, bindEnvFlags(fs, flags, []string{"--help"}))
+
+	for _, v := range os.Environ() {
+		if strings.HasPrefix(v, "YIIGO_TEST_") {
+			os.Unsetenv(strings.SplitN(v, "=", 2)[0])
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package gen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shenghui0779/yiigo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoName(t *testing.T) {
+	assert.Equal(t, "UserID", GoName("user_id"))
+	assert.Equal(t, "HTTPURL", GoName("http_url"))
+	assert.Equal(t, "CreatedAt", GoName("createdAt"))
+	assert.Equal(t, "T2fa", GoName("2fa"))
+}
+
+func TestGenerate(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := sqlx.Open(string(yiigo.SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TABLE user (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name VARCHAR(32) NOT NULL,
+		email TEXT,
+		enabled BOOLEAN NOT NULL,
+		avatar BLOB,
+		created_at DATETIME NOT NULL
+	)`)
+	assert.Nil(t, err)
+
+	_, err = db.Exec(`CREATE TABLE user_role (user_id INTEGER NOT NULL, role_id INTEGER NOT NULL, PRIMARY KEY (user_id, role_id))`)
+	assert.Nil(t, err)
+
+	tables, err := Introspect(ctx, db, yiigo.SQLite, "")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(tables))
+	assert.Equal(t, "user", tables[0].Name)
+	assert.Equal(t, &Column{Name: "id", DataType: "integer", PrimaryKey: true}, tables[0].Columns[0])
+	assert.Equal(t, &Column{Name: "email", DataType: "text", Nullable: true}, tables[0].Columns[2])
+
+	tables, err = Introspect(ctx, db, yiigo.SQLite, "", "user")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(tables))
+
+	code, err := Generate("model", tables)
+
+	assert.Nil(t, err)
+
+	src := string(code)
+
+	assert.Contains(t, src, "// Code generated by yiigo gen. DO NOT EDIT.\n\npackage model\n")
+	assert.Contains(t, src, `"database/sql"`)
+	assert.Contains(t, src, `"time"`)
+	assert.Contains(t, src, "type User struct {\n"+
+		"\tID        int64          `db:\"id\"`\n"+
+		"\tName      string         `db:\"name\"`\n"+
+		"\tEmail     sql.NullString `db:\"email\"`\n"+
+		"\tEnabled   bool           `db:\"enabled\"`\n"+
+		"\tAvatar    []byte         `db:\"avatar\"`\n"+
+		"\tCreatedAt time.Time      `db:\"created_at\"`\n"+
+		"}")
+	assert.Contains(t, src, `const UserTable = "user"`)
+	assert.Contains(t, src, `UserCreatedAt = "created_at"`)
+	assert.Contains(t, src, "var UserColumns = []string{UserID, UserName, UserEmail, UserEnabled, UserAvatar, UserCreatedAt}")
+	assert.Contains(t, src, "var UserCols = struct {\n"+
+		"\tID        yiigo.Col[int64]\n"+
+		"\tName      yiigo.Col[string]\n"+
+		"\tEmail     yiigo.Col[string]\n"+
+		"\tEnabled   yiigo.Col[bool]\n"+
+		"\tAvatar    yiigo.Col[[]byte]\n"+
+		"\tCreatedAt yiigo.Col[time.Time]\n"+
+		"}{\n"+
+		"\tID:        UserID,\n")
+	assert.Contains(t, src, "func UserQuery(builder yiigo.SQLBuilder, options ...yiigo.QueryOption) *yiigo.SQLWrapperOf[User] {")
+	assert.Contains(t, src, "func FindUserByID(ctx context.Context, builder yiigo.SQLBuilder, db sqlx.QueryerContext, id int64) (User, error) {")
+
+	// composite primary key without finder
+	tables, err = Introspect(ctx, db, yiigo.SQLite, "", "user_role")
+
+	assert.Nil(t, err)
+
+	code, err = Generate("model", tables)
+
+	assert.Nil(t, err)
+	assert.NotContains(t, string(code), "func Find")
+	assert.NotContains(t, string(code), `"context"`)
+
+	_, err = Generate("model", nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,310 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// commonInitialisms the initialisms kept upper case in Go names (like golint does).
+var commonInitialisms = map[string]bool{
+	"API":  true,
+	"CPU":  true,
+	"DNS":  true,
+	"HTML": true,
+	"HTTP": true,
+	"ID":   true,
+	"IP":   true,
+	"JSON": true,
+	"SQL":  true,
+	"URI":  true,
+	"URL":  true,
+	"UUID": true,
+	"XML":  true,
+}
+
+var tpl = template.Must(template.New("").Parse(`
+{{define "header"}}// Code generated by yiigo gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{range .Std}}	{{printf "%q" .}}
+{{end}}
+{{range .Third}}	{{printf "%q" .}}
+{{end}})
+{{end}}
+
+{{define "model"}}
+// {{.Name}} the model of table ` + "`{{.Table}}`" + `{{if .Comment}} ({{.Comment}}){{end}}.
+type {{.Name}} struct {
+{{range .Fields}}	{{.Name}} {{.Type}} ` + "`db:\"{{.Column}}\"`" + `{{if .Comment}} // {{.Comment}}{{end}}
+{{end}}}
+
+// {{.Name}}Table the table name of {{.Name}}.
+const {{.Name}}Table = "{{.Table}}"
+
+// The columns of {{.Name}}.
+const (
+{{range .Fields}}	{{.Const}} = "{{.Column}}"
+{{end}})
+
+// {{.Name}}Columns all the columns of {{.Name}}.
+var {{.Name}}Columns = []string{ {{range $i, $f := .Fields}}{{if $i}}, {{end}}{{$f.Const}}{{end}} }
+
+// {{.Name}}Cols the typed columns of {{.Name}}, eg: {{.Name}}Cols.{{(index .Fields 0).Name}}.Eq(v).
+var {{.Name}}Cols = struct {
+{{range .Fields}}	{{.Name}} yiigo.Col[{{.ColType}}]
+{{end}}}{
+{{range .Fields}}	{{.Name}}: {{.Const}},
+{{end}}}
+
+// {{.Name}}Query returns the typed wrapper of table ` + "`{{.Table}}`" + `, eg:
+//
+//	{{.Name}}Query(builder, yiigo.Where("id = ?", 1)).Get(ctx, yiigo.DB())
+func {{.Name}}Query(builder yiigo.SQLBuilder, options ...yiigo.QueryOption) *yiigo.SQLWrapperOf[{{.Name}}] {
+	return yiigo.WrapOf[{{.Name}}](builder, append([]yiigo.QueryOption{yiigo.Table({{.Name}}Table)}, options...)...)
+}
+{{with .PK}}
+// Find{{$.Name}}By{{.Name}} returns the {{$.Name}} by primary key, returns sql.ErrNoRows if not found.
+func Find{{$.Name}}By{{.Name}}(ctx context.Context, builder yiigo.SQLBuilder, db sqlx.QueryerContext, {{.Arg}} {{.Type}}) ({{$.Name}}, error) {
+	return {{$.Name}}Query(builder, yiigo.Where({{.Const}}+" = ?", {{.Arg}})).Get(ctx, db)
+}
+{{end}}{{end}}`))
+
+type tplField struct {
+	Name    string
+	Const   string
+	Type    string
+	ColType string
+	Column  string
+	Comment string
+	Arg     string
+}
+
+type tplModel struct {
+	Name    string
+	Table   string
+	Comment string
+	Fields  []*tplField
+	PK      *tplField
+}
+
+// Generate returns the formatted Go source of the models, including:
+//
+//   - the struct with `db` tags per table
+//   - the table name and column constants
+//   - the typed query helpers which plug into the SQLBuilder
+func Generate(pkg string, tables []*Table) ([]byte, error) {
+	imports := map[string]struct{}{
+		"github.com/shenghui0779/yiigo": {},
+	}
+
+	models := make([]*tplModel, 0, len(tables))
+
+	for _, t := range tables {
+		m := &tplModel{
+			Name:    GoName(t.Name),
+			Table:   t.Name,
+			Comment: oneLine(t.Comment),
+			Fields:  make([]*tplField, 0, len(t.Columns)),
+		}
+
+		pks := 0
+
+		for _, c := range t.Columns {
+			typ, pkg := GoType(c)
+
+			if len(pkg) != 0 {
+				imports[pkg] = struct{}{}
+			}
+
+			colType := scalarType(typ)
+
+			if colType == "time.Time" {
+				imports["time"] = struct{}{}
+			}
+
+			name := GoName(c.Name)
+
+			f := &tplField{
+				Name:    name,
+				Const:   m.Name + name,
+				Type:    typ,
+				ColType: colType,
+				Column:  c.Name,
+				Comment: oneLine(c.Comment),
+				Arg:     lowerFirst(name),
+			}
+
+			m.Fields = append(m.Fields, f)
+
+			if c.PrimaryKey {
+				pks++
+
+				m.PK = f
+			}
+		}
+
+		// the finder is generated for the single primary key only
+		if pks != 1 {
+			m.PK = nil
+		}
+
+		if m.PK != nil {
+			imports["context"] = struct{}{}
+			imports["github.com/jmoiron/sqlx"] = struct{}{}
+		}
+
+		models = append(models, m)
+	}
+
+	if len(models) == 0 {
+		return nil, fmt.Errorf("gen: no tables")
+	}
+
+	var buf bytes.Buffer
+
+	std, third := sortedImports(imports)
+
+	header := map[string]any{
+		"Package": pkg,
+		"Std":     std,
+		"Third":   third,
+	}
+
+	if err := tpl.ExecuteTemplate(&buf, "header", header); err != nil {
+		return nil, err
+	}
+
+	for _, m := range models {
+		if err := tpl.ExecuteTemplate(&buf, "model", m); err != nil {
+			return nil, err
+		}
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// GoType returns the Go type of the column, and the package to import (if any).
+func GoType(c *Column) (string, string) {
+	dataType := c.DataType
+
+	// eg: varchar(32), int unsigned
+	if i := strings.IndexAny(dataType, "( "); i != -1 && dataType != "tinyint(1)" {
+		dataType = dataType[:i]
+	}
+
+	switch dataType {
+	case "tinyint(1)", "bool", "boolean":
+		if c.Nullable {
+			return "sql.NullBool", "database/sql"
+		}
+
+		return "bool", ""
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "int2", "int4", "int8", "serial", "bigserial", "smallserial":
+		if c.Nullable {
+			return "sql.NullInt64", "database/sql"
+		}
+
+		return "int64", ""
+	case "float", "double", "real", "float4", "float8", "double precision":
+		if c.Nullable {
+			return "sql.NullFloat64", "database/sql"
+		}
+
+		return "float64", ""
+	case "date", "datetime", "timestamp", "timestamptz":
+		if c.Nullable {
+			return "sql.NullTime", "database/sql"
+		}
+
+		return "time.Time", "time"
+	case "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary", "bytea":
+		return "[]byte", ""
+	}
+
+	// string, text, json, decimal (keep precision), etc.
+	if c.Nullable {
+		return "sql.NullString", "database/sql"
+	}
+
+	return "string", ""
+}
+
+// scalarType returns the value type of sql.NullX, eg: sql.NullString -> string.
+func scalarType(typ string) string {
+	switch typ {
+	case "sql.NullBool":
+		return "bool"
+	case "sql.NullInt64":
+		return "int64"
+	case "sql.NullFloat64":
+		return "float64"
+	case "sql.NullTime":
+		return "time.Time"
+	case "sql.NullString":
+		return "string"
+	}
+
+	return typ
+}
+
+// GoName returns the exported Go name of the identifier, eg: user_id -> UserID.
+func GoName(s string) string {
+	var builder strings.Builder
+
+	for _, v := range strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if upper := strings.ToUpper(v); commonInitialisms[upper] {
+			builder.WriteString(upper)
+
+			continue
+		}
+
+		builder.WriteString(strings.ToUpper(v[:1]))
+		builder.WriteString(v[1:])
+	}
+
+	name := builder.String()
+
+	if len(name) == 0 || unicode.IsDigit(rune(name[0])) {
+		name = "T" + name
+	}
+
+	return name
+}
+
+func lowerFirst(s string) string {
+	if upper := strings.ToUpper(s); commonInitialisms[upper] {
+		return strings.ToLower(s)
+	}
+
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// sortedImports returns the sorted standard and third-party imports.
+func sortedImports(m map[string]struct{}) (std, third []string) {
+	for k := range m {
+		// the standard library has no dot in the first path element
+		if first, _, _ := strings.Cut(k, "/"); strings.Contains(first, ".") {
+			third = append(third, k)
+		} else {
+			std = append(std, k)
+		}
+	}
+
+	sort.Strings(std)
+	sort.Strings(third)
+
+	return
+}
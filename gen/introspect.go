@@ -0,0 +1,200 @@
+package gen
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shenghui0779/yiigo"
+)
+
+// Table the table schema read from the database.
+type Table struct {
+	Name    string
+	Comment string
+	Columns []*Column
+}
+
+// Column the column schema read from the database.
+type Column struct {
+	Name       string
+	DataType   string // eg: varchar, bigint, tinyint(1)
+	Nullable   bool
+	PrimaryKey bool
+	Comment    string
+}
+
+// Introspect reads the tables (all if not specified) of the schema from the database.
+// The schema defaults to the current database (MySQL) or `public` (Postgres), and is ignored by SQLite.
+func Introspect(ctx context.Context, db *sqlx.DB, driver yiigo.DBDriver, schema string, tables ...string) ([]*Table, error) {
+	var (
+		result []*Table
+		err    error
+	)
+
+	switch driver {
+	case yiigo.MySQL:
+		result, err = introspectMySQL(ctx, db, schema)
+	case yiigo.Postgres:
+		result, err = introspectPostgres(ctx, db, schema)
+	case yiigo.SQLite:
+		result, err = introspectSQLite(ctx, db)
+	default:
+		return nil, fmt.Errorf("gen: driver %s is not supported", driver)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tables) == 0 {
+		return result, nil
+	}
+
+	wanted := make(map[string]struct{}, len(tables))
+
+	for _, v := range tables {
+		wanted[v] = struct{}{}
+	}
+
+	filtered := make([]*Table, 0, len(tables))
+
+	for _, v := range result {
+		if _, ok := wanted[v.Name]; ok {
+			filtered = append(filtered, v)
+		}
+	}
+
+	return filtered, nil
+}
+
+type columnRow struct {
+	TableName    string `db:"table_name"`
+	TableComment string `db:"table_comment"`
+	ColumnName   string `db:"column_name"`
+	DataType     string `db:"data_type"`
+	IsNullable   string `db:"is_nullable"`
+	ColumnKey    string `db:"column_key"`
+	Comment      string `db:"column_comment"`
+}
+
+func introspectMySQL(ctx context.Context, db *sqlx.DB, schema string) ([]*Table, error) {
+	query := `SELECT c.TABLE_NAME AS table_name, t.TABLE_COMMENT AS table_comment, c.COLUMN_NAME AS column_name,
+	IF(c.COLUMN_TYPE LIKE 'tinyint(1)%', 'tinyint(1)', c.DATA_TYPE) AS data_type,
+	c.IS_NULLABLE AS is_nullable, c.COLUMN_KEY AS column_key, c.COLUMN_COMMENT AS column_comment
+FROM information_schema.COLUMNS c
+JOIN information_schema.TABLES t ON t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME
+WHERE c.TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND t.TABLE_TYPE = 'BASE TABLE'
+ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION`
+
+	rows := make([]*columnRow, 0)
+
+	if err := db.SelectContext(ctx, &rows, query, schema); err != nil {
+		return nil, err
+	}
+
+	return groupColumns(rows), nil
+}
+
+func introspectPostgres(ctx context.Context, db *sqlx.DB, schema string) ([]*Table, error) {
+	query := `SELECT c.table_name, COALESCE(obj_description(to_regclass(quote_ident(c.table_schema) || '.' || quote_ident(c.table_name)), 'pg_class'), '') AS table_comment,
+	c.column_name, c.udt_name AS data_type, c.is_nullable,
+	CASE WHEN EXISTS (
+		SELECT 1 FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu ON kcu.constraint_name = tc.constraint_name AND kcu.table_schema = tc.table_schema
+		WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = c.table_schema AND tc.table_name = c.table_name AND kcu.column_name = c.column_name
+	) THEN 'PRI' ELSE '' END AS column_key,
+	COALESCE(col_description(to_regclass(quote_ident(c.table_schema) || '.' || quote_ident(c.table_name)), c.ordinal_position::int), '') AS column_comment
+FROM information_schema.columns c
+JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+WHERE c.table_schema = COALESCE(NULLIF($1, ''), 'public') AND t.table_type = 'BASE TABLE'
+ORDER BY c.table_name, c.ordinal_position`
+
+	rows := make([]*columnRow, 0)
+
+	if err := db.SelectContext(ctx, &rows, query, schema); err != nil {
+		return nil, err
+	}
+
+	return groupColumns(rows), nil
+}
+
+func introspectSQLite(ctx context.Context, db *sqlx.DB) ([]*Table, error) {
+	names := make([]string, 0)
+
+	if err := db.SelectContext(ctx, &names, `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`); err != nil {
+		return nil, err
+	}
+
+	tables := make([]*Table, 0, len(names))
+
+	for _, name := range names {
+		info := make([]struct {
+			CID          int     `db:"cid"`
+			Name         string  `db:"name"`
+			Type         string  `db:"type"`
+			NotNull      bool    `db:"notnull"`
+			DefaultValue *string `db:"dflt_value"`
+			PK           int     `db:"pk"`
+		}, 0)
+
+		if err := db.SelectContext(ctx, &info, "SELECT * FROM pragma_table_info(?)", name); err != nil {
+			return nil, err
+		}
+
+		t := &Table{
+			Name:    name,
+			Columns: make([]*Column, 0, len(info)),
+		}
+
+		for _, v := range info {
+			t.Columns = append(t.Columns, &Column{
+				Name:       v.Name,
+				DataType:   strings.ToLower(v.Type),
+				Nullable:   !v.NotNull && v.PK == 0,
+				PrimaryKey: v.PK != 0,
+			})
+		}
+
+		tables = append(tables, t)
+	}
+
+	return tables, nil
+}
+
+func groupColumns(rows []*columnRow) []*Table {
+	tables := make([]*Table, 0)
+
+	m := make(map[string]*Table)
+
+	for _, v := range rows {
+		t, ok := m[v.TableName]
+
+		if !ok {
+			t = &Table{
+				Name:    v.TableName,
+				Comment: v.TableComment,
+			}
+
+			m[v.TableName] = t
+
+			tables = append(tables, t)
+		}
+
+		t.Columns = append(t.Columns, &Column{
+			Name:       v.ColumnName,
+			DataType:   strings.ToLower(v.DataType),
+			Nullable:   strings.EqualFold(v.IsNullable, "YES"),
+			PrimaryKey: v.ColumnKey == "PRI",
+			Comment:    v.Comment,
+		})
+	}
+
+	sort.SliceStable(tables, func(i, j int) bool {
+		return tables[i].Name < tables[j].Name
+	})
+
+	return tables
+}
@@ -3,14 +3,12 @@ package yiigo
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"errors"
 	"io"
 	"mime/multipart"
-	"net"
 	"net/http"
 	"net/url"
-	"time"
+	"sync"
 )
 
 // httpSetting http request setting
@@ -59,6 +57,7 @@ type formfile struct {
 	fieldname string
 	filename  string
 	filefunc  FormFileFunc
+	size      int64 // -1 if unknown
 }
 
 type uploadform struct {
@@ -92,6 +91,51 @@ func (f *uploadform) Write(w *multipart.Writer) error {
 	return nil
 }
 
+// contentLength returns the length of the multipart body, -1 if the size of any file is unknown.
+func (f *uploadform) contentLength(boundary string) int64 {
+	cw := new(countWriter)
+
+	w := multipart.NewWriter(cw)
+
+	if err := w.SetBoundary(boundary); err != nil {
+		return -1
+	}
+
+	for _, v := range f.formfiles {
+		if v.size < 0 {
+			return -1
+		}
+
+		if _, err := w.CreateFormFile(v.fieldname, v.filename); err != nil {
+			return -1
+		}
+
+		cw.n += v.size
+	}
+
+	for name, value := range f.formfields {
+		if err := w.WriteField(name, value); err != nil {
+			return -1
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return -1
+	}
+
+	return cw.n
+}
+
+type countWriter struct {
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+
+	return len(p), nil
+}
+
 // UploadField configures how we set up the upload from.
 type UploadField func(f *uploadform)
 
@@ -102,6 +146,24 @@ func WithFormFile(fieldname, filename string, fn FormFileFunc) UploadField {
 			fieldname: fieldname,
 			filename:  filename,
 			filefunc:  fn,
+			size:      -1,
+		})
+	}
+}
+
+// WithFormReader specifies the file field to upload from, which is streamed from the reader,
+// the size is -1 if unknown (the request is chunked), eg: yiigo.WithFormReader("media", "demo.mp4", f, fi.Size())
+func WithFormReader(fieldname, filename string, r io.Reader, size int64) UploadField {
+	return func(f *uploadform) {
+		f.formfiles = append(f.formfiles, &formfile{
+			fieldname: fieldname,
+			filename:  filename,
+			filefunc: func(w io.Writer) error {
+				_, err := io.Copy(w, r)
+
+				return err
+			},
+			size: size,
 		})
 	}
 }
@@ -138,12 +200,32 @@ type HTTPClient interface {
 	Upload(ctx context.Context, reqURL string, form UploadForm, options ...HTTPOption) (*http.Response, error)
 }
 
+// HTTPMiddlewareUser is the client which supports the middlewares, eg: the client returned by NewHTTPClient,
+// client.(yiigo.HTTPMiddlewareUser).Use(yiigo.HTTPLogMiddleware(yiigo.Logger("http")))
+type HTTPMiddlewareUser interface {
+	// Use appends the middlewares of the transport, the first one is the outermost (see `HTTPMiddleware`).
+	Use(middlewares ...HTTPMiddleware)
+}
+
 type httpclient struct {
-	client *http.Client
+	client      *http.Client
+	transport   http.RoundTripper // the transport without middlewares
+	middlewares []HTTPMiddleware
+	mutex       sync.RWMutex
 }
 
 func (c *httpclient) Do(ctx context.Context, method, reqURL string, body []byte, options ...HTTPOption) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, method, reqURL, bytes.NewReader(body))
+	req, err := newHTTPRequest(ctx, method, reqURL, bytes.NewReader(body), options...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.send(ctx, req)
+}
+
+func newHTTPRequest(ctx context.Context, method, reqURL string, body io.Reader, options ...HTTPOption) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
 
 	if err != nil {
 		return nil, err
@@ -177,7 +259,15 @@ func (c *httpclient) Do(ctx context.Context, method, reqURL string, body []byte,
 		req.Close = true
 	}
 
-	resp, err := c.client.Do(req)
+	return req, nil
+}
+
+func (c *httpclient) send(ctx context.Context, req *http.Request) (*http.Response, error) {
+	c.mutex.RLock()
+	client := c.client
+	c.mutex.RUnlock()
+
+	resp, err := client.Do(req)
 
 	if err != nil {
 		// If the context has been canceled, the context's error is probably more useful.
@@ -193,45 +283,75 @@ func (c *httpclient) Do(ctx context.Context, method, reqURL string, body []byte,
 	return resp, nil
 }
 
+// Upload streams the multipart body by the pipe without buffering the files in memory,
+// the Content-Length is set if the sizes of all the files are known (see `WithFormReader`), otherwise the request is chunked.
 func (c *httpclient) Upload(ctx context.Context, reqURL string, form UploadForm, options ...HTTPOption) (*http.Response, error) {
-	buf := bytes.NewBuffer(make([]byte, 0, 20<<10)) // 20kb
-	w := multipart.NewWriter(buf)
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+
+	options = append(options, WithHTTPHeader("Content-Type", w.FormDataContentType()))
+
+	req, err := newHTTPRequest(ctx, http.MethodPost, reqURL, pr, options...)
 
-	if err := form.Write(w); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
-	options = append(options, WithHTTPHeader("Content-Type", w.FormDataContentType()))
+	if f, ok := form.(*uploadform); ok {
+		if n := f.contentLength(w.Boundary()); n >= 0 {
+			req.ContentLength = n
+		}
+	}
 
-	// Don't forget to close the multipart writer.
-	// If you don't close it, your request will be missing the terminating boundary.
-	if err := w.Close(); err != nil {
-		return nil, err
+	go func() {
+		if err := form.Write(w); err != nil {
+			pw.CloseWithError(err)
+
+			return
+		}
+
+		// Don't forget to close the multipart writer.
+		// If you don't close it, your request will be missing the terminating boundary.
+		pw.CloseWithError(w.Close())
+	}()
+
+	resp, err := c.send(ctx, req)
+
+	// the writer is unblocked if the body is not consumed
+	pr.CloseWithError(io.ErrClosedPipe)
+
+	return resp, err
+}
+
+func (c *httpclient) Use(middlewares ...HTTPMiddleware) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.transport == nil {
+		c.transport = c.client.Transport
+
+		if c.transport == nil {
+			c.transport = http.DefaultTransport
+		}
 	}
 
-	return c.Do(ctx, http.MethodPost, reqURL, buf.Bytes(), options...)
+	c.middlewares = append(c.middlewares, middlewares...)
+
+	// the shallow copy, the in-flight requests keep the previous transport
+	client := *c.client
+	client.Transport = chainHTTPMiddlewares(c.transport, c.middlewares)
+
+	c.client = &client
 }
 
 // NewDefaultHTTPClient returns a new client with default http.Client
 func NewDefaultHTTPClient() HTTPClient {
+	// the default config never fails
+	t, _ := NewHTTPTransport(nil)
+
 	return &httpclient{
 		client: &http.Client{
-			Transport: &http.Transport{
-				Proxy: http.ProxyFromEnvironment,
-				DialContext: (&net.Dialer{
-					Timeout:   30 * time.Second,
-					KeepAlive: 60 * time.Second,
-				}).DialContext,
-				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: true,
-				},
-				MaxIdleConns:          0,
-				MaxIdleConnsPerHost:   1000,
-				MaxConnsPerHost:       1000,
-				IdleConnTimeout:       60 * time.Second,
-				TLSHandshakeTimeout:   10 * time.Second,
-				ExpectContinueTimeout: time.Second,
-			},
+			Transport: t,
 		},
 	}
 }
@@ -243,31 +363,74 @@ func NewHTTPClient(client *http.Client) HTTPClient {
 	}
 }
 
-var defaultHTTPClient = NewDefaultHTTPClient()
+var (
+	defaultHTTPClient      = NewDefaultHTTPClient()
+	defaultHTTPMiddlewares []HTTPMiddleware // registered by UseHTTPMiddleware, carried over when the default client is replaced
+	defaultHTTPMutex       sync.RWMutex
+)
+
+func defaultHTTP() HTTPClient {
+	defaultHTTPMutex.RLock()
+	defer defaultHTTPMutex.RUnlock()
+
+	return defaultHTTPClient
+}
+
+// setDefaultHTTP replaces the default client (eg: configured by `WithHTTPClient`),
+// the middlewares registered by `UseHTTPMiddleware` are appended to the new one.
+func setDefaultHTTP(c HTTPClient) {
+	defaultHTTPMutex.Lock()
+	defer defaultHTTPMutex.Unlock()
+
+	if u, ok := c.(HTTPMiddlewareUser); ok && len(defaultHTTPMiddlewares) != 0 {
+		u.Use(defaultHTTPMiddlewares...)
+	}
+
+	defaultHTTPClient = c
+}
+
+// UseHTTPMiddleware appends the middlewares of the default client, which is used by HTTPGet, HTTPPost etc.
+// The middlewares are kept when the default client is configured later (eg: `Init` after `UseHTTPMiddleware`).
+func UseHTTPMiddleware(middlewares ...HTTPMiddleware) {
+	defaultHTTPMutex.Lock()
+	defer defaultHTTPMutex.Unlock()
+
+	c, ok := defaultHTTPClient.(HTTPMiddlewareUser)
+
+	if !ok {
+		logger.Warn("default http client doesn't support middlewares")
+
+		return
+	}
+
+	defaultHTTPMiddlewares = append(defaultHTTPMiddlewares, middlewares...)
+
+	c.Use(middlewares...)
+}
 
 // HTTPGet issues a GET to the specified URL.
 func HTTPGet(ctx context.Context, reqURL string, options ...HTTPOption) (*http.Response, error) {
-	return defaultHTTPClient.Do(ctx, http.MethodGet, reqURL, nil, options...)
+	return defaultHTTP().Do(ctx, http.MethodGet, reqURL, nil, options...)
 }
 
 // HTTPPost issues a POST to the specified URL.
 func HTTPPost(ctx context.Context, reqURL string, body []byte, options ...HTTPOption) (*http.Response, error) {
-	return defaultHTTPClient.Do(ctx, http.MethodPost, reqURL, body, options...)
+	return defaultHTTP().Do(ctx, http.MethodPost, reqURL, body, options...)
 }
 
 // HTTPPostForm issues a POST to the specified URL, with data's keys and values URL-encoded as the request body.
 func HTTPPostForm(ctx context.Context, reqURL string, data url.Values, options ...HTTPOption) (*http.Response, error) {
 	options = append(options, WithHTTPHeader("Content-Type", "application/x-www-form-urlencoded"))
 
-	return defaultHTTPClient.Do(ctx, http.MethodPost, reqURL, []byte(data.Encode()), options...)
+	return defaultHTTP().Do(ctx, http.MethodPost, reqURL, []byte(data.Encode()), options...)
 }
 
 // HTTPUpload issues a UPLOAD to the specified URL.
 func HTTPUpload(ctx context.Context, reqURL string, form UploadForm, options ...HTTPOption) (*http.Response, error) {
-	return defaultHTTPClient.Upload(ctx, reqURL, form, options...)
+	return defaultHTTP().Upload(ctx, reqURL, form, options...)
 }
 
 // HTTPDo sends an HTTP request and returns an HTTP response
 func HTTPDo(ctx context.Context, method, reqURL string, body []byte, options ...HTTPOption) (*http.Response, error) {
-	return defaultHTTPClient.Do(ctx, method, reqURL, body, options...)
+	return defaultHTTP().Do(ctx, method, reqURL, body, options...)
 }
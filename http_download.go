@@ -0,0 +1,294 @@
+package yiigo
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DownloadProgressFunc reports the progress of the download, the written includes the resumed bytes,
+// and the total is -1 if unknown.
+type DownloadProgressFunc func(written, total int64)
+
+type downloadOptions struct {
+	client   HTTPClient
+	options  []HTTPOption
+	hash     hash.Hash
+	checksum string
+	progress DownloadProgressFunc
+}
+
+// DownloadOption configures how we set up the download.
+type DownloadOption func(o *downloadOptions)
+
+// WithDownloadClient specifies the http client of the download, default: the default client (see `UseHTTPMiddleware`).
+// NOTE: The client should not have the overall timeout, use context to cancel the download.
+func WithDownloadClient(c HTTPClient) DownloadOption {
+	return func(o *downloadOptions) {
+		o.client = c
+	}
+}
+
+// WithDownloadHTTPOptions specifies the options of the http request, eg: yiigo.WithHTTPHeader("Authorization", "Bearer token")
+func WithDownloadHTTPOptions(options ...HTTPOption) DownloadOption {
+	return func(o *downloadOptions) {
+		o.options = append(o.options, options...)
+	}
+}
+
+// WithDownloadChecksum verifies the checksum (hex) of the download, eg: yiigo.WithDownloadChecksum(sha256.New(), "9f86d0...")
+// The hash is reset for each download, so the option can be reused by the retries but not the concurrent downloads.
+func WithDownloadChecksum(h hash.Hash, checksum string) DownloadOption {
+	return func(o *downloadOptions) {
+		o.hash = h
+		o.checksum = strings.ToLower(checksum)
+	}
+}
+
+// WithDownloadProgress specifies the progress callback, which is called on every write.
+func WithDownloadProgress(fn DownloadProgressFunc) DownloadOption {
+	return func(o *downloadOptions) {
+		o.progress = fn
+	}
+}
+
+func newDownloadOptions(options ...DownloadOption) *downloadOptions {
+	o := &downloadOptions{client: defaultHTTP()}
+
+	for _, f := range options {
+		f(o)
+	}
+
+	// the option may be reused, eg: the retries
+	if o.hash != nil {
+		o.hash.Reset()
+	}
+
+	return o
+}
+
+// HTTPDownload streams the response body of the URL to the writer without buffering in memory,
+// and returns the written bytes.
+func HTTPDownload(ctx context.Context, reqURL string, w io.Writer, options ...DownloadOption) (int64, error) {
+	o := newDownloadOptions(options...)
+
+	resp, err := o.client.Do(ctx, http.MethodGet, reqURL, nil, o.options...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected http status %d", resp.StatusCode)
+	}
+
+	return o.copy(w, resp, 0)
+}
+
+// HTTPDownloadFile downloads the URL to the file, and returns the size of the file.
+// The existing file is resumed by the `Range` request, and it's downloaded from the beginning if the server doesn't
+// support ranges. The file is removed if the checksum mismatches (see `WithDownloadChecksum`), so the retry starts over.
+func HTTPDownloadFile(ctx context.Context, reqURL, filename string, options ...DownloadOption) (int64, error) {
+	o := newDownloadOptions(options...)
+
+	var offset int64
+
+	if fi, err := os.Stat(filename); err == nil {
+		offset = fi.Size()
+	}
+
+	// the checksum includes the resumed bytes
+	if offset > 0 && o.hash != nil {
+		if err := o.hashFile(filename); err != nil {
+			return 0, err
+		}
+	}
+
+	reqOptions := o.options
+
+	if offset > 0 {
+		reqOptions = append(append(make([]HTTPOption, 0, len(o.options)+1), o.options...), WithHTTPHeader("Range", fmt.Sprintf("bytes=%d-", offset)))
+	}
+
+	resp, err := o.client.Do(ctx, http.MethodGet, reqURL, nil, reqOptions...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	flag := os.O_WRONLY | os.O_CREATE
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if start, _ := contentRange(resp.Header.Get("Content-Range")); start != offset {
+			return 0, fmt.Errorf("unexpected content range: %s", resp.Header.Get("Content-Range"))
+		}
+
+		flag |= os.O_APPEND
+	case http.StatusOK: // the range is not supported
+		offset = 0
+		flag |= os.O_TRUNC
+
+		if o.hash != nil {
+			o.hash.Reset()
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the file has been downloaded
+		if _, total := contentRange(resp.Header.Get("Content-Range")); offset == 0 || total != offset {
+			return 0, fmt.Errorf("unexpected http status %d", resp.StatusCode)
+		}
+
+		if o.progress != nil {
+			o.progress(offset, offset)
+		}
+
+		if err = o.verify(); err != nil {
+			os.Remove(filename)
+
+			return 0, err
+		}
+
+		return offset, nil
+	default:
+		return 0, fmt.Errorf("unexpected http status %d", resp.StatusCode)
+	}
+
+	f, err := os.OpenFile(filename, flag, 0644)
+
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := o.copy(f, resp, offset)
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		if errors.Is(err, errChecksumMismatch) {
+			os.Remove(filename)
+		}
+
+		return 0, err
+	}
+
+	return n, nil
+}
+
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+// copy copies the response body to the writer, and returns the written bytes (including the offset).
+func (o *downloadOptions) copy(w io.Writer, resp *http.Response, offset int64) (int64, error) {
+	total := int64(-1)
+
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+
+	if o.hash != nil {
+		w = io.MultiWriter(w, o.hash)
+	}
+
+	pw := &progressWriter{
+		w:        w,
+		written:  offset,
+		total:    total,
+		progress: o.progress,
+	}
+
+	if _, err := io.Copy(pw, resp.Body); err != nil {
+		return pw.written, err
+	}
+
+	if total >= 0 && pw.written != total {
+		return pw.written, io.ErrUnexpectedEOF
+	}
+
+	if err := o.verify(); err != nil {
+		return pw.written, err
+	}
+
+	return pw.written, nil
+}
+
+func (o *downloadOptions) hashFile(filename string) error {
+	f, err := os.Open(filename)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	_, err = io.Copy(o.hash, f)
+
+	return err
+}
+
+func (o *downloadOptions) verify() error {
+	if o.hash == nil {
+		return nil
+	}
+
+	if sum := hex.EncodeToString(o.hash.Sum(nil)); sum != o.checksum {
+		return fmt.Errorf("%w: expected %s, got %s", errChecksumMismatch, o.checksum, sum)
+	}
+
+	return nil
+}
+
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	progress DownloadProgressFunc
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+
+	w.written += int64(n)
+
+	if w.progress != nil {
+		w.progress(w.written, w.total)
+	}
+
+	return n, err
+}
+
+// contentRange parses the Content-Range header, eg: bytes 100-199/200, bytes */200, the total is -1 if unknown.
+func contentRange(s string) (start, total int64) {
+	start, total = -1, -1
+
+	s = strings.TrimSpace(strings.TrimPrefix(s, "bytes"))
+
+	rng, size, ok := strings.Cut(s, "/")
+
+	if !ok {
+		return
+	}
+
+	if v, err := strconv.ParseInt(size, 10, 64); err == nil {
+		total = v
+	}
+
+	if from, _, ok := strings.Cut(rng, "-"); ok {
+		if v, err := strconv.ParseInt(from, 10, 64); err == nil {
+			start = v
+		}
+	}
+
+	return
+}
@@ -0,0 +1,96 @@
+package yiigo
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type ctxHTTPRouteKey struct{}
+
+// ContextWithHTTPRoute returns a copy of ctx with the route of the outbound request, which labels the metrics
+// and the span instead of the path (the high cardinality), eg: yiigo.ContextWithHTTPRoute(ctx, "/users/{id}")
+func ContextWithHTTPRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, ctxHTTPRouteKey{}, route)
+}
+
+func httpRouteFromCtx(ctx context.Context) string {
+	route, _ := ctx.Value(ctxHTTPRouteKey{}).(string)
+
+	return route
+}
+
+// HTTPMetrics is the Prometheus collector of the requests sent by the http client, eg:
+//
+//	metrics := yiigo.NewHTTPMetrics("app")
+//	prometheus.MustRegister(metrics)
+//
+//	client.(yiigo.HTTPMiddlewareUser).Use(yiigo.HTTPMetricsMiddleware(metrics))
+//
+// The metrics (labeled by host, route and method, the route is specified by `ContextWithHTTPRoute`):
+//   - {namespace}_http_client_requests_total: the number of the requests, labeled by status (the status code or error) as well
+//   - {namespace}_http_client_request_duration_seconds: the histogram of the request duration (until the response headers)
+type HTTPMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewHTTPMetrics returns new HTTPMetrics, the buckets default to prometheus.DefBuckets.
+func NewHTTPMetrics(namespace string, buckets ...float64) *HTTPMetrics {
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+
+	return &HTTPMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "requests_total",
+			Help:      "The number of the sent requests.",
+		}, []string{"host", "route", "method", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "request_duration_seconds",
+			Help:      "The duration of the sent requests.",
+			Buckets:   buckets,
+		}, []string{"host", "route", "method"}),
+	}
+}
+
+func (m *HTTPMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+func (m *HTTPMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.duration.Collect(ch)
+}
+
+// HTTPMetricsMiddleware records the metrics of the requests, see `HTTPMetrics`.
+func HTTPMetricsMiddleware(m *HTTPMetrics) HTTPMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return HTTPRoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			now := time.Now()
+
+			resp, err := next.RoundTrip(req)
+
+			route := httpRouteFromCtx(req.Context())
+
+			status := "error"
+
+			if err == nil {
+				status = strconv.Itoa(resp.StatusCode)
+			}
+
+			m.requests.WithLabelValues(req.URL.Host, route, req.Method, status).Inc()
+			m.duration.WithLabelValues(req.URL.Host, route, req.Method).Observe(time.Since(now).Seconds())
+
+			return resp, err
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// HTTPMiddleware wraps the transport of the client, eg: auth headers, signing, logging, metrics and retries.
+// NOTE: The request should not be modified, clone it before modifying (see http.RoundTripper).
+type HTTPMiddleware func(next http.RoundTripper) http.RoundTripper
+
+// HTTPRoundTripFunc the function of http.RoundTripper, eg:
+//
+//	func(next http.RoundTripper) http.RoundTripper {
+//		return yiigo.HTTPRoundTripFunc(func(req *http.Request) (*http.Response, error) {
+//			req = req.Clone(req.Context())
+//			req.Header.Set("X-Sign", sign(req))
+//
+//			return next.RoundTrip(req)
+//		})
+//	}
+type HTTPRoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f HTTPRoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// chainHTTPMiddlewares returns the transport wrapped by the middlewares, the first one is the outermost.
+func chainHTTPMiddlewares(transport http.RoundTripper, middlewares []HTTPMiddleware) http.RoundTripper {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		transport = middlewares[i](transport)
+	}
+
+	return transport
+}
+
+// HTTPHeaderMiddleware sets the header of the requests if not set, eg: the auth token.
+func HTTPHeaderMiddleware(key, value string) HTTPMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return HTTPRoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if len(req.Header.Get(key)) == 0 {
+				req = req.Clone(req.Context())
+				req.Header.Set(key, value)
+			}
+
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// HTTPLogMiddleware logs the requests with the log fields in ctx (see `LogFieldsFromCtx`),
+// the failures (errors and 5xx) are logged at error level, the others at info level.
+func HTTPLogMiddleware(l *zap.Logger) HTTPMiddleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return HTTPRoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			now := time.Now()
+
+			resp, err := next.RoundTrip(req)
+
+			fields := append(LogFieldsFromCtx(req.Context()),
+				zap.String("method", req.Method),
+				zap.String("url", req.URL.String()),
+				zap.String("duration", time.Since(now).String()),
+			)
+
+			if err != nil {
+				l.Error("http request failed", append(fields, zap.Error(err))...)
+
+				return nil, err
+			}
+
+			fields = append(fields, zap.Int("status", resp.StatusCode))
+
+			if resp.StatusCode >= http.StatusInternalServerError {
+				l.Error("http request failed", fields...)
+			} else {
+				l.Info("http request", fields...)
+			}
+
+			return resp, nil
+		})
+	}
+}
+
+// HTTPRetryMiddleware retries the idempotent requests (GET, HEAD, OPTIONS, PUT, DELETE and the ones with the
+// Idempotency-Key header) on the network errors and the status 429, 502, 503 and 504 up to attempts
+// (including the first one) with the exponential backoff (doubles per retry), default: 100ms.
+func HTTPRetryMiddleware(attempts int, backoff time.Duration) HTTPMiddleware {
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return HTTPRoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if attempts <= 1 || !idempotentHTTPRequest(req) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+				return next.RoundTrip(req)
+			}
+
+			for i := 1; ; i++ {
+				resp, err := next.RoundTrip(req)
+
+				if i >= attempts || !retryableHTTPResponse(req.Context(), resp, err) {
+					return resp, err
+				}
+
+				if resp != nil {
+					// reuse the connection
+					io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+				}
+
+				select {
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				case <-time.After(backoff << (i - 1)):
+				}
+
+				if req.GetBody != nil {
+					body, err := req.GetBody()
+
+					if err != nil {
+						return nil, err
+					}
+
+					req = req.Clone(req.Context())
+					req.Body = body
+				}
+			}
+		})
+	}
+}
+
+func idempotentHTTPRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return len(req.Header.Get("Idempotency-Key")) != 0
+}
+
+func retryableHTTPResponse(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
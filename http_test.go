@@ -1,9 +1,39 @@
 package yiigo
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestHTTPOption(t *testing.T) {
@@ -28,3 +58,683 @@ func TestHTTPOption(t *testing.T) {
 		close: true,
 	}, setting)
 }
+
+func TestHTTPMiddleware(t *testing.T) {
+	var calls int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// fails at the first call
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		b, _ := io.ReadAll(r.Body)
+
+		w.Write([]byte(r.Header.Get("Authorization") + "|" + r.Header.Get("X-Trace") + "|" + string(b)))
+	}))
+
+	defer srv.Close()
+
+	var order []string
+
+	mark := func(name string) HTTPMiddleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return HTTPRoundTripFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+
+				req = req.Clone(req.Context())
+				req.Header.Set("X-Trace", req.Header.Get("X-Trace")+name)
+
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	client := NewHTTPClient(&http.Client{})
+
+	client.(HTTPMiddlewareUser).Use(HTTPHeaderMiddleware("Authorization", "Bearer token"), mark("a"))
+	client.(HTTPMiddlewareUser).Use(HTTPRetryMiddleware(3, time.Millisecond), mark("b"))
+
+	resp, err := client.Do(context.TODO(), http.MethodPut, srv.URL, []byte("hello"))
+
+	assert.Nil(t, err)
+
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer token|ab|hello", string(b))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, []string{"a", "b", "b"}, order)
+
+	// the non-idempotent request is not retried
+	atomic.StoreInt32(&calls, 0)
+
+	resp, err = client.Do(context.TODO(), http.MethodPost, srv.URL, []byte("hello"))
+
+	assert.Nil(t, err)
+
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestHTTPLogMiddleware(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	defer srv.Close()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	client := NewHTTPClient(&http.Client{})
+	client.(HTTPMiddlewareUser).Use(HTTPLogMiddleware(zap.New(core)))
+
+	resp, err := client.Do(ContextWithRequestID(context.TODO(), "r1"), http.MethodGet, srv.URL+"/ping", nil)
+
+	assert.Nil(t, err)
+
+	resp.Body.Close()
+
+	entries := logs.TakeAll()
+
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, zapcore.ErrorLevel, entries[0].Level)
+	assert.Equal(t, "r1", entries[0].ContextMap()["request_id"])
+	assert.Equal(t, int64(500), entries[0].ContextMap()["status"])
+	assert.Equal(t, srv.URL+"/ping", entries[0].ContextMap()["url"])
+}
+
+func TestHTTPDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("yiigo"), 100<<10)
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "yiigo.bin", time.Time{}, bytes.NewReader(content))
+	}))
+
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	var written, total int64
+
+	n, err := HTTPDownload(context.TODO(), srv.URL, &buf,
+		WithDownloadChecksum(sha256.New(), checksum),
+		WithDownloadProgress(func(w, t int64) {
+			written, total = w, t
+		}),
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(content)), n)
+	assert.Equal(t, content, buf.Bytes())
+	assert.Equal(t, int64(len(content)), written)
+	assert.Equal(t, int64(len(content)), total)
+
+	// mismatch
+	_, err = HTTPDownload(context.TODO(), srv.URL, io.Discard, WithDownloadChecksum(sha256.New(), "00"))
+
+	assert.NotNil(t, err)
+
+	// the option is reused by the retries
+	opt := WithDownloadChecksum(sha256.New(), checksum)
+
+	for i := 0; i < 2; i++ {
+		_, err = HTTPDownload(context.TODO(), srv.URL, io.Discard, opt)
+
+		assert.Nil(t, err)
+	}
+}
+
+func TestHTTPDownloadFile(t *testing.T) {
+	content := bytes.Repeat([]byte("yiigo"), 100<<10)
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	var ranges []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+
+		http.ServeContent(w, r, "yiigo.bin", time.Time{}, bytes.NewReader(content))
+	}))
+
+	defer srv.Close()
+
+	filename := filepath.Join(t.TempDir(), "yiigo.bin")
+
+	// the partial file
+	assert.Nil(t, os.WriteFile(filename, content[:1000], 0644))
+
+	var written int64
+
+	n, err := HTTPDownloadFile(context.TODO(), srv.URL, filename,
+		WithDownloadChecksum(sha256.New(), checksum),
+		WithDownloadProgress(func(w, t int64) {
+			written = w
+		}),
+	)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(content)), n)
+	assert.Equal(t, int64(len(content)), written)
+
+	b, err := os.ReadFile(filename)
+
+	assert.Nil(t, err)
+	assert.Equal(t, content, b)
+
+	// downloaded
+	n, err = HTTPDownloadFile(context.TODO(), srv.URL, filename, WithDownloadChecksum(sha256.New(), checksum))
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(content)), n)
+	assert.Equal(t, []string{"bytes=1000-", fmt.Sprintf("bytes=%d-", len(content))}, ranges)
+
+	// the corrupted file is removed
+	assert.Nil(t, os.WriteFile(filename, []byte("corrupted"), 0644))
+
+	_, err = HTTPDownloadFile(context.TODO(), srv.URL, filename, WithDownloadChecksum(sha256.New(), checksum))
+
+	assert.NotNil(t, err)
+
+	_, err = os.Stat(filename)
+
+	assert.True(t, os.IsNotExist(err))
+
+	// starts over, the option is reused
+	opt := WithDownloadChecksum(sha256.New(), checksum)
+
+	n, err = HTTPDownloadFile(context.TODO(), srv.URL, filename, opt)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(content)), n)
+
+	n, err = HTTPDownloadFile(context.TODO(), srv.URL, filename, opt)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(content)), n)
+}
+
+func TestContentRange(t *testing.T) {
+	start, total := contentRange("bytes 100-199/200")
+
+	assert.Equal(t, int64(100), start)
+	assert.Equal(t, int64(200), total)
+
+	start, total = contentRange("bytes */200")
+
+	assert.Equal(t, int64(-1), start)
+	assert.Equal(t, int64(200), total)
+
+	start, total = contentRange("bytes 0-99/*")
+
+	assert.Equal(t, int64(0), start)
+	assert.Equal(t, int64(-1), total)
+}
+
+func TestHTTPUpload(t *testing.T) {
+	type received struct {
+		length  int64
+		chunked bool
+		files   map[string]string
+		fields  map[string]string
+	}
+
+	ch := make(chan *received, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ret := &received{
+			length:  r.ContentLength,
+			chunked: len(r.TransferEncoding) != 0,
+			files:   make(map[string]string),
+			fields:  make(map[string]string),
+		}
+
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		for name, headers := range r.MultipartForm.File {
+			f, _ := headers[0].Open()
+			b, _ := io.ReadAll(f)
+			f.Close()
+
+			ret.files[name] = headers[0].Filename + ":" + string(b)
+		}
+
+		for name, values := range r.MultipartForm.Value {
+			ret.fields[name] = values[0]
+		}
+
+		ch <- ret
+	}))
+
+	defer srv.Close()
+
+	client := NewHTTPClient(&http.Client{})
+
+	// the known size
+	form := NewUploadForm(
+		WithFormReader("a", "a.txt", strings.NewReader("hello"), 5),
+		WithFormReader("b", "b.txt", strings.NewReader("world"), 5),
+		WithFormField("title", "yiigo"),
+	)
+
+	resp, err := client.Upload(context.TODO(), srv.URL, form)
+
+	assert.Nil(t, err)
+
+	resp.Body.Close()
+
+	ret := <-ch
+
+	assert.False(t, ret.chunked)
+	assert.True(t, ret.length > 0)
+	assert.Equal(t, map[string]string{"a": "a.txt:hello", "b": "b.txt:world"}, ret.files)
+	assert.Equal(t, map[string]string{"title": "yiigo"}, ret.fields)
+
+	// the unknown size
+	form = NewUploadForm(
+		WithFormReader("a", "a.txt", strings.NewReader("hello"), -1),
+		WithFormFile("b", "b.txt", func(w io.Writer) error {
+			_, err := w.Write([]byte("world"))
+
+			return err
+		}),
+	)
+
+	resp, err = client.Upload(context.TODO(), srv.URL, form)
+
+	assert.Nil(t, err)
+
+	resp.Body.Close()
+
+	ret = <-ch
+
+	assert.True(t, ret.chunked)
+	assert.Equal(t, map[string]string{"a": "a.txt:hello", "b": "b.txt:world"}, ret.files)
+
+	// the form error
+	_, err = client.Upload(context.TODO(), srv.URL, NewUploadForm(WithFormField("title", "yiigo")))
+
+	assert.NotNil(t, err)
+}
+
+func TestHTTPMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/2" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	defer srv.Close()
+
+	metrics := NewHTTPMetrics("test")
+
+	client := NewHTTPClient(&http.Client{})
+	client.(HTTPMiddlewareUser).Use(HTTPMetricsMiddleware(metrics))
+
+	ctx := ContextWithHTTPRoute(context.TODO(), "/users/{id}")
+
+	for _, id := range []string{"1", "2", "3"} {
+		resp, err := client.Do(ctx, http.MethodGet, srv.URL+"/users/"+id, nil)
+
+		assert.Nil(t, err)
+
+		resp.Body.Close()
+	}
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	expected := fmt.Sprintf(`
+# HELP test_http_client_requests_total The number of the sent requests.
+# TYPE test_http_client_requests_total counter
+test_http_client_requests_total{host="%s",method="GET",route="/users/{id}",status="200"} 2
+test_http_client_requests_total{host="%s",method="GET",route="/users/{id}",status="404"} 1
+`, host, host)
+
+	assert.Nil(t, testutil.CollectAndCompare(metrics, strings.NewReader(expected), "test_http_client_requests_total"))
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics, "test_http_client_request_duration_seconds"))
+}
+
+func TestHTTPTracing(t *testing.T) {
+	var traceparent string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+
+		// the server side
+		sc := trace.SpanContextFromContext(ExtractHTTPTrace(context.TODO(), r.Header))
+
+		w.Write([]byte(sc.TraceID().String()))
+	}))
+
+	defer srv.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	client := NewHTTPClient(&http.Client{})
+	client.(HTTPMiddlewareUser).Use(HTTPTracingMiddleware(tp))
+
+	ctx, parent := tp.Tracer("test").Start(context.TODO(), "parent")
+
+	resp, err := client.Do(ContextWithHTTPRoute(ctx, "/ping"), http.MethodGet, srv.URL+"/ping?token=secret", nil)
+
+	assert.Nil(t, err)
+
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	parent.End()
+
+	spans := recorder.Ended()
+
+	assert.Len(t, spans, 2)
+
+	span := spans[0]
+
+	assert.Equal(t, "HTTP GET /ping", span.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	assert.Equal(t, span.SpanContext().TraceID().String(), string(b))
+	assert.Equal(t, fmt.Sprintf("00-%s-%s-01", span.SpanContext().TraceID(), span.SpanContext().SpanID()), traceparent)
+	assert.Contains(t, span.Attributes(), attribute.String("http.url", srv.URL+"/ping"))
+	assert.Contains(t, span.Attributes(), attribute.Int("http.status_code", 200))
+}
+
+func TestNewHTTPTransport(t *testing.T) {
+	tr, err := NewHTTPTransport(nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1000, tr.MaxIdleConnsPerHost)
+	assert.Equal(t, 60*time.Second, tr.IdleConnTimeout)
+	assert.True(t, tr.TLSClientConfig.InsecureSkipVerify)
+	assert.False(t, tr.ForceAttemptHTTP2)
+
+	tr, err = NewHTTPTransport(&HTTPClientConfig{
+		Proxy:               "socks5://127.0.0.1:1080",
+		MaxIdleConnsPerHost: 10,
+		HTTP2:               true,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 10, tr.MaxIdleConnsPerHost)
+	assert.True(t, tr.ForceAttemptHTTP2)
+
+	proxy, err := tr.Proxy(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "socks5://127.0.0.1:1080", proxy.String())
+
+	_, err = NewHTTPTransport(&HTTPClientConfig{Proxy: "ftp://127.0.0.1:21"})
+
+	assert.NotNil(t, err)
+}
+
+func TestDefaultHTTPMiddleware(t *testing.T) {
+	client, middlewares := defaultHTTP(), defaultHTTPMiddlewares
+
+	defer func() {
+		defaultHTTPClient, defaultHTTPMiddlewares = client, middlewares
+		httpClientMap.Delete(Default)
+	}()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Client") + "|" + r.Header.Get("X-Default")))
+	}))
+
+	defer srv.Close()
+
+	UseHTTPMiddleware(HTTPHeaderMiddleware("X-Default", "yes"))
+
+	// the default client is replaced, the middlewares are carried over
+	initHTTPClient(Default, &HTTPClientConfig{
+		Middlewares: []HTTPMiddleware{HTTPHeaderMiddleware("X-Client", "configured")},
+	})
+
+	resp, err := HTTPGet(context.TODO(), srv.URL)
+
+	assert.Nil(t, err)
+
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	assert.Equal(t, "configured|yes", string(b))
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	// the http proxy receives the absolute url
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("proxy:" + r.URL.String()))
+	}))
+
+	defer proxy.Close()
+
+	Init(WithHTTPClient("proxy", &HTTPClientConfig{
+		Proxy:       proxy.URL,
+		Middlewares: []HTTPMiddleware{HTTPHeaderMiddleware("X-Client", "proxy")},
+	}))
+
+	defer httpClientMap.Delete("proxy")
+
+	resp, err := HTTP("proxy").Do(context.TODO(), http.MethodGet, "http://example.com/ping", nil)
+
+	assert.Nil(t, err)
+
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	assert.Equal(t, "proxy:http://example.com/ping", string(b))
+	assert.Equal(t, defaultHTTP(), HTTP())
+	assert.Panics(t, func() { HTTP("none") })
+}
+
+func newTestCert(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	assert.Nil(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+
+	assert.Nil(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+
+	assert.Nil(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+
+	assert.Nil(t, err)
+
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestHTTPMutualTLS(t *testing.T) {
+	ca, caKey, caPEM, _ := newTestCert(t, "ca", nil, nil)
+	_, _, srvCert, srvKey := newTestCert(t, "server", ca, caKey)
+	_, _, cliCert, cliKey := newTestCert(t, "client-1", ca, caKey)
+
+	pair, err := tls.X509KeyPair(srvCert, srvKey)
+
+	assert.Nil(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caPEM)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+
+	srv.StartTLS()
+
+	defer srv.Close()
+
+	dir := t.TempDir()
+
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	caFile := filepath.Join(dir, "ca.crt")
+
+	assert.Nil(t, os.WriteFile(certFile, cliCert, 0644))
+	assert.Nil(t, os.WriteFile(keyFile, cliKey, 0600))
+	assert.Nil(t, os.WriteFile(caFile, caPEM, 0644))
+
+	tr, err := NewHTTPTransport(&HTTPClientConfig{
+		TLS: &HTTPTLSConfig{
+			CertFile:       certFile,
+			KeyFile:        keyFile,
+			CAFile:         caFile,
+			ReloadInterval: time.Millisecond,
+		},
+	})
+
+	assert.Nil(t, err)
+
+	client := NewHTTPClient(&http.Client{Transport: tr})
+
+	get := func() (string, error) {
+		resp, err := client.Do(context.TODO(), http.MethodGet, srv.URL, nil)
+
+		if err != nil {
+			return "", err
+		}
+
+		defer resp.Body.Close()
+
+		b, err := io.ReadAll(resp.Body)
+
+		return string(b), err
+	}
+
+	cn, err := get()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "client-1", cn)
+
+	// rotate
+	_, _, cliCert, cliKey = newTestCert(t, "client-2", ca, caKey)
+
+	assert.Nil(t, os.WriteFile(certFile, cliCert, 0644))
+	assert.Nil(t, os.WriteFile(keyFile, cliKey, 0600))
+
+	later := time.Now().Add(time.Minute)
+
+	assert.Nil(t, os.Chtimes(certFile, later, later))
+
+	time.Sleep(2 * time.Millisecond)
+
+	// the new connection
+	tr.CloseIdleConnections()
+
+	cn, err = get()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "client-2", cn)
+
+	// the PEM
+	tr, err = NewHTTPTransport(&HTTPClientConfig{
+		TLS: &HTTPTLSConfig{
+			CertPEM: cliCert,
+			KeyPEM:  cliKey,
+			CAPEM:   caPEM,
+		},
+	})
+
+	assert.Nil(t, err)
+
+	client = NewHTTPClient(&http.Client{Transport: tr})
+
+	cn, err = get()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "client-2", cn)
+
+	// the server name is verified
+	assert.Nil(t, os.WriteFile(caFile, caPEM, 0644))
+
+	tr, err = NewHTTPTransport(&HTTPClientConfig{
+		TLS: &HTTPTLSConfig{
+			CertPEM:    cliCert,
+			KeyPEM:     cliKey,
+			CAFile:     caFile,
+			ServerName: "example.com",
+		},
+	})
+
+	assert.Nil(t, err)
+
+	client = NewHTTPClient(&http.Client{Transport: tr})
+
+	_, err = get()
+
+	assert.NotNil(t, err)
+
+	// the IP without the ServerName can't be verified by the config only
+	c, err := NewTLSConfig(&HTTPTLSConfig{CertPEM: cliCert, KeyPEM: cliKey, CAFile: caFile})
+
+	assert.Nil(t, err)
+
+	client = NewHTTPClient(&http.Client{Transport: &http.Transport{TLSClientConfig: c}})
+
+	_, err = get()
+
+	assert.NotNil(t, err)
+
+	// the untrusted server
+	_, err = NewTLSConfig(&HTTPTLSConfig{CAPEM: []byte("invalid")})
+
+	assert.NotNil(t, err)
+
+	_, _, otherPEM, _ := newTestCert(t, "other", nil, nil)
+
+	assert.Nil(t, os.WriteFile(caFile, otherPEM, 0644))
+
+	tr, err = NewHTTPTransport(&HTTPClientConfig{
+		TLS: &HTTPTLSConfig{
+			CertPEM: cliCert,
+			KeyPEM:  cliKey,
+			CAFile:  caFile,
+		},
+	})
+
+	assert.Nil(t, err)
+
+	client = NewHTTPClient(&http.Client{Transport: tr})
+
+	_, err = get()
+
+	assert.NotNil(t, err)
+}
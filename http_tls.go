@@ -0,0 +1,310 @@
+package yiigo
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// HTTPTLSConfig keeps the settings of the mTLS (the client certificate and the CA pool), eg:
+//
+//	TLS: &yiigo.HTTPTLSConfig{
+//		CertFile: "/etc/certs/client.crt",
+//		KeyFile:  "/etc/certs/client.key",
+//		CAFile:   "/etc/certs/ca.crt",
+//	}
+//
+// The files are reloaded when they are modified (eg: the rotation of the k8s secret), which takes effect on the new connections.
+type HTTPTLSConfig struct {
+	// CertFile and KeyFile the client certificate and key (PEM) files, which take precedence over CertPEM and KeyPEM.
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+
+	// CertPEM and KeyPEM the client certificate and key (PEM).
+	CertPEM []byte `json:"-"`
+	KeyPEM  []byte `json:"-"`
+
+	// CAFile the CA certificates (PEM) file to verify the server, which takes precedence over CAPEM, default: the system pool.
+	CAFile string `json:"ca_file"`
+
+	// CAPEM the CA certificates (PEM) to verify the server.
+	CAPEM []byte `json:"-"`
+
+	// ServerName the server name to verify the server (and the SNI), default: the host of the request.
+	ServerName string `json:"server_name"`
+
+	// InsecureSkipVerify skips verifying the server.
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+
+	// ReloadInterval the min interval of checking the modification of the files, default: 1m.
+	ReloadInterval time.Duration `json:"reload_interval"`
+}
+
+// NewTLSConfig returns the tls config of the client with the client certificate and the CA pool.
+// NOTE: With the CAFile, the server is verified by the ServerName (or the SNI), so specify the ServerName to connect by IP,
+// or use `NewHTTPTransport` which verifies the server by the dialed host (the IP SANs for the IP).
+func NewTLSConfig(cfg *HTTPTLSConfig) (*tls.Config, error) {
+	c, _, err := newTLSConfig(cfg)
+
+	return c, err
+}
+
+// newTLSConfig returns the tls config, and the reloader if the server is verified by the reloaded pool.
+func newTLSConfig(cfg *HTTPTLSConfig) (*tls.Config, *tlsReloader, error) {
+	r := &tlsReloader{
+		cfg:      cfg,
+		modTimes: make(map[string]time.Time),
+		interval: cfg.ReloadInterval,
+	}
+
+	if r.interval <= 0 {
+		r.interval = time.Minute
+	}
+
+	if err := r.load(); err != nil {
+		return nil, nil, err
+	}
+
+	c := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if r.cert != nil {
+		c.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			r.reload()
+
+			r.mutex.RLock()
+			defer r.mutex.RUnlock()
+
+			return r.cert, nil
+		}
+	}
+
+	if r.pool == nil || cfg.InsecureSkipVerify {
+		return c, nil, nil
+	}
+
+	if len(cfg.CAFile) == 0 {
+		c.RootCAs = r.pool
+
+		return c, nil, nil
+	}
+
+	// NOTE: RootCAs can't be changed after the config is used, so the server is verified by the reloaded pool manually.
+	c.InsecureSkipVerify = true
+	c.VerifyConnection = func(cs tls.ConnectionState) error {
+		return r.verify(cs, cs.ServerName)
+	}
+
+	return c, r, nil
+}
+
+type tlsReloader struct {
+	cfg      *HTTPTLSConfig
+	interval time.Duration
+	cert     *tls.Certificate
+	pool     *x509.CertPool
+	modTimes map[string]time.Time
+	checked  time.Time
+	mutex    sync.RWMutex
+}
+
+// load loads the certificate and the pool, the previous ones are kept if failed.
+func (r *tlsReloader) load() error {
+	modTimes := make(map[string]time.Time)
+
+	readFile := func(filename string) ([]byte, error) {
+		fi, err := os.Stat(filename)
+
+		if err != nil {
+			return nil, err
+		}
+
+		modTimes[filename] = fi.ModTime()
+
+		return os.ReadFile(filename)
+	}
+
+	var (
+		cert *tls.Certificate
+		pool *x509.CertPool
+	)
+
+	certPEM, keyPEM := r.cfg.CertPEM, r.cfg.KeyPEM
+
+	if len(r.cfg.CertFile) != 0 || len(r.cfg.KeyFile) != 0 {
+		var err error
+
+		if certPEM, err = readFile(r.cfg.CertFile); err != nil {
+			return fmt.Errorf("read cert: %w", err)
+		}
+
+		if keyPEM, err = readFile(r.cfg.KeyFile); err != nil {
+			return fmt.Errorf("read key: %w", err)
+		}
+	}
+
+	if len(certPEM) != 0 || len(keyPEM) != 0 {
+		pair, err := tls.X509KeyPair(certPEM, keyPEM)
+
+		if err != nil {
+			return fmt.Errorf("load cert: %w", err)
+		}
+
+		cert = &pair
+	}
+
+	caPEM := r.cfg.CAPEM
+
+	if len(r.cfg.CAFile) != 0 {
+		var err error
+
+		if caPEM, err = readFile(r.cfg.CAFile); err != nil {
+			return fmt.Errorf("read ca: %w", err)
+		}
+	}
+
+	if len(caPEM) != 0 {
+		pool = x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return errors.New("load ca: no valid certificates")
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.cert, r.pool, r.modTimes = cert, pool, modTimes
+
+	return nil
+}
+
+// reload reloads the files if modified, at most once per interval.
+func (r *tlsReloader) reload() {
+	r.mutex.Lock()
+
+	if len(r.modTimes) == 0 || time.Since(r.checked) < r.interval {
+		r.mutex.Unlock()
+
+		return
+	}
+
+	r.checked = time.Now()
+
+	modified := false
+
+	for filename, modTime := range r.modTimes {
+		if fi, err := os.Stat(filename); err == nil && !fi.ModTime().Equal(modTime) {
+			modified = true
+
+			break
+		}
+	}
+
+	r.mutex.Unlock()
+
+	if !modified {
+		return
+	}
+
+	// eg: the key is not written yet, retried at the next interval
+	if err := r.load(); err != nil {
+		logger.Error("err tls reload", zap.Error(err))
+
+		return
+	}
+
+	logger.Info("tls certificates reloaded")
+}
+
+// verify verifies the server by the reloaded pool and the name (the ServerName takes precedence),
+// which is the host name or the IP (verified by the IP SANs).
+func (r *tlsReloader) verify(cs tls.ConnectionState, name string) error {
+	r.reload()
+
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("no server certificates")
+	}
+
+	if len(r.cfg.ServerName) != 0 {
+		name = r.cfg.ServerName
+	}
+
+	// the SNI is empty for the IP
+	if len(name) == 0 {
+		return errors.New("no server name to verify, specify the ServerName")
+	}
+
+	r.mutex.RLock()
+	pool := r.pool
+	r.mutex.RUnlock()
+
+	opts := x509.VerifyOptions{
+		DNSName:       name,
+		Roots:         pool,
+		Intermediates: x509.NewCertPool(),
+	}
+
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(opts)
+
+	return err
+}
+
+// dialTLS returns the DialTLSContext of the transport, which verifies the server by the dialed host.
+func (r *tlsReloader) dialTLS(c *tls.Config, dial func(ctx context.Context, network, addr string) (net.Conn, error), timeout time.Duration) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := dial(ctx, network, addr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// the config is cloned when dialing, which has the NextProtos set by the transport
+		cfg := c.Clone()
+
+		if len(cfg.ServerName) == 0 {
+			cfg.ServerName = host
+		}
+
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return r.verify(cs, host)
+		}
+
+		if timeout > 0 {
+			var cancel context.CancelFunc
+
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		tlsConn := tls.Client(conn, cfg)
+
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+
+			return nil, err
+		}
+
+		return tlsConn, nil
+	}
+}
@@ -0,0 +1,92 @@
+package yiigo
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// httpPropagator propagates the W3C trace context (traceparent, tracestate) and baggage.
+var httpPropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// HTTPTracingMiddleware records the OpenTelemetry span of the requests with the attributes:
+// http.method, http.url (without the query), net.peer.name, http.route and http.status_code,
+// and injects the W3C traceparent header of the span, the tracer provider defaults to otel.GetTracerProvider().
+// The span is the child of the span in the request context.
+func HTTPTracingMiddleware(tp ...trace.TracerProvider) HTTPMiddleware {
+	provider := otel.GetTracerProvider()
+
+	if len(tp) != 0 && tp[0] != nil {
+		provider = tp[0]
+	}
+
+	tracer := provider.Tracer(tracerName)
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return HTTPRoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			route := httpRouteFromCtx(req.Context())
+
+			name := "HTTP " + req.Method
+
+			if len(route) != 0 {
+				name += " " + route
+			}
+
+			// the query may contain the secrets
+			u := *req.URL
+			u.RawQuery, u.Fragment, u.User = "", "", nil
+
+			ctx, span := tracer.Start(req.Context(), name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.HTTPMethod(req.Method),
+					semconv.HTTPURL(u.String()),
+					semconv.NetPeerName(req.URL.Hostname()),
+				),
+			)
+
+			defer span.End()
+
+			if len(route) != 0 {
+				span.SetAttributes(semconv.HTTPRoute(route))
+			}
+
+			req = req.Clone(ctx)
+
+			InjectHTTPTrace(ctx, req.Header)
+
+			resp, err := next.RoundTrip(req)
+
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+
+				return nil, err
+			}
+
+			span.SetAttributes(semconv.HTTPStatusCode(resp.StatusCode))
+
+			if resp.StatusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, resp.Status)
+			}
+
+			return resp, nil
+		})
+	}
+}
+
+// InjectHTTPTrace injects the W3C trace context (traceparent, tracestate) and baggage of ctx into the header.
+func InjectHTTPTrace(ctx context.Context, header http.Header) {
+	httpPropagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// ExtractHTTPTrace returns a copy of ctx with the remote span context extracted from the header (eg: the inbound request),
+// so the spans started by ctx are the children of the caller, eg: ctx := yiigo.ExtractHTTPTrace(r.Context(), r.Header)
+func ExtractHTTPTrace(ctx context.Context, header http.Header) context.Context {
+	return httpPropagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
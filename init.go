@@ -42,11 +42,12 @@ func WithSQLite(name string, cfg *DBConfig) InitOption {
 // WithMongo register mongodb.
 // [DSN] mongodb://localhost:27017/?connectTimeoutMS=10000&minPoolSize=10&maxPoolSize=20&maxIdleTimeMS=60000&readPreference=primary
 // [Reference] https://docs.mongodb.com/manual/reference/connection-string
-func WithMongo(name string, dsn string) InitOption {
+// [Options] eg: yiigo.WithMongoTracing(), yiigo.WithMongoMetrics(metrics), yiigo.WithMongoDecimal()
+func WithMongo(name string, dsn string, options ...MongoOption) InitOption {
 	return func(wg *sync.WaitGroup) {
 		defer wg.Done()
 
-		initMongoDB(name, dsn)
+		initMongoDB(name, dsn, options...)
 	}
 }
 
@@ -99,6 +100,24 @@ func WithWebsocket(upgrader *websocket.Upgrader) InitOption {
 	}
 }
 
+// WithHTTPClient register http client, the default one is used by HTTPGet, HTTPPost etc.
+func WithHTTPClient(name string, cfg *HTTPClientConfig) InitOption {
+	return func(wg *sync.WaitGroup) {
+		defer wg.Done()
+
+		initHTTPClient(name, cfg)
+	}
+}
+
+// WithAudit specifies the sinks of the audit records, see `Audit`.
+func WithAudit(sinks ...AuditSink) InitOption {
+	return func(wg *sync.WaitGroup) {
+		defer wg.Done()
+
+		auditSinks = sinks
+	}
+}
+
 // Init yiigo initialization.
 func Init(options ...InitOption) {
 	var wg sync.WaitGroup
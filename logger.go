@@ -1,7 +1,11 @@
 package yiigo
 
 import (
+	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,8 +15,14 @@ import (
 )
 
 var (
-	logger = debugLogger()
+	logger = debugLogger(logLevel)
 	logMap sync.Map
+
+	// the level of the default debug logger and the base level of the registered loggers
+	logLevel = zap.NewAtomicLevelAt(zap.DebugLevel)
+
+	// the levels of the registered loggers, which can be changed at runtime
+	levelMap sync.Map
 )
 
 // LoggerConfig keeps the settings to configure logger.
@@ -46,48 +56,166 @@ type LoggerOptions struct {
 	// using gzip. The default is not to perform compression.
 	Compress bool `json:"compress"`
 
+	// Daily rotates the log file at midnight (in the timezone, see `SetTimezone`),
+	// as well as by MaxSize.
+	Daily bool `json:"daily"`
+
 	// Stderr specifies the stderr for logger
 	Stderr bool `json:"stderr"`
 
+	// Level the initial level of the logger, eg: warn to silence the noisy module,
+	// default: the level of all the loggers (see `SetLogLevel`).
+	Level string `json:"level"`
+
+	// Sinks the extra outputs with the independent encoders and levels (see `LogSink`),
+	// eg: console to stdout and errors to syslog.
+	Sinks []*LogSink `json:"sinks"`
+
+	// Mask masks the sensitive fields before encoding, see `LogMask`.
+	Mask *LogMask `json:"mask"`
+
+	// Alert forwards the error entries to the webhook, see `LogAlert`.
+	Alert *LogAlert `json:"alert"`
+
 	// ZapOptions specifies the zap options stderr for logger
 	ZapOptions []zap.Option `json:"zap_options"`
 }
 
 // newLogger returns a new logger.
-func newLogger(cfg *LoggerConfig) *zap.Logger {
-	if len(cfg.Filename) == 0 {
-		return debugLogger(cfg.Options.ZapOptions...)
+func newLogger(cfg *LoggerConfig, level zap.AtomicLevel) *zap.Logger {
+	masker := newLogMasker(cfg.Options.Mask)
+
+	l := buildLogger(cfg, level, masker)
+
+	if cfg.Options.Alert != nil {
+		core, err := newLogAlertCore(cfg.Options.Alert, level)
+
+		if err != nil {
+			logger.Panic("err logger alert", zap.Error(err))
+		}
+
+		l = l.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(c, masker.wrap(core))
+		}))
 	}
 
+	return l
+}
+
+// buildLogger builds the logger, each core is masked by the masker (not the tee, which writes to all the cores).
+func buildLogger(cfg *LoggerConfig, level zap.AtomicLevel, masker *logMasker) *zap.Logger {
+	if len(cfg.Filename) == 0 && len(cfg.Options.Sinks) == 0 {
+		options := cfg.Options.ZapOptions
+
+		if masker != nil {
+			options = append([]zap.Option{zap.WrapCore(masker.wrap)}, options...)
+		}
+
+		return debugLogger(level, options...)
+	}
+
+	cores := make([]zapcore.Core, 0, len(cfg.Options.Sinks)+1)
+
+	if len(cfg.Filename) != 0 {
+		ws := make([]zapcore.WriteSyncer, 0, 2)
+
+		ws = append(ws, zapcore.AddSync(newRotateWriter(cfg.Filename, cfg.Options)))
+
+		if cfg.Options.Stderr {
+			ws = append(ws, zapcore.Lock(os.Stderr))
+		}
+
+		cores = append(cores, masker.wrap(zapcore.NewCore(zapcore.NewJSONEncoder(logEncoderConfig()), zapcore.NewMultiWriteSyncer(ws...), level)))
+	}
+
+	for _, sink := range cfg.Options.Sinks {
+		core, err := sink.core(cfg.Options, level)
+
+		if err != nil {
+			logger.Panic("err logger sink", zap.String("output", sink.Output), zap.Error(err))
+		}
+
+		cores = append(cores, masker.wrap(core))
+	}
+
+	return zap.New(zapcore.NewTee(cores...), cfg.Options.ZapOptions...)
+}
+
+func logEncoderConfig() zapcore.EncoderConfig {
 	c := zap.NewProductionEncoderConfig()
 
 	c.TimeKey = "time"
 	c.EncodeTime = MyTimeEncoder
 	c.EncodeCaller = zapcore.FullCallerEncoder
 
-	ws := make([]zapcore.WriteSyncer, 0, 2)
+	return c
+}
 
-	ws = append(ws, zapcore.AddSync(&lumberjack.Logger{
-		Filename:   cfg.Filename,
-		MaxSize:    cfg.Options.MaxSize,
-		MaxAge:     cfg.Options.MaxAge,
-		MaxBackups: cfg.Options.MaxBackups,
-		Compress:   cfg.Options.Compress,
+// newRotateWriter returns the writer of the log file, which is rotated by the options.
+func newRotateWriter(filename string, opts *LoggerOptions) io.Writer {
+	l := &lumberjack.Logger{
+		Filename:   filename,
+		MaxSize:    opts.MaxSize,
+		MaxAge:     opts.MaxAge,
+		MaxBackups: opts.MaxBackups,
+		Compress:   opts.Compress,
 		LocalTime:  true,
-	}))
+	}
+
+	if opts.Daily {
+		return newDailyWriter(l)
+	}
 
-	if cfg.Options.Stderr {
-		ws = append(ws, zapcore.Lock(os.Stderr))
+	return l
+}
+
+// dailyWriter rotates the log file at midnight.
+type dailyWriter struct {
+	logger *lumberjack.Logger
+	next   time.Time
+	mutex  sync.Mutex
+}
+
+func newDailyWriter(l *lumberjack.Logger) *dailyWriter {
+	w := &dailyWriter{
+		logger: l,
+		next:   nextMidnight(time.Now()),
 	}
 
-	core := zapcore.NewCore(zapcore.NewJSONEncoder(c), zapcore.NewMultiWriteSyncer(ws...), zap.DebugLevel)
+	// the existing log file of the previous days is rotated at the first write
+	if fi, err := os.Stat(l.Filename); err == nil {
+		w.next = nextMidnight(fi.ModTime())
+	}
 
-	return zap.New(core, cfg.Options.ZapOptions...)
+	return w
 }
 
-func debugLogger(options ...zap.Option) *zap.Logger {
+func (w *dailyWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+
+	if now := time.Now(); !now.Before(w.next) {
+		if err := w.logger.Rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "err log rotate: %v\n", err)
+		}
+
+		w.next = nextMidnight(now)
+	}
+
+	w.mutex.Unlock()
+
+	return w.logger.Write(p)
+}
+
+func nextMidnight(t time.Time) time.Time {
+	t = t.In(timezone)
+
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, timezone)
+}
+
+func debugLogger(level zap.AtomicLevel, options ...zap.Option) *zap.Logger {
 	cfg := zap.NewDevelopmentConfig()
 
+	cfg.Level = level
 	cfg.DisableCaller = true
 	cfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	cfg.EncoderConfig.EncodeTime = MyTimeEncoder
@@ -103,28 +231,134 @@ func initLogger(name string, cfg *LoggerConfig) {
 		cfg.Options = new(LoggerOptions)
 	}
 
-	l := newLogger(cfg)
+	level := zap.NewAtomicLevelAt(logLevel.Level())
+
+	if len(cfg.Options.Level) != 0 {
+		if err := level.UnmarshalText([]byte(cfg.Options.Level)); err != nil {
+			logger.Panic("err logger level", zap.String("name", name), zap.Error(err))
+		}
+	}
+
+	l := newLogger(cfg, level)
 
 	if name == Default {
 		logger = l
+	} else {
+		// eg: {"logger": "db"}
+		l = l.Named(name)
 	}
 
 	logMap.Store(name, l)
+	levelMap.Store(name, level)
 }
 
-// Logger returns a logger
+// Logger returns a logger, the hierarchical name (separated by dots) falls back to the nearest registered parent, eg:
+// Logger("db.mysql") returns the child of the "db" logger named "db.mysql" if not registered, which shares the
+// configuration and level of the parent. The default logger is returned if none registered.
 func Logger(name ...string) *zap.Logger {
 	if len(name) == 0 || name[0] == Default {
 		return logger
 	}
 
-	v, ok := logMap.Load(name[0])
+	if v, ok := logMap.Load(name[0]); ok {
+		return v.(*zap.Logger)
+	}
+
+	parent := name[0]
+
+	for i := strings.LastIndex(parent, "."); i > 0; i = strings.LastIndex(parent, ".") {
+		parent = parent[:i]
+
+		if v, ok := logMap.Load(parent); ok {
+			return v.(*zap.Logger).Named(strings.TrimPrefix(name[0], parent+"."))
+		}
+	}
+
+	return logger
+}
+
+// SetLogLevel changes the level of all the loggers at runtime, eg: debug, info, warn, error, default: debug.
+func SetLogLevel(level string) error {
+	l, err := zapcore.ParseLevel(level)
+
+	if err != nil {
+		return err
+	}
+
+	logLevel.SetLevel(l)
+
+	levelMap.Range(func(key, value any) bool {
+		value.(zap.AtomicLevel).SetLevel(l)
+
+		return true
+	})
+
+	return nil
+}
+
+// SetLoggerLevel changes the level of the named logger at runtime, eg: yiigo.SetLoggerLevel("other", "debug")
+func SetLoggerLevel(name, level string) error {
+	l, err := zapcore.ParseLevel(level)
+
+	if err != nil {
+		return err
+	}
+
+	v, ok := loggerLevel(name)
 
 	if !ok {
-		return logger
+		return fmt.Errorf("unknown logger.%s (forgotten configure?)", name)
+	}
+
+	v.SetLevel(l)
+
+	return nil
+}
+
+// LoggerLevel returns the level of the named logger, eg: yiigo.LoggerLevel(yiigo.Default)
+func LoggerLevel(name string) (zapcore.Level, bool) {
+	v, ok := loggerLevel(name)
+
+	if !ok {
+		return zapcore.InvalidLevel, false
+	}
+
+	return v.Level(), true
+}
+
+// LogLevelHandler returns the http handler which gets (GET) and changes (PUT) the level of the named logger, eg:
+//
+//	http.Handle("/log/level", yiigo.LogLevelHandler(yiigo.Default))
+//
+//	curl -X PUT localhost:8000/log/level -d '{"level":"debug"}'
+//	curl -X PUT localhost:8000/log/level -d level=debug
+//
+// See zap.AtomicLevel.ServeHTTP for details.
+func LogLevelHandler(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v, ok := loggerLevel(name)
+
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown logger.%s", name), http.StatusNotFound)
+
+			return
+		}
+
+		v.ServeHTTP(w, r)
+	})
+}
+
+// loggerLevel returns the level of the named logger, the default debug logger if not configured.
+func loggerLevel(name string) (zap.AtomicLevel, bool) {
+	if v, ok := levelMap.Load(name); ok {
+		return v.(zap.AtomicLevel), true
+	}
+
+	if name == Default {
+		return logLevel, true
 	}
 
-	return v.(*zap.Logger)
+	return zap.AtomicLevel{}, false
 }
 
 // MyTimeEncoder zap time encoder.
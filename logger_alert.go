@@ -0,0 +1,419 @@
+package yiigo
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogAlert forwards the error entries of the logger to the notifier, which are debounced and batched, eg:
+//
+//	Alert: &yiigo.LogAlert{
+//		Title:    "[order-service] error",
+//		Notifier: yiigo.DingTalkNotifier("https://oapi.dingtalk.com/robot/send?access_token=xxx", "SECxxx"),
+//	}
+type LogAlert struct {
+	// Title the title of the alert, eg: the service name.
+	Title string `json:"title"`
+
+	// Notifier sends the alert, eg: `WebhookNotifier`, `DingTalkNotifier`, `FeishuNotifier` and `SlackNotifier`.
+	Notifier LogAlertNotifier `json:"-"`
+
+	// Level the min level of the entries, default: error.
+	Level string `json:"level"`
+
+	// Interval the entries within the interval (since the first one) are sent in one alert, default: 10s.
+	Interval time.Duration `json:"interval"`
+
+	// MaxBatch the max entries of one alert, the rest are counted only, default: 10.
+	MaxBatch int `json:"max_batch"`
+}
+
+// LogAlertEntry the log entry of the alert.
+type LogAlertEntry struct {
+	Time    time.Time      `json:"time"`
+	Level   string         `json:"level"`
+	Logger  string         `json:"logger,omitempty"`
+	Message string         `json:"message"`
+	Caller  string         `json:"caller,omitempty"`
+	Fields  map[string]any `json:"fields,omitempty"`
+}
+
+// LogAlertNotifier sends the alert of the entries, the dropped is the number of the entries beyond `MaxBatch`.
+type LogAlertNotifier interface {
+	Notify(ctx context.Context, title string, entries []*LogAlertEntry, dropped int) error
+}
+
+// LogAlertNotifyFunc the function of LogAlertNotifier.
+type LogAlertNotifyFunc func(ctx context.Context, title string, entries []*LogAlertEntry, dropped int) error
+
+// Notify calls f(ctx, title, entries, dropped).
+func (f LogAlertNotifyFunc) Notify(ctx context.Context, title string, entries []*LogAlertEntry, dropped int) error {
+	return f(ctx, title, entries, dropped)
+}
+
+// WebhookNotifier posts the alert as json to the webhook, eg: {"title": "...", "entries": [...], "dropped": 0}
+func WebhookNotifier(webhook string, options ...HTTPOption) LogAlertNotifier {
+	return LogAlertNotifyFunc(func(ctx context.Context, title string, entries []*LogAlertEntry, dropped int) error {
+		return postAlert(ctx, webhook, map[string]any{
+			"title":   title,
+			"entries": entries,
+			"dropped": dropped,
+		}, options...)
+	})
+}
+
+// DingTalkNotifier sends the alert by the DingTalk robot, the secret is optional (the sign of the security settings).
+func DingTalkNotifier(webhook, secret string) LogAlertNotifier {
+	return LogAlertNotifyFunc(func(ctx context.Context, title string, entries []*LogAlertEntry, dropped int) error {
+		reqURL := webhook
+
+		if len(secret) != 0 {
+			timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+			h := hmac.New(sha256.New, []byte(secret))
+			h.Write([]byte(timestamp + "\n" + secret))
+
+			query := url.Values{}
+
+			query.Set("timestamp", timestamp)
+			query.Set("sign", base64.StdEncoding.EncodeToString(h.Sum(nil)))
+
+			if strings.Contains(reqURL, "?") {
+				reqURL += "&" + query.Encode()
+			} else {
+				reqURL += "?" + query.Encode()
+			}
+		}
+
+		return postAlert(ctx, reqURL, map[string]any{
+			"msgtype": "text",
+			"text":    map[string]string{"content": alertText(title, entries, dropped)},
+		})
+	})
+}
+
+// FeishuNotifier sends the alert by the Feishu (Lark) bot, the secret is optional (the signature of the security settings).
+func FeishuNotifier(webhook, secret string) LogAlertNotifier {
+	return LogAlertNotifyFunc(func(ctx context.Context, title string, entries []*LogAlertEntry, dropped int) error {
+		body := map[string]any{
+			"msg_type": "text",
+			"content":  map[string]string{"text": alertText(title, entries, dropped)},
+		}
+
+		if len(secret) != 0 {
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+			h := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+
+			body["timestamp"] = timestamp
+			body["sign"] = base64.StdEncoding.EncodeToString(h.Sum(nil))
+		}
+
+		return postAlert(ctx, webhook, body)
+	})
+}
+
+// SlackNotifier sends the alert by the Slack incoming webhook.
+func SlackNotifier(webhook string) LogAlertNotifier {
+	return LogAlertNotifyFunc(func(ctx context.Context, title string, entries []*LogAlertEntry, dropped int) error {
+		return postAlert(ctx, webhook, map[string]string{"text": alertText(title, entries, dropped)})
+	})
+}
+
+// alertHTTPClient is dedicated to the alerts without the middlewares of the default client (eg: logging, tracing),
+// so the alert doesn't trigger another alert by the logged error, and the default client being replaced doesn't matter.
+var alertHTTPClient = NewDefaultHTTPClient()
+
+func postAlert(ctx context.Context, reqURL string, body any, options ...HTTPOption) error {
+	b, err := json.Marshal(body)
+
+	if err != nil {
+		return err
+	}
+
+	options = append(options, WithHTTPHeader("Content-Type", "application/json; charset=utf-8"))
+
+	resp, err := alertHTTPClient.Do(ctx, http.MethodPost, reqURL, b, options...)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		b, _ = io.ReadAll(resp.Body)
+
+		return fmt.Errorf("unexpected http status %d: %s", resp.StatusCode, b)
+	}
+
+	return nil
+}
+
+// alertText returns the text of the alert, eg:
+//
+//	[order-service] error
+//	2023-01-02 15:04:05 ERROR pay failed {"order_id":1} (pay.go:42)
+//	... and 3 more
+func alertText(title string, entries []*LogAlertEntry, dropped int) string {
+	var builder strings.Builder
+
+	if len(title) != 0 {
+		builder.WriteString(title)
+		builder.WriteString("\n")
+	}
+
+	for _, e := range entries {
+		builder.WriteString(e.Time.In(timezone).Format(layouttime))
+		builder.WriteString(" ")
+		builder.WriteString(strings.ToUpper(e.Level))
+		builder.WriteString(" ")
+		builder.WriteString(e.Message)
+
+		if len(e.Fields) != 0 {
+			if b, err := json.Marshal(e.Fields); err == nil {
+				builder.WriteString(" ")
+				builder.Write(b)
+			}
+		}
+
+		if len(e.Caller) != 0 {
+			builder.WriteString(" (")
+			builder.WriteString(e.Caller)
+			builder.WriteString(")")
+		}
+
+		builder.WriteString("\n")
+	}
+
+	if dropped > 0 {
+		builder.WriteString(fmt.Sprintf("... and %d more\n", dropped))
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// logAlerter batches the entries and sends the alerts in the background.
+type logAlerter struct {
+	cfg     *LogAlert
+	entries chan *LogAlertEntry
+	flush   chan chan struct{}
+}
+
+func newLogAlerter(cfg *LogAlert) *logAlerter {
+	a := &logAlerter{
+		cfg:     cfg,
+		entries: make(chan *LogAlertEntry, 1000),
+		flush:   make(chan chan struct{}),
+	}
+
+	if a.cfg.Interval <= 0 {
+		a.cfg.Interval = 10 * time.Second
+	}
+
+	if a.cfg.MaxBatch <= 0 {
+		a.cfg.MaxBatch = 10
+	}
+
+	go a.run()
+
+	return a
+}
+
+func (a *logAlerter) push(e *LogAlertEntry) {
+	select {
+	case a.entries <- e:
+	default: // the alerts are overwhelmed
+	}
+}
+
+// sync sends the pending entries, eg: before the fatal exit.
+func (a *logAlerter) sync() {
+	done := make(chan struct{})
+
+	select {
+	case a.flush <- done:
+	case <-time.After(time.Second):
+		return
+	}
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+	}
+}
+
+func (a *logAlerter) run() {
+	var (
+		batch   []*LogAlertEntry
+		dropped int
+		timer   *time.Timer
+		timeout <-chan time.Time
+	)
+
+	send := func() {
+		if timer != nil {
+			timer.Stop()
+		}
+
+		timer, timeout = nil, nil
+
+		if len(batch) == 0 {
+			return
+		}
+
+		a.notify(batch, dropped)
+
+		batch, dropped = nil, 0
+	}
+
+	for {
+		select {
+		case e := <-a.entries:
+			if len(batch) < a.cfg.MaxBatch {
+				batch = append(batch, e)
+			} else {
+				dropped++
+			}
+
+			// debounce since the first entry
+			if timer == nil {
+				timer = time.NewTimer(a.cfg.Interval)
+				timeout = timer.C
+			}
+		case <-timeout:
+			send()
+		case done := <-a.flush:
+			// drain the pushed entries
+			for n := len(a.entries); n > 0; n-- {
+				if e := <-a.entries; len(batch) < a.cfg.MaxBatch {
+					batch = append(batch, e)
+				} else {
+					dropped++
+				}
+			}
+
+			send()
+			close(done)
+		}
+	}
+}
+
+func (a *logAlerter) notify(entries []*LogAlertEntry, dropped int) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "log alert panic: %v\n", r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// NOTE: not logged by the logger to avoid the alert loop
+	if err := a.cfg.Notifier.Notify(ctx, a.cfg.Title, entries, dropped); err != nil {
+		fmt.Fprintf(os.Stderr, "err log alert: %v\n", err)
+	}
+}
+
+// logAlertCore the zap core which pushes the entries to the alerter.
+type logAlertCore struct {
+	zapcore.LevelEnabler
+
+	alerter *logAlerter
+	fields  []zapcore.Field
+}
+
+func newLogAlertCore(cfg *LogAlert, level zap.AtomicLevel) (zapcore.Core, error) {
+	minLevel := zapcore.ErrorLevel
+
+	if len(cfg.Level) != 0 {
+		l, err := zapcore.ParseLevel(cfg.Level)
+
+		if err != nil {
+			return nil, err
+		}
+
+		minLevel = l
+	}
+
+	if cfg.Notifier == nil {
+		return nil, fmt.Errorf("nil log alert notifier")
+	}
+
+	return &logAlertCore{
+		LevelEnabler: zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+			return l >= minLevel && level.Enabled(l)
+		}),
+		alerter: newLogAlerter(cfg),
+	}, nil
+}
+
+func (c *logAlertCore) With(fields []zapcore.Field) zapcore.Core {
+	return &logAlertCore{
+		LevelEnabler: c.LevelEnabler,
+		alerter:      c.alerter,
+		fields:       append(append(make([]zapcore.Field, 0, len(c.fields)+len(fields)), c.fields...), fields...),
+	}
+}
+
+func (c *logAlertCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *logAlertCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	e := &LogAlertEntry{
+		Time:    ent.Time,
+		Level:   ent.Level.String(),
+		Logger:  ent.LoggerName,
+		Message: ent.Message,
+		Fields:  enc.Fields,
+	}
+
+	if ent.Caller.Defined {
+		e.Caller = ent.Caller.TrimmedPath()
+	}
+
+	c.alerter.push(e)
+
+	// the entries are sent before the exit
+	if ent.Level > zapcore.ErrorLevel {
+		c.alerter.sync()
+	}
+
+	return nil
+}
+
+// Sync sends the pending entries, eg: defer logger.Sync()
+func (c *logAlertCore) Sync() error {
+	c.alerter.sync()
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package yiigo
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogAlert(t *testing.T) {
+	var (
+		alerts  [][]*LogAlertEntry
+		dropped []int
+		mutex   sync.Mutex
+	)
+
+	notifier := LogAlertNotifyFunc(func(ctx context.Context, title string, entries []*LogAlertEntry, n int) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		assert.Equal(t, "[test]", title)
+
+		alerts = append(alerts, entries)
+		dropped = append(dropped, n)
+
+		return nil
+	})
+
+	l := newLogger(&LoggerConfig{
+		Options: &LoggerOptions{
+			Alert: &LogAlert{
+				Title:    "[test]",
+				Notifier: notifier,
+				Interval: 100 * time.Millisecond,
+				MaxBatch: 2,
+			},
+		},
+	}, zap.NewAtomicLevelAt(zapcore.DebugLevel))
+
+	l = l.With(zap.String("service", "order"))
+
+	l.Info("ignored")
+	l.Error("e1", zap.Int("id", 1))
+	l.Error("e2")
+	l.Error("e3")
+
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return len(alerts) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Len(t, alerts[0], 2)
+	assert.Equal(t, "e1", alerts[0][0].Message)
+	assert.Equal(t, "error", alerts[0][0].Level)
+	assert.Equal(t, map[string]any{"service": "order", "id": int64(1)}, alerts[0][0].Fields)
+	assert.Equal(t, 1, dropped[0])
+
+	// flushed by Sync
+	l.Error("e4")
+
+	// NOTE: the sync of stderr may fail (eg: /dev/stderr is not a file)
+	l.Sync()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	assert.Len(t, alerts, 2)
+	assert.Equal(t, "e4", alerts[1][0].Message)
+}
+
+func TestAlertNotifiers(t *testing.T) {
+	bodies := make(map[string]map[string]any)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		b, _ := io.ReadAll(r.Body)
+
+		var body map[string]any
+
+		assert.Nil(t, json.Unmarshal(b, &body))
+
+		if r.URL.Path == "/dingtalk" {
+			assert.Equal(t, "x", r.URL.Query().Get("access_token"))
+			assert.NotEmpty(t, r.URL.Query().Get("sign"))
+		}
+
+		bodies[r.URL.Path] = body
+	}))
+
+	defer srv.Close()
+
+	entries := []*LogAlertEntry{
+		{Time: time.Date(2023, 1, 2, 15, 4, 5, 0, timezone), Level: "error", Message: "pay failed", Caller: "pay.go:42", Fields: map[string]any{"order_id": 1}},
+	}
+
+	text := "[test]\n2023-01-02 15:04:05 ERROR pay failed {\"order_id\":1} (pay.go:42)\n... and 3 more"
+
+	assert.Equal(t, text, alertText("[test]", entries, 3))
+
+	ctx := context.TODO()
+
+	assert.Nil(t, WebhookNotifier(srv.URL+"/webhook").Notify(ctx, "[test]", entries, 3))
+	assert.Nil(t, DingTalkNotifier(srv.URL+"/dingtalk?access_token=x", "sec").Notify(ctx, "[test]", entries, 3))
+	assert.Nil(t, FeishuNotifier(srv.URL+"/feishu", "sec").Notify(ctx, "[test]", entries, 3))
+	assert.Nil(t, SlackNotifier(srv.URL+"/slack").Notify(ctx, "[test]", entries, 3))
+
+	assert.Equal(t, "[test]", bodies["/webhook"]["title"])
+	assert.Equal(t, text, bodies["/dingtalk"]["text"].(map[string]any)["content"])
+	assert.Equal(t, text, bodies["/feishu"]["content"].(map[string]any)["text"])
+	assert.NotEmpty(t, bodies["/feishu"]["sign"])
+	assert.Equal(t, text, bodies["/slack"]["text"])
+
+	// the error status
+	err := SlackNotifier(srv.URL+"/error").Notify(ctx, "", entries, 0)
+
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "400"))
+}
@@ -0,0 +1,86 @@
+package yiigo
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type ctxLogFieldsKey struct{}
+
+// ContextWithLogFields returns a copy of ctx with the log fields, which are attached by `LoggerFromCtx`,
+// the field of the same key is replaced, eg: yiigo.ContextWithLogFields(ctx, zap.String("tenant", "acme"))
+func ContextWithLogFields(ctx context.Context, fields ...zap.Field) context.Context {
+	prev, _ := ctx.Value(ctxLogFieldsKey{}).([]zap.Field)
+
+	merged := make([]zap.Field, 0, len(prev)+len(fields))
+
+	for _, v := range prev {
+		if !hasLogField(fields, v.Key) {
+			merged = append(merged, v)
+		}
+	}
+
+	merged = append(merged, fields...)
+
+	return context.WithValue(ctx, ctxLogFieldsKey{}, merged)
+}
+
+// ContextWithTraceID returns a copy of ctx with the trace_id log field,
+// which defaults to the trace id of the span in ctx (opentelemetry).
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return ContextWithLogFields(ctx, zap.String("trace_id", traceID))
+}
+
+// ContextWithRequestID returns a copy of ctx with the request_id log field.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return ContextWithLogFields(ctx, zap.String("request_id", requestID))
+}
+
+// ContextWithUserID returns a copy of ctx with the user_id log field.
+func ContextWithUserID(ctx context.Context, userID any) context.Context {
+	return ContextWithLogFields(ctx, zap.Any("user_id", userID))
+}
+
+// LogFieldsFromCtx returns the log fields in ctx (see `ContextWithLogFields`),
+// the trace_id and span_id of the span in ctx are attached if trace_id is not set.
+func LogFieldsFromCtx(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+
+	prev, _ := ctx.Value(ctxLogFieldsKey{}).([]zap.Field)
+
+	fields := append(make([]zap.Field, 0, len(prev)+2), prev...)
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() && !hasLogField(fields, "trace_id") {
+		fields = append(fields, zap.String("trace_id", sc.TraceID().String()), zap.String("span_id", sc.SpanID().String()))
+	}
+
+	return fields
+}
+
+// LoggerFromCtx returns the named logger (default if not specified) with the log fields in ctx, eg:
+//
+//	ctx = yiigo.ContextWithRequestID(ctx, r.Header.Get("X-Request-ID"))
+//	yiigo.LoggerFromCtx(ctx).Info("order created") // {"msg": "order created", "request_id": "..."}
+func LoggerFromCtx(ctx context.Context, name ...string) *zap.Logger {
+	l := Logger(name...)
+
+	if fields := LogFieldsFromCtx(ctx); len(fields) != 0 {
+		return l.With(fields...)
+	}
+
+	return l
+}
+
+func hasLogField(fields []zap.Field, key string) bool {
+	for _, v := range fields {
+		if v.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
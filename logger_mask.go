@@ -0,0 +1,325 @@
+package yiigo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogMask masks the sensitive fields of the logger before encoding, including the fields of the objects
+// and the json (eg: the request and response bodies logged by zap.ByteString("body", b)), eg:
+//
+//	{"password": "123456", "phone": "13812345678"} => {"password": "******", "phone": "138*****678"}
+//
+// The keys are matched by the patterns (see path.Match) case-insensitively, eg: *token => access_token.
+type LogMask struct {
+	// Keys the values are masked entirely, default: `DefaultMaskKeys`.
+	Keys []string `json:"keys"`
+
+	// PartialKeys the values are masked except the head and tail, default: `DefaultPartialMaskKeys`.
+	PartialKeys []string `json:"partial_keys"`
+}
+
+var (
+	// DefaultMaskKeys the default keys masked entirely.
+	DefaultMaskKeys = []string{"password", "passwd", "pwd", "secret", "*_secret", "token", "*_token", "authorization", "cookie"}
+
+	// DefaultPartialMaskKeys the default keys masked except the head and tail.
+	DefaultPartialMaskKeys = []string{"phone", "mobile", "id_card", "idcard", "card_no", "bank_card", "email"}
+)
+
+const maskedValue = "******"
+
+// MaskString masks the string except the head and tail (a third of the length, max 4 chars), eg: 13812345678 => 138*****678
+func MaskString(s string) string {
+	n := utf8.RuneCountInString(s)
+
+	if n <= 4 {
+		return strings.Repeat("*", n)
+	}
+
+	keep := n / 3
+
+	if keep > 4 {
+		keep = 4
+	}
+
+	runes := []rune(s)
+
+	return string(runes[:keep]) + strings.Repeat("*", n-2*keep) + string(runes[n-keep:])
+}
+
+type logMasker struct {
+	keys    []string
+	partial []string
+}
+
+func newLogMasker(cfg *LogMask) *logMasker {
+	if cfg == nil {
+		return nil
+	}
+
+	m := &logMasker{
+		keys:    lowerStrings(DefaultMaskKeys),
+		partial: lowerStrings(DefaultPartialMaskKeys),
+	}
+
+	if len(cfg.Keys) != 0 {
+		m.keys = lowerStrings(cfg.Keys)
+	}
+
+	if len(cfg.PartialKeys) != 0 {
+		m.partial = lowerStrings(cfg.PartialKeys)
+	}
+
+	return m
+}
+
+// wrap returns the core which masks the fields, the core itself if the masker is nil.
+func (m *logMasker) wrap(core zapcore.Core) zapcore.Core {
+	if m == nil {
+		return core
+	}
+
+	return &logMaskCore{Core: core, masker: m}
+}
+
+// match returns the masking of the key: 0 - none, 1 - entirely, 2 - partially.
+func (m *logMasker) match(key string) int {
+	key = strings.ToLower(key)
+
+	for _, p := range m.keys {
+		if ok, _ := path.Match(p, key); ok {
+			return 1
+		}
+	}
+
+	for _, p := range m.partial {
+		if ok, _ := path.Match(p, key); ok {
+			return 2
+		}
+	}
+
+	return 0
+}
+
+func (m *logMasker) maskString(key, s string) (string, bool) {
+	switch m.match(key) {
+	case 1:
+		return maskedValue, true
+	case 2:
+		return MaskString(s), true
+	}
+
+	return s, false
+}
+
+func (m *logMasker) fields(fields []zapcore.Field) []zapcore.Field {
+	ret := make([]zapcore.Field, 0, len(fields))
+
+	for _, f := range fields {
+		ret = append(ret, m.field(f))
+	}
+
+	return ret
+}
+
+func (m *logMasker) field(f zapcore.Field) zapcore.Field {
+	switch f.Type {
+	case zapcore.StringType:
+		if s, ok := m.maskString(f.Key, f.String); ok {
+			return zap.String(f.Key, s)
+		}
+
+		if b, ok := m.maskJSON([]byte(f.String)); ok {
+			return zap.String(f.Key, string(b))
+		}
+	case zapcore.ByteStringType:
+		if s, ok := m.maskString(f.Key, string(f.Interface.([]byte))); ok {
+			return zap.String(f.Key, s)
+		}
+
+		if b, ok := m.maskJSON(f.Interface.([]byte)); ok {
+			return zap.ByteString(f.Key, b)
+		}
+	case zapcore.StringerType:
+		if s, ok := m.maskString(f.Key, fmt.Sprint(f.Interface)); ok {
+			return zap.String(f.Key, s)
+		}
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type,
+		zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type:
+		// eg: the phone number in integer
+		if m.match(f.Key) != 0 {
+			enc := zapcore.NewMapObjectEncoder()
+			f.AddTo(enc)
+
+			s, _ := m.maskString(f.Key, fmt.Sprint(enc.Fields[f.Key]))
+
+			return zap.String(f.Key, s)
+		}
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.ReflectType:
+		if m.match(f.Key) == 1 {
+			return zap.String(f.Key, maskedValue)
+		}
+
+		// the generic value of the object
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+
+		v, ok := enc.Fields[f.Key]
+
+		if !ok {
+			return f
+		}
+
+		if f.Type == zapcore.ReflectType {
+			b, err := json.Marshal(v)
+
+			if err != nil {
+				return f
+			}
+
+			if v, err = decodeJSON(b); err != nil {
+				return f
+			}
+		}
+
+		return zap.Any(f.Key, m.maskValue(f.Key, v))
+	}
+
+	return f
+}
+
+// maskJSON masks the json object (array), false if not json.
+func (m *logMasker) maskJSON(b []byte) ([]byte, bool) {
+	trimmed := bytes.TrimSpace(b)
+
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return nil, false
+	}
+
+	v, err := decodeJSON(trimmed)
+
+	if err != nil {
+		return nil, false
+	}
+
+	masked, err := json.Marshal(m.maskValue("", v))
+
+	if err != nil {
+		return nil, false
+	}
+
+	return masked, true
+}
+
+func (m *logMasker) maskValue(key string, v any) any {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		ret := make(map[string]any, len(t))
+
+		for k, val := range t {
+			if m.match(k) == 1 {
+				ret[k] = maskedValue
+
+				continue
+			}
+
+			ret[k] = m.maskValue(k, val)
+		}
+
+		return ret
+	case []any:
+		ret := make([]any, 0, len(t))
+
+		for _, val := range t {
+			ret = append(ret, m.maskValue(key, val))
+		}
+
+		return ret
+	case []map[string]any:
+		ret := make([]any, 0, len(t))
+
+		for _, val := range t {
+			ret = append(ret, m.maskValue(key, val))
+		}
+
+		return ret
+	case string:
+		if s, ok := m.maskString(key, t); ok {
+			return s
+		}
+
+		// eg: the nested json string
+		if b, ok := m.maskJSON([]byte(t)); ok {
+			return string(b)
+		}
+
+		return t
+	}
+
+	if len(key) != 0 && m.match(key) != 0 {
+		s, _ := m.maskString(key, fmt.Sprint(v))
+
+		return s
+	}
+
+	return v
+}
+
+func decodeJSON(b []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	var v any
+
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func lowerStrings(s []string) []string {
+	ret := make([]string, 0, len(s))
+
+	for _, v := range s {
+		ret = append(ret, strings.ToLower(v))
+	}
+
+	return ret
+}
+
+// logMaskCore the zap core which masks the fields before encoding.
+type logMaskCore struct {
+	zapcore.Core
+
+	masker *logMasker
+}
+
+func (c *logMaskCore) With(fields []zapcore.Field) zapcore.Core {
+	return &logMaskCore{
+		Core:   c.Core.With(c.masker.fields(fields)),
+		masker: c.masker,
+	}
+}
+
+func (c *logMaskCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *logMaskCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.masker.fields(fields))
+}
@@ -0,0 +1,83 @@
+package yiigo
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type maskUser struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+	Phone    string `json:"phone"`
+}
+
+func (u *maskUser) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", u.Name)
+	enc.AddString("password", u.Password)
+	enc.AddString("phone", u.Phone)
+
+	return nil
+}
+
+func TestMaskString(t *testing.T) {
+	assert.Equal(t, "138*****678", MaskString("13812345678"))
+	assert.Equal(t, "1101**********1234", MaskString("110101199001011234"))
+	assert.Equal(t, "****", MaskString("1234"))
+	assert.Equal(t, "张三***王五", MaskString("张三丰李四王五"))
+}
+
+func TestLogMask(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := newLogger(&LoggerConfig{
+		Options: &LoggerOptions{
+			Sinks: []*LogSink{{Writer: &buf}},
+			Mask:  &LogMask{},
+		},
+	}, zap.NewAtomicLevelAt(zapcore.DebugLevel))
+
+	l.With(zap.String("access_token", "abc")).Info("hello",
+		zap.String("password", "123456"),
+		zap.Int64("Phone", 13812345678),
+		zap.String("name", "yiigo"),
+		zap.ByteString("body", []byte(`{"user":{"password":"123456","card_no":"6222020200112233"},"items":[{"mobile":"13812345678"}]}`)),
+		zap.Object("user", &maskUser{Name: "yiigo", Password: "123456", Phone: "13812345678"}),
+		zap.Any("users", []maskUser{{Name: "yiigo", Password: "123456", Phone: "13812345678"}}),
+		zap.String("raw", "{not json"),
+	)
+
+	var ret map[string]any
+
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &ret))
+
+	assert.Equal(t, "******", ret["access_token"])
+	assert.Equal(t, "******", ret["password"])
+	assert.Equal(t, "138*****678", ret["Phone"])
+	assert.Equal(t, "yiigo", ret["name"])
+	assert.JSONEq(t, `{"user":{"password":"******","card_no":"6222********2233"},"items":[{"mobile":"138*****678"}]}`, ret["body"].(string))
+	assert.Equal(t, map[string]any{"name": "yiigo", "password": "******", "phone": "138*****678"}, ret["user"])
+	assert.Equal(t, []any{map[string]any{"name": "yiigo", "password": "******", "phone": "138*****678"}}, ret["users"])
+	assert.Equal(t, "{not json", ret["raw"])
+
+	// custom keys
+	buf.Reset()
+
+	l = newLogger(&LoggerConfig{
+		Options: &LoggerOptions{
+			Sinks: []*LogSink{{Writer: &buf}},
+			Mask:  &LogMask{Keys: []string{"pin"}, PartialKeys: []string{"*_no"}},
+		},
+	}, zap.NewAtomicLevelAt(zapcore.DebugLevel))
+
+	l.Info("hello", zap.String("PIN", "1234"), zap.String("order_no", "202301020001"), zap.String("password", "123456"))
+
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &ret))
+	assert.Equal(t, "******", ret["PIN"])
+	assert.Equal(t, "2023****0001", ret["order_no"])
+	assert.Equal(t, "123456", ret["password"])
+}
@@ -0,0 +1,106 @@
+package yiigo
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogSink the output of the logger with the independent encoder and level, eg:
+//
+//	Sinks: []*yiigo.LogSink{
+//		{Output: "stdout", Encoder: "console"},
+//		{Output: "syslog://127.0.0.1:514?tag=app", Level: "error"},
+//		{Output: "kafka://127.0.0.1:9092/logs"}, // registered by zap.RegisterSink("kafka", factory)
+//	}
+type LogSink struct {
+	// Output the output of the sink:
+	//   - stdout, stderr
+	//   - the file path, which is rotated by the options of the logger (eg: MaxSize)
+	//   - the url opened by zap.Open, eg: syslog://127.0.0.1:514?tag=app (unix only),
+	//     and the scheme registered by zap.RegisterSink, eg: kafka://...
+	Output string `json:"output"`
+
+	// Writer the output writer, which takes precedence over Output.
+	Writer io.Writer `json:"-"`
+
+	// Encoder json or console, default: json.
+	Encoder string `json:"encoder"`
+
+	// Level the min level of the sink, which works with the level of the logger (see `SetLoggerLevel`), eg: error
+	Level string `json:"level"`
+}
+
+func (s *LogSink) core(opts *LoggerOptions, level zap.AtomicLevel) (zapcore.Core, error) {
+	enc, err := s.encoder()
+
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := s.writer(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	enabler := zapcore.LevelEnabler(level)
+
+	if len(s.Level) != 0 {
+		minLevel, err := zapcore.ParseLevel(s.Level)
+
+		if err != nil {
+			return nil, err
+		}
+
+		enabler = zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+			return l >= minLevel && level.Enabled(l)
+		})
+	}
+
+	return zapcore.NewCore(enc, ws, enabler), nil
+}
+
+func (s *LogSink) encoder() (zapcore.Encoder, error) {
+	c := logEncoderConfig()
+
+	switch strings.ToLower(s.Encoder) {
+	case "", "json":
+		return zapcore.NewJSONEncoder(c), nil
+	case "console":
+		c.EncodeLevel = zapcore.CapitalLevelEncoder
+
+		return zapcore.NewConsoleEncoder(c), nil
+	}
+
+	return nil, fmt.Errorf("unknown log encoder: %s", s.Encoder)
+}
+
+func (s *LogSink) writer(opts *LoggerOptions) (zapcore.WriteSyncer, error) {
+	// the writer may not be safe for concurrent use, eg: bytes.Buffer
+	if s.Writer != nil {
+		return zapcore.Lock(zapcore.AddSync(s.Writer)), nil
+	}
+
+	switch s.Output {
+	case "":
+		return nil, fmt.Errorf("empty log output")
+	case "stdout":
+		return zapcore.Lock(os.Stdout), nil
+	case "stderr":
+		return zapcore.Lock(os.Stderr), nil
+	}
+
+	if strings.Contains(s.Output, "://") {
+		ws, _, err := zap.Open(s.Output)
+
+		return ws, err
+	}
+
+	return zapcore.AddSync(newRotateWriter(filepath.Clean(s.Output), opts)), nil
+}
@@ -0,0 +1,54 @@
+//go:build !windows && !plan9
+
+package yiigo
+
+import (
+	"log/syslog"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+)
+
+func init() {
+	if err := zap.RegisterSink("syslog", newSyslogSink); err != nil {
+		panic(err)
+	}
+}
+
+type syslogSink struct {
+	*syslog.Writer
+}
+
+func (s *syslogSink) Sync() error {
+	return nil
+}
+
+// newSyslogSink opens the syslog sink, eg: syslog://127.0.0.1:514?tag=app&network=tcp, syslog:/// (the local syslog)
+// The tag defaults to the program name, and the network defaults to udp.
+func newSyslogSink(u *url.URL) (zap.Sink, error) {
+	query := u.Query()
+
+	tag := query.Get("tag")
+
+	if len(tag) == 0 {
+		tag = filepath.Base(os.Args[0])
+	}
+
+	network := ""
+
+	if len(u.Host) != 0 {
+		if network = query.Get("network"); len(network) == 0 {
+			network = "udp"
+		}
+	}
+
+	w, err := syslog.Dial(network, u.Host, syslog.LOG_INFO|syslog.LOG_USER, tag)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &syslogSink{Writer: w}, nil
+}
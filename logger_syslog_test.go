@@ -0,0 +1,40 @@
+//go:build !windows && !plan9
+
+package yiigo
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSyslogSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+
+	assert.Nil(t, err)
+
+	defer conn.Close()
+
+	l := newLogger(&LoggerConfig{
+		Options: &LoggerOptions{
+			Sinks: []*LogSink{
+				{Output: "syslog://" + conn.LocalAddr().String() + "?tag=yiigo"},
+			},
+		},
+	}, zap.NewAtomicLevel())
+
+	l.Info("hello syslog")
+
+	buf := make([]byte, 1024)
+
+	assert.Nil(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+
+	n, _, err := conn.ReadFrom(buf)
+
+	assert.Nil(t, err)
+	assert.Contains(t, string(buf[:n]), "yiigo")
+	assert.Contains(t, string(buf[:n]), "hello syslog")
+}
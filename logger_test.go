@@ -0,0 +1,280 @@
+package yiigo
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+func TestDailyWriter(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+
+	// the log file of yesterday
+	assert.Nil(t, os.WriteFile(filename, []byte("yesterday\n"), 0644))
+
+	yesterday := time.Now().Add(-24 * time.Hour)
+
+	assert.Nil(t, os.Chtimes(filename, yesterday, yesterday))
+
+	l := &lumberjack.Logger{Filename: filename, LocalTime: true}
+	defer l.Close()
+
+	w := newDailyWriter(l)
+
+	assert.Equal(t, nextMidnight(yesterday), w.next)
+
+	_, err := w.Write([]byte("today\n"))
+
+	assert.Nil(t, err)
+	assert.True(t, w.next.After(time.Now()))
+
+	b, err := os.ReadFile(filename)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "today\n", string(b))
+
+	files, err := os.ReadDir(dir)
+
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+
+	// not rotated within the day
+	_, err = w.Write([]byte("again\n"))
+
+	assert.Nil(t, err)
+
+	files, err = os.ReadDir(dir)
+
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+}
+
+func TestNextMidnight(t *testing.T) {
+	next := nextMidnight(time.Date(2023, 12, 31, 23, 59, 59, 0, timezone))
+
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, timezone), next)
+}
+
+func TestSetLoggerLevel(t *testing.T) {
+	initLogger("level", &LoggerConfig{})
+
+	defer func() {
+		logMap.Delete("level")
+		levelMap.Delete("level")
+	}()
+
+	assert.Nil(t, SetLoggerLevel("level", "warn"))
+
+	level, ok := LoggerLevel("level")
+
+	assert.True(t, ok)
+	assert.Equal(t, zapcore.WarnLevel, level)
+	assert.False(t, Logger("level").Core().Enabled(zapcore.InfoLevel))
+
+	// the default logger is not changed
+	level, _ = LoggerLevel(Default)
+
+	assert.Equal(t, zapcore.DebugLevel, level)
+
+	// unknown
+	assert.NotNil(t, SetLoggerLevel("none", "warn"))
+	assert.NotNil(t, SetLoggerLevel("level", "verbose"))
+
+	// env
+	t.Setenv("YIIGO_LOG_LEVEL_LEVEL", "error")
+	defer envLevels.Delete("YIIGO_LOG_LEVEL_LEVEL")
+
+	applyEnvSettings()
+
+	level, _ = LoggerLevel("level")
+
+	assert.Equal(t, zapcore.ErrorLevel, level)
+}
+
+func TestNamedLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	initLogger("db", &LoggerConfig{
+		Options: &LoggerOptions{
+			Level: "warn",
+			Sinks: []*LogSink{{Writer: &buf}},
+		},
+	})
+
+	defer func() {
+		logMap.Delete("db")
+		levelMap.Delete("db")
+	}()
+
+	level, _ := LoggerLevel("db")
+
+	assert.Equal(t, zapcore.WarnLevel, level)
+
+	// the child falls back to the parent
+	Logger("db.mysql").Info("silenced")
+	Logger("db.mysql").Warn("hello")
+	Logger("db").Warn("world")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	assert.Equal(t, 2, len(lines))
+	assert.Contains(t, lines[0], `"logger":"db.mysql"`)
+	assert.Contains(t, lines[1], `"logger":"db"`)
+
+	// the level of the parent
+	assert.Nil(t, SetLoggerLevel("db", "info"))
+	assert.True(t, Logger("db.mysql.slow").Core().Enabled(zapcore.InfoLevel))
+
+	// not registered
+	assert.Equal(t, Logger(), Logger("cache.redis"))
+}
+
+func TestLogLevelHandler(t *testing.T) {
+	initLogger("handler", &LoggerConfig{})
+
+	defer func() {
+		logMap.Delete("handler")
+		levelMap.Delete("handler")
+	}()
+
+	h := LogLevelHandler("handler")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader(`{"level":"info"}`)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"level":"info"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/log/level", nil))
+
+	assert.JSONEq(t, `{"level":"info"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	LogLevelHandler("none").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/log/level", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestLoggerFromCtx(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	logMap.Store("ctx", zap.New(core))
+	defer logMap.Delete("ctx")
+
+	ctx := ContextWithTraceID(context.Background(), "t1")
+	ctx = ContextWithRequestID(ctx, "r1")
+	ctx = ContextWithUserID(ctx, 10)
+	ctx = ContextWithRequestID(ctx, "r2")
+
+	LoggerFromCtx(ctx, "ctx").Info("hello")
+
+	assert.Equal(t, map[string]any{"trace_id": "t1", "request_id": "r2", "user_id": int64(10)}, logs.TakeAll()[0].ContextMap())
+
+	// the span in ctx
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+
+	ctx = trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+
+	LoggerFromCtx(ctx, "ctx").Info("hello")
+
+	assert.Equal(t, map[string]any{"trace_id": traceID.String(), "span_id": spanID.String()}, logs.TakeAll()[0].ContextMap())
+
+	// no fields
+	assert.Equal(t, Logger("ctx"), LoggerFromCtx(context.Background(), "ctx"))
+}
+
+func TestLogSinks(t *testing.T) {
+	var jsonBuf, consoleBuf bytes.Buffer
+
+	filename := filepath.Join(t.TempDir(), "sink.log")
+
+	level := zap.NewAtomicLevelAt(zapcore.DebugLevel)
+
+	l := newLogger(&LoggerConfig{
+		Options: &LoggerOptions{
+			Sinks: []*LogSink{
+				{Writer: &jsonBuf},
+				{Writer: &consoleBuf, Encoder: "console", Level: "error"},
+				{Output: filename, Level: "warn"},
+			},
+		},
+	}, level)
+
+	l.Info("hello")
+	l.Error("oops")
+
+	assert.Nil(t, l.Sync())
+
+	lines := strings.Split(strings.TrimSpace(jsonBuf.String()), "\n")
+
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"msg":"hello"`)
+
+	assert.NotContains(t, consoleBuf.String(), "hello")
+	assert.Contains(t, consoleBuf.String(), "ERROR\toops")
+
+	b, err := os.ReadFile(filename)
+
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), `"msg":"oops"`)
+	assert.NotContains(t, string(b), "hello")
+
+	// the level of the logger
+	level.SetLevel(zapcore.ErrorLevel)
+	jsonBuf.Reset()
+
+	l.Warn("ignored")
+
+	assert.Empty(t, jsonBuf.String())
+
+	// invalid
+	_, err = (&LogSink{Writer: &jsonBuf, Encoder: "xml"}).core(&LoggerOptions{}, level)
+
+	assert.NotNil(t, err)
+
+	_, err = (&LogSink{Writer: &jsonBuf, Level: "verbose"}).core(&LoggerOptions{}, level)
+
+	assert.NotNil(t, err)
+
+	_, err = (&LogSink{}).core(&LoggerOptions{}, level)
+
+	assert.NotNil(t, err)
+
+	// the writer is locked for the concurrent writes
+	var buf bytes.Buffer
+
+	l = newLogger(&LoggerConfig{Options: &LoggerOptions{Sinks: []*LogSink{{Writer: &buf}}}}, zap.NewAtomicLevel())
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			l.Info("concurrent")
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 10, strings.Count(buf.String(), `"msg":"concurrent"`))
+}
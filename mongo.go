@@ -6,6 +6,8 @@ import (
 	"sync"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
@@ -16,9 +18,84 @@ var (
 	mgoMap       sync.Map
 )
 
-func initMongoDB(name, dsn string) {
+type mongoOptions struct {
+	monitors []*event.CommandMonitor
+	codecs   []func(rb *bsoncodec.RegistryBuilder)
+}
+
+// MongoOption mongo client option
+type MongoOption func(o *mongoOptions)
+
+// WithMongoMonitor specifies the command monitor, multiple monitors are called in order.
+func WithMongoMonitor(m *event.CommandMonitor) MongoOption {
+	return func(o *mongoOptions) {
+		o.monitors = append(o.monitors, m)
+	}
+}
+
+// MongoMonitor returns the command monitor of the options (eg: `WithMongoTracing` and `WithMongoMetrics`),
+// which is used by the client not registered by `Init`, eg: options.Client().SetMonitor(yiigo.MongoMonitor(yiigo.WithMongoTracing()))
+func MongoMonitor(options ...MongoOption) *event.CommandMonitor {
+	o := new(mongoOptions)
+
+	for _, f := range options {
+		f(o)
+	}
+
+	return chainMongoMonitors(o.monitors)
+}
+
+// chainMongoMonitors returns the monitor which calls the monitors in order, nil if empty.
+func chainMongoMonitors(monitors []*event.CommandMonitor) *event.CommandMonitor {
+	switch len(monitors) {
+	case 0:
+		return nil
+	case 1:
+		return monitors[0]
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m.Started != nil {
+					m.Started(ctx, evt)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m.Succeeded != nil {
+					m.Succeeded(ctx, evt)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m.Failed != nil {
+					m.Failed(ctx, evt)
+				}
+			}
+		},
+	}
+}
+
+func initMongoDB(name, dsn string, mopts ...MongoOption) {
 	opts := options.Client().ApplyURI(dsn)
 
+	o := new(mongoOptions)
+
+	for _, f := range mopts {
+		f(o)
+	}
+
+	if m := chainMongoMonitors(o.monitors); m != nil {
+		opts.SetMonitor(m)
+	}
+
+	if r := o.registry(); r != nil {
+		opts.SetRegistry(r)
+	}
+
 	client, err := mongo.Connect(context.Background(), opts)
 
 	if err != nil {
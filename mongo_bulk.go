@@ -0,0 +1,222 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoBulkError the error of the document (write model) in the bulk.
+type MongoBulkError struct {
+	Index   int // the index of the model added to the bulk
+	Code    int
+	Message string
+	Model   mongo.WriteModel
+}
+
+// MongoBulkResult the result of the flushed models.
+type MongoBulkResult struct {
+	Inserted int64
+	Matched  int64
+	Modified int64
+	Deleted  int64
+	Upserted int64
+	Errors   []*MongoBulkError
+}
+
+type mongoBulkOptions struct {
+	chunk     int
+	unordered bool
+}
+
+// MongoBulkOption mongo bulk option
+type MongoBulkOption func(o *mongoBulkOptions)
+
+// WithMongoBulkChunk specifies the models of each BulkWrite, the bulk is flushed once the chunk is full, default: 1000.
+func WithMongoBulkChunk(n int) MongoBulkOption {
+	return func(o *mongoBulkOptions) {
+		if n > 0 {
+			o.chunk = n
+		}
+	}
+}
+
+// WithMongoBulkUnordered executes the models unordered, the failed documents don't stop the others,
+// which is faster and suitable for the import (the errors are reported by `Result`).
+func WithMongoBulkUnordered() MongoBulkOption {
+	return func(o *mongoBulkOptions) {
+		o.unordered = true
+	}
+}
+
+// mongoBulkWriter is the BulkWrite of *mongo.Collection.
+type mongoBulkWriter interface {
+	BulkWrite(ctx context.Context, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error)
+}
+
+// MongoBulk accumulates the write models and flushes them by BulkWrite in chunks, eg:
+//
+//	bulk := yiigo.NewMongoBulk(yiigo.Mongo().Database("app").Collection("users"), yiigo.WithMongoBulkUnordered())
+//
+//	for _, v := range users {
+//		if err := bulk.Insert(ctx, v); err != nil {
+//			return err
+//		}
+//	}
+//
+//	if err := bulk.Flush(ctx); err != nil {
+//		return err
+//	}
+//
+//	result := bulk.Result()
+type MongoBulk struct {
+	coll    mongoBulkWriter
+	options *mongoBulkOptions
+	models  []mongo.WriteModel
+	offset  int // the index of models[0] in the bulk
+	result  *MongoBulkResult
+	mutex   sync.Mutex
+}
+
+// NewMongoBulk returns new MongoBulk of the collection.
+func NewMongoBulk(coll *mongo.Collection, options ...MongoBulkOption) *MongoBulk {
+	return newMongoBulk(coll, options...)
+}
+
+func newMongoBulk(coll mongoBulkWriter, options ...MongoBulkOption) *MongoBulk {
+	o := &mongoBulkOptions{
+		chunk: 1000,
+	}
+
+	for _, f := range options {
+		f(o)
+	}
+
+	return &MongoBulk{
+		coll:    coll,
+		options: o,
+		models:  make([]mongo.WriteModel, 0, o.chunk),
+		result:  new(MongoBulkResult),
+	}
+}
+
+// Insert adds the documents to insert.
+func (b *MongoBulk) Insert(ctx context.Context, docs ...any) error {
+	models := make([]mongo.WriteModel, 0, len(docs))
+
+	for _, v := range docs {
+		models = append(models, mongo.NewInsertOneModel().SetDocument(v))
+	}
+
+	return b.Add(ctx, models...)
+}
+
+// Update adds the update of the document matched by the filter, eg: bulk.Update(ctx, yiigo.M.Where("_id", yiigo.Eq(id)), yiigo.M.Set("name", name), false)
+func (b *MongoBulk) Update(ctx context.Context, filter, update any, upsert bool) error {
+	return b.Add(ctx, mongo.NewUpdateOneModel().SetFilter(mongoDoc(filter)).SetUpdate(mongoDoc(update)).SetUpsert(upsert))
+}
+
+// Replace adds the replacement of the document matched by the filter.
+func (b *MongoBulk) Replace(ctx context.Context, filter, doc any, upsert bool) error {
+	return b.Add(ctx, mongo.NewReplaceOneModel().SetFilter(mongoDoc(filter)).SetReplacement(doc).SetUpsert(upsert))
+}
+
+// Delete adds the deletion of the document matched by the filter.
+func (b *MongoBulk) Delete(ctx context.Context, filter any) error {
+	return b.Add(ctx, mongo.NewDeleteOneModel().SetFilter(mongoDoc(filter)))
+}
+
+// Add adds the write models (eg: mongo.NewUpdateManyModel()), and flushes the full chunks.
+func (b *MongoBulk) Add(ctx context.Context, models ...mongo.WriteModel) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, v := range models {
+		if v == nil {
+			return mongo.ErrNilDocument
+		}
+
+		b.models = append(b.models, v)
+
+		if len(b.models) >= b.options.chunk {
+			if err := b.flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Flush writes the pending models, which should be called after the last model is added.
+func (b *MongoBulk) Flush(ctx context.Context) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.flush(ctx)
+}
+
+// Result returns the result of the flushed models.
+func (b *MongoBulk) Result() *MongoBulkResult {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	result := *b.result
+	result.Errors = append(make([]*MongoBulkError, 0, len(b.result.Errors)), b.result.Errors...)
+
+	return &result
+}
+
+// flush writes the chunk, the errors of the documents are reported by Result and returns nil if unordered.
+// Otherwise (eg: ordered, network or write concern error) returns the error, and the models not written of the chunk are discarded.
+func (b *MongoBulk) flush(ctx context.Context) error {
+	if len(b.models) == 0 {
+		return nil
+	}
+
+	models := b.models
+
+	b.models = make([]mongo.WriteModel, 0, b.options.chunk)
+
+	offset := b.offset
+
+	b.offset += len(models)
+
+	ret, err := b.coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(!b.options.unordered))
+
+	if ret != nil {
+		b.result.Inserted += ret.InsertedCount
+		b.result.Matched += ret.MatchedCount
+		b.result.Modified += ret.ModifiedCount
+		b.result.Deleted += ret.DeletedCount
+		b.result.Upserted += ret.UpsertedCount
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	var ex mongo.BulkWriteException
+
+	if !errors.As(err, &ex) {
+		return err
+	}
+
+	for _, v := range ex.WriteErrors {
+		b.result.Errors = append(b.result.Errors, &MongoBulkError{
+			Index:   offset + v.Index,
+			Code:    v.Code,
+			Message: v.Message,
+			Model:   v.Request,
+		})
+	}
+
+	if ex.WriteConcernError != nil || !b.options.unordered {
+		return err
+	}
+
+	return nil
+}
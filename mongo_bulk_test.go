@@ -0,0 +1,122 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type fakeBulkWriter struct {
+	chunks  []int
+	ordered []bool
+	// fails the models of the index in the chunk (duplicate key)
+	fails map[int]bool
+	err   error
+}
+
+func (w *fakeBulkWriter) BulkWrite(_ context.Context, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	w.chunks = append(w.chunks, len(models))
+	w.ordered = append(w.ordered, *options.MergeBulkWriteOptions(opts...).Ordered)
+
+	if w.err != nil {
+		return &mongo.BulkWriteResult{}, w.err
+	}
+
+	ret := new(mongo.BulkWriteResult)
+	ex := mongo.BulkWriteException{}
+
+	for i, v := range models {
+		if w.fails[i] {
+			ex.WriteErrors = append(ex.WriteErrors, mongo.BulkWriteError{
+				WriteError: mongo.WriteError{Index: i, Code: 11000, Message: "duplicate key"},
+				Request:    v,
+			})
+
+			if !*options.MergeBulkWriteOptions(opts...).Ordered {
+				continue
+			}
+
+			break
+		}
+
+		switch v.(type) {
+		case *mongo.InsertOneModel:
+			ret.InsertedCount++
+		case *mongo.UpdateOneModel:
+			ret.MatchedCount++
+			ret.ModifiedCount++
+		case *mongo.DeleteOneModel:
+			ret.DeletedCount++
+		}
+	}
+
+	if len(ex.WriteErrors) != 0 {
+		return ret, ex
+	}
+
+	return ret, nil
+}
+
+func TestMongoBulk(t *testing.T) {
+	ctx := context.Background()
+
+	w := &fakeBulkWriter{fails: map[int]bool{1: true}}
+
+	bulk := newMongoBulk(w, WithMongoBulkChunk(2), WithMongoBulkUnordered())
+
+	assert.Nil(t, bulk.Insert(ctx, bson.M{"_id": 1}, bson.M{"_id": 2}, bson.M{"_id": 3}))
+	assert.Nil(t, bulk.Update(ctx, M.Where("_id", Eq(1)), M.Set("name", "yiigo"), false))
+	assert.Nil(t, bulk.Delete(ctx, M.Where("_id", Eq(3))))
+	assert.Equal(t, []int{2, 2}, w.chunks)
+	assert.Equal(t, []bool{false, false}, w.ordered)
+
+	assert.Nil(t, bulk.Flush(ctx))
+	assert.Equal(t, []int{2, 2, 1}, w.chunks)
+
+	// nothing to flush
+	assert.Nil(t, bulk.Flush(ctx))
+	assert.Equal(t, 3, len(w.chunks))
+
+	ret := bulk.Result()
+	assert.Equal(t, int64(2), ret.Inserted)
+	assert.Equal(t, int64(0), ret.Matched)
+	assert.Equal(t, int64(1), ret.Deleted)
+	assert.Equal(t, 2, len(ret.Errors))
+	assert.Equal(t, 1, ret.Errors[0].Index)
+	assert.Equal(t, 11000, ret.Errors[0].Code)
+	assert.Equal(t, 3, ret.Errors[1].Index)
+	assert.IsType(t, &mongo.UpdateOneModel{}, ret.Errors[1].Model)
+}
+
+func TestMongoBulkOrdered(t *testing.T) {
+	ctx := context.Background()
+
+	w := &fakeBulkWriter{fails: map[int]bool{0: true}}
+
+	bulk := newMongoBulk(w, WithMongoBulkChunk(3))
+
+	assert.Nil(t, bulk.Insert(ctx, bson.M{"_id": 1}, bson.M{"_id": 2}))
+
+	err := bulk.Flush(ctx)
+
+	var ex mongo.BulkWriteException
+
+	assert.True(t, errors.As(err, &ex))
+	assert.Equal(t, []bool{true}, w.ordered)
+
+	ret := bulk.Result()
+	assert.Equal(t, int64(0), ret.Inserted)
+	assert.Equal(t, 1, len(ret.Errors))
+
+	// network error
+	w.err = errors.New("connection reset")
+
+	assert.Nil(t, bulk.Insert(ctx, bson.M{"_id": 3}))
+	assert.Equal(t, w.err, bulk.Flush(ctx))
+	assert.Equal(t, mongo.ErrNilDocument, bulk.Add(ctx, nil))
+}
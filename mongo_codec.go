@@ -0,0 +1,239 @@
+package yiigo
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MongoRegistry returns the bson registry with the codecs of the options (eg: `WithMongoDecimal` and `WithMongoEnum`),
+// which is used by the client not registered by `Init`, eg: options.Client().SetRegistry(yiigo.MongoRegistry(yiigo.WithMongoDecimal())),
+// nil if no codecs.
+func MongoRegistry(options ...MongoOption) *bsoncodec.Registry {
+	o := new(mongoOptions)
+
+	for _, f := range options {
+		f(o)
+	}
+
+	return o.registry()
+}
+
+// WithMongoCodec registers the encoder and decoder of the type, eg: yiigo.WithMongoCodec(reflect.TypeOf(Money{}), enc, dec)
+func WithMongoCodec(t reflect.Type, enc bsoncodec.ValueEncoder, dec bsoncodec.ValueDecoder) MongoOption {
+	return func(o *mongoOptions) {
+		o.codecs = append(o.codecs, func(rb *bsoncodec.RegistryBuilder) {
+			if enc != nil {
+				rb.RegisterTypeEncoder(t, enc)
+			}
+
+			if dec != nil {
+				rb.RegisterTypeDecoder(t, dec)
+			}
+		})
+	}
+}
+
+var decimalType = reflect.TypeOf(decimal.Decimal{})
+
+// WithMongoDecimal stores decimal.Decimal (shopspring/decimal) as Decimal128, which is decoded from Decimal128, double, int and string.
+// NOTE: Decimal128 has 34 significant digits, the decimal out of range fails to encode.
+func WithMongoDecimal() MongoOption {
+	return WithMongoCodec(decimalType, bsoncodec.ValueEncoderFunc(encodeDecimal), bsoncodec.ValueDecoderFunc(decodeDecimal))
+}
+
+func encodeDecimal(_ bsoncodec.EncodeContext, vw bsonrw.ValueWriter, val reflect.Value) error {
+	if !val.IsValid() || val.Type() != decimalType {
+		return bsoncodec.ValueEncoderError{Name: "DecimalEncodeValue", Types: []reflect.Type{decimalType}, Received: val}
+	}
+
+	d, err := primitive.ParseDecimal128(val.Interface().(decimal.Decimal).String())
+
+	if err != nil {
+		return err
+	}
+
+	return vw.WriteDecimal128(d)
+}
+
+func decodeDecimal(_ bsoncodec.DecodeContext, vr bsonrw.ValueReader, val reflect.Value) error {
+	if !val.CanSet() || val.Type() != decimalType {
+		return bsoncodec.ValueDecoderError{Name: "DecimalDecodeValue", Types: []reflect.Type{decimalType}, Received: val}
+	}
+
+	var (
+		d   decimal.Decimal
+		err error
+	)
+
+	switch vr.Type() {
+	case bsontype.Decimal128:
+		var v primitive.Decimal128
+
+		if v, err = vr.ReadDecimal128(); err == nil {
+			d, err = decimal.NewFromString(v.String())
+		}
+	case bsontype.Double:
+		var v float64
+
+		if v, err = vr.ReadDouble(); err == nil {
+			d = decimal.NewFromFloat(v)
+		}
+	case bsontype.Int32:
+		var v int32
+
+		if v, err = vr.ReadInt32(); err == nil {
+			d = decimal.NewFromInt32(v)
+		}
+	case bsontype.Int64:
+		var v int64
+
+		if v, err = vr.ReadInt64(); err == nil {
+			d = decimal.NewFromInt(v)
+		}
+	case bsontype.String:
+		var v string
+
+		if v, err = vr.ReadString(); err == nil {
+			d, err = decimal.NewFromString(v)
+		}
+	case bsontype.Null:
+		err = vr.ReadNull()
+	default:
+		err = fmt.Errorf("cannot decode %v into decimal.Decimal", vr.Type())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	val.Set(reflect.ValueOf(d))
+
+	return nil
+}
+
+// WithMongoTimeZone decodes time.Time in the location (the driver decodes in UTC), eg: yiigo.WithMongoTimeZone(time.Local)
+func WithMongoTimeZone(loc *time.Location) MongoOption {
+	codec := bsoncodec.NewTimeCodec()
+
+	dec := bsoncodec.ValueDecoderFunc(func(dc bsoncodec.DecodeContext, vr bsonrw.ValueReader, val reflect.Value) error {
+		if err := codec.DecodeValue(dc, vr, val); err != nil {
+			return err
+		}
+
+		val.Set(reflect.ValueOf(val.Interface().(time.Time).In(loc)))
+
+		return nil
+	})
+
+	return WithMongoCodec(timeType, nil, dec)
+}
+
+// Enum is the integer type of the enum.
+type Enum interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// WithMongoEnum stores the integer enum as the name, which is decoded from the name or the integer (eg: the legacy data), eg:
+//
+//	yiigo.WithMongoEnum(map[OrderStatus]string{
+//		OrderPending: "pending",
+//		OrderPaid:    "paid",
+//	})
+//
+// The unknown enum (or name) fails to encode (decode).
+func WithMongoEnum[T Enum](names map[T]string) MongoOption {
+	t := reflect.TypeOf(T(0))
+
+	values := make(map[string]T, len(names))
+
+	for k, v := range names {
+		values[v] = k
+	}
+
+	enc := bsoncodec.ValueEncoderFunc(func(_ bsoncodec.EncodeContext, vw bsonrw.ValueWriter, val reflect.Value) error {
+		if !val.IsValid() || val.Type() != t {
+			return bsoncodec.ValueEncoderError{Name: "EnumEncodeValue", Types: []reflect.Type{t}, Received: val}
+		}
+
+		name, ok := names[val.Interface().(T)]
+
+		if !ok {
+			return fmt.Errorf("unknown enum %v of %s", val.Interface(), t)
+		}
+
+		return vw.WriteString(name)
+	})
+
+	dec := bsoncodec.ValueDecoderFunc(func(_ bsoncodec.DecodeContext, vr bsonrw.ValueReader, val reflect.Value) error {
+		if !val.CanSet() || val.Type() != t {
+			return bsoncodec.ValueDecoderError{Name: "EnumDecodeValue", Types: []reflect.Type{t}, Received: val}
+		}
+
+		var (
+			v   T
+			err error
+		)
+
+		switch vr.Type() {
+		case bsontype.String:
+			var name string
+
+			if name, err = vr.ReadString(); err == nil {
+				var ok bool
+
+				if v, ok = values[name]; !ok {
+					err = fmt.Errorf("unknown enum %q of %s", name, t)
+				}
+			}
+		case bsontype.Int32:
+			var n int32
+
+			if n, err = vr.ReadInt32(); err == nil {
+				v = T(n)
+			}
+		case bsontype.Int64:
+			var n int64
+
+			if n, err = vr.ReadInt64(); err == nil {
+				v = T(n)
+			}
+		case bsontype.Null:
+			err = vr.ReadNull()
+		default:
+			err = fmt.Errorf("cannot decode %v into %s", vr.Type(), t)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		val.Set(reflect.ValueOf(v))
+
+		return nil
+	})
+
+	return WithMongoCodec(t, enc, dec)
+}
+
+// registry returns the registry with the codecs, nil if no codecs.
+func (o *mongoOptions) registry() *bsoncodec.Registry {
+	if len(o.codecs) == 0 {
+		return nil
+	}
+
+	rb := bson.NewRegistryBuilder()
+
+	for _, f := range o.codecs {
+		f(rb)
+	}
+
+	return rb.Build()
+}
@@ -0,0 +1,105 @@
+package yiigo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+type codecStatus int
+
+const (
+	codecPending codecStatus = iota + 1
+	codecPaid
+)
+
+type codecOrder struct {
+	Amount    decimal.Decimal  `bson:"amount"`
+	Discount  *decimal.Decimal `bson:"discount"`
+	Status    codecStatus      `bson:"status"`
+	CreatedAt time.Time        `bson:"created_at"`
+}
+
+func TestMongoRegistry(t *testing.T) {
+	assert.Nil(t, MongoRegistry())
+
+	loc := time.FixedZone("CST", 8*3600)
+
+	registry := MongoRegistry(
+		WithMongoDecimal(),
+		WithMongoTimeZone(loc),
+		WithMongoEnum(map[codecStatus]string{
+			codecPending: "pending",
+			codecPaid:    "paid",
+		}),
+	)
+
+	now := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+
+	order := &codecOrder{
+		Amount:    decimal.RequireFromString("12345678901234567890.123456789"),
+		Status:    codecPaid,
+		CreatedAt: now,
+	}
+
+	b, err := bson.MarshalWithRegistry(registry, order)
+	assert.Nil(t, err)
+
+	raw := bson.Raw(b)
+	assert.Equal(t, bsontype.Decimal128, raw.Lookup("amount").Type)
+	assert.Equal(t, bsontype.Null, raw.Lookup("discount").Type)
+	assert.Equal(t, "paid", raw.Lookup("status").StringValue())
+
+	ret := new(codecOrder)
+
+	assert.Nil(t, bson.UnmarshalWithRegistry(registry, b, ret))
+	assert.True(t, order.Amount.Equal(ret.Amount))
+	assert.Nil(t, ret.Discount)
+	assert.Equal(t, codecPaid, ret.Status)
+	assert.True(t, now.Equal(ret.CreatedAt))
+	assert.Equal(t, loc, ret.CreatedAt.Location())
+
+	// the legacy data
+	b, err = bson.Marshal(bson.M{"amount": 9.99, "discount": "1.5", "status": 1})
+	assert.Nil(t, err)
+
+	ret = new(codecOrder)
+
+	assert.Nil(t, bson.UnmarshalWithRegistry(registry, b, ret))
+	assert.Equal(t, "9.99", ret.Amount.String())
+	assert.Equal(t, "1.5", ret.Discount.String())
+	assert.Equal(t, codecPending, ret.Status)
+
+	// unknown enum
+	_, err = bson.MarshalWithRegistry(registry, &codecOrder{Status: 3})
+	assert.NotNil(t, err)
+
+	b, err = bson.Marshal(bson.M{"status": "refunded"})
+	assert.Nil(t, err)
+	assert.NotNil(t, bson.UnmarshalWithRegistry(registry, b, new(codecOrder)))
+}
+
+func TestMongoFilterRegistry(t *testing.T) {
+	registry := MongoRegistry(WithMongoEnum(map[codecStatus]string{
+		codecPending: "pending",
+		codecPaid:    "paid",
+	}))
+
+	b, err := bson.MarshalWithRegistry(registry, M.Where("status", In([]codecStatus{codecPending, codecPaid})).D())
+	assert.Nil(t, err)
+
+	filter := struct {
+		Status struct {
+			In []codecStatus `bson:"$in"`
+		} `bson:"status"`
+	}{}
+
+	assert.Nil(t, bson.UnmarshalWithRegistry(registry, b, &filter))
+	assert.Equal(t, []codecStatus{codecPending, codecPaid}, filter.Status.In)
+	assert.Equal(t, "pending", bson.Raw(b).Lookup("status", "$in", "0").StringValue())
+	assert.Equal(t, "paid", bson.Raw(b).Lookup("status", "$in", "1").StringValue())
+}
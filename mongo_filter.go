@@ -0,0 +1,223 @@
+package yiigo
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// M the entry of the mongo filter and update builders, pass the documents (`D`) to the driver, so the values
+// are encoded by the registry of the client (eg: `WithMongoEnum`), eg:
+//
+//	coll.Find(ctx, yiigo.M.Where("status", yiigo.Eq(1)).Where("age", yiigo.Gte(18)).D())
+//	coll.UpdateOne(ctx, yiigo.M.Where("_id", yiigo.Eq(id)).D(), yiigo.M.Set("name", "yiigo").Inc("version", 1).D())
+var M mongoEntry
+
+type mongoEntry struct{}
+
+// Where returns the filter with the condition of the field.
+func (mongoEntry) Where(field string, expr CondExpr) *MongoFilter {
+	return new(MongoFilter).Where(field, expr)
+}
+
+// Or returns the filter which matches any of the filters.
+func (mongoEntry) Or(filters ...*MongoFilter) *MongoFilter {
+	return new(MongoFilter).Or(filters...)
+}
+
+// Set returns the update which sets the field ($set).
+func (mongoEntry) Set(field string, value any) *MongoUpdate {
+	return new(MongoUpdate).Set(field, value)
+}
+
+// Inc returns the update which increments the field by n ($inc).
+func (mongoEntry) Inc(field string, n any) *MongoUpdate {
+	return new(MongoUpdate).Inc(field, n)
+}
+
+// Push returns the update which appends the values to the array field ($push).
+func (mongoEntry) Push(field string, values ...any) *MongoUpdate {
+	return new(MongoUpdate).Push(field, values...)
+}
+
+// MongoFilter the mongo filter built by the conditions, the conditions are combined with AND.
+type MongoFilter struct {
+	fields bson.D
+	ors    []bson.A
+}
+
+// Where adds the condition of the field, the conditions of the same field are merged, eg: {age: {$gte: 18, $lt: 60}},
+// and the same operator of the field is replaced by the latter.
+func (f *MongoFilter) Where(field string, expr CondExpr) *MongoFilter {
+	for i, v := range f.fields {
+		if v.Key != field {
+			continue
+		}
+
+		if ops, ok := v.Value.(bson.D); ok {
+			f.fields[i].Value = mergeOps(ops, expr.mongo())
+
+			return f
+		}
+	}
+
+	f.fields = append(f.fields, bson.E{Key: field, Value: expr.mongo()})
+
+	return f
+}
+
+// WhereRaw adds the raw condition, eg: f.WhereRaw("tags", bson.M{"$all": []string{"a", "b"}})
+func (f *MongoFilter) WhereRaw(key string, value any) *MongoFilter {
+	f.fields = append(f.fields, bson.E{Key: key, Value: value})
+
+	return f
+}
+
+// Or adds the condition which matches any of the filters ($or), multiple Or are combined with AND.
+// Or without filters is ignored.
+func (f *MongoFilter) Or(filters ...*MongoFilter) *MongoFilter {
+	if len(filters) == 0 {
+		return f
+	}
+
+	or := make(bson.A, 0, len(filters))
+
+	for _, v := range filters {
+		or = append(or, v.D())
+	}
+
+	f.ors = append(f.ors, or)
+
+	return f
+}
+
+// mergeOps merges the operators into ops, the existing operator is replaced.
+func mergeOps(ops, merged bson.D) bson.D {
+	for _, e := range merged {
+		replaced := false
+
+		for i, v := range ops {
+			if v.Key == e.Key {
+				ops[i].Value = e.Value
+				replaced = true
+
+				break
+			}
+		}
+
+		if !replaced {
+			ops = append(ops, e)
+		}
+	}
+
+	return ops
+}
+
+// D returns the filter document.
+func (f *MongoFilter) D() bson.D {
+	d := make(bson.D, 0, len(f.fields)+1)
+
+	d = append(d, f.fields...)
+
+	switch len(f.ors) {
+	case 0:
+	case 1:
+		d = append(d, bson.E{Key: "$or", Value: f.ors[0]})
+	default:
+		and := make(bson.A, 0, len(f.ors))
+
+		for _, v := range f.ors {
+			and = append(and, bson.D{{Key: "$or", Value: v}})
+		}
+
+		d = append(d, bson.E{Key: "$and", Value: and})
+	}
+
+	return d
+}
+
+// MongoUpdate the mongo update document, the fields of the same operator are merged, eg: {$set: {a: 1, b: 2}}
+type MongoUpdate struct {
+	ops bson.D
+}
+
+func (u *MongoUpdate) add(op, field string, value any) *MongoUpdate {
+	for i, v := range u.ops {
+		if v.Key == op {
+			u.ops[i].Value = append(v.Value.(bson.D), bson.E{Key: field, Value: value})
+
+			return u
+		}
+	}
+
+	u.ops = append(u.ops, bson.E{Key: op, Value: bson.D{{Key: field, Value: value}}})
+
+	return u
+}
+
+// Set sets the field ($set).
+func (u *MongoUpdate) Set(field string, value any) *MongoUpdate {
+	return u.add("$set", field, value)
+}
+
+// SetOnInsert sets the field if the upsert inserts the document ($setOnInsert).
+func (u *MongoUpdate) SetOnInsert(field string, value any) *MongoUpdate {
+	return u.add("$setOnInsert", field, value)
+}
+
+// Unset removes the fields ($unset).
+func (u *MongoUpdate) Unset(fields ...string) *MongoUpdate {
+	for _, v := range fields {
+		u.add("$unset", v, "")
+	}
+
+	return u
+}
+
+// Inc increments the field by n ($inc).
+func (u *MongoUpdate) Inc(field string, n any) *MongoUpdate {
+	return u.add("$inc", field, n)
+}
+
+// Push appends the values to the array field ($push, with $each for multiple values).
+func (u *MongoUpdate) Push(field string, values ...any) *MongoUpdate {
+	return u.add("$push", field, eachValue(values))
+}
+
+// AddToSet adds the values to the array field unless they already exist ($addToSet, with $each for multiple values).
+func (u *MongoUpdate) AddToSet(field string, values ...any) *MongoUpdate {
+	return u.add("$addToSet", field, eachValue(values))
+}
+
+// Pull removes the elements which match the condition from the array field ($pull), eg: u.Pull("scores", yiigo.Lt(60))
+func (u *MongoUpdate) Pull(field string, expr CondExpr) *MongoUpdate {
+	// the elements equal to the value
+	if expr.op == condEq {
+		return u.add("$pull", field, expr.values[0])
+	}
+
+	return u.add("$pull", field, expr.mongo())
+}
+
+// D returns the update document.
+func (u *MongoUpdate) D() bson.D {
+	return u.ops
+}
+
+// mongoDoc returns the document of the filter (update) builder, or v itself.
+func mongoDoc(v any) any {
+	switch x := v.(type) {
+	case *MongoFilter:
+		return x.D()
+	case *MongoUpdate:
+		return x.D()
+	}
+
+	return v
+}
+
+func eachValue(values []any) any {
+	if len(values) == 1 {
+		return values[0]
+	}
+
+	return bson.D{{Key: "$each", Value: bson.A(values)}}
+}
@@ -0,0 +1,83 @@
+package yiigo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMongoFilter(t *testing.T) {
+	filter := M.Where("status", Eq(1)).
+		Where("age", Gte(18)).
+		Where("age", Lt(60)).
+		Where("uid", In([]int{1, 2})).
+		Where("deleted_at", IsNull()).
+		WhereRaw("tags", bson.M{"$all": bson.A{"a", "b"}}).
+		Or(M.Where("vip", Eq(true)), M.Where("score", Between(80, 100)))
+
+	assert.Equal(t, bson.D{
+		{Key: "status", Value: bson.D{{Key: "$eq", Value: 1}}},
+		{Key: "age", Value: bson.D{{Key: "$gte", Value: 18}, {Key: "$lt", Value: 60}}},
+		{Key: "uid", Value: bson.D{{Key: "$in", Value: bson.A{1, 2}}}},
+		{Key: "deleted_at", Value: bson.D{{Key: "$eq", Value: nil}}},
+		{Key: "tags", Value: bson.M{"$all": bson.A{"a", "b"}}},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "vip", Value: bson.D{{Key: "$eq", Value: true}}}},
+			bson.D{{Key: "score", Value: bson.D{{Key: "$gte", Value: 80}, {Key: "$lte", Value: 100}}}},
+		}},
+	}, filter.D())
+
+	filter = M.Or(M.Where("a", Eq(1)), M.Where("b", Eq(1))).Or(M.Where("c", Eq(1)), M.Where("d", Eq(1)))
+
+	assert.Equal(t, bson.D{
+		{Key: "$and", Value: bson.A{
+			bson.D{{Key: "$or", Value: bson.A{bson.D{{Key: "a", Value: bson.D{{Key: "$eq", Value: 1}}}}, bson.D{{Key: "b", Value: bson.D{{Key: "$eq", Value: 1}}}}}}},
+			bson.D{{Key: "$or", Value: bson.A{bson.D{{Key: "c", Value: bson.D{{Key: "$eq", Value: 1}}}}, bson.D{{Key: "d", Value: bson.D{{Key: "$eq", Value: 1}}}}}}},
+		}},
+	}, filter.D())
+
+	// the same operator is replaced, Or without filters is ignored
+	filter = M.Where("status", Eq(1)).Where("status", Eq(2)).Where("age", Between(18, 60)).Where("age", Gte(20)).Or()
+
+	assert.Equal(t, bson.D{
+		{Key: "status", Value: bson.D{{Key: "$eq", Value: 2}}},
+		{Key: "age", Value: bson.D{{Key: "$gte", Value: 20}, {Key: "$lte", Value: 60}}},
+	}, filter.D())
+
+	assert.Equal(t, bson.D{}, M.Or().D())
+
+	b, err := bson.Marshal(M.Where("status", Eq(1)).D())
+	assert.Nil(t, err)
+
+	m := bson.M{}
+	assert.Nil(t, bson.Unmarshal(b, &m))
+	assert.Equal(t, bson.M{"status": bson.M{"$eq": int32(1)}}, m)
+}
+
+func TestMongoUpdate(t *testing.T) {
+	update := M.Set("name", "yiigo").
+		Set("status", 1).
+		Inc("version", 1).
+		Push("tags", "a").
+		Push("logs", "x", "y").
+		AddToSet("roles", "admin").
+		Pull("scores", Lt(60)).
+		Unset("tmp").
+		SetOnInsert("created_at", 100)
+
+	assert.Equal(t, bson.D{
+		{Key: "$set", Value: bson.D{{Key: "name", Value: "yiigo"}, {Key: "status", Value: 1}}},
+		{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+		{Key: "$push", Value: bson.D{{Key: "tags", Value: "a"}, {Key: "logs", Value: bson.D{{Key: "$each", Value: bson.A{"x", "y"}}}}}},
+		{Key: "$addToSet", Value: bson.D{{Key: "roles", Value: "admin"}}},
+		{Key: "$pull", Value: bson.D{{Key: "scores", Value: bson.D{{Key: "$lt", Value: 60}}}}},
+		{Key: "$unset", Value: bson.D{{Key: "tmp", Value: ""}}},
+		{Key: "$setOnInsert", Value: bson.D{{Key: "created_at", Value: 100}}},
+	}, update.D())
+
+	_, err := bson.Marshal(update.D())
+	assert.Nil(t, err)
+
+	assert.Equal(t, update.D(), mongoDoc(update))
+}
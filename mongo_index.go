@@ -0,0 +1,518 @@
+package yiigo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// MongoIndex the index definition of the collection.
+type MongoIndex struct {
+	// Name the index name, default: generated by the keys like the driver, eg: uid_1_created_at_-1
+	Name string
+
+	// Keys the index keys, eg: yiigo.IndexKeys("uid", "-created_at") or bson.D{{Key: "location", Value: "2dsphere"}}
+	Keys bson.D
+
+	Unique bool
+	Sparse bool
+
+	// ExpireAfterSeconds the TTL of the documents, nil if not the TTL index.
+	ExpireAfterSeconds *int32
+
+	// PartialFilter the partial filter expression, eg: bson.M{"deleted_at": nil}
+	PartialFilter any
+}
+
+func (idx *MongoIndex) name() string {
+	if len(idx.Name) != 0 {
+		return idx.Name
+	}
+
+	parts := make([]string, 0, len(idx.Keys))
+
+	for _, v := range idx.Keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", v.Key, v.Value))
+	}
+
+	return strings.Join(parts, "_")
+}
+
+func (idx *MongoIndex) model() mongo.IndexModel {
+	opts := options.Index().SetName(idx.name())
+
+	if idx.Unique {
+		opts.SetUnique(true)
+	}
+
+	if idx.Sparse {
+		opts.SetSparse(true)
+	}
+
+	if idx.ExpireAfterSeconds != nil {
+		opts.SetExpireAfterSeconds(*idx.ExpireAfterSeconds)
+	}
+
+	if idx.PartialFilter != nil {
+		opts.SetPartialFilterExpression(idx.PartialFilter)
+	}
+
+	return mongo.IndexModel{
+		Keys:    idx.Keys,
+		Options: opts,
+	}
+}
+
+// IndexKeys returns the ascending index keys, the field prefixed by "-" is descending, eg: yiigo.IndexKeys("uid", "-created_at")
+func IndexKeys(fields ...string) bson.D {
+	keys := make(bson.D, 0, len(fields))
+
+	for _, v := range fields {
+		if strings.HasPrefix(v, "-") {
+			keys = append(keys, bson.E{Key: v[1:], Value: -1})
+		} else {
+			keys = append(keys, bson.E{Key: v, Value: 1})
+		}
+	}
+
+	return keys
+}
+
+// MongoCollIndexes the indexes of the collection.
+type MongoCollIndexes struct {
+	Client  string // the mongo client name, default: yiigo.Default
+	DB      string
+	Coll    string
+	Indexes []*MongoIndex
+}
+
+func (c *MongoCollIndexes) ns() string {
+	return fmt.Sprintf("%s.%s.%s", c.Client, c.DB, c.Coll)
+}
+
+var mongoIndexes = &mongoIndexRegistry{}
+
+type mongoIndexRegistry struct {
+	colls []*MongoCollIndexes
+	mutex sync.Mutex
+}
+
+func (r *mongoIndexRegistry) register(colls ...*MongoCollIndexes) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, c := range colls {
+		v := &MongoCollIndexes{
+			Client:  c.Client,
+			DB:      c.DB,
+			Coll:    c.Coll,
+			Indexes: append(make([]*MongoIndex, 0, len(c.Indexes)), c.Indexes...),
+		}
+
+		if len(v.Client) == 0 {
+			v.Client = Default
+		}
+
+		merged := false
+
+		// the indexes of the same collection are merged
+		for _, e := range r.colls {
+			if e.ns() == v.ns() {
+				e.Indexes = append(e.Indexes, v.Indexes...)
+				merged = true
+
+				break
+			}
+		}
+
+		if !merged {
+			r.colls = append(r.colls, v)
+		}
+	}
+}
+
+func (r *mongoIndexRegistry) all() []*MongoCollIndexes {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append(make([]*MongoCollIndexes, 0, len(r.colls)), r.colls...)
+}
+
+// RegisterMongoIndexes registers the index definitions of the collections, which are applied by `EnsureIndexes`, eg:
+//
+//	yiigo.RegisterMongoIndexes(&yiigo.MongoCollIndexes{
+//		DB:   "app",
+//		Coll: "orders",
+//		Indexes: []*yiigo.MongoIndex{
+//			{Keys: yiigo.IndexKeys("uid", "-created_at")},
+//			{Keys: yiigo.IndexKeys("order_no"), Unique: true},
+//		},
+//	})
+func RegisterMongoIndexes(colls ...*MongoCollIndexes) {
+	mongoIndexes.register(colls...)
+}
+
+// IndexAction the action of the index change.
+type IndexAction string
+
+// The actions of the index change
+const (
+	IndexCreate IndexAction = "create"
+	IndexDrop   IndexAction = "drop"
+)
+
+// IndexChange the change applied (to apply if dry run) to the collection.
+type IndexChange struct {
+	Client string
+	DB     string
+	Coll   string
+	Action IndexAction
+	Index  string
+	Keys   bson.D
+}
+
+type ensureIndexOptions struct {
+	drop   bool
+	dryRun bool
+}
+
+// EnsureIndexOption ensure index option
+type EnsureIndexOption func(o *ensureIndexOptions)
+
+// WithIndexDrop drops the indexes which are not defined (except _id_), and the defined indexes whose options are changed
+// are dropped and recreated. Without it, the changed indexes are skipped with the warning.
+func WithIndexDrop() EnsureIndexOption {
+	return func(o *ensureIndexOptions) {
+		o.drop = true
+	}
+}
+
+// WithIndexDryRun returns (and logs) the changes to apply without applying them.
+func WithIndexDryRun() EnsureIndexOption {
+	return func(o *ensureIndexOptions) {
+		o.dryRun = true
+	}
+}
+
+// EnsureIndexes diffs the registered indexes (see `RegisterMongoIndexes`) against the existing indexes of the collections,
+// creates the missing indexes and drops the undefined ones (see `WithIndexDrop`), returns the applied changes.
+// NOTE: The index is matched by the name, building the index on the large collection may take a long time.
+func EnsureIndexes(ctx context.Context, options ...EnsureIndexOption) ([]*IndexChange, error) {
+	o := new(ensureIndexOptions)
+
+	for _, f := range options {
+		f(o)
+	}
+
+	changes := make([]*IndexChange, 0)
+
+	for _, c := range mongoIndexes.all() {
+		view := &mongoIndexView{iv: Mongo(c.Client).Database(c.DB).Collection(c.Coll).Indexes()}
+
+		applied, err := ensureCollIndexes(ctx, view, c, o)
+
+		changes = append(changes, applied...)
+
+		if err != nil {
+			return changes, fmt.Errorf("ensure indexes of %s: %w", c.ns(), err)
+		}
+	}
+
+	return changes, nil
+}
+
+// indexView is the index operations of the collection (mongo.IndexView).
+type indexView interface {
+	List(ctx context.Context) ([]bson.Raw, error)
+	Create(ctx context.Context, model mongo.IndexModel) error
+	Drop(ctx context.Context, name string) error
+}
+
+type mongoIndexView struct {
+	iv mongo.IndexView
+}
+
+func (v *mongoIndexView) List(ctx context.Context) ([]bson.Raw, error) {
+	cursor, err := v.iv.List(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	indexes := make([]bson.Raw, 0)
+
+	for cursor.Next(ctx) {
+		indexes = append(indexes, append(bson.Raw{}, cursor.Current...))
+	}
+
+	return indexes, cursor.Err()
+}
+
+func (v *mongoIndexView) Create(ctx context.Context, model mongo.IndexModel) error {
+	_, err := v.iv.CreateOne(ctx, model)
+
+	return err
+}
+
+func (v *mongoIndexView) Drop(ctx context.Context, name string) error {
+	_, err := v.iv.DropOne(ctx, name)
+
+	return err
+}
+
+func ensureCollIndexes(ctx context.Context, view indexView, c *MongoCollIndexes, o *ensureIndexOptions) ([]*IndexChange, error) {
+	existing, err := view.List(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	drops, creates := diffIndexes(c, existing, o.drop)
+
+	changes := make([]*IndexChange, 0, len(drops)+len(creates))
+
+	// drop first, since the recreated index has the same name
+	for _, v := range drops {
+		change := &IndexChange{
+			Client: c.Client,
+			DB:     c.DB,
+			Coll:   c.Coll,
+			Action: IndexDrop,
+			Index:  v.Lookup("name").StringValue(),
+			Keys:   rawIndexKeys(v),
+		}
+
+		if o.dryRun {
+			logger.Info("ensure index (dry run)", zap.String("ns", c.ns()), zap.String("action", string(IndexDrop)), zap.String("index", change.Index))
+
+			changes = append(changes, change)
+
+			continue
+		}
+
+		if err = view.Drop(ctx, change.Index); err != nil {
+			return changes, fmt.Errorf("drop index %s: %w", change.Index, err)
+		}
+
+		logger.Info("ensure index", zap.String("ns", c.ns()), zap.String("action", string(IndexDrop)), zap.String("index", change.Index))
+
+		changes = append(changes, change)
+	}
+
+	for _, v := range creates {
+		change := &IndexChange{
+			Client: c.Client,
+			DB:     c.DB,
+			Coll:   c.Coll,
+			Action: IndexCreate,
+			Index:  v.name(),
+			Keys:   v.Keys,
+		}
+
+		if o.dryRun {
+			logger.Info("ensure index (dry run)", zap.String("ns", c.ns()), zap.String("action", string(IndexCreate)), zap.String("index", change.Index))
+
+			changes = append(changes, change)
+
+			continue
+		}
+
+		if err = view.Create(ctx, v.model()); err != nil {
+			return changes, fmt.Errorf("create index %s: %w", change.Index, err)
+		}
+
+		logger.Info("ensure index", zap.String("ns", c.ns()), zap.String("action", string(IndexCreate)), zap.String("index", change.Index))
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// diffIndexes returns the existing indexes to drop (ordered by name) and the defined indexes to create.
+func diffIndexes(c *MongoCollIndexes, existing []bson.Raw, drop bool) ([]bson.Raw, []*MongoIndex) {
+	defined := make(map[string]*MongoIndex, len(c.Indexes))
+
+	for _, v := range c.Indexes {
+		defined[v.name()] = v
+	}
+
+	current := make(map[string]bson.Raw, len(existing))
+
+	drops := make([]bson.Raw, 0)
+
+	for _, v := range existing {
+		name := v.Lookup("name").StringValue()
+
+		if name == "_id_" {
+			continue
+		}
+
+		current[name] = v
+
+		idx, ok := defined[name]
+
+		if !ok {
+			if drop {
+				drops = append(drops, v)
+			}
+
+			continue
+		}
+
+		if !sameIndex(idx, v) {
+			if drop {
+				drops = append(drops, v)
+
+				continue
+			}
+
+			logger.Warn(fmt.Sprintf("index %s of %s is changed, skipped without drop", name, c.ns()))
+		}
+	}
+
+	sort.Slice(drops, func(i, j int) bool {
+		return drops[i].Lookup("name").StringValue() < drops[j].Lookup("name").StringValue()
+	})
+
+	creates := make([]*MongoIndex, 0)
+
+	for _, v := range c.Indexes {
+		raw, ok := current[v.name()]
+
+		if !ok || (drop && !sameIndex(v, raw)) {
+			creates = append(creates, v)
+		}
+	}
+
+	return drops, creates
+}
+
+// sameIndex reports whether the existing index matches the definition.
+func sameIndex(idx *MongoIndex, existing bson.Raw) bool {
+	keys, err := bson.Marshal(idx.Keys)
+
+	if err != nil || !sameBSONValue(bson.RawValue{Type: bson.TypeEmbeddedDocument, Value: keys}, existing.Lookup("key"), true) {
+		return false
+	}
+
+	if idx.Unique != lookupBool(existing, "unique") || idx.Sparse != lookupBool(existing, "sparse") {
+		return false
+	}
+
+	ttl, ok := bsonNumber(existing.Lookup("expireAfterSeconds"))
+
+	if idx.ExpireAfterSeconds == nil {
+		if ok {
+			return false
+		}
+	} else if !ok || float64(*idx.ExpireAfterSeconds) != ttl {
+		return false
+	}
+
+	filter := existing.Lookup("partialFilterExpression")
+
+	if idx.PartialFilter == nil {
+		return filter.Type == 0
+	}
+
+	b, err := bson.Marshal(idx.PartialFilter)
+
+	return err == nil && sameBSONValue(bson.RawValue{Type: bson.TypeEmbeddedDocument, Value: b}, filter, false)
+}
+
+func lookupBool(doc bson.Raw, key string) bool {
+	b, ok := doc.Lookup(key).BooleanOK()
+
+	return ok && b
+}
+
+// sameBSONValue compares the values, the numbers are compared by value (eg: int32(1) and float64(1) are equal),
+// and the fields of the documents are compared regardless of the order if not ordered.
+func sameBSONValue(a, b bson.RawValue, ordered bool) bool {
+	if x, ok := bsonNumber(a); ok {
+		y, ok := bsonNumber(b)
+
+		return ok && x == y
+	}
+
+	if a.Type != b.Type {
+		return false
+	}
+
+	if a.Type != bson.TypeEmbeddedDocument && a.Type != bson.TypeArray {
+		return bytes.Equal(a.Value, b.Value)
+	}
+
+	x, err := bson.Raw(a.Value).Elements()
+
+	if err != nil {
+		return false
+	}
+
+	y, err := bson.Raw(b.Value).Elements()
+
+	if err != nil || len(x) != len(y) {
+		return false
+	}
+
+	// the order of the fields matters for the keys, but not for the filter
+	if !ordered && a.Type == bson.TypeEmbeddedDocument {
+		fields := make(map[string]bson.RawValue, len(y))
+
+		for _, v := range y {
+			fields[v.Key()] = v.Value()
+		}
+
+		for _, v := range x {
+			f, ok := fields[v.Key()]
+
+			if !ok || !sameBSONValue(v.Value(), f, ordered) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	for i := range x {
+		if x[i].Key() != y[i].Key() || !sameBSONValue(x[i].Value(), y[i].Value(), ordered) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func bsonNumber(v bson.RawValue) (float64, bool) {
+	switch v.Type {
+	case bson.TypeInt32:
+		return float64(v.Int32()), true
+	case bson.TypeInt64:
+		return float64(v.Int64()), true
+	case bson.TypeDouble:
+		return v.Double(), true
+	}
+
+	return 0, false
+}
+
+func rawIndexKeys(v bson.Raw) bson.D {
+	keys := bson.D{}
+
+	if doc, ok := v.Lookup("key").DocumentOK(); ok {
+		_ = bson.Unmarshal(doc, &keys)
+	}
+
+	return keys
+}
@@ -0,0 +1,163 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type fakeIndexView struct {
+	indexes []bson.Raw
+	creates []string
+	drops   []string
+}
+
+func (v *fakeIndexView) List(context.Context) ([]bson.Raw, error) {
+	return v.indexes, nil
+}
+
+func (v *fakeIndexView) Create(_ context.Context, model mongo.IndexModel) error {
+	v.creates = append(v.creates, *model.Options.Name)
+
+	return nil
+}
+
+func (v *fakeIndexView) Drop(_ context.Context, name string) error {
+	v.drops = append(v.drops, name)
+
+	return nil
+}
+
+func existingIndex(t *testing.T, doc bson.D) bson.Raw {
+	b, err := bson.Marshal(doc)
+
+	assert.Nil(t, err)
+
+	return b
+}
+
+func TestIndexKeys(t *testing.T) {
+	assert.Equal(t, bson.D{{Key: "uid", Value: 1}, {Key: "created_at", Value: -1}}, IndexKeys("uid", "-created_at"))
+
+	idx := &MongoIndex{Keys: IndexKeys("uid", "-created_at")}
+	assert.Equal(t, "uid_1_created_at_-1", idx.name())
+
+	idx = &MongoIndex{Keys: bson.D{{Key: "location", Value: "2dsphere"}}}
+	assert.Equal(t, "location_2dsphere", idx.name())
+}
+
+func TestEnsureIndexes(t *testing.T) {
+	ttl := int32(3600)
+
+	c := &MongoCollIndexes{
+		Client: Default,
+		DB:     "app",
+		Coll:   "orders",
+		Indexes: []*MongoIndex{
+			{Keys: IndexKeys("uid", "-created_at")},
+			{Keys: IndexKeys("order_no"), Unique: true},
+			{Name: "ttl", Keys: IndexKeys("expired_at"), ExpireAfterSeconds: &ttl},
+			{Keys: IndexKeys("coupon"), PartialFilter: bson.M{"coupon": bson.M{"$exists": true}}},
+		},
+	}
+
+	newView := func() *fakeIndexView {
+		return &fakeIndexView{
+			indexes: []bson.Raw{
+				existingIndex(t, bson.D{{Key: "v", Value: 2}, {Key: "key", Value: bson.D{{Key: "_id", Value: 1}}}, {Key: "name", Value: "_id_"}}),
+				// same, the number type differs
+				existingIndex(t, bson.D{{Key: "v", Value: 2}, {Key: "key", Value: bson.D{{Key: "uid", Value: int32(1)}, {Key: "created_at", Value: float64(-1)}}}, {Key: "name", Value: "uid_1_created_at_-1"}}),
+				// changed, not unique
+				existingIndex(t, bson.D{{Key: "v", Value: 2}, {Key: "key", Value: bson.D{{Key: "order_no", Value: 1}}}, {Key: "name", Value: "order_no_1"}}),
+				existingIndex(t, bson.D{{Key: "v", Value: 2}, {Key: "key", Value: bson.D{{Key: "expired_at", Value: 1}}}, {Key: "name", Value: "ttl"}, {Key: "expireAfterSeconds", Value: int64(3600)}}),
+				// undefined
+				existingIndex(t, bson.D{{Key: "v", Value: 2}, {Key: "key", Value: bson.D{{Key: "status", Value: 1}}}, {Key: "name", Value: "status_1"}}),
+			},
+		}
+	}
+
+	ctx := context.Background()
+
+	// without drop, the changed index is skipped
+	view := newView()
+
+	changes, err := ensureCollIndexes(ctx, view, c, &ensureIndexOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, IndexCreate, changes[0].Action)
+	assert.Equal(t, "coupon_1", changes[0].Index)
+	assert.Equal(t, []string{"coupon_1"}, view.creates)
+	assert.Nil(t, view.drops)
+
+	// with drop, the changed index is recreated
+	view = newView()
+
+	changes, err = ensureCollIndexes(ctx, view, c, &ensureIndexOptions{drop: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(changes))
+	assert.Equal(t, []string{"order_no_1", "status_1"}, view.drops)
+	assert.Equal(t, []string{"order_no_1", "coupon_1"}, view.creates)
+	assert.Equal(t, bson.D{{Key: "status", Value: int32(1)}}, changes[1].Keys)
+
+	// dry run
+	view = newView()
+
+	changes, err = ensureCollIndexes(ctx, view, c, &ensureIndexOptions{drop: true, dryRun: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(changes))
+	assert.Nil(t, view.drops)
+	assert.Nil(t, view.creates)
+
+	// up to date
+	c.Indexes = c.Indexes[:1]
+	view = newView()
+
+	changes, err = ensureCollIndexes(ctx, view, c, &ensureIndexOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(changes))
+}
+
+func TestSameIndex(t *testing.T) {
+	idx := &MongoIndex{
+		Keys:          IndexKeys("uid", "status"),
+		PartialFilter: bson.D{{Key: "status", Value: bson.M{"$gt": 0}}, {Key: "deleted", Value: false}},
+	}
+
+	// the order of the filter fields doesn't matter
+	existing := existingIndex(t, bson.D{
+		{Key: "key", Value: bson.D{{Key: "uid", Value: 1}, {Key: "status", Value: 1}}},
+		{Key: "partialFilterExpression", Value: bson.D{{Key: "deleted", Value: false}, {Key: "status", Value: bson.D{{Key: "$gt", Value: int32(0)}}}}},
+	})
+	assert.True(t, sameIndex(idx, existing))
+
+	// the order of the keys matters
+	existing = existingIndex(t, bson.D{
+		{Key: "key", Value: bson.D{{Key: "status", Value: 1}, {Key: "uid", Value: 1}}},
+		{Key: "partialFilterExpression", Value: bson.D{{Key: "status", Value: bson.D{{Key: "$gt", Value: 0}}}, {Key: "deleted", Value: false}}},
+	})
+	assert.False(t, sameIndex(idx, existing))
+
+	// the filter is changed
+	existing = existingIndex(t, bson.D{
+		{Key: "key", Value: bson.D{{Key: "uid", Value: 1}, {Key: "status", Value: 1}}},
+		{Key: "partialFilterExpression", Value: bson.D{{Key: "status", Value: bson.D{{Key: "$gt", Value: 0}}}}},
+	})
+	assert.False(t, sameIndex(idx, existing))
+}
+
+func TestRegisterMongoIndexes(t *testing.T) {
+	r := &mongoIndexRegistry{}
+
+	r.register(&MongoCollIndexes{DB: "app", Coll: "orders", Indexes: []*MongoIndex{{Keys: IndexKeys("uid")}}})
+	r.register(&MongoCollIndexes{DB: "app", Coll: "orders", Indexes: []*MongoIndex{{Keys: IndexKeys("order_no")}}})
+	r.register(&MongoCollIndexes{Client: "other", DB: "app", Coll: "orders"})
+
+	colls := r.all()
+	assert.Equal(t, 2, len(colls))
+	assert.Equal(t, Default, colls[0].Client)
+	assert.Equal(t, 2, len(colls[0].Indexes))
+	assert.Equal(t, "other", colls[1].Client)
+}
@@ -0,0 +1,94 @@
+package yiigo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// MongoMetrics is the Prometheus collector of the commands of the mongo clients, eg:
+//
+//	metrics := yiigo.NewMongoMetrics("app")
+//	prometheus.MustRegister(metrics)
+//
+//	yiigo.Init(yiigo.WithMongo(yiigo.Default, dsn, yiigo.WithMongoMetrics(metrics)))
+//
+// The metrics (labeled by collection and command):
+//   - {namespace}_mongo_commands_total: the number of the commands, labeled by status (ok, error) as well
+//   - {namespace}_mongo_command_duration_seconds: the histogram of the command duration
+type MongoMetrics struct {
+	commands *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewMongoMetrics returns new MongoMetrics, the buckets default to prometheus.DefBuckets.
+func NewMongoMetrics(namespace string, buckets ...float64) *MongoMetrics {
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+
+	return &MongoMetrics{
+		commands: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "mongo",
+			Name:      "commands_total",
+			Help:      "The number of the executed commands.",
+		}, []string{"collection", "command", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "mongo",
+			Name:      "command_duration_seconds",
+			Help:      "The duration of the executed commands.",
+			Buckets:   buckets,
+		}, []string{"collection", "command"}),
+	}
+}
+
+func (m *MongoMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.commands.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+func (m *MongoMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.commands.Collect(ch)
+	m.duration.Collect(ch)
+}
+
+// monitor returns the command monitor which records the metrics.
+func (m *MongoMetrics) monitor() *event.CommandMonitor {
+	// the collections of the started commands
+	var colls sync.Map
+
+	observe := func(evt *event.CommandFinishedEvent, status string) {
+		v, ok := colls.LoadAndDelete(mongoCommandKey{conn: evt.ConnectionID, id: evt.RequestID})
+
+		if !ok {
+			return
+		}
+
+		coll := v.(string)
+
+		m.commands.WithLabelValues(coll, evt.CommandName, status).Inc()
+		m.duration.WithLabelValues(coll, evt.CommandName).Observe(time.Duration(evt.DurationNanos).Seconds())
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			colls.Store(mongoCommandKey{conn: evt.ConnectionID, id: evt.RequestID}, mongoCommandCollection(evt.CommandName, evt.Command))
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			observe(&evt.CommandFinishedEvent, "ok")
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			observe(&evt.CommandFinishedEvent, "error")
+		},
+	}
+}
+
+// WithMongoMetrics records the metrics of the commands of the client.
+func WithMongoMetrics(m *MongoMetrics) MongoOption {
+	return WithMongoMonitor(m.monitor())
+}
@@ -0,0 +1,40 @@
+package yiigo
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMongoMetrics(t *testing.T) {
+	ctx := context.TODO()
+
+	metrics := NewMongoMetrics("test")
+
+	recorder := tracetest.NewSpanRecorder()
+
+	// chained with the tracing
+	m := MongoMonitor(WithMongoMetrics(metrics), WithMongoTracing(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))))
+
+	runMongoCommand(ctx, t, m, 1, bson.D{{Key: "find", Value: "users"}}, "")
+	runMongoCommand(ctx, t, m, 2, bson.D{{Key: "find", Value: "users"}}, "")
+	runMongoCommand(ctx, t, m, 3, bson.D{{Key: "update", Value: "orders"}}, "WriteConflict")
+
+	assert.Len(t, recorder.Ended(), 3)
+
+	expected := `
+# HELP test_mongo_commands_total The number of the executed commands.
+# TYPE test_mongo_commands_total counter
+test_mongo_commands_total{collection="orders",command="update",status="error"} 1
+test_mongo_commands_total{collection="users",command="find",status="ok"} 2
+`
+
+	assert.Nil(t, testutil.CollectAndCompare(metrics, strings.NewReader(expected), "test_mongo_commands_total"))
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics, "test_mongo_command_duration_seconds"))
+}
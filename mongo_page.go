@@ -0,0 +1,221 @@
+package yiigo
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrInvalidCursor the cursor of the pagination is invalid.
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// mongoFinder is the queries of *mongo.Collection.
+type mongoFinder interface {
+	Find(ctx context.Context, filter any, opts ...*options.FindOptions) (*mongo.Cursor, error)
+	CountDocuments(ctx context.Context, filter any, opts ...*options.CountOptions) (int64, error)
+}
+
+// MongoPaginate returns the documents of the page (starts from 1) and the total by skip/limit,
+// the sort and projection are specified by opts, eg:
+//
+//	page, err := yiigo.MongoPaginate[User](ctx, coll, yiigo.M.Where("status", yiigo.Eq(1)), 2, 20, options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}))
+//
+// NOTE: The skip is slow for the deep pages, use `MongoCursorPaginate` instead.
+func MongoPaginate[T any](ctx context.Context, coll *mongo.Collection, filter any, page, size int, opts ...*options.FindOptions) (*Page[T], error) {
+	return mongoPaginate[T](ctx, coll, filter, page, size, opts...)
+}
+
+func mongoPaginate[T any](ctx context.Context, coll mongoFinder, filter any, page, size int, opts ...*options.FindOptions) (*Page[T], error) {
+	if page < 1 {
+		page = 1
+	}
+
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	filter = mongoDoc(filter)
+
+	total, err := coll.CountDocuments(ctx, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Page[T]{
+		Items: make([]T, 0),
+		Total: total,
+		Page:  page,
+		Size:  size,
+	}
+
+	offset := int64(page-1) * int64(size)
+
+	if total == 0 || offset >= total {
+		return result, nil
+	}
+
+	o := options.MergeFindOptions(opts...).SetSkip(offset).SetLimit(int64(size))
+
+	cursor, err := coll.Find(ctx, filter, o)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cursor.All(ctx, &result.Items); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// MongoCursorPaginate returns the documents after the cursor (empty for the first page) ordered by the sort key
+// (prefixed by "-" if descending, default: _id), and `Page.Next` is the cursor of the next page (empty if no more).
+// The _id is the tiebreaker of the sort key, so the documents of the same key are neither skipped nor duplicated, eg:
+//
+//	page, err := yiigo.MongoCursorPaginate[Order](ctx, coll, yiigo.M.Where("uid", yiigo.Eq(uid)), cursor, 20, "-created_at")
+//
+// NOTE: The total is not counted, the sort key should exist in the documents and be indexed with _id, eg: {created_at: -1, _id: -1}.
+func MongoCursorPaginate[T any](ctx context.Context, coll *mongo.Collection, filter any, cursor string, size int, sortKey string, opts ...*options.FindOptions) (*Page[T], error) {
+	return mongoCursorPaginate[T](ctx, coll, filter, cursor, size, sortKey, opts...)
+}
+
+func mongoCursorPaginate[T any](ctx context.Context, coll mongoFinder, filter any, cursor string, size int, sortKey string, opts ...*options.FindOptions) (*Page[T], error) {
+	field, order := sortKey, 1
+
+	if strings.HasPrefix(field, "-") {
+		field, order = field[1:], -1
+	}
+
+	if len(field) == 0 {
+		field = "_id"
+	}
+
+	sort := bson.D{{Key: field, Value: order}}
+
+	if field != "_id" {
+		sort = append(sort, bson.E{Key: "_id", Value: order})
+	}
+
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	filter = mongoDoc(filter)
+
+	if len(cursor) != 0 {
+		after, err := cursorFilter(cursor, field, order)
+
+		if err != nil {
+			return nil, err
+		}
+
+		filter = bson.D{{Key: "$and", Value: bson.A{filter, after}}}
+	}
+
+	// one more to know whether there is the next page
+	o := options.MergeFindOptions(opts...).SetSort(sort).SetLimit(int64(size) + 1)
+
+	c, err := coll.Find(ctx, filter, o)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.Close(ctx)
+
+	result := &Page[T]{
+		Items: make([]T, 0, size),
+		Size:  size,
+	}
+
+	var last bson.Raw
+
+	for c.Next(ctx) {
+		if len(result.Items) == size {
+			if result.Next, err = encodeCursor(last, field); err != nil {
+				return nil, err
+			}
+
+			break
+		}
+
+		var v T
+
+		if err = c.Decode(&v); err != nil {
+			return nil, err
+		}
+
+		result.Items = append(result.Items, v)
+
+		last = append(last[:0], c.Current...)
+	}
+
+	if err = c.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+type pageCursor struct {
+	Value bson.RawValue `bson:"v"`
+	ID    bson.RawValue `bson:"id"`
+}
+
+// encodeCursor encodes the sort key and the _id of the document.
+func encodeCursor(doc bson.Raw, field string) (string, error) {
+	c := pageCursor{
+		Value: doc.Lookup(strings.Split(field, ".")...),
+		ID:    doc.Lookup("_id"),
+	}
+
+	// the missing field is sorted as null
+	if c.Value.Type == 0 {
+		c.Value = bson.RawValue{Type: bson.TypeNull}
+	}
+
+	b, err := bson.Marshal(c)
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// cursorFilter returns the filter of the documents after the cursor.
+func cursorFilter(cursor, field string, order int) (bson.D, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	c := new(pageCursor)
+
+	if err = bson.Unmarshal(b, c); err != nil || c.ID.Type == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	op := "$gt"
+
+	if order < 0 {
+		op = "$lt"
+	}
+
+	if field == "_id" {
+		return bson.D{{Key: "_id", Value: bson.D{{Key: op, Value: c.ID}}}}, nil
+	}
+
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: field, Value: bson.D{{Key: op, Value: c.Value}}}},
+		bson.D{{Key: field, Value: c.Value}, {Key: "_id", Value: bson.D{{Key: op, Value: c.ID}}}},
+	}}}, nil
+}
@@ -0,0 +1,119 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type fakeMongoFinder struct {
+	total  int64
+	docs   []any
+	finds  int
+	filter any
+	opts   *options.FindOptions
+}
+
+func (f *fakeMongoFinder) Find(_ context.Context, filter any, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	f.finds++
+	f.filter = filter
+	f.opts = options.MergeFindOptions(opts...)
+
+	return mongo.NewCursorFromDocuments(f.docs, nil, nil)
+}
+
+func (f *fakeMongoFinder) CountDocuments(context.Context, any, ...*options.CountOptions) (int64, error) {
+	return f.total, nil
+}
+
+type pageDoc struct {
+	ID        int    `bson:"_id"`
+	CreatedAt int64  `bson:"created_at"`
+	Name      string `bson:"name"`
+}
+
+func extJSON(t *testing.T, v any) string {
+	b, err := bson.MarshalExtJSON(v, false, false)
+
+	assert.Nil(t, err)
+
+	return string(b)
+}
+
+func TestMongoPaginate(t *testing.T) {
+	ctx := context.TODO()
+
+	f := &fakeMongoFinder{
+		total: 45,
+		docs:  []any{bson.M{"_id": 41, "name": "a"}, bson.M{"_id": 42, "name": "b"}},
+	}
+
+	page, err := mongoPaginate[pageDoc](ctx, f, M.Where("status", Eq(1)), 3, 20, options.Find().SetSort(bson.M{"_id": 1}))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(45), page.Total)
+	assert.Equal(t, 3, page.Page)
+	assert.Equal(t, 20, page.Size)
+	assert.Equal(t, []pageDoc{{ID: 41, Name: "a"}, {ID: 42, Name: "b"}}, page.Items)
+	assert.Equal(t, int64(40), *f.opts.Skip)
+	assert.Equal(t, int64(20), *f.opts.Limit)
+	assert.Equal(t, bson.M{"_id": 1}, f.opts.Sort)
+
+	// out of range
+	page, err = mongoPaginate[pageDoc](ctx, f, nil, 4, 20)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(page.Items))
+	assert.Equal(t, 1, f.finds)
+}
+
+func TestMongoCursorPaginate(t *testing.T) {
+	ctx := context.TODO()
+
+	f := &fakeMongoFinder{
+		docs: []any{
+			bson.M{"_id": 3, "created_at": int64(300)},
+			bson.M{"_id": 2, "created_at": int64(200)},
+			bson.M{"_id": 1, "created_at": int64(200)},
+		},
+	}
+
+	// first page
+	page, err := mongoCursorPaginate[pageDoc](ctx, f, nil, "", 2, "-created_at")
+	assert.Nil(t, err)
+	assert.Equal(t, []pageDoc{{ID: 3, CreatedAt: 300}, {ID: 2, CreatedAt: 200}}, page.Items)
+	assert.NotEmpty(t, page.Next)
+	assert.Equal(t, int64(0), page.Total)
+	assert.Equal(t, `{}`, extJSON(t, f.filter))
+	assert.Equal(t, bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}, f.opts.Sort)
+	assert.Equal(t, int64(3), *f.opts.Limit)
+
+	// next page
+	f.docs = f.docs[2:]
+
+	page, err = mongoCursorPaginate[pageDoc](ctx, f, M.Where("uid", Eq(1)), page.Next, 2, "-created_at")
+	assert.Nil(t, err)
+	assert.Equal(t, []pageDoc{{ID: 1, CreatedAt: 200}}, page.Items)
+	assert.Empty(t, page.Next)
+	assert.Equal(t, `{"$and":[{"uid":{"$eq":1}},{"$or":[{"created_at":{"$lt":200}},{"created_at":200,"_id":{"$lt":2}}]}]}`, extJSON(t, f.filter))
+
+	// by _id
+	f.docs = []any{bson.M{"_id": 1}, bson.M{"_id": 2}}
+
+	page, err = mongoCursorPaginate[pageDoc](ctx, f, nil, "", 1, "")
+	assert.Nil(t, err)
+	assert.Equal(t, bson.D{{Key: "_id", Value: 1}}, f.opts.Sort)
+
+	_, err = mongoCursorPaginate[pageDoc](ctx, f, nil, page.Next, 1, "")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"$and":[{},{"_id":{"$gt":1}}]}`, extJSON(t, f.filter))
+
+	// invalid cursor
+	_, err = mongoCursorPaginate[pageDoc](ctx, f, nil, "!invalid", 1, "")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = mongoCursorPaginate[pageDoc](ctx, f, nil, "e30", 1, "")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
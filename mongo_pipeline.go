@@ -0,0 +1,258 @@
+package yiigo
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type pipelineBuilder struct {
+	stages mongo.Pipeline
+}
+
+// merge appends the field to the last stage if it's the same operator, otherwise appends a new stage.
+func (p *pipelineBuilder) merge(op string, e bson.E) {
+	if n := len(p.stages); n != 0 && len(p.stages[n-1]) == 1 && p.stages[n-1][0].Key == op {
+		if d, ok := p.stages[n-1][0].Value.(bson.D); ok {
+			p.stages[n-1][0].Value = append(d, e)
+
+			return
+		}
+	}
+
+	p.stages = append(p.stages, bson.D{{Key: op, Value: bson.D{e}}})
+}
+
+// PipelineOption aggregation pipeline stage option
+type PipelineOption func(p *pipelineBuilder)
+
+// NewPipeline returns the aggregation pipeline of the stages, eg:
+//
+//	pipeline := yiigo.NewPipeline(
+//		yiigo.Match(bson.M{"status": 1}),
+//		yiigo.Group("$uid", yiigo.Accumulate("total", "$sum", "$amount")),
+//		yiigo.Sort("-total"),
+//		yiigo.SkipLimit(0, 10),
+//	)
+//
+//	cursor, err := yiigo.Mongo().Database("db").Collection("orders").Aggregate(ctx, pipeline)
+func NewPipeline(options ...PipelineOption) mongo.Pipeline {
+	p := &pipelineBuilder{stages: make(mongo.Pipeline, 0, len(options))}
+
+	for _, f := range options {
+		f(p)
+	}
+
+	return p.stages
+}
+
+// Stage specifies the stage by the operator and the value, eg: yiigo.Stage("$sample", bson.M{"size": 10})
+func Stage(op string, value any) PipelineOption {
+	return func(p *pipelineBuilder) {
+		p.stages = append(p.stages, bson.D{{Key: op, Value: value}})
+	}
+}
+
+// Match specifies the $match stage, eg: yiigo.Match(bson.M{"age": bson.M{"$gte": 18}})
+func Match(filter any) PipelineOption {
+	return Stage("$match", mongoDoc(filter))
+}
+
+// Accumulate returns the accumulator field of `Group`, eg: yiigo.Accumulate("total", "$sum", "$amount")
+func Accumulate(field, op string, expr any) bson.E {
+	return bson.E{Key: field, Value: bson.D{{Key: op, Value: expr}}}
+}
+
+// Group specifies the $group stage by the _id expression and the accumulator fields,
+// eg: yiigo.Group("$uid", yiigo.Accumulate("count", "$sum", 1))
+func Group(id any, fields ...bson.E) PipelineOption {
+	return func(p *pipelineBuilder) {
+		group := make(bson.D, 0, len(fields)+1)
+
+		group = append(group, bson.E{Key: "_id", Value: id})
+		group = append(group, fields...)
+
+		p.stages = append(p.stages, bson.D{{Key: "$group", Value: group}})
+	}
+}
+
+type lookup struct {
+	let      any
+	pipeline mongo.Pipeline
+}
+
+// LookupOption lookup option
+type LookupOption func(l *lookup)
+
+// LookupLet specifies the variables of the lookup pipeline, eg: yiigo.LookupLet(bson.M{"uid": "$_id"})
+func LookupLet(vars any) LookupOption {
+	return func(l *lookup) {
+		l.let = vars
+	}
+}
+
+// LookupPipeline specifies the pipeline to run on the joined collection.
+func LookupPipeline(options ...PipelineOption) LookupOption {
+	return func(l *lookup) {
+		l.pipeline = NewPipeline(options...)
+	}
+}
+
+// Lookup specifies the $lookup stage which joins the collection from, the localField and foreignField
+// are omitted if empty (eg: only the pipeline), eg: yiigo.Lookup("users", "uid", "_id", "user")
+func Lookup(from, localField, foreignField, as string, options ...LookupOption) PipelineOption {
+	return func(p *pipelineBuilder) {
+		l := new(lookup)
+
+		for _, f := range options {
+			f(l)
+		}
+
+		stage := bson.D{{Key: "from", Value: from}}
+
+		if len(localField) != 0 || len(foreignField) != 0 {
+			stage = append(stage, bson.E{Key: "localField", Value: localField}, bson.E{Key: "foreignField", Value: foreignField})
+		}
+
+		if l.let != nil {
+			stage = append(stage, bson.E{Key: "let", Value: l.let})
+		}
+
+		if l.pipeline != nil {
+			stage = append(stage, bson.E{Key: "pipeline", Value: l.pipeline})
+		}
+
+		stage = append(stage, bson.E{Key: "as", Value: as})
+
+		p.stages = append(p.stages, bson.D{{Key: "$lookup", Value: stage}})
+	}
+}
+
+type unwind struct {
+	preserve bool
+	index    string
+}
+
+// UnwindOption unwind option
+type UnwindOption func(u *unwind)
+
+// UnwindPreserveEmpty keeps the documents whose array is null, missing or empty.
+func UnwindPreserveEmpty() UnwindOption {
+	return func(u *unwind) {
+		u.preserve = true
+	}
+}
+
+// UnwindIndex specifies the field to hold the array index of the element.
+func UnwindIndex(field string) UnwindOption {
+	return func(u *unwind) {
+		u.index = field
+	}
+}
+
+// Unwind specifies the $unwind stage of the array field, eg: yiigo.Unwind("items") or yiigo.Unwind("$items")
+func Unwind(path string, options ...UnwindOption) PipelineOption {
+	return func(p *pipelineBuilder) {
+		if !strings.HasPrefix(path, "$") {
+			path = "$" + path
+		}
+
+		u := new(unwind)
+
+		for _, f := range options {
+			f(u)
+		}
+
+		if !u.preserve && len(u.index) == 0 {
+			p.stages = append(p.stages, bson.D{{Key: "$unwind", Value: path}})
+
+			return
+		}
+
+		stage := bson.D{{Key: "path", Value: path}}
+
+		if len(u.index) != 0 {
+			stage = append(stage, bson.E{Key: "includeArrayIndex", Value: u.index})
+		}
+
+		if u.preserve {
+			stage = append(stage, bson.E{Key: "preserveNullAndEmptyArrays", Value: true})
+		}
+
+		p.stages = append(p.stages, bson.D{{Key: "$unwind", Value: stage}})
+	}
+}
+
+// Sort specifies the $sort stage, the field prefixed by "-" is descending, eg: yiigo.Sort("-created_at", "_id")
+func Sort(fields ...string) PipelineOption {
+	return func(p *pipelineBuilder) {
+		stage := make(bson.D, 0, len(fields))
+
+		for _, v := range fields {
+			if strings.HasPrefix(v, "-") {
+				stage = append(stage, bson.E{Key: v[1:], Value: -1})
+			} else {
+				stage = append(stage, bson.E{Key: v, Value: 1})
+			}
+		}
+
+		p.stages = append(p.stages, bson.D{{Key: "$sort", Value: stage}})
+	}
+}
+
+// Facet specifies the sub-pipeline of the $facet stage, the consecutive Facet are merged into one stage, eg:
+//
+//	yiigo.Facet("total", yiigo.Count("count")),
+//	yiigo.Facet("list", yiigo.Sort("-_id"), yiigo.SkipLimit(0, 10)),
+func Facet(name string, options ...PipelineOption) PipelineOption {
+	return func(p *pipelineBuilder) {
+		p.merge("$facet", bson.E{Key: name, Value: NewPipeline(options...)})
+	}
+}
+
+// Project specifies the fields of the $project stage, the field prefixed by "-" is excluded,
+// the consecutive Project and ProjectExpr are merged into one stage, eg: yiigo.Project("name", "-_id")
+func Project(fields ...string) PipelineOption {
+	return func(p *pipelineBuilder) {
+		for _, v := range fields {
+			if strings.HasPrefix(v, "-") {
+				p.merge("$project", bson.E{Key: v[1:], Value: 0})
+			} else {
+				p.merge("$project", bson.E{Key: v, Value: 1})
+			}
+		}
+	}
+}
+
+// ProjectExpr specifies the computed field of the $project stage, eg: yiigo.ProjectExpr("year", bson.M{"$year": "$created_at"})
+func ProjectExpr(field string, expr any) PipelineOption {
+	return func(p *pipelineBuilder) {
+		p.merge("$project", bson.E{Key: field, Value: expr})
+	}
+}
+
+// AddFields specifies the field of the $addFields stage, the consecutive AddFields are merged into one stage.
+func AddFields(field string, expr any) PipelineOption {
+	return func(p *pipelineBuilder) {
+		p.merge("$addFields", bson.E{Key: field, Value: expr})
+	}
+}
+
+// Count specifies the $count stage which outputs the number of documents into the field.
+func Count(field string) PipelineOption {
+	return Stage("$count", field)
+}
+
+// SkipLimit specifies the $skip and $limit stages, which is omitted if the value is not positive.
+func SkipLimit(skip, limit int64) PipelineOption {
+	return func(p *pipelineBuilder) {
+		if skip > 0 {
+			p.stages = append(p.stages, bson.D{{Key: "$skip", Value: skip}})
+		}
+
+		if limit > 0 {
+			p.stages = append(p.stages, bson.D{{Key: "$limit", Value: limit}})
+		}
+	}
+}
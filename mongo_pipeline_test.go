@@ -0,0 +1,71 @@
+package yiigo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestNewPipeline(t *testing.T) {
+	pipeline := NewPipeline(
+		Match(bson.M{"status": 1}),
+		Lookup("users", "uid", "_id", "user"),
+		Unwind("user", UnwindPreserveEmpty()),
+		Group("$uid", Accumulate("total", "$sum", "$amount"), Accumulate("count", "$sum", 1)),
+		Sort("-total", "_id"),
+		Project("total", "-_id"),
+		ProjectExpr("uid", "$_id"),
+		SkipLimit(0, 10),
+	)
+
+	assert.Equal(t, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": 1}}},
+		{{Key: "$lookup", Value: bson.D{{Key: "from", Value: "users"}, {Key: "localField", Value: "uid"}, {Key: "foreignField", Value: "_id"}, {Key: "as", Value: "user"}}}},
+		{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$user"}, {Key: "preserveNullAndEmptyArrays", Value: true}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$uid"},
+			{Key: "total", Value: bson.D{{Key: "$sum", Value: "$amount"}}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "total", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$project", Value: bson.D{{Key: "total", Value: 1}, {Key: "_id", Value: 0}, {Key: "uid", Value: "$_id"}}}},
+		{{Key: "$limit", Value: int64(10)}},
+	}, pipeline)
+
+	pipeline = NewPipeline(
+		Lookup("orders", "", "", "orders",
+			LookupLet(bson.M{"uid": "$_id"}),
+			LookupPipeline(Match(bson.M{"$expr": bson.M{"$eq": bson.A{"$uid", "$$uid"}}}), Unwind("$items")),
+		),
+		Facet("total", Count("count")),
+		Facet("list", Sort("-_id"), SkipLimit(20, 10)),
+	)
+
+	assert.Equal(t, mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "orders"},
+			{Key: "let", Value: bson.M{"uid": "$_id"}},
+			{Key: "pipeline", Value: mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"$expr": bson.M{"$eq": bson.A{"$uid", "$$uid"}}}}},
+				{{Key: "$unwind", Value: "$items"}},
+			}},
+			{Key: "as", Value: "orders"},
+		}}},
+		{{Key: "$facet", Value: bson.D{
+			{Key: "total", Value: mongo.Pipeline{{{Key: "$count", Value: "count"}}}},
+			{Key: "list", Value: mongo.Pipeline{
+				{{Key: "$sort", Value: bson.D{{Key: "_id", Value: -1}}}},
+				{{Key: "$skip", Value: int64(20)}},
+				{{Key: "$limit", Value: int64(10)}},
+			}},
+		}}},
+	}, pipeline)
+
+	// marshalable
+	for _, stage := range pipeline {
+		_, err := bson.Marshal(stage)
+		assert.Nil(t, err)
+	}
+}
@@ -0,0 +1,402 @@
+package yiigo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// ChangeEvent the change event of the change stream, the full document is decoded into T.
+type ChangeEvent[T any] struct {
+	ID                bson.Raw            `bson:"_id"` // the resume token
+	OperationType     string              `bson:"operationType"`
+	NS                ChangeNamespace     `bson:"ns"`
+	DocumentKey       bson.Raw            `bson:"documentKey"`
+	FullDocument      *T                  `bson:"fullDocument"` // nil for delete, or update without `WithChangeFullDocument`
+	UpdateDescription *UpdateDescription  `bson:"updateDescription"`
+	ClusterTime       primitive.Timestamp `bson:"clusterTime"`
+}
+
+// ChangeNamespace the namespace of the change event.
+type ChangeNamespace struct {
+	DB   string `bson:"db"`
+	Coll string `bson:"coll"`
+}
+
+// UpdateDescription the fields updated (removed) by the update event.
+type UpdateDescription struct {
+	UpdatedFields bson.M   `bson:"updatedFields"`
+	RemovedFields []string `bson:"removedFields"`
+}
+
+// ChangeHandler handles the change event, the resume token is saved if returns nil, otherwise the stream is reopened
+// from the last saved token after the backoff, so the event is redelivered (at least once).
+type ChangeHandler[T any] func(ctx context.Context, event *ChangeEvent[T]) error
+
+// ChangeStreamSource is the source of the change stream, eg: *mongo.Client, *mongo.Database and *mongo.Collection.
+type ChangeStreamSource interface {
+	Watch(ctx context.Context, pipeline any, opts ...*options.ChangeStreamOptions) (*mongo.ChangeStream, error)
+}
+
+// ResumeTokenStore stores the resume tokens of the change streams.
+type ResumeTokenStore interface {
+	// Load returns the token of the stream, nil if not exists.
+	Load(ctx context.Context, name string) (bson.Raw, error)
+
+	// Save saves the token of the stream.
+	Save(ctx context.Context, name string, token bson.Raw) error
+}
+
+type redisResumeTokenStore struct {
+	pool   RedisPool
+	prefix string
+}
+
+// NewRedisResumeTokenStore returns the ResumeTokenStore in redis, the key is yiigo:changestream:{name}.
+func NewRedisResumeTokenStore(pool RedisPool) ResumeTokenStore {
+	return &redisResumeTokenStore{
+		pool:   pool,
+		prefix: "yiigo:changestream:",
+	}
+}
+
+func (s *redisResumeTokenStore) Load(ctx context.Context, name string) (bson.Raw, error) {
+	b, err := redis.Bytes(s.pool.Do(ctx, "GET", s.prefix+name))
+
+	if err != nil {
+		if err == redis.ErrNil {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (s *redisResumeTokenStore) Save(ctx context.Context, name string, token bson.Raw) error {
+	_, err := s.pool.Do(ctx, "SET", s.prefix+name, []byte(token))
+
+	return err
+}
+
+type mongoResumeTokenStore struct {
+	coll *mongo.Collection
+}
+
+// NewMongoResumeTokenStore returns the ResumeTokenStore in the collection, the document is {_id: name, token: token}.
+func NewMongoResumeTokenStore(coll *mongo.Collection) ResumeTokenStore {
+	return &mongoResumeTokenStore{coll: coll}
+}
+
+func (s *mongoResumeTokenStore) Load(ctx context.Context, name string) (bson.Raw, error) {
+	doc := struct {
+		Token bson.Raw `bson:"token"`
+	}{}
+
+	if err := s.coll.FindOne(ctx, bson.D{{Key: "_id", Value: name}}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return doc.Token, nil
+}
+
+func (s *mongoResumeTokenStore) Save(ctx context.Context, name string, token bson.Raw) error {
+	_, err := s.coll.UpdateOne(ctx, bson.D{{Key: "_id", Value: name}}, M.Set("token", token).D(), options.Update().SetUpsert(true))
+
+	return err
+}
+
+type memResumeTokenStore struct {
+	tokens map[string]bson.Raw
+	mutex  sync.RWMutex
+}
+
+// NewMemResumeTokenStore returns the in-process ResumeTokenStore, the stream starts from now after restarted.
+func NewMemResumeTokenStore() ResumeTokenStore {
+	return &memResumeTokenStore{tokens: make(map[string]bson.Raw)}
+}
+
+func (s *memResumeTokenStore) Load(ctx context.Context, name string) (bson.Raw, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.tokens[name], nil
+}
+
+func (s *memResumeTokenStore) Save(ctx context.Context, name string, token bson.Raw) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.tokens[name] = token
+
+	return nil
+}
+
+// changeCursor is the cursor of the change stream (*mongo.ChangeStream).
+type changeCursor interface {
+	TryNext(ctx context.Context) bool
+	Decode(v any) error
+	ResumeToken() bson.Raw
+	Err() error
+	Close(ctx context.Context) error
+}
+
+type changeStreamOptions struct {
+	store        ResumeTokenStore
+	pipeline     any
+	fullDocument options.FullDocument
+	backoff      time.Duration
+	maxBackoff   time.Duration
+}
+
+// ChangeStreamOption change stream option
+type ChangeStreamOption func(o *changeStreamOptions)
+
+// WithChangeTokenStore specifies the store of the resume token, default: NewMemResumeTokenStore().
+func WithChangeTokenStore(store ResumeTokenStore) ChangeStreamOption {
+	return func(o *changeStreamOptions) {
+		o.store = store
+	}
+}
+
+// WithChangePipeline specifies the pipeline to filter (transform) the events,
+// eg: yiigo.NewPipeline(yiigo.Match(bson.M{"operationType": bson.M{"$in": bson.A{"insert", "update"}}}))
+func WithChangePipeline(pipeline any) ChangeStreamOption {
+	return func(o *changeStreamOptions) {
+		o.pipeline = pipeline
+	}
+}
+
+// WithChangeFullDocument specifies the full document of the update event, eg: options.UpdateLookup.
+func WithChangeFullDocument(fd options.FullDocument) ChangeStreamOption {
+	return func(o *changeStreamOptions) {
+		o.fullDocument = fd
+	}
+}
+
+// WithChangeBackoff specifies the backoff of reopening the stream after the error, which is doubled after each failure
+// and capped by max, default: 1s and 30s.
+func WithChangeBackoff(backoff, max time.Duration) ChangeStreamOption {
+	return func(o *changeStreamOptions) {
+		o.backoff = backoff
+		o.maxBackoff = max
+	}
+}
+
+// ChangeStream is the managed change stream, which resumes from the saved token and reopens on the errors.
+type ChangeStream[T any] struct {
+	name    string
+	handler ChangeHandler[T]
+	options *changeStreamOptions
+
+	// open opens the cursor after the token, startAfter is true if the stream is invalidated
+	open func(ctx context.Context, token bson.Raw, startAfter bool) (changeCursor, error)
+}
+
+// NewChangeStream returns the change stream of the source, the name identifies the resume token in the store, eg:
+//
+//	cs := yiigo.NewChangeStream[User]("user_cache", yiigo.Mongo().Database("app").Collection("users"), handler,
+//		yiigo.WithChangeTokenStore(yiigo.NewRedisResumeTokenStore(yiigo.Redis())),
+//		yiigo.WithChangeFullDocument(options.UpdateLookup),
+//	)
+//
+//	go cs.Run(ctx)
+func NewChangeStream[T any](name string, source ChangeStreamSource, handler ChangeHandler[T], options ...ChangeStreamOption) *ChangeStream[T] {
+	o := &changeStreamOptions{
+		backoff:    time.Second,
+		maxBackoff: 30 * time.Second,
+	}
+
+	for _, f := range options {
+		f(o)
+	}
+
+	if o.store == nil {
+		o.store = NewMemResumeTokenStore()
+	}
+
+	if o.pipeline == nil {
+		o.pipeline = mongo.Pipeline{}
+	}
+
+	cs := &ChangeStream[T]{
+		name:    name,
+		handler: handler,
+		options: o,
+	}
+
+	cs.open = func(ctx context.Context, token bson.Raw, startAfter bool) (changeCursor, error) {
+		opts := newChangeStreamOptions(o.fullDocument)
+
+		if token != nil {
+			if startAfter {
+				opts.SetStartAfter(token)
+			} else {
+				opts.SetResumeAfter(token)
+			}
+		}
+
+		return source.Watch(ctx, o.pipeline, opts)
+	}
+
+	return cs
+}
+
+func newChangeStreamOptions(fd options.FullDocument) *options.ChangeStreamOptions {
+	opts := options.ChangeStream()
+
+	if len(fd) != 0 {
+		opts.SetFullDocument(fd)
+	}
+
+	return opts
+}
+
+// Run consumes the events until ctx is done, the stream is reopened (with backoff) from the last saved token
+// on the errors (eg: network, handler), and started after the invalidate event (eg: the collection is dropped or renamed).
+// NOTE: The stream can't be resumed if the token falls off the oplog (ChangeStreamHistoryLost), delete the token to start from now.
+func (cs *ChangeStream[T]) Run(ctx context.Context) error {
+	var (
+		token      bson.Raw
+		loaded     bool
+		startAfter bool
+		failures   int
+	)
+
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		var err error
+
+		if !loaded {
+			if token, err = cs.options.store.Load(ctx, cs.name); err == nil {
+				loaded = true
+			}
+		}
+
+		if err == nil {
+			token, startAfter, err = cs.consume(ctx, token, startAfter)
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err == nil {
+			failures = 0
+
+			continue
+		}
+
+		failures++
+
+		logger.Error(fmt.Sprintf("err change stream(%s)", cs.name), zap.Int("failures", failures), zap.Error(err))
+
+		backoff := cs.options.backoff << (failures - 1)
+
+		if backoff <= 0 || backoff > cs.options.maxBackoff {
+			backoff = cs.options.maxBackoff
+		}
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// consume opens the cursor after the token and handles the events, returns the token to reopen from
+// (the last saved one, or the invalidate one) and whether the stream is invalidated (nil error) once the cursor is closed.
+func (cs *ChangeStream[T]) consume(ctx context.Context, token bson.Raw, startAfter bool) (bson.Raw, bool, error) {
+	cursor, err := cs.open(ctx, token, startAfter)
+
+	if err != nil {
+		return token, startAfter, err
+	}
+
+	defer cursor.Close(context.Background())
+
+	for {
+		if !cursor.TryNext(ctx) {
+			if err = cursor.Err(); err != nil || ctx.Err() != nil {
+				return token, startAfter, err
+			}
+
+			// the post batch token advances without events, save it to not fall off the oplog
+			if t := cursor.ResumeToken(); t != nil && !bytes.Equal(t, token) {
+				if err = cs.options.store.Save(ctx, cs.name, t); err != nil {
+					return token, startAfter, err
+				}
+
+				token, startAfter = t, false
+			}
+
+			continue
+		}
+
+		event := new(ChangeEvent[T])
+
+		if err = cursor.Decode(event); err != nil {
+			return token, startAfter, err
+		}
+
+		if err = cs.call(ctx, event); err != nil {
+			return token, startAfter, err
+		}
+
+		t := cursor.ResumeToken()
+
+		if t == nil {
+			t = event.ID
+		}
+
+		// the cursor is closed after the invalidate event, which can only be started after.
+		// The token isn't saved (can't be resumed after), so the event is redelivered after restarting.
+		if event.OperationType == "invalidate" {
+			logger.Warn(fmt.Sprintf("change stream(%s) is invalidated", cs.name))
+
+			return t, true, nil
+		}
+
+		if err = cs.options.store.Save(ctx, cs.name, t); err != nil {
+			return token, startAfter, err
+		}
+
+		token, startAfter = t, false
+	}
+}
+
+func (cs *ChangeStream[T]) call(ctx context.Context, event *ChangeEvent[T]) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+
+			logger.Error(fmt.Sprintf("change stream(%s) handler panic", cs.name), zap.Any("error", r), zap.ByteString("stack", debug.Stack()))
+		}
+	}()
+
+	return cs.handler(ctx, event)
+}
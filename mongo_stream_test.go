@@ -0,0 +1,246 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type changeUser struct {
+	Name string `bson:"name"`
+}
+
+// fakeChangeCursor returns the events, and then the error (or empty batches) once drained.
+type fakeChangeCursor struct {
+	events []bson.D
+	err    error
+	cur    bson.Raw
+	token  bson.Raw
+}
+
+func (c *fakeChangeCursor) TryNext(ctx context.Context) bool {
+	if len(c.events) == 0 {
+		time.Sleep(time.Millisecond)
+
+		return false
+	}
+
+	c.cur, _ = bson.Marshal(c.events[0])
+	c.token = c.cur.Lookup("_id").Value
+	c.events = c.events[1:]
+
+	return true
+}
+
+func (c *fakeChangeCursor) Decode(v any) error {
+	return bson.Unmarshal(c.cur, v)
+}
+
+func (c *fakeChangeCursor) ResumeToken() bson.Raw {
+	return c.token
+}
+
+func (c *fakeChangeCursor) Err() error {
+	if len(c.events) == 0 {
+		return c.err
+	}
+
+	return nil
+}
+
+func (c *fakeChangeCursor) Close(ctx context.Context) error {
+	return nil
+}
+
+func changeEventDoc(id, op, name string) bson.D {
+	doc := bson.D{
+		{Key: "_id", Value: bson.D{{Key: "_data", Value: id}}},
+		{Key: "operationType", Value: op},
+		{Key: "ns", Value: bson.D{{Key: "db", Value: "app"}, {Key: "coll", Value: "users"}}},
+	}
+
+	if len(name) != 0 {
+		doc = append(doc, bson.E{Key: "fullDocument", Value: bson.D{{Key: "name", Value: name}}})
+	}
+
+	return doc
+}
+
+func TestChangeStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	defer cancel()
+
+	store := NewMemResumeTokenStore()
+
+	var (
+		mutex  sync.Mutex
+		opens  []string // the token (and start after) of each open
+		names  []string
+		failed bool
+	)
+
+	// the cursors of each open
+	cursors := []*fakeChangeCursor{
+		{events: []bson.D{changeEventDoc("1", "insert", "a"), changeEventDoc("2", "insert", "b")}, err: errors.New("network error")},
+		{events: []bson.D{changeEventDoc("3", "update", "c")}},
+		{events: []bson.D{changeEventDoc("3", "update", "c"), changeEventDoc("4", "invalidate", "")}},
+		{events: []bson.D{changeEventDoc("5", "insert", "d")}},
+	}
+
+	cs := NewChangeStream[changeUser]("users", nil, func(ctx context.Context, event *ChangeEvent[changeUser]) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		// fails once, redelivered after reopened
+		if event.OperationType == "update" && !failed {
+			failed = true
+
+			return errors.New("handler error")
+		}
+
+		if event.FullDocument != nil {
+			names = append(names, event.FullDocument.Name)
+		}
+
+		return nil
+	}, WithChangeTokenStore(store), WithChangeBackoff(time.Millisecond, 5*time.Millisecond))
+
+	cs.open = func(ctx context.Context, token bson.Raw, startAfter bool) (changeCursor, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		open := ""
+
+		if token != nil {
+			open = token.Lookup("_data").StringValue()
+		}
+
+		if startAfter {
+			open += ":after"
+		}
+
+		opens = append(opens, open)
+
+		if len(cursors) == 0 {
+			return new(fakeChangeCursor), nil
+		}
+
+		c := cursors[0]
+		cursors = cursors[1:]
+
+		return c, nil
+	}
+
+	go cs.Run(ctx)
+
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return len(names) == 4
+	}, time.Second, 5*time.Millisecond)
+
+	mutex.Lock()
+	assert.Equal(t, []string{"a", "b", "c", "d"}, names)
+	assert.Equal(t, []string{"", "2", "2", "4:after"}, opens)
+	mutex.Unlock()
+
+	// saved after handled
+	assert.Eventually(t, func() bool {
+		token, err := store.Load(ctx, "users")
+
+		return err == nil && token.Lookup("_data").StringValue() == "5"
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestChangeStreamInvalidate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	defer cancel()
+
+	store := NewMemResumeTokenStore()
+
+	var (
+		mutex sync.Mutex
+		opens []string
+	)
+
+	cursors := []*fakeChangeCursor{
+		{events: []bson.D{changeEventDoc("1", "insert", "a"), changeEventDoc("2", "invalidate", "")}},
+	}
+
+	cs := NewChangeStream[changeUser]("users", nil, func(ctx context.Context, event *ChangeEvent[changeUser]) error {
+		return nil
+	}, WithChangeTokenStore(store))
+
+	cs.open = func(ctx context.Context, token bson.Raw, startAfter bool) (changeCursor, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		open := ""
+
+		if token != nil {
+			open = token.Lookup("_data").StringValue()
+		}
+
+		if startAfter {
+			open += ":after"
+		}
+
+		opens = append(opens, open)
+
+		if len(cursors) == 0 {
+			return new(fakeChangeCursor), nil
+		}
+
+		c := cursors[0]
+		cursors = cursors[1:]
+
+		return c, nil
+	}
+
+	go cs.Run(ctx)
+
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return len(opens) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	mutex.Lock()
+	assert.Equal(t, []string{"", "2:after"}, opens)
+	mutex.Unlock()
+
+	// the invalidate token isn't saved, which can't be resumed after restarting
+	token, err := store.Load(ctx, "users")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", token.Lookup("_data").StringValue())
+}
+
+func TestRedisResumeTokenStore(t *testing.T) {
+	ctx := context.TODO()
+
+	s := miniredis.RunT(t)
+
+	store := NewRedisResumeTokenStore(newRedisPool(&RedisConfig{Addr: s.Addr()}))
+
+	token, err := store.Load(ctx, "users")
+	assert.Nil(t, err)
+	assert.Nil(t, token)
+
+	b, _ := bson.Marshal(bson.D{{Key: "_data", Value: "1"}})
+
+	assert.Nil(t, store.Save(ctx, "users", b))
+
+	token, err = store.Load(ctx, "users")
+	assert.Nil(t, err)
+	assert.Equal(t, bson.Raw(b), token)
+}
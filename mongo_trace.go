@@ -0,0 +1,103 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// mongoCommandKey identifies the command between the started and finished events.
+type mongoCommandKey struct {
+	conn string
+	id   int64
+}
+
+// WithMongoTracing records the OpenTelemetry span of the commands with the attributes:
+// db.system, db.name, db.operation and db.mongodb.collection (the command document is never recorded),
+// the tracer provider defaults to otel.GetTracerProvider().
+// The span is the child of the span in the context passed to the operation.
+func WithMongoTracing(tp ...trace.TracerProvider) MongoOption {
+	provider := otel.GetTracerProvider()
+
+	if len(tp) != 0 && tp[0] != nil {
+		provider = tp[0]
+	}
+
+	tracer := provider.Tracer(tracerName)
+
+	var spans sync.Map
+
+	end := func(evt *event.CommandFinishedEvent, err error) {
+		v, ok := spans.LoadAndDelete(mongoCommandKey{conn: evt.ConnectionID, id: evt.RequestID})
+
+		if !ok {
+			return
+		}
+
+		span := v.(trace.Span)
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		span.End()
+	}
+
+	return WithMongoMonitor(&event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			coll := mongoCommandCollection(evt.CommandName, evt.Command)
+
+			name := evt.CommandName
+
+			if len(coll) != 0 {
+				name += " " + coll
+			}
+
+			_, span := tracer.Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.DBSystemMongoDB,
+					semconv.DBName(evt.DatabaseName),
+					semconv.DBOperation(evt.CommandName),
+					semconv.DBMongoDBCollection(coll),
+				),
+			)
+
+			spans.Store(mongoCommandKey{conn: evt.ConnectionID, id: evt.RequestID}, span)
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			end(&evt.CommandFinishedEvent, nil)
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			end(&evt.CommandFinishedEvent, errors.New(evt.Failure))
+		},
+	})
+}
+
+// mongoCommandCollection returns the collection of the command, eg: {find: "users"}, {getMore: 1, collection: "users"}.
+func mongoCommandCollection(name string, cmd bson.Raw) string {
+	if name == "getMore" {
+		coll, _ := cmd.Lookup("collection").StringValueOK()
+
+		return coll
+	}
+
+	elem, err := cmd.IndexErr(0)
+
+	if err != nil {
+		return ""
+	}
+
+	// eg: {aggregate: 1} on the database
+	coll, _ := elem.Value().StringValueOK()
+
+	return coll
+}
@@ -0,0 +1,87 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// runMongoCommand emits the events of the command to the monitor, fails if failure is not empty.
+func runMongoCommand(ctx context.Context, t *testing.T, m *event.CommandMonitor, id int64, cmd bson.D, failure string) {
+	b, err := bson.Marshal(cmd)
+
+	assert.Nil(t, err)
+
+	m.Started(ctx, &event.CommandStartedEvent{
+		Command:      b,
+		DatabaseName: "app",
+		CommandName:  cmd[0].Key,
+		RequestID:    id,
+		ConnectionID: "localhost:27017[-1]",
+	})
+
+	finished := event.CommandFinishedEvent{
+		DurationNanos: 2000000,
+		CommandName:   cmd[0].Key,
+		RequestID:     id,
+		ConnectionID:  "localhost:27017[-1]",
+	}
+
+	if len(failure) != 0 {
+		m.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: finished, Failure: failure})
+
+		return
+	}
+
+	m.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: finished})
+}
+
+func TestWithMongoTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	m := MongoMonitor(WithMongoTracing(tp))
+
+	ctx, parent := tp.Tracer("test").Start(context.TODO(), "parent")
+
+	runMongoCommand(ctx, t, m, 1, bson.D{{Key: "find", Value: "users"}, {Key: "filter", Value: bson.M{"name": "yiigo"}}}, "")
+	runMongoCommand(ctx, t, m, 2, bson.D{{Key: "getMore", Value: int64(100)}, {Key: "collection", Value: "users"}}, "")
+	runMongoCommand(ctx, t, m, 3, bson.D{{Key: "insert", Value: "users"}}, "E11000 duplicate key error")
+	runMongoCommand(ctx, t, m, 4, bson.D{{Key: "ping", Value: 1}}, "")
+
+	parent.End()
+
+	spans := recorder.Ended()
+
+	assert.Len(t, spans, 5)
+
+	assert.Equal(t, "find users", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+
+	attrs := make(map[attribute.Key]attribute.Value)
+
+	for _, v := range spans[0].Attributes() {
+		attrs[v.Key] = v.Value
+	}
+
+	assert.Equal(t, "mongodb", attrs["db.system"].AsString())
+	assert.Equal(t, "app", attrs["db.name"].AsString())
+	assert.Equal(t, "find", attrs["db.operation"].AsString())
+	assert.Equal(t, "users", attrs["db.mongodb.collection"].AsString())
+
+	assert.Equal(t, "getMore users", spans[1].Name())
+
+	assert.Equal(t, "insert users", spans[2].Name())
+	assert.Equal(t, codes.Error, spans[2].Status().Code)
+	assert.Equal(t, "E11000 duplicate key error", spans[2].Status().Description)
+
+	assert.Equal(t, "ping", spans[3].Name())
+}
@@ -0,0 +1,155 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	mongoTransientTxError    = "TransientTransactionError"
+	mongoUnknownCommitResult = "UnknownTransactionCommitResult"
+)
+
+type mongoTxOptions struct {
+	timeout time.Duration
+	opts    *options.TransactionOptions
+}
+
+// MongoTxOption mongo transaction option
+type MongoTxOption func(o *mongoTxOptions)
+
+// WithMongoTxTimeout specifies the max duration of retrying the transaction, default: 120s (the driver spec).
+func WithMongoTxTimeout(d time.Duration) MongoTxOption {
+	return func(o *mongoTxOptions) {
+		o.timeout = d
+	}
+}
+
+// WithMongoTxOptions specifies the read concern, write concern and read preference of the transaction.
+func WithMongoTxOptions(opts *options.TransactionOptions) MongoTxOption {
+	return func(o *mongoTxOptions) {
+		o.opts = opts
+	}
+}
+
+// MongoTransact executes fn in the transaction of the session on the mongodb of the name, which is committed if fn returns nil,
+// otherwise aborted. The operations in fn must use sess as the context, eg: coll.InsertOne(sess, doc).
+// The transaction is retried (fn is called again) on TransientTransactionError and the commit is retried on
+// UnknownTransactionCommitResult until the timeout (see `WithMongoTxTimeout`), so fn should be idempotent besides the db operations.
+// NOTE: The transaction requires the replica set or sharded cluster.
+func MongoTransact(ctx context.Context, name string, fn func(sess mongo.SessionContext) error, options ...MongoTxOption) error {
+	o := &mongoTxOptions{
+		timeout: 120 * time.Second,
+	}
+
+	for _, f := range options {
+		f(o)
+	}
+
+	sess, err := Mongo(name).StartSession()
+
+	if err != nil {
+		return err
+	}
+
+	defer sess.EndSession(context.Background())
+
+	return mongoTransact(ctx, sess, fn, o)
+}
+
+func mongoTransact(ctx context.Context, sess mongo.Session, fn func(sess mongo.SessionContext) error, o *mongoTxOptions) error {
+	start := time.Now()
+
+	for i := 1; ; i++ {
+		if err := sess.StartTransaction(o.opts); err != nil {
+			return err
+		}
+
+		sctx := mongo.NewSessionContext(ctx, sess)
+
+		err := callMongoTx(sctx, fn)
+
+		if err == nil {
+			if err = commitMongoTx(sctx, sess, start, o.timeout); err == nil {
+				return nil
+			}
+		} else {
+			abortMongoTx(sess)
+		}
+
+		if !mongoErrorHasLabel(err, mongoTransientTxError) || time.Since(start) >= o.timeout || ctx.Err() != nil {
+			return err
+		}
+
+		logger.Warn("mongo tx retry", zap.Int("retry", i), zap.Error(err))
+	}
+}
+
+// commitMongoTx commits the transaction, and retries the commit on UnknownTransactionCommitResult until the timeout.
+func commitMongoTx(ctx context.Context, sess mongo.Session, start time.Time, timeout time.Duration) error {
+	for {
+		err := sess.CommitTransaction(ctx)
+
+		if err == nil || !mongoErrorHasLabel(err, mongoUnknownCommitResult) || isMongoMaxTimeExpired(err) {
+			return err
+		}
+
+		if time.Since(start) >= timeout || ctx.Err() != nil {
+			return err
+		}
+
+		logger.Warn("mongo tx commit retry", zap.Error(err))
+	}
+}
+
+func callMongoTx(sess mongo.SessionContext, fn func(sess mongo.SessionContext) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("mongo tx panic", zap.Any("error", r), zap.ByteString("stack", debug.Stack()))
+
+			err = fmt.Errorf("mongo tx panic: %v", r)
+		}
+	}()
+
+	return fn(sess)
+}
+
+// abortMongoTx aborts the transaction with the new context, since ctx may be done.
+func abortMongoTx(sess mongo.Session) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := sess.AbortTransaction(ctx); err != nil {
+		logger.Warn("err mongo tx abort", zap.Error(err))
+	}
+}
+
+func mongoErrorHasLabel(err error, label string) bool {
+	var le mongo.LabeledError
+
+	return errors.As(err, &le) && le.HasErrorLabel(label)
+}
+
+// isMongoMaxTimeExpired reports whether the commit exceeded the maxTimeMS, which is not retried.
+func isMongoMaxTimeExpired(err error) bool {
+	var ce mongo.CommandError
+
+	if errors.As(err, &ce) {
+		return ce.Code == 50
+	}
+
+	var we mongo.WriteException
+
+	if errors.As(err, &we) && we.WriteConcernError != nil {
+		return we.WriteConcernError.Code == 50
+	}
+
+	return false
+}
@@ -0,0 +1,118 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type fakeMongoSession struct {
+	mongo.Session
+
+	starts     int
+	aborts     int
+	commits    int
+	commitErrs []error
+}
+
+func (s *fakeMongoSession) StartTransaction(...*options.TransactionOptions) error {
+	s.starts++
+
+	return nil
+}
+
+func (s *fakeMongoSession) AbortTransaction(context.Context) error {
+	s.aborts++
+
+	return nil
+}
+
+func (s *fakeMongoSession) CommitTransaction(context.Context) error {
+	s.commits++
+
+	if len(s.commitErrs) == 0 {
+		return nil
+	}
+
+	err := s.commitErrs[0]
+	s.commitErrs = s.commitErrs[1:]
+
+	return err
+}
+
+func TestMongoTransact(t *testing.T) {
+	ctx := context.TODO()
+	o := &mongoTxOptions{timeout: time.Minute}
+
+	transient := mongo.CommandError{Name: "WriteConflict", Labels: []string{mongoTransientTxError}}
+	unknown := mongo.CommandError{Name: "NetworkError", Labels: []string{mongoUnknownCommitResult}}
+
+	// fn is retried on TransientTransactionError
+	sess := new(fakeMongoSession)
+	calls := 0
+
+	err := mongoTransact(ctx, sess, func(sc mongo.SessionContext) error {
+		calls++
+
+		if calls < 3 {
+			return transient
+		}
+
+		return nil
+	}, o)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 3, sess.starts)
+	assert.Equal(t, 2, sess.aborts)
+	assert.Equal(t, 1, sess.commits)
+
+	// commit is retried on UnknownTransactionCommitResult, and the transaction on TransientTransactionError
+	sess = &fakeMongoSession{commitErrs: []error{unknown, unknown, transient}}
+	calls = 0
+
+	err = mongoTransact(ctx, sess, func(sc mongo.SessionContext) error {
+		calls++
+
+		return nil
+	}, o)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 4, sess.commits)
+
+	// not retried
+	sess = new(fakeMongoSession)
+	errFn := errors.New("fn failed")
+
+	assert.ErrorIs(t, mongoTransact(ctx, sess, func(sc mongo.SessionContext) error {
+		return errFn
+	}, o), errFn)
+	assert.Equal(t, 1, sess.starts)
+	assert.Equal(t, 1, sess.aborts)
+
+	// panic
+	sess = new(fakeMongoSession)
+
+	assert.NotNil(t, mongoTransact(ctx, sess, func(sc mongo.SessionContext) error {
+		panic("oops")
+	}, o))
+	assert.Equal(t, 1, sess.aborts)
+
+	// timeout
+	sess = new(fakeMongoSession)
+	calls = 0
+
+	assert.NotNil(t, mongoTransact(ctx, sess, func(sc mongo.SessionContext) error {
+		calls++
+		time.Sleep(5 * time.Millisecond)
+
+		return transient
+	}, &mongoTxOptions{timeout: 20 * time.Millisecond}))
+	assert.Less(t, calls, 10)
+}
@@ -2,32 +2,98 @@ package yiigo
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gomodule/redigo/redis"
+	"go.uber.org/zap"
 )
 
+// The lock value is the owner (string) as before, so the holders of the old versions (SET NX) are exclusive during the
+// rolling deploy, the reentrant count and the fencing token of the holder are kept in the hold hash.
+var (
+	// KEYS[1]: lock, KEYS[2]: hold, KEYS[3]: fencing, ARGV[1]: owner, ARGV[2]: expire (ms)
+	// returns the fencing token, or 0 if the lock is held by others
+	mutexAcquireScript = redis.NewScript(3, `
+local owner = redis.call('GET', KEYS[1])
+if owner == false then
+	local token = redis.call('INCR', KEYS[3])
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	redis.call('HSET', KEYS[2], 'count', 1, 'token', token)
+	redis.call('PEXPIRE', KEYS[2], ARGV[2])
+	return token
+end
+if owner == ARGV[1] then
+	redis.call('HINCRBY', KEYS[2], 'count', 1)
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	redis.call('PEXPIRE', KEYS[2], ARGV[2])
+	return tonumber(redis.call('HGET', KEYS[2], 'token')) or 0
+end
+return 0`)
+
+	// KEYS[1]: lock, KEYS[2]: hold, ARGV[1]: owner, ARGV[2]: expire (ms)
+	// returns the remaining holds, or -1 if the lock is not held by the owner
+	mutexReleaseScript = redis.NewScript(2, `
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return -1
+end
+local count = redis.call('HINCRBY', KEYS[2], 'count', -1)
+if count > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	redis.call('PEXPIRE', KEYS[2], ARGV[2])
+	return count
+end
+redis.call('DEL', KEYS[1], KEYS[2])
+return 0`)
+
+	// KEYS[1]: lock, KEYS[2]: hold, ARGV[1]: owner, ARGV[2]: expire (ms)
+	// returns 1 if renewed, or 0 if the lock is not held by the owner
+	mutexRenewScript = redis.NewScript(2, `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[2], ARGV[2])
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0`)
+)
+
+// minMutexRenewInterval is the min interval of the watchdog renewal.
+const minMutexRenewInterval = 10 * time.Millisecond
+
 // Mutex is a reader/writer mutual exclusion lock.
 type Mutex interface {
 	// Lock attempts to acquire lock at regular intervals.
+	// It's reentrant for the same uniqueID, eg: the nested calls within the same request (context).
 	Lock(ctx context.Context, interval, timeout time.Duration) error
 
-	// UnLock releases the lock.
+	// UnLock releases the lock, the reentrant lock is released when all holds are released.
 	UnLock(ctx context.Context) error
+
+	// Token returns the fencing token of the lock, which increases monotonically for each new holder (0 if not held).
+	// Pass it to the downstream writes to reject the stale holder (eg: the lock expired during the GC pause),
+	// eg: UPDATE ... SET fencing_token = ? WHERE id = ? AND fencing_token < ?
+	Token() int64
 }
 
 type distributed struct {
-	pool   RedisPool
-	key    string
-	uniqID string
-	expire time.Duration
+	pool     RedisPool
+	key      string
+	uniqID   string
+	expire   time.Duration
+	watchdog bool
+
+	token int64
+	watch context.Context
+	stop  context.CancelFunc
+	mutex sync.Mutex
 }
 
 func (d *distributed) Lock(ctx context.Context, interval, timeout time.Duration) error {
-	ctx, cancel := context.WithTimeout(ctx, timeout)
+	lockCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	conn, err := d.pool.Get(ctx)
+	conn, err := d.pool.Get(lockCtx)
 
 	if err != nil {
 		return err
@@ -37,18 +103,20 @@ func (d *distributed) Lock(ctx context.Context, interval, timeout time.Duration)
 
 	for {
 		select {
-		case <-ctx.Done(): // timeout or canceled
-			return ctx.Err()
+		case <-lockCtx.Done(): // timeout or canceled
+			return lockCtx.Err()
 		default:
 		}
 
-		ok, err := d.attempt(conn)
+		token, err := d.attempt(conn)
 
 		if err != nil {
 			return err
 		}
 
-		if ok {
+		if token > 0 {
+			d.acquired(ctx, token)
+
 			return nil
 		}
 
@@ -65,34 +133,129 @@ func (d *distributed) UnLock(ctx context.Context) error {
 
 	defer d.pool.Put(conn)
 
-	v, err := redis.String(conn.Do("GET", d.key))
+	count, err := redis.Int(mutexReleaseScript.Do(conn.Conn, d.key, d.subKey("hold"), d.uniqID, d.expire.Milliseconds()))
 
 	if err != nil {
 		return err
 	}
 
-	if v != d.uniqID {
-		return nil
+	// released or not held (eg: expired)
+	if count <= 0 {
+		d.released()
+	}
+
+	return nil
+}
+
+func (d *distributed) Token() int64 {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.token
+}
+
+func (d *distributed) attempt(conn *RedisConn) (int64, error) {
+	return redis.Int64(mutexAcquireScript.Do(conn.Conn, d.key, d.subKey("hold"), d.subKey("fencing"), d.uniqID, d.expire.Milliseconds()))
+}
+
+func (d *distributed) acquired(ctx context.Context, token int64) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.token = token
+
+	if !d.watchdog || (d.watch != nil && d.watch.Err() == nil) {
+		return
+	}
+
+	// the watchdog stops once ctx is done (the holder is gone), then the lock expires
+	d.watch, d.stop = context.WithCancel(ctx)
+
+	go d.renew(d.watch)
+}
+
+func (d *distributed) released() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.token = 0
+
+	if d.stop != nil {
+		d.stop()
+
+		d.watch, d.stop = nil, nil
+	}
+}
+
+// renew extends the expire of the lock at 1/3 expire intervals (at least 10ms) until ctx is done or the lock is lost.
+func (d *distributed) renew(ctx context.Context) {
+	interval := d.expire / 3
+
+	if interval < minMutexRenewInterval {
+		interval = minMutexRenewInterval
 	}
 
-	_, err = conn.Do("DEL", d.key)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ok, err := d.extend(ctx)
+
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Error(fmt.Sprintf("err mutex(%s) renew", d.key), zap.Error(err))
+			}
+
+			continue
+		}
+
+		if !ok {
+			logger.Warn(fmt.Sprintf("mutex(%s) is lost", d.key))
 
-	return err
+			d.released()
+
+			return
+		}
+	}
 }
 
-func (d *distributed) attempt(conn *RedisConn) (bool, error) {
-	// attempt to acquire lock with `setnx`
-	reply, err := redis.String(conn.Do("SET", d.key, d.uniqID, "PX", d.expire.Milliseconds(), "NX"))
+func (d *distributed) extend(ctx context.Context) (bool, error) {
+	conn, err := d.pool.Get(ctx)
 
-	if err != nil && err != redis.ErrNil {
+	if err != nil {
 		return false, err
 	}
 
-	if reply == OK {
-		return true, nil
+	defer d.pool.Put(conn)
+
+	return redis.Bool(mutexRenewScript.Do(conn.Conn, d.key, d.subKey("hold"), d.uniqID, d.expire.Milliseconds()))
+}
+
+// subKey returns the key of the hold hash (or the fencing token counter), see `mutexKey`.
+func (d *distributed) subKey(suffix string) string {
+	return mutexKey(redisKeyPrefix(d.pool), d.key, suffix)
+}
+
+// mutexKey returns the key of the hold hash (or the fencing token counter), which is in the same slot as the lock key (cluster mode).
+// The lock key is kept as is for the holders of the old versions, so the hash tag is the prefixed lock key if it has no hash tag,
+// since the key prefix of the pool is prepended to both keys, eg: svc:lock -> svc:{svc:lock}:hold.
+// NOTE: The unpaired braces (eg: lock{}) in the untagged lock key are not supported in cluster mode.
+func mutexKey(prefix, key, suffix string) string {
+	full := prefix + key
+
+	if i := strings.IndexByte(full, '{'); i >= 0 {
+		if j := strings.IndexByte(full[i+1:], '}'); j > 0 {
+			return key + ":" + suffix
+		}
 	}
 
-	return false, nil
+	return "{" + full + "}:" + suffix
 }
 
 // MutexOption mutex option
@@ -112,8 +275,16 @@ func WithMutexExpire(e time.Duration) MutexOption {
 	}
 }
 
+// WithMutexWatchdog extends the expire of the lock at 1/3 expire intervals while the holder is alive
+// (until unlocked or the ctx of `Lock` is done), so the long task doesn't lose the lock.
+func WithMutexWatchdog() MutexOption {
+	return func(d *distributed) {
+		d.watchdog = true
+	}
+}
+
 // DistributedMutex returns a simple distributed mutual exclusion lock.
-// uniqueID: suggest to use the request id.
+// uniqueID: suggest to use the request id, which identifies the holder for the reentrancy.
 func DistributedMutex(key, uniqueID string, options ...MutexOption) Mutex {
 	mutex := &distributed{
 		pool:   defaultRedis,
@@ -0,0 +1,133 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mna/redisc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistributedMutex(t *testing.T) {
+	ctx := context.TODO()
+
+	s := miniredis.RunT(t)
+	pool := newRedisPool(&RedisConfig{Addr: s.Addr()})
+
+	m1 := DistributedMutex("lock", "a")
+	m1.(*distributed).pool = pool
+
+	assert.Nil(t, m1.Lock(ctx, 10*time.Millisecond, time.Second))
+	assert.Equal(t, int64(1), m1.Token())
+
+	// reentrant
+	m2 := DistributedMutex("lock", "a")
+	m2.(*distributed).pool = pool
+
+	assert.Nil(t, m2.Lock(ctx, 10*time.Millisecond, time.Second))
+	assert.Equal(t, int64(1), m2.Token())
+
+	m3 := DistributedMutex("lock", "b")
+	m3.(*distributed).pool = pool
+
+	assert.ErrorIs(t, m3.Lock(ctx, 10*time.Millisecond, 50*time.Millisecond), context.DeadlineExceeded)
+	assert.Equal(t, int64(0), m3.Token())
+
+	// held until all holds are released
+	assert.Nil(t, m2.UnLock(ctx))
+	assert.True(t, s.Exists("lock"))
+	assert.Nil(t, m1.UnLock(ctx))
+	assert.False(t, s.Exists("lock"))
+	assert.Equal(t, int64(0), m1.Token())
+
+	// the fencing token increases
+	assert.Nil(t, m3.Lock(ctx, 10*time.Millisecond, time.Second))
+	assert.Equal(t, int64(2), m3.Token())
+	assert.Equal(t, "2", s.HGet("{lock}:hold", "token"))
+	assert.Nil(t, m3.UnLock(ctx))
+
+	// not held
+	assert.Nil(t, m3.UnLock(ctx))
+
+	// held by the old version (SET NX)
+	assert.Nil(t, s.Set("lock", "c"))
+
+	assert.ErrorIs(t, m3.Lock(ctx, 10*time.Millisecond, 50*time.Millisecond), context.DeadlineExceeded)
+
+	s.Del("lock")
+
+	assert.Nil(t, m3.Lock(ctx, 10*time.Millisecond, time.Second))
+
+	v, err := s.Get("lock")
+	assert.Nil(t, err)
+	assert.Equal(t, "b", v)
+	assert.Nil(t, m3.UnLock(ctx))
+}
+
+func TestDistributedMutexWatchdog(t *testing.T) {
+	ctx := context.TODO()
+
+	s := miniredis.RunT(t)
+
+	m := DistributedMutex("lock", "a", WithMutexExpire(300*time.Millisecond), WithMutexWatchdog())
+	m.(*distributed).pool = newRedisPool(&RedisConfig{Addr: s.Addr()})
+
+	assert.Nil(t, m.Lock(ctx, 10*time.Millisecond, time.Second))
+
+	s.SetTTL("lock", time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return s.TTL("lock") == 300*time.Millisecond
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Nil(t, m.UnLock(ctx))
+	assert.Nil(t, m.(*distributed).watch)
+
+	// the lock is lost
+	assert.Nil(t, m.Lock(ctx, 10*time.Millisecond, time.Second))
+
+	s.Del("lock")
+
+	assert.Eventually(t, func() bool {
+		return m.Token() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMutexKeySlot(t *testing.T) {
+	for _, prefix := range []string{"", "svc:", "{svc}:"} {
+		for _, key := range []string{"lock", "{user:1}:lock"} {
+			lock := prefix + key
+
+			for _, suffix := range []string{"hold", "fencing"} {
+				assert.Equal(t, redisc.Slot(lock), redisc.Slot(prefix+mutexKey(prefix, key, suffix)), lock)
+			}
+		}
+	}
+}
+
+func TestDistributedMutexCluster(t *testing.T) {
+	ctx := context.TODO()
+
+	s := miniredis.RunT(t)
+
+	pool, err := newRedisClusterPool(&RedisConfig{
+		ClusterAddrs: []string{s.Addr()},
+		Options:      &RedisOptions{KeyPrefix: "svc:"},
+	})
+
+	assert.Nil(t, err)
+
+	defer pool.cluster.Close()
+
+	m := DistributedMutex("lock", "a")
+	m.(*distributed).pool = pool
+
+	assert.Nil(t, m.Lock(ctx, 10*time.Millisecond, time.Second))
+	assert.Equal(t, int64(1), m.Token())
+	assert.True(t, s.Exists("svc:lock"))
+	assert.Equal(t, "1", s.HGet("svc:{svc:lock}:hold", "token"))
+	assert.Nil(t, m.UnLock(ctx))
+	assert.False(t, s.Exists("svc:lock"))
+}
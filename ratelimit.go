@@ -0,0 +1,234 @@
+package yiigo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// The time of the redis server (TIME) is used, so the clock skew of the instances doesn't matter.
+var (
+	// KEYS[1]: bucket, ARGV[1]: rate (tokens per ms), ARGV[2]: burst, ARGV[3]: n
+	// returns {allowed, wait (ms)}
+	tokenBucketScript = redis.NewScript(1, `
+redis.replicate_commands()
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or burst
+local ts = tonumber(bucket[2]) or now
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate)
+	ts = now
+end
+local wait = 0
+if tokens >= n then
+	tokens = tokens - n
+else
+	wait = math.ceil((n - tokens) / rate)
+end
+redis.call('HMSET', KEYS[1], 'tokens', tokens, 'ts', ts)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate) + 1000)
+if wait > 0 then
+	return {0, wait}
+end
+return {1, 0}`)
+
+	// KEYS[1]: window, ARGV[1]: limit, ARGV[2]: window (ms), ARGV[3]: n, ARGV[4]: member
+	// returns {allowed, wait (ms)}
+	slidingWindowScript = redis.NewScript(1, `
+redis.replicate_commands()
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+if count + n <= limit then
+	for i = 1, n do
+		redis.call('ZADD', KEYS[1], now, ARGV[4] .. ':' .. now .. ':' .. i)
+	end
+	redis.call('PEXPIRE', KEYS[1], window)
+	return {1, 0}
+end
+local oldest = redis.call('ZRANGE', KEYS[1], count + n - limit - 1, count + n - limit - 1, 'WITHSCORES')
+local wait = tonumber(oldest[2]) + window - now
+if wait < 1 then
+	wait = 1
+end
+return {0, wait}`)
+)
+
+// RateLimiter is a distributed rate limiter based on redis, which is shared by multiple instances.
+type RateLimiter interface {
+	// Allow reports whether an event may happen now.
+	Allow(ctx context.Context) (bool, error)
+
+	// AllowN reports whether n events may happen now.
+	AllowN(ctx context.Context, n int) (bool, error)
+
+	// Wait blocks until an event is allowed or ctx is done.
+	Wait(ctx context.Context) error
+
+	// WaitN blocks until n events are allowed or ctx is done.
+	WaitN(ctx context.Context, n int) error
+}
+
+// RateLimiterOption rate limiter option
+type RateLimiterOption func(l *rateLimiter)
+
+// WithRatePeriod specifies the period (at least 1ms) of the rate, eg: 100 per minute, default: 1s.
+func WithRatePeriod(d time.Duration) RateLimiterOption {
+	return func(l *rateLimiter) {
+		l.period = d
+	}
+}
+
+// WithSlidingWindow uses the sliding window (log) algorithm instead of the token bucket,
+// which allows at most rate events in any period, and the burst is ignored.
+func WithSlidingWindow() RateLimiterOption {
+	return func(l *rateLimiter) {
+		l.sliding = true
+	}
+}
+
+type rateLimiter struct {
+	pool    RedisPool
+	key     string
+	rate    int
+	burst   int
+	period  time.Duration
+	sliding bool
+}
+
+// NewRateLimiter returns a rate limiter of the key (eg: rate_limit:api:{uid}) on the redis of the name, which is atomic by lua script.
+// The token bucket (default) allows rate events per period (see `WithRatePeriod`) on average, and the bursts of at most burst events.
+func NewRateLimiter(redisName, key string, rate, burst int, options ...RateLimiterOption) RateLimiter {
+	l := &rateLimiter{
+		pool:   Redis(redisName),
+		key:    key,
+		rate:   rate,
+		burst:  burst,
+		period: time.Second,
+	}
+
+	for _, f := range options {
+		f(l)
+	}
+
+	return l
+}
+
+func (l *rateLimiter) Allow(ctx context.Context) (bool, error) {
+	return l.AllowN(ctx, 1)
+}
+
+func (l *rateLimiter) AllowN(ctx context.Context, n int) (bool, error) {
+	ok, _, err := l.reserve(ctx, n)
+
+	return ok, err
+}
+
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+func (l *rateLimiter) WaitN(ctx context.Context, n int) error {
+	if err := l.check(); err != nil {
+		return err
+	}
+
+	if c := l.capacity(); n > c {
+		return fmt.Errorf("rate limiter(%s): n (%d) exceeds the capacity (%d)", l.key, n, c)
+	}
+
+	for {
+		ok, wait, err := l.reserve(ctx, n)
+
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			return nil
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// check returns error if the rate or the period is invalid.
+func (l *rateLimiter) check() error {
+	if l.rate <= 0 || l.period.Milliseconds() <= 0 {
+		return fmt.Errorf("rate limiter(%s): invalid rate %d per %s", l.key, l.rate, l.period)
+	}
+
+	return nil
+}
+
+// capacity returns the max events at once.
+func (l *rateLimiter) capacity() int {
+	if l.sliding {
+		return l.rate
+	}
+
+	return l.burst
+}
+
+// reserve attempts to take n events, and returns the wait duration if not allowed.
+func (l *rateLimiter) reserve(ctx context.Context, n int) (bool, time.Duration, error) {
+	if err := l.check(); err != nil {
+		return false, 0, err
+	}
+
+	if n > l.capacity() {
+		return false, 0, nil
+	}
+
+	conn, err := l.pool.Get(ctx)
+
+	if err != nil {
+		return false, 0, err
+	}
+
+	defer l.pool.Put(conn)
+
+	var reply []int64
+
+	if l.sliding {
+		b := make([]byte, 8)
+
+		if _, err = rand.Read(b); err != nil {
+			return false, 0, err
+		}
+
+		reply, err = redis.Int64s(slidingWindowScript.Do(conn.Conn, l.key, l.rate, l.period.Milliseconds(), n, hex.EncodeToString(b)))
+	} else {
+		rate := float64(l.rate) / float64(l.period.Milliseconds())
+
+		reply, err = redis.Int64s(tokenBucketScript.Do(conn.Conn, l.key, strconv.FormatFloat(rate, 'f', -1, 64), l.burst, n))
+	}
+
+	if err != nil {
+		return false, 0, err
+	}
+
+	return reply[0] == 1, time.Duration(reply[1]) * time.Millisecond, nil
+}
@@ -0,0 +1,109 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucketRateLimiter(t *testing.T) {
+	ctx := context.TODO()
+
+	s := miniredis.RunT(t)
+	redisMap.Store("ratelimit", newRedisPool(&RedisConfig{Addr: s.Addr()}))
+
+	defer redisMap.Delete("ratelimit")
+
+	// 20 per second, burst 3
+	l := NewRateLimiter("ratelimit", "rate:token", 20, 3)
+
+	for i := 0; i < 3; i++ {
+		ok, err := l.Allow(ctx)
+
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	}
+
+	ok, err := l.Allow(ctx)
+
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = l.AllowN(ctx, 4)
+
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// refilled 1 token per 50ms
+	start := time.Now()
+
+	assert.Nil(t, l.Wait(ctx))
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	assert.NotNil(t, l.WaitN(ctx, 4))
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, l.Wait(timeout), context.DeadlineExceeded)
+
+	// refilled by the time of the redis server
+	s.SetTime(time.Now().Add(time.Second))
+
+	ok, err = l.AllowN(ctx, 3)
+
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
+
+func TestSlidingWindowRateLimiter(t *testing.T) {
+	ctx := context.TODO()
+
+	s := miniredis.RunT(t)
+	redisMap.Store("ratelimit", newRedisPool(&RedisConfig{Addr: s.Addr()}))
+
+	defer redisMap.Delete("ratelimit")
+
+	// 2 per 100ms
+	l := NewRateLimiter("ratelimit", "rate:window", 2, 0, WithRatePeriod(100*time.Millisecond), WithSlidingWindow())
+
+	ok, err := l.AllowN(ctx, 2)
+
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = l.Allow(ctx)
+
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	start := time.Now()
+
+	assert.Nil(t, l.Wait(ctx))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	ok, err = l.AllowN(ctx, 3)
+
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestRateLimiterInvalid(t *testing.T) {
+	ctx := context.TODO()
+
+	s := miniredis.RunT(t)
+	redisMap.Store("ratelimit", newRedisPool(&RedisConfig{Addr: s.Addr()}))
+
+	defer redisMap.Delete("ratelimit")
+
+	_, err := NewRateLimiter("ratelimit", "rate:invalid", 0, 3).Allow(ctx)
+	assert.NotNil(t, err)
+
+	assert.NotNil(t, NewRateLimiter("ratelimit", "rate:invalid", 10, 3, WithRatePeriod(0)).Wait(ctx))
+
+	_, err = NewRateLimiter("ratelimit", "rate:invalid", 0, 0, WithSlidingWindow()).Allow(ctx)
+	assert.NotNil(t, err)
+}
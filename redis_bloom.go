@@ -0,0 +1,304 @@
+package yiigo
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+var (
+	// KEYS[1]: bitmap, ARGV: the bit offsets
+	bloomAddScript = redis.NewScript(1, `
+for _, offset in ipairs(ARGV) do
+	redis.call('SETBIT', KEYS[1], offset, 1)
+end
+return 1`)
+
+	// KEYS[1]: bitmap, ARGV: the bit offsets
+	bloomExistsScript = redis.NewScript(1, `
+for _, offset in ipairs(ARGV) do
+	if redis.call('GETBIT', KEYS[1], offset) == 0 then
+		return 0
+	end
+end
+return 1`)
+)
+
+// BloomFilter is the probabilistic set for the membership checks, eg: the dedup of the event ids.
+// It may report the false positive (exists but not added) at the rate, but never the false negative.
+type BloomFilter interface {
+	// Add adds the items.
+	Add(ctx context.Context, items ...string) error
+
+	// Exists reports whether the item may exist.
+	Exists(ctx context.Context, item string) (bool, error)
+}
+
+// bloomParams returns the bits (m) and the hash functions (k) for n items with the false positive rate p.
+func bloomParams(n uint64, p float64) (m, k uint64) {
+	if n == 0 {
+		n = 1
+	}
+
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+
+	m = uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	k = uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+
+	if k == 0 {
+		k = 1
+	}
+
+	return m, k
+}
+
+// bloomOffsets returns the k bit offsets of the item by the double hashing.
+func bloomOffsets(item string, m, k uint64) []uint64 {
+	h1 := fnv.New64a()
+	h1.Write([]byte(item))
+
+	h2 := fnv.New64()
+	h2.Write([]byte(item))
+
+	a, b := h1.Sum64(), h2.Sum64()|1
+
+	offsets := make([]uint64, k)
+
+	for i := uint64(0); i < k; i++ {
+		offsets[i] = (a + i*b) % m
+	}
+
+	return offsets
+}
+
+// BloomOption bloom filter option
+type BloomOption func(f *redisBloomFilter)
+
+// WithBloomModule uses the RedisBloom module (BF.RESERVE, BF.MADD, BF.EXISTS) instead of the bitmap.
+func WithBloomModule() BloomOption {
+	return func(f *redisBloomFilter) {
+		f.module = true
+	}
+}
+
+type redisBloomFilter struct {
+	pool     RedisPool
+	key      string
+	n        uint64
+	p        float64
+	m        uint64
+	k        uint64
+	module   bool
+	reserved bool
+	mutex    sync.Mutex
+}
+
+// NewRedisBloomFilter returns the bloom filter stored in redis (the bitmap of the key by default) for n items
+// with the false positive rate p (default: 0.01), eg: yiigo.NewRedisBloomFilter(yiigo.Redis(), "bloom:events", 1000000, 0.001).
+// NOTE: The bitmap is at most 512MB (2^32 bits), and n and p must be the same for the key.
+func NewRedisBloomFilter(pool RedisPool, key string, n uint64, p float64, options ...BloomOption) BloomFilter {
+	f := &redisBloomFilter{
+		pool: pool,
+		key:  key,
+		n:    n,
+		p:    p,
+	}
+
+	f.m, f.k = bloomParams(n, p)
+
+	for _, fn := range options {
+		fn(f)
+	}
+
+	return f
+}
+
+func (f *redisBloomFilter) Add(ctx context.Context, items ...string) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	conn, err := f.pool.Get(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.pool.Put(conn)
+
+	if f.module {
+		if err = f.reserveModule(conn); err != nil {
+			return err
+		}
+
+		_, err = conn.Do("BF.MADD", redis.Args{f.key}.AddFlat(items)...)
+
+		return err
+	}
+
+	args := redis.Args{f.key}
+
+	for _, v := range items {
+		args = args.AddFlat(bloomOffsets(v, f.m, f.k))
+	}
+
+	_, err = bloomAddScript.Do(conn.Conn, args...)
+
+	return err
+}
+
+// reserveModule reserves the filter (BF.RESERVE) with n and p before the first add, which is retried if fails,
+// otherwise BF.MADD creates it with the defaults.
+func (f *redisBloomFilter) reserveModule(conn *RedisConn) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.reserved {
+		return nil
+	}
+
+	// "item exists" means the filter is reserved already
+	if _, err := conn.Do("BF.RESERVE", f.key, f.p, f.n); err != nil && !strings.Contains(err.Error(), "item exists") {
+		return err
+	}
+
+	f.reserved = true
+
+	return nil
+}
+
+func (f *redisBloomFilter) Exists(ctx context.Context, item string) (bool, error) {
+	conn, err := f.pool.Get(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer f.pool.Put(conn)
+
+	if f.module {
+		return redis.Bool(conn.Do("BF.EXISTS", f.key, item))
+	}
+
+	return redis.Bool(bloomExistsScript.Do(conn.Conn, redis.Args{f.key}.AddFlat(bloomOffsets(item, f.m, f.k))...))
+}
+
+type memBloomFilter struct {
+	bits  []uint64
+	m     uint64
+	k     uint64
+	mutex sync.RWMutex
+}
+
+// NewMemBloomFilter returns the in-process bloom filter for n items with the false positive rate p (default: 0.01), eg: the tests.
+func NewMemBloomFilter(n uint64, p float64) BloomFilter {
+	m, k := bloomParams(n, p)
+
+	return &memBloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+func (f *memBloomFilter) Add(ctx context.Context, items ...string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for _, v := range items {
+		for _, offset := range bloomOffsets(v, f.m, f.k) {
+			f.bits[offset/64] |= 1 << (offset % 64)
+		}
+	}
+
+	return nil
+}
+
+func (f *memBloomFilter) Exists(ctx context.Context, item string) (bool, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	for _, offset := range bloomOffsets(item, f.m, f.k) {
+		if f.bits[offset/64]&(1<<(offset%64)) == 0 {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// HyperLogLog is the probabilistic counter for the cardinality estimation, eg: the unique visitors.
+type HyperLogLog interface {
+	// Add adds the items.
+	Add(ctx context.Context, items ...string) error
+
+	// Count returns the approximated cardinality (the standard error is 0.81% in redis).
+	Count(ctx context.Context) (int64, error)
+}
+
+type redisHyperLogLog struct {
+	pool RedisPool
+	key  string
+}
+
+// NewRedisHyperLogLog returns the HyperLogLog of the key in redis (PFADD, PFCOUNT), eg: yiigo.NewRedisHyperLogLog(yiigo.Redis(), "uv:20260101").
+func NewRedisHyperLogLog(pool RedisPool, key string) HyperLogLog {
+	return &redisHyperLogLog{
+		pool: pool,
+		key:  key,
+	}
+}
+
+func (h *redisHyperLogLog) Add(ctx context.Context, items ...string) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	_, err := h.pool.Do(ctx, "PFADD", redis.Args{h.key}.AddFlat(items)...)
+
+	return err
+}
+
+func (h *redisHyperLogLog) Count(ctx context.Context) (int64, error) {
+	return redis.Int64(h.pool.Do(ctx, "PFCOUNT", h.key))
+}
+
+// PFCountUnion returns the approximated cardinality of the union of the HyperLogLogs, eg: the unique visitors of the week.
+// NOTE: The keys must be in the same slot in cluster mode, eg: uv:{202601}:01
+func PFCountUnion(ctx context.Context, pool RedisPool, keys ...string) (int64, error) {
+	return redis.Int64(pool.Do(ctx, "PFCOUNT", redis.Args{}.AddFlat(keys)...))
+}
+
+type memHyperLogLog struct {
+	items map[string]struct{}
+	mutex sync.RWMutex
+}
+
+// NewMemHyperLogLog returns the in-process HyperLogLog which counts exactly, eg: the tests.
+func NewMemHyperLogLog() HyperLogLog {
+	return &memHyperLogLog{items: make(map[string]struct{})}
+}
+
+func (h *memHyperLogLog) Add(ctx context.Context, items ...string) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for _, v := range items {
+		h.items[v] = struct{}{}
+	}
+
+	return nil
+}
+
+func (h *memHyperLogLog) Count(ctx context.Context) (int64, error) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	return int64(len(h.items)), nil
+}
@@ -0,0 +1,91 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBloomParams(t *testing.T) {
+	m, k := bloomParams(1000, 0.01)
+
+	assert.Equal(t, uint64(9586), m)
+	assert.Equal(t, uint64(7), k)
+}
+
+func TestBloomFilter(t *testing.T) {
+	ctx := context.TODO()
+
+	s := miniredis.RunT(t)
+
+	filters := []BloomFilter{
+		NewMemBloomFilter(1000, 0.01),
+		NewRedisBloomFilter(newRedisPool(&RedisConfig{Addr: s.Addr()}), "bloom", 1000, 0.01),
+	}
+
+	for _, f := range filters {
+		items := make([]string, 0, 1000)
+
+		for i := 0; i < 1000; i++ {
+			items = append(items, fmt.Sprintf("event:%d", i))
+		}
+
+		assert.Nil(t, f.Add(ctx, items...))
+
+		// no false negative
+		for _, v := range items {
+			ok, err := f.Exists(ctx, v)
+
+			assert.Nil(t, err)
+			assert.True(t, ok)
+		}
+
+		fp := 0
+
+		for i := 1000; i < 2000; i++ {
+			ok, err := f.Exists(ctx, fmt.Sprintf("event:%d", i))
+
+			assert.Nil(t, err)
+
+			if ok {
+				fp++
+			}
+		}
+
+		assert.Less(t, fp, 50)
+	}
+
+	// the reserve error is returned and retried, eg: the module isn't loaded
+	f := NewRedisBloomFilter(newRedisPool(&RedisConfig{Addr: s.Addr()}), "bloom:module", 1000, 0.01, WithBloomModule())
+
+	assert.NotNil(t, f.Add(ctx, "a"))
+	assert.False(t, f.(*redisBloomFilter).reserved)
+	assert.NotNil(t, f.Add(ctx, "a"))
+}
+
+func TestHyperLogLog(t *testing.T) {
+	ctx := context.TODO()
+
+	s := miniredis.RunT(t)
+	pool := newRedisPool(&RedisConfig{Addr: s.Addr()})
+
+	for _, h := range []HyperLogLog{NewMemHyperLogLog(), NewRedisHyperLogLog(pool, "uv:1")} {
+		assert.Nil(t, h.Add(ctx, "a", "b", "c"))
+		assert.Nil(t, h.Add(ctx, "a", "d"))
+
+		n, err := h.Count(ctx)
+
+		assert.Nil(t, err)
+		assert.Equal(t, int64(4), n)
+	}
+
+	assert.Nil(t, NewRedisHyperLogLog(pool, "uv:2").Add(ctx, "d", "e"))
+
+	n, err := PFCountUnion(ctx, pool, "uv:1", "uv:2")
+
+	assert.Nil(t, err)
+	assert.InDelta(t, 5, n, 1)
+}
@@ -0,0 +1,143 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/mna/redisc"
+	"go.uber.org/zap"
+)
+
+// redisClusterConn follows the redirections (MOVED, ASK) for Do,
+// the pipeline (Send, Flush, Receive) is sent to the node bound by the first command (or redisc.BindConn).
+type redisClusterConn struct {
+	redis.Conn // *redisc.Conn
+
+	retry redis.Conn
+}
+
+func (c *redisClusterConn) Do(cmd string, args ...any) (any, error) {
+	return c.retry.Do(cmd, args...)
+}
+
+type redisClusterPool struct {
+	cluster *redisc.Cluster
+	prefix  string
+}
+
+// newRedisClusterPool returns the pool of the cluster, each node has its own pool of PoolSize connections.
+// NOTE: The cluster only supports the database 0, and PoolPrefill is ignored.
+func newRedisClusterPool(cfg *RedisConfig) (*redisClusterPool, error) {
+	opt := redisOptions(cfg)
+
+	if opt.Database != 0 {
+		return nil, fmt.Errorf("redis cluster only supports the database 0, but got %d", opt.Database)
+	}
+
+	cluster := &redisc.Cluster{
+		StartupNodes: cfg.ClusterAddrs,
+		DialOptions:  redisDialOptions(opt),
+		CreatePool: func(addr string, options ...redis.DialOption) (*redis.Pool, error) {
+			return &redis.Pool{
+				Dial: func() (redis.Conn, error) {
+					return redis.Dial("tcp", addr, options...)
+				},
+				MaxIdle:     opt.PoolSize,
+				MaxActive:   opt.PoolSize,
+				IdleTimeout: opt.IdleTimeout,
+				Wait:        true,
+			}, nil
+		},
+		PoolWaitTime: opt.ConnTimeout,
+		BgError: func(src redisc.BgErrorSrc, err error) {
+			logger.Warn("err redis cluster", zap.Uint("source", uint(src)), zap.Error(err))
+		},
+	}
+
+	// load the slots mapping, it's refreshed by the MOVED replies afterwards
+	if err := cluster.Refresh(); err != nil {
+		cluster.Close()
+
+		return nil, err
+	}
+
+	return &redisClusterPool{cluster: cluster, prefix: opt.KeyPrefix}, nil
+}
+
+// dial returns the connection which is not managed by the pool, eg: the subscription.
+func (rp *redisClusterPool) dial() (redis.Conn, error) {
+	conn, err := rp.cluster.Dial()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return withKeyPrefix(conn, rp.prefix), nil
+}
+
+func (rp *redisClusterPool) keyPrefix() string {
+	return rp.prefix
+}
+
+func (rp *redisClusterPool) Get(ctx context.Context) (*RedisConn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	conn := rp.cluster.Get()
+
+	if err := conn.Err(); err != nil {
+		conn.Close()
+
+		return nil, err
+	}
+
+	retry, err := redisc.RetryConn(conn, 3, 100*time.Millisecond)
+
+	if err != nil {
+		conn.Close()
+
+		return nil, err
+	}
+
+	// the keys are prefixed before routing, so the slot is of the prefixed key
+	return &RedisConn{withKeyPrefix(&redisClusterConn{Conn: conn, retry: retry}, rp.prefix)}, nil
+}
+
+func (rp *redisClusterPool) Put(conn *RedisConn) {
+	// the connection is bound to a node, so release it to the node's pool
+	conn.Close()
+}
+
+func (rp *redisClusterPool) Do(ctx context.Context, cmd string, args ...any) (any, error) {
+	conn, err := rp.Get(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rp.Put(conn)
+
+	return conn.Do(cmd, args...)
+}
+
+func (rp *redisClusterPool) DoFunc(ctx context.Context, f func(ctx context.Context, conn *RedisConn) error) error {
+	conn, err := rp.Get(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		rp.Put(conn)
+
+		if r := recover(); r != nil {
+			logger.Error("redis do func panic", zap.Any("error", r), zap.ByteString("stack", debug.Stack()))
+		}
+	}()
+
+	return f(ctx, conn)
+}
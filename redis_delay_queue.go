@@ -0,0 +1,264 @@
+package yiigo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"go.uber.org/zap"
+)
+
+var (
+	// KEYS[1]: delayed, KEYS[2]: jobs, ARGV[1]: id, ARGV[2]: payload, ARGV[3]: run at (ms)
+	delayQueueAddScript = redis.NewScript(2, `
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+redis.call('ZADD', KEYS[1], ARGV[3], ARGV[1])
+return 1`)
+
+	// KEYS[1]: delayed, KEYS[2]: inflight, KEYS[3]: ready, ARGV[1]: now (ms), ARGV[2]: batch
+	// moves the due jobs and the jobs exceeded the visibility timeout to the ready list
+	delayQueuePromoteScript = redis.NewScript(3, `
+local n = 0
+for i = 1, 2 do
+	local ids = redis.call('ZRANGEBYSCORE', KEYS[i], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+	for _, id in ipairs(ids) do
+		redis.call('ZREM', KEYS[i], id)
+		redis.call('RPUSH', KEYS[3], id)
+	end
+	n = n + #ids
+end
+return n`)
+
+	// KEYS[1]: ready, KEYS[2]: inflight, KEYS[3]: jobs, KEYS[4]: attempts, ARGV[1]: visible at (ms)
+	// returns {id, payload, attempts}, or nil if empty
+	delayQueueReserveScript = redis.NewScript(4, `
+while true do
+	local id = redis.call('LPOP', KEYS[1])
+	if not id then
+		return nil
+	end
+	local payload = redis.call('HGET', KEYS[3], id)
+	if payload then
+		redis.call('ZADD', KEYS[2], ARGV[1], id)
+		local attempts = redis.call('HINCRBY', KEYS[4], id, 1)
+		return {id, payload, attempts}
+	end
+end`)
+
+	// KEYS[1]: delayed, KEYS[2]: inflight, KEYS[3]: jobs, KEYS[4]: attempts, ARGV[1]: id
+	delayQueueRemoveScript = redis.NewScript(4, `
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('HDEL', KEYS[4], ARGV[1])
+return redis.call('HDEL', KEYS[3], ARGV[1])`)
+)
+
+// DelayJob the job of `DelayQueue`.
+type DelayJob struct {
+	ID       string
+	Payload  []byte
+	Attempts int64 // 1 for the first delivery
+}
+
+// DelayJobHandler handles the job, the job is removed if returns nil,
+// otherwise it's redelivered after the visibility timeout (at least once).
+type DelayJobHandler func(ctx context.Context, job *DelayJob) error
+
+// DelayQueueOption delay queue option
+type DelayQueueOption func(q *DelayQueue)
+
+// WithDelayQueueRedis specifies redis pool for the queue.
+func WithDelayQueueRedis(name string) DelayQueueOption {
+	return func(q *DelayQueue) {
+		q.pool = Redis(name)
+	}
+}
+
+// WithDelayQueueVisibility specifies the visibility timeout of the reserved job, the job is redelivered if it isn't
+// done in time (eg: the consumer crashed), default: 30s.
+func WithDelayQueueVisibility(d time.Duration) DelayQueueOption {
+	return func(q *DelayQueue) {
+		q.visibility = d
+	}
+}
+
+// WithDelayQueuePoll specifies the interval of polling the due jobs when the queue is empty, default: 1s.
+func WithDelayQueuePoll(d time.Duration) DelayQueueOption {
+	return func(q *DelayQueue) {
+		q.poll = d
+	}
+}
+
+// DelayQueue is the delayed (scheduled) job queue based on redis, the jobs are stored in the sorted set
+// by the run time, and moved to the ready list once due.
+type DelayQueue struct {
+	pool       RedisPool
+	delayed    string
+	ready      string
+	inflight   string
+	jobs       string
+	attempts   string
+	visibility time.Duration
+	poll       time.Duration
+}
+
+// NewDelayQueue returns the delay queue of the name, the keys are prefixed by {name} (the same slot in cluster mode).
+func NewDelayQueue(name string, options ...DelayQueueOption) *DelayQueue {
+	prefix := "{" + name + "}:"
+
+	q := &DelayQueue{
+		pool:       defaultRedis,
+		delayed:    prefix + "delayed",
+		ready:      prefix + "ready",
+		inflight:   prefix + "inflight",
+		jobs:       prefix + "jobs",
+		attempts:   prefix + "attempts",
+		visibility: 30 * time.Second,
+		poll:       time.Second,
+	}
+
+	for _, f := range options {
+		f(q)
+	}
+
+	return q
+}
+
+// Add adds the job which runs after the delay, and returns the id of the job.
+func (q *DelayQueue) Add(ctx context.Context, payload []byte, delay time.Duration) (string, error) {
+	return q.AddAt(ctx, payload, time.Now().Add(delay))
+}
+
+// AddAt adds the job which runs at the time, and returns the id of the job.
+func (q *DelayQueue) AddAt(ctx context.Context, payload []byte, runAt time.Time) (string, error) {
+	b := make([]byte, 16)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	id := hex.EncodeToString(b)
+
+	conn, err := q.pool.Get(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer q.pool.Put(conn)
+
+	if _, err = delayQueueAddScript.Do(conn.Conn, q.delayed, q.jobs, id, payload, runAt.UnixMilli()); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// Remove removes the job (eg: canceled), returns false if not exists.
+func (q *DelayQueue) Remove(ctx context.Context, id string) (bool, error) {
+	conn, err := q.pool.Get(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer q.pool.Put(conn)
+
+	return redis.Bool(delayQueueRemoveScript.Do(conn.Conn, q.delayed, q.inflight, q.jobs, q.attempts, id))
+}
+
+// Consume handles the due jobs (in order) until ctx is done, run multiple goroutines (instances) for the concurrency.
+func (q *DelayQueue) Consume(ctx context.Context, handler DelayJobHandler) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		job, err := q.next(ctx)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			logger.Error("err delay queue", zap.String("queue", q.ready), zap.Error(err))
+		}
+
+		if job == nil {
+			timer := time.NewTimer(q.poll)
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+
+				return nil
+			case <-timer.C:
+			}
+
+			continue
+		}
+
+		if err = q.call(ctx, handler, job); err != nil {
+			logger.Warn("err delay job handle", zap.String("queue", q.ready), zap.String("id", job.ID), zap.Int64("attempts", job.Attempts), zap.Error(err))
+
+			continue
+		}
+
+		if _, err = q.Remove(ctx, job.ID); err != nil {
+			logger.Error("err delay job remove", zap.String("queue", q.ready), zap.String("id", job.ID), zap.Error(err))
+		}
+	}
+}
+
+// next promotes the due jobs, and reserves the next ready job (nil if none).
+func (q *DelayQueue) next(ctx context.Context) (*DelayJob, error) {
+	conn, err := q.pool.Get(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer q.pool.Put(conn)
+
+	now := time.Now()
+
+	if _, err = delayQueuePromoteScript.Do(conn.Conn, q.delayed, q.inflight, q.ready, now.UnixMilli(), 100); err != nil {
+		return nil, err
+	}
+
+	reply, err := redis.Values(delayQueueReserveScript.Do(conn.Conn, q.ready, q.inflight, q.jobs, q.attempts, now.Add(q.visibility).UnixMilli()))
+
+	if err != nil {
+		if err == redis.ErrNil {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	job := new(DelayJob)
+
+	if _, err = redis.Scan(reply, &job.ID, &job.Payload, &job.Attempts); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (q *DelayQueue) call(ctx context.Context, handler DelayJobHandler, job *DelayJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+
+			logger.Error("delay job handler panic", zap.String("id", job.ID), zap.Any("error", r), zap.ByteString("stack", debug.Stack()))
+		}
+	}()
+
+	return handler(ctx, job)
+}
@@ -0,0 +1,67 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelayQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	s := miniredis.RunT(t)
+
+	q := NewDelayQueue("jobs", WithDelayQueueVisibility(100*time.Millisecond), WithDelayQueuePoll(10*time.Millisecond))
+	q.pool = newRedisPool(&RedisConfig{Addr: s.Addr()})
+
+	start := time.Now()
+
+	_, err := q.Add(ctx, []byte("later"), 80*time.Millisecond)
+	assert.Nil(t, err)
+
+	_, err = q.Add(ctx, []byte("now"), 0)
+	assert.Nil(t, err)
+
+	id, err := q.Add(ctx, []byte("canceled"), 50*time.Millisecond)
+	assert.Nil(t, err)
+
+	ok, err := q.Remove(ctx, id)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	jobs := make(chan *DelayJob, 10)
+
+	go q.Consume(ctx, func(ctx context.Context, job *DelayJob) error {
+		jobs <- job
+
+		// redelivered after the visibility timeout
+		if string(job.Payload) == "now" && job.Attempts == 1 {
+			return errors.New("oops")
+		}
+
+		return nil
+	})
+
+	job := <-jobs
+	assert.Equal(t, "now", string(job.Payload))
+	assert.Equal(t, int64(1), job.Attempts)
+
+	job = <-jobs
+	assert.Equal(t, "later", string(job.Payload))
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+
+	job = <-jobs
+	assert.Equal(t, "now", string(job.Payload))
+	assert.Equal(t, int64(2), job.Attempts)
+
+	assert.Eventually(t, func() bool {
+		return !s.Exists("{jobs}:jobs") && !s.Exists("{jobs}:inflight")
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Len(t, jobs, 0)
+}
@@ -0,0 +1,290 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// RedisPoolStats the stats of the redis pool.
+type RedisPoolStats struct {
+	Hits         int64         `json:"hits"`     // the times of Get served by the reused conn
+	Misses       int64         `json:"misses"`   // the times of Get served by the new conn
+	Timeouts     int64         `json:"timeouts"` // the times of Get timed out on waiting for the conn
+	ActiveConns  int64         `json:"active_conns"`
+	IdleConns    int64         `json:"idle_conns"`
+	WaitCount    int64         `json:"wait_count"`
+	WaitDuration time.Duration `json:"wait_duration"`
+}
+
+// redisStatser is implemented by the pools which report the stats.
+type redisStatser interface {
+	stats() RedisPoolStats
+}
+
+func (rp *redisResourcePool) stats() RedisPoolStats {
+	return RedisPoolStats{
+		Hits:         atomic.LoadInt64(&rp.hits),
+		Misses:       atomic.LoadInt64(&rp.misses),
+		Timeouts:     atomic.LoadInt64(&rp.timeouts),
+		ActiveConns:  rp.pool.Active(),
+		IdleConns:    rp.pool.Active() - rp.pool.InUse(),
+		WaitCount:    rp.pool.WaitCount(),
+		WaitDuration: rp.pool.WaitTime(),
+	}
+}
+
+// stats returns the sum of the node pools, the Get counters (hits, misses, timeouts) are not recorded.
+func (rp *redisClusterPool) stats() RedisPoolStats {
+	var stats RedisPoolStats
+
+	for _, v := range rp.cluster.Stats() {
+		stats.ActiveConns += int64(v.ActiveCount)
+		stats.IdleConns += int64(v.IdleCount)
+		stats.WaitCount += v.WaitCount
+		stats.WaitDuration += v.WaitDuration
+	}
+
+	return stats
+}
+
+// RedisHealth the health status of the redis registered by `Init`.
+type RedisHealth struct {
+	Name      string         `json:"name"`
+	Healthy   bool           `json:"healthy"`
+	Failures  int            `json:"failures"`   // the consecutive failures
+	LastError string         `json:"last_error"` // the error of the last failed check
+	LastCheck time.Time      `json:"last_check"` // zero if never checked
+	Stats     RedisPoolStats `json:"stats"`
+}
+
+var redishealth = &dbHealthRegistry{status: make(map[string]*DBHealth)}
+
+// RedisStatus returns the health status (sorted by name) of the redis registered by `Init`, eg: the readiness probe.
+// The redis not checked yet (see `StartRedisHealthCheck`) is healthy since it's pinged on registering.
+func RedisStatus() []RedisHealth {
+	status := make([]RedisHealth, 0)
+
+	redishealth.mutex.RLock()
+	defer redishealth.mutex.RUnlock()
+
+	redisMap.Range(func(key, value any) bool {
+		name := key.(string)
+
+		s := RedisHealth{Name: name, Healthy: true}
+
+		if v, ok := redishealth.status[name]; ok {
+			s.Healthy = v.Healthy
+			s.Failures = v.Failures
+			s.LastError = v.LastError
+			s.LastCheck = v.LastCheck
+		}
+
+		if v, ok := value.(redisStatser); ok {
+			s.Stats = v.stats()
+		}
+
+		status = append(status, s)
+
+		return true
+	})
+
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Name < status[j].Name
+	})
+
+	return status
+}
+
+// StartRedisHealthCheck pings (PING) the redis registered by `Init` periodically until ctx is done, the redis which fails
+// the ping is marked unhealthy and checked with backoff until it recovers, the options are the same as `StartDBHealthCheck`.
+// NOTE: The timeout only limits the wait for the conn of the pool, the ping is limited by the ReadTimeout of the redis.
+func StartRedisHealthCheck(ctx context.Context, options ...DBHealthOption) {
+	o := &dbHealthOptions{
+		interval:   10 * time.Second,
+		timeout:    3 * time.Second,
+		backoff:    time.Second,
+		maxBackoff: 30 * time.Second,
+	}
+
+	for _, f := range options {
+		f(o)
+	}
+
+	go func() {
+		checkers := make(map[RedisPool]struct{})
+
+		// the redis registered later are checked from the next round
+		spawn := func() {
+			redisMap.Range(func(key, value any) bool {
+				pool := value.(RedisPool)
+
+				if _, ok := checkers[pool]; !ok {
+					checkers[pool] = struct{}{}
+
+					go checkRedis(ctx, key.(string), pool, o)
+				}
+
+				return true
+			})
+		}
+
+		spawn()
+
+		ticker := time.NewTicker(o.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				spawn()
+			}
+		}
+	}()
+}
+
+func checkRedis(ctx context.Context, name string, pool RedisPool, o *dbHealthOptions) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		// the redis is replaced or removed
+		if v, ok := redisMap.Load(name); !ok || v.(RedisPool) != pool {
+			return
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, o.timeout)
+		_, err := pool.Do(pingCtx, "PING")
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		prev, failures := redishealth.update(name, err)
+
+		if err == nil {
+			if prev != 0 {
+				logger.Info(fmt.Sprintf("redis.%s is recovered", name), zap.Int("failures", prev))
+			}
+
+			timer.Reset(o.interval)
+
+			continue
+		}
+
+		if failures == 1 {
+			logger.Warn(fmt.Sprintf("redis.%s is unhealthy", name), zap.Error(err))
+		}
+
+		backoff := o.backoff << (failures - 1)
+
+		if backoff <= 0 || backoff > o.maxBackoff {
+			backoff = o.maxBackoff
+		}
+
+		timer.Reset(backoff)
+	}
+}
+
+type redisStatsCollector struct {
+	name string
+	pool redisStatser
+
+	up           *prometheus.Desc
+	hits         *prometheus.Desc
+	misses       *prometheus.Desc
+	timeouts     *prometheus.Desc
+	activeConns  *prometheus.Desc
+	idleConns    *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+}
+
+func newRedisStatsCollector(name string, pool redisStatser) *redisStatsCollector {
+	labels := prometheus.Labels{"redis_name": name}
+
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName("redis", "pool", name), help, nil, labels)
+	}
+
+	return &redisStatsCollector{
+		name:         name,
+		pool:         pool,
+		up:           prometheus.NewDesc("redis_up", "Whether the last health check succeeded (1 if not checked).", nil, labels),
+		hits:         desc("hits_total", "The number of times a reused connection was got from the pool."),
+		misses:       desc("misses_total", "The number of times a new connection was got from the pool."),
+		timeouts:     desc("timeouts_total", "The number of times a get from the pool timed out."),
+		activeConns:  desc("active_connections", "The number of established connections."),
+		idleConns:    desc("idle_connections", "The number of idle connections."),
+		waitCount:    desc("wait_count_total", "The total number of connections waited for."),
+		waitDuration: desc("wait_duration_seconds_total", "The total time blocked waiting for a connection."),
+	}
+}
+
+func (c *redisStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.up
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.activeConns
+	ch <- c.idleConns
+	ch <- c.waitCount
+	ch <- c.waitDuration
+}
+
+func (c *redisStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	up := float64(1)
+
+	redishealth.mutex.RLock()
+
+	if v, ok := redishealth.status[c.name]; ok && !v.Healthy {
+		up = 0
+	}
+
+	redishealth.mutex.RUnlock()
+
+	stats := c.pool.stats()
+
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, up)
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.activeConns, prometheus.GaugeValue, float64(stats.ActiveConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+}
+
+// RegisterRedisStats registers the collectors of the pool stats (hits, misses, timeouts, conns, etc.) and the health (redis_up)
+// for the redis registered by `Init`, which are labeled by redis_name, eg: yiigo.RegisterRedisStats(prometheus.DefaultRegisterer).
+func RegisterRedisStats(reg prometheus.Registerer) error {
+	var err error
+
+	redisMap.Range(func(key, value any) bool {
+		pool, ok := value.(redisStatser)
+
+		if !ok {
+			return true
+		}
+
+		err = reg.Register(newRedisStatsCollector(key.(string), pool))
+
+		return err == nil
+	})
+
+	return err
+}
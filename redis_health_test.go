@@ -0,0 +1,110 @@
+package yiigo
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisHealthCheck(t *testing.T) {
+	s := miniredis.RunT(t)
+
+	pool := newRedisPool(&RedisConfig{Addr: s.Addr(), Options: &RedisOptions{PoolSize: 1}})
+
+	redisMap.Store("health", pool)
+
+	defer func() {
+		redisMap.Delete("health")
+
+		redishealth.mutex.Lock()
+		delete(redishealth.status, "health")
+		redishealth.mutex.Unlock()
+	}()
+
+	status := func() RedisHealth {
+		for _, v := range RedisStatus() {
+			if v.Name == "health" {
+				return v
+			}
+		}
+
+		return RedisHealth{}
+	}
+
+	// not checked yet
+	assert.True(t, status().Healthy)
+	assert.True(t, status().LastCheck.IsZero())
+
+	// stats
+	ctx := context.TODO()
+
+	conn, err := pool.Get(ctx)
+	assert.Nil(t, err)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	_, err = pool.Get(timeoutCtx)
+	cancel()
+	assert.NotNil(t, err)
+
+	pool.Put(conn)
+
+	_, err = pool.Do(ctx, "PING")
+	assert.Nil(t, err)
+
+	stats := status().Stats
+
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, int64(1), stats.Timeouts)
+	assert.Equal(t, int64(1), stats.ActiveConns)
+	assert.Equal(t, int64(1), stats.IdleConns)
+
+	reg := prometheus.NewRegistry()
+
+	assert.Nil(t, RegisterRedisStats(reg))
+
+	expected := `
+# HELP redis_pool_hits_total The number of times a reused connection was got from the pool.
+# TYPE redis_pool_hits_total counter
+redis_pool_hits_total{redis_name="health"} 1
+`
+
+	assert.Nil(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "redis_pool_hits_total"))
+
+	checkCtx, stop := context.WithCancel(ctx)
+
+	defer stop()
+
+	StartRedisHealthCheck(checkCtx, WithHealthInterval(10*time.Millisecond), WithHealthBackoff(5*time.Millisecond, 20*time.Millisecond))
+
+	assert.Eventually(t, func() bool {
+		return !status().LastCheck.IsZero()
+	}, time.Second, 5*time.Millisecond)
+
+	assert.True(t, status().Healthy)
+
+	s.SetError("LOADING")
+
+	assert.Eventually(t, func() bool {
+		s := status()
+
+		return !s.Healthy && s.Failures >= 2 && len(s.LastError) != 0
+	}, time.Second, 5*time.Millisecond)
+
+	n, err := testutil.GatherAndCount(reg, "redis_up")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	assert.Nil(t, testutil.GatherAndCompare(reg, strings.NewReader("# HELP redis_up Whether the last health check succeeded (1 if not checked).\n# TYPE redis_up gauge\nredis_up{redis_name=\"health\"} 0\n"), "redis_up"))
+
+	s.SetError("")
+
+	assert.Eventually(t, func() bool {
+		return status().Healthy
+	}, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,270 @@
+package yiigo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// redisKeySpec specifies the positions of the keys in the args of the command.
+type redisKeySpec int
+
+const (
+	redisKeyFirst          redisKeySpec = iota // the first arg, eg: GET key
+	redisKeyNone                               // no key, eg: PING, PUBLISH channel
+	redisKeyAll                                // all args, eg: DEL key [key ...]
+	redisKeyFirstTwo                           // eg: RPOPLPUSH source destination
+	redisKeyAllButLast                         // eg: BLPOP key [key ...] timeout
+	redisKeyAllButFirst                        // eg: BITOP operation destkey key [key ...]
+	redisKeyOdd                                // eg: MSET key value [key value ...]
+	redisKeySecond                             // the arg after the subcommand, eg: XGROUP CREATE key group id
+	redisKeyNumkeys                            // eg: EVAL script numkeys key [key ...] arg [arg ...]
+	redisKeyLeadingNumkeys                     // eg: ZUNION numkeys key [key ...]
+	redisKeyDestNumkeys                        // eg: ZUNIONSTORE destination numkeys key [key ...]
+	redisKeyStreams                            // eg: XREAD ... STREAMS key [key ...] id [id ...]
+	redisKeyScan                               // the pattern of SCAN cursor [MATCH pattern]
+)
+
+// redisKeySpecs the key specs of the commands, the args of the unknown commands are not prefixed.
+var redisKeySpecs = map[string]redisKeySpec{
+	"AUTH": redisKeyNone, "CLIENT": redisKeyNone, "CLUSTER": redisKeyNone, "COMMAND": redisKeyNone, "CONFIG": redisKeyNone,
+	"DBSIZE": redisKeyNone, "DISCARD": redisKeyNone, "ECHO": redisKeyNone, "EXEC": redisKeyNone, "FLUSHALL": redisKeyNone,
+	"FLUSHDB": redisKeyNone, "FUNCTION": redisKeyNone, "HELLO": redisKeyNone, "INFO": redisKeyNone, "MULTI": redisKeyNone,
+	"PING": redisKeyNone, "PSUBSCRIBE": redisKeyNone, "PUBLISH": redisKeyNone, "PUBSUB": redisKeyNone, "PUNSUBSCRIBE": redisKeyNone,
+	"QUIT": redisKeyNone, "RANDOMKEY": redisKeyNone, "READONLY": redisKeyNone, "READWRITE": redisKeyNone,
+	"SCRIPT": redisKeyNone, "SELECT": redisKeyNone, "SPUBLISH": redisKeyNone, "SUBSCRIBE": redisKeyNone, "TIME": redisKeyNone,
+	"UNSUBSCRIBE": redisKeyNone, "UNWATCH": redisKeyNone, "WAIT": redisKeyNone,
+
+	// strings
+	"APPEND": redisKeyFirst, "DECR": redisKeyFirst, "DECRBY": redisKeyFirst, "GET": redisKeyFirst, "GETDEL": redisKeyFirst,
+	"GETEX": redisKeyFirst, "GETRANGE": redisKeyFirst, "GETSET": redisKeyFirst, "INCR": redisKeyFirst, "INCRBY": redisKeyFirst,
+	"INCRBYFLOAT": redisKeyFirst, "PSETEX": redisKeyFirst, "SET": redisKeyFirst, "SETEX": redisKeyFirst, "SETNX": redisKeyFirst,
+	"SETRANGE": redisKeyFirst, "STRLEN": redisKeyFirst, "SUBSTR": redisKeyFirst,
+	// bitmaps
+	"BITCOUNT": redisKeyFirst, "BITFIELD": redisKeyFirst, "BITFIELD_RO": redisKeyFirst, "BITPOS": redisKeyFirst,
+	"GETBIT": redisKeyFirst, "SETBIT": redisKeyFirst,
+	// generic
+	"DUMP": redisKeyFirst, "EXPIRE": redisKeyFirst, "EXPIREAT": redisKeyFirst, "EXPIRETIME": redisKeyFirst, "KEYS": redisKeyFirst,
+	"PERSIST": redisKeyFirst, "PEXPIRE": redisKeyFirst, "PEXPIREAT": redisKeyFirst, "PEXPIRETIME": redisKeyFirst,
+	"PTTL": redisKeyFirst, "RESTORE": redisKeyFirst, "SORT": redisKeyFirst, "SORT_RO": redisKeyFirst, "TTL": redisKeyFirst,
+	"TYPE": redisKeyFirst,
+	// hashes
+	"HDEL": redisKeyFirst, "HEXISTS": redisKeyFirst, "HGET": redisKeyFirst, "HGETALL": redisKeyFirst, "HINCRBY": redisKeyFirst,
+	"HINCRBYFLOAT": redisKeyFirst, "HKEYS": redisKeyFirst, "HLEN": redisKeyFirst, "HMGET": redisKeyFirst, "HMSET": redisKeyFirst,
+	"HRANDFIELD": redisKeyFirst, "HSCAN": redisKeyFirst, "HSET": redisKeyFirst, "HSETNX": redisKeyFirst, "HSTRLEN": redisKeyFirst,
+	"HVALS": redisKeyFirst,
+	// lists
+	"LINDEX": redisKeyFirst, "LINSERT": redisKeyFirst, "LLEN": redisKeyFirst, "LPOP": redisKeyFirst, "LPOS": redisKeyFirst,
+	"LPUSH": redisKeyFirst, "LPUSHX": redisKeyFirst, "LRANGE": redisKeyFirst, "LREM": redisKeyFirst, "LSET": redisKeyFirst,
+	"LTRIM": redisKeyFirst, "RPOP": redisKeyFirst, "RPUSH": redisKeyFirst, "RPUSHX": redisKeyFirst,
+	// sets
+	"SADD": redisKeyFirst, "SCARD": redisKeyFirst, "SISMEMBER": redisKeyFirst, "SMEMBERS": redisKeyFirst, "SMISMEMBER": redisKeyFirst,
+	"SPOP": redisKeyFirst, "SRANDMEMBER": redisKeyFirst, "SREM": redisKeyFirst, "SSCAN": redisKeyFirst,
+	// sorted sets
+	"ZADD": redisKeyFirst, "ZCARD": redisKeyFirst, "ZCOUNT": redisKeyFirst, "ZINCRBY": redisKeyFirst, "ZLEXCOUNT": redisKeyFirst,
+	"ZMSCORE": redisKeyFirst, "ZPOPMAX": redisKeyFirst, "ZPOPMIN": redisKeyFirst, "ZRANDMEMBER": redisKeyFirst, "ZRANGE": redisKeyFirst,
+	"ZRANGEBYLEX": redisKeyFirst, "ZRANGEBYSCORE": redisKeyFirst, "ZRANK": redisKeyFirst, "ZREM": redisKeyFirst,
+	"ZREMRANGEBYLEX": redisKeyFirst, "ZREMRANGEBYRANK": redisKeyFirst, "ZREMRANGEBYSCORE": redisKeyFirst, "ZREVRANGE": redisKeyFirst,
+	"ZREVRANGEBYLEX": redisKeyFirst, "ZREVRANGEBYSCORE": redisKeyFirst, "ZREVRANK": redisKeyFirst, "ZSCAN": redisKeyFirst,
+	"ZSCORE": redisKeyFirst,
+	// hyperloglog, geo
+	"PFADD": redisKeyFirst, "GEOADD": redisKeyFirst, "GEODIST": redisKeyFirst, "GEOHASH": redisKeyFirst, "GEOPOS": redisKeyFirst,
+	"GEORADIUS": redisKeyFirst, "GEORADIUSBYMEMBER": redisKeyFirst, "GEORADIUSBYMEMBER_RO": redisKeyFirst, "GEORADIUS_RO": redisKeyFirst,
+	"GEOSEARCH": redisKeyFirst,
+	// streams
+	"XACK": redisKeyFirst, "XADD": redisKeyFirst, "XAUTOCLAIM": redisKeyFirst, "XCLAIM": redisKeyFirst, "XDEL": redisKeyFirst,
+	"XLEN": redisKeyFirst, "XPENDING": redisKeyFirst, "XRANGE": redisKeyFirst, "XREVRANGE": redisKeyFirst, "XSETID": redisKeyFirst,
+	"XTRIM": redisKeyFirst,
+	// RedisBloom
+	"BF.ADD": redisKeyFirst, "BF.EXISTS": redisKeyFirst, "BF.INFO": redisKeyFirst, "BF.INSERT": redisKeyFirst,
+	"BF.MADD": redisKeyFirst, "BF.MEXISTS": redisKeyFirst, "BF.RESERVE": redisKeyFirst,
+
+	"DEL": redisKeyAll, "EXISTS": redisKeyAll, "MGET": redisKeyAll, "PFCOUNT": redisKeyAll, "PFMERGE": redisKeyAll,
+	"RENAME": redisKeyAll, "RENAMENX": redisKeyAll, "SDIFF": redisKeyAll, "SDIFFSTORE": redisKeyAll, "SINTER": redisKeyAll,
+	"SINTERSTORE": redisKeyAll, "SUNION": redisKeyAll, "SUNIONSTORE": redisKeyAll, "TOUCH": redisKeyAll, "UNLINK": redisKeyAll,
+	"WATCH": redisKeyAll,
+
+	"BLMOVE": redisKeyFirstTwo, "BRPOPLPUSH": redisKeyFirstTwo, "COPY": redisKeyFirstTwo, "GEOSEARCHSTORE": redisKeyFirstTwo,
+	"LCS": redisKeyFirstTwo, "LMOVE": redisKeyFirstTwo, "RPOPLPUSH": redisKeyFirstTwo, "SMOVE": redisKeyFirstTwo,
+	"ZRANGESTORE": redisKeyFirstTwo,
+
+	"BLPOP": redisKeyAllButLast, "BRPOP": redisKeyAllButLast, "BZPOPMAX": redisKeyAllButLast, "BZPOPMIN": redisKeyAllButLast,
+
+	"BITOP": redisKeyAllButFirst,
+
+	"MSET": redisKeyOdd, "MSETNX": redisKeyOdd,
+
+	"MEMORY": redisKeySecond, "OBJECT": redisKeySecond, "XGROUP": redisKeySecond, "XINFO": redisKeySecond,
+
+	"EVAL": redisKeyNumkeys, "EVALSHA": redisKeyNumkeys, "EVAL_RO": redisKeyNumkeys, "EVALSHA_RO": redisKeyNumkeys,
+	"FCALL": redisKeyNumkeys, "FCALL_RO": redisKeyNumkeys, "BLMPOP": redisKeyNumkeys, "BZMPOP": redisKeyNumkeys,
+
+	"LMPOP": redisKeyLeadingNumkeys, "SINTERCARD": redisKeyLeadingNumkeys, "ZDIFF": redisKeyLeadingNumkeys,
+	"ZINTER": redisKeyLeadingNumkeys, "ZINTERCARD": redisKeyLeadingNumkeys, "ZMPOP": redisKeyLeadingNumkeys,
+	"ZUNION": redisKeyLeadingNumkeys,
+
+	"ZDIFFSTORE": redisKeyDestNumkeys, "ZINTERSTORE": redisKeyDestNumkeys, "ZUNIONSTORE": redisKeyDestNumkeys,
+
+	"XREAD": redisKeyStreams, "XREADGROUP": redisKeyStreams,
+
+	"SCAN": redisKeyScan,
+}
+
+// prefixArgs returns the args with the keys prefixed, the args is not modified.
+func prefixArgs(prefix, cmd string, args []any) []any {
+	if len(prefix) == 0 || len(args) == 0 {
+		return args
+	}
+
+	spec, ok := redisKeySpecs[strings.ToUpper(cmd)]
+
+	if !ok || spec == redisKeyNone {
+		return args
+	}
+
+	ret := make([]any, len(args))
+	copy(ret, args)
+
+	key := func(i int) {
+		if i < len(ret) {
+			ret[i] = prefixKey(prefix, ret[i])
+		}
+	}
+
+	switch spec {
+	case redisKeyFirst:
+		key(0)
+	case redisKeyAll:
+		for i := range ret {
+			key(i)
+		}
+	case redisKeyFirstTwo:
+		key(0)
+		key(1)
+	case redisKeyAllButLast:
+		for i := 0; i < len(ret)-1; i++ {
+			key(i)
+		}
+	case redisKeyAllButFirst:
+		for i := 1; i < len(ret); i++ {
+			key(i)
+		}
+	case redisKeyOdd:
+		for i := 0; i < len(ret); i += 2 {
+			key(i)
+		}
+	case redisKeySecond:
+		// eg: XGROUP HELP, MEMORY STATS
+		if len(ret) > 1 {
+			key(1)
+		}
+	case redisKeyNumkeys:
+		prefixNumkeys(ret, 1, key)
+	case redisKeyLeadingNumkeys:
+		prefixNumkeys(ret, 0, key)
+	case redisKeyDestNumkeys:
+		key(0)
+		prefixNumkeys(ret, 1, key)
+	case redisKeyStreams:
+		for i, v := range ret {
+			if s, ok := v.(string); ok && strings.EqualFold(s, "STREAMS") {
+				n := (len(ret) - i - 1) / 2
+
+				for j := i + 1; j <= i+n; j++ {
+					key(j)
+				}
+
+				break
+			}
+		}
+	case redisKeyScan:
+		return prefixScan(prefix, ret)
+	}
+
+	return ret
+}
+
+// prefixScan prefixes the pattern of SCAN, or matches the keys with the prefix if the pattern is not specified.
+func prefixScan(prefix string, args []any) []any {
+	for i := 1; i < len(args)-1; i++ {
+		if s, ok := args[i].(string); ok && strings.EqualFold(s, "MATCH") {
+			args[i+1] = prefixKey(prefix, args[i+1])
+
+			return args
+		}
+	}
+
+	return append(args, "MATCH", prefix+"*")
+}
+
+// prefixNumkeys prefixes the numkeys keys after the numkeys at i.
+func prefixNumkeys(args []any, i int, key func(i int)) {
+	if i >= len(args) {
+		return
+	}
+
+	n, err := strconv.Atoi(fmt.Sprint(args[i]))
+
+	if err != nil {
+		return
+	}
+
+	for j := i + 1; j <= i+n; j++ {
+		key(j)
+	}
+}
+
+func prefixKey(prefix string, key any) any {
+	switch v := key.(type) {
+	case string:
+		return prefix + v
+	case []byte:
+		return append([]byte(prefix), v...)
+	default:
+		return prefix + fmt.Sprint(v)
+	}
+}
+
+// prefixConn prefixes the keys of the commands, see `RedisOptions.KeyPrefix`.
+type prefixConn struct {
+	redis.Conn
+
+	prefix string
+}
+
+func (c *prefixConn) Do(cmd string, args ...any) (any, error) {
+	return c.Conn.Do(cmd, prefixArgs(c.prefix, cmd, args)...)
+}
+
+func (c *prefixConn) Send(cmd string, args ...any) error {
+	return c.Conn.Send(cmd, prefixArgs(c.prefix, cmd, args)...)
+}
+
+func (c *prefixConn) DoWithTimeout(timeout time.Duration, cmd string, args ...any) (any, error) {
+	return redis.DoWithTimeout(c.Conn, timeout, cmd, prefixArgs(c.prefix, cmd, args)...)
+}
+
+func (c *prefixConn) ReceiveWithTimeout(timeout time.Duration) (any, error) {
+	return redis.ReceiveWithTimeout(c.Conn, timeout)
+}
+
+// withKeyPrefix wraps the conn to prefix the keys if the prefix is specified.
+func withKeyPrefix(conn redis.Conn, prefix string) redis.Conn {
+	if len(prefix) == 0 {
+		return conn
+	}
+
+	return &prefixConn{Conn: conn, prefix: prefix}
+}
+
+// redisKeyPrefix returns the key prefix of the pool (see `RedisOptions.KeyPrefix`).
+func redisKeyPrefix(pool RedisPool) string {
+	if p, ok := pool.(interface{ keyPrefix() string }); ok {
+		return p.keyPrefix()
+	}
+
+	return ""
+}
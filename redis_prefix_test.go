@@ -0,0 +1,87 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixArgs(t *testing.T) {
+	cases := []struct {
+		cmd    string
+		args   []any
+		expect []any
+	}{
+		{"GET", []any{"k"}, []any{"app:k"}},
+		{"set", []any{"k", "v", "PX", 100}, []any{"app:k", "v", "PX", 100}},
+		{"PING", nil, nil},
+		{"PUBLISH", []any{"ch", "msg"}, []any{"ch", "msg"}},
+		{"DEL", []any{"a", []byte("b")}, []any{"app:a", []byte("app:b")}},
+		{"MSET", []any{"a", 1, "b", 2}, []any{"app:a", 1, "app:b", 2}},
+		{"BLPOP", []any{"a", "b", 5}, []any{"app:a", "app:b", 5}},
+		{"SMOVE", []any{"a", "b", "m"}, []any{"app:a", "app:b", "m"}},
+		{"EVALSHA", []any{"sha", 2, "a", "b", "arg"}, []any{"sha", 2, "app:a", "app:b", "arg"}},
+		{"ZUNIONSTORE", []any{"d", "2", "a", "b", "WEIGHTS", 1, 2}, []any{"app:d", "2", "app:a", "app:b", "WEIGHTS", 1, 2}},
+		{"XGROUP", []any{"CREATE", "s", "g", "$"}, []any{"CREATE", "app:s", "g", "$"}},
+		{"XREADGROUP", []any{"GROUP", "g", "c", "COUNT", 10, "STREAMS", "s1", "s2", ">", ">"}, []any{"GROUP", "g", "c", "COUNT", 10, "STREAMS", "app:s1", "app:s2", ">", ">"}},
+		{"BITOP", []any{"AND", "d", "a", "b"}, []any{"AND", "app:d", "app:a", "app:b"}},
+		{"ZUNION", []any{2, "a", "b", "WITHSCORES"}, []any{2, "app:a", "app:b", "WITHSCORES"}},
+		{"ZDIFF", []any{2, "a", "b"}, []any{2, "app:a", "app:b"}},
+		{"SINTERCARD", []any{2, "a", "b", "LIMIT", 5}, []any{2, "app:a", "app:b", "LIMIT", 5}},
+		{"LMPOP", []any{2, "a", "b", "LEFT"}, []any{2, "app:a", "app:b", "LEFT"}},
+		{"ZMPOP", []any{1, "a", "MIN"}, []any{1, "app:a", "MIN"}},
+		{"BZMPOP", []any{5, 2, "a", "b", "MAX"}, []any{5, 2, "app:a", "app:b", "MAX"}},
+		{"SCAN", []any{0, "MATCH", "user:*", "COUNT", 100}, []any{0, "MATCH", "app:user:*", "COUNT", 100}},
+		{"SCAN", []any{0, "COUNT", 100}, []any{0, "COUNT", 100, "MATCH", "app:*"}},
+		{"UNKNOWN.CMD", []any{"k"}, []any{"k"}},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expect, prefixArgs("app:", c.cmd, c.args), c.cmd)
+	}
+
+	args := []any{"k"}
+
+	prefixArgs("app:", "GET", args)
+	assert.Equal(t, []any{"k"}, args)
+}
+
+func TestRedisKeyPrefix(t *testing.T) {
+	ctx := context.TODO()
+
+	s := miniredis.RunT(t)
+
+	pool := newRedisPool(&RedisConfig{
+		Addr:    s.Addr(),
+		Options: &RedisOptions{KeyPrefix: "svc:test:"},
+	})
+
+	_, err := pool.Do(ctx, "SET", "k", "v")
+	assert.Nil(t, err)
+	assert.True(t, s.Exists("svc:test:k"))
+
+	v, err := redis.String(pool.Do(ctx, "GET", "k"))
+	assert.Nil(t, err)
+	assert.Equal(t, "v", v)
+
+	// lua script
+	m := DistributedMutex("lock", "a")
+	m.(*distributed).pool = pool
+
+	assert.Nil(t, m.Lock(ctx, 10*time.Millisecond, time.Second))
+	assert.True(t, s.Exists("svc:test:lock"))
+	assert.True(t, s.Exists("svc:test:{svc:test:lock}:hold"))
+	assert.True(t, s.Exists("svc:test:{svc:test:lock}:fencing"))
+	assert.Nil(t, m.UnLock(ctx))
+
+	// the cache invalidation channel
+	NewRedisCache(pool, WithLocalCache(100, time.Minute))
+
+	assert.Eventually(t, func() bool {
+		return s.PubSubNumSub("svc:test:" + cacheInvalidateChannel)["svc:test:"+cacheInvalidateChannel] == 1
+	}, time.Second, 10*time.Millisecond)
+}
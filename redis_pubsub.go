@@ -0,0 +1,218 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"go.uber.org/zap"
+)
+
+// PubSubMessage the message received by `Subscribe`.
+type PubSubMessage struct {
+	Channel string
+	Pattern string // the matched pattern (see `WithSubscribePattern`)
+	Data    []byte
+}
+
+// PubSubHandler handles the message received by `Subscribe`.
+type PubSubHandler func(ctx context.Context, msg *PubSubMessage)
+
+// SubscribeOption subscribe option
+type SubscribeOption func(o *subscribeOptions)
+
+type subscribeOptions struct {
+	pool         RedisPool
+	pattern      bool
+	pingInterval time.Duration
+	onReset      func() // called when (re)subscribed or broken, the messages may be missed
+}
+
+// WithSubscribeRedis specifies redis pool for the subscription.
+func WithSubscribeRedis(name string) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.pool = Redis(name)
+	}
+}
+
+// WithSubscribePattern subscribes the channels as the patterns (PSUBSCRIBE), eg: news.*
+func WithSubscribePattern() SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.pattern = true
+	}
+}
+
+// WithSubscribePing specifies the interval of the PING to check the connection, default: 30s.
+// The connection is considered broken if no reply is received within 2 intervals.
+func WithSubscribePing(d time.Duration) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.pingInterval = d
+	}
+}
+
+// Subscribe subscribes the channels and handles the messages (in order) until ctx is done, the connection is dedicated
+// (not from the pool) and resubscribed with backoff after the network errors.
+// NOTE: The messages published during reconnecting are lost (at most once), use `StreamConsumer` for the reliable delivery.
+func Subscribe(ctx context.Context, channels []string, handler PubSubHandler, options ...SubscribeOption) error {
+	o := &subscribeOptions{
+		pool:         defaultRedis,
+		pingInterval: 30 * time.Second,
+	}
+
+	for _, f := range options {
+		f(o)
+	}
+
+	if o.pool == nil {
+		return fmt.Errorf("unknown redis.%s (forgotten configure?)", Default)
+	}
+
+	backoff := time.Second
+
+	for {
+		subscribed, err := receivePubSub(ctx, channels, handler, o)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if o.onReset != nil {
+			o.onReset()
+		}
+
+		logger.Error("err redis subscribe", zap.Strings("channels", channels), zap.Error(err))
+
+		// reset after the successful subscription
+		if subscribed {
+			backoff = time.Second
+		}
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return nil
+		case <-timer.C:
+		}
+
+		if backoff *= 2; backoff > 30*time.Second {
+			backoff = 30 * time.Second
+		}
+	}
+}
+
+// receivePubSub subscribes and receives the messages until the connection is broken or ctx is done.
+func receivePubSub(ctx context.Context, channels []string, handler PubSubHandler, o *subscribeOptions) (subscribed bool, err error) {
+	conn, release, err := subscribeConn(o.pool)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer release()
+
+	psc := redis.PubSubConn{Conn: conn}
+
+	args := redis.Args{}.AddFlat(channels)
+
+	if o.pattern {
+		err = psc.PSubscribe(args...)
+	} else {
+		err = psc.Subscribe(args...)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	// ping periodically, and unblock the receive once ctx is done
+	go func() {
+		ticker := time.NewTicker(o.pingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				conn.Close()
+
+				return
+			case <-ticker.C:
+				psc.Ping("")
+			}
+		}
+	}()
+
+	_, timeout := conn.(redis.ConnWithTimeout)
+
+	for {
+		var reply any
+
+		if timeout {
+			reply = psc.ReceiveWithTimeout(2 * o.pingInterval)
+		} else {
+			reply = psc.Receive()
+		}
+
+		switch v := reply.(type) {
+		case redis.Message:
+			callPubSubHandler(ctx, handler, &PubSubMessage{
+				Channel: v.Channel,
+				Pattern: v.Pattern,
+				Data:    v.Data,
+			})
+		case redis.Subscription:
+			if v.Count == len(channels) && (v.Kind == "subscribe" || v.Kind == "psubscribe") {
+				subscribed = true
+
+				if o.onReset != nil {
+					o.onReset()
+				}
+			}
+		case error:
+			return subscribed, v
+		}
+	}
+}
+
+func callPubSubHandler(ctx context.Context, handler PubSubHandler, msg *PubSubMessage) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("redis subscribe handler panic", zap.String("channel", msg.Channel), zap.Any("error", r), zap.ByteString("stack", debug.Stack()))
+		}
+	}()
+
+	handler(ctx, msg)
+}
+
+// subscribeConn returns the dedicated connection of the subscription, which is not returned to the pool.
+func subscribeConn(pool RedisPool) (redis.Conn, func(), error) {
+	if d, ok := pool.(interface{ dial() (redis.Conn, error) }); ok {
+		conn, err := d.dial()
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return conn, func() { conn.Close() }, nil
+	}
+
+	rc, err := pool.Get(context.Background())
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rc.Conn, func() {
+		rc.Close()
+		pool.Put(rc)
+	}, nil
+}
@@ -0,0 +1,56 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	s := miniredis.RunT(t)
+	redisMap.Store("pubsub", newRedisPool(&RedisConfig{Addr: s.Addr()}))
+
+	defer redisMap.Delete("pubsub")
+
+	msgs := make(chan *PubSubMessage, 10)
+	done := make(chan error)
+
+	go func() {
+		done <- Subscribe(ctx, []string{"news.*"}, func(ctx context.Context, msg *PubSubMessage) {
+			msgs <- msg
+		}, WithSubscribeRedis("pubsub"), WithSubscribePattern(), WithSubscribePing(100*time.Millisecond))
+	}()
+
+	subscribed := func() bool {
+		return s.PubSubNumPat() == 1
+	}
+
+	assert.Eventually(t, subscribed, time.Second, 10*time.Millisecond)
+
+	s.Publish("news.tech", "hello")
+
+	msg := <-msgs
+
+	assert.Equal(t, &PubSubMessage{Channel: "news.tech", Pattern: "news.*", Data: []byte("hello")}, msg)
+
+	// resubscribed after the network error
+	s.Close()
+	assert.Nil(t, s.Restart())
+
+	assert.Eventually(t, subscribed, 3*time.Second, 10*time.Millisecond)
+
+	s.Publish("news.sport", "world")
+
+	msg = <-msgs
+
+	assert.Equal(t, "world", string(msg.Data))
+
+	cancel()
+
+	assert.Nil(t, <-done)
+}
@@ -0,0 +1,110 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// ScriptRegistry is the registry of the lua scripts, which are called by EVALSHA (the sha1 is cached),
+// and fall back to EVAL (the script is cached by the server again) on NOSCRIPT, eg: the server is restarted.
+type ScriptRegistry struct {
+	pool    RedisPool
+	scripts map[string]*redis.Script
+	mutex   sync.RWMutex
+}
+
+// NewScriptRegistry returns a registry of the lua scripts on the redis pool, eg: yiigo.NewScriptRegistry(yiigo.Redis()).
+func NewScriptRegistry(pool RedisPool) *ScriptRegistry {
+	return &ScriptRegistry{
+		pool:    pool,
+		scripts: make(map[string]*redis.Script),
+	}
+}
+
+// Register registers the script with the number of keys, the one of the same name is replaced.
+// If keyCount < 0, the number of keys is passed as the first argument of `Do`.
+func (r *ScriptRegistry) Register(name string, keyCount int, src string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.scripts[name] = redis.NewScript(keyCount, src)
+}
+
+// RegisterFS registers the scripts (*.lua) in the dir of fsys (eg: embed.FS) by the file name without the extension,
+// eg: scripts/deduct_stock.lua => deduct_stock. The number of keys is passed as the first argument of `Do`.
+func (r *ScriptRegistry) RegisterFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+
+	if err != nil {
+		return err
+	}
+
+	for _, v := range entries {
+		if v.IsDir() || path.Ext(v.Name()) != ".lua" {
+			continue
+		}
+
+		b, err := fs.ReadFile(fsys, path.Join(dir, v.Name()))
+
+		if err != nil {
+			return err
+		}
+
+		r.Register(strings.TrimSuffix(v.Name(), ".lua"), -1, string(b))
+	}
+
+	return nil
+}
+
+// Load loads (SCRIPT LOAD) all the scripts to the server, eg: at startup.
+// NOTE: In cluster mode, the scripts are loaded to one node, the others are cached on the first call (EVAL).
+func (r *ScriptRegistry) Load(ctx context.Context) error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	names := make([]string, 0, len(r.scripts))
+
+	for k := range r.scripts {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+
+	return r.pool.DoFunc(ctx, func(ctx context.Context, conn *RedisConn) error {
+		for _, name := range names {
+			if err := r.scripts[name].Load(conn.Conn); err != nil {
+				return fmt.Errorf("script(%s) load: %w", name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Do evaluates the script of the name with the keys and args.
+func (r *ScriptRegistry) Do(ctx context.Context, name string, keysAndArgs ...any) (any, error) {
+	r.mutex.RLock()
+	script, ok := r.scripts[name]
+	r.mutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown script: %s", name)
+	}
+
+	conn, err := r.pool.Get(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.pool.Put(conn)
+
+	return script.Do(conn.Conn, keysAndArgs...)
+}
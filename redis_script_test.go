@@ -0,0 +1,55 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScriptRegistry(t *testing.T) {
+	ctx := context.TODO()
+
+	s := miniredis.RunT(t)
+	pool := newRedisPool(&RedisConfig{Addr: s.Addr()})
+
+	registry := NewScriptRegistry(pool)
+
+	registry.Register("incr_by", 1, `return redis.call('INCRBY', KEYS[1], ARGV[1])`)
+
+	assert.Nil(t, registry.RegisterFS(fstest.MapFS{
+		"scripts/deduct_stock.lua": {Data: []byte(`
+local stock = tonumber(redis.call('GET', KEYS[1]) or '0')
+if stock < tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('DECRBY', KEYS[1], ARGV[1])
+return 1`)},
+		"scripts/README.md": {Data: []byte("scripts")},
+	}, "scripts"))
+
+	assert.Nil(t, registry.Load(ctx))
+
+	n, err := redis.Int(registry.Do(ctx, "incr_by", "stock", 3))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+
+	// the number of keys is the first argument
+	ok, err := redis.Bool(registry.Do(ctx, "deduct_stock", 1, "stock", 2))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// NOSCRIPT => EVAL
+	_, err = pool.Do(ctx, "SCRIPT", "FLUSH")
+	assert.Nil(t, err)
+
+	ok, err = redis.Bool(registry.Do(ctx, "deduct_stock", 1, "stock", 2))
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, err = registry.Do(ctx, "unknown")
+	assert.NotNil(t, err)
+}
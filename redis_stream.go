@@ -0,0 +1,415 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"go.uber.org/zap"
+)
+
+// StreamMessage the message of the redis stream.
+type StreamMessage struct {
+	Stream     string
+	ID         string
+	Values     map[string]string
+	Deliveries int64 // the times of delivery, 1 for the first
+}
+
+// StreamHandler handles the message of the stream, the message is acked (XACK) if returns nil,
+// otherwise it's redelivered after the claim idle (see `WithStreamClaim`) until the max deliveries.
+type StreamHandler func(ctx context.Context, msg *StreamMessage) error
+
+// StreamAdd appends the message to the stream (XADD), and returns the id of the message.
+func StreamAdd(ctx context.Context, pool RedisPool, stream string, values map[string]any) (string, error) {
+	args := redis.Args{stream, "*"}
+
+	for k, v := range values {
+		args = args.Add(k, v)
+	}
+
+	return redis.String(pool.Do(ctx, "XADD", args...))
+}
+
+// StreamConsumerOption stream consumer option
+type StreamConsumerOption func(c *StreamConsumer)
+
+// WithStreamRedis specifies redis pool for the consumer.
+func WithStreamRedis(name string) StreamConsumerOption {
+	return func(c *StreamConsumer) {
+		c.pool = Redis(name)
+	}
+}
+
+// WithStreamBatch specifies the max messages of each read, default: 10.
+func WithStreamBatch(n int) StreamConsumerOption {
+	return func(c *StreamConsumer) {
+		c.batch = n
+	}
+}
+
+// WithStreamBlock specifies the block timeout of each read, default: 5s.
+// NOTE: It should be less than the ReadTimeout of the redis.
+func WithStreamBlock(d time.Duration) StreamConsumerOption {
+	return func(c *StreamConsumer) {
+		c.block = d
+	}
+}
+
+// WithStreamClaim specifies the min idle of the pending messages to be claimed (the consumer is dead or the handler failed),
+// and the interval of claiming, default: 1m and 30s.
+func WithStreamClaim(minIdle, interval time.Duration) StreamConsumerOption {
+	return func(c *StreamConsumer) {
+		c.minIdle = minIdle
+		c.claimInterval = interval
+	}
+}
+
+// WithStreamMaxDeliveries specifies the max deliveries of the message, the message which fails (or exceeds) it
+// is moved to the dead-letter stream, default: 5.
+func WithStreamMaxDeliveries(n int64) StreamConsumerOption {
+	return func(c *StreamConsumer) {
+		c.maxDeliveries = n
+	}
+}
+
+// WithStreamDeadLetter specifies the dead-letter stream, default: {stream}:dead.
+// The dead message keeps the values, with the additional values: _stream, _id, _deliveries and _error.
+func WithStreamDeadLetter(stream string) StreamConsumerOption {
+	return func(c *StreamConsumer) {
+		c.deadLetter = stream
+	}
+}
+
+// StreamConsumer is the consumer of the redis stream in the consumer group.
+type StreamConsumer struct {
+	pool          RedisPool
+	stream        string
+	group         string
+	consumer      string
+	handler       StreamHandler
+	batch         int
+	block         time.Duration
+	minIdle       time.Duration
+	claimInterval time.Duration
+	maxDeliveries int64
+	deadLetter    string
+}
+
+// NewStreamConsumer returns a consumer of the stream in the group, the consumer name should be unique in the group (eg: hostname).
+func NewStreamConsumer(stream, group, consumer string, handler StreamHandler, options ...StreamConsumerOption) *StreamConsumer {
+	c := &StreamConsumer{
+		pool:          defaultRedis,
+		stream:        stream,
+		group:         group,
+		consumer:      consumer,
+		handler:       handler,
+		batch:         10,
+		block:         5 * time.Second,
+		minIdle:       time.Minute,
+		claimInterval: 30 * time.Second,
+		maxDeliveries: 5,
+		deadLetter:    stream + ":dead",
+	}
+
+	for _, f := range options {
+		f(c)
+	}
+
+	return c
+}
+
+// Run creates the group (if not exists, from the new messages) and consumes the messages until ctx is done,
+// the pending messages idle for long (the consumer is dead or the handler failed) are claimed periodically.
+func (c *StreamConsumer) Run(ctx context.Context) error {
+	if err := c.createGroup(ctx); err != nil {
+		return err
+	}
+
+	go c.claimLoop(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		msgs, err := c.read(ctx)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			logger.Error(fmt.Sprintf("err stream(%s) read", c.stream), zap.String("group", c.group), zap.Error(err))
+
+			time.Sleep(time.Second)
+
+			continue
+		}
+
+		for _, msg := range msgs {
+			c.handle(ctx, msg)
+		}
+	}
+}
+
+func (c *StreamConsumer) createGroup(ctx context.Context) error {
+	_, err := c.pool.Do(ctx, "XGROUP", "CREATE", c.stream, c.group, "$", "MKSTREAM")
+
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	return nil
+}
+
+func (c *StreamConsumer) read(ctx context.Context) ([]*StreamMessage, error) {
+	reply, err := redis.Values(c.pool.Do(ctx, "XREADGROUP", "GROUP", c.group, c.consumer, "COUNT", c.batch, "BLOCK", c.block.Milliseconds(), "STREAMS", c.stream, ">"))
+
+	if err != nil {
+		if err == redis.ErrNil {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	msgs := make([]*StreamMessage, 0)
+
+	for _, v := range reply {
+		// [stream, [[id, [field, value, ...]], ...]]
+		stream, err := redis.Values(v, nil)
+
+		if err != nil || len(stream) != 2 {
+			return nil, fmt.Errorf("unexpected reply of XREADGROUP: %v", v)
+		}
+
+		entries, err := streamEntries(c.stream, stream[1], 1)
+
+		if err != nil {
+			return nil, err
+		}
+
+		msgs = append(msgs, entries...)
+	}
+
+	return msgs, nil
+}
+
+func (c *StreamConsumer) claimLoop(ctx context.Context) {
+	ticker := time.NewTicker(c.claimInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := c.claim(ctx); err != nil && ctx.Err() == nil {
+			logger.Error(fmt.Sprintf("err stream(%s) claim", c.stream), zap.String("group", c.group), zap.Error(err))
+		}
+	}
+}
+
+// claim claims the pending messages idle for long, and handles them.
+func (c *StreamConsumer) claim(ctx context.Context) error {
+	start := "-"
+
+	for {
+		// [[id, consumer, idle (ms), deliveries], ...]
+		pending, err := redis.Values(c.pool.Do(ctx, "XPENDING", c.stream, c.group, start, "+", 100))
+
+		if err != nil {
+			if err == redis.ErrNil {
+				return nil
+			}
+
+			return err
+		}
+
+		for _, v := range pending {
+			entry, err := redis.Values(v, nil)
+
+			if err != nil || len(entry) != 4 {
+				return fmt.Errorf("unexpected reply of XPENDING: %v", v)
+			}
+
+			id, _ := redis.String(entry[0], nil)
+			idle, _ := redis.Int64(entry[2], nil)
+			deliveries, _ := redis.Int64(entry[3], nil)
+
+			if start, err = nextStreamID(id); err != nil {
+				return err
+			}
+
+			if idle < c.minIdle.Milliseconds() {
+				continue
+			}
+
+			if err = c.claimOne(ctx, id, deliveries+1); err != nil {
+				return err
+			}
+		}
+
+		if len(pending) < 100 || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func (c *StreamConsumer) claimOne(ctx context.Context, id string, deliveries int64) error {
+	reply, err := c.pool.Do(ctx, "XCLAIM", c.stream, c.group, c.consumer, c.minIdle.Milliseconds(), id)
+
+	if err != nil {
+		return err
+	}
+
+	// empty if claimed by another consumer, or deleted
+	msgs, err := streamEntries(c.stream, reply, deliveries)
+
+	if err != nil {
+		return err
+	}
+
+	for _, msg := range msgs {
+		// exceeded, eg: the consumer crashed while handling
+		if msg.Deliveries > c.maxDeliveries {
+			c.dead(ctx, msg, "exceeds the max deliveries")
+
+			continue
+		}
+
+		c.handle(ctx, msg)
+	}
+
+	return nil
+}
+
+func (c *StreamConsumer) handle(ctx context.Context, msg *StreamMessage) {
+	err := c.call(ctx, msg)
+
+	if err == nil {
+		if _, err = c.pool.Do(ctx, "XACK", c.stream, c.group, msg.ID); err != nil {
+			logger.Error(fmt.Sprintf("err stream(%s) ack", c.stream), zap.String("id", msg.ID), zap.Error(err))
+		}
+
+		return
+	}
+
+	logger.Warn(fmt.Sprintf("err stream(%s) handle", c.stream), zap.String("id", msg.ID), zap.Int64("deliveries", msg.Deliveries), zap.Error(err))
+
+	if msg.Deliveries >= c.maxDeliveries {
+		c.dead(ctx, msg, err.Error())
+	}
+}
+
+func (c *StreamConsumer) call(ctx context.Context, msg *StreamMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+
+			logger.Error(fmt.Sprintf("stream(%s) handler panic", c.stream), zap.Any("error", r), zap.ByteString("stack", debug.Stack()))
+		}
+	}()
+
+	return c.handler(ctx, msg)
+}
+
+// dead moves the message to the dead-letter stream.
+func (c *StreamConsumer) dead(ctx context.Context, msg *StreamMessage, reason string) {
+	values := make(map[string]any, len(msg.Values)+4)
+
+	for k, v := range msg.Values {
+		values[k] = v
+	}
+
+	values["_stream"] = msg.Stream
+	values["_id"] = msg.ID
+	values["_deliveries"] = msg.Deliveries
+	values["_error"] = reason
+
+	if _, err := StreamAdd(ctx, c.pool, c.deadLetter, values); err != nil {
+		logger.Error(fmt.Sprintf("err stream(%s) dead letter", c.stream), zap.String("id", msg.ID), zap.Error(err))
+
+		return
+	}
+
+	if _, err := c.pool.Do(ctx, "XACK", c.stream, c.group, msg.ID); err != nil {
+		logger.Error(fmt.Sprintf("err stream(%s) ack", c.stream), zap.String("id", msg.ID), zap.Error(err))
+	}
+
+	logger.Warn(fmt.Sprintf("stream(%s) message is dead", c.stream), zap.String("id", msg.ID), zap.String("dead_letter", c.deadLetter))
+}
+
+// streamEntries parses the entries: [[id, [field, value, ...]], ...], the deleted entries (nil values) are skipped.
+func streamEntries(stream string, reply any, deliveries int64) ([]*StreamMessage, error) {
+	entries, err := redis.Values(reply, nil)
+
+	if err != nil {
+		if err == redis.ErrNil {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	msgs := make([]*StreamMessage, 0, len(entries))
+
+	for _, v := range entries {
+		entry, err := redis.Values(v, nil)
+
+		if err != nil || len(entry) != 2 {
+			return nil, fmt.Errorf("unexpected stream entry: %v", v)
+		}
+
+		id, err := redis.String(entry[0], nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if entry[1] == nil {
+			continue
+		}
+
+		values, err := redis.StringMap(entry[1], nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		msgs = append(msgs, &StreamMessage{
+			Stream:     stream,
+			ID:         id,
+			Values:     values,
+			Deliveries: deliveries,
+		})
+	}
+
+	return msgs, nil
+}
+
+// nextStreamID returns the smallest id after id (ms-seq), eg: the start of the next page.
+func nextStreamID(id string) (string, error) {
+	i := strings.IndexByte(id, '-')
+
+	if i < 0 {
+		return "", fmt.Errorf("invalid stream id: %s", id)
+	}
+
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+
+	if err != nil {
+		return "", fmt.Errorf("invalid stream id: %s", id)
+	}
+
+	return id[:i+1] + strconv.FormatUint(seq+1, 10), nil
+}
@@ -0,0 +1,89 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamConsumer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	s := miniredis.RunT(t)
+	pool := newRedisPool(&RedisConfig{Addr: s.Addr()})
+
+	var (
+		handled    []string
+		deliveries = make(map[string]int64)
+		mutex      sync.Mutex
+	)
+
+	handler := func(ctx context.Context, msg *StreamMessage) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		deliveries[msg.Values["name"]] = msg.Deliveries
+
+		if msg.Values["name"] == "bad" {
+			return errors.New("oops")
+		}
+
+		handled = append(handled, msg.Values["name"])
+
+		return nil
+	}
+
+	consumer := NewStreamConsumer("events", "group", "c1", handler,
+		WithStreamBlock(20*time.Millisecond),
+		WithStreamClaim(30*time.Millisecond, 10*time.Millisecond),
+		WithStreamMaxDeliveries(2),
+	)
+	consumer.pool = pool
+
+	assert.Nil(t, consumer.createGroup(ctx))
+
+	go consumer.Run(ctx)
+
+	_, err := StreamAdd(ctx, pool, "events", map[string]any{"name": "good"})
+	assert.Nil(t, err)
+
+	_, err = StreamAdd(ctx, pool, "events", map[string]any{"name": "bad"})
+	assert.Nil(t, err)
+
+	// retried once by claiming, then moved to the dead-letter stream
+	assert.Eventually(t, func() bool {
+		entries, _ := s.Stream("events:dead")
+
+		return len(entries) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	entries, _ := s.Stream("events:dead")
+
+	assert.Subset(t, entries[0].Values, []string{"name", "bad", "_stream", "events", "_error", "oops"})
+
+	mutex.Lock()
+	assert.Equal(t, []string{"good"}, handled)
+	assert.Equal(t, int64(2), deliveries["bad"])
+	mutex.Unlock()
+
+	// all acked
+	pending, err := pool.Do(ctx, "XPENDING", "events", "group")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), pending.([]any)[0])
+}
+
+func TestNextStreamID(t *testing.T) {
+	id, err := nextStreamID("1526569495631-0")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "1526569495631-1", id)
+
+	_, err = nextStreamID("abc")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,191 @@
+package yiigo
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var strPtrType = reflect.TypeOf((*string)(nil))
+
+// PGArray returns a driver.Valuer which binds the Go slice as Postgres array,
+// eg: yiigo.Where("tags && ?", yiigo.PGArray([]string{"a", "b"})).
+// The slice is encoded to the array literal (eg: {a,b}) by pgtype, so it would not be expanded by `WhereIn`.
+// The nested slices are encoded to the multidimensional array, and the `[]byte` element is encoded as bytea.
+func PGArray(v any) driver.Valuer {
+	return &pgArray{v: v}
+}
+
+type pgArray struct {
+	v any
+}
+
+func (a *pgArray) Value() (driver.Value, error) {
+	rv := reflect.ValueOf(a.v)
+
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil
+		}
+
+		rv = rv.Elem()
+	}
+
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type() == bytesType {
+		return nil, fmt.Errorf("pg array: unsupported type %T, expects slice or array", a.v)
+	}
+
+	if rv.Kind() == reflect.Slice && rv.IsNil() {
+		return nil, nil
+	}
+
+	elem := pgArrayElemType(rv.Type())
+
+	oid, ok := pgArrayOID(elem)
+
+	if !ok {
+		return nil, fmt.Errorf("pg array: unsupported element type %s", elem)
+	}
+
+	v := rv.Interface()
+
+	// the infinity is encoded as +Inf by strconv, which is not the literal of Postgres
+	if elem.Kind() == reflect.Float32 || elem.Kind() == reflect.Float64 {
+		v = pgFloatText(rv).Interface()
+	}
+
+	// the new map for each encoding, since the cached plans of the shared map (not safe for concurrent use)
+	// mismatch the nested slices whose element types have been encoded, eg: []int64 and [][]int64.
+	b, err := pgtype.NewMap().Encode(oid, pgtype.TextFormatCode, v, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("pg array: %w", err)
+	}
+
+	if b == nil {
+		return nil, nil
+	}
+
+	return string(b), nil
+}
+
+// pgArrayElemType returns the element type of the (nested) slice, the `[]byte` is the element (bytea).
+func pgArrayElemType(t reflect.Type) reflect.Type {
+	for {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		if (t.Kind() != reflect.Slice && t.Kind() != reflect.Array) || t == bytesType {
+			return t
+		}
+
+		t = t.Elem()
+	}
+}
+
+func pgArrayOID(t reflect.Type) (uint32, bool) {
+	switch t {
+	case bytesType:
+		return pgtype.ByteaArrayOID, true
+	case timeType:
+		return pgtype.TimestamptzArrayOID, true
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return pgtype.TextArrayOID, true
+	case reflect.Bool:
+		return pgtype.BoolArrayOID, true
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return pgtype.Int2ArrayOID, true
+	case reflect.Int32, reflect.Uint16:
+		return pgtype.Int4ArrayOID, true
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return pgtype.Int8ArrayOID, true
+	case reflect.Float32:
+		return pgtype.Float4ArrayOID, true
+	case reflect.Float64:
+		return pgtype.Float8ArrayOID, true
+	}
+
+	return 0, false
+}
+
+// pgFloatText converts the (nested) float slice to the string slice of the same shape,
+// eg: []float64{1.5, math.Inf(1)} -> []*string{"1.5", "Infinity"}.
+func pgFloatText(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Zero(strPtrType)
+		}
+
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		f := v.Float()
+
+		var s string
+
+		switch {
+		case math.IsInf(f, 1):
+			s = "Infinity"
+		case math.IsInf(f, -1):
+			s = "-Infinity"
+		default:
+			s = strconv.FormatFloat(f, 'g', -1, v.Type().Bits())
+		}
+
+		return reflect.ValueOf(&s)
+	}
+
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		return reflect.Zero(reflect.SliceOf(pgFloatTextType(v.Type().Elem())))
+	}
+
+	ret := reflect.MakeSlice(reflect.SliceOf(pgFloatTextType(v.Type().Elem())), v.Len(), v.Len())
+
+	for i := 0; i < v.Len(); i++ {
+		ret.Index(i).Set(pgFloatText(v.Index(i)))
+	}
+
+	return ret
+}
+
+func pgFloatTextType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return strPtrType
+	}
+
+	return reflect.SliceOf(pgFloatTextType(t.Elem()))
+}
+
+// PGAny returns the clause `column = ANY(?)` which binds the values as Postgres array,
+// it can be used instead of `WhereIn` to avoid the huge IN lists.
+func PGAny(column string, values any) *SQLClause {
+	return Clause(column+" = ANY(?)", PGArray(values))
+}
+
+// PGOverlap returns the clause `column && ?` (Postgres array overlap).
+func PGOverlap(column string, values any) *SQLClause {
+	return Clause(column+" && ?", PGArray(values))
+}
+
+// PGContains returns the clause `column @> ?` (Postgres array contains).
+func PGContains(column string, values any) *SQLClause {
+	return Clause(column+" @> ?", PGArray(values))
+}
+
+// PGContainedBy returns the clause `column <@ ?` (Postgres array is contained by).
+func PGContainedBy(column string, values any) *SQLClause {
+	return Clause(column+" <@ ?", PGArray(values))
+}
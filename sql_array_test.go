@@ -0,0 +1,95 @@
+package yiigo
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPGArray(t *testing.T) {
+	v, err := PGArray([]int{1, 2, 3}).Value()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "{1,2,3}", v)
+
+	v, err = PGArray([]string{"a", `b"c`, `d\e`}).Value()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `{a,"b\"c","d\\e"}`, v)
+
+	v, err = PGArray([]*string{nil}).Value()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "{NULL}", v)
+
+	v, err = PGArray([]int(nil)).Value()
+
+	assert.Nil(t, err)
+	assert.Nil(t, v)
+
+	// the infinity and NaN
+	v, err = PGArray([]float64{1.5, math.Inf(1), math.Inf(-1), math.NaN()}).Value()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "{1.5,Infinity,-Infinity,NaN}", v)
+
+	// bytea[]
+	v, err = PGArray([][]byte{[]byte("ab"), nil}).Value()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `{"\\x6162",NULL}`, v)
+
+	// multidimensional
+	v, err = PGArray([][]int64{{1, 2}, {3, 4}}).Value()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "{{1,2},{3,4}}", v)
+
+	v, err = PGArray([][]float32{{1.5}, {float32(math.Inf(1))}}).Value()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "{{1.5},{Infinity}}", v)
+
+	v, err = PGArray([]time.Time{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}).Value()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "{2020-01-02 03:04:05Z}", v)
+
+	_, err = PGArray(1).Value()
+
+	assert.NotNil(t, err)
+
+	// the []byte is bytea, not array
+	_, err = PGArray([]byte("ab")).Value()
+
+	assert.NotNil(t, err)
+
+	_, err = PGArray([]struct{}{{}}).Value()
+
+	assert.NotNil(t, err)
+}
+
+func TestPGAny(t *testing.T) {
+	sql, args, err := NewPGSQLBuilder().Wrap(
+		Table("article"),
+		WhereIn("status IN (?)", []int{1, 2}),
+		WhereClause(PGAny("id", []int64{1, 2, 3}), PGOverlap("tags", []string{"go", "sql"})),
+	).ToQuery(context.TODO())
+
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM "article" WHERE (status IN ($1, $2)) AND (id = ANY($3)) AND (tags && $4)`, sql)
+	// the valuers are resolved by `WhereIn`, but not expanded
+	assert.Equal(t, []any{1, 2, "{1,2,3}", "{go,sql}"}, args)
+
+	sql, args, err = NewPGSQLBuilder().Wrap(
+		Table("article"),
+		WhereClause(PGContains("tags", []string{"go"})),
+	).ToQuery(context.TODO())
+
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM "article" WHERE tags @> $1`, sql)
+	assert.Equal(t, []any{PGArray([]string{"go"})}, args)
+}
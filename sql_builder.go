@@ -3,20 +3,40 @@ package yiigo
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"golang.org/x/sync/singleflight"
 )
 
 var (
 	// ErrUpsertData invalid insert or update data.
-	ErrUpsertData = errors.New("invaild data, expects struct, *struct, yiigo.X")
+	ErrUpsertData = errors.New("invaild data, expects struct, *struct, map[string]any")
 
 	// ErrBatchInsertData invalid batch insert data.
-	ErrBatchInsertData = errors.New("invaild data, expects []struct, []*struct, []yiigo.X")
+	ErrBatchInsertData = errors.New("invaild data, expects []struct, []*struct, []map[string]any")
+
+	// ErrBatchInsertColumns inconsistent columns between batch insert rows.
+	ErrBatchInsertColumns = errors.New("inconsistent columns between rows")
+
+	// ErrInvalidWrapper misconfigured wrapper, eg: missing table.
+	ErrInvalidWrapper = errors.New("invalid wrapper")
+
+	// ErrUnsafeMutation update (delete) without `where` in the safe mode.
+	ErrUnsafeMutation = errors.New("update (delete) without where")
+
+	// ErrDriverUnsupported the sql feature is not supported by the driver.
+	ErrDriverUnsupported = errors.New("unsupported by the driver")
 )
 
+func errDriverUnsupported(driver DBDriver, feature string) error {
+	return fmt.Errorf("%w: %s (%s)", ErrDriverUnsupported, feature, driver)
+}
+
 // SQLBuilder is the interface for wrapping query options.
 type SQLBuilder interface {
 	// Wrap wrapping query options
@@ -28,6 +48,9 @@ type SQLWrapper interface {
 	// ToQuery returns query statement and binds.
 	ToQuery(ctx context.Context) (sql string, args []any, err error)
 
+	// ToExplain returns the dialect-specific `EXPLAIN` (`EXPLAIN ANALYZE`) query statement and binds.
+	ToExplain(ctx context.Context, analyze bool) (sql string, args []any, err error)
+
 	// ToInsert returns insert statement and binds.
 	// data expects `struct`, `*struct`, `yiigo.X`.
 	ToInsert(ctx context.Context, data any) (sql string, args []any, err error)
@@ -41,14 +64,77 @@ type SQLWrapper interface {
 	ToUpdate(ctx context.Context, data any) (sql string, args []any, err error)
 
 	// ToDelete returns delete statement and binds.
+	// If the soft delete is enabled, it returns update statement which sets the soft delete column.
 	ToDelete(ctx context.Context) (sql string, args []any, err error)
 
-	// ToTruncate returns truncate statement
-	ToTruncate(ctx context.Context) string
+	// ToForceDelete returns delete statement and binds, the soft delete is ignored.
+	ToForceDelete(ctx context.Context) (sql string, args []any, err error)
+
+	// ToTruncate returns truncate statement.
+	ToTruncate(ctx context.Context, options ...TruncateOption) (sql string, err error)
+
+	// ToCopy returns the COPY statement with the columns and the rows of the batch insert data (Postgres only).
+	// data expects `[]struct`, `[]*struct`, `[]yiigo.X`.
+	ToCopy(ctx context.Context, data any) (sql string, columns []string, rows [][]any, err error)
+
+	// Get executes the query and scans the first row into dest, returns sql.ErrNoRows if no rows.
+	Get(ctx context.Context, db sqlx.QueryerContext, dest any) error
+
+	// Select executes the query and scans the rows into dest (slice).
+	Select(ctx context.Context, db sqlx.QueryerContext, dest any) error
+
+	// Explain executes the `EXPLAIN` (`EXPLAIN ANALYZE`) statement and returns the plan rows.
+	Explain(ctx context.Context, db sqlx.QueryerContext, analyze bool) ([]X, error)
+
+	// Iterate executes the query and calls fn for each row without loading all rows into memory,
+	// the iteration stops if fn returns an error, and the error is returned.
+	Iterate(ctx context.Context, db sqlx.QueryerContext, fn func(rows *sqlx.Rows) error) error
+
+	// Insert executes the insert statement and returns the last insert id.
+	// Postgres uses `RETURNING` id, SQL Server uses `SCOPE_IDENTITY()`, others use LastInsertId.
+	Insert(ctx context.Context, db sqlx.ExtContext, data any) (int64, error)
+
+	// BatchInsert executes the batch insert statement and returns the rows affected.
+	BatchInsert(ctx context.Context, db sqlx.ExtContext, data any) (int64, error)
+
+	// BulkLoad inserts the large number of rows by COPY (Postgres) or the chunked batch insert, and returns the rows affected.
+	BulkLoad(ctx context.Context, db sqlx.ExtContext, data any, options ...BulkLoadOption) (int64, error)
+
+	// Update executes the update statement and returns the rows affected.
+	Update(ctx context.Context, db sqlx.ExtContext, data any) (int64, error)
+
+	// Delete executes the delete statement (update statement if soft delete) and returns the rows affected.
+	Delete(ctx context.Context, db sqlx.ExtContext) (int64, error)
+
+	// InvalidateCache invalidates the cached results of the table (requires WithQueryCache),
+	// it should be called after commit if the table is written in the raw `*sqlx.Tx`.
+	InvalidateCache(ctx context.Context) error
+
+	// Clone returns a copy of the wrapper with the options applied, the original is not affected.
+	// The wrapper is read-only once built, so a base query can be shared between goroutines and specialized by Clone.
+	Clone(options ...QueryOption) SQLWrapper
 }
 
 type queryBuilder struct {
-	driver DBDriver
+	driver      DBDriver
+	quote       bool
+	strict      bool
+	whitelist   map[string]struct{}
+	prefix      string
+	schema      string
+	createdAt   string
+	updatedAt   string
+	nowFunc     func() any
+	nowExpr     string
+	softDelete  string
+	hooks       []BuildHook
+	execHooks   []ExecHook
+	safe        bool
+	cache       QueryCache
+	flight      *singleflight.Group
+	shards      map[string]*ShardRule
+	stmtTimeout bool
+	retry       *retryPolicy
 }
 
 func (b *queryBuilder) Wrap(options ...QueryOption) SQLWrapper {
@@ -64,26 +150,75 @@ func (b *queryBuilder) Wrap(options ...QueryOption) SQLWrapper {
 	return wrapper
 }
 
-// NewSQLBuilder returns new SQLBuilder
-func NewSQLBuilder(driver DBDriver) SQLBuilder {
-	return &queryBuilder{
+// SQLBuilderOption configures how we set up the SQLBuilder.
+type SQLBuilderOption func(b *queryBuilder)
+
+// WithoutQuote disables the identifier quoting, table and column names will be written verbatim.
+func WithoutQuote() SQLBuilderOption {
+	return func(b *queryBuilder) {
+		b.quote = false
+	}
+}
+
+// WithTablePrefix specifies the table prefix, eg: yiigo.WithTablePrefix("t_") makes `user` to be `t_user`.
+// NOTE: The prefix is not applied to the qualified table (eg: db.user) and the column references in clauses,
+// use `TableAs` for the tables referenced in `where` or `join on` clause.
+func WithTablePrefix(prefix string) SQLBuilderOption {
+	return func(b *queryBuilder) {
+		b.prefix = prefix
+	}
+}
+
+// WithSchema specifies the schema (database for MySQL) to qualify the tables, eg: tenant.user.
+// NOTE: The schema is not applied to the qualified table (eg: db.user).
+func WithSchema(schema string) SQLBuilderOption {
+	return func(b *queryBuilder) {
+		b.schema = schema
+	}
+}
+
+// WithSafeMutation enables the safe mode, ToUpdate and ToDelete return ErrUnsafeMutation without `where`,
+// use `AllowFullTableMutation` to opt out for the statement.
+func WithSafeMutation() SQLBuilderOption {
+	return func(b *queryBuilder) {
+		b.safe = true
+	}
+}
+
+// NewSQLBuilder returns new SQLBuilder.
+// Identifiers (table and column names) are quoted according to the driver by default,
+// eg: `name` for MySQL, "name" for Postgres and SQLite.
+func NewSQLBuilder(driver DBDriver, options ...SQLBuilderOption) SQLBuilder {
+	builder := &queryBuilder{
 		driver: driver,
+		quote:  true,
 	}
+
+	for _, f := range options {
+		f(builder)
+	}
+
+	return builder
 }
 
 // NewMySQLBuilder returns new SQLBuilder for MySQL
-func NewMySQLBuilder() SQLBuilder {
-	return NewSQLBuilder(MySQL)
+func NewMySQLBuilder(options ...SQLBuilderOption) SQLBuilder {
+	return NewSQLBuilder(MySQL, options...)
 }
 
 // NewPGSQLBuilder returns new SQLBuilder for Postgres
-func NewPGSQLBuilder() SQLBuilder {
-	return NewSQLBuilder(Postgres)
+func NewPGSQLBuilder(options ...SQLBuilderOption) SQLBuilder {
+	return NewSQLBuilder(Postgres, options...)
 }
 
 // NewSQLiteBuilder returns new SQLBuilder for SQLite
-func NewSQLiteBuilder() SQLBuilder {
-	return NewSQLBuilder(SQLite)
+func NewSQLiteBuilder(options ...SQLBuilderOption) SQLBuilder {
+	return NewSQLBuilder(SQLite, options...)
+}
+
+// NewMSSQLBuilder returns new SQLBuilder for SQL Server
+func NewMSSQLBuilder(options ...SQLBuilderOption) SQLBuilder {
+	return NewSQLBuilder(SQLServer, options...)
 }
 
 // SQLClause SQL clause
@@ -102,24 +237,186 @@ func Clause(query string, binds ...any) *SQLClause {
 	}
 }
 
+type windowSpec struct {
+	partitions []string
+	orders     []string
+	frame      string
+}
+
+// WindowOption configures how we set up the window function `over` clause.
+type WindowOption func(s *windowSpec)
+
+// PartitionBy specifies the `partition by` clause of window function.
+func PartitionBy(columns ...string) WindowOption {
+	return func(s *windowSpec) {
+		s.partitions = append(s.partitions, columns...)
+	}
+}
+
+// OrderByAsc specifies the `order by ... asc` clause of window function.
+func OrderByAsc(columns ...string) WindowOption {
+	return func(s *windowSpec) {
+		for _, v := range columns {
+			s.orders = append(s.orders, v+" ASC")
+		}
+	}
+}
+
+// OrderByDesc specifies the `order by ... desc` clause of window function.
+func OrderByDesc(columns ...string) WindowOption {
+	return func(s *windowSpec) {
+		for _, v := range columns {
+			s.orders = append(s.orders, v+" DESC")
+		}
+	}
+}
+
+// Frame specifies the frame clause of window function, eg: yiigo.Frame("ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW").
+func Frame(clause string) WindowOption {
+	return func(s *windowSpec) {
+		s.frame = clause
+	}
+}
+
+// Over returns window function expression which can be used in `Select`,
+// eg: yiigo.Over("ROW_NUMBER()", yiigo.PartitionBy("user_id"), yiigo.OrderByDesc("created_at")).
+func Over(fn string, options ...WindowOption) string {
+	spec := new(windowSpec)
+
+	for _, f := range options {
+		f(spec)
+	}
+
+	var builder strings.Builder
+
+	builder.WriteString(fn)
+	builder.WriteString(" OVER (")
+
+	if len(spec.partitions) != 0 {
+		builder.WriteString("PARTITION BY ")
+		builder.WriteString(strings.Join(spec.partitions, ", "))
+	}
+
+	if len(spec.orders) != 0 {
+		if len(spec.partitions) != 0 {
+			builder.WriteString(" ")
+		}
+
+		builder.WriteString("ORDER BY ")
+		builder.WriteString(strings.Join(spec.orders, ", "))
+	}
+
+	if len(spec.frame) != 0 {
+		if len(spec.partitions) != 0 || len(spec.orders) != 0 {
+			builder.WriteString(" ")
+		}
+
+		builder.WriteString(spec.frame)
+	}
+
+	builder.WriteString(")")
+
+	return builder.String()
+}
+
+type groupMode int
+
+const (
+	groupDefault groupMode = iota
+	groupRollup
+	groupCube
+	groupSets
+)
+
+type unionClause struct {
+	keyword string
+	wrapper *queryWrapper
+}
+
+type indexHint struct {
+	keyword string
+	indexes []string
+}
+
 type queryWrapper struct {
-	builder  *queryBuilder
-	table    string
-	columns  []string
-	where    *SQLClause
-	joins    []*SQLClause
-	groups   []string
-	having   *SQLClause
-	orders   []string
-	offset   int
-	limit    int
-	unions   []*SQLClause
-	distinct bool
-	whereIn  bool
+	builder   *queryBuilder
+	table     string
+	alias     string
+	hints     []string
+	indexes   []*indexHint
+	partition []string
+	comments  map[string]string
+	columns   []string
+	where     *SQLClause
+	conds     []*SQLClause
+	joins     []*SQLClause
+	groups    []string
+	groupSets [][]string
+	groupMode groupMode
+	having    *SQLClause
+	orders    []string
+	offset    int
+	limit     int
+	unions    []*unionClause
+	distinct  bool
+	whereIn   bool
+	trashed   trashedMode
+	fullTable bool
+	cacheTTL  time.Duration
+	cacheKey  string
+	shardKey  any
+	shardSet  bool
+	attempts  int
+	err       error
+}
+
+func (w *queryWrapper) Clone(options ...QueryOption) SQLWrapper {
+	wrapper := *w
+
+	wrapper.hints = cloneSlice(w.hints)
+	wrapper.indexes = cloneSlice(w.indexes)
+	wrapper.partition = cloneSlice(w.partition)
+	wrapper.columns = cloneSlice(w.columns)
+	wrapper.conds = cloneSlice(w.conds)
+	wrapper.joins = cloneSlice(w.joins)
+	wrapper.groups = cloneSlice(w.groups)
+	wrapper.groupSets = cloneSlice(w.groupSets)
+	wrapper.orders = cloneSlice(w.orders)
+	wrapper.unions = cloneSlice(w.unions)
+
+	if w.comments != nil {
+		wrapper.comments = make(map[string]string, len(w.comments))
+
+		for k, v := range w.comments {
+			wrapper.comments[k] = v
+		}
+	}
+
+	for _, f := range options {
+		f(&wrapper)
+	}
+
+	return &wrapper
+}
+
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+
+	return append(make([]T, 0, len(s)), s...)
 }
 
 func (w *queryWrapper) ToQuery(ctx context.Context) (sql string, args []any, err error) {
-	sql, args = w.subquery()
+	if w, err = w.shard(nil); err != nil {
+		return
+	}
+
+	sql, args, err = w.subquery()
+
+	if err != nil {
+		return
+	}
 
 	// unions
 	if l := len(w.unions); l != 0 {
@@ -130,13 +427,21 @@ func (w *queryWrapper) ToQuery(ctx context.Context) (sql string, args []any, err
 		builder.WriteString(")")
 
 		for _, v := range w.unions {
+			query, binds, subErr := v.wrapper.subquery()
+
+			if subErr != nil {
+				err = subErr
+
+				return
+			}
+
 			builder.WriteString(" ")
 			builder.WriteString(v.keyword)
 			builder.WriteString(" (")
-			builder.WriteString(v.query)
+			builder.WriteString(query)
 			builder.WriteString(")")
 
-			args = append(args, v.binds...)
+			args = append(args, binds...)
 		}
 
 		sql = builder.String()
@@ -151,38 +456,92 @@ func (w *queryWrapper) ToQuery(ctx context.Context) (sql string, args []any, err
 		}
 	}
 
-	sql = sqlx.Rebind(sqlx.BindType(string(w.builder.driver)), sql)
+	sql, args, err = w.build(ctx, StmtQuery, sql, args)
 
 	return
 }
 
-func (w *queryWrapper) subquery() (string, []any) {
-	binds := make([]any, 0)
+func (w *queryWrapper) ToExplain(ctx context.Context, analyze bool) (sql string, args []any, err error) {
+	var explain string
+
+	switch w.builder.driver {
+	case MySQL, Postgres:
+		explain = "EXPLAIN "
+
+		if analyze {
+			explain = "EXPLAIN ANALYZE "
+		}
+	case SQLite:
+		if analyze {
+			err = errDriverUnsupported(w.builder.driver, "EXPLAIN ANALYZE")
+
+			return
+		}
+
+		explain = "EXPLAIN QUERY PLAN "
+	default:
+		err = errDriverUnsupported(w.builder.driver, "EXPLAIN")
+
+		return
+	}
+
+	sql, args, err = w.ToQuery(ctx)
+
+	if err != nil {
+		return
+	}
+
+	// keep the comment ahead
+	comment := w.comment(ctx)
+	sql = comment + explain + strings.TrimPrefix(sql, comment)
+
+	return
+}
+
+func (w *queryWrapper) subquery() (string, []any, error) {
+	if err := w.checkQuery(); err != nil {
+		return "", nil, err
+	}
+
+	binds := make([]any, 0, w.bindsHint())
 
 	var builder strings.Builder
 
+	builder.Grow(w.sizeHint())
 	builder.WriteString("SELECT ")
+	builder.WriteString(w.optimizerHint())
 
 	if w.distinct {
 		builder.WriteString("DISTINCT ")
 	}
 
-	builder.WriteString(w.columns[0])
-
-	for _, column := range w.columns[1:] {
-		builder.WriteString(", ")
-		builder.WriteString(column)
+	// SQL Server: SELECT TOP (?) ...
+	if w.builder.driver == SQLServer && w.limit != 0 && w.offset == 0 {
+		builder.WriteString("TOP (?) ")
+		binds = append(binds, w.limit)
 	}
 
+	w.builder.writeColumns(&builder, w.columns, w.builder.quoteColumn)
+
 	builder.WriteString(" FROM ")
-	builder.WriteString(w.table)
+	builder.WriteString(w.fromTable())
+
+	if len(w.indexes) != 0 {
+		hint, err := w.indexHint()
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		builder.WriteString(hint)
+	}
 
 	if len(w.joins) != 0 {
 		for _, join := range w.joins {
 			builder.WriteString(" ")
 			builder.WriteString(join.keyword)
 			builder.WriteString(" JOIN ")
-			builder.WriteString(join.table)
+			builder.WriteString(w.builder.quoteTable(w.builder.tableName(join.table)))
 
 			if len(join.query) != 0 {
 				builder.WriteString(" ON ")
@@ -191,21 +550,22 @@ func (w *queryWrapper) subquery() (string, []any) {
 		}
 	}
 
-	if w.where != nil {
+	if query, whereBinds := w.whereClause(); len(query) != 0 {
 		builder.WriteString(" WHERE ")
-		builder.WriteString(w.where.query)
+		builder.WriteString(query)
 
-		binds = append(binds, w.where.binds...)
+		binds = append(binds, whereBinds...)
 	}
 
-	if len(w.groups) != 0 {
-		builder.WriteString(" GROUP BY ")
-		builder.WriteString(w.groups[0])
+	if len(w.groups) != 0 || len(w.groupSets) != 0 {
+		clause, err := w.groupBy()
 
-		for _, column := range w.groups[1:] {
-			builder.WriteString(", ")
-			builder.WriteString(column)
+		if err != nil {
+			return "", nil, err
 		}
+
+		builder.WriteString(" GROUP BY ")
+		builder.WriteString(clause)
 	}
 
 	if w.having != nil {
@@ -217,12 +577,13 @@ func (w *queryWrapper) subquery() (string, []any) {
 
 	if len(w.orders) != 0 {
 		builder.WriteString(" ORDER BY ")
-		builder.WriteString(w.orders[0])
+		w.builder.writeColumns(&builder, w.orders, w.builder.quoteOrder)
+	}
 
-		for _, column := range w.orders[1:] {
-			builder.WriteString(", ")
-			builder.WriteString(column)
-		}
+	if w.builder.driver == SQLServer {
+		binds = append(binds, w.fetch(&builder)...)
+
+		return builder.String(), binds, nil
 	}
 
 	if w.limit != 0 {
@@ -235,17 +596,344 @@ func (w *queryWrapper) subquery() (string, []any) {
 		binds = append(binds, w.offset)
 	}
 
+	return builder.String(), binds, nil
+}
+
+// sizeHint estimates the length of the statement to pre-size the strings.Builder.
+func (w *queryWrapper) sizeHint() int {
+	// keywords, placeholders and quotes
+	n := 64 + len(w.table) + len(w.alias)
+
+	for _, v := range w.columns {
+		n += len(v) + 4
+	}
+
+	if w.where != nil {
+		n += len(w.where.query)
+	}
+
+	for _, v := range w.conds {
+		n += len(v.query) + 9
+	}
+
+	for _, v := range w.joins {
+		n += len(v.keyword) + len(v.table) + len(v.query) + 16
+	}
+
+	for _, v := range w.groups {
+		n += len(v) + 4
+	}
+
+	if w.having != nil {
+		n += len(w.having.query) + 8
+	}
+
+	for _, v := range w.orders {
+		n += len(v) + 4
+	}
+
+	return n
+}
+
+// columnsHint estimates the length of the insert (update) columns and placeholders.
+func columnsHint(columns []string) int {
+	n := 0
+
+	for _, v := range columns {
+		n += len(v) + 8
+	}
+
+	return n
+}
+
+// bindsHint estimates the number of the binds to pre-size the binds slice.
+func (w *queryWrapper) bindsHint() int {
+	n := 2 // offset and limit
+
+	if w.where != nil {
+		n += len(w.where.binds)
+	}
+
+	for _, v := range w.conds {
+		n += len(v.binds)
+	}
+
+	if w.having != nil {
+		n += len(w.having.binds)
+	}
+
+	return n
+}
+
+// fetch writes the SQL Server pagination, eg: ORDER BY ... OFFSET ? ROWS FETCH NEXT ? ROWS ONLY.
+// The limit without offset is written as `TOP (?)`.
+func (w *queryWrapper) fetch(builder *strings.Builder) []any {
+	if w.offset == 0 {
+		return nil
+	}
+
+	binds := make([]any, 0, 2)
+
+	// OFFSET requires ORDER BY
+	if len(w.orders) == 0 {
+		builder.WriteString(" ORDER BY (SELECT NULL)")
+	}
+
+	builder.WriteString(" OFFSET ? ROWS")
+	binds = append(binds, w.offset)
+
+	if w.limit != 0 {
+		builder.WriteString(" FETCH NEXT ? ROWS ONLY")
+		binds = append(binds, w.limit)
+	}
+
+	return binds
+}
+
+// whereClause returns the `where` clause which combines the conditions with `AND`.
+func (w *queryWrapper) whereClause() (string, []any) {
+	clauses := make([]*SQLClause, 0, len(w.conds)+1)
+
+	if w.where != nil {
+		clauses = append(clauses, w.where)
+	}
+
+	clauses = append(clauses, w.conds...)
+
+	if clause := w.softDeleteClause(); clause != nil {
+		clauses = append(clauses, clause)
+	}
+
+	switch len(clauses) {
+	case 0:
+		return "", nil
+	case 1:
+		return clauses[0].query, clauses[0].binds
+	}
+
+	var (
+		builder strings.Builder
+		binds   []any
+	)
+
+	for i, v := range clauses {
+		if i != 0 {
+			builder.WriteString(" AND ")
+		}
+
+		builder.WriteString("(")
+		builder.WriteString(v.query)
+		builder.WriteString(")")
+
+		binds = append(binds, v.binds...)
+	}
+
 	return builder.String(), binds
 }
 
+// fromTable returns the table with alias for select, update and delete statement.
+func (w *queryWrapper) fromTable() string {
+	table := w.builder.quoteTable(w.builder.tableName(w.table)) + w.partitions()
+
+	if len(w.alias) != 0 {
+		table += " AS " + w.builder.quoteIdent(w.alias)
+	}
+
+	return table
+}
+
+// mutationAlias returns the alias of the table for update and delete statement of SQL Server,
+// which are rendered as `UPDATE [u] SET ... FROM [user] AS [u]` and `DELETE [u] FROM [user] AS [u]`.
+func (w *queryWrapper) mutationAlias() string {
+	if w.builder.driver != SQLServer {
+		return ""
+	}
+
+	if len(w.alias) != 0 {
+		return w.alias
+	}
+
+	if m := tableRegexp.FindStringSubmatch(strings.TrimSpace(w.table)); len(m) != 0 {
+		return m[3]
+	}
+
+	return ""
+}
+
+// optimizerHint returns the optimizer hints which follow the statement keyword, eg: SELECT /*+ MAX_EXECUTION_TIME(1000) */ ...
+func (w *queryWrapper) optimizerHint() string {
+	if len(w.hints) == 0 {
+		return ""
+	}
+
+	return strings.Join(w.hints, " ") + " "
+}
+
+// indexHint returns the index hints which follow the table (MySQL only), eg: FROM t USE INDEX (idx_a).
+func (w *queryWrapper) indexHint() (string, error) {
+	if w.builder.driver != MySQL {
+		return "", errDriverUnsupported(w.builder.driver, "INDEX HINT")
+	}
+
+	var builder strings.Builder
+
+	for _, v := range w.indexes {
+		builder.WriteString(" ")
+		builder.WriteString(v.keyword)
+		builder.WriteString(" INDEX (")
+		builder.WriteString(strings.Join(w.builder.quoteColumns(v.indexes, w.builder.quoteIdent), ", "))
+		builder.WriteString(")")
+	}
+
+	return builder.String(), nil
+}
+
+// partitions returns the partition clause which follows the table (MySQL only), eg: t PARTITION (p0, p1).
+func (w *queryWrapper) partitions() string {
+	if len(w.partition) == 0 {
+		return ""
+	}
+
+	return " PARTITION (" + strings.Join(w.builder.quoteColumns(w.partition, w.builder.quoteIdent), ", ") + ")"
+}
+
+func (w *queryWrapper) checkTable() error {
+	// the error of the query options
+	if w.err != nil {
+		return w.err
+	}
+
+	if len(strings.TrimSpace(w.table)) == 0 {
+		return fmt.Errorf("%w: table is required", ErrInvalidWrapper)
+	}
+
+	if err := w.builder.checkTable(w.table); err != nil {
+		return err
+	}
+
+	if len(w.partition) != 0 && w.builder.driver != MySQL {
+		return errDriverUnsupported(w.builder.driver, "PARTITION")
+	}
+
+	if len(w.alias) != 0 {
+		return w.builder.checkIdent(w.alias)
+	}
+
+	return nil
+}
+
+func (w *queryWrapper) checkQuery() error {
+	if err := w.checkTable(); err != nil {
+		return err
+	}
+
+	if len(w.columns) == 0 {
+		return fmt.Errorf("%w: select columns are empty", ErrInvalidWrapper)
+	}
+
+	if w.limit < 0 || w.offset < 0 {
+		return fmt.Errorf("%w: negative limit (%d) or offset (%d)", ErrInvalidWrapper, w.limit, w.offset)
+	}
+
+	// MySQL and SQLite require LIMIT for OFFSET
+	if w.offset != 0 && w.limit == 0 && (w.builder.driver == MySQL || w.builder.driver == SQLite) {
+		return fmt.Errorf("%w: offset without limit (%s)", ErrInvalidWrapper, w.builder.driver)
+	}
+
+	for _, join := range w.joins {
+		if err := w.builder.checkTable(join.table); err != nil {
+			return err
+		}
+	}
+
+	if err := w.builder.checkColumns(w.groups, w.builder.checkIdent); err != nil {
+		return err
+	}
+
+	for _, set := range w.groupSets {
+		if err := w.builder.checkColumns(set, w.builder.checkIdent); err != nil {
+			return err
+		}
+	}
+
+	return w.builder.checkColumns(w.orders, w.builder.checkOrder)
+}
+
+func (w *queryWrapper) checkMutation(columns []string) error {
+	if err := w.checkTable(); err != nil {
+		return err
+	}
+
+	return w.builder.checkColumns(columns, w.builder.checkIdent)
+}
+
+// checkWhere refuses the update (delete) without `where` in the safe mode.
+func (w *queryWrapper) checkWhere() error {
+	if !w.builder.safe || w.fullTable {
+		return nil
+	}
+
+	if !w.hasWhere() {
+		return fmt.Errorf("%w: %s", ErrUnsafeMutation, w.table)
+	}
+
+	return nil
+}
+
+// hasWhere reports whether the `where` conditions are specified, the soft delete condition doesn't count.
+func (w *queryWrapper) hasWhere() bool {
+	return w.where != nil || len(w.conds) != 0
+}
+
+func (w *queryWrapper) groupBy() (string, error) {
+	driver := w.builder.driver
+	groups := w.builder.quoteColumns(w.groups, w.builder.quoteIdent)
+
+	switch w.groupMode {
+	case groupRollup:
+		switch driver {
+		case MySQL:
+			return strings.Join(groups, ", ") + " WITH ROLLUP", nil
+		case Postgres, SQLServer:
+			return "ROLLUP (" + strings.Join(groups, ", ") + ")", nil
+		}
+
+		return "", errDriverUnsupported(driver, "GROUP BY ROLLUP")
+	case groupCube:
+		if driver == Postgres || driver == SQLServer {
+			return "CUBE (" + strings.Join(groups, ", ") + ")", nil
+		}
+
+		return "", errDriverUnsupported(driver, "GROUP BY CUBE")
+	case groupSets:
+		if driver != Postgres && driver != SQLServer {
+			return "", errDriverUnsupported(driver, "GROUP BY GROUPING SETS")
+		}
+
+		sets := make([]string, 0, len(w.groupSets))
+
+		for _, v := range w.groupSets {
+			sets = append(sets, "("+strings.Join(w.builder.quoteColumns(v, w.builder.quoteIdent), ", ")+")")
+		}
+
+		return "GROUPING SETS (" + strings.Join(sets, ", ") + ")", nil
+	}
+
+	return strings.Join(groups, ", "), nil
+}
+
 func (w *queryWrapper) ToInsert(ctx context.Context, data any) (sql string, args []any, err error) {
+	if w, err = w.shard(data); err != nil {
+		return
+	}
+
 	var columns []string
 
 	v := reflect.Indirect(reflect.ValueOf(data))
 
 	switch v.Kind() {
 	case reflect.Map:
-		x, ok := data.(X)
+		x, ok := toX(v)
 
 		if !ok {
 			err = ErrUpsertData
@@ -262,36 +950,97 @@ func (w *queryWrapper) ToInsert(ctx context.Context, data any) (sql string, args
 		return
 	}
 
+	exprs := make(map[string]string)
+
+	columns, args = w.builder.timestamp(w.builder.createdAt, columns, exprs, args, 1)
+
+	if err = w.checkMutation(columns); err != nil {
+		return
+	}
+
 	var builder strings.Builder
 
-	builder.WriteString("INSERT INTO ")
-	builder.WriteString(w.table)
+	builder.Grow(w.sizeHint() + columnsHint(columns)*2)
+	builder.WriteString("INSERT ")
+	builder.WriteString(w.optimizerHint())
+	builder.WriteString("INTO ")
+	builder.WriteString(w.builder.quoteTable(w.builder.tableName(w.table)))
+	builder.WriteString(w.partitions())
 
-	if l := len(columns); l != 0 {
+	if len(columns) != 0 {
 		builder.WriteString(" (")
-		builder.WriteString(columns[0])
-
-		for _, column := range columns[1:] {
-			builder.WriteString(", ")
-			builder.WriteString(column)
+		w.builder.writeColumns(&builder, columns, w.builder.quoteIdent)
+		builder.WriteString(")")
+		builder.WriteString(" VALUES ")
+		builder.WriteString(insertValues(columns, exprs))
+	} else {
+		// all columns use the default values
+		if w.builder.driver == MySQL {
+			builder.WriteString(" () VALUES ()")
+		} else {
+			builder.WriteString(" DEFAULT VALUES")
 		}
+	}
+
+	if w.builder.driver == Postgres {
+		builder.WriteString(" RETURNING ")
+		builder.WriteString(w.builder.quoteIdent("id"))
+	}
+
+	sql, args, err = w.build(ctx, StmtInsert, builder.String(), args)
+
+	return
+}
 
-		builder.WriteString(") VALUES (?")
+// insertValues returns the row values of insert, eg: (?, ?, CURRENT_TIMESTAMP).
+func insertValues(columns []string, exprs map[string]string) string {
+	values := make([]string, 0, len(columns))
 
-		for i := 1; i < l; i++ {
-			builder.WriteString(", ?")
+	for _, v := range columns {
+		if expr, ok := exprs[v]; ok {
+			values = append(values, expr)
+
+			continue
 		}
 
-		builder.WriteString(")")
+		values = append(values, "?")
 	}
 
-	if w.builder.driver == Postgres {
-		builder.WriteString(" RETURNING id")
+	return "(" + strings.Join(values, ", ") + ")"
+}
+
+// toX converts the string-keyed map (eg: map[string]any, map[string]string) to yiigo.X.
+func toX(v reflect.Value) (X, bool) {
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil, false
 	}
 
-	sql = sqlx.Rebind(sqlx.BindType(string(w.builder.driver)), builder.String())
+	if x, ok := v.Interface().(X); ok {
+		return x, true
+	}
 
-	return
+	x := make(X, v.Len())
+
+	iter := v.MapRange()
+
+	for iter.Next() {
+		x[iter.Key().String()] = iter.Value().Interface()
+	}
+
+	return x, true
+}
+
+// sortedKeys returns the sorted keys of the map, so the columns are in a deterministic order.
+func sortedKeys(data X) []string {
+	keys := make([]string, 0, len(data))
+
+	for k := range data {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
 }
 
 func (w *queryWrapper) insertWithMap(data X) (columns []string, binds []any) {
@@ -300,51 +1049,79 @@ func (w *queryWrapper) insertWithMap(data X) (columns []string, binds []any) {
 	columns = make([]string, 0, fieldNum)
 	binds = make([]any, 0, fieldNum)
 
-	for k, v := range data {
+	for _, k := range sortedKeys(data) {
 		columns = append(columns, k)
-		binds = append(binds, v)
+		binds = append(binds, bindValue(reflect.ValueOf(data[k])))
 	}
 
 	return
 }
 
 func (w *queryWrapper) insertWithStruct(v reflect.Value) (columns []string, binds []any) {
-	fieldNum := v.NumField()
+	return structColumns(v)
+}
 
-	columns = make([]string, 0, fieldNum)
-	binds = make([]any, 0, fieldNum)
+func (w *queryWrapper) ToBatchInsert(ctx context.Context, data any) (sql string, args []any, err error) {
+	if w, err = w.shard(data); err != nil {
+		return
+	}
 
-	t := v.Type()
+	columns, exprs, args, rows, err := w.batchValues(data)
 
-	for i := 0; i < fieldNum; i++ {
-		fieldT := t.Field(i)
-		tag := fieldT.Tag.Get("db")
+	if err != nil {
+		return
+	}
 
-		if tag == "-" {
-			continue
-		}
+	var builder strings.Builder
 
-		fieldV := v.Field(i)
-		column := fieldT.Name
+	builder.Grow(w.sizeHint() + columnsHint(columns) + rows*(len(columns)*3+4))
+	builder.WriteString("INSERT ")
+	builder.WriteString(w.optimizerHint())
+	builder.WriteString("INTO ")
+	builder.WriteString(w.builder.quoteTable(w.builder.tableName(w.table)))
+	builder.WriteString(w.partitions())
 
-		if len(tag) != 0 {
-			name, opts := parseTag(tag)
+	if len(columns) != 0 {
+		builder.WriteString(" (")
+		w.builder.writeColumns(&builder, columns, w.builder.quoteIdent)
+		builder.WriteString(") VALUES ")
 
-			if opts.Contains("omitempty") && isEmptyValue(fieldV) {
-				continue
-			}
+		values := insertValues(columns, exprs)
+
+		// 首行
+		builder.WriteString(values)
 
-			column = name
+		// 其余行
+		for i := 1; i < rows; i++ {
+			builder.WriteString(", ")
+			builder.WriteString(values)
 		}
+	} else {
+		// all columns use the default values
+		switch {
+		case w.builder.driver == MySQL:
+			builder.WriteString(" () VALUES ()")
+
+			for i := 1; i < rows; i++ {
+				builder.WriteString(", ()")
+			}
+		case rows == 1:
+			builder.WriteString(" DEFAULT VALUES")
+		default:
+			err = errDriverUnsupported(w.builder.driver, "BATCH INSERT DEFAULT VALUES")
 
-		columns = append(columns, column)
-		binds = append(binds, fieldV.Interface())
+			return
+		}
 	}
 
+	sql, args, err = w.build(ctx, StmtInsert, builder.String(), args)
+
 	return
 }
 
-func (w *queryWrapper) ToBatchInsert(ctx context.Context, data any) (sql string, args []any, err error) {
+// batchValues returns the columns and the binds (row by row) of the batch insert data with the created timestamp,
+// exprs is the column expressions (eg: NOW()) without binds.
+func (w *queryWrapper) batchValues(data any) (columns []string, exprs map[string]string, binds []any, rows int, err error) {
 	v := reflect.Indirect(reflect.ValueOf(data))
 
 	if v.Kind() != reflect.Slice {
@@ -359,23 +1136,27 @@ func (w *queryWrapper) ToBatchInsert(ctx context.Context, data any) (sql string,
 		return
 	}
 
-	var columns []string
-
 	e := v.Type().Elem()
 
 	switch e.Kind() {
 	case reflect.Map:
-		x, ok := data.([]X)
+		x := make([]X, 0, v.Len())
+
+		for i := 0; i < v.Len(); i++ {
+			m, ok := toX(v.Index(i))
 
-		if !ok {
-			err = ErrBatchInsertData
+			if !ok {
+				err = ErrBatchInsertData
 
-			return
+				return
+			}
+
+			x = append(x, m)
 		}
 
-		columns, args = w.batchInsertWithMap(x)
+		columns, binds, err = w.batchInsertWithMap(x)
 	case reflect.Struct:
-		columns, args = w.batchInsertWithStruct(v)
+		columns, binds, err = w.batchInsertWithStruct(v)
 	case reflect.Ptr:
 		if e.Elem().Kind() != reflect.Struct {
 			err = ErrBatchInsertData
@@ -383,121 +1164,87 @@ func (w *queryWrapper) ToBatchInsert(ctx context.Context, data any) (sql string,
 			return
 		}
 
-		columns, args = w.batchInsertWithStruct(v)
+		columns, binds, err = w.batchInsertWithStruct(v)
 	default:
 		err = ErrBatchInsertData
 
 		return
 	}
 
-	var builder strings.Builder
-
-	builder.WriteString("INSERT INTO ")
-	builder.WriteString(w.table)
-
-	if l := len(columns); l != 0 {
-		builder.WriteString(" (")
-		builder.WriteString(columns[0])
-
-		for _, column := range columns[1:] {
-			builder.WriteString(", ")
-			builder.WriteString(column)
-		}
-
-		builder.WriteString(") VALUES (?")
-
-		// 首行
-		for i := 1; i < l; i++ {
-			builder.WriteString(", ?")
-		}
-
-		builder.WriteString(")")
-
-		rows := len(args) / l
-
-		// 其余行
-		for i := 1; i < rows; i++ {
-			builder.WriteString(", (?")
+	if err != nil {
+		return
+	}
 
-			for j := 1; j < l; j++ {
-				builder.WriteString(", ?")
-			}
+	rows = v.Len()
+	exprs = make(map[string]string)
 
-			builder.WriteString(")")
-		}
-	}
+	columns, binds = w.builder.timestamp(w.builder.createdAt, columns, exprs, binds, rows)
 
-	sql = sqlx.Rebind(sqlx.BindType(string(w.builder.driver)), builder.String())
+	err = w.checkMutation(columns)
 
 	return
 }
 
-func (w *queryWrapper) batchInsertWithMap(data []X) (columns []string, binds []any) {
+func (w *queryWrapper) batchInsertWithMap(data []X) (columns []string, binds []any, err error) {
 	dataLen := len(data)
 	fieldNum := len(data[0])
 
 	columns = make([]string, 0, fieldNum)
 	binds = make([]any, 0, fieldNum*dataLen)
 
-	for k := range data[0] {
-		columns = append(columns, k)
-	}
+	columns = append(columns, sortedKeys(data[0])...)
+
+	for i, x := range data {
+		if len(x) != fieldNum {
+			err = fmt.Errorf("%w: row %d has %d columns, expects %d", ErrBatchInsertColumns, i, len(x), fieldNum)
+
+			return
+		}
 
-	for _, x := range data {
 		for _, v := range columns {
-			binds = append(binds, x[v])
+			value, ok := x[v]
+
+			if !ok {
+				err = fmt.Errorf("%w: row %d missing column %q", ErrBatchInsertColumns, i, v)
+
+				return
+			}
+
+			binds = append(binds, bindValue(reflect.ValueOf(value)))
 		}
 	}
 
 	return
 }
 
-func (w *queryWrapper) batchInsertWithStruct(v reflect.Value) (columns []string, binds []any) {
-	first := reflect.Indirect(v.Index(0))
-
+// batchInsertWithStruct uses the same columns for all rows,
+// the `omitempty` (or `omitnil`) column is omitted only if it's empty (or nil) in every row.
+func (w *queryWrapper) batchInsertWithStruct(v reflect.Value) (columns []string, binds []any, err error) {
 	dataLen := v.Len()
-	fieldNum := first.NumField()
-
-	columns = make([]string, 0, fieldNum)
-	binds = make([]any, 0, fieldNum*dataLen)
-
-	t := first.Type()
+	rows := make([]reflect.Value, 0, dataLen)
 
 	for i := 0; i < dataLen; i++ {
-		for j := 0; j < fieldNum; j++ {
-			fieldT := t.Field(j)
-
-			tag := fieldT.Tag.Get("db")
+		row := reflect.Indirect(v.Index(i))
 
-			if tag == "-" {
-				continue
-			}
-
-			fieldV := reflect.Indirect(v.Index(i)).Field(j)
-			column := fieldT.Name
-
-			if len(tag) != 0 {
-				name, opts := parseTag(tag)
-
-				if opts.Contains("omitempty") && isEmptyValue(fieldV) {
-					continue
-				}
-
-				column = name
-			}
-
-			if i == 0 {
-				columns = append(columns, column)
-			}
+		if !row.IsValid() {
+			err = fmt.Errorf("%w: row %d is nil", ErrBatchInsertData, i)
 
-			binds = append(binds, fieldV.Interface())
+			return
 		}
+
+		rows = append(rows, row)
 	}
 
+	columns, binds = structBatchColumns(rows)
+
 	return
 }
 
 func (w *queryWrapper) ToUpdate(ctx context.Context, data any) (sql string, args []any, err error) {
+	if w, err = w.shard(nil); err != nil {
+		return
+	}
+
 	var (
 		columns []string
 		exprs   map[string]string
@@ -507,7 +1254,7 @@ func (w *queryWrapper) ToUpdate(ctx context.Context, data any) (sql string, args
 
 	switch v.Kind() {
 	case reflect.Map:
-		x, ok := data.(X)
+		x, ok := toX(v)
 
 		if !ok {
 			err = ErrUpsertData
@@ -524,14 +1271,55 @@ func (w *queryWrapper) ToUpdate(ctx context.Context, data any) (sql string, args
 		return
 	}
 
+	if exprs == nil {
+		exprs = make(map[string]string)
+	}
+
+	columns, args = w.builder.timestamp(w.builder.updatedAt, columns, exprs, args, 1)
+
+	if err = w.checkMutation(columns); err != nil {
+		return
+	}
+
+	if err = w.checkWhere(); err != nil {
+		return
+	}
+
+	if len(columns) == 0 {
+		err = fmt.Errorf("%w: update data is empty", ErrInvalidWrapper)
+
+		return
+	}
+
 	var builder strings.Builder
 
+	builder.Grow(w.sizeHint() + columnsHint(columns))
 	builder.WriteString("UPDATE ")
-	builder.WriteString(w.table)
+	builder.WriteString(w.optimizerHint())
+
+	alias := w.mutationAlias()
+
+	if len(alias) != 0 {
+		builder.WriteString(w.builder.quoteIdent(alias))
+	} else {
+		builder.WriteString(w.fromTable())
+	}
+
+	if len(w.indexes) != 0 {
+		hint, hintErr := w.indexHint()
+
+		if hintErr != nil {
+			err = hintErr
+
+			return
+		}
+
+		builder.WriteString(hint)
+	}
 
 	if len(columns) != 0 {
 		builder.WriteString(" SET ")
-		builder.WriteString(columns[0])
+		builder.WriteString(w.builder.quoteIdent(columns[0]))
 
 		if expr, ok := exprs[columns[0]]; ok {
 			builder.WriteString(" = ")
@@ -542,7 +1330,7 @@ func (w *queryWrapper) ToUpdate(ctx context.Context, data any) (sql string, args
 
 		for _, column := range columns[1:] {
 			builder.WriteString(", ")
-			builder.WriteString(column)
+			builder.WriteString(w.builder.quoteIdent(column))
 
 			if expr, ok := exprs[column]; ok {
 				builder.WriteString(" = ")
@@ -553,11 +1341,16 @@ func (w *queryWrapper) ToUpdate(ctx context.Context, data any) (sql string, args
 		}
 	}
 
-	if w.where != nil {
+	if len(alias) != 0 {
+		builder.WriteString(" FROM ")
+		builder.WriteString(w.fromTable())
+	}
+
+	if query, binds := w.whereClause(); len(query) != 0 {
 		builder.WriteString(" WHERE ")
-		builder.WriteString(w.where.query)
+		builder.WriteString(query)
 
-		args = append(args, w.where.binds...)
+		args = append(args, binds...)
 	}
 
 	sql = builder.String()
@@ -570,7 +1363,7 @@ func (w *queryWrapper) ToUpdate(ctx context.Context, data any) (sql string, args
 		}
 	}
 
-	sql = sqlx.Rebind(sqlx.BindType(string(w.builder.driver)), sql)
+	sql, args, err = w.build(ctx, StmtUpdate, sql, args)
 
 	return
 }
@@ -582,7 +1375,9 @@ func (w *queryWrapper) updateWithMap(data X) (columns []string, exprs map[string
 	exprs = make(map[string]string)
 	binds = make([]any, 0, fieldNum)
 
-	for k, v := range data {
+	for _, k := range sortedKeys(data) {
+		v := data[k]
+
 		columns = append(columns, k)
 
 		if clause, ok := v.(*SQLClause); ok {
@@ -592,59 +1387,72 @@ func (w *queryWrapper) updateWithMap(data X) (columns []string, exprs map[string
 			continue
 		}
 
-		binds = append(binds, v)
+		binds = append(binds, bindValue(reflect.ValueOf(v)))
 	}
 
 	return
 }
 
 func (w *queryWrapper) updateWithStruct(v reflect.Value) (columns []string, binds []any) {
-	fieldNum := v.NumField()
-
-	columns = make([]string, 0, fieldNum)
-	binds = make([]any, 0, fieldNum)
+	return structColumns(v)
+}
 
-	t := v.Type()
+func (w *queryWrapper) ToDelete(ctx context.Context) (sql string, args []any, err error) {
+	if w, err = w.shard(nil); err != nil {
+		return
+	}
 
-	for i := 0; i < fieldNum; i++ {
-		fieldT := t.Field(i)
-		tag := fieldT.Tag.Get("db")
+	if len(w.builder.softDelete) != 0 {
+		return w.softDelete(ctx)
+	}
 
-		if tag == "-" {
-			continue
-		}
+	return w.delete(ctx)
+}
 
-		fieldV := v.Field(i)
-		column := fieldT.Name
+func (w *queryWrapper) ToForceDelete(ctx context.Context) (sql string, args []any, err error) {
+	if w, err = w.shard(nil); err != nil {
+		return
+	}
 
-		if len(tag) != 0 {
-			name, opts := parseTag(tag)
+	// the soft deleted rows are included unless `OnlyTrashed`
+	if w.trashed == withoutTrashed {
+		wrapper := *w
+		wrapper.trashed = withTrashed
 
-			if opts.Contains("omitempty") && isEmptyValue(fieldV) {
-				continue
-			}
+		return wrapper.delete(ctx)
+	}
 
-			column = name
-		}
+	return w.delete(ctx)
+}
 
-		columns = append(columns, column)
-		binds = append(binds, fieldV.Interface())
+func (w *queryWrapper) delete(ctx context.Context) (sql string, args []any, err error) {
+	if err = w.checkTable(); err != nil {
+		return
 	}
 
-	return
-}
+	if err = w.checkWhere(); err != nil {
+		return
+	}
 
-func (w *queryWrapper) ToDelete(ctx context.Context) (sql string, args []any, err error) {
 	var builder strings.Builder
 
-	builder.WriteString("DELETE FROM ")
-	builder.WriteString(w.table)
+	builder.Grow(w.sizeHint())
+	builder.WriteString("DELETE ")
+	builder.WriteString(w.optimizerHint())
 
-	if w.where != nil {
+	if alias := w.mutationAlias(); len(alias) != 0 {
+		builder.WriteString(w.builder.quoteIdent(alias))
+		builder.WriteString(" ")
+	}
+
+	builder.WriteString("FROM ")
+	builder.WriteString(w.fromTable())
+
+	if query, binds := w.whereClause(); len(query) != 0 {
 		builder.WriteString(" WHERE ")
-		builder.WriteString(w.where.query)
+		builder.WriteString(query)
 
-		args = append(args, w.where.binds...)
+		args = append(args, binds...)
 	}
 
 	sql = builder.String()
@@ -657,18 +1465,133 @@ func (w *queryWrapper) ToDelete(ctx context.Context) (sql string, args []any, er
 		}
 	}
 
-	sql = sqlx.Rebind(sqlx.BindType(string(w.builder.driver)), sql)
+	sql, args, err = w.build(ctx, StmtDelete, sql, args)
 
 	return
 }
 
-func (w *queryWrapper) ToTruncate(ctx context.Context) string {
+type truncate struct {
+	restartIdentity bool
+	cascade         bool
+}
+
+// TruncateOption truncate option.
+type TruncateOption func(t *truncate)
+
+// RestartIdentity specifies `RESTART IDENTITY` for truncate (Postgres).
+// NOTE: MySQL and SQL Server always reset the AUTO_INCREMENT (IDENTITY) on truncate.
+func RestartIdentity() TruncateOption {
+	return func(t *truncate) {
+		t.restartIdentity = true
+	}
+}
+
+// Cascade specifies `CASCADE` for truncate (Postgres).
+func Cascade() TruncateOption {
+	return func(t *truncate) {
+		t.cascade = true
+	}
+}
+
+// ToTruncate returns the truncate statement, SQLite doesn't support `TRUNCATE`, so `DELETE FROM` is used instead.
+func (w *queryWrapper) ToTruncate(ctx context.Context, options ...TruncateOption) (sql string, err error) {
+	if len(strings.TrimSpace(w.table)) == 0 {
+		err = fmt.Errorf("%w: table is required", ErrInvalidWrapper)
+
+		return
+	}
+
+	if w, err = w.shard(nil); err != nil {
+		return
+	}
+
+	if err = w.builder.checkTable(w.table); err != nil {
+		return
+	}
+
+	t := new(truncate)
+
+	for _, f := range options {
+		f(t)
+	}
+
 	var builder strings.Builder
 
-	builder.WriteString("TRUNCATE ")
-	builder.WriteString(w.table)
+	table := w.builder.quoteTable(w.builder.tableName(w.table))
 
-	return builder.String()
+	switch w.builder.driver {
+	case MySQL:
+		if t.cascade {
+			err = errDriverUnsupported(w.builder.driver, "TRUNCATE CASCADE")
+
+			return
+		}
+
+		builder.WriteString("TRUNCATE ")
+		builder.WriteString(table)
+	case Postgres:
+		builder.WriteString("TRUNCATE ")
+		builder.WriteString(table)
+
+		if t.restartIdentity {
+			builder.WriteString(" RESTART IDENTITY")
+		}
+
+		if t.cascade {
+			builder.WriteString(" CASCADE")
+		}
+	case SQLServer:
+		// SQL Server always resets the IDENTITY on truncate
+		if t.cascade {
+			err = errDriverUnsupported(w.builder.driver, "TRUNCATE CASCADE")
+
+			return
+		}
+
+		builder.WriteString("TRUNCATE TABLE ")
+		builder.WriteString(table)
+	case SQLite:
+		if t.restartIdentity || t.cascade {
+			err = errDriverUnsupported(w.builder.driver, "TRUNCATE RESTART IDENTITY/CASCADE")
+
+			return
+		}
+
+		// SQLite applies the truncate optimization to `DELETE FROM` without `WHERE`
+		builder.WriteString("DELETE FROM ")
+		builder.WriteString(table)
+	default:
+		err = errDriverUnsupported(w.builder.driver, "TRUNCATE")
+
+		return
+	}
+
+	sql, _, err = w.build(ctx, StmtTruncate, builder.String(), nil)
+
+	return
+}
+
+// build runs the build hooks, then prepends the comment and transforms the bindvars according to the driver.
+func (w *queryWrapper) build(ctx context.Context, kind StmtKind, query string, args []any) (string, []any, error) {
+	if len(w.builder.hooks) != 0 {
+		stmt := &BuiltStatement{
+			Kind:  kind,
+			Table: w.table,
+			Where: w.hasWhere(),
+			SQL:   query,
+			Args:  args,
+		}
+
+		for _, fn := range w.builder.hooks {
+			if err := fn(ctx, stmt); err != nil {
+				return "", nil, err
+			}
+		}
+
+		query, args = stmt.SQL, stmt.Args
+	}
+
+	return w.comment(ctx) + sqlx.Rebind(sqlx.BindType(string(w.builder.driver)), query), args, nil
 }
 
 // QueryOption configures how we set up the SQL query statement.
@@ -678,9 +1601,25 @@ type QueryOption func(w *queryWrapper)
 func Table(name string) QueryOption {
 	return func(w *queryWrapper) {
 		w.table = name
+		w.alias = ""
+	}
+}
+
+// TableAs specifies the query table with alias, eg: yiigo.TableAs("order", "o").
+// The alias is ignored in insert and truncate statement.
+func TableAs(name, alias string) QueryOption {
+	return func(w *queryWrapper) {
+		w.table = name
+		w.alias = alias
 	}
 }
 
+// As returns the expression with alias which can be used in `Select` and `Join`,
+// eg: yiigo.As("COUNT(*)", "total"), yiigo.As("address", "a").
+func As(expr, alias string) string {
+	return expr + " AS " + alias
+}
+
 // Select specifies the query columns.
 func Select(columns ...string) QueryOption {
 	return func(w *queryWrapper) {
@@ -696,6 +1635,73 @@ func Distinct(columns ...string) QueryOption {
 	}
 }
 
+// UseIndex specifies the `use index` hint (MySQL only).
+func UseIndex(indexes ...string) QueryOption {
+	return func(w *queryWrapper) {
+		w.indexes = append(w.indexes, &indexHint{
+			keyword: "USE",
+			indexes: indexes,
+		})
+	}
+}
+
+// ForceIndex specifies the `force index` hint (MySQL only).
+func ForceIndex(indexes ...string) QueryOption {
+	return func(w *queryWrapper) {
+		w.indexes = append(w.indexes, &indexHint{
+			keyword: "FORCE",
+			indexes: indexes,
+		})
+	}
+}
+
+// IgnoreIndex specifies the `ignore index` hint (MySQL only).
+func IgnoreIndex(indexes ...string) QueryOption {
+	return func(w *queryWrapper) {
+		w.indexes = append(w.indexes, &indexHint{
+			keyword: "IGNORE",
+			indexes: indexes,
+		})
+	}
+}
+
+// OptimizerHint specifies the optimizer hint which follows the statement keyword,
+// eg: yiigo.OptimizerHint("/*+ MAX_EXECUTION_TIME(1000) */") or yiigo.OptimizerHint("MAX_EXECUTION_TIME(1000)").
+// The hint is normalized once, so the option is safe to be shared by the concurrent queries.
+func OptimizerHint(hint string) QueryOption {
+	hint = strings.TrimSpace(hint)
+
+	if !strings.HasPrefix(hint, "/*+") {
+		hint = "/*+ " + hint + " */"
+	}
+
+	return func(w *queryWrapper) {
+		w.hints = append(w.hints, hint)
+	}
+}
+
+// Comment specifies the key-value pairs of the sql comment, eg: yiigo.Comment("service", "checkout", "route", "/orders").
+// See `ContextWithSQLComment` for details.
+func Comment(kv ...string) QueryOption {
+	return func(w *queryWrapper) {
+		if w.comments == nil {
+			w.comments = make(map[string]string, len(kv)/2)
+		}
+
+		for i := 0; i+1 < len(kv); i += 2 {
+			w.comments[kv[i]] = kv[i+1]
+		}
+	}
+}
+
+// Partition specifies the partitions of the table (MySQL only), eg: SELECT * FROM t PARTITION (p202401).
+// It is applied to query, insert, update and delete statement.
+func Partition(names ...string) QueryOption {
+	return func(w *queryWrapper) {
+		w.partition = names
+	}
+}
+
 // Join specifies the `inner join` clause.
 func Join(table, on string) QueryOption {
 	return func(w *queryWrapper) {
@@ -772,10 +1778,81 @@ func WhereIn(query string, binds ...any) QueryOption {
 	}
 }
 
+// AllowFullTableMutation allows the update (delete) without `where` in the safe mode.
+func AllowFullTableMutation() QueryOption {
+	return func(w *queryWrapper) {
+		w.fullTable = true
+	}
+}
+
+// WhereNamed specifies the `where` clause with named binds (struct or map), eg:
+// yiigo.WhereNamed("status = :status AND created_at > :since", yiigo.X{"status": 1, "since": since}).
+// The slice bind is expanded like `WhereIn`, eg: id IN (:ids).
+func WhereNamed(query string, arg any) QueryOption {
+	return func(w *queryWrapper) {
+		q, binds, err := sqlx.Named(query, arg)
+
+		if err != nil {
+			w.err = fmt.Errorf("where named: %w", err)
+
+			return
+		}
+
+		w.where = &SQLClause{
+			query: q,
+			binds: binds,
+		}
+
+		for _, v := range binds {
+			if t := reflect.TypeOf(v); t != nil && t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+				w.whereIn = true
+
+				break
+			}
+		}
+	}
+}
+
+// WhereClause specifies the conditions of `where` clause, which are combined with `Where` by `AND`,
+// eg: yiigo.WhereClause(yiigo.PGAny("tags", []string{"a", "b"}), yiigo.Clause("status = ?", 1)).
+func WhereClause(clauses ...*SQLClause) QueryOption {
+	return func(w *queryWrapper) {
+		w.conds = append(w.conds, clauses...)
+	}
+}
+
 // GroupBy specifies the `group by` clause.
 func GroupBy(columns ...string) QueryOption {
 	return func(w *queryWrapper) {
 		w.groups = columns
+		w.groupMode = groupDefault
+	}
+}
+
+// GroupByRollup specifies the `group by` clause with rollup.
+// [-- MySQL] GROUP BY a, b WITH ROLLUP
+// [Postgres] GROUP BY ROLLUP (a, b)
+func GroupByRollup(columns ...string) QueryOption {
+	return func(w *queryWrapper) {
+		w.groups = columns
+		w.groupMode = groupRollup
+	}
+}
+
+// GroupByCube specifies the `group by cube` clause (Postgres only).
+func GroupByCube(columns ...string) QueryOption {
+	return func(w *queryWrapper) {
+		w.groups = columns
+		w.groupMode = groupCube
+	}
+}
+
+// GroupingSets specifies the `group by grouping sets` clause (Postgres only),
+// eg: yiigo.GroupingSets([]string{"brand", "size"}, []string{"brand"}, []string{}).
+func GroupingSets(sets ...[]string) QueryOption {
+	return func(w *queryWrapper) {
+		w.groupSets = sets
+		w.groupMode = groupSets
 	}
 }
 
@@ -824,12 +1901,9 @@ func Union(wrappers ...SQLWrapper) QueryOption {
 				w.whereIn = true
 			}
 
-			query, binds := v.subquery()
-
-			w.unions = append(w.unions, &SQLClause{
+			w.unions = append(w.unions, &unionClause{
 				keyword: "UNION",
-				query:   query,
-				binds:   binds,
+				wrapper: v,
 			})
 		}
 	}
@@ -849,12 +1923,9 @@ func UnionAll(wrappers ...SQLWrapper) QueryOption {
 				w.whereIn = true
 			}
 
-			query, binds := v.subquery()
-
-			w.unions = append(w.unions, &SQLClause{
+			w.unions = append(w.unions, &unionClause{
 				keyword: "UNION ALL",
-				query:   query,
-				binds:   binds,
+				wrapper: v,
 			})
 		}
 	}
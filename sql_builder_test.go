@@ -2,6 +2,8 @@ package yiigo
 
 import (
 	"context"
+	"database/sql"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,7 +20,7 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "SELECT * FROM user WHERE id = ?", sql)
+	assert.Equal(t, "SELECT * FROM `user` WHERE id = ?", sql)
 	assert.Equal(t, []any{1}, args)
 
 	sql, args, err = builder.Wrap(
@@ -27,7 +29,7 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "SELECT * FROM user WHERE name = ? AND age > ?", sql)
+	assert.Equal(t, "SELECT * FROM `user` WHERE name = ? AND age > ?", sql)
 	assert.Equal(t, []any{"yiigo", 20}, args)
 
 	sql, args, err = builder.Wrap(
@@ -36,7 +38,7 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "SELECT * FROM user WHERE age IN (?, ?)", sql)
+	assert.Equal(t, "SELECT * FROM `user` WHERE age IN (?, ?)", sql)
 	assert.Equal(t, []any{20, 30}, args)
 
 	sql, args, err = builder.Wrap(
@@ -46,7 +48,7 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "SELECT id, name, age FROM user WHERE id = ?", sql)
+	assert.Equal(t, "SELECT `id`, `name`, `age` FROM `user` WHERE id = ?", sql)
 	assert.Equal(t, []any{1}, args)
 
 	sql, args, err = builder.Wrap(
@@ -56,7 +58,7 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "SELECT DISTINCT name FROM user WHERE id = ?", sql)
+	assert.Equal(t, "SELECT DISTINCT `name` FROM `user` WHERE id = ?", sql)
 	assert.Equal(t, []any{1}, args)
 
 	sql, args, err = builder.Wrap(
@@ -66,7 +68,7 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "SELECT * FROM user INNER JOIN address ON user.id = address.user_id WHERE user.id = ?", sql)
+	assert.Equal(t, "SELECT * FROM `user` INNER JOIN `address` ON user.id = address.user_id WHERE user.id = ?", sql)
 	assert.Equal(t, []any{1}, args)
 
 	sql, args, err = builder.Wrap(
@@ -76,7 +78,7 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "SELECT * FROM user LEFT JOIN address ON user.id = address.user_id WHERE user.id = ?", sql)
+	assert.Equal(t, "SELECT * FROM `user` LEFT JOIN `address` ON user.id = address.user_id WHERE user.id = ?", sql)
 	assert.Equal(t, []any{1}, args)
 
 	sql, args, err = builder.Wrap(
@@ -86,7 +88,7 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "SELECT * FROM user RIGHT JOIN address ON user.id = address.user_id WHERE user.id = ?", sql)
+	assert.Equal(t, "SELECT * FROM `user` RIGHT JOIN `address` ON user.id = address.user_id WHERE user.id = ?", sql)
 	assert.Equal(t, []any{1}, args)
 
 	sql, args, err = builder.Wrap(
@@ -96,7 +98,7 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "SELECT * FROM user FULL JOIN address ON user.id = address.user_id WHERE user.id = ?", sql)
+	assert.Equal(t, "SELECT * FROM `user` FULL JOIN `address` ON user.id = address.user_id WHERE user.id = ?", sql)
 	assert.Equal(t, []any{1}, args)
 
 	sql, _, err = builder.Wrap(
@@ -105,7 +107,7 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "SELECT * FROM sizes CROSS JOIN colors", sql)
+	assert.Equal(t, "SELECT * FROM `sizes` CROSS JOIN `colors`", sql)
 
 	sql, args, err = builder.Wrap(
 		Table("user"),
@@ -115,7 +117,7 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "SELECT * FROM user LEFT JOIN address ON user.id = address.user_id RIGHT JOIN company ON user.id = company.user_id WHERE user.id = ?", sql)
+	assert.Equal(t, "SELECT * FROM `user` LEFT JOIN `address` ON user.id = address.user_id RIGHT JOIN `company` ON user.id = company.user_id WHERE user.id = ?", sql)
 	assert.Equal(t, []any{1}, args)
 
 	sql, args, err = builder.Wrap(
@@ -126,7 +128,7 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "SELECT user_id, COUNT(*) AS total FROM address GROUP BY user_id HAVING user_id = ?", sql)
+	assert.Equal(t, "SELECT `user_id`, COUNT(*) AS `total` FROM `address` GROUP BY `user_id` HAVING user_id = ?", sql)
 	assert.Equal(t, []any{1}, args)
 
 	sql, args, err = builder.Wrap(
@@ -138,7 +140,7 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "SELECT * FROM user WHERE age > ? ORDER BY age ASC, id DESC LIMIT ? OFFSET ?", sql)
+	assert.Equal(t, "SELECT * FROM `user` WHERE age > ? ORDER BY `age` ASC, `id` DESC LIMIT ? OFFSET ?", sql)
 	assert.Equal(t, []any{20, 10, 5}, args)
 
 	sql, args, err = builder.Wrap(
@@ -148,7 +150,7 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "(SELECT * FROM user_0 WHERE id = ?) UNION (SELECT * FROM user_1 WHERE id = ?)", sql)
+	assert.Equal(t, "(SELECT * FROM `user_0` WHERE id = ?) UNION (SELECT * FROM `user_1` WHERE id = ?)", sql)
 	assert.Equal(t, []any{1, 2}, args)
 
 	sql, args, err = builder.Wrap(
@@ -158,7 +160,7 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "(SELECT * FROM user_0 WHERE id = ?) UNION ALL (SELECT * FROM user_1 WHERE id = ?)", sql)
+	assert.Equal(t, "(SELECT * FROM `user_0` WHERE id = ?) UNION ALL (SELECT * FROM `user_1` WHERE id = ?)", sql)
 	assert.Equal(t, []any{1, 2}, args)
 
 	sql, args, err = builder.Wrap(
@@ -175,7 +177,7 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "(SELECT * FROM user_0 WHERE age IN (?, ?) LIMIT ?) UNION (SELECT * FROM user_1 WHERE age IN (?, ?) LIMIT ?)", sql)
+	assert.Equal(t, "(SELECT * FROM `user_0` WHERE age IN (?, ?) LIMIT ?) UNION (SELECT * FROM `user_1` WHERE age IN (?, ?) LIMIT ?)", sql)
 	assert.Equal(t, []any{10, 20, 5, 30, 40, 5}, args)
 
 	sql, args, err = builder.Wrap(
@@ -186,10 +188,382 @@ func TestToQuery(t *testing.T) {
 	).ToQuery(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "(SELECT * FROM user_0 WHERE id = ?) UNION (SELECT * FROM user_1 WHERE id = ?) UNION ALL (SELECT * FROM user_2 WHERE id = ?)", sql)
+	assert.Equal(t, "(SELECT * FROM `user_0` WHERE id = ?) UNION (SELECT * FROM `user_1` WHERE id = ?) UNION ALL (SELECT * FROM `user_2` WHERE id = ?)", sql)
 	assert.Equal(t, []any{1, 2, 3}, args)
 }
 
+func TestOver(t *testing.T) {
+	assert.Equal(t, "ROW_NUMBER() OVER ()", Over("ROW_NUMBER()"))
+	assert.Equal(t, "ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at DESC)", Over("ROW_NUMBER()", PartitionBy("user_id"), OrderByDesc("created_at")))
+	assert.Equal(t, "SUM(amount) OVER (ORDER BY id ASC ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW)", Over("SUM(amount)", OrderByAsc("id"), Frame("ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW")))
+
+	sql, args, err := NewMySQLBuilder().Wrap(
+		Table("order"),
+		Select("id", "user_id", Over("ROW_NUMBER()", PartitionBy("user_id"), OrderByDesc("created_at"))+" AS rn"),
+		Where("status = ?", 1),
+	).ToQuery(context.TODO())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT `id`, `user_id`, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at DESC) AS `rn` FROM `order` WHERE status = ?", sql)
+	assert.Equal(t, []any{1}, args)
+}
+
+func TestGroupByRollup(t *testing.T) {
+	ctx := context.TODO()
+
+	sql, _, err := NewMySQLBuilder().Wrap(
+		Table("sales"),
+		Select("brand", "size", "SUM(amount) AS total"),
+		GroupByRollup("brand", "size"),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT `brand`, `size`, SUM(amount) AS `total` FROM `sales` GROUP BY `brand`, `size` WITH ROLLUP", sql)
+
+	sql, _, err = NewPGSQLBuilder().Wrap(
+		Table("sales"),
+		Select("brand", "size", "SUM(amount) AS total"),
+		GroupByRollup("brand", "size"),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT "brand", "size", SUM(amount) AS "total" FROM "sales" GROUP BY ROLLUP ("brand", "size")`, sql)
+
+	sql, _, err = NewPGSQLBuilder().Wrap(
+		Table("sales"),
+		Select("brand", "size", "SUM(amount) AS total"),
+		GroupByCube("brand", "size"),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT "brand", "size", SUM(amount) AS "total" FROM "sales" GROUP BY CUBE ("brand", "size")`, sql)
+
+	sql, _, err = NewPGSQLBuilder().Wrap(
+		Table("sales"),
+		Select("brand", "size", "SUM(amount) AS total"),
+		GroupingSets([]string{"brand", "size"}, []string{"brand"}, []string{}),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT "brand", "size", SUM(amount) AS "total" FROM "sales" GROUP BY GROUPING SETS (("brand", "size"), ("brand"), ())`, sql)
+
+	_, _, err = NewMySQLBuilder().Wrap(
+		Table("sales"),
+		GroupByCube("brand", "size"),
+	).ToQuery(ctx)
+
+	assert.ErrorIs(t, err, ErrDriverUnsupported)
+
+	_, _, err = NewSQLiteBuilder().Wrap(
+		Table("sales"),
+		GroupByRollup("brand", "size"),
+	).ToQuery(ctx)
+
+	assert.ErrorIs(t, err, ErrDriverUnsupported)
+}
+
+func TestQuote(t *testing.T) {
+	ctx := context.TODO()
+
+	sql, args, err := NewPGSQLBuilder().Wrap(
+		Table("order AS o"),
+		Select("o.*", "u.userName", "COUNT(*) AS total"),
+		LeftJoin("user u", "u.id = o.user_id"),
+		Where("o.status = ?", 1),
+		GroupBy("o.id", "u.userName"),
+		OrderBy("o.id DESC NULLS LAST", "LENGTH(u.userName)"),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT "o".*, "u"."userName", COUNT(*) AS "total" FROM "order" AS "o" LEFT JOIN "user" AS "u" ON u.id = o.user_id WHERE o.status = $1 GROUP BY "o"."id", "u"."userName" ORDER BY "o"."id" DESC NULLS LAST, LENGTH(u.userName)`, sql)
+	assert.Equal(t, []any{1}, args)
+
+	sql, args, err = NewSQLiteBuilder().Wrap(
+		Table("group"),
+		Where("id = ?", 1),
+	).ToUpdate(ctx, X{"order": 1})
+
+	assert.Nil(t, err)
+	assert.Equal(t, `UPDATE "group" SET "order" = ? WHERE id = ?`, sql)
+	assert.Equal(t, []any{1, 1}, args)
+
+	sql, args, err = NewMySQLBuilder(WithoutQuote()).Wrap(
+		Table("user"),
+		Select("id", "name"),
+		Where("id = ?", 1),
+		OrderBy("id DESC"),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT id, name FROM user WHERE id = ? ORDER BY id DESC", sql)
+	assert.Equal(t, []any{1}, args)
+}
+
+func TestStrictIdent(t *testing.T) {
+	ctx := context.TODO()
+
+	builder := NewMySQLBuilder(WithStrictIdent())
+
+	sql, args, err := builder.Wrap(
+		Table("user AS u"),
+		Where("u.age > ?", 20),
+		GroupBy("u.age"),
+		OrderBy("u.age DESC"),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM `user` AS `u` WHERE u.age > ? GROUP BY `u`.`age` ORDER BY `u`.`age` DESC", sql)
+	assert.Equal(t, []any{20}, args)
+
+	_, _, err = builder.Wrap(
+		Table("user"),
+		OrderBy("(CASE WHEN 1=1 THEN id ELSE name END)"),
+	).ToQuery(ctx)
+
+	assert.ErrorIs(t, err, ErrInvalidIdent)
+
+	_, _, err = builder.Wrap(
+		Table("user; DROP TABLE user"),
+	).ToDelete(ctx)
+
+	assert.ErrorIs(t, err, ErrInvalidIdent)
+
+	_, _, err = builder.Wrap(
+		Table("user"),
+		Where("id = ?", 1),
+	).ToUpdate(ctx, X{"name = 'x', age": 1})
+
+	assert.ErrorIs(t, err, ErrInvalidIdent)
+
+	builder = NewMySQLBuilder(WithStrictIdent("user", "id", "age"))
+
+	_, _, err = builder.Wrap(
+		Table("user"),
+		OrderBy("age DESC"),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+
+	_, _, err = builder.Wrap(
+		Table("user"),
+		OrderBy("password DESC"),
+	).ToQuery(ctx)
+
+	assert.ErrorIs(t, err, ErrInvalidIdent)
+
+	// each segment of the qualified identifier is checked
+	_, _, err = builder.Wrap(
+		Table("user"),
+		OrderBy("password.age DESC"),
+	).ToQuery(ctx)
+
+	assert.ErrorIs(t, err, ErrInvalidIdent)
+
+	// the alias is not whitelisted implicitly
+	_, _, err = builder.Wrap(
+		TableAs("user", "u"),
+		OrderBy("u.age DESC"),
+	).ToQuery(ctx)
+
+	assert.ErrorIs(t, err, ErrInvalidIdent)
+
+	_, _, err = NewMySQLBuilder(WithStrictIdent("user", "u", "age", "user.id")).Wrap(
+		TableAs("user", "u"),
+		GroupBy("user.id"),
+		OrderBy("u.age DESC"),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+}
+
+func TestAlias(t *testing.T) {
+	ctx := context.TODO()
+
+	builder := NewMySQLBuilder()
+
+	sql, args, err := builder.Wrap(
+		TableAs("order", "o"),
+		Select("o.user_id", As("COUNT(*)", "total"), As(Over("ROW_NUMBER()", OrderByDesc("o.id")), "rn")),
+		Join(As("user", "u"), "u.id = o.user_id"),
+		Where("o.status = ?", 1),
+		GroupBy("o.user_id"),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT `o`.`user_id`, COUNT(*) AS `total`, ROW_NUMBER() OVER (ORDER BY o.id DESC) AS `rn` FROM `order` AS `o` INNER JOIN `user` AS `u` ON u.id = o.user_id WHERE o.status = ? GROUP BY `o`.`user_id`", sql)
+	assert.Equal(t, []any{1}, args)
+
+	sql, args, err = builder.Wrap(
+		TableAs("order", "o"),
+		Where("o.id = ?", 1),
+	).ToDelete(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE FROM `order` AS `o` WHERE o.id = ?", sql)
+	assert.Equal(t, []any{1}, args)
+
+	_, _, err = NewMySQLBuilder(WithStrictIdent()).Wrap(
+		TableAs("order", "o o"),
+	).ToQuery(ctx)
+
+	assert.ErrorIs(t, err, ErrInvalidIdent)
+}
+
+func TestTablePrefix(t *testing.T) {
+	ctx := context.TODO()
+
+	builder := NewPGSQLBuilder(WithTablePrefix("t_"), WithSchema("tenant"))
+
+	sql, args, err := builder.Wrap(
+		TableAs("order", "o"),
+		Join("user AS u", "u.id = o.user_id"),
+		LeftJoin("public.address", "address.user_id = u.id"),
+		Where("o.id = ?", 1),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM "tenant"."t_order" AS "o" INNER JOIN "tenant"."t_user" AS "u" ON u.id = o.user_id LEFT JOIN "public"."address" ON address.user_id = u.id WHERE o.id = $1`, sql)
+	assert.Equal(t, []any{1}, args)
+
+	sql, args, err = builder.Wrap(Table("user")).ToInsert(ctx, X{"name": "yiigo"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, `INSERT INTO "tenant"."t_user" ("name") VALUES ($1) RETURNING "id"`, sql)
+	assert.Equal(t, []any{"yiigo"}, args)
+
+	sql, _, err = NewMySQLBuilder(WithTablePrefix("t_")).Wrap(
+		Table("user"),
+		Where("id = ?", 1),
+	).ToDelete(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE FROM `t_user` WHERE id = ?", sql)
+}
+
+func TestHint(t *testing.T) {
+	ctx := context.TODO()
+
+	builder := NewMySQLBuilder()
+
+	sql, args, err := builder.Wrap(
+		TableAs("order", "o"),
+		OptimizerHint("/*+ MAX_EXECUTION_TIME(1000) */"),
+		UseIndex("idx_user"),
+		IgnoreIndex("idx_status", "idx_created"),
+		Where("o.user_id = ?", 1),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT /*+ MAX_EXECUTION_TIME(1000) */ * FROM `order` AS `o` USE INDEX (`idx_user`) IGNORE INDEX (`idx_status`, `idx_created`) WHERE o.user_id = ?", sql)
+	assert.Equal(t, []any{1}, args)
+
+	sql, args, err = builder.Wrap(
+		Table("order"),
+		OptimizerHint("NO_INDEX_MERGE(order)"),
+		ForceIndex("idx_user"),
+		Where("user_id = ?", 1),
+	).ToUpdate(ctx, X{"status": 2})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE /*+ NO_INDEX_MERGE(order) */ `order` FORCE INDEX (`idx_user`) SET `status` = ? WHERE user_id = ?", sql)
+	assert.Equal(t, []any{2, 1}, args)
+
+	_, _, err = NewPGSQLBuilder().Wrap(
+		Table("order"),
+		UseIndex("idx_user"),
+	).ToQuery(ctx)
+
+	assert.ErrorIs(t, err, ErrDriverUnsupported)
+
+	// the shared option by the concurrent queries
+	hint := OptimizerHint("MAX_EXECUTION_TIME(1000)")
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sql, _, err := builder.Wrap(Table("order"), hint).ToQuery(ctx)
+
+			assert.Nil(t, err)
+			assert.Equal(t, "SELECT /*+ MAX_EXECUTION_TIME(1000) */ * FROM `order`", sql)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestComment(t *testing.T) {
+	ctx := ContextWithSQLComment(context.TODO(), "service", "checkout", "trace_id", "4bf92f3577b34da6")
+
+	builder := NewMySQLBuilder()
+
+	sql, args, err := builder.Wrap(
+		Table("order"),
+		Comment("route", "/orders/{id}", "service", "order's"),
+		Where("id = ?", 1),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/*route='%2Forders%2F%7Bid%7D',service='order%27s',trace_id='4bf92f3577b34da6'*/ SELECT * FROM `order` WHERE id = ?", sql)
+	assert.Equal(t, []any{1}, args)
+
+	sql, args, err = builder.Wrap(
+		Table("order"),
+		Where("id = ?", 1),
+	).ToDelete(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/*service='checkout',trace_id='4bf92f3577b34da6'*/ DELETE FROM `order` WHERE id = ?", sql)
+	assert.Equal(t, []any{1}, args)
+}
+
+func TestPartition(t *testing.T) {
+	ctx := context.TODO()
+
+	builder := NewMySQLBuilder()
+
+	sql, args, err := builder.Wrap(
+		TableAs("order", "o"),
+		Partition("p202401", "p202402"),
+		UseIndex("idx_user"),
+		Where("o.user_id = ?", 1),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM `order` PARTITION (`p202401`, `p202402`) AS `o` USE INDEX (`idx_user`) WHERE o.user_id = ?", sql)
+	assert.Equal(t, []any{1}, args)
+
+	sql, args, err = builder.Wrap(
+		Table("order"),
+		Partition("p202401"),
+	).ToInsert(ctx, X{"user_id": 1})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `order` PARTITION (`p202401`) (`user_id`) VALUES (?)", sql)
+	assert.Equal(t, []any{1}, args)
+
+	sql, args, err = builder.Wrap(
+		Table("order"),
+		Partition("p202401"),
+		Where("id = ?", 1),
+	).ToDelete(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE FROM `order` PARTITION (`p202401`) WHERE id = ?", sql)
+	assert.Equal(t, []any{1}, args)
+
+	_, _, err = NewPGSQLBuilder().Wrap(
+		Table("order"),
+		Partition("p202401"),
+	).ToQuery(ctx)
+
+	assert.ErrorIs(t, err, ErrDriverUnsupported)
+}
+
 func TestToInsert(t *testing.T) {
 	ctx := context.TODO()
 
@@ -210,7 +584,7 @@ func TestToInsert(t *testing.T) {
 	})
 
 	assert.Nil(t, err)
-	assert.Equal(t, "INSERT INTO user (name, gender, age) VALUES (?, ?, ?)", sql)
+	assert.Equal(t, "INSERT INTO `user` (`name`, `gender`, `age`) VALUES (?, ?, ?)", sql)
 	assert.Equal(t, []any{"yiigo", "M", 29}, args)
 
 	sql, args, err = builder.Wrap(Table("user")).ToInsert(ctx, &User{
@@ -221,18 +595,199 @@ func TestToInsert(t *testing.T) {
 	})
 
 	assert.Nil(t, err)
-	assert.Equal(t, "INSERT INTO user (name, gender, age, phone) VALUES (?, ?, ?, ?)", sql)
+	assert.Equal(t, "INSERT INTO `user` (`name`, `gender`, `age`, `phone`) VALUES (?, ?, ?, ?)", sql)
 	assert.Equal(t, []any{"yiigo", "M", 29, "13605109425"}, args)
 
-	// map 字段顺序不一定
-	// sql, args, err = builder.Wrap(Table("user")).ToInsert(ctx, X{
-	// 	"age":    29,
-	// 	"gender": "M",
-	// 	"name":   "yiigo",
-	// })
-	//
-	// assert.Equal(t, "INSERT INTO user (age, gender, name) VALUES (?, ?, ?)", sql)
-	// assert.Equal(t, []any{29, "M", "yiigo"}, args)
+	sql, args, err = builder.Wrap(Table("user")).ToInsert(ctx, X{
+		"age":    29,
+		"gender": "M",
+		"name":   "yiigo",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `user` (`age`, `gender`, `name`) VALUES (?, ?, ?)", sql)
+	assert.Equal(t, []any{29, "M", "yiigo"}, args)
+}
+
+func TestInsertDefaultValues(t *testing.T) {
+	ctx := context.TODO()
+
+	sql, args, err := NewMySQLBuilder().Wrap(Table("user")).ToInsert(ctx, X{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `user` () VALUES ()", sql)
+	assert.Equal(t, 0, len(args))
+
+	sql, _, err = NewPGSQLBuilder().Wrap(Table("user")).ToInsert(ctx, X{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, `INSERT INTO "user" DEFAULT VALUES RETURNING "id"`, sql)
+
+	type Log struct {
+		ID int64 `db:"id,omitempty"`
+	}
+
+	sql, _, err = NewSQLiteBuilder().Wrap(Table("log")).ToInsert(ctx, &Log{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, `INSERT INTO "log" DEFAULT VALUES`, sql)
+
+	// batch insert
+	sql, _, err = NewMySQLBuilder().Wrap(Table("log")).ToBatchInsert(ctx, []X{{}, {}})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `log` () VALUES (), ()", sql)
+
+	sql, _, err = NewMSSQLBuilder().Wrap(Table("log")).ToBatchInsert(ctx, []X{{}})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO [log] DEFAULT VALUES", sql)
+
+	_, _, err = NewSQLiteBuilder().Wrap(Table("log")).ToBatchInsert(ctx, []*Log{{}, {}})
+
+	assert.ErrorIs(t, err, ErrDriverUnsupported)
+}
+
+func TestEmbeddedStruct(t *testing.T) {
+	ctx := context.TODO()
+
+	builder := NewMySQLBuilder()
+
+	type Model struct {
+		ID        int64 `db:"id,omitempty"`
+		CreatedAt int64 `db:"created_at"`
+	}
+
+	type Extra struct {
+		Remark string `db:"remark"`
+	}
+
+	type User struct {
+		Model
+		*Extra
+		Name   string `db:"name"`
+		Age    int    `db:"age"`
+		secret string
+	}
+
+	sql, args, err := builder.Wrap(Table("user")).ToInsert(ctx, &User{
+		Model: Model{CreatedAt: 1680000000},
+		Name:  "yiigo",
+		Age:   29,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `user` (`created_at`, `name`, `age`) VALUES (?, ?, ?)", sql)
+	assert.Equal(t, []any{int64(1680000000), "yiigo", 29}, args)
+
+	sql, args, err = builder.Wrap(
+		Table("user"),
+		Where("id = ?", 1),
+	).ToUpdate(ctx, &User{
+		Model: Model{ID: 1, CreatedAt: 1680000000},
+		Extra: &Extra{Remark: "vip"},
+		Name:  "yiigo",
+		Age:   29,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE `user` SET `id` = ?, `created_at` = ?, `remark` = ?, `name` = ?, `age` = ? WHERE id = ?", sql)
+	assert.Equal(t, []any{int64(1), int64(1680000000), "vip", "yiigo", 29, 1}, args)
+
+	type Admin struct {
+		User
+		Name string `db:"name"`
+	}
+
+	sql, args, err = builder.Wrap(Table("admin")).ToInsert(ctx, &Admin{
+		User: User{Name: "hidden", Age: 30},
+		Name: "root",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `admin` (`created_at`, `age`, `name`) VALUES (?, ?, ?)", sql)
+	assert.Equal(t, []any{int64(0), 30, "root"}, args)
+}
+
+func TestNullValue(t *testing.T) {
+	ctx := context.TODO()
+
+	builder := NewMySQLBuilder()
+
+	type User struct {
+		Name    string         `db:"name"`
+		Age     *int           `db:"age,omitnil"`
+		Phone   *string        `db:"phone"`
+		Remark  sql.NullString `db:"remark"`
+		Balance sql.NullInt64  `db:"balance,omitnil"`
+		Status  int            `db:"status,omitnil"`
+	}
+
+	query, args, err := builder.Wrap(
+		Table("user"),
+		Where("id = ?", 1),
+	).ToUpdate(ctx, &User{Name: "yiigo"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE `user` SET `name` = ?, `phone` = ?, `remark` = ?, `status` = ? WHERE id = ?", query)
+	assert.Equal(t, []any{"yiigo", nil, nil, 0, 1}, args)
+
+	age := 29
+
+	query, args, err = builder.Wrap(Table("user")).ToInsert(ctx, &User{
+		Name:    "yiigo",
+		Age:     &age,
+		Balance: sql.NullInt64{Int64: 100, Valid: true},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `user` (`name`, `age`, `phone`, `remark`, `balance`, `status`) VALUES (?, ?, ?, ?, ?, ?)", query)
+	assert.Equal(t, []any{"yiigo", &age, nil, nil, sql.NullInt64{Int64: 100, Valid: true}, 0}, args)
+
+	query, args, err = builder.Wrap(
+		Table("user"),
+		Where("id = ?", 1),
+	).ToUpdate(ctx, X{"phone": (*string)(nil)})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE `user` SET `phone` = ? WHERE id = ?", query)
+	assert.Equal(t, []any{nil, 1}, args)
+}
+
+func TestPlainMap(t *testing.T) {
+	ctx := context.TODO()
+
+	builder := NewMySQLBuilder()
+
+	query, args, err := builder.Wrap(Table("user")).ToInsert(ctx, map[string]any{"name": "yiigo"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `user` (`name`) VALUES (?)", query)
+	assert.Equal(t, []any{"yiigo"}, args)
+
+	query, args, err = builder.Wrap(
+		Table("user"),
+		Where("id = ?", 1),
+	).ToUpdate(ctx, &map[string]string{"name": "yiigo"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE `user` SET `name` = ? WHERE id = ?", query)
+	assert.Equal(t, []any{"yiigo", 1}, args)
+
+	query, args, err = builder.Wrap(Table("user")).ToBatchInsert(ctx, []map[string]any{
+		{"name": "yiigo"},
+		{"name": "test"},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `user` (`name`) VALUES (?), (?)", query)
+	assert.Equal(t, []any{"yiigo", "test"}, args)
+
+	_, _, err = builder.Wrap(Table("user")).ToInsert(ctx, map[int]any{1: "yiigo"})
+	assert.ErrorIs(t, err, ErrUpsertData)
+
+	_, _, err = builder.Wrap(Table("user")).ToBatchInsert(ctx, []map[int]any{{1: "yiigo"}})
+	assert.ErrorIs(t, err, ErrBatchInsertData)
 }
 
 func TestToBatchInsert(t *testing.T) {
@@ -262,7 +817,7 @@ func TestToBatchInsert(t *testing.T) {
 	})
 
 	assert.Nil(t, err)
-	assert.Equal(t, "INSERT INTO user (name, gender, age) VALUES (?, ?, ?), (?, ?, ?)", sql)
+	assert.Equal(t, "INSERT INTO `user` (`name`, `gender`, `age`) VALUES (?, ?, ?), (?, ?, ?)", sql)
 	assert.Equal(t, []any{"yiigo", "M", 29, "test", "W", 20}, args)
 
 	sql, args, err = builder.Wrap(Table("user")).ToBatchInsert(ctx, []*User{
@@ -281,25 +836,59 @@ func TestToBatchInsert(t *testing.T) {
 	})
 
 	assert.Nil(t, err)
-	assert.Equal(t, "INSERT INTO user (name, gender, age, phone) VALUES (?, ?, ?, ?), (?, ?, ?, ?)", sql)
+	assert.Equal(t, "INSERT INTO `user` (`name`, `gender`, `age`, `phone`) VALUES (?, ?, ?, ?), (?, ?, ?, ?)", sql)
 	assert.Equal(t, []any{"yiigo", "M", 29, "13605109425", "test", "W", 20, "13605105471"}, args)
 
-	// map 字段顺序不一定
-	// sql, args, err = builder.Wrap(Table("user")).ToBatchInsert(ctx, []X{
-	// 	{
-	// 		"age":    29,
-	// 		"gender": "M",
-	// 		"name":   "yiigo",
-	// 	},
-	// 	{
-	// 		"age":    20,
-	// 		"gender": "W",
-	// 		"name":   "test",
-	// 	},
-	// })
-	//
-	// assert.Equal(t, "INSERT INTO user (age, gender, name) VALUES (?, ?, ?), (?, ?, ?)", sql)
-	// assert.Equal(t, []any{29, "M", "yiigo", 20, "W", "test"}, args)
+	// ragged rows
+	sql, args, err = builder.Wrap(Table("user")).ToBatchInsert(ctx, []*User{
+		{
+			Name:   "yiigo",
+			Gender: "M",
+			Age:    29,
+		},
+		{
+			Name:   "test",
+			Gender: "W",
+			Age:    20,
+			Phone:  "13605105471",
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `user` (`name`, `gender`, `age`, `phone`) VALUES (?, ?, ?, ?), (?, ?, ?, ?)", sql)
+	assert.Equal(t, []any{"yiigo", "M", 29, "", "test", "W", 20, "13605105471"}, args)
+
+	_, _, err = builder.Wrap(Table("user")).ToBatchInsert(ctx, []*User{{Name: "yiigo"}, nil})
+	assert.ErrorIs(t, err, ErrBatchInsertData)
+
+	_, _, err = builder.Wrap(Table("user")).ToBatchInsert(ctx, []X{
+		{"name": "yiigo", "age": 29},
+		{"name": "test", "phone": "13605105471"},
+	})
+	assert.ErrorIs(t, err, ErrBatchInsertColumns)
+
+	_, _, err = builder.Wrap(Table("user")).ToBatchInsert(ctx, []X{
+		{"name": "yiigo"},
+		{"name": "test", "age": 20},
+	})
+	assert.ErrorIs(t, err, ErrBatchInsertColumns)
+
+	sql, args, err = builder.Wrap(Table("user")).ToBatchInsert(ctx, []X{
+		{
+			"age":    29,
+			"gender": "M",
+			"name":   "yiigo",
+		},
+		{
+			"age":    20,
+			"gender": "W",
+			"name":   "test",
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `user` (`age`, `gender`, `name`) VALUES (?, ?, ?), (?, ?, ?)", sql)
+	assert.Equal(t, []any{29, "M", "yiigo", 20, "W", "test"}, args)
 }
 
 func TestToUpdate(t *testing.T) {
@@ -324,7 +913,7 @@ func TestToUpdate(t *testing.T) {
 	})
 
 	assert.Nil(t, err)
-	assert.Equal(t, "UPDATE user SET name = ?, gender = ?, age = ? WHERE id = ?", sql)
+	assert.Equal(t, "UPDATE `user` SET `name` = ?, `gender` = ?, `age` = ? WHERE id = ?", sql)
 	assert.Equal(t, []any{"yiigo", "M", 29, 1}, args)
 
 	sql, args, err = builder.Wrap(
@@ -338,21 +927,21 @@ func TestToUpdate(t *testing.T) {
 	})
 
 	assert.Nil(t, err)
-	assert.Equal(t, "UPDATE user SET name = ?, gender = ?, age = ?, phone = ? WHERE id = ?", sql)
+	assert.Equal(t, "UPDATE `user` SET `name` = ?, `gender` = ?, `age` = ?, `phone` = ? WHERE id = ?", sql)
 	assert.Equal(t, []any{"yiigo", "M", 29, "13605109425", 1}, args)
 
-	// map 字段顺序不一定
-	// sql, args, err = builder.Wrap(
-	// 	Table("user"),
-	// 	Where("id = ?", 1),
-	// ).ToUpdate(ctx, X{
-	// 	"age":    29,
-	// 	"gender": "M",
-	// 	"name":   "yiigo",
-	// })
-	//
-	// assert.Equal(t, "UPDATE user SET age = ?, gender = ?, name = ? WHERE id = ?", sql)
-	// assert.Equal(t, []any{29, "M", "yiigo", 1}, args)
+	sql, args, err = builder.Wrap(
+		Table("user"),
+		Where("id = ?", 1),
+	).ToUpdate(ctx, X{
+		"age":    29,
+		"gender": "M",
+		"name":   "yiigo",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE `user` SET `age` = ?, `gender` = ?, `name` = ? WHERE id = ?", sql)
+	assert.Equal(t, []any{29, "M", "yiigo", 1}, args)
 
 	sql, args, err = builder.Wrap(
 		Table("user"),
@@ -364,7 +953,7 @@ func TestToUpdate(t *testing.T) {
 	})
 
 	assert.Nil(t, err)
-	assert.Equal(t, "UPDATE user SET name = ?, gender = ?, age = ? WHERE id IN (?, ?)", sql)
+	assert.Equal(t, "UPDATE `user` SET `name` = ?, `gender` = ?, `age` = ? WHERE id IN (?, ?)", sql)
 	assert.Equal(t, []any{"yiigo", "M", 29, 1, 2}, args)
 
 	sql, args, err = builder.Wrap(
@@ -373,7 +962,7 @@ func TestToUpdate(t *testing.T) {
 	).ToUpdate(ctx, X{"price": Clause("price * ? + ?", 2, 100)})
 
 	assert.Nil(t, err)
-	assert.Equal(t, "UPDATE product SET price = price * ? + ? WHERE id = ?", sql)
+	assert.Equal(t, "UPDATE `product` SET `price` = price * ? + ? WHERE id = ?", sql)
 	assert.Equal(t, []any{2, 100, 1}, args)
 }
 
@@ -388,7 +977,7 @@ func TestToDelete(t *testing.T) {
 	).ToDelete(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "DELETE FROM user WHERE id = ?", sql)
+	assert.Equal(t, "DELETE FROM `user` WHERE id = ?", sql)
 	assert.Equal(t, []any{1}, args)
 
 	sql, args, err = builder.Wrap(
@@ -397,12 +986,217 @@ func TestToDelete(t *testing.T) {
 	).ToDelete(ctx)
 
 	assert.Nil(t, err)
-	assert.Equal(t, "DELETE FROM user WHERE id IN (?, ?)", sql)
+	assert.Equal(t, "DELETE FROM `user` WHERE id IN (?, ?)", sql)
 	assert.Equal(t, []any{1, 2}, args)
 }
 
 func TestToTruncate(t *testing.T) {
+	ctx := context.TODO()
+
+	sql, err := NewMySQLBuilder().Wrap(Table("user")).ToTruncate(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "TRUNCATE `user`", sql)
+
+	_, err = NewMySQLBuilder().Wrap(Table("user")).ToTruncate(ctx, Cascade())
+	assert.ErrorIs(t, err, ErrDriverUnsupported)
+
+	sql, err = NewPGSQLBuilder().Wrap(Table("user")).ToTruncate(ctx, RestartIdentity(), Cascade())
+
+	assert.Nil(t, err)
+	assert.Equal(t, `TRUNCATE "user" RESTART IDENTITY CASCADE`, sql)
+
+	sql, err = NewSQLiteBuilder().Wrap(Table("user")).ToTruncate(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `DELETE FROM "user"`, sql)
+
+	_, err = NewSQLiteBuilder().Wrap(Table("user")).ToTruncate(ctx, RestartIdentity())
+	assert.ErrorIs(t, err, ErrDriverUnsupported)
+}
+
+func TestClone(t *testing.T) {
+	ctx := context.TODO()
+
+	base := NewMySQLBuilder().Wrap(
+		Table("user"),
+		WhereClause(Clause("status = ?", 1)),
+		Comment("route", "/users"),
+	)
+
+	sql, args, err := base.Clone(WhereClause(Clause("age > ?", 20)), OrderBy("id DESC"), Limit(10)).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/*route='%2Fusers'*/ SELECT * FROM `user` WHERE (status = ?) AND (age > ?) ORDER BY `id` DESC LIMIT ?", sql)
+	assert.Equal(t, []any{1, 20, 10}, args)
+
+	sql, args, err = base.Clone(WhereClause(Clause("gender = ?", "M")), Comment("route", "/men")).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/*route='%2Fmen'*/ SELECT * FROM `user` WHERE (status = ?) AND (gender = ?)", sql)
+	assert.Equal(t, []any{1, "M"}, args)
+
+	sql, args, err = base.ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/*route='%2Fusers'*/ SELECT * FROM `user` WHERE status = ?", sql)
+	assert.Equal(t, []any{1}, args)
+}
+
+func BenchmarkToQuery(b *testing.B) {
+	ctx := context.TODO()
+
+	builder := NewMySQLBuilder()
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, _, _ = builder.Wrap(
+			Table("user"),
+			Select("id", "name", "age"),
+			Where("age > ? AND status = ?", 20, 1),
+			OrderBy("id DESC"),
+			Offset(10),
+			Limit(10),
+		).ToQuery(ctx)
+	}
+}
+
+func BenchmarkToUpdate(b *testing.B) {
+	ctx := context.TODO()
+
+	builder := NewMySQLBuilder()
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, _, _ = builder.Wrap(
+			Table("user"),
+			Where("id = ?", 1),
+		).ToUpdate(ctx, X{"name": "yiigo", "age": 29, "gender": "M"})
+	}
+}
+
+func BenchmarkToBatchInsert(b *testing.B) {
+	ctx := context.TODO()
+
+	builder := NewMySQLBuilder()
+
+	type User struct {
+		ID     int64  `db:"id,omitempty"`
+		Name   string `db:"name"`
+		Gender string `db:"gender"`
+		Age    int    `db:"age"`
+		Phone  string `db:"phone,omitempty"`
+	}
+
+	users := make([]*User, 0, 100)
+
+	for i := 0; i < 100; i++ {
+		users = append(users, &User{Name: "yiigo", Gender: "M", Age: 29})
+	}
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, _, _ = builder.Wrap(Table("user")).ToBatchInsert(ctx, users)
+	}
+}
+
+func TestWhereNamed(t *testing.T) {
+	ctx := context.TODO()
+
+	sql, args, err := NewPGSQLBuilder().Wrap(
+		Table("user"),
+		WhereNamed("status = :status AND age > :age", X{"status": 1, "age": 20}),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM "user" WHERE status = $1 AND age > $2`, sql)
+	assert.Equal(t, []any{1, 20}, args)
+
+	type Params struct {
+		IDs    []int `db:"ids"`
+		Status int   `db:"status"`
+	}
+
+	sql, args, err = NewMySQLBuilder().Wrap(
+		Table("user"),
+		WhereNamed("id IN (:ids) AND status = :status", &Params{IDs: []int{1, 2, 3}, Status: 1}),
+	).ToUpdate(ctx, X{"status": 0})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE `user` SET `status` = ? WHERE id IN (?, ?, ?) AND status = ?", sql)
+	assert.Equal(t, []any{0, 1, 2, 3, 1}, args)
+
+	_, _, err = NewMySQLBuilder().Wrap(
+		Table("user"),
+		WhereNamed("id = :id", X{"name": "yiigo"}),
+	).ToQuery(ctx)
+
+	assert.NotNil(t, err)
+}
+
+func TestSafeMutation(t *testing.T) {
+	ctx := context.TODO()
+
+	builder := NewMySQLBuilder(WithSafeMutation(), WithSoftDelete("deleted_at"))
+
+	_, _, err := builder.Wrap(Table("user")).ToUpdate(ctx, X{"status": 0})
+	assert.ErrorIs(t, err, ErrUnsafeMutation)
+
+	_, _, err = builder.Wrap(Table("user")).ToDelete(ctx)
+	assert.ErrorIs(t, err, ErrUnsafeMutation)
+
+	_, _, err = builder.Wrap(Table("user")).ToForceDelete(ctx)
+	assert.ErrorIs(t, err, ErrUnsafeMutation)
+
+	sql, _, err := builder.Wrap(Table("user"), WhereClause(Clause("status = ?", 1))).ToUpdate(ctx, X{"status": 0})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE `user` SET `status` = ? WHERE (status = ?) AND (`deleted_at` IS NULL)", sql)
+
+	sql, _, err = builder.Wrap(Table("user"), AllowFullTableMutation()).ToForceDelete(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE FROM `user`", sql)
+}
+
+func TestInvalidWrapper(t *testing.T) {
+	ctx := context.TODO()
+
 	builder := NewMySQLBuilder()
 
-	assert.Equal(t, "TRUNCATE user", builder.Wrap(Table("user")).ToTruncate(context.TODO()))
+	_, _, err := builder.Wrap(Where("id = ?", 1)).ToQuery(ctx)
+	assert.ErrorIs(t, err, ErrInvalidWrapper)
+
+	_, _, err = builder.Wrap().ToInsert(ctx, X{"name": "yiigo"})
+	assert.ErrorIs(t, err, ErrInvalidWrapper)
+
+	_, err = builder.Wrap().ToTruncate(ctx)
+	assert.ErrorIs(t, err, ErrInvalidWrapper)
+
+	_, _, err = builder.Wrap(Table("user"), Select()).ToQuery(ctx)
+	assert.ErrorIs(t, err, ErrInvalidWrapper)
+
+	_, _, err = builder.Wrap(Table("user"), Offset(10)).ToQuery(ctx)
+	assert.ErrorIs(t, err, ErrInvalidWrapper)
+
+	_, _, err = builder.Wrap(Table("user"), Limit(-1)).ToQuery(ctx)
+	assert.ErrorIs(t, err, ErrInvalidWrapper)
+
+	_, _, err = builder.Wrap(Table("user"), Where("id = ?", 1)).ToUpdate(ctx, X{})
+	assert.ErrorIs(t, err, ErrInvalidWrapper)
+
+	// HAVING without GROUP BY aggregates the whole table
+	sql, _, err := builder.Wrap(Table("user"), Select("COUNT(*)"), Having("COUNT(*) > ?", 1)).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT COUNT(*) FROM `user` HAVING COUNT(*) > ?", sql)
+
+	// Postgres supports OFFSET without LIMIT
+	sql, _, err = NewPGSQLBuilder().Wrap(Table("user"), Offset(10)).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM "user" OFFSET $1`, sql)
 }
@@ -0,0 +1,382 @@
+package yiigo
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// QueryCache is the store of the query results cached by `Cached`.
+type QueryCache interface {
+	// Get returns the value of key, nil if not exists.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set sets the value of key with ttl (never expires if ttl is 0).
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Incr increments the number stored at key by one.
+	Incr(ctx context.Context, key string) error
+}
+
+const queryCachePrefix = "yiigo:sql:"
+
+// WithQueryCache specifies the store of the query results cached by `Cached`,
+// the writes (Insert, BatchInsert, Update and Delete) invalidate the cached results of the same table.
+func WithQueryCache(cache QueryCache) SQLBuilderOption {
+	return func(b *queryBuilder) {
+		b.cache = cache
+		b.flight = new(singleflight.Group)
+	}
+}
+
+// Cached caches the results of `Get` and `Select` for ttl (requires WithQueryCache), the key defaults to
+// the hash of the statement. The results are serialized by JSON, so the dest must be able to round-trip JSON.
+// The cache misses of the same key are executed once (single flight).
+// NOTE: The cached results are invalidated by the writes to the table and the joined tables via the executor only.
+func Cached(ttl time.Duration, key ...string) QueryOption {
+	return func(w *queryWrapper) {
+		w.cacheTTL = ttl
+		w.cacheKey = strings.Join(key, ":")
+	}
+}
+
+// cached executes fetch (or returns the cached result) into dest.
+// The queries in the transaction bypass the cache, since they may read the uncommitted rows.
+func (w *queryWrapper) cached(ctx context.Context, db sqlx.QueryerContext, query string, args []any, dest any, fetch func(ctx context.Context, dest any) error) error {
+	cache := w.builder.cache
+
+	if cache == nil || w.cacheTTL <= 0 || inTx(db) {
+		return fetch(ctx, dest)
+	}
+
+	key := w.cacheKeyOf(ctx, query, args)
+
+	if data, err := cache.Get(ctx, key); err != nil {
+		logger.Warn("err query cache get", zap.String("key", key), zap.Error(err))
+	} else if data != nil {
+		return json.Unmarshal(data, dest)
+	}
+
+	// the shared fetch runs without the cancellation of the first caller, so that it doesn't fail the others,
+	// and each caller waits for it until its own ctx is done.
+	flightCtx := detachedContext{ctx}
+
+	ch := w.builder.flight.DoChan(key, func() (any, error) {
+		fresh := reflect.New(reflect.TypeOf(dest).Elem())
+
+		if err := fetch(flightCtx, fresh.Interface()); err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(fresh.Interface())
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err = cache.Set(flightCtx, key, data, w.cacheTTL); err != nil {
+			logger.Warn("err query cache set", zap.String("key", key), zap.Error(err))
+		}
+
+		return data, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case ret := <-ch:
+		if ret.Err != nil {
+			return ret.Err
+		}
+
+		return json.Unmarshal(ret.Val.([]byte), dest)
+	}
+}
+
+// cacheKeyOf returns the cache key which contains the versions of the tables, eg: yiigo:sql:user:3:{hash}.
+func (w *queryWrapper) cacheKeyOf(ctx context.Context, query string, args []any) string {
+	var builder strings.Builder
+
+	builder.WriteString(queryCachePrefix)
+
+	for i, table := range w.cacheTables() {
+		if i != 0 {
+			builder.WriteString(",")
+		}
+
+		builder.WriteString(table)
+		builder.WriteString(":")
+		builder.WriteString(w.tableVersion(ctx, table))
+	}
+
+	builder.WriteString(":")
+
+	if len(w.cacheKey) != 0 {
+		builder.WriteString(w.cacheKey)
+
+		return builder.String()
+	}
+
+	h := sha1.New()
+
+	h.Write([]byte(query))
+	h.Write([]byte(fmt.Sprintf("%#v", args)))
+
+	builder.WriteString(hex.EncodeToString(h.Sum(nil)))
+
+	return builder.String()
+}
+
+// cacheTables returns the table and the joined tables without alias.
+func (w *queryWrapper) cacheTables() []string {
+	tables := make([]string, 0, len(w.joins)+1)
+
+	tables = append(tables, cacheTable(w.builder.tableName(w.table)))
+
+	for _, v := range w.joins {
+		tables = append(tables, cacheTable(w.builder.tableName(v.table)))
+	}
+
+	return tables
+}
+
+func cacheTable(s string) string {
+	s = strings.TrimSpace(s)
+
+	if m := tableRegexp.FindStringSubmatch(s); len(m) != 0 {
+		return m[1]
+	}
+
+	return s
+}
+
+func (w *queryWrapper) tableVersion(ctx context.Context, table string) string {
+	data, err := w.builder.cache.Get(ctx, queryCachePrefix+"version:"+table)
+
+	if err != nil {
+		logger.Warn("err query cache version", zap.String("table", table), zap.Error(err))
+	}
+
+	if len(data) == 0 {
+		return "0"
+	}
+
+	return string(data)
+}
+
+// invalidate invalidates the cached results of the table by incrementing the version,
+// which is done after commit if executed in the transaction (`Tx`), since the stale rows may be cached before commit.
+// The commit of the raw `*sqlx.Tx` can't be observed, so the caller should call `InvalidateCache` after commit.
+func (w *queryWrapper) invalidate(ctx context.Context, db sqlx.ExtContext) {
+	if w.builder.cache == nil {
+		return
+	}
+
+	incr := func() {
+		if err := w.InvalidateCache(ctx); err != nil {
+			logger.Error("err query cache invalidate", zap.String("table", w.table), zap.Error(err))
+		}
+	}
+
+	if tx, ok := db.(*Tx); ok {
+		tx.OnCommit(incr)
+
+		return
+	}
+
+	incr()
+}
+
+func (w *queryWrapper) InvalidateCache(ctx context.Context) error {
+	if w.builder.cache == nil {
+		return nil
+	}
+
+	return w.builder.cache.Incr(ctx, queryCachePrefix+"version:"+cacheTable(w.builder.tableName(w.table)))
+}
+
+// detachedContext keeps the values of the parent but never be canceled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key any) any {
+	return c.parent.Value(key)
+}
+
+func inTx(db any) bool {
+	switch db.(type) {
+	case *Tx, *sqlx.Tx:
+		return true
+	}
+
+	return false
+}
+
+type redisQueryCache struct {
+	pool RedisPool
+}
+
+// NewRedisQueryCache returns the QueryCache stored in Redis, eg: yiigo.NewRedisQueryCache(yiigo.Redis()).
+func NewRedisQueryCache(pool RedisPool) QueryCache {
+	return &redisQueryCache{pool: pool}
+}
+
+func (c *redisQueryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := redis.Bytes(c.pool.Do(ctx, "GET", key))
+
+	if err == redis.ErrNil {
+		return nil, nil
+	}
+
+	return data, err
+}
+
+func (c *redisQueryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		_, err := c.pool.Do(ctx, "SET", key, value)
+
+		return err
+	}
+
+	_, err := c.pool.Do(ctx, "SET", key, value, "PX", ttl.Milliseconds())
+
+	return err
+}
+
+func (c *redisQueryCache) Incr(ctx context.Context, key string) error {
+	_, err := c.pool.Do(ctx, "INCR", key)
+
+	return err
+}
+
+func (c *redisQueryCache) Delete(ctx context.Context, keys ...string) error {
+	return c.pool.DoFunc(ctx, func(ctx context.Context, conn *RedisConn) error {
+		// one by one, the keys may be in different slots (cluster mode)
+		for _, k := range keys {
+			if _, err := conn.Do("DEL", k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+type memQueryCacheItem struct {
+	value    []byte
+	expireAt time.Time
+}
+
+type memQueryCache struct {
+	items     map[string]*memQueryCacheItem
+	nextPurge int
+	mutex     sync.Mutex
+}
+
+// NewMemQueryCache returns the in-process QueryCache.
+func NewMemQueryCache() QueryCache {
+	return &memQueryCache{
+		items:     make(map[string]*memQueryCacheItem),
+		nextPurge: 1024,
+	}
+}
+
+func (c *memQueryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item, ok := c.items[key]
+
+	if !ok {
+		return nil, nil
+	}
+
+	if !item.expireAt.IsZero() && time.Now().After(item.expireAt) {
+		delete(c.items, key)
+
+		return nil, nil
+	}
+
+	return item.value, nil
+}
+
+func (c *memQueryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	item := &memQueryCacheItem{value: value}
+
+	if ttl > 0 {
+		item.expireAt = time.Now().Add(ttl)
+	}
+
+	c.items[key] = item
+
+	// purge the expired items which are never read again
+	if len(c.items) >= c.nextPurge {
+		now := time.Now()
+
+		for k, v := range c.items {
+			if !v.expireAt.IsZero() && now.After(v.expireAt) {
+				delete(c.items, k)
+			}
+		}
+
+		if c.nextPurge = len(c.items) * 2; c.nextPurge < 1024 {
+			c.nextPurge = 1024
+		}
+	}
+
+	return nil
+}
+
+func (c *memQueryCache) Incr(ctx context.Context, key string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var n int64
+
+	if item, ok := c.items[key]; ok {
+		n, _ = strconv.ParseInt(string(item.value), 10, 64)
+	}
+
+	c.items[key] = &memQueryCacheItem{value: []byte(strconv.FormatInt(n+1, 10))}
+
+	return nil
+}
+
+func (c *memQueryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, k := range keys {
+		delete(c.items, k)
+	}
+
+	return nil
+}
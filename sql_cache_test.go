@@ -0,0 +1,314 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingDB counts the queries executed.
+type countingDB struct {
+	*sqlx.DB
+
+	queries int64
+}
+
+func (db *countingDB) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	atomic.AddInt64(&db.queries, 1)
+
+	time.Sleep(10 * time.Millisecond)
+
+	return db.DB.QueryxContext(ctx, query, args...)
+}
+
+func (db *countingDB) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	atomic.AddInt64(&db.queries, 1)
+
+	return db.DB.QueryRowxContext(ctx, query, args...)
+}
+
+func TestQueryCache(t *testing.T) {
+	ctx := context.TODO()
+
+	conn, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer conn.Close()
+
+	conn.SetMaxOpenConns(1)
+
+	_, err = conn.Exec("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
+	assert.Nil(t, err)
+
+	_, err = conn.Exec("INSERT INTO user (id, name, age) VALUES (1, 'foo', 20), (2, 'bar', 30)")
+	assert.Nil(t, err)
+
+	type User struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+		Age  int    `db:"age"`
+	}
+
+	db := &countingDB{DB: conn}
+
+	builder := NewSQLiteBuilder(WithQueryCache(NewMemQueryCache()))
+
+	// single flight
+	var wg sync.WaitGroup
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			var users []User
+
+			err := builder.Wrap(Table("user"), OrderBy("id"), Cached(time.Minute)).Select(ctx, db, &users)
+
+			assert.Nil(t, err)
+			assert.Equal(t, []User{{ID: 1, Name: "foo", Age: 20}, {ID: 2, Name: "bar", Age: 30}}, users)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int64(1), atomic.LoadInt64(&db.queries))
+
+	// cached, the write without executor is not visible
+	_, err = conn.Exec("UPDATE user SET age = 21 WHERE id = 1")
+	assert.Nil(t, err)
+
+	user := new(User)
+
+	err = builder.Wrap(Table("user"), Where("id = ?", 1), Cached(time.Minute, "user", "1")).Get(ctx, db, user)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 21, user.Age)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&db.queries))
+
+	_, err = conn.Exec("UPDATE user SET age = 22 WHERE id = 1")
+	assert.Nil(t, err)
+
+	err = builder.Wrap(Table("user"), Where("id = ?", 1), Cached(time.Minute, "user", "1")).Get(ctx, db, user)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 21, user.Age)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&db.queries))
+
+	// invalidated by the write to the same table
+	_, err = builder.Wrap(Table("user"), Where("id = ?", 2)).Update(ctx, db, X{"age": 31})
+	assert.Nil(t, err)
+
+	err = builder.Wrap(Table("user"), Where("id = ?", 1), Cached(time.Minute, "user", "1")).Get(ctx, db, user)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 22, user.Age)
+	assert.Equal(t, int64(3), atomic.LoadInt64(&db.queries))
+
+	// sql.ErrNoRows is not cached
+	err = builder.Wrap(Table("user"), Where("id = ?", 3), Cached(time.Minute)).Get(ctx, db, user)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// not cached without `Cached`
+	err = builder.Wrap(Table("user"), Where("id = ?", 1)).Get(ctx, db, user)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), atomic.LoadInt64(&db.queries))
+
+	// the canceled caller doesn't fail the others of the single flight
+	cctx, cancel := context.WithCancel(ctx)
+
+	errs := make(chan error, 1)
+
+	go func() {
+		var users []User
+
+		errs <- builder.Wrap(Table("user"), Cached(time.Minute, "flight")).Select(cctx, db, &users)
+	}()
+
+	time.Sleep(2 * time.Millisecond)
+
+	go func() {
+		time.Sleep(time.Millisecond)
+		cancel()
+	}()
+
+	var users []User
+
+	err = builder.Wrap(Table("user"), Cached(time.Minute, "flight")).Select(ctx, db, &users)
+
+	assert.Nil(t, err)
+	assert.Len(t, users, 2)
+	assert.ErrorIs(t, <-errs, context.Canceled)
+
+	// expired
+	cache := NewMemQueryCache()
+
+	assert.Nil(t, cache.Set(ctx, "k", []byte("v"), time.Millisecond))
+
+	time.Sleep(5 * time.Millisecond)
+
+	v, err := cache.Get(ctx, "k")
+
+	assert.Nil(t, err)
+	assert.Nil(t, v)
+}
+
+// fakeRedisPool is the RedisPool which supports GET, SET and INCR in memory.
+type fakeRedisPool struct {
+	RedisPool
+
+	data map[string][]byte
+	ttl  map[string]int64
+}
+
+func (p *fakeRedisPool) Do(ctx context.Context, cmd string, args ...any) (any, error) {
+	key := args[0].(string)
+
+	switch cmd {
+	case "GET":
+		v, ok := p.data[key]
+
+		if !ok {
+			return nil, nil
+		}
+
+		return v, nil
+	case "SET":
+		p.data[key] = args[1].([]byte)
+
+		if len(args) == 4 {
+			p.ttl[key] = args[3].(int64)
+		}
+
+		return "OK", nil
+	case "INCR":
+		n, _ := strconv.ParseInt(string(p.data[key]), 10, 64)
+
+		p.data[key] = []byte(strconv.FormatInt(n+1, 10))
+
+		return n + 1, nil
+	}
+
+	return nil, redis.Error("unknown command")
+}
+
+func TestRedisQueryCache(t *testing.T) {
+	ctx := context.TODO()
+
+	pool := &fakeRedisPool{data: make(map[string][]byte), ttl: make(map[string]int64)}
+
+	cache := NewRedisQueryCache(pool)
+
+	v, err := cache.Get(ctx, "k")
+
+	assert.Nil(t, err)
+	assert.Nil(t, v)
+
+	assert.Nil(t, cache.Set(ctx, "k", []byte("v"), time.Second))
+
+	v, err = cache.Get(ctx, "k")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("v"), v)
+	assert.Equal(t, int64(1000), pool.ttl["k"])
+
+	assert.Nil(t, cache.Incr(ctx, "version"))
+	assert.Nil(t, cache.Incr(ctx, "version"))
+
+	v, err = cache.Get(ctx, "version")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("2"), v)
+}
+
+func TestQueryCacheTx(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
+	assert.Nil(t, err)
+
+	_, err = db.Exec("INSERT INTO user (id, name, age) VALUES (1, 'foo', 20)")
+	assert.Nil(t, err)
+
+	cache := NewMemQueryCache()
+	builder := NewSQLiteBuilder(WithQueryCache(cache))
+
+	var age int
+
+	err = builder.Wrap(Table("user"), Select("age"), Where("id = ?", 1), Cached(time.Minute, "age")).Get(ctx, db, &age)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 20, age)
+
+	err = Transact(ctx, db, func(ctx context.Context, tx *Tx) error {
+		if _, err := builder.Wrap(Table("user"), Where("id = ?", 1)).Update(ctx, tx, X{"age": 30}); err != nil {
+			return err
+		}
+
+		// bypass the cache in the transaction
+		var v int
+
+		if err := builder.Wrap(Table("user"), Select("age"), Where("id = ?", 1), Cached(time.Minute, "age")).Get(ctx, tx, &v); err != nil {
+			return err
+		}
+
+		assert.Equal(t, 30, v)
+
+		return nil
+	})
+
+	assert.Nil(t, err)
+
+	// invalidated after commit
+	err = builder.Wrap(Table("user"), Select("age"), Where("id = ?", 1), Cached(time.Minute, "age")).Get(ctx, db, &age)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 30, age)
+
+	// the raw *sqlx.Tx is invalidated explicitly after commit
+	tx, err := db.Beginx()
+
+	assert.Nil(t, err)
+
+	_, err = builder.Wrap(Table("user"), Where("id = ?", 1)).Update(ctx, tx, X{"age": 40})
+	assert.Nil(t, err)
+	assert.Nil(t, tx.Commit())
+
+	err = builder.Wrap(Table("user"), Select("age"), Where("id = ?", 1), Cached(time.Minute, "age")).Get(ctx, db, &age)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 40, age)
+
+	_, err = db.Exec("UPDATE user SET age = 50 WHERE id = 1")
+	assert.Nil(t, err)
+
+	err = builder.Wrap(Table("user"), Select("age"), Where("id = ?", 1), Cached(time.Minute, "age")).Get(ctx, db, &age)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 40, age)
+	assert.Nil(t, builder.Wrap(Table("user")).InvalidateCache(ctx))
+
+	err = builder.Wrap(Table("user"), Select("age"), Where("id = ?", 1), Cached(time.Minute, "age")).Get(ctx, db, &age)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 50, age)
+}
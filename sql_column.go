@@ -0,0 +1,163 @@
+package yiigo
+
+import "strings"
+
+// Col is the typed column which builds the conditions (used with `WhereClause`), eg:
+//
+//	id := yiigo.Col[int64]("id")
+//	name := yiigo.Col[string]("name")
+//
+//	builder.Wrap(
+//		yiigo.Table("user"),
+//		yiigo.WhereClause(id.Gt(5), name.Like("%yiigo%")),
+//		yiigo.OrderBy(id.Desc()),
+//	)
+//	// SELECT * FROM user WHERE (id > ?) AND (name LIKE ?) ORDER BY id DESC
+type Col[T any] string
+
+// Name returns the column name.
+func (c Col[T]) Name() string {
+	return string(c)
+}
+
+// Eq returns the clause `column = ?`.
+func (c Col[T]) Eq(v T) *SQLClause {
+	return Clause(string(c)+" = ?", v)
+}
+
+// Neq returns the clause `column <> ?`.
+func (c Col[T]) Neq(v T) *SQLClause {
+	return Clause(string(c)+" <> ?", v)
+}
+
+// Gt returns the clause `column > ?`.
+func (c Col[T]) Gt(v T) *SQLClause {
+	return Clause(string(c)+" > ?", v)
+}
+
+// Gte returns the clause `column >= ?`.
+func (c Col[T]) Gte(v T) *SQLClause {
+	return Clause(string(c)+" >= ?", v)
+}
+
+// Lt returns the clause `column < ?`.
+func (c Col[T]) Lt(v T) *SQLClause {
+	return Clause(string(c)+" < ?", v)
+}
+
+// Lte returns the clause `column <= ?`.
+func (c Col[T]) Lte(v T) *SQLClause {
+	return Clause(string(c)+" <= ?", v)
+}
+
+// Between returns the clause `column BETWEEN ? AND ?`.
+func (c Col[T]) Between(from, to T) *SQLClause {
+	return Clause(string(c)+" BETWEEN ? AND ?", from, to)
+}
+
+// In returns the clause `column IN (?, ?, ...)`, the empty values returns the clause which is always false.
+func (c Col[T]) In(values ...T) *SQLClause {
+	if len(values) == 0 {
+		return Clause("1 = 0")
+	}
+
+	return Clause(string(c)+" IN ("+placeholders(len(values))+")", anySlice(values)...)
+}
+
+// NotIn returns the clause `column NOT IN (?, ?, ...)`, the empty values returns the clause which is always true.
+func (c Col[T]) NotIn(values ...T) *SQLClause {
+	if len(values) == 0 {
+		return Clause("1 = 1")
+	}
+
+	return Clause(string(c)+" NOT IN ("+placeholders(len(values))+")", anySlice(values)...)
+}
+
+// Like returns the clause `column LIKE ?`.
+func (c Col[T]) Like(pattern string) *SQLClause {
+	return Clause(string(c)+" LIKE ?", pattern)
+}
+
+// NotLike returns the clause `column NOT LIKE ?`.
+func (c Col[T]) NotLike(pattern string) *SQLClause {
+	return Clause(string(c)+" NOT LIKE ?", pattern)
+}
+
+// IsNull returns the clause `column IS NULL`.
+func (c Col[T]) IsNull() *SQLClause {
+	return Clause(string(c) + " IS NULL")
+}
+
+// IsNotNull returns the clause `column IS NOT NULL`.
+func (c Col[T]) IsNotNull() *SQLClause {
+	return Clause(string(c) + " IS NOT NULL")
+}
+
+// Asc returns the `order by` column, eg: id ASC.
+func (c Col[T]) Asc() string {
+	return string(c) + " ASC"
+}
+
+// Desc returns the `order by` column, eg: id DESC.
+func (c Col[T]) Desc() string {
+	return string(c) + " DESC"
+}
+
+// And combines the clauses with `AND`, eg: yiigo.And(id.Gt(5), name.Like("%yiigo%")).
+// The empty clauses returns the clause which is always true.
+func And(clauses ...*SQLClause) *SQLClause {
+	return combineClauses(" AND ", "1 = 1", clauses)
+}
+
+// Or combines the clauses with `OR`, eg: yiigo.Or(status.Eq(1), status.Eq(2)).
+// The empty clauses returns the clause which is always false.
+func Or(clauses ...*SQLClause) *SQLClause {
+	return combineClauses(" OR ", "1 = 0", clauses)
+}
+
+func combineClauses(sep, empty string, clauses []*SQLClause) *SQLClause {
+	var (
+		builder strings.Builder
+		binds   []any
+	)
+
+	n := 0
+
+	for _, v := range clauses {
+		if v == nil || len(v.query) == 0 {
+			continue
+		}
+
+		if n != 0 {
+			builder.WriteString(sep)
+		}
+
+		builder.WriteString("(")
+		builder.WriteString(v.query)
+		builder.WriteString(")")
+
+		binds = append(binds, v.binds...)
+
+		n++
+	}
+
+	if n == 0 {
+		return Clause(empty)
+	}
+
+	return Clause(builder.String(), binds...)
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func anySlice[T any](values []T) []any {
+	binds := make([]any, 0, len(values))
+
+	for _, v := range values {
+		binds = append(binds, v)
+	}
+
+	return binds
+}
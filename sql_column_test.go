@@ -0,0 +1,50 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCol(t *testing.T) {
+	ctx := context.TODO()
+
+	var (
+		id     = Col[int64]("id")
+		name   = Col[string]("name")
+		status = Col[int]("status")
+		email  = Col[string]("email")
+	)
+
+	builder := NewPGSQLBuilder()
+
+	sql, args, err := builder.Wrap(
+		Table("user"),
+		WhereClause(
+			id.Gt(5),
+			name.Like("%yiigo%"),
+			Or(status.In(1, 2), email.IsNull()),
+			id.Between(1, 100),
+		),
+		OrderBy(id.Desc()),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM "user" WHERE (id > $1) AND (name LIKE $2) AND ((status IN ($3, $4)) OR (email IS NULL)) AND (id BETWEEN $5 AND $6) ORDER BY "id" DESC`, sql)
+	assert.Equal(t, []any{int64(5), "%yiigo%", 1, 2, int64(1), int64(100)}, args)
+
+	sql, args, err = builder.Wrap(
+		Table("user"),
+		WhereClause(id.Eq(1), status.NotIn(), And()),
+	).ToUpdate(ctx, X{name.Name(): "yiigo"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, `UPDATE "user" SET "name" = $1 WHERE (id = $2) AND (1 = 1) AND (1 = 1)`, sql)
+	assert.Equal(t, []any{"yiigo", int64(1)}, args)
+
+	sql, _, err = builder.Wrap(Table("user"), WhereClause(id.In(), Or())).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM "user" WHERE (1 = 0) AND (1 = 0)`, sql)
+}
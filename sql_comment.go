@@ -0,0 +1,79 @@
+package yiigo
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type ctxSQLCommentKey struct{}
+
+// ContextWithSQLComment returns a copy of ctx with the key-value pairs of the sql comment,
+// eg: yiigo.ContextWithSQLComment(ctx, "service", "checkout", "trace_id", traceID).
+//
+// The comment is prepended to the generated sql in sqlcommenter format, eg:
+// /*service='checkout',trace_id='4bf92f3577b34da6'*/ SELECT * FROM `order` WHERE id = ?
+// so that the slow-query logs can be traced back to the call sites.
+func ContextWithSQLComment(ctx context.Context, kv ...string) context.Context {
+	comments := make(map[string]string)
+
+	if v, ok := ctx.Value(ctxSQLCommentKey{}).(map[string]string); ok {
+		for k, s := range v {
+			comments[k] = s
+		}
+	}
+
+	for i := 0; i+1 < len(kv); i += 2 {
+		comments[kv[i]] = kv[i+1]
+	}
+
+	return context.WithValue(ctx, ctxSQLCommentKey{}, comments)
+}
+
+// comment returns the sqlcommenter comment with a trailing space,
+// the key-value pairs of the wrapper take precedence over the context.
+func (w *queryWrapper) comment(ctx context.Context) string {
+	var comments map[string]string
+
+	if ctx != nil {
+		comments, _ = ctx.Value(ctxSQLCommentKey{}).(map[string]string)
+	}
+
+	if len(comments) == 0 && len(w.comments) == 0 {
+		return ""
+	}
+
+	kv := make(map[string]string, len(comments)+len(w.comments))
+
+	for k, v := range comments {
+		kv[k] = v
+	}
+
+	for k, v := range w.comments {
+		kv[k] = v
+	}
+
+	return formatSQLComment(kv) + " "
+}
+
+// formatSQLComment serializes the key-value pairs according to the sqlcommenter spec:
+// keys are sorted, keys and values are url-encoded and values are enclosed in single quotes.
+// See: https://google.github.io/sqlcommenter/spec/
+func formatSQLComment(kv map[string]string) string {
+	keys := make([]string, 0, len(kv))
+
+	for k := range kv {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+
+	for _, k := range keys {
+		pairs = append(pairs, url.PathEscape(k)+"='"+url.PathEscape(kv[k])+"'")
+	}
+
+	return "/*" + strings.Join(pairs, ",") + "*/"
+}
@@ -0,0 +1,217 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrCopyDriver COPY is only supported by Postgres.
+var ErrCopyDriver = errors.New("copy is only supported by postgres")
+
+// the max binds of the statement
+var maxBinds = map[DBDriver]int{
+	MySQL:     65535,
+	Postgres:  65535,
+	SQLite:    32766,
+	SQLServer: 2100,
+}
+
+// BulkLoadOption bulk load option
+type BulkLoadOption func(o *bulkLoadOptions)
+
+type bulkLoadOptions struct {
+	chunk int
+}
+
+// WithBulkChunk specifies the rows of each multi-row INSERT of the fallback, default: 1000,
+// which is reduced if the binds exceed the limit of the driver.
+func WithBulkChunk(n int) BulkLoadOption {
+	return func(o *bulkLoadOptions) {
+		if n > 0 {
+			o.chunk = n
+		}
+	}
+}
+
+// ToCopy returns the COPY statement (eg: COPY "user" ("name", "age") FROM STDIN), the columns and the rows of the batch insert data,
+// which is used by pgx CopyFrom (or pq.CopyIn). Only Postgres is supported.
+func (w *queryWrapper) ToCopy(ctx context.Context, data any) (sql string, columns []string, rows [][]any, err error) {
+	_, sql, columns, rows, err = w.copyData(data)
+
+	return
+}
+
+// copyData returns the table (without alias) and the COPY statement with the columns and the rows.
+func (w *queryWrapper) copyData(data any) (table, sql string, columns []string, rows [][]any, err error) {
+	if w.builder.driver != Postgres {
+		err = ErrCopyDriver
+
+		return
+	}
+
+	if w, err = w.shard(data); err != nil {
+		return
+	}
+
+	columns, exprs, binds, n, err := w.batchValues(data)
+
+	if err != nil {
+		return
+	}
+
+	// COPY doesn't accept the expression (eg: NOW()), use the value instead
+	for i, v := range columns {
+		if _, ok := exprs[v]; ok {
+			binds = fillColumn(binds, len(columns)-1, n, i, w.builder.now())
+		}
+	}
+
+	rows = make([][]any, 0, n)
+
+	for i := 0; i < n; i++ {
+		rows = append(rows, binds[i*len(columns):(i+1)*len(columns)])
+	}
+
+	table = strings.TrimSpace(w.builder.tableName(w.table))
+
+	if m := tableRegexp.FindStringSubmatch(table); len(m) != 0 {
+		table = m[1]
+	}
+
+	var builder strings.Builder
+
+	builder.WriteString("COPY ")
+	builder.WriteString(w.builder.quoteTable(table))
+	builder.WriteString(" (")
+	w.builder.writeColumns(&builder, columns, w.builder.quoteIdent)
+	builder.WriteString(") FROM STDIN")
+
+	sql = builder.String()
+
+	return
+}
+
+// fillColumn inserts the value at index into each row of the binds (fieldNum binds per row).
+func fillColumn(binds []any, fieldNum, rows, index int, value any) []any {
+	filled := make([]any, 0, len(binds)+rows)
+
+	for i := 0; i < rows; i++ {
+		row := binds[i*fieldNum : (i+1)*fieldNum]
+
+		filled = append(filled, row[:index]...)
+		filled = append(filled, value)
+		filled = append(filled, row[index:]...)
+	}
+
+	return filled
+}
+
+// BulkLoad inserts the large number of rows ([]struct, []*struct or []yiigo.X) and returns the rows affected.
+// Postgres uses COPY FROM (pgx CopyFrom) if db is the *sqlx.DB of pgx, otherwise (eg: MySQL, SQLite or in the transaction)
+// falls back to the chunked multi-row INSERT.
+// NOTE: The chunks out of the transaction are not atomic, the rows inserted are returned with the error of the failed chunk.
+func (w *queryWrapper) BulkLoad(ctx context.Context, db sqlx.ExtContext, data any, options ...BulkLoadOption) (int64, error) {
+	db, err := shardExecutor(w, db, data)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if v, ok := db.(*sqlx.DB); ok && w.builder.driver == Postgres {
+		if _, ok = v.Driver().(*stdlib.Driver); ok {
+			return w.copyFrom(ctx, v, data)
+		}
+	}
+
+	o := &bulkLoadOptions{chunk: 1000}
+
+	for _, f := range options {
+		f(o)
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(data))
+
+	if v.Kind() != reflect.Slice {
+		return 0, ErrBatchInsertData
+	}
+
+	size := v.Len()
+	chunk := o.chunk
+
+	if chunk > size {
+		chunk = size
+	}
+
+	// reduce the chunk if the binds exceed the limit of the driver
+	if limit, ok := maxBinds[w.builder.driver]; ok && chunk != 0 {
+		columns, _, _, _, err := w.batchValues(v.Slice(0, 1).Interface())
+
+		if err != nil {
+			return 0, err
+		}
+
+		if len(columns) != 0 && chunk*len(columns) > limit {
+			chunk = limit / len(columns)
+		}
+	}
+
+	var total int64
+
+	for i := 0; i < size; i += chunk {
+		end := i + chunk
+
+		if end > size {
+			end = size
+		}
+
+		rows, err := w.BatchInsert(ctx, db, v.Slice(i, end).Interface())
+
+		total += rows
+
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+func (w *queryWrapper) copyFrom(ctx context.Context, db *sqlx.DB, data any) (int64, error) {
+	if err := callModelHooks(data, func(h BeforeInserter) error { return h.BeforeInsert(ctx) }); err != nil {
+		return 0, err
+	}
+
+	table, query, columns, rows, err := w.copyData(data)
+
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.execute(ctx, db, StmtInsert, query, nil, func(ctx context.Context) (n int64, err error) {
+		err = PgxConn(ctx, db, func(conn *pgx.Conn) error {
+			n, err = conn.CopyFrom(ctx, pgx.Identifier(strings.Split(table, ".")), columns, pgx.CopyFromRows(rows))
+
+			return err
+		})
+
+		return
+	})
+
+	if err != nil {
+		return n, err
+	}
+
+	w.invalidate(ctx, db)
+
+	if err = callModelHooks(data, func(h AfterInserter) error { return h.AfterInsert(ctx) }); err != nil {
+		return n, err
+	}
+
+	return n, nil
+}
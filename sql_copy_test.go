@@ -0,0 +1,77 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToCopy(t *testing.T) {
+	ctx := context.TODO()
+
+	builder := NewPGSQLBuilder(WithTimestamps("created_at", ""), WithTimestampFunc(func() any { return int64(1) }))
+
+	sql, columns, rows, err := builder.Wrap(TableAs("user", "u")).ToCopy(ctx, []X{
+		{"name": "foo", "age": 20},
+		{"name": "bar", "age": 30},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, `COPY "user" ("age", "name", "created_at") FROM STDIN`, sql)
+	assert.Equal(t, []string{"age", "name", "created_at"}, columns)
+	assert.Equal(t, [][]any{{20, "foo", int64(1)}, {30, "bar", int64(1)}}, rows)
+
+	// CURRENT_TIMESTAMP is replaced by the value
+	_, columns, rows, err = NewPGSQLBuilder(WithTimestamps("created_at", ""), WithDBTimestamp()).Wrap(Table("user")).ToCopy(ctx, []X{{"name": "foo"}})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"name", "created_at"}, columns)
+	assert.Len(t, rows[0], 2)
+	assert.NotNil(t, rows[0][1])
+
+	_, _, _, err = NewMySQLBuilder().Wrap(Table("user")).ToCopy(ctx, []X{{"name": "foo"}})
+	assert.ErrorIs(t, err, ErrCopyDriver)
+}
+
+func TestBulkLoad(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := OpenSQLiteMemory()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT)")
+	assert.Nil(t, err)
+
+	statements := 0
+
+	builder := NewSQLiteBuilder(OnExec(func(ctx context.Context, stmt *ExecutedStatement, next func(ctx context.Context) error) error {
+		statements++
+
+		return next(ctx)
+	}))
+
+	data := make([]X, 0, 5)
+
+	for _, v := range []string{"a", "b", "c", "d", "e"} {
+		data = append(data, X{"name": v})
+	}
+
+	n, err := builder.Wrap(Table("user")).BulkLoad(ctx, db, data, WithBulkChunk(2))
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), n)
+	assert.Equal(t, 3, statements)
+
+	var count int
+
+	assert.Nil(t, db.Get(&count, "SELECT COUNT(*) FROM user"))
+	assert.Equal(t, 5, count)
+
+	// invalid data
+	_, err = NewSQLBuilder(SQLServer).Wrap(Table("user")).BulkLoad(ctx, db, "foo")
+	assert.ErrorIs(t, err, ErrBatchInsertData)
+}
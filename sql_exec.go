@@ -0,0 +1,362 @@
+package yiigo
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (w *queryWrapper) Get(ctx context.Context, db sqlx.QueryerContext, dest any) error {
+	query, args, err := w.ToQuery(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if db, err = shardExecutor(w, db, nil); err != nil {
+		return err
+	}
+
+	return w.cached(ctx, db, query, args, dest, func(ctx context.Context, dest any) error {
+		_, err := w.execute(ctx, db, StmtQuery, query, args, func(ctx context.Context) (int64, error) {
+			query, err := w.timeout(ctx, db, StmtQuery, query)
+
+			if err != nil {
+				return 0, err
+			}
+
+			if err = sqlx.GetContext(ctx, db, dest, query, args...); err != nil {
+				return 0, err
+			}
+
+			return 1, nil
+		})
+
+		return err
+	})
+}
+
+func (w *queryWrapper) Select(ctx context.Context, db sqlx.QueryerContext, dest any) error {
+	query, args, err := w.ToQuery(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if db, err = shardExecutor(w, db, nil); err != nil {
+		return err
+	}
+
+	return w.cached(ctx, db, query, args, dest, func(ctx context.Context, dest any) error {
+		_, err := w.execute(ctx, db, StmtQuery, query, args, func(ctx context.Context) (int64, error) {
+			query, err := w.timeout(ctx, db, StmtQuery, query)
+
+			if err != nil {
+				return 0, err
+			}
+
+			if err = sqlx.SelectContext(ctx, db, dest, query, args...); err != nil {
+				return 0, err
+			}
+
+			if v := reflect.Indirect(reflect.ValueOf(dest)); v.Kind() == reflect.Slice {
+				return int64(v.Len()), nil
+			}
+
+			return 0, nil
+		})
+
+		return err
+	})
+}
+
+func (w *queryWrapper) Explain(ctx context.Context, db sqlx.QueryerContext, analyze bool) ([]X, error) {
+	query, args, err := w.ToExplain(ctx, analyze)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if db, err = shardExecutor(w, db, nil); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryxContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	plans := make([]X, 0)
+
+	for rows.Next() {
+		row := make(map[string]any)
+
+		if err = rows.MapScan(row); err != nil {
+			return nil, err
+		}
+
+		// []byte -> string for readability
+		for k, v := range row {
+			if b, ok := v.([]byte); ok {
+				row[k] = string(b)
+			}
+		}
+
+		plans = append(plans, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+func (w *queryWrapper) Iterate(ctx context.Context, db sqlx.QueryerContext, fn func(rows *sqlx.Rows) error) error {
+	query, args, err := w.ToQuery(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if db, err = shardExecutor(w, db, nil); err != nil {
+		return err
+	}
+
+	_, err = w.execute(ctx, db, StmtQuery, query, args, func(ctx context.Context) (int64, error) {
+		query, err := w.timeout(ctx, db, StmtQuery, query)
+
+		if err != nil {
+			return 0, err
+		}
+
+		rows, err := db.QueryxContext(ctx, query, args...)
+
+		if err != nil {
+			return 0, err
+		}
+
+		defer rows.Close()
+
+		var n int64
+
+		for rows.Next() {
+			n++
+
+			if err = fn(rows); err != nil {
+				return n, err
+			}
+		}
+
+		return n, rows.Err()
+	})
+
+	return err
+}
+
+func (w *queryWrapper) Insert(ctx context.Context, db sqlx.ExtContext, data any) (int64, error) {
+	if err := callModelHooks(data, func(h BeforeInserter) error { return h.BeforeInsert(ctx) }); err != nil {
+		return 0, err
+	}
+
+	query, args, err := w.ToInsert(ctx, data)
+
+	if err != nil {
+		return 0, err
+	}
+
+	// SQL Server: SCOPE_IDENTITY() instead of OUTPUT INSERTED.[id], which fails on the table with triggers
+	if w.builder.driver == SQLServer {
+		query += "; SELECT COALESCE(CAST(SCOPE_IDENTITY() AS BIGINT), 0)"
+	}
+
+	if db, err = shardExecutor(w, db, data); err != nil {
+		return 0, err
+	}
+
+	var id int64
+
+	_, err = w.execute(ctx, db, StmtInsert, query, args, func(ctx context.Context) (int64, error) {
+		query, err := w.timeout(ctx, db, StmtInsert, query)
+
+		if err != nil {
+			return 0, err
+		}
+
+		switch w.builder.driver {
+		case Postgres, SQLServer:
+			// RETURNING id (SCOPE_IDENTITY)
+			if err := db.QueryRowxContext(ctx, query, args...).Scan(&id); err != nil {
+				return 0, err
+			}
+		default:
+			ret, err := db.ExecContext(ctx, query, args...)
+
+			if err != nil {
+				return 0, err
+			}
+
+			if id, err = ret.LastInsertId(); err != nil {
+				return 0, err
+			}
+		}
+
+		return 1, nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	w.invalidate(ctx, db)
+
+	if err = callModelHooks(data, func(h AfterInserter) error { return h.AfterInsert(ctx) }); err != nil {
+		return id, err
+	}
+
+	return id, nil
+}
+
+func (w *queryWrapper) BatchInsert(ctx context.Context, db sqlx.ExtContext, data any) (int64, error) {
+	if err := callModelHooks(data, func(h BeforeInserter) error { return h.BeforeInsert(ctx) }); err != nil {
+		return 0, err
+	}
+
+	query, args, err := w.ToBatchInsert(ctx, data)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if db, err = shardExecutor(w, db, data); err != nil {
+		return 0, err
+	}
+
+	rows, err := w.exec(ctx, db, StmtInsert, query, args)
+
+	if err != nil {
+		return 0, err
+	}
+
+	w.invalidate(ctx, db)
+
+	if err = callModelHooks(data, func(h AfterInserter) error { return h.AfterInsert(ctx) }); err != nil {
+		return rows, err
+	}
+
+	return rows, nil
+}
+
+func (w *queryWrapper) Update(ctx context.Context, db sqlx.ExtContext, data any) (int64, error) {
+	if err := callModelHooks(data, func(h BeforeUpdater) error { return h.BeforeUpdate(ctx) }); err != nil {
+		return 0, err
+	}
+
+	query, args, err := w.ToUpdate(ctx, data)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if db, err = shardExecutor(w, db, nil); err != nil {
+		return 0, err
+	}
+
+	rows, err := w.exec(ctx, db, StmtUpdate, query, args)
+
+	if err != nil {
+		return 0, err
+	}
+
+	w.invalidate(ctx, db)
+
+	if err = callModelHooks(data, func(h AfterUpdater) error { return h.AfterUpdate(ctx) }); err != nil {
+		return rows, err
+	}
+
+	return rows, nil
+}
+
+func (w *queryWrapper) Delete(ctx context.Context, db sqlx.ExtContext) (int64, error) {
+	query, args, err := w.ToDelete(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if db, err = shardExecutor(w, db, nil); err != nil {
+		return 0, err
+	}
+
+	rows, err := w.exec(ctx, db, StmtDelete, query, args)
+
+	if err != nil {
+		return 0, err
+	}
+
+	w.invalidate(ctx, db)
+
+	return rows, nil
+}
+
+// exec executes the statement and returns the rows affected.
+func (w *queryWrapper) exec(ctx context.Context, db sqlx.ExtContext, kind StmtKind, query string, args []any) (int64, error) {
+	return w.execute(ctx, db, kind, query, args, func(ctx context.Context) (int64, error) {
+		query, err := w.timeout(ctx, db, kind, query)
+
+		if err != nil {
+			return 0, err
+		}
+
+		ret, err := db.ExecContext(ctx, query, args...)
+
+		if err != nil {
+			return 0, err
+		}
+
+		return ret.RowsAffected()
+	})
+}
+
+// execute calls fn (the db operation) through the exec hooks, fn returns the rows affected (returned).
+// The read is retried on the transient failures by the retry policy, each attempt goes through the exec hooks.
+func (w *queryWrapper) execute(ctx context.Context, db any, kind StmtKind, query string, args []any, fn func(ctx context.Context) (int64, error)) (int64, error) {
+	hooks := w.builder.execHooks
+
+	return w.retry(ctx, db, kind, func(attempt int) (int64, error) {
+		if len(hooks) == 0 {
+			return fn(ctx)
+		}
+
+		stmt := &ExecutedStatement{
+			Kind:    kind,
+			Table:   cacheTable(w.table),
+			SQL:     query,
+			Args:    args,
+			Attempt: attempt,
+		}
+
+		next := func(ctx context.Context) (err error) {
+			stmt.Rows, err = fn(ctx)
+
+			return
+		}
+
+		// the first hook is the outermost
+		for i := len(hooks) - 1; i >= 0; i-- {
+			hook, inner := hooks[i], next
+
+			next = func(ctx context.Context) error {
+				return hook(ctx, stmt, inner)
+			}
+		}
+
+		err := next(ctx)
+
+		return stmt.Rows, err
+	})
+}
@@ -0,0 +1,104 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExec(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
+	assert.Nil(t, err)
+
+	type User struct {
+		ID   int64  `db:"id,omitempty"`
+		Name string `db:"name"`
+		Age  int    `db:"age"`
+	}
+
+	builder := NewSQLiteBuilder()
+
+	id, err := builder.Wrap(Table("user")).Insert(ctx, db, &User{Name: "yiigo", Age: 29})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), id)
+
+	rows, err := builder.Wrap(Table("user")).BatchInsert(ctx, db, []User{{Name: "foo", Age: 20}, {Name: "bar", Age: 30}})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), rows)
+
+	rows, err = builder.Wrap(Table("user"), Where("age >= ?", 29)).Update(ctx, db, X{"age": Clause("age + ?", 1)})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), rows)
+
+	user := new(User)
+
+	err = builder.Wrap(Table("user"), Where("id = ?", 1)).Get(ctx, db, user)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &User{ID: 1, Name: "yiigo", Age: 30}, user)
+
+	rows, err = builder.Wrap(Table("user"), Where("name = ?", "foo")).Delete(ctx, db)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), rows)
+
+	var users []User
+
+	err = builder.Wrap(Table("user"), OrderBy("id")).Select(ctx, db, &users)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []User{{ID: 1, Name: "yiigo", Age: 30}, {ID: 3, Name: "bar", Age: 31}}, users)
+}
+
+func TestExplain(t *testing.T) {
+	ctx := context.TODO()
+
+	sql, args, err := NewPGSQLBuilder().Wrap(Table("user"), Where("id = ?", 1)).ToExplain(ctx, true)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `EXPLAIN ANALYZE SELECT * FROM "user" WHERE id = $1`, sql)
+	assert.Equal(t, []any{1}, args)
+
+	sql, _, err = NewMySQLBuilder().Wrap(Table("user"), Where("id = ?", 1)).ToExplain(ContextWithSQLComment(ctx, "route", "/users"), false)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/*route='%2Fusers'*/ EXPLAIN SELECT * FROM `user` WHERE id = ?", sql)
+
+	_, _, err = NewSQLiteBuilder().Wrap(Table("user")).ToExplain(ctx, true)
+	assert.ErrorIs(t, err, ErrDriverUnsupported)
+
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
+	assert.Nil(t, err)
+
+	_, err = db.Exec("CREATE INDEX idx_age ON user (age)")
+	assert.Nil(t, err)
+
+	plans, err := NewSQLiteBuilder().Wrap(Table("user"), Where("age = ?", 20)).Explain(ctx, db, false)
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, plans)
+	assert.Contains(t, plans[0]["detail"], "idx_age")
+}
@@ -0,0 +1,87 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SQLWrapperOf is the typed SQLWrapper which executes the query and scans the result into T.
+type SQLWrapperOf[T any] struct {
+	SQLWrapper
+}
+
+// WrapOf wraps the query options with the type of the result, eg:
+//
+//	users, err := yiigo.WrapOf[User](builder, yiigo.Table("user"), yiigo.Where("age > ?", 20)).Select(ctx, yiigo.DB())
+func WrapOf[T any](builder SQLBuilder, options ...QueryOption) *SQLWrapperOf[T] {
+	return &SQLWrapperOf[T]{
+		SQLWrapper: builder.Wrap(options...),
+	}
+}
+
+// Get executes the query and scans the first row into T, returns sql.ErrNoRows if no rows.
+func (w *SQLWrapperOf[T]) Get(ctx context.Context, db sqlx.QueryerContext) (T, error) {
+	var dest T
+
+	if err := w.SQLWrapper.Get(ctx, db, &dest); err != nil {
+		return dest, err
+	}
+
+	err := callModelHooks(&dest, func(h AfterFinder) error { return h.AfterFind(ctx) })
+
+	return dest, err
+}
+
+// Select executes the query and scans the rows into []T.
+func (w *SQLWrapperOf[T]) Select(ctx context.Context, db sqlx.QueryerContext) ([]T, error) {
+	dest := make([]T, 0)
+
+	if err := w.SQLWrapper.Select(ctx, db, &dest); err != nil {
+		return nil, err
+	}
+
+	if err := callModelHooks(dest, func(h AfterFinder) error { return h.AfterFind(ctx) }); err != nil {
+		return nil, err
+	}
+
+	return dest, nil
+}
+
+// Each executes the query and calls fn for each row scanned into T without loading all rows into memory,
+// the iteration stops if fn returns an error, and the error is returned.
+func (w *SQLWrapperOf[T]) Each(ctx context.Context, db sqlx.QueryerContext, fn func(v T) error) error {
+	return w.Iterate(ctx, db, func(rows *sqlx.Rows) error {
+		var v T
+
+		if err := scanRow(rows, &v); err != nil {
+			return err
+		}
+
+		if err := callModelHooks(&v, func(h AfterFinder) error { return h.AfterFind(ctx) }); err != nil {
+			return err
+		}
+
+		return fn(v)
+	})
+}
+
+// scanRow scans the row into dest, the struct is scanned by columns and others (eg: int, time.Time) by the only column.
+func scanRow(rows *sqlx.Rows, dest any) error {
+	if _, ok := dest.(sql.Scanner); ok {
+		return rows.Scan(dest)
+	}
+
+	if t := reflect.TypeOf(dest).Elem(); t.Kind() == reflect.Struct {
+		// the struct without exported fields (eg: time.Time) is scanned as a value like sqlx does
+		for i := 0; i < t.NumField(); i++ {
+			if len(t.Field(i).PkgPath) == 0 {
+				return rows.StructScan(dest)
+			}
+		}
+	}
+
+	return rows.Scan(dest)
+}
@@ -0,0 +1,125 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapOf(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
+	assert.Nil(t, err)
+
+	_, err = db.Exec("INSERT INTO user (name, age) VALUES ('yiigo', 29), ('test', 20)")
+	assert.Nil(t, err)
+
+	type User struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+		Age  int    `db:"age"`
+	}
+
+	builder := NewSQLiteBuilder()
+
+	user, err := WrapOf[User](builder, Table("user"), Where("age > ?", 25)).Get(ctx, db)
+
+	assert.Nil(t, err)
+	assert.Equal(t, User{ID: 1, Name: "yiigo", Age: 29}, user)
+
+	_, err = WrapOf[User](builder, Table("user"), Where("age > ?", 30)).Get(ctx, db)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	users, err := WrapOf[User](builder, Table("user"), OrderBy("age")).Select(ctx, db)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []User{{ID: 2, Name: "test", Age: 20}, {ID: 1, Name: "yiigo", Age: 29}}, users)
+
+	count, err := WrapOf[int](builder, Table("user"), Select("COUNT(*)")).Get(ctx, db)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestEach(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
+	assert.Nil(t, err)
+
+	_, err = db.Exec("INSERT INTO user (name, age) VALUES ('yiigo', 29), ('foo', 20), ('bar', 30)")
+	assert.Nil(t, err)
+
+	type User struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+		Age  int    `db:"age"`
+	}
+
+	builder := NewSQLiteBuilder()
+
+	names := make([]string, 0)
+
+	err = WrapOf[User](builder, Table("user"), OrderBy("id")).Each(ctx, db, func(v User) error {
+		names = append(names, v.Name)
+
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"yiigo", "foo", "bar"}, names)
+
+	errStop := errors.New("stop")
+	ages := make([]int, 0)
+
+	err = WrapOf[int](builder, Table("user"), Select("age"), OrderBy("id")).Each(ctx, db, func(v int) error {
+		ages = append(ages, v)
+
+		if len(ages) == 2 {
+			return errStop
+		}
+
+		return nil
+	})
+
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, []int{29, 20}, ages)
+
+	total := 0
+
+	err = builder.Wrap(Table("user"), Select("age")).Iterate(ctx, db, func(rows *sqlx.Rows) error {
+		var age int
+
+		if err := rows.Scan(&age); err != nil {
+			return err
+		}
+
+		total += age
+
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 79, total)
+}
@@ -0,0 +1,80 @@
+package yiigo
+
+import (
+	"context"
+)
+
+// StmtKind the kind of the built statement.
+type StmtKind string
+
+const (
+	StmtQuery    StmtKind = "SELECT"
+	StmtInsert   StmtKind = "INSERT"
+	StmtUpdate   StmtKind = "UPDATE"
+	StmtDelete   StmtKind = "DELETE"
+	StmtTruncate StmtKind = "TRUNCATE"
+)
+
+// BuiltStatement the statement passed to the build hooks,
+// the SQL uses `?` as bindvar (before rebind), and the hooks can modify the SQL and Args.
+// NOTE: The soft delete is an update statement.
+type BuiltStatement struct {
+	// Kind the kind of the statement
+	Kind StmtKind
+
+	// Table the table specified by `Table` or `TableAs`
+	Table string
+
+	// Where reports whether the statement has `where` conditions (the soft delete condition excluded)
+	Where bool
+
+	// SQL the statement
+	SQL string
+
+	// Args the binds
+	Args []any
+}
+
+// BuildHook is called on every build of the statement,
+// the build fails if the hook returns an error.
+type BuildHook func(ctx context.Context, stmt *BuiltStatement) error
+
+// OnBuild specifies the build hooks which are called in order, eg: block the dangerous statements, record metrics.
+func OnBuild(hooks ...BuildHook) SQLBuilderOption {
+	return func(b *queryBuilder) {
+		b.hooks = append(b.hooks, hooks...)
+	}
+}
+
+// ExecutedStatement the statement passed to the exec hooks.
+type ExecutedStatement struct {
+	// Kind the kind of the statement
+	Kind StmtKind
+
+	// Table the table specified by `Table` or `TableAs` (without alias, before sharding)
+	Table string
+
+	// SQL the statement (after rebind)
+	SQL string
+
+	// Args the binds
+	Args []any
+
+	// Rows the rows returned (query) or affected (insert, update and delete), it's set after the execution
+	Rows int64
+
+	// Attempt the attempt of the statement (starts from 1), which is greater than 1 if the read is retried
+	Attempt int
+}
+
+// ExecHook wraps the execution of the statement by the executors (eg: Get, Select, Insert, Update),
+// it must call next with the context (which can be derived) and return the error, eg: record metrics, tracing.
+// NOTE: The cache hits of `Cached` are not executed.
+type ExecHook func(ctx context.Context, stmt *ExecutedStatement, next func(ctx context.Context) error) error
+
+// OnExec specifies the exec hooks, the first one is the outermost.
+func OnExec(hooks ...ExecHook) SQLBuilderOption {
+	return func(b *queryBuilder) {
+		b.execHooks = append(b.execHooks, hooks...)
+	}
+}
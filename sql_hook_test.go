@@ -0,0 +1,130 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnBuild(t *testing.T) {
+	ctx := context.TODO()
+
+	errUnsafe := errors.New("delete without where")
+
+	kinds := make([]StmtKind, 0)
+
+	builder := NewPGSQLBuilder(
+		OnBuild(
+			func(ctx context.Context, stmt *BuiltStatement) error {
+				kinds = append(kinds, stmt.Kind)
+
+				return nil
+			},
+			func(ctx context.Context, stmt *BuiltStatement) error {
+				if stmt.Kind == StmtDelete && !stmt.Where {
+					return errUnsafe
+				}
+
+				return nil
+			},
+			func(ctx context.Context, stmt *BuiltStatement) error {
+				// sharding
+				if stmt.Table == "order" {
+					stmt.SQL = strings.Replace(stmt.SQL, `"order"`, `"order_1"`, 1)
+				}
+
+				return nil
+			},
+		),
+	)
+
+	sql, args, err := builder.Wrap(Table("order"), Where("id = ?", 1)).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT * FROM "order_1" WHERE id = $1`, sql)
+	assert.Equal(t, []any{1}, args)
+
+	_, _, err = builder.Wrap(Table("user")).ToDelete(ctx)
+	assert.ErrorIs(t, err, errUnsafe)
+
+	_, _, err = builder.Wrap(Table("user"), Where("id = ?", 1)).ToDelete(ctx)
+	assert.Nil(t, err)
+
+	sql, err = builder.Wrap(Table("order")).ToTruncate(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `TRUNCATE "order_1"`, sql)
+
+	assert.Equal(t, []StmtKind{StmtQuery, StmtDelete, StmtDelete, StmtTruncate}, kinds)
+
+	// the soft delete condition is not the `where` condition
+	var where []bool
+
+	_, _, err = NewMySQLBuilder(
+		WithSoftDelete("deleted_at"),
+		OnBuild(func(ctx context.Context, stmt *BuiltStatement) error {
+			where = append(where, stmt.Where)
+
+			return nil
+		}),
+	).Wrap(Table("user")).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{false}, where)
+}
+
+func TestOnExec(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := OpenSQLiteMemory()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT)")
+	assert.Nil(t, err)
+
+	type ctxKey struct{}
+
+	calls := make([]string, 0)
+
+	builder := NewSQLiteBuilder(
+		OnExec(
+			func(ctx context.Context, stmt *ExecutedStatement, next func(ctx context.Context) error) error {
+				calls = append(calls, "outer:"+string(stmt.Kind)+":"+stmt.Table)
+
+				return next(context.WithValue(ctx, ctxKey{}, "traced"))
+			},
+			func(ctx context.Context, stmt *ExecutedStatement, next func(ctx context.Context) error) error {
+				assert.Equal(t, "traced", ctx.Value(ctxKey{}))
+
+				err := next(ctx)
+
+				calls = append(calls, fmt.Sprintf("inner:%d", stmt.Rows))
+
+				return err
+			},
+		),
+	)
+
+	_, err = builder.Wrap(Table("user")).BatchInsert(ctx, db, []X{{"name": "foo"}, {"name": "bar"}})
+	assert.Nil(t, err)
+
+	names := make([]string, 0)
+
+	assert.Nil(t, builder.Wrap(TableAs("user", "u"), Select("name")).Select(ctx, db, &names))
+
+	_, err = builder.Wrap(Table("user"), Where("id = ?", 1)).Update(ctx, db, X{"name": "baz"})
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{
+		"outer:INSERT:user", "inner:2",
+		"outer:SELECT:user", "inner:2",
+		"outer:UPDATE:user", "inner:1",
+	}, calls)
+}
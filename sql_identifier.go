@@ -0,0 +1,270 @@
+package yiigo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidIdent invalid identifier (table or column name).
+var ErrInvalidIdent = errors.New("invalid identifier")
+
+var (
+	// eg: name, user.name, user.*
+	identRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.([A-Za-z_][A-Za-z0-9_$]*|\*))*$`)
+
+	// eg: COUNT(*) AS total
+	aliasRegexp = regexp.MustCompile(`(?i)^(.+?)\s+AS\s+([A-Za-z_][A-Za-z0-9_$]*)$`)
+
+	// eg: user u, user AS u
+	tableRegexp = regexp.MustCompile(`(?i)^([A-Za-z_][A-Za-z0-9_$.]*)(\s+AS)?\s+([A-Za-z_][A-Za-z0-9_$]*)$`)
+
+	// eg: age DESC, age DESC NULLS LAST
+	orderRegexp = regexp.MustCompile(`(?i)^([A-Za-z_][A-Za-z0-9_$.]*)((\s+(ASC|DESC))?(\s+NULLS\s+(FIRST|LAST))?)$`)
+)
+
+// tableName returns the table with the prefix and schema, eg: user AS u -> tenant.t_user AS u.
+func (b *queryBuilder) tableName(s string) string {
+	if len(b.prefix) == 0 && len(b.schema) == 0 {
+		return s
+	}
+
+	name, alias := strings.TrimSpace(s), ""
+
+	if m := tableRegexp.FindStringSubmatch(name); len(m) != 0 {
+		name, alias = m[1], m[3]
+	}
+
+	// expression or qualified table
+	if !identRegexp.MatchString(name) || strings.Contains(name, ".") {
+		return s
+	}
+
+	name = b.prefix + name
+
+	if len(b.schema) != 0 {
+		name = b.schema + "." + name
+	}
+
+	if len(alias) != 0 {
+		name += " AS " + alias
+	}
+
+	return name
+}
+
+func (b *queryBuilder) quoteChars() (string, string) {
+	switch b.driver {
+	case MySQL:
+		return "`", "`"
+	case SQLServer:
+		return "[", "]"
+	}
+
+	return `"`, `"`
+}
+
+// quoteIdent quotes the identifier according to the driver, eg: user.name -> `user`.`name`.
+// Expressions (eg: COUNT(*)) are returned verbatim.
+func (b *queryBuilder) quoteIdent(s string) string {
+	if !b.quote || !identRegexp.MatchString(s) {
+		return s
+	}
+
+	open, end := b.quoteChars()
+
+	var builder strings.Builder
+
+	builder.Grow(len(s) + (strings.Count(s, ".")+1)*2)
+
+	for {
+		part := s
+
+		i := strings.IndexByte(s, '.')
+
+		if i != -1 {
+			part = s[:i]
+		}
+
+		if part == "*" {
+			builder.WriteString(part)
+		} else {
+			builder.WriteString(open)
+			builder.WriteString(part)
+			builder.WriteString(end)
+		}
+
+		if i == -1 {
+			break
+		}
+
+		builder.WriteString(".")
+
+		s = s[i+1:]
+	}
+
+	return builder.String()
+}
+
+// quoteColumn quotes the select column, eg: COUNT(*) AS total -> COUNT(*) AS `total`.
+func (b *queryBuilder) quoteColumn(s string) string {
+	if !b.quote {
+		return s
+	}
+
+	s = strings.TrimSpace(s)
+
+	if m := aliasRegexp.FindStringSubmatch(s); len(m) != 0 {
+		return b.quoteIdent(strings.TrimSpace(m[1])) + " AS " + b.quoteIdent(m[2])
+	}
+
+	return b.quoteIdent(s)
+}
+
+// quoteTable quotes the table, eg: user AS u -> `user` AS `u`.
+func (b *queryBuilder) quoteTable(s string) string {
+	if !b.quote {
+		return s
+	}
+
+	s = strings.TrimSpace(s)
+
+	if m := tableRegexp.FindStringSubmatch(s); len(m) != 0 {
+		return b.quoteIdent(m[1]) + " AS " + b.quoteIdent(m[3])
+	}
+
+	return b.quoteIdent(s)
+}
+
+// quoteOrder quotes the order column, eg: age DESC -> `age` DESC.
+func (b *queryBuilder) quoteOrder(s string) string {
+	if !b.quote {
+		return s
+	}
+
+	s = strings.TrimSpace(s)
+
+	if m := orderRegexp.FindStringSubmatch(s); len(m) != 0 {
+		return b.quoteIdent(m[1]) + m[2]
+	}
+
+	return s
+}
+
+func (b *queryBuilder) quoteColumns(columns []string, fn func(s string) string) []string {
+	quoted := make([]string, 0, len(columns))
+
+	for _, v := range columns {
+		quoted = append(quoted, fn(v))
+	}
+
+	return quoted
+}
+
+// writeColumns writes the quoted columns separated by comma, without the intermediate slice.
+func (b *queryBuilder) writeColumns(builder *strings.Builder, columns []string, fn func(s string) string) {
+	for i, v := range columns {
+		if i != 0 {
+			builder.WriteString(", ")
+		}
+
+		builder.WriteString(fn(v))
+	}
+}
+
+// WithStrictIdent enables the strict mode which validates table names, insert/update columns,
+// `group by` and `order by` inputs against the identifier grammar, and returns ErrInvalidIdent otherwise.
+// If the whitelist is specified, the identifiers must be in the whitelist as well (including the table aliases).
+// NOTE: The `select` columns may contain expressions (eg: COUNT(*) AS total), so they are not validated.
+func WithStrictIdent(whitelist ...string) SQLBuilderOption {
+	return func(b *queryBuilder) {
+		b.strict = true
+
+		if len(whitelist) != 0 {
+			b.whitelist = make(map[string]struct{}, len(whitelist))
+
+			for _, v := range whitelist {
+				b.whitelist[v] = struct{}{}
+			}
+		}
+	}
+}
+
+// allowed reports whether the identifier is in the whitelist, the qualified identifier (eg: u.name)
+// is allowed if either the full name or each segment of it is in the whitelist, so the table aliases must be whitelisted as well.
+func (b *queryBuilder) allowed(ident string) bool {
+	if b.whitelist == nil {
+		return true
+	}
+
+	if _, ok := b.whitelist[ident]; ok {
+		return true
+	}
+
+	if !strings.Contains(ident, ".") {
+		return false
+	}
+
+	for _, v := range strings.Split(ident, ".") {
+		if _, ok := b.whitelist[v]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (b *queryBuilder) checkIdent(s string) error {
+	if !b.strict {
+		return nil
+	}
+
+	if !identRegexp.MatchString(s) || strings.HasSuffix(s, "*") || !b.allowed(s) {
+		return fmt.Errorf("%w: %q", ErrInvalidIdent, s)
+	}
+
+	return nil
+}
+
+func (b *queryBuilder) checkTable(s string) error {
+	if !b.strict {
+		return nil
+	}
+
+	s = strings.TrimSpace(s)
+
+	if m := tableRegexp.FindStringSubmatch(s); len(m) != 0 {
+		return b.checkIdent(m[1])
+	}
+
+	return b.checkIdent(s)
+}
+
+func (b *queryBuilder) checkOrder(s string) error {
+	if !b.strict {
+		return nil
+	}
+
+	m := orderRegexp.FindStringSubmatch(strings.TrimSpace(s))
+
+	if len(m) == 0 {
+		return fmt.Errorf("%w: %q", ErrInvalidIdent, s)
+	}
+
+	return b.checkIdent(m[1])
+}
+
+func (b *queryBuilder) checkColumns(columns []string, fn func(s string) error) error {
+	if !b.strict {
+		return nil
+	}
+
+	for _, v := range columns {
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
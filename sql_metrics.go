@@ -0,0 +1,114 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// SQLMetrics is the Prometheus collector of the statements executed by the executors, eg:
+//
+//	metrics := yiigo.NewSQLMetrics("app")
+//	prometheus.MustRegister(metrics)
+//
+//	builder := yiigo.NewMySQLBuilder(yiigo.WithSQLMetrics(metrics))
+//
+// The metrics (labeled by table and operation):
+//   - {namespace}_sql_statements_total: the number of the statements, labeled by status (ok, no_rows, error) as well
+//   - {namespace}_sql_statement_duration_seconds: the histogram of the statement duration
+//   - {namespace}_sql_retries_total: the number of the retries of the reads (see `WithReadRetry`)
+type SQLMetrics struct {
+	statements *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+	retries    *prometheus.CounterVec
+}
+
+// NewSQLMetrics returns new SQLMetrics, the buckets default to prometheus.DefBuckets.
+func NewSQLMetrics(namespace string, buckets ...float64) *SQLMetrics {
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+
+	return &SQLMetrics{
+		statements: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sql",
+			Name:      "statements_total",
+			Help:      "The number of the executed statements.",
+		}, []string{"table", "operation", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "sql",
+			Name:      "statement_duration_seconds",
+			Help:      "The duration of the executed statements.",
+			Buckets:   buckets,
+		}, []string{"table", "operation"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sql",
+			Name:      "retries_total",
+			Help:      "The number of the retries of the statements on the transient failures.",
+		}, []string{"table", "operation"}),
+	}
+}
+
+func (m *SQLMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.statements.Describe(ch)
+	m.duration.Describe(ch)
+	m.retries.Describe(ch)
+}
+
+func (m *SQLMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.statements.Collect(ch)
+	m.duration.Collect(ch)
+	m.retries.Collect(ch)
+}
+
+func (m *SQLMetrics) observe(ctx context.Context, stmt *ExecutedStatement, next func(ctx context.Context) error) error {
+	if stmt.Attempt > 1 {
+		m.retries.WithLabelValues(stmt.Table, string(stmt.Kind)).Inc()
+	}
+
+	now := time.Now()
+
+	err := next(ctx)
+
+	status := "ok"
+
+	if err != nil {
+		status = "error"
+
+		if errors.Is(err, sql.ErrNoRows) {
+			status = "no_rows"
+		}
+	}
+
+	m.statements.WithLabelValues(stmt.Table, string(stmt.Kind), status).Inc()
+	m.duration.WithLabelValues(stmt.Table, string(stmt.Kind)).Observe(time.Since(now).Seconds())
+
+	return err
+}
+
+// WithSQLMetrics records the metrics of the statements executed by the executors.
+func WithSQLMetrics(m *SQLMetrics) SQLBuilderOption {
+	return OnExec(m.observe)
+}
+
+// RegisterDBStats registers the collectors of sql.DBStats (open, idle, wait count, wait duration, etc.) for the dbs
+// registered by `Init`, which are labeled by db_name, eg: yiigo.RegisterDBStats(prometheus.DefaultRegisterer).
+func RegisterDBStats(reg prometheus.Registerer) error {
+	var err error
+
+	dbmap.Range(func(key, value any) bool {
+		err = reg.Register(collectors.NewDBStatsCollector(value.(*sqlx.DB).DB, key.(string)))
+
+		return err == nil
+	})
+
+	return err
+}
@@ -0,0 +1,67 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLMetrics(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := OpenSQLiteMemory()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT)")
+	assert.Nil(t, err)
+
+	metrics := NewSQLMetrics("test")
+
+	builder := NewSQLiteBuilder(WithSQLMetrics(metrics))
+
+	_, err = builder.Wrap(Table("user")).Insert(ctx, db, X{"name": "foo"})
+	assert.Nil(t, err)
+
+	var name string
+
+	assert.Nil(t, builder.Wrap(Table("user"), Select("name"), Where("id = ?", 1)).Get(ctx, db, &name))
+
+	err = builder.Wrap(Table("user"), Select("name"), Where("id = ?", 2)).Get(ctx, db, &name)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = builder.Wrap(Table("user"), Where("id = ?", 1)).Update(ctx, db, X{"unknown": 1})
+	assert.NotNil(t, err)
+
+	expected := `
+# HELP test_sql_statements_total The number of the executed statements.
+# TYPE test_sql_statements_total counter
+test_sql_statements_total{operation="INSERT",status="ok",table="user"} 1
+test_sql_statements_total{operation="SELECT",status="no_rows",table="user"} 1
+test_sql_statements_total{operation="SELECT",status="ok",table="user"} 1
+test_sql_statements_total{operation="UPDATE",status="error",table="user"} 1
+`
+
+	assert.Nil(t, testutil.CollectAndCompare(metrics, strings.NewReader(expected), "test_sql_statements_total"))
+	assert.Equal(t, 3, testutil.CollectAndCount(metrics, "test_sql_statement_duration_seconds"))
+
+	// db stats
+	reg := prometheus.NewRegistry()
+
+	dbmap.Store("metrics_test", db)
+
+	defer dbmap.Delete("metrics_test")
+
+	assert.Nil(t, RegisterDBStats(reg))
+
+	n, err := testutil.GatherAndCount(reg, "go_sql_open_connections")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+}
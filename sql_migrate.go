@@ -0,0 +1,679 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// ErrMigrationLock failed to acquire the migration lock in time.
+var ErrMigrationLock = errors.New("migration lock timeout")
+
+// eg: 20260101120000_create_user.up.sql
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration the versioned migration, the Up (Down) is executed in the transaction.
+// NOTE: The DDL of MySQL causes implicit commit, so it can't be rolled back on failure.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(ctx context.Context, tx *sqlx.Tx) error
+	Down    func(ctx context.Context, tx *sqlx.Tx) error
+
+	upSQL   string
+	downSQL string
+}
+
+// SQLMigration returns the migration which executes the SQL statements split by `SplitSQL` with the dialect of the db,
+// the Up (Down) is nil if up (down) is empty (or comment only), so the migration without up is rejected by `NewMigrator`.
+func SQLMigration(version int64, name, up, down string) *Migration {
+	m := &Migration{
+		Version: version,
+		Name:    name,
+		upSQL:   up,
+		downSQL: down,
+	}
+
+	if hasStatements(up) {
+		m.Up = execStatements(up)
+	}
+
+	if hasStatements(down) {
+		m.Down = execStatements(down)
+	}
+
+	return m
+}
+
+// hasStatements reports whether the script has statements, the invalid script fails on execution.
+func hasStatements(script string) bool {
+	stmts, err := SplitSQL("", script)
+
+	return err != nil || len(stmts) != 0
+}
+
+func execStatements(script string) func(ctx context.Context, tx *sqlx.Tx) error {
+	return func(ctx context.Context, tx *sqlx.Tx) error {
+		stmts, err := SplitSQL(DBDriver(tx.DriverName()), script)
+
+		if err != nil {
+			return err
+		}
+
+		for _, v := range stmts {
+			if _, err = tx.ExecContext(ctx, v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// MigrationStatus the status of the migration.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type migrateOptions struct {
+	table       string
+	migrations  []*Migration
+	fsys        fs.FS
+	dir         string
+	dryRun      bool
+	lockTimeout time.Duration
+}
+
+// MigrateOption migration option
+type MigrateOption func(o *migrateOptions)
+
+// WithMigrationTable specifies the table which tracks the applied versions, default: schema_migrations.
+func WithMigrationTable(name string) MigrateOption {
+	return func(o *migrateOptions) {
+		o.table = name
+	}
+}
+
+// WithMigrations specifies the migrations, eg: Go func migration or `SQLMigration`.
+func WithMigrations(migrations ...*Migration) MigrateOption {
+	return func(o *migrateOptions) {
+		o.migrations = append(o.migrations, migrations...)
+	}
+}
+
+// WithMigrationFS specifies the SQL file migrations in the dir of fsys (eg: embed.FS),
+// the file is named `{version}_{name}.up.sql` and `{version}_{name}.down.sql`, eg: 20260101120000_create_user.up.sql.
+func WithMigrationFS(fsys fs.FS, dir string) MigrateOption {
+	return func(o *migrateOptions) {
+		o.fsys = fsys
+		o.dir = dir
+	}
+}
+
+// WithMigrationDryRun returns (and logs) the migrations to execute without executing them.
+func WithMigrationDryRun() MigrateOption {
+	return func(o *migrateOptions) {
+		o.dryRun = true
+	}
+}
+
+// WithMigrationLockTimeout specifies the timeout to acquire the migration lock, default: 1 minute.
+func WithMigrationLockTimeout(d time.Duration) MigrateOption {
+	return func(o *migrateOptions) {
+		o.lockTimeout = d
+	}
+}
+
+// Migrator runs the versioned migrations, which are serialized across processes by the database lock
+// (MySQL GET_LOCK, Postgres advisory lock, SQL Server sp_getapplock), so concurrent deploys don't double-apply.
+type Migrator struct {
+	db         *sqlx.DB
+	driver     DBDriver
+	builder    SQLBuilder
+	options    *migrateOptions
+	migrations []*Migration
+}
+
+// NewMigrator returns new Migrator, returns error if the SQL files are invalid, the versions are duplicate or the Up is nil.
+func NewMigrator(db *sqlx.DB, options ...MigrateOption) (*Migrator, error) {
+	o := &migrateOptions{
+		table:       "schema_migrations",
+		lockTimeout: time.Minute,
+	}
+
+	for _, f := range options {
+		f(o)
+	}
+
+	migrations := append(make([]*Migration, 0, len(o.migrations)), o.migrations...)
+
+	if o.fsys != nil {
+		files, err := loadMigrationFS(o.fsys, o.dir)
+
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, files...)
+	}
+
+	for _, v := range migrations {
+		if v == nil {
+			return nil, errors.New("nil migration")
+		}
+
+		if v.Up == nil {
+			return nil, fmt.Errorf("migration %d_%s: up not found", v.Version, v.Name)
+		}
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].Version)
+		}
+	}
+
+	driver := DBDriver(db.DriverName())
+
+	return &Migrator{
+		db:         db,
+		driver:     driver,
+		builder:    NewSQLBuilder(driver),
+		options:    o,
+		migrations: migrations,
+	}, nil
+}
+
+// Migrate applies the pending migrations, returns the applied migrations.
+func Migrate(ctx context.Context, db *sqlx.DB, options ...MigrateOption) ([]*Migration, error) {
+	m, err := NewMigrator(db, options...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Up(ctx)
+}
+
+func loadMigrationFS(fsys fs.FS, dir string) ([]*Migration, error) {
+	if len(dir) == 0 {
+		dir = "."
+	}
+
+	entries, err := fs.ReadDir(fsys, dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	type files struct {
+		name     string
+		up, down string
+		hasUp    bool
+	}
+
+	versions := make(map[int64]*files)
+
+	for _, v := range entries {
+		m := migrationFileRegexp.FindStringSubmatch(v.Name())
+
+		if v.IsDir() || len(m) == 0 {
+			continue
+		}
+
+		version, err := strconv.ParseInt(m[1], 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", v.Name(), err)
+		}
+
+		b, err := fs.ReadFile(fsys, path.Join(dir, v.Name()))
+
+		if err != nil {
+			return nil, err
+		}
+
+		f, ok := versions[version]
+
+		if !ok {
+			f = &files{name: m[2]}
+			versions[version] = f
+		}
+
+		if f.name != m[2] {
+			return nil, fmt.Errorf("duplicate migration version %d", version)
+		}
+
+		if m[3] == "up" {
+			f.up, f.hasUp = string(b), true
+		} else {
+			f.down = string(b)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(versions))
+
+	for version, f := range versions {
+		if !f.hasUp {
+			return nil, fmt.Errorf("migration %d_%s: up file not found", version, f.name)
+		}
+
+		migrations = append(migrations, SQLMigration(version, f.name, f.up, f.down))
+	}
+
+	return migrations, nil
+}
+
+// Up applies the pending migrations in order of version, returns the applied (to apply if dry run) migrations.
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	done := make([]*Migration, 0)
+
+	err := m.run(ctx, func(conn *sqlx.Conn) error {
+		applied, err := m.applied(ctx, conn)
+
+		if err != nil {
+			return err
+		}
+
+		for _, v := range m.migrations {
+			if _, ok := applied[v.Version]; ok {
+				continue
+			}
+
+			if m.options.dryRun {
+				stmts, err := SplitSQL(m.driver, v.upSQL)
+
+				if err != nil {
+					return fmt.Errorf("migration %d_%s up: %w", v.Version, v.Name, err)
+				}
+
+				logger.Info("migrate up (dry run)", zap.Int64("version", v.Version), zap.String("name", v.Name), zap.Strings("sql", stmts))
+
+				done = append(done, v)
+
+				continue
+			}
+
+			ok, err := m.exec(ctx, conn, v, true)
+
+			if err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", v.Version, v.Name, err)
+			}
+
+			if !ok {
+				continue
+			}
+
+			logger.Info("migrate up", zap.Int64("version", v.Version), zap.String("name", v.Name))
+
+			done = append(done, v)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Down rolls back the latest applied migrations (in reverse order of version) by steps,
+// returns the rolled back (to roll back if dry run) migrations, and error if steps is negative or the Down is nil.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	if steps < 0 {
+		return nil, fmt.Errorf("invalid migrate down steps %d", steps)
+	}
+
+	done := make([]*Migration, 0, steps)
+
+	err := m.run(ctx, func(conn *sqlx.Conn) error {
+		applied, err := m.applied(ctx, conn)
+
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int64, 0, len(applied))
+
+		for v := range applied {
+			versions = append(versions, v)
+		}
+
+		sort.Slice(versions, func(i, j int) bool {
+			return versions[i] > versions[j]
+		})
+
+		if steps < len(versions) {
+			versions = versions[:steps]
+		}
+
+		for _, version := range versions {
+			v := m.migration(version)
+
+			if v == nil {
+				return fmt.Errorf("migration %d not found", version)
+			}
+
+			if v.Down == nil {
+				return fmt.Errorf("migration %d_%s: down not found", v.Version, v.Name)
+			}
+
+			if m.options.dryRun {
+				stmts, err := SplitSQL(m.driver, v.downSQL)
+
+				if err != nil {
+					return fmt.Errorf("migration %d_%s down: %w", v.Version, v.Name, err)
+				}
+
+				logger.Info("migrate down (dry run)", zap.Int64("version", v.Version), zap.String("name", v.Name), zap.Strings("sql", stmts))
+
+				done = append(done, v)
+
+				continue
+			}
+
+			ok, err := m.exec(ctx, conn, v, false)
+
+			if err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", v.Version, v.Name, err)
+			}
+
+			if !ok {
+				continue
+			}
+
+			logger.Info("migrate down", zap.Int64("version", v.Version), zap.String("name", v.Name))
+
+			done = append(done, v)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Status returns the status of the migrations (including the applied versions not found) in order of version.
+func (m *Migrator) Status(ctx context.Context) ([]*MigrationStatus, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(ctx, m.db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]*MigrationStatus, 0, len(m.migrations))
+
+	for _, v := range m.migrations {
+		at, ok := applied[v.Version]
+
+		status = append(status, &MigrationStatus{
+			Version:   v.Version,
+			Name:      v.Name,
+			Applied:   ok,
+			AppliedAt: at,
+		})
+
+		delete(applied, v.Version)
+	}
+
+	for version, at := range applied {
+		status = append(status, &MigrationStatus{
+			Version:   version,
+			Applied:   true,
+			AppliedAt: at,
+		})
+	}
+
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Version < status[j].Version
+	})
+
+	return status, nil
+}
+
+func (m *Migrator) migration(version int64) *Migration {
+	for _, v := range m.migrations {
+		if v.Version == version {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// run calls fn with the connection which holds the migration lock, the dry run doesn't lock.
+func (m *Migrator) run(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	if !m.options.dryRun {
+		if err := m.createTable(ctx); err != nil {
+			return err
+		}
+	}
+
+	conn, err := m.db.Connx(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if m.options.dryRun {
+		return fn(conn)
+	}
+
+	if err = m.lock(ctx, conn); err != nil {
+		return err
+	}
+
+	defer m.unlock(conn)
+
+	return fn(conn)
+}
+
+// exec executes the migration and records the version in the transaction, returns false if it's done by others.
+func (m *Migrator) exec(ctx context.Context, conn *sqlx.Conn, v *Migration, up bool) (bool, error) {
+	tx, err := conn.BeginTxx(ctx, nil)
+
+	if err != nil {
+		return false, err
+	}
+
+	// check again in the transaction, in case the lock is unsupported (eg: SQLite)
+	var count int
+
+	if err = m.builder.Wrap(Table(m.options.table), Select("COUNT(*)"), Where("version = ?", v.Version)).Get(ctx, tx, &count); err != nil {
+		rollback(tx)
+
+		return false, err
+	}
+
+	// applied for up, or rolled back for down
+	if applied := count != 0; applied == up {
+		rollback(tx)
+
+		return false, nil
+	}
+
+	if up {
+		err = v.Up(ctx, tx)
+	} else {
+		err = v.Down(ctx, tx)
+	}
+
+	if err != nil {
+		rollback(tx)
+
+		return false, err
+	}
+
+	if up {
+		_, err = m.builder.Wrap(Table(m.options.table)).BatchInsert(ctx, tx, []X{{
+			"version":    v.Version,
+			"name":       v.Name,
+			"applied_at": time.Now(),
+		}})
+	} else {
+		_, err = m.builder.Wrap(Table(m.options.table), Where("version = ?", v.Version)).Delete(ctx, tx)
+	}
+
+	if err != nil {
+		rollback(tx)
+
+		return false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// applied returns the applied versions, the dry run returns empty if the table doesn't exist.
+func (m *Migrator) applied(ctx context.Context, db sqlx.QueryerContext) (map[int64]time.Time, error) {
+	var rows []struct {
+		Version   int64     `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}
+
+	if err := m.builder.Wrap(Table(m.options.table), Select("version", "applied_at")).Select(ctx, db, &rows); err != nil {
+		if m.options.dryRun {
+			logger.Warn("migrate (dry run) assumes no applied versions", zap.Error(err))
+
+			return map[int64]time.Time{}, nil
+		}
+
+		return nil, err
+	}
+
+	applied := make(map[int64]time.Time, len(rows))
+
+	for _, v := range rows {
+		applied[v.Version] = v.AppliedAt
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) createTable(ctx context.Context) error {
+	sb := NewSchemaBuilder(m.driver)
+
+	stmts, err := sb.CreateTable(m.options.table, func(t *TableDef) {
+		if m.driver != SQLServer {
+			t.IfNotExists()
+		}
+
+		t.BigInt("version").PrimaryKey()
+		t.String("name", 255).NotNull()
+		t.Timestamp("applied_at").NotNull()
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, v := range stmts {
+		if m.driver == SQLServer {
+			v = "IF OBJECT_ID(N" + quoteString(m.options.table) + ", N'U') IS NULL " + v
+		}
+
+		if _, err = m.db.ExecContext(ctx, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) lockName() string {
+	return "yiigo:migrate:" + m.options.table
+}
+
+func (m *Migrator) lock(ctx context.Context, conn *sqlx.Conn) error {
+	var (
+		ok  bool
+		err error
+	)
+
+	switch m.driver {
+	case MySQL:
+		var ret sql.NullInt64
+
+		err = conn.GetContext(ctx, &ret, "SELECT GET_LOCK(?, ?)", m.lockName(), int(m.options.lockTimeout.Seconds()))
+
+		ok = ret.Valid && ret.Int64 == 1
+	case Postgres:
+		lockCtx, cancel := context.WithTimeout(ctx, m.options.lockTimeout)
+
+		defer cancel()
+
+		_, err = conn.ExecContext(lockCtx, "SELECT pg_advisory_lock($1)", int64(crc32.ChecksumIEEE([]byte(m.lockName()))))
+
+		// the lock is waited until timeout
+		if err != nil && ctx.Err() == nil && lockCtx.Err() != nil {
+			return ErrMigrationLock
+		}
+
+		ok = true
+	case SQLServer:
+		var ret int
+
+		err = conn.GetContext(ctx, &ret, "DECLARE @ret INT; EXEC @ret = sp_getapplock @Resource = @p1, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = @p2; SELECT @ret",
+			m.lockName(), m.options.lockTimeout.Milliseconds())
+
+		ok = ret >= 0
+	default:
+		// SQLite locks the database on write
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrMigrationLock
+	}
+
+	return nil
+}
+
+func (m *Migrator) unlock(conn *sqlx.Conn) {
+	// the lock is released even if ctx is canceled
+	ctx := context.Background()
+
+	var err error
+
+	switch m.driver {
+	case MySQL:
+		_, err = conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", m.lockName())
+	case Postgres:
+		_, err = conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", int64(crc32.ChecksumIEEE([]byte(m.lockName()))))
+	case SQLServer:
+		_, err = conn.ExecContext(ctx, "EXEC sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'", m.lockName())
+	}
+
+	if err != nil {
+		logger.Error("err migrate unlock", zap.Error(err))
+	}
+}
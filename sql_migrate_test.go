@@ -0,0 +1,191 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLMigration(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	m := SQLMigration(1, "create_user", `
+-- create table
+CREATE TABLE user (
+    id INTEGER PRIMARY KEY,
+    name TEXT DEFAULT 'a;b'
+); CREATE INDEX idx_name ON user (name);
+INSERT INTO user (id, name) VALUES (1, 'x;
+y'); INSERT INTO user (id) VALUES (2);`, "-- only comment;")
+
+	assert.Nil(t, m.Down)
+
+	tx, err := db.Beginx()
+
+	assert.Nil(t, err)
+	assert.Nil(t, m.Up(ctx, tx))
+	assert.Nil(t, tx.Commit())
+
+	var names []string
+
+	assert.Nil(t, db.Select(&names, "SELECT name FROM user ORDER BY id"))
+	assert.Equal(t, []string{"x;\ny", "a;b"}, names)
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	fsys := fstest.MapFS{
+		"migrations/1_create_user.up.sql":    {Data: []byte("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT);\nCREATE INDEX idx_user_name ON user (name);")},
+		"migrations/1_create_user.down.sql":  {Data: []byte("DROP TABLE user;")},
+		"migrations/3_create_order.up.sql":   {Data: []byte("CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER);")},
+		"migrations/3_create_order.down.sql": {Data: []byte("DROP TABLE orders;")},
+		"migrations/README.md":               {Data: []byte("ignored")},
+	}
+
+	seed := &Migration{
+		Version: 2,
+		Name:    "seed_user",
+		Up: func(ctx context.Context, tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(ctx, "INSERT INTO user (name) VALUES ('yiigo')")
+
+			return err
+		},
+		Down: func(ctx context.Context, tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(ctx, "DELETE FROM user")
+
+			return err
+		},
+	}
+
+	versions := func(migrations []*Migration) []int64 {
+		ret := make([]int64, 0, len(migrations))
+
+		for _, v := range migrations {
+			ret = append(ret, v.Version)
+		}
+
+		return ret
+	}
+
+	// dry run
+	done, err := Migrate(ctx, db, WithMigrationFS(fsys, "migrations"), WithMigrations(seed), WithMigrationDryRun())
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, versions(done))
+
+	var count int
+
+	assert.Nil(t, db.Get(&count, "SELECT COUNT(*) FROM sqlite_master WHERE name IN ('user', 'schema_migrations')"))
+	assert.Equal(t, 0, count)
+
+	// up
+	m, err := NewMigrator(db, WithMigrationFS(fsys, "migrations"), WithMigrations(seed))
+	assert.Nil(t, err)
+
+	done, err = m.Up(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, versions(done))
+
+	assert.Nil(t, db.Get(&count, "SELECT COUNT(*) FROM user"))
+	assert.Equal(t, 1, count)
+
+	done, err = m.Up(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, done, 0)
+
+	status, err := m.Status(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, status, 3)
+
+	for _, v := range status {
+		assert.True(t, v.Applied)
+		assert.False(t, v.AppliedAt.IsZero())
+	}
+
+	assert.Equal(t, "create_order", status[2].Name)
+
+	// down
+	done, err = m.Down(ctx, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{3, 2}, versions(done))
+
+	assert.Nil(t, db.Get(&count, "SELECT COUNT(*) FROM user"))
+	assert.Equal(t, 0, count)
+
+	status, err = m.Status(ctx)
+	assert.Nil(t, err)
+	assert.True(t, status[0].Applied)
+	assert.False(t, status[1].Applied)
+	assert.False(t, status[2].Applied)
+
+	// failed migration is rolled back
+	errFailed := errors.New("failed")
+
+	broken := &Migration{
+		Version: 4,
+		Name:    "broken",
+		Up: func(ctx context.Context, tx *sqlx.Tx) error {
+			if _, err := tx.ExecContext(ctx, "CREATE TABLE broken (id INTEGER)"); err != nil {
+				return err
+			}
+
+			return errFailed
+		},
+	}
+
+	done, err = Migrate(ctx, db, WithMigrationFS(fsys, "migrations"), WithMigrations(seed, broken))
+	assert.ErrorIs(t, err, errFailed)
+	assert.Equal(t, []int64{2, 3}, versions(done))
+
+	assert.Nil(t, db.Get(&count, "SELECT COUNT(*) FROM sqlite_master WHERE name = 'broken'"))
+	assert.Equal(t, 0, count)
+
+	// down not found
+	m, err = NewMigrator(db, WithMigrationFS(fsys, "migrations"), WithMigrations(seed, SQLMigration(5, "no_down", "SELECT 1;", "")))
+	assert.Nil(t, err)
+
+	_, err = m.Up(ctx)
+	assert.Nil(t, err)
+
+	_, err = m.Down(ctx, 1)
+	assert.NotNil(t, err)
+
+	// negative steps
+	_, err = m.Down(ctx, -1)
+	assert.NotNil(t, err)
+
+	// up not found
+	_, err = NewMigrator(db, WithMigrations(&Migration{Version: 6, Name: "no_up"}))
+	assert.NotNil(t, err)
+
+	_, err = NewMigrator(db, WithMigrations(SQLMigration(6, "empty_up", "-- nothing", "SELECT 1;")))
+	assert.NotNil(t, err)
+
+	// the down file only
+	_, err = NewMigrator(db, WithMigrationFS(fstest.MapFS{"0001_init.down.sql": {Data: []byte("DROP TABLE user;")}}, "."))
+	assert.NotNil(t, err)
+
+	// duplicate version
+	_, err = NewMigrator(db, WithMigrationFS(fsys, "migrations"), WithMigrations(SQLMigration(1, "dup", "SELECT 1;", "")))
+	assert.NotNil(t, err)
+}
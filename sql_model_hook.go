@@ -0,0 +1,74 @@
+package yiigo
+
+import (
+	"context"
+	"reflect"
+)
+
+// BeforeInserter is implemented by the model which is called before `Insert` and `BatchInsert`,
+// the error aborts the statement. It's the place for validation and audit fields, eg: created_by.
+type BeforeInserter interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+// AfterInserter is implemented by the model which is called after `Insert` and `BatchInsert` succeed.
+type AfterInserter interface {
+	AfterInsert(ctx context.Context) error
+}
+
+// BeforeUpdater is implemented by the model which is called before `Update`, the error aborts the statement.
+type BeforeUpdater interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterUpdater is implemented by the model which is called after `Update` succeeds.
+type AfterUpdater interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+// AfterFinder is implemented by the model which is called after scanned by `SQLWrapperOf` (and `Repo`).
+type AfterFinder interface {
+	AfterFind(ctx context.Context) error
+}
+
+// callModelHooks calls fn with each model (the data or the elements of slice) implements H.
+// NOTE: The hooks with pointer receiver are called only if the model is addressable (eg: *struct, []struct).
+func callModelHooks[H any](data any, fn func(h H) error) error {
+	if data == nil {
+		return nil
+	}
+
+	if h, ok := data.(H); ok {
+		return fn(h)
+	}
+
+	v := reflect.ValueOf(data)
+
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+
+		if elem.Kind() == reflect.Ptr && elem.IsNil() {
+			continue
+		}
+
+		if elem.Kind() != reflect.Ptr && elem.CanAddr() {
+			elem = elem.Addr()
+		}
+
+		if h, ok := elem.Interface().(H); ok {
+			if err := fn(h); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+var errEmptyName = errors.New("empty name")
+
+type HookUser struct {
+	ID        int64  `db:"id,omitempty"`
+	Name      string `db:"name"`
+	CreatedBy string `db:"created_by"`
+	Display   string `db:"-"`
+
+	events []string
+}
+
+func (u *HookUser) BeforeInsert(ctx context.Context) error {
+	if len(u.Name) == 0 {
+		return errEmptyName
+	}
+
+	u.CreatedBy = "system"
+	u.events = append(u.events, "before_insert")
+
+	return nil
+}
+
+func (u *HookUser) AfterInsert(ctx context.Context) error {
+	u.events = append(u.events, "after_insert")
+
+	return nil
+}
+
+func (u *HookUser) BeforeUpdate(ctx context.Context) error {
+	u.Name = strings.TrimSpace(u.Name)
+	u.events = append(u.events, "before_update")
+
+	return nil
+}
+
+func (u *HookUser) AfterUpdate(ctx context.Context) error {
+	u.events = append(u.events, "after_update")
+
+	return nil
+}
+
+func (u *HookUser) AfterFind(ctx context.Context) error {
+	u.Display = strings.ToUpper(u.Name)
+
+	return nil
+}
+
+func TestModelHooks(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT, created_by TEXT)")
+	assert.Nil(t, err)
+
+	builder := NewSQLiteBuilder()
+
+	// insert
+	user := &HookUser{Name: "yiigo"}
+
+	_, err = builder.Wrap(Table("user")).Insert(ctx, db, user)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "system", user.CreatedBy)
+	assert.Equal(t, []string{"before_insert", "after_insert"}, user.events)
+
+	_, err = builder.Wrap(Table("user")).Insert(ctx, db, &HookUser{})
+	assert.ErrorIs(t, err, errEmptyName)
+
+	// batch insert
+	users := []HookUser{{Name: "foo"}, {Name: "bar"}}
+
+	_, err = builder.Wrap(Table("user")).BatchInsert(ctx, db, users)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "system", users[1].CreatedBy)
+	assert.Equal(t, []string{"before_insert", "after_insert"}, users[1].events)
+
+	_, err = builder.Wrap(Table("user")).BatchInsert(ctx, db, []*HookUser{{Name: "baz"}, {}})
+	assert.ErrorIs(t, err, errEmptyName)
+
+	// update
+	user = &HookUser{Name: " shenghui ", CreatedBy: "admin"}
+
+	_, err = builder.Wrap(Table("user"), Where("id = ?", 1)).Update(ctx, db, user)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"before_update", "after_update"}, user.events)
+
+	// find
+	found, err := WrapOf[HookUser](builder, Table("user"), Where("id = ?", 1)).Get(ctx, db)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "shenghui", found.Name)
+	assert.Equal(t, "SHENGHUI", found.Display)
+
+	list, err := NewRepo[HookUser](builder, db, WithRepoTable("user")).FindAll(ctx, OrderBy("id"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(list))
+	assert.Equal(t, "BAR", list[2].Display)
+}
@@ -0,0 +1,83 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMSSQLBuilder(t *testing.T) {
+	ctx := context.TODO()
+
+	builder := NewMSSQLBuilder()
+
+	sql, args, err := builder.Wrap(
+		Table("user"),
+		Where("age > ?", 20),
+		Limit(10),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT TOP (@p1) * FROM [user] WHERE age > @p2", sql)
+	assert.Equal(t, []any{10, 20}, args)
+
+	sql, args, err = builder.Wrap(
+		Table("user"),
+		Where("age > ?", 20),
+		OrderBy("id DESC"),
+		Offset(20),
+		Limit(10),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM [user] WHERE age > @p1 ORDER BY [id] DESC OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY", sql)
+	assert.Equal(t, []any{20, 20, 10}, args)
+
+	sql, args, err = builder.Wrap(Table("user"), Offset(20)).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM [user] ORDER BY (SELECT NULL) OFFSET @p1 ROWS", sql)
+	assert.Equal(t, []any{20}, args)
+
+	sql, args, err = builder.Wrap(Table("user")).ToInsert(ctx, X{"name": "yiigo"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO [user] ([name]) VALUES (@p1)", sql)
+	assert.Equal(t, []any{"yiigo"}, args)
+
+	sql, _, err = builder.Wrap(Table("log")).ToInsert(ctx, X{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO [log] DEFAULT VALUES", sql)
+
+	sql, args, err = builder.Wrap(
+		Table("user"),
+		Where("id = ?", 1),
+	).ToUpdate(ctx, X{"name": "yiigo"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE [user] SET [name] = @p1 WHERE id = @p2", sql)
+	assert.Equal(t, []any{"yiigo", 1}, args)
+
+	// the alias is referenced by the FROM clause
+	sql, args, err = builder.Wrap(
+		TableAs("user", "u"),
+		Where("u.id = ?", 1),
+	).ToUpdate(ctx, X{"name": "yiigo"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE [u] SET [name] = @p1 FROM [user] AS [u] WHERE u.id = @p2", sql)
+	assert.Equal(t, []any{"yiigo", 1}, args)
+
+	sql, args, err = builder.Wrap(Table("user u"), Where("u.id = ?", 1)).ToDelete(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE [u] FROM [user] AS [u] WHERE u.id = @p1", sql)
+	assert.Equal(t, []any{1}, args)
+
+	sql, err = builder.Wrap(Table("user")).ToTruncate(ctx, RestartIdentity())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "TRUNCATE TABLE [user]", sql)
+}
@@ -0,0 +1,378 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type relationKind int
+
+const (
+	hasMany relationKind = iota
+	hasOne
+	belongsTo
+)
+
+// Relation the relation between models which is eager loaded by `Repo.With`.
+type Relation struct {
+	kind       relationKind
+	name       string
+	field      string
+	foreignKey string
+	references string
+	table      string
+	model      reflect.Type
+	options    []QueryOption
+	relations  map[string]*Relation
+}
+
+func newRelation[T any](kind relationKind, name, field, foreignKey string) *Relation {
+	return &Relation{
+		kind:       kind,
+		name:       name,
+		field:      field,
+		foreignKey: foreignKey,
+		references: "id",
+		model:      reflect.TypeOf((*T)(nil)).Elem(),
+	}
+}
+
+// HasMany declares the relation that the model has many T, the foreign key is the column of T, eg:
+// yiigo.HasMany[Order]("orders", "Orders", "user_id") loads `Orders []Order` by `order.user_id IN (user.id...)`.
+func HasMany[T any](name, field, foreignKey string) *Relation {
+	return newRelation[T](hasMany, name, field, foreignKey)
+}
+
+// HasOne declares the relation that the model has one T, the foreign key is the column of T, eg:
+// yiigo.HasOne[Profile]("profile", "Profile", "user_id") loads `Profile *Profile` by `profile.user_id IN (user.id...)`.
+func HasOne[T any](name, field, foreignKey string) *Relation {
+	return newRelation[T](hasOne, name, field, foreignKey)
+}
+
+// BelongsTo declares the relation that the model belongs to T, the foreign key is the column of the model, eg:
+// yiigo.BelongsTo[User]("user", "User", "user_id") loads `User *User` by `user.id IN (order.user_id...)`.
+func BelongsTo[T any](name, field, foreignKey string) *Relation {
+	return newRelation[T](belongsTo, name, field, foreignKey)
+}
+
+// References specifies the referenced column (default: id), which is the column of the model for HasMany (HasOne),
+// and the column of T for BelongsTo.
+func (rel *Relation) References(column string) *Relation {
+	rel.references = column
+
+	return rel
+}
+
+// Table specifies the table of T, which is resolved like `Repo` by default.
+func (rel *Relation) Table(name string) *Relation {
+	rel.table = name
+
+	return rel
+}
+
+// Options specifies the query options of T, eg: yiigo.Where("status = ?", 1), yiigo.OrderBy("id DESC").
+func (rel *Relation) Options(options ...QueryOption) *Relation {
+	rel.options = append(rel.options, options...)
+
+	return rel
+}
+
+// Nested declares the relations of T, which are loaded by the path, eg: With("orders.items").
+func (rel *Relation) Nested(relations ...*Relation) *Relation {
+	if rel.relations == nil {
+		rel.relations = make(map[string]*Relation, len(relations))
+	}
+
+	for _, v := range relations {
+		rel.relations[v.name] = v
+	}
+
+	return rel
+}
+
+// WithRelations declares the relations of the model, which are eager loaded by `Repo.With`.
+func WithRelations(relations ...*Relation) RepoOption {
+	return func(o *repoOptions) {
+		if o.relations == nil {
+			o.relations = make(map[string]*Relation, len(relations))
+		}
+
+		for _, v := range relations {
+			o.relations[v.name] = v
+		}
+	}
+}
+
+// With returns the Repo which eager loads the relations by batched `IN` queries (avoid N+1) after
+// `FindByID`, `FindOne`, `FindAll` and `Paginate`, the nested relation is specified by path, eg: With("orders", "orders.items").
+func (r *Repo[T]) With(relations ...string) *Repo[T] {
+	repo := *r
+
+	repo.with = append(cloneSlice(r.with), relations...)
+
+	return &repo
+}
+
+type relationLoader struct {
+	builder  SQLBuilder
+	db       sqlx.QueryerContext
+	resolver TableResolver
+}
+
+// relationTree parses the paths, eg: ["orders", "orders.items"] -> {"orders": ["items"]}.
+func relationTree(paths []string) map[string][]string {
+	tree := make(map[string][]string, len(paths))
+
+	for _, v := range paths {
+		name, sub, _ := strings.Cut(v, ".")
+
+		if len(sub) != 0 {
+			tree[name] = append(tree[name], sub)
+		} else if _, ok := tree[name]; !ok {
+			tree[name] = nil
+		}
+	}
+
+	return tree
+}
+
+// load loads the relations of the models (slice of struct or *struct).
+func (l *relationLoader) load(ctx context.Context, models reflect.Value, relations map[string]*Relation, paths []string) error {
+	if models.Len() == 0 || len(paths) == 0 {
+		return nil
+	}
+
+	for name, sub := range relationTree(paths) {
+		rel, ok := relations[name]
+
+		if !ok {
+			return fmt.Errorf("unknown relation %q", name)
+		}
+
+		if err := l.loadRelation(ctx, models, rel, sub); err != nil {
+			return fmt.Errorf("relation %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (l *relationLoader) loadRelation(ctx context.Context, models reflect.Value, rel *Relation, sub []string) error {
+	// the column of the model to collect keys, and the column of T to match
+	localColumn, remoteColumn := rel.references, rel.foreignKey
+
+	if rel.kind == belongsTo {
+		localColumn, remoteColumn = rel.foreignKey, rel.references
+	}
+
+	keys := make([]any, 0, models.Len())
+	seen := make(map[string]struct{}, models.Len())
+
+	for i := 0; i < models.Len(); i++ {
+		v, ok := structElem(models.Index(i))
+
+		if !ok {
+			continue
+		}
+
+		key, ok, err := columnKey(v, localColumn)
+
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+
+		if _, ok := seen[fmt.Sprint(key)]; !ok {
+			seen[fmt.Sprint(key)] = struct{}{}
+
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	table := rel.table
+
+	if len(table) == 0 {
+		table = tableOf(rel.model, l.resolver)
+	}
+
+	children := reflect.New(reflect.SliceOf(rel.model))
+
+	options := append([]QueryOption{
+		Table(table),
+		WhereClause(Clause(remoteColumn+" IN ("+placeholders(len(keys))+")", keys...)),
+	}, rel.options...)
+
+	if err := l.builder.Wrap(options...).Select(ctx, l.db, children.Interface()); err != nil {
+		return err
+	}
+
+	children = children.Elem()
+
+	// the nested relations are loaded before stitching, since the children are copied into the models
+	if err := l.load(ctx, children, rel.relations, sub); err != nil {
+		return err
+	}
+
+	groups := make(map[string][]reflect.Value, len(keys))
+
+	for i := 0; i < children.Len(); i++ {
+		child := children.Index(i)
+
+		v, ok := structElem(child)
+
+		if !ok {
+			continue
+		}
+
+		key, ok, err := columnKey(v, remoteColumn)
+
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			groups[fmt.Sprint(key)] = append(groups[fmt.Sprint(key)], child)
+		}
+	}
+
+	for i := 0; i < models.Len(); i++ {
+		v, ok := structElem(models.Index(i))
+
+		if !ok {
+			continue
+		}
+
+		field := v.FieldByName(rel.field)
+
+		if !field.IsValid() || !field.CanSet() {
+			return fmt.Errorf("field %s not found (or unexported) in %s", rel.field, v.Type())
+		}
+
+		key, ok, _ := columnKey(v, localColumn)
+
+		if !ok {
+			continue
+		}
+
+		if err := setRelationField(field, groups[fmt.Sprint(key)], rel.kind == hasMany); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setRelationField sets the field (T, *T, []T or []*T) with the matched children.
+func setRelationField(field reflect.Value, children []reflect.Value, many bool) error {
+	if many {
+		if field.Kind() != reflect.Slice {
+			return fmt.Errorf("field of has many expects slice, got %s", field.Type())
+		}
+
+		s := reflect.MakeSlice(field.Type(), 0, len(children))
+
+		for _, v := range children {
+			elem, err := assignable(v, field.Type().Elem())
+
+			if err != nil {
+				return err
+			}
+
+			s = reflect.Append(s, elem)
+		}
+
+		field.Set(s)
+
+		return nil
+	}
+
+	if len(children) == 0 {
+		return nil
+	}
+
+	elem, err := assignable(children[0], field.Type())
+
+	if err != nil {
+		return err
+	}
+
+	field.Set(elem)
+
+	return nil
+}
+
+// assignable returns the value (or its address) assignable to t.
+func assignable(v reflect.Value, t reflect.Type) (reflect.Value, error) {
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+
+	if v.CanAddr() && v.Addr().Type().AssignableTo(t) {
+		return v.Addr(), nil
+	}
+
+	if v.Kind() == reflect.Ptr && v.Elem().Type().AssignableTo(t) {
+		return v.Elem(), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("cannot assign %s to %s", v.Type(), t)
+}
+
+func structElem(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+
+		v = v.Elem()
+	}
+
+	return v, v.Kind() == reflect.Struct
+}
+
+// columnKey returns the value of the column (by `db` tag), ok is false if the value is NULL.
+func columnKey(v reflect.Value, column string) (any, bool, error) {
+	for _, f := range structFields(v.Type()) {
+		if f.column != column {
+			continue
+		}
+
+		fv, ok := fieldValue(v, f.index)
+
+		if !ok || isNilValue(fv) {
+			return nil, false, nil
+		}
+
+		key := fv.Interface()
+
+		if valuer, ok := key.(driver.Valuer); ok {
+			dv, err := valuer.Value()
+
+			if err != nil {
+				return nil, false, err
+			}
+
+			key = dv
+		}
+
+		if rv := reflect.ValueOf(key); rv.Kind() == reflect.Ptr {
+			key = rv.Elem().Interface()
+		}
+
+		return key, true, nil
+	}
+
+	return nil, false, fmt.Errorf("column %s not found in %s", column, v.Type())
+}
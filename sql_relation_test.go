@@ -0,0 +1,123 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type RelUser struct {
+	ID      int64       `db:"id"`
+	Name    string      `db:"name"`
+	Orders  []*RelOrder `db:"-"`
+	Profile *RelProfile `db:"-"`
+}
+
+type RelProfile struct {
+	ID     int64  `db:"id"`
+	UserID int64  `db:"user_id"`
+	Bio    string `db:"bio"`
+}
+
+type RelOrder struct {
+	ID     int64         `db:"id"`
+	UserID sql.NullInt64 `db:"user_id"`
+	Amount int           `db:"amount"`
+	Items  []RelItem     `db:"-"`
+	User   *RelUser      `db:"-"`
+}
+
+type RelItem struct {
+	ID      int64  `db:"id"`
+	OrderID int64  `db:"order_id"`
+	Name    string `db:"name"`
+}
+
+func TestRelation(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	for _, v := range []string{
+		"CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE profile (id INTEGER PRIMARY KEY, user_id INTEGER, bio TEXT)",
+		"CREATE TABLE `order` (id INTEGER PRIMARY KEY, user_id INTEGER, amount INTEGER)",
+		"CREATE TABLE item (id INTEGER PRIMARY KEY, order_id INTEGER, name TEXT)",
+		"INSERT INTO user (id, name) VALUES (1, 'foo'), (2, 'bar'), (3, 'baz')",
+		"INSERT INTO profile (id, user_id, bio) VALUES (1, 2, 'hello')",
+		"INSERT INTO `order` (id, user_id, amount) VALUES (1, 1, 10), (2, 1, 20), (3, 2, 30), (4, NULL, 40)",
+		"INSERT INTO item (id, order_id, name) VALUES (1, 1, 'a'), (2, 1, 'b'), (3, 3, 'c')",
+	} {
+		_, err = db.Exec(v)
+		assert.Nil(t, err)
+	}
+
+	builder := NewSQLiteBuilder()
+
+	users := NewRepo[RelUser](builder, db,
+		WithRepoTable("user"),
+		WithRelations(
+			HasMany[RelOrder]("orders", "Orders", "user_id").
+				Table("order").
+				Options(OrderBy("id")).
+				Nested(HasMany[RelItem]("items", "Items", "order_id").Table("item")),
+			HasOne[RelProfile]("profile", "Profile", "user_id").Table("profile"),
+		),
+	)
+
+	list, err := users.With("orders.items", "profile").FindAll(ctx, OrderBy("id"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(list))
+
+	assert.Equal(t, 2, len(list[0].Orders))
+	assert.Equal(t, 10, list[0].Orders[0].Amount)
+	assert.Equal(t, []RelItem{{ID: 1, OrderID: 1, Name: "a"}, {ID: 2, OrderID: 1, Name: "b"}}, list[0].Orders[0].Items)
+	assert.Equal(t, 0, len(list[0].Orders[1].Items))
+	assert.Nil(t, list[0].Profile)
+
+	assert.Equal(t, 1, len(list[1].Orders))
+	assert.Equal(t, "c", list[1].Orders[0].Items[0].Name)
+	assert.Equal(t, &RelProfile{ID: 1, UserID: 2, Bio: "hello"}, list[1].Profile)
+
+	assert.Equal(t, 0, len(list[2].Orders))
+
+	// without With
+	user, err := users.FindByID(ctx, 1)
+
+	assert.Nil(t, err)
+	assert.Nil(t, user.Orders)
+
+	user, err = users.With("orders").FindByID(ctx, 1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(user.Orders))
+	assert.Nil(t, user.Orders[0].Items)
+
+	// belongs to
+	orders := NewRepo[RelOrder](builder, db,
+		WithRepoTable("order"),
+		WithRelations(BelongsTo[RelUser]("user", "User", "user_id").Table("user")),
+	)
+
+	page, err := orders.With("user").Paginate(ctx, 1, 10, OrderBy("id"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(page.Items))
+	assert.Equal(t, "foo", page.Items[0].User.Name)
+	assert.Equal(t, "bar", page.Items[2].User.Name)
+	assert.Nil(t, page.Items[3].User)
+
+	// unknown
+	_, err = users.With("comments").FindAll(ctx)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,292 @@
+package yiigo
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TableNamer is implemented by the model which specifies its table name.
+type TableNamer interface {
+	TableName() string
+}
+
+// TableResolver resolves the table name from the model type.
+type TableResolver func(t reflect.Type) string
+
+// SnakeTableResolver is the default TableResolver, eg: OrderItem -> order_item.
+func SnakeTableResolver(t reflect.Type) string {
+	return snakeCase(t.Name())
+}
+
+type repoOptions struct {
+	table     string
+	pk        string
+	resolver  TableResolver
+	relations map[string]*Relation
+}
+
+// RepoOption Repo option
+type RepoOption func(o *repoOptions)
+
+// WithRepoTable specifies the table name of Repo.
+func WithRepoTable(table string) RepoOption {
+	return func(o *repoOptions) {
+		o.table = table
+	}
+}
+
+// WithPrimaryKey specifies the primary key of Repo, default: id.
+func WithPrimaryKey(column string) RepoOption {
+	return func(o *repoOptions) {
+		o.pk = column
+	}
+}
+
+// WithTableResolver specifies the TableResolver of Repo, default: SnakeTableResolver.
+func WithTableResolver(fn TableResolver) RepoOption {
+	return func(o *repoOptions) {
+		o.resolver = fn
+	}
+}
+
+// Page the result of Paginate (and `MongoPaginate`, `MongoCursorPaginate`).
+type Page[T any] struct {
+	Items []T    `json:"items"`
+	Total int64  `json:"total"`
+	Page  int    `json:"page"`
+	Size  int    `json:"size"`
+	Next  string `json:"next,omitempty"` // the cursor of the next page, only for the cursor pagination
+}
+
+// Repo is the generic CRUD repository of model T built on SQLWrapper, eg:
+//
+//	repo := yiigo.NewRepo[User](builder, yiigo.DB())
+//	user, err := repo.FindByID(ctx, 1)
+//
+// The table name is resolved in order: WithRepoTable, T implements TableNamer, TableResolver.
+type Repo[T any] struct {
+	builder   SQLBuilder
+	db        sqlx.ExtContext
+	table     string
+	pk        string
+	resolver  TableResolver
+	relations map[string]*Relation
+	with      []string
+}
+
+// NewRepo returns new Repo of model T.
+func NewRepo[T any](builder SQLBuilder, db sqlx.ExtContext, options ...RepoOption) *Repo[T] {
+	o := &repoOptions{
+		pk:       "id",
+		resolver: SnakeTableResolver,
+	}
+
+	for _, f := range options {
+		f(o)
+	}
+
+	table := o.table
+
+	if len(table) == 0 {
+		table = tableOf(reflect.TypeOf((*T)(nil)).Elem(), o.resolver)
+	}
+
+	return &Repo[T]{
+		builder:   builder,
+		db:        db,
+		table:     table,
+		pk:        o.pk,
+		resolver:  o.resolver,
+		relations: o.relations,
+	}
+}
+
+// tableOf returns the table name of the model type, which implements TableNamer or is resolved by the resolver.
+func tableOf(t reflect.Type, resolver TableResolver) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if v, ok := reflect.Zero(t).Interface().(TableNamer); ok {
+		return v.TableName()
+	}
+
+	if v, ok := reflect.New(t).Interface().(TableNamer); ok {
+		return v.TableName()
+	}
+
+	return resolver(t)
+}
+
+// Table returns the table name of Repo.
+func (r *Repo[T]) Table() string {
+	return r.table
+}
+
+// Query returns the typed wrapper of the table, for the queries not covered by Repo.
+func (r *Repo[T]) Query(options ...QueryOption) *SQLWrapperOf[T] {
+	return WrapOf[T](r.builder, r.options(options)...)
+}
+
+// FindByID returns the model by primary key, returns sql.ErrNoRows if not found.
+func (r *Repo[T]) FindByID(ctx context.Context, id any) (T, error) {
+	return r.get(ctx, Where(r.pk+" = ?", id))
+}
+
+// FindOne returns the first model matches the options, returns sql.ErrNoRows if not found.
+func (r *Repo[T]) FindOne(ctx context.Context, options ...QueryOption) (T, error) {
+	return r.get(ctx, append(options, Limit(1))...)
+}
+
+// FindAll returns the models match the options.
+func (r *Repo[T]) FindAll(ctx context.Context, options ...QueryOption) ([]T, error) {
+	models, err := r.Query(options...).Select(ctx, r.db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.loadRelations(ctx, models); err != nil {
+		return nil, err
+	}
+
+	return models, nil
+}
+
+// Count returns the number of the models match the options.
+func (r *Repo[T]) Count(ctx context.Context, options ...QueryOption) (int64, error) {
+	w := r.builder.Wrap(r.options(options)...).(*queryWrapper)
+
+	var total int64
+
+	// the grouped (distinct, union) query is counted as subquery
+	if len(w.groups) != 0 || len(w.groupSets) != 0 || w.distinct || len(w.unions) != 0 {
+		w.orders, w.offset, w.limit = nil, 0, 0
+
+		query, args, err := w.ToQuery(ctx)
+
+		if err != nil {
+			return 0, err
+		}
+
+		err = sqlx.GetContext(ctx, r.db, &total, "SELECT COUNT(*) FROM ("+query+") AS t", args...)
+
+		return total, err
+	}
+
+	w.columns, w.orders, w.offset, w.limit = []string{"COUNT(*)"}, nil, 0, 0
+
+	err := w.Get(ctx, r.db, &total)
+
+	return total, err
+}
+
+// Paginate returns the models of the page (starts from 1) and the total.
+func (r *Repo[T]) Paginate(ctx context.Context, page, size int, options ...QueryOption) (*Page[T], error) {
+	if page < 1 {
+		page = 1
+	}
+
+	total, err := r.Count(ctx, options...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Page[T]{
+		Items: make([]T, 0),
+		Total: total,
+		Page:  page,
+		Size:  size,
+	}
+
+	offset := (page - 1) * size
+
+	if total == 0 || int64(offset) >= total {
+		return result, nil
+	}
+
+	result.Items, err = r.FindAll(ctx, append(options, Offset(offset), Limit(size))...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Create inserts the model (struct, *struct or map[string]any), returns the last insert id.
+func (r *Repo[T]) Create(ctx context.Context, data any) (int64, error) {
+	return r.builder.Wrap(Table(r.table)).Insert(ctx, r.db, data)
+}
+
+// Update updates the model by primary key, the data expects struct, *struct or map[string]any.
+func (r *Repo[T]) Update(ctx context.Context, id, data any) (int64, error) {
+	return r.builder.Wrap(Table(r.table), Where(r.pk+" = ?", id)).Update(ctx, r.db, data)
+}
+
+// Delete deletes the model by primary key, which is soft delete if enabled by the builder.
+func (r *Repo[T]) Delete(ctx context.Context, id any) (int64, error) {
+	return r.builder.Wrap(Table(r.table), Where(r.pk+" = ?", id)).Delete(ctx, r.db)
+}
+
+func (r *Repo[T]) get(ctx context.Context, options ...QueryOption) (T, error) {
+	model, err := r.Query(options...).Get(ctx, r.db)
+
+	if err != nil {
+		return model, err
+	}
+
+	models := []T{model}
+
+	if err = r.loadRelations(ctx, models); err != nil {
+		return model, err
+	}
+
+	return models[0], nil
+}
+
+func (r *Repo[T]) loadRelations(ctx context.Context, models []T) error {
+	if len(r.with) == 0 {
+		return nil
+	}
+
+	loader := &relationLoader{
+		builder:  r.builder,
+		db:       r.db,
+		resolver: r.resolver,
+	}
+
+	return loader.load(ctx, reflect.ValueOf(models), r.relations, r.with)
+}
+
+func (r *Repo[T]) options(options []QueryOption) []QueryOption {
+	return append([]QueryOption{Table(r.table)}, options...)
+}
+
+func snakeCase(s string) string {
+	var builder strings.Builder
+
+	runes := []rune(s)
+
+	for i, c := range runes {
+		if unicode.IsUpper(c) {
+			// eg: UserID -> user_id, HTTPServer -> http_server
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				builder.WriteByte('_')
+			}
+
+			builder.WriteRune(unicode.ToLower(c))
+
+			continue
+		}
+
+		builder.WriteRune(c)
+	}
+
+	return builder.String()
+}
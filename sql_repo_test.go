@@ -0,0 +1,124 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+type RepoUser struct {
+	ID   int64  `db:"id,omitempty"`
+	Name string `db:"name"`
+	Age  int    `db:"age"`
+}
+
+func (u *RepoUser) TableName() string {
+	return "user"
+}
+
+type OrderItem struct {
+	ID int64 `db:"id"`
+}
+
+func TestRepo(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
+	assert.Nil(t, err)
+
+	repo := NewRepo[RepoUser](NewSQLiteBuilder(), db)
+
+	assert.Equal(t, "user", repo.Table())
+
+	for i, v := range []string{"foo", "bar", "baz", "qux", "yiigo"} {
+		id, err := repo.Create(ctx, &RepoUser{Name: v, Age: 20 + i})
+
+		assert.Nil(t, err)
+		assert.Equal(t, int64(i+1), id)
+	}
+
+	user, err := repo.FindByID(ctx, 5)
+
+	assert.Nil(t, err)
+	assert.Equal(t, RepoUser{ID: 5, Name: "yiigo", Age: 24}, user)
+
+	_, err = repo.FindByID(ctx, 10)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	user, err = repo.FindOne(ctx, Where("age > ?", 20), OrderBy("age DESC"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), user.ID)
+
+	users, err := repo.FindAll(ctx, Where("age < ?", 22))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []RepoUser{{ID: 1, Name: "foo", Age: 20}, {ID: 2, Name: "bar", Age: 21}}, users)
+
+	total, err := repo.Count(ctx, Where("age > ?", 20), OrderBy("id"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), total)
+
+	total, err = repo.Count(ctx, Select("age"), GroupBy("age"), Having("age > ?", 22))
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), total)
+
+	page, err := repo.Paginate(ctx, 2, 2, OrderBy("id"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Page[RepoUser]{
+		Items: []RepoUser{{ID: 3, Name: "baz", Age: 22}, {ID: 4, Name: "qux", Age: 23}},
+		Total: 5,
+		Page:  2,
+		Size:  2,
+	}, page)
+
+	page, err = repo.Paginate(ctx, 4, 2)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(page.Items))
+	assert.Equal(t, int64(5), page.Total)
+
+	rows, err := repo.Update(ctx, 1, X{"age": 30})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), rows)
+
+	rows, err = repo.Delete(ctx, 2)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), rows)
+
+	users, err = repo.Query(Where("age >= ?", 24), OrderBy("id")).Select(ctx, db)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []RepoUser{{ID: 1, Name: "foo", Age: 30}, {ID: 5, Name: "yiigo", Age: 24}}, users)
+}
+
+func TestRepoTable(t *testing.T) {
+	builder := NewMySQLBuilder()
+
+	assert.Equal(t, "order_item", NewRepo[OrderItem](builder, nil).Table())
+	assert.Equal(t, "order_item", NewRepo[*OrderItem](builder, nil).Table())
+	assert.Equal(t, "t_item", NewRepo[OrderItem](builder, nil, WithRepoTable("t_item")).Table())
+	assert.Equal(t, "OrderItem", NewRepo[OrderItem](builder, nil, WithTableResolver(func(t reflect.Type) string {
+		return t.Name()
+	})).Table())
+
+	assert.Equal(t, "user_id", snakeCase("UserID"))
+	assert.Equal(t, "http_server", snakeCase("HTTPServer"))
+}
@@ -0,0 +1,159 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+type retryPolicy struct {
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+// WithReadRetry retries the reads (Get, Select and Iterate before any row is consumed) on the transient failures
+// (see `IsTransientError`) up to attempts (including the first one) with the exponential backoff (doubles per retry with jitter)
+// capped by maxBackoff, default: 50ms and 1s. The retries are recorded by `SQLMetrics`.
+// NOTE: The writes are never retried since they are not idempotent, neither are the reads in the transaction.
+func WithReadRetry(attempts int, backoff, maxBackoff time.Duration) SQLBuilderOption {
+	return func(b *queryBuilder) {
+		b.retry = &retryPolicy{
+			attempts:   attempts,
+			backoff:    backoff,
+			maxBackoff: maxBackoff,
+		}
+	}
+}
+
+// Retry specifies the attempts (including the first one) of the read on the transient failures, which overrides `WithReadRetry`,
+// 1 disables the retry, eg: the read which is not idempotent (SELECT ... FOR UPDATE SKIP LOCKED).
+func Retry(attempts int) QueryOption {
+	return func(w *queryWrapper) {
+		w.attempts = attempts
+	}
+}
+
+// readAttempts returns the attempts of the read.
+func (w *queryWrapper) readAttempts() int {
+	if w.attempts > 0 {
+		return w.attempts
+	}
+
+	if w.builder.retry != nil && w.builder.retry.attempts > 0 {
+		return w.builder.retry.attempts
+	}
+
+	return 1
+}
+
+// retryBackoff returns the backoff before the nth retry.
+func (w *queryWrapper) retryBackoff(n int) time.Duration {
+	backoff, maxBackoff := 50*time.Millisecond, time.Second
+
+	if p := w.builder.retry; p != nil {
+		if p.backoff > 0 {
+			backoff = p.backoff
+		}
+
+		if p.maxBackoff > 0 {
+			maxBackoff = p.maxBackoff
+		}
+	}
+
+	d := backoff << (n - 1)
+
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+
+	// jitter
+	return d/2 + time.Duration(rand.Int63n(int64(d)/2+1))
+}
+
+// retry calls fn (the attempt is 1-based) until it succeeds, the attempts run out, or the error is not transient.
+// fn returns the rows consumed, the read is not retried once any row is consumed.
+// The read in the transaction is not retried, since the transaction is aborted by the failure (eg: the bad connection).
+func (w *queryWrapper) retry(ctx context.Context, db any, kind StmtKind, fn func(attempt int) (int64, error)) (int64, error) {
+	attempts := 1
+
+	if kind == StmtQuery && !inTx(db) {
+		attempts = w.readAttempts()
+	}
+
+	rows, err := fn(1)
+
+	for i := 1; i < attempts && err != nil && rows == 0 && IsTransientError(err); i++ {
+		d := w.retryBackoff(i)
+
+		logger.Warn("sql read retry", zap.String("table", cacheTable(w.table)), zap.Int("retry", i), zap.Duration("backoff", d), zap.Error(err))
+
+		timer := time.NewTimer(d)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return rows, err
+		case <-timer.C:
+		}
+
+		rows, err = fn(i + 1)
+	}
+
+	return rows, err
+}
+
+// IsTransientError reports whether the error is transient which the retry may succeed, eg: the bad connection,
+// the connection reset or refused (failover), the server shutdown or too many connections.
+// The network timeout is transient only if it occurs on dial.
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	for _, v := range []error{driver.ErrBadConn, mysql.ErrInvalidConn, io.EOF, io.ErrUnexpectedEOF, syscall.ECONNRESET, syscall.ECONNREFUSED, syscall.EPIPE} {
+		if errors.Is(err, v) {
+			return true
+		}
+	}
+
+	var myErr *mysql.MySQLError
+
+	if errors.As(err, &myErr) {
+		// ER_CON_COUNT_ERROR, ER_SERVER_SHUTDOWN
+		return myErr.Number == 1040 || myErr.Number == 1053
+	}
+
+	var pgErr *pgconn.PgError
+
+	if errors.As(err, &pgErr) {
+		// connection_exception, too_many_connections, admin_shutdown, crash_shutdown, cannot_connect_now
+		return strings.HasPrefix(pgErr.Code, "08") || pgErr.Code == "53300" || pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+
+	// the timeout of the established connection (eg: i/o timeout) is not transient, the server may be still executing
+	var opErr *net.OpError
+
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && !netErr.Timeout()
+}
@@ -0,0 +1,126 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadRetry(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := OpenSQLiteMemory()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT)")
+	assert.Nil(t, err)
+
+	_, err = db.Exec("INSERT INTO user (name) VALUES ('foo')")
+	assert.Nil(t, err)
+
+	metrics := NewSQLMetrics("retry")
+
+	attempts := make([]int, 0)
+
+	// the first 2 attempts fail with the bad connection
+	builder := NewSQLiteBuilder(
+		WithReadRetry(3, time.Millisecond, 5*time.Millisecond),
+		WithSQLMetrics(metrics),
+		OnExec(func(ctx context.Context, stmt *ExecutedStatement, next func(ctx context.Context) error) error {
+			attempts = append(attempts, stmt.Attempt)
+
+			if stmt.Attempt < 3 {
+				return driver.ErrBadConn
+			}
+
+			return next(ctx)
+		}),
+	)
+
+	names := make([]string, 0)
+
+	assert.Nil(t, builder.Wrap(Table("user"), Select("name")).Select(ctx, db, &names))
+	assert.Equal(t, []string{"foo"}, names)
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+
+	expected := `
+# HELP retry_sql_retries_total The number of the retries of the statements on the transient failures.
+# TYPE retry_sql_retries_total counter
+retry_sql_retries_total{operation="SELECT",table="user"} 2
+`
+
+	assert.Nil(t, testutil.CollectAndCompare(metrics, strings.NewReader(expected), "retry_sql_retries_total"))
+
+	// disabled by the query
+	attempts = attempts[:0]
+
+	var name string
+
+	err = builder.Wrap(Table("user"), Select("name"), Retry(1)).Get(ctx, db, &name)
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+	assert.Equal(t, []int{1}, attempts)
+
+	// the writes are not retried
+	attempts = attempts[:0]
+
+	_, err = builder.Wrap(Table("user"), Where("id = ?", 1)).Update(ctx, db, X{"name": "bar"})
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+	assert.Equal(t, []int{1}, attempts)
+
+	// the attempts run out
+	attempts = attempts[:0]
+
+	err = builder.Wrap(Table("user"), Select("name"), Retry(2)).Get(ctx, db, &name)
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+	assert.Equal(t, []int{1, 2}, attempts)
+
+	// the reads in the transaction are not retried
+	attempts = attempts[:0]
+
+	tx, err := db.Beginx()
+
+	assert.Nil(t, err)
+
+	err = builder.Wrap(Table("user"), Select("name")).Get(ctx, tx, &name)
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+	assert.Equal(t, []int{1}, attempts)
+	assert.Nil(t, tx.Rollback())
+}
+
+func TestIsTransientError(t *testing.T) {
+	assert.True(t, IsTransientError(driver.ErrBadConn))
+	assert.True(t, IsTransientError(fmt.Errorf("query: %w", syscall.ECONNRESET)))
+	assert.True(t, IsTransientError(mysql.ErrInvalidConn))
+	assert.True(t, IsTransientError(&mysql.MySQLError{Number: 1053}))
+	assert.True(t, IsTransientError(&pgconn.PgError{Code: "57P01"}))
+	assert.True(t, IsTransientError(&pgconn.PgError{Code: "08006"}))
+
+	assert.False(t, IsTransientError(nil))
+	assert.False(t, IsTransientError(sql.ErrNoRows))
+	assert.False(t, IsTransientError(context.DeadlineExceeded))
+	assert.False(t, IsTransientError(errors.New("syntax error")))
+	assert.False(t, IsTransientError(&mysql.MySQLError{Number: 1064}))
+	assert.False(t, IsTransientError(&pgconn.PgError{Code: "23505"}))
+
+	// the network timeout is transient only on dial
+	timeout := &net.DNSError{Err: "i/o timeout", IsTimeout: true}
+
+	assert.True(t, IsTransientError(&net.OpError{Op: "dial", Net: "tcp", Err: timeout}))
+	assert.False(t, IsTransientError(&net.OpError{Op: "read", Net: "tcp", Err: timeout}))
+	assert.True(t, IsTransientError(&net.OpError{Op: "read", Net: "tcp", Err: errors.New("use of closed network connection")}))
+}
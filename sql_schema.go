@@ -0,0 +1,847 @@
+package yiigo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidSchema invalid schema definition, eg: table without columns.
+var ErrInvalidSchema = errors.New("invalid schema")
+
+// ColumnType the abstract column type which is rendered per dialect.
+type ColumnType int
+
+const (
+	TypeInt ColumnType = iota
+	TypeBigInt
+	TypeSmallInt
+	TypeBool
+	TypeString
+	TypeText
+	TypeDecimal
+	TypeFloat
+	TypeDate
+	TypeTimestamp
+	TypeJSON
+	TypeBinary
+	TypeRaw
+)
+
+// ColumnDef the column definition.
+type ColumnDef struct {
+	name          string
+	typ           ColumnType
+	raw           string
+	size          int
+	scale         int
+	notNull       bool
+	defaultValue  string
+	autoIncrement bool
+	primaryKey    bool
+	unique        bool
+	comment       string
+}
+
+// NotNull specifies `NOT NULL`.
+func (c *ColumnDef) NotNull() *ColumnDef {
+	c.notNull = true
+
+	return c
+}
+
+// Default specifies the default value (string, number or bool), eg: Default(""), Default(0).
+func (c *ColumnDef) Default(v any) *ColumnDef {
+	switch x := v.(type) {
+	case string:
+		c.defaultValue = quoteString(x)
+	case bool:
+		c.defaultValue = "FALSE"
+
+		if x {
+			c.defaultValue = "TRUE"
+		}
+	default:
+		c.defaultValue = fmt.Sprint(x)
+	}
+
+	return c
+}
+
+// DefaultExpr specifies the default expression, eg: DefaultExpr("CURRENT_TIMESTAMP").
+func (c *ColumnDef) DefaultExpr(expr string) *ColumnDef {
+	c.defaultValue = expr
+
+	return c
+}
+
+// AutoIncrement specifies the auto increment (identity) column.
+func (c *ColumnDef) AutoIncrement() *ColumnDef {
+	c.autoIncrement = true
+
+	return c
+}
+
+// PrimaryKey specifies the column as primary key, use `TableDef.PrimaryKey` for composite primary key.
+func (c *ColumnDef) PrimaryKey() *ColumnDef {
+	c.primaryKey = true
+
+	return c
+}
+
+// Unique specifies the `UNIQUE` constraint.
+func (c *ColumnDef) Unique() *ColumnDef {
+	c.unique = true
+
+	return c
+}
+
+// Comment specifies the column comment (MySQL and Postgres).
+func (c *ColumnDef) Comment(comment string) *ColumnDef {
+	c.comment = comment
+
+	return c
+}
+
+// IndexDef the index definition.
+type IndexDef struct {
+	name    string
+	columns []string
+	unique  bool
+}
+
+// ForeignKeyDef the foreign key definition.
+type ForeignKeyDef struct {
+	name       string
+	columns    []string
+	refTable   string
+	refColumns []string
+	onDelete   string
+	onUpdate   string
+}
+
+// OnDelete specifies the `ON DELETE` action, eg: CASCADE, SET NULL.
+func (fk *ForeignKeyDef) OnDelete(action string) *ForeignKeyDef {
+	fk.onDelete = action
+
+	return fk
+}
+
+// OnUpdate specifies the `ON UPDATE` action, eg: CASCADE, SET NULL.
+func (fk *ForeignKeyDef) OnUpdate(action string) *ForeignKeyDef {
+	fk.onUpdate = action
+
+	return fk
+}
+
+// ColumnSet defines the columns of the table.
+type ColumnSet struct {
+	columns []*ColumnDef
+}
+
+func (s *ColumnSet) add(name string, typ ColumnType) *ColumnDef {
+	c := &ColumnDef{
+		name: name,
+		typ:  typ,
+	}
+
+	s.columns = append(s.columns, c)
+
+	return c
+}
+
+// Int defines the integer column.
+func (s *ColumnSet) Int(name string) *ColumnDef {
+	return s.add(name, TypeInt)
+}
+
+// BigInt defines the big integer column.
+func (s *ColumnSet) BigInt(name string) *ColumnDef {
+	return s.add(name, TypeBigInt)
+}
+
+// SmallInt defines the small integer column.
+func (s *ColumnSet) SmallInt(name string) *ColumnDef {
+	return s.add(name, TypeSmallInt)
+}
+
+// Bool defines the boolean column.
+func (s *ColumnSet) Bool(name string) *ColumnDef {
+	return s.add(name, TypeBool)
+}
+
+// String defines the varchar column with size.
+func (s *ColumnSet) String(name string, size int) *ColumnDef {
+	c := s.add(name, TypeString)
+	c.size = size
+
+	return c
+}
+
+// Text defines the text column.
+func (s *ColumnSet) Text(name string) *ColumnDef {
+	return s.add(name, TypeText)
+}
+
+// Decimal defines the decimal column with precision and scale.
+func (s *ColumnSet) Decimal(name string, precision, scale int) *ColumnDef {
+	c := s.add(name, TypeDecimal)
+	c.size = precision
+	c.scale = scale
+
+	return c
+}
+
+// Float defines the double precision column.
+func (s *ColumnSet) Float(name string) *ColumnDef {
+	return s.add(name, TypeFloat)
+}
+
+// Date defines the date column.
+func (s *ColumnSet) Date(name string) *ColumnDef {
+	return s.add(name, TypeDate)
+}
+
+// Timestamp defines the datetime (timestamp) column.
+func (s *ColumnSet) Timestamp(name string) *ColumnDef {
+	return s.add(name, TypeTimestamp)
+}
+
+// JSON defines the json column.
+func (s *ColumnSet) JSON(name string) *ColumnDef {
+	return s.add(name, TypeJSON)
+}
+
+// Binary defines the binary column.
+func (s *ColumnSet) Binary(name string) *ColumnDef {
+	return s.add(name, TypeBinary)
+}
+
+// Column defines the column with the raw type, eg: Column("location", "POINT").
+func (s *ColumnSet) Column(name, typ string) *ColumnDef {
+	c := s.add(name, TypeRaw)
+	c.raw = typ
+
+	return c
+}
+
+// TableDef the table definition for `CreateTable`.
+type TableDef struct {
+	ColumnSet
+
+	ifNotExists bool
+	primaryKey  []string
+	indexes     []*IndexDef
+	foreignKeys []*ForeignKeyDef
+	options     []string
+	comment     string
+}
+
+// IfNotExists specifies `IF NOT EXISTS`, which applies to the indexes as well (MySQL defines the indexes in the table).
+func (t *TableDef) IfNotExists() {
+	t.ifNotExists = true
+}
+
+// PrimaryKey specifies the (composite) primary key.
+func (t *TableDef) PrimaryKey(columns ...string) {
+	t.primaryKey = columns
+}
+
+// Index defines the index, which is created by `CREATE INDEX` after the table.
+func (t *TableDef) Index(name string, columns ...string) {
+	t.indexes = append(t.indexes, &IndexDef{name: name, columns: columns})
+}
+
+// UniqueIndex defines the unique index, which is created by `CREATE UNIQUE INDEX` after the table.
+func (t *TableDef) UniqueIndex(name string, columns ...string) {
+	t.indexes = append(t.indexes, &IndexDef{name: name, columns: columns, unique: true})
+}
+
+// ForeignKey defines the foreign key constraint.
+func (t *TableDef) ForeignKey(name string, columns []string, refTable string, refColumns []string) *ForeignKeyDef {
+	fk := &ForeignKeyDef{
+		name:       name,
+		columns:    columns,
+		refTable:   refTable,
+		refColumns: refColumns,
+	}
+
+	t.foreignKeys = append(t.foreignKeys, fk)
+
+	return fk
+}
+
+// Option specifies the raw table option (MySQL), eg: Option("ENGINE=InnoDB"), Option("DEFAULT CHARSET=utf8mb4").
+func (t *TableDef) Option(option string) {
+	t.options = append(t.options, option)
+}
+
+// Comment specifies the table comment (MySQL and Postgres).
+func (t *TableDef) Comment(comment string) {
+	t.comment = comment
+}
+
+// AlterDef the table definition for `AlterTable`, the columns defined are added.
+type AlterDef struct {
+	ColumnSet
+
+	actions []alterAction
+}
+
+type alterAction struct {
+	kind    string
+	name    string
+	newName string
+	index   *IndexDef
+}
+
+// DropColumn drops the column.
+func (a *AlterDef) DropColumn(name string) {
+	a.actions = append(a.actions, alterAction{kind: "drop_column", name: name})
+}
+
+// RenameColumn renames the column.
+func (a *AlterDef) RenameColumn(name, newName string) {
+	a.actions = append(a.actions, alterAction{kind: "rename_column", name: name, newName: newName})
+}
+
+// Index creates the index.
+func (a *AlterDef) Index(name string, columns ...string) {
+	a.actions = append(a.actions, alterAction{kind: "create_index", index: &IndexDef{name: name, columns: columns}})
+}
+
+// UniqueIndex creates the unique index.
+func (a *AlterDef) UniqueIndex(name string, columns ...string) {
+	a.actions = append(a.actions, alterAction{kind: "create_index", index: &IndexDef{name: name, columns: columns, unique: true}})
+}
+
+// DropIndex drops the index.
+func (a *AlterDef) DropIndex(name string) {
+	a.actions = append(a.actions, alterAction{kind: "drop_index", name: name})
+}
+
+// SchemaBuilder is the interface for building DDL statements.
+type SchemaBuilder interface {
+	// CreateTable returns the `CREATE TABLE` statement, followed by the `CREATE INDEX` (and `COMMENT ON`) statements.
+	CreateTable(table string, fn func(t *TableDef)) ([]string, error)
+
+	// CreateTableFrom returns the `CREATE TABLE` statements generated from the model struct (`db` and `ddl` tags).
+	CreateTableFrom(table string, model any, fn ...func(t *TableDef)) ([]string, error)
+
+	// AlterTable returns the `ALTER TABLE` statements, one statement per action.
+	AlterTable(table string, fn func(t *AlterDef)) ([]string, error)
+
+	// DropTable returns the `DROP TABLE` statement.
+	DropTable(table string, ifExists bool) (string, error)
+
+	// CreateIndex returns the `CREATE [UNIQUE] INDEX` statement.
+	CreateIndex(table, name string, unique bool, columns ...string) (string, error)
+
+	// DropIndex returns the `DROP INDEX` statement.
+	DropIndex(table, name string) (string, error)
+}
+
+type schemaBuilder struct {
+	builder *queryBuilder
+}
+
+// NewSchemaBuilder returns new SchemaBuilder, the SQLBuilderOption (eg: WithTablePrefix, WithoutQuote) is supported as well.
+func NewSchemaBuilder(driver DBDriver, options ...SQLBuilderOption) SchemaBuilder {
+	return &schemaBuilder{
+		builder: NewSQLBuilder(driver, options...).(*queryBuilder),
+	}
+}
+
+func (sb *schemaBuilder) CreateTable(table string, fn func(t *TableDef)) ([]string, error) {
+	t := new(TableDef)
+
+	fn(t)
+
+	if len(t.columns) == 0 {
+		return nil, fmt.Errorf("%w: table %s without columns", ErrInvalidSchema, table)
+	}
+
+	b := sb.builder
+
+	if err := b.checkIdent(table); err != nil {
+		return nil, err
+	}
+
+	defs := make([]string, 0, len(t.columns)+len(t.foreignKeys)+1)
+
+	for _, c := range t.columns {
+		def, err := sb.columnDef(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		defs = append(defs, def)
+	}
+
+	if len(t.primaryKey) != 0 {
+		defs = append(defs, "PRIMARY KEY ("+strings.Join(b.quoteColumns(t.primaryKey, b.quoteIdent), ", ")+")")
+	}
+
+	// MySQL doesn't support `CREATE INDEX IF NOT EXISTS`, so the indexes are defined in the table
+	inlineIndex := t.ifNotExists && b.driver == MySQL
+
+	if inlineIndex {
+		for _, v := range t.indexes {
+			def, err := sb.indexDef(table, v)
+
+			if err != nil {
+				return nil, err
+			}
+
+			defs = append(defs, def)
+		}
+	}
+
+	for _, fk := range t.foreignKeys {
+		defs = append(defs, sb.foreignKeyDef(fk))
+	}
+
+	var builder strings.Builder
+
+	builder.WriteString("CREATE TABLE ")
+
+	if t.ifNotExists {
+		if b.driver == SQLServer {
+			return nil, errDriverUnsupported(b.driver, "CREATE TABLE IF NOT EXISTS")
+		}
+
+		builder.WriteString("IF NOT EXISTS ")
+	}
+
+	builder.WriteString(b.quoteIdent(b.tableName(table)))
+	builder.WriteString(" (\n  ")
+	builder.WriteString(strings.Join(defs, ",\n  "))
+	builder.WriteString("\n)")
+
+	if b.driver == MySQL {
+		for _, v := range t.options {
+			builder.WriteString(" ")
+			builder.WriteString(v)
+		}
+
+		if len(t.comment) != 0 {
+			builder.WriteString(" COMMENT=")
+			builder.WriteString(quoteString(t.comment))
+		}
+	}
+
+	stmts := []string{builder.String()}
+
+	if !inlineIndex {
+		for _, v := range t.indexes {
+			stmt, err := sb.createIndex(table, v, t.ifNotExists)
+
+			if err != nil {
+				return nil, err
+			}
+
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	// Postgres: COMMENT ON
+	if b.driver == Postgres {
+		name := b.quoteIdent(b.tableName(table))
+
+		if len(t.comment) != 0 {
+			stmts = append(stmts, "COMMENT ON TABLE "+name+" IS "+quoteString(t.comment))
+		}
+
+		for _, c := range t.columns {
+			if len(c.comment) != 0 {
+				stmts = append(stmts, "COMMENT ON COLUMN "+name+"."+b.quoteIdent(c.name)+" IS "+quoteString(c.comment))
+			}
+		}
+	}
+
+	return stmts, nil
+}
+
+func (sb *schemaBuilder) AlterTable(table string, fn func(t *AlterDef)) ([]string, error) {
+	a := new(AlterDef)
+
+	fn(a)
+
+	b := sb.builder
+
+	if err := b.checkIdent(table); err != nil {
+		return nil, err
+	}
+
+	name := b.quoteIdent(b.tableName(table))
+
+	stmts := make([]string, 0, len(a.columns)+len(a.actions))
+
+	for _, c := range a.columns {
+		def, err := sb.columnDef(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// SQL Server: ALTER TABLE t ADD c ...
+		if b.driver == SQLServer {
+			stmts = append(stmts, "ALTER TABLE "+name+" ADD "+def)
+		} else {
+			stmts = append(stmts, "ALTER TABLE "+name+" ADD COLUMN "+def)
+		}
+	}
+
+	for _, v := range a.actions {
+		switch v.kind {
+		case "drop_column":
+			stmts = append(stmts, "ALTER TABLE "+name+" DROP COLUMN "+b.quoteIdent(v.name))
+		case "rename_column":
+			if b.driver == SQLServer {
+				stmts = append(stmts, "EXEC sp_rename "+quoteString(b.tableName(table)+"."+v.name)+", "+quoteString(v.newName)+", 'COLUMN'")
+			} else {
+				stmts = append(stmts, "ALTER TABLE "+name+" RENAME COLUMN "+b.quoteIdent(v.name)+" TO "+b.quoteIdent(v.newName))
+			}
+		case "create_index":
+			stmt, err := sb.CreateIndex(table, v.index.name, v.index.unique, v.index.columns...)
+
+			if err != nil {
+				return nil, err
+			}
+
+			stmts = append(stmts, stmt)
+		case "drop_index":
+			stmt, err := sb.DropIndex(table, v.name)
+
+			if err != nil {
+				return nil, err
+			}
+
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	if len(stmts) == 0 {
+		return nil, fmt.Errorf("%w: alter table %s without actions", ErrInvalidSchema, table)
+	}
+
+	return stmts, nil
+}
+
+func (sb *schemaBuilder) DropTable(table string, ifExists bool) (string, error) {
+	b := sb.builder
+
+	if err := b.checkIdent(table); err != nil {
+		return "", err
+	}
+
+	if ifExists {
+		return "DROP TABLE IF EXISTS " + b.quoteIdent(b.tableName(table)), nil
+	}
+
+	return "DROP TABLE " + b.quoteIdent(b.tableName(table)), nil
+}
+
+func (sb *schemaBuilder) CreateIndex(table, name string, unique bool, columns ...string) (string, error) {
+	return sb.createIndex(table, &IndexDef{name: name, columns: columns, unique: unique}, false)
+}
+
+func (sb *schemaBuilder) createIndex(table string, idx *IndexDef, ifNotExists bool) (string, error) {
+	b := sb.builder
+
+	if err := sb.checkIndex(table, idx); err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+
+	builder.WriteString("CREATE ")
+
+	if idx.unique {
+		builder.WriteString("UNIQUE ")
+	}
+
+	builder.WriteString("INDEX ")
+
+	if ifNotExists {
+		builder.WriteString("IF NOT EXISTS ")
+	}
+
+	builder.WriteString(b.quoteIdent(idx.name))
+	builder.WriteString(" ON ")
+	builder.WriteString(b.quoteIdent(b.tableName(table)))
+	builder.WriteString(" (")
+	builder.WriteString(strings.Join(b.quoteColumns(idx.columns, b.quoteIdent), ", "))
+	builder.WriteString(")")
+
+	return builder.String(), nil
+}
+
+// indexDef returns the index definition in the table (MySQL), eg: UNIQUE INDEX `uniq_name` (`name`).
+func (sb *schemaBuilder) indexDef(table string, idx *IndexDef) (string, error) {
+	b := sb.builder
+
+	if err := sb.checkIndex(table, idx); err != nil {
+		return "", err
+	}
+
+	def := "INDEX " + b.quoteIdent(idx.name) + " (" + strings.Join(b.quoteColumns(idx.columns, b.quoteIdent), ", ") + ")"
+
+	if idx.unique {
+		def = "UNIQUE " + def
+	}
+
+	return def, nil
+}
+
+func (sb *schemaBuilder) checkIndex(table string, idx *IndexDef) error {
+	if len(idx.columns) == 0 {
+		return fmt.Errorf("%w: index %s without columns", ErrInvalidSchema, idx.name)
+	}
+
+	b := sb.builder
+
+	return b.checkColumns(append([]string{table, idx.name}, idx.columns...), b.checkIdent)
+}
+
+func (sb *schemaBuilder) DropIndex(table, name string) (string, error) {
+	b := sb.builder
+
+	if err := b.checkColumns([]string{table, name}, b.checkIdent); err != nil {
+		return "", err
+	}
+
+	// MySQL and SQL Server: DROP INDEX idx ON t
+	if b.driver == MySQL || b.driver == SQLServer {
+		return "DROP INDEX " + b.quoteIdent(name) + " ON " + b.quoteIdent(b.tableName(table)), nil
+	}
+
+	return "DROP INDEX " + b.quoteIdent(name), nil
+}
+
+func (sb *schemaBuilder) columnDef(c *ColumnDef) (string, error) {
+	b := sb.builder
+
+	if err := b.checkIdent(c.name); err != nil {
+		return "", err
+	}
+
+	typ, err := sb.columnType(c)
+
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+
+	builder.WriteString(b.quoteIdent(c.name))
+	builder.WriteString(" ")
+	builder.WriteString(typ)
+
+	if c.autoIncrement {
+		switch b.driver {
+		case Postgres:
+			builder.WriteString(" GENERATED BY DEFAULT AS IDENTITY")
+		case SQLServer:
+			builder.WriteString(" IDENTITY(1,1)")
+		case SQLite:
+			// INTEGER PRIMARY KEY AUTOINCREMENT
+			if !c.primaryKey {
+				return "", fmt.Errorf("%w: sqlite autoincrement column %s must be primary key", ErrInvalidSchema, c.name)
+			}
+		}
+	}
+
+	if c.notNull {
+		builder.WriteString(" NOT NULL")
+	}
+
+	if len(c.defaultValue) != 0 {
+		builder.WriteString(" DEFAULT ")
+		builder.WriteString(sb.defaultValue(c.defaultValue))
+	}
+
+	if c.autoIncrement && b.driver == MySQL {
+		builder.WriteString(" AUTO_INCREMENT")
+	}
+
+	if c.primaryKey {
+		builder.WriteString(" PRIMARY KEY")
+
+		if c.autoIncrement && b.driver == SQLite {
+			builder.WriteString(" AUTOINCREMENT")
+		}
+	}
+
+	if c.unique {
+		builder.WriteString(" UNIQUE")
+	}
+
+	if len(c.comment) != 0 && b.driver == MySQL {
+		builder.WriteString(" COMMENT ")
+		builder.WriteString(quoteString(c.comment))
+	}
+
+	return builder.String(), nil
+}
+
+// defaultValue returns the default value, SQL Server uses 1/0 for bool.
+func (sb *schemaBuilder) defaultValue(v string) string {
+	if sb.builder.driver == SQLServer {
+		switch v {
+		case "TRUE":
+			return "1"
+		case "FALSE":
+			return "0"
+		}
+	}
+
+	return v
+}
+
+func (sb *schemaBuilder) columnType(c *ColumnDef) (string, error) {
+	driver := sb.builder.driver
+
+	switch driver {
+	case MySQL, Postgres, SQLite, SQLServer:
+	default:
+		return "", errDriverUnsupported(driver, "SCHEMA")
+	}
+
+	switch c.typ {
+	case TypeInt:
+		if driver == MySQL || driver == SQLServer {
+			return "INT", nil
+		}
+
+		return "INTEGER", nil
+	case TypeBigInt:
+		// SQLite: AUTOINCREMENT requires INTEGER
+		if driver == SQLite {
+			return "INTEGER", nil
+		}
+
+		return "BIGINT", nil
+	case TypeSmallInt:
+		if driver == SQLite {
+			return "INTEGER", nil
+		}
+
+		return "SMALLINT", nil
+	case TypeBool:
+		switch driver {
+		case MySQL:
+			return "TINYINT(1)", nil
+		case SQLServer:
+			return "BIT", nil
+		}
+
+		return "BOOLEAN", nil
+	case TypeString:
+		if c.size <= 0 {
+			return "", fmt.Errorf("%w: string column %s without size", ErrInvalidSchema, c.name)
+		}
+
+		switch driver {
+		case SQLite:
+			return "TEXT", nil
+		case SQLServer:
+			return "NVARCHAR(" + strconv.Itoa(c.size) + ")", nil
+		}
+
+		return "VARCHAR(" + strconv.Itoa(c.size) + ")", nil
+	case TypeText:
+		if driver == SQLServer {
+			return "NVARCHAR(MAX)", nil
+		}
+
+		return "TEXT", nil
+	case TypeDecimal:
+		if driver == SQLite {
+			return "NUMERIC", nil
+		}
+
+		return "DECIMAL(" + strconv.Itoa(c.size) + "," + strconv.Itoa(c.scale) + ")", nil
+	case TypeFloat:
+		switch driver {
+		case MySQL:
+			return "DOUBLE", nil
+		case Postgres:
+			return "DOUBLE PRECISION", nil
+		case SQLite:
+			return "REAL", nil
+		}
+
+		return "FLOAT", nil
+	case TypeDate:
+		return "DATE", nil
+	case TypeTimestamp:
+		switch driver {
+		case Postgres:
+			return "TIMESTAMP", nil
+		case SQLServer:
+			return "DATETIME2", nil
+		}
+
+		return "DATETIME", nil
+	case TypeJSON:
+		switch driver {
+		case Postgres:
+			return "JSONB", nil
+		case SQLite:
+			return "TEXT", nil
+		case SQLServer:
+			return "NVARCHAR(MAX)", nil
+		}
+
+		return "JSON", nil
+	case TypeBinary:
+		switch driver {
+		case Postgres:
+			return "BYTEA", nil
+		case SQLServer:
+			return "VARBINARY(MAX)", nil
+		}
+
+		return "BLOB", nil
+	case TypeRaw:
+		return c.raw, nil
+	}
+
+	return "", fmt.Errorf("%w: unknown type of column %s", ErrInvalidSchema, c.name)
+}
+
+func (sb *schemaBuilder) foreignKeyDef(fk *ForeignKeyDef) string {
+	b := sb.builder
+
+	var builder strings.Builder
+
+	builder.WriteString("CONSTRAINT ")
+	builder.WriteString(b.quoteIdent(fk.name))
+	builder.WriteString(" FOREIGN KEY (")
+	builder.WriteString(strings.Join(b.quoteColumns(fk.columns, b.quoteIdent), ", "))
+	builder.WriteString(") REFERENCES ")
+	builder.WriteString(b.quoteIdent(b.tableName(fk.refTable)))
+	builder.WriteString(" (")
+	builder.WriteString(strings.Join(b.quoteColumns(fk.refColumns, b.quoteIdent), ", "))
+	builder.WriteString(")")
+
+	if len(fk.onDelete) != 0 {
+		builder.WriteString(" ON DELETE ")
+		builder.WriteString(fk.onDelete)
+	}
+
+	if len(fk.onUpdate) != 0 {
+		builder.WriteString(" ON UPDATE ")
+		builder.WriteString(fk.onUpdate)
+	}
+
+	return builder.String()
+}
+
+// quoteString returns the SQL string literal, the single quote is escaped by doubling it.
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,292 @@
+package yiigo
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	bytesType     = reflect.TypeOf([]byte(nil))
+	nullTypeKinds = map[reflect.Type]ColumnType{
+		reflect.TypeOf(sql.NullString{}):  TypeString,
+		reflect.TypeOf(sql.NullInt64{}):   TypeBigInt,
+		reflect.TypeOf(sql.NullInt32{}):   TypeInt,
+		reflect.TypeOf(sql.NullInt16{}):   TypeSmallInt,
+		reflect.TypeOf(sql.NullByte{}):    TypeSmallInt,
+		reflect.TypeOf(sql.NullBool{}):    TypeBool,
+		reflect.TypeOf(sql.NullFloat64{}): TypeFloat,
+		reflect.TypeOf(sql.NullTime{}):    TypeTimestamp,
+	}
+	columnTypeNames = map[string]ColumnType{
+		"int":       TypeInt,
+		"bigint":    TypeBigInt,
+		"smallint":  TypeSmallInt,
+		"bool":      TypeBool,
+		"string":    TypeString,
+		"text":      TypeText,
+		"decimal":   TypeDecimal,
+		"float":     TypeFloat,
+		"date":      TypeDate,
+		"timestamp": TypeTimestamp,
+		"json":      TypeJSON,
+		"binary":    TypeBinary,
+	}
+)
+
+// defaultStringSize the size of string column without `size` tag.
+const defaultStringSize = 255
+
+// CreateTableFrom returns the `CREATE TABLE` statements generated from the model struct.
+// The column name comes from the `db` tag, and the column definition comes from the `ddl` tag
+// (separated by semicolon), eg:
+//
+//	type User struct {
+//		ID    int64     `db:"id" ddl:"pk;autoincr"`
+//		Name  string    `db:"name" ddl:"size:32;notnull;default:'';index:idx_name"`
+//		Email string    `db:"email" ddl:"notnull;uniqueIndex:uniq_email"`
+//		Price float64   `db:"price" ddl:"type:decimal(10,2)"`
+//		Extra string    `db:"extra" ddl:"type:json"`
+//		Ctime time.Time `db:"created_at" ddl:"default:CURRENT_TIMESTAMP"`
+//	}
+//
+// Supported keys:
+//
+//	pk                  primary key (composite if more than one field)
+//	autoincr            auto increment
+//	notnull             NOT NULL
+//	unique              UNIQUE
+//	size:n              the size of string column (default 255)
+//	type:t              int, bigint, smallint, bool, string, text, decimal(p,s), float, date, timestamp, json, binary, or the raw type
+//	default:v           the raw default value or expression
+//	index:name          the index, fields with the same name form the composite index
+//	uniqueIndex:name    the unique index, fields with the same name form the composite index
+//	comment:c           the column comment
+//	-                   ignore the field
+//
+// The column type is inferred from the field type if `type` is not specified.
+// The fn (if any) is called after the columns are defined, eg: t.IfNotExists(), t.Option("ENGINE=InnoDB").
+func (sb *schemaBuilder) CreateTableFrom(table string, model any, fn ...func(t *TableDef)) ([]string, error) {
+	mt := reflect.TypeOf(model)
+
+	for mt != nil && mt.Kind() == reflect.Ptr {
+		mt = mt.Elem()
+	}
+
+	if mt == nil || mt.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: model expects struct or *struct", ErrInvalidSchema)
+	}
+
+	var modelErr error
+
+	stmts, err := sb.CreateTable(table, func(t *TableDef) {
+		pks := make([]*ColumnDef, 0, 1)
+
+		indexes := make(map[string]*IndexDef)
+
+		for _, f := range structFields(mt) {
+			if f.ddl == "-" {
+				continue
+			}
+
+			c, err := modelColumn(t, f)
+
+			if err != nil {
+				modelErr = err
+
+				return
+			}
+
+			if c.primaryKey {
+				pks = append(pks, c)
+			}
+
+			for _, v := range strings.Split(f.ddl, ";") {
+				k, name := ddlOption(v)
+
+				if k != "index" && k != "uniqueIndex" {
+					continue
+				}
+
+				idx, ok := indexes[name]
+
+				if !ok {
+					idx = &IndexDef{name: name, unique: k == "uniqueIndex"}
+					indexes[name] = idx
+
+					t.indexes = append(t.indexes, idx)
+				}
+
+				idx.columns = append(idx.columns, f.column)
+			}
+		}
+
+		// composite primary key
+		if len(pks) > 1 {
+			for _, c := range pks {
+				c.primaryKey = false
+
+				t.primaryKey = append(t.primaryKey, c.name)
+			}
+		}
+
+		for _, f := range fn {
+			f(t)
+		}
+	})
+
+	if modelErr != nil {
+		return nil, modelErr
+	}
+
+	return stmts, err
+}
+
+func modelColumn(t *TableDef, f *structField) (*ColumnDef, error) {
+	c := &ColumnDef{
+		name: f.column,
+		typ:  -1,
+		size: defaultStringSize,
+	}
+
+	for _, v := range strings.Split(f.ddl, ";") {
+		k, arg := ddlOption(v)
+
+		switch k {
+		case "":
+		case "pk":
+			c.primaryKey = true
+		case "autoincr":
+			c.autoIncrement = true
+		case "notnull":
+			c.notNull = true
+		case "unique":
+			c.unique = true
+		case "size":
+			size, err := strconv.Atoi(arg)
+
+			if err != nil || size <= 0 {
+				return nil, fmt.Errorf("%w: invalid size %q of column %s", ErrInvalidSchema, arg, f.column)
+			}
+
+			c.size = size
+		case "type":
+			if err := c.parseType(arg); err != nil {
+				return nil, err
+			}
+		case "default":
+			c.defaultValue = arg
+		case "comment":
+			c.comment = arg
+		case "index", "uniqueIndex":
+		default:
+			return nil, fmt.Errorf("%w: unknown ddl option %q of column %s", ErrInvalidSchema, k, f.column)
+		}
+	}
+
+	if c.typ == -1 {
+		typ, ok := inferColumnType(f.typ)
+
+		if !ok {
+			return nil, fmt.Errorf("%w: unable to infer the type of column %s from %s, use `type` instead", ErrInvalidSchema, f.column, f.typ)
+		}
+
+		c.typ = typ
+	}
+
+	t.columns = append(t.columns, c)
+
+	return c, nil
+}
+
+// parseType parses the `type` option, eg: decimal(10,2), VARCHAR(64).
+func (c *ColumnDef) parseType(s string) error {
+	name, args := s, ""
+
+	if i := strings.IndexByte(s, '('); i != -1 && strings.HasSuffix(s, ")") {
+		name, args = s[:i], s[i+1:len(s)-1]
+	}
+
+	typ, ok := columnTypeNames[strings.ToLower(strings.TrimSpace(name))]
+
+	if !ok {
+		c.typ, c.raw = TypeRaw, s
+
+		return nil
+	}
+
+	c.typ = typ
+
+	if len(args) == 0 {
+		if typ == TypeDecimal {
+			return fmt.Errorf("%w: decimal column %s without precision", ErrInvalidSchema, c.name)
+		}
+
+		return nil
+	}
+
+	var err error
+
+	switch typ {
+	case TypeString:
+		c.size, err = strconv.Atoi(strings.TrimSpace(args))
+	case TypeDecimal:
+		p, s, _ := strings.Cut(args, ",")
+
+		if c.size, err = strconv.Atoi(strings.TrimSpace(p)); err == nil {
+			c.scale, err = strconv.Atoi(strings.TrimSpace(s))
+		}
+	default:
+		err = ErrInvalidSchema
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: invalid type %q of column %s", ErrInvalidSchema, s, c.name)
+	}
+
+	return nil
+}
+
+func ddlOption(s string) (string, string) {
+	k, v, _ := strings.Cut(strings.TrimSpace(s), ":")
+
+	return strings.TrimSpace(k), strings.TrimSpace(v)
+}
+
+func inferColumnType(t reflect.Type) (ColumnType, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if typ, ok := nullTypeKinds[t]; ok {
+		return typ, true
+	}
+
+	switch t {
+	case timeType:
+		return TypeTimestamp, true
+	case bytesType:
+		return TypeBinary, true
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return TypeBool, true
+	case reflect.Int8, reflect.Int16, reflect.Uint8, reflect.Uint16:
+		return TypeSmallInt, true
+	case reflect.Int32, reflect.Uint32:
+		return TypeInt, true
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return TypeBigInt, true
+	case reflect.Float32, reflect.Float64:
+		return TypeFloat, true
+	case reflect.String:
+		return TypeString, true
+	}
+
+	return 0, false
+}
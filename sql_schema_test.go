@@ -0,0 +1,321 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTable(t *testing.T) {
+	define := func(t *TableDef) {
+		t.BigInt("id").AutoIncrement().PrimaryKey()
+		t.String("name", 32).NotNull().Default("").Comment("名称")
+		t.Bool("enabled").NotNull().Default(true)
+		t.BigInt("group_id")
+		t.Timestamp("created_at").DefaultExpr("CURRENT_TIMESTAMP")
+		t.UniqueIndex("uniq_name", "name")
+		t.ForeignKey("fk_group", []string{"group_id"}, "group", []string{"id"}).OnDelete("CASCADE")
+	}
+
+	// MySQL
+	stmts, err := NewSchemaBuilder(MySQL).CreateTable("user", func(t *TableDef) {
+		define(t)
+		t.Option("ENGINE=InnoDB")
+		t.Comment("用户")
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"CREATE TABLE `user` (\n" +
+			"  `id` BIGINT AUTO_INCREMENT PRIMARY KEY,\n" +
+			"  `name` VARCHAR(32) NOT NULL DEFAULT '' COMMENT '名称',\n" +
+			"  `enabled` TINYINT(1) NOT NULL DEFAULT TRUE,\n" +
+			"  `group_id` BIGINT,\n" +
+			"  `created_at` DATETIME DEFAULT CURRENT_TIMESTAMP,\n" +
+			"  CONSTRAINT `fk_group` FOREIGN KEY (`group_id`) REFERENCES `group` (`id`) ON DELETE CASCADE\n" +
+			") ENGINE=InnoDB COMMENT='用户'",
+		"CREATE UNIQUE INDEX `uniq_name` ON `user` (`name`)",
+	}, stmts)
+
+	// Postgres
+	stmts, err = NewSchemaBuilder(Postgres, WithTablePrefix("t_")).CreateTable("user", func(t *TableDef) {
+		define(t)
+		t.IfNotExists()
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		`CREATE TABLE IF NOT EXISTS "t_user" (` + "\n" +
+			`  "id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,` + "\n" +
+			`  "name" VARCHAR(32) NOT NULL DEFAULT '',` + "\n" +
+			`  "enabled" BOOLEAN NOT NULL DEFAULT TRUE,` + "\n" +
+			`  "group_id" BIGINT,` + "\n" +
+			`  "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,` + "\n" +
+			`  CONSTRAINT "fk_group" FOREIGN KEY ("group_id") REFERENCES "t_group" ("id") ON DELETE CASCADE` + "\n" +
+			`)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS "uniq_name" ON "t_user" ("name")`,
+		`COMMENT ON COLUMN "t_user"."name" IS '名称'`,
+	}, stmts)
+
+	// MySQL: IF NOT EXISTS
+	stmts, err = NewSchemaBuilder(MySQL).CreateTable("user", func(t *TableDef) {
+		t.BigInt("id").AutoIncrement().PrimaryKey()
+		t.String("name", 32).NotNull().Default("")
+		t.UniqueIndex("uniq_name", "name")
+		t.IfNotExists()
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"CREATE TABLE IF NOT EXISTS `user` (\n" +
+			"  `id` BIGINT AUTO_INCREMENT PRIMARY KEY,\n" +
+			"  `name` VARCHAR(32) NOT NULL DEFAULT '',\n" +
+			"  UNIQUE INDEX `uniq_name` (`name`)\n" +
+			")",
+	}, stmts)
+
+	// SQL Server
+	stmts, err = NewSchemaBuilder(SQLServer).CreateTable("user", define)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"CREATE TABLE [user] (\n" +
+			"  [id] BIGINT IDENTITY(1,1) PRIMARY KEY,\n" +
+			"  [name] NVARCHAR(32) NOT NULL DEFAULT '',\n" +
+			"  [enabled] BIT NOT NULL DEFAULT 1,\n" +
+			"  [group_id] BIGINT,\n" +
+			"  [created_at] DATETIME2 DEFAULT CURRENT_TIMESTAMP,\n" +
+			"  CONSTRAINT [fk_group] FOREIGN KEY ([group_id]) REFERENCES [group] ([id]) ON DELETE CASCADE\n" +
+			")",
+		"CREATE UNIQUE INDEX [uniq_name] ON [user] ([name])",
+	}, stmts)
+
+	// composite primary key
+	stmts, err = NewSchemaBuilder(SQLite).CreateTable("user_role", func(t *TableDef) {
+		t.Int("user_id").NotNull()
+		t.Int("role_id").NotNull()
+		t.PrimaryKey("user_id", "role_id")
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"CREATE TABLE \"user_role\" (\n" +
+			"  \"user_id\" INTEGER NOT NULL,\n" +
+			"  \"role_id\" INTEGER NOT NULL,\n" +
+			"  PRIMARY KEY (\"user_id\", \"role_id\")\n" +
+			")",
+	}, stmts)
+
+	// invalid
+	_, err = NewSchemaBuilder(MySQL).CreateTable("user", func(t *TableDef) {})
+	assert.ErrorIs(t, err, ErrInvalidSchema)
+
+	_, err = NewSchemaBuilder(SQLite).CreateTable("user", func(t *TableDef) {
+		t.Int("id").AutoIncrement()
+	})
+	assert.ErrorIs(t, err, ErrInvalidSchema)
+
+	_, err = NewSchemaBuilder(MySQL).CreateTable("user", func(t *TableDef) {
+		t.String("name", 0)
+	})
+	assert.ErrorIs(t, err, ErrInvalidSchema)
+}
+
+func TestAlterTable(t *testing.T) {
+	alter := func(t *AlterDef) {
+		t.String("email", 64).NotNull().Default("")
+		t.DropColumn("age")
+		t.RenameColumn("name", "nickname")
+		t.Index("idx_email", "email")
+		t.DropIndex("idx_name")
+	}
+
+	stmts, err := NewSchemaBuilder(MySQL).AlterTable("user", alter)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"ALTER TABLE `user` ADD COLUMN `email` VARCHAR(64) NOT NULL DEFAULT ''",
+		"ALTER TABLE `user` DROP COLUMN `age`",
+		"ALTER TABLE `user` RENAME COLUMN `name` TO `nickname`",
+		"CREATE INDEX `idx_email` ON `user` (`email`)",
+		"DROP INDEX `idx_name` ON `user`",
+	}, stmts)
+
+	stmts, err = NewSchemaBuilder(Postgres).AlterTable("user", alter)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		`ALTER TABLE "user" ADD COLUMN "email" VARCHAR(64) NOT NULL DEFAULT ''`,
+		`ALTER TABLE "user" DROP COLUMN "age"`,
+		`ALTER TABLE "user" RENAME COLUMN "name" TO "nickname"`,
+		`CREATE INDEX "idx_email" ON "user" ("email")`,
+		`DROP INDEX "idx_name"`,
+	}, stmts)
+
+	stmts, err = NewSchemaBuilder(SQLServer).AlterTable("user", alter)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"ALTER TABLE [user] ADD [email] NVARCHAR(64) NOT NULL DEFAULT ''",
+		"ALTER TABLE [user] DROP COLUMN [age]",
+		"EXEC sp_rename 'user.name', 'nickname', 'COLUMN'",
+		"CREATE INDEX [idx_email] ON [user] ([email])",
+		"DROP INDEX [idx_name] ON [user]",
+	}, stmts)
+
+	_, err = NewSchemaBuilder(MySQL).AlterTable("user", func(t *AlterDef) {})
+	assert.ErrorIs(t, err, ErrInvalidSchema)
+}
+
+func TestSchemaExec(t *testing.T) {
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	schema := NewSchemaBuilder(SQLite)
+
+	stmts, err := schema.CreateTable("user", func(t *TableDef) {
+		t.BigInt("id").AutoIncrement().PrimaryKey()
+		t.String("name", 32).NotNull().Default("")
+		t.Bool("enabled").NotNull().Default(false)
+		t.Index("idx_name", "name")
+	})
+
+	assert.Nil(t, err)
+
+	alters, err := schema.AlterTable("user", func(t *AlterDef) {
+		t.Int("age").NotNull().Default(0)
+		t.RenameColumn("name", "nickname")
+	})
+
+	assert.Nil(t, err)
+
+	for _, v := range append(stmts, alters...) {
+		_, err = db.Exec(v)
+		assert.Nil(t, err)
+	}
+
+	_, err = db.Exec(`INSERT INTO "user" ("nickname") VALUES ('shenghui')`)
+	assert.Nil(t, err)
+
+	var age int
+
+	err = db.Get(&age, `SELECT "age" FROM "user" WHERE "nickname" = 'shenghui'`)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, age)
+
+	drop, err := schema.DropTable("user", true)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `DROP TABLE IF EXISTS "user"`, drop)
+
+	_, err = db.Exec(drop)
+	assert.Nil(t, err)
+}
+
+type SchemaModel struct {
+	ID        int64          `db:"id,omitempty" ddl:"pk;autoincr"`
+	Name      string         `db:"name" ddl:"size:32;notnull;default:'';index:idx_name_age"`
+	Age       int32          `db:"age" ddl:"notnull;default:0;index:idx_name_age"`
+	Email     sql.NullString `db:"email" ddl:"uniqueIndex:uniq_email"`
+	Price     float64        `db:"price" ddl:"type:decimal(10,2)"`
+	Extra     string         `db:"extra" ddl:"type:json"`
+	Enabled   bool           `db:"enabled"`
+	CreatedAt time.Time      `db:"created_at" ddl:"default:CURRENT_TIMESTAMP"`
+	Ignored   string         `db:"-"`
+	Skipped   string         `db:"skipped,omitempty" ddl:"-"`
+}
+
+func TestCreateTableFrom(t *testing.T) {
+	stmts, err := NewSchemaBuilder(MySQL).CreateTableFrom("user", new(SchemaModel), func(t *TableDef) {
+		t.Option("ENGINE=InnoDB")
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"CREATE TABLE `user` (\n" +
+			"  `id` BIGINT AUTO_INCREMENT PRIMARY KEY,\n" +
+			"  `name` VARCHAR(32) NOT NULL DEFAULT '',\n" +
+			"  `age` INT NOT NULL DEFAULT 0,\n" +
+			"  `email` VARCHAR(255),\n" +
+			"  `price` DECIMAL(10,2),\n" +
+			"  `extra` JSON,\n" +
+			"  `enabled` TINYINT(1),\n" +
+			"  `created_at` DATETIME DEFAULT CURRENT_TIMESTAMP\n" +
+			") ENGINE=InnoDB",
+		"CREATE INDEX `idx_name_age` ON `user` (`name`, `age`)",
+		"CREATE UNIQUE INDEX `uniq_email` ON `user` (`email`)",
+	}, stmts)
+
+	// composite primary key
+	type UserRole struct {
+		UserID int64 `db:"user_id" ddl:"pk"`
+		RoleID int64 `db:"role_id" ddl:"pk"`
+	}
+
+	stmts, err = NewSchemaBuilder(Postgres).CreateTableFrom("user_role", UserRole{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		`CREATE TABLE "user_role" (` + "\n" +
+			`  "user_id" BIGINT,` + "\n" +
+			`  "role_id" BIGINT,` + "\n" +
+			`  PRIMARY KEY ("user_id", "role_id")` + "\n" +
+			`)`,
+	}, stmts)
+
+	// invalid
+	_, err = NewSchemaBuilder(MySQL).CreateTableFrom("user", X{})
+	assert.ErrorIs(t, err, ErrInvalidSchema)
+
+	type Invalid struct {
+		Tags []string `db:"tags"`
+	}
+
+	_, err = NewSchemaBuilder(MySQL).CreateTableFrom("user", Invalid{})
+	assert.ErrorIs(t, err, ErrInvalidSchema)
+
+	type Unknown struct {
+		Name string `db:"name" ddl:"nullable"`
+	}
+
+	_, err = NewSchemaBuilder(MySQL).CreateTableFrom("user", Unknown{})
+	assert.ErrorIs(t, err, ErrInvalidSchema)
+
+	// SQLite
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	stmts, err = NewSchemaBuilder(SQLite).CreateTableFrom("user", SchemaModel{})
+
+	assert.Nil(t, err)
+
+	for _, v := range stmts {
+		_, err = db.Exec(v)
+		assert.Nil(t, err)
+	}
+
+	id, err := NewSQLiteBuilder().Wrap(Table("user")).Insert(context.TODO(), db, &SchemaModel{
+		Name:      "shenghui",
+		Age:       29,
+		Extra:     "{}",
+		CreatedAt: time.Now(),
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), id)
+}
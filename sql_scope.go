@@ -0,0 +1,39 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+var scopes sync.Map
+
+// ScopeFunc returns the query options of the scope, eg: tenant filter by the context.
+type ScopeFunc func(ctx context.Context) []QueryOption
+
+// Scopes composes the query options into one, eg:
+//
+//	ActiveUsers := yiigo.Scopes(yiigo.Table("user"), yiigo.Where("status = ?", 1))
+func Scopes(options ...QueryOption) QueryOption {
+	return func(w *queryWrapper) {
+		for _, f := range options {
+			f(w)
+		}
+	}
+}
+
+// RegisterScope registers the named scope, which can be applied by `yiigo.Scope(ctx, name)`.
+func RegisterScope(name string, fn ScopeFunc) {
+	scopes.Store(name, fn)
+}
+
+// Scope applies the registered scope by name, panics if the scope is not registered.
+func Scope(ctx context.Context, name string) QueryOption {
+	v, ok := scopes.Load(name)
+
+	if !ok {
+		logger.Panic(fmt.Sprintf("unknown scope.%s (forgotten register?)", name))
+	}
+
+	return Scopes(v.(ScopeFunc)(ctx)...)
+}
@@ -0,0 +1,36 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tenantKey struct{}
+
+func TestScope(t *testing.T) {
+	ctx := context.WithValue(context.TODO(), tenantKey{}, 10)
+
+	RegisterScope("tenant", func(ctx context.Context) []QueryOption {
+		return []QueryOption{
+			WhereClause(Clause("tenant_id = ?", ctx.Value(tenantKey{}))),
+		}
+	})
+
+	activeUsers := Scopes(Table("user"), WhereClause(Clause("status = ?", 1)))
+
+	sql, args, err := NewMySQLBuilder().Wrap(
+		activeUsers,
+		Scope(ctx, "tenant"),
+		Where("age > ?", 20),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM `user` WHERE (age > ?) AND (status = ?) AND (tenant_id = ?)", sql)
+	assert.Equal(t, []any{20, 1, 10}, args)
+
+	assert.Panics(t, func() {
+		Scope(ctx, "unknown")
+	})
+}
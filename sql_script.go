@@ -0,0 +1,310 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// eg: $$, $body$
+	dollarQuoteRegexp = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+	// eg: DELIMITER //
+	delimiterRegexp = regexp.MustCompile(`(?i)^DELIMITER[ \t]+(\S+)[ \t]*(?:\r?\n|$)`)
+
+	// eg: GO
+	batchRegexp = regexp.MustCompile(`(?i)^GO[ \t]*(?:\r?\n|$)`)
+)
+
+// SplitSQL splits the script into the statements by the dialect of the driver, the blank (or comment only) ones are skipped.
+// The `;` in the quotes, identifiers, comments and dollar quotes (Postgres) doesn't end the statement, and:
+//   - MySQL: the `DELIMITER` directive changes the delimiter, eg: the procedures and triggers
+//   - SQL Server: the line of `GO` ends the batch (statement) besides `;`
+//   - SQLite: the `;` in the body (BEGIN ... END) of `CREATE TRIGGER` doesn't end the statement
+//
+// The unknown driver (eg: empty) is handled like MySQL and Postgres.
+func SplitSQL(driver DBDriver, script string) ([]string, error) {
+	var (
+		stmts     = make([]string, 0)
+		builder   strings.Builder
+		delimiter = ";"
+		code      bool // the statement has the code besides the comments
+		lineStart = true
+		head      []string // the leading keywords of the statement (SQLite)
+		depth     int      // the nesting depth of BEGIN (CASE) ... END in the trigger body (SQLite)
+	)
+
+	flush := func() {
+		if code {
+			stmts = append(stmts, strings.TrimSpace(builder.String()))
+		}
+
+		builder.Reset()
+		code = false
+		head = head[:0]
+		depth = 0
+	}
+
+	for i := 0; i < len(script); {
+		rest := script[i:]
+
+		if lineStart {
+			// the directives are at the start of line
+			if trimmed := strings.TrimLeft(rest, " \t"); len(trimmed) != 0 {
+				skip := len(rest) - len(trimmed)
+
+				if driver != Postgres && driver != SQLite && driver != SQLServer {
+					if m := delimiterRegexp.FindStringSubmatch(trimmed); len(m) != 0 {
+						flush()
+
+						delimiter = m[1]
+						i += skip + len(m[0])
+
+						continue
+					}
+				}
+
+				if driver == SQLServer {
+					if m := batchRegexp.FindString(trimmed); len(m) != 0 {
+						flush()
+
+						i += skip + len(m)
+
+						continue
+					}
+				}
+			}
+		}
+
+		c := script[i]
+
+		switch {
+		case strings.HasPrefix(rest, delimiter) && depth == 0:
+			flush()
+
+			i += len(delimiter)
+			lineStart = false
+
+			continue
+		case strings.HasPrefix(rest, "--"), c == '#' && (driver == MySQL || len(driver) == 0):
+			end := strings.IndexByte(rest, '\n')
+
+			if end < 0 {
+				end = len(rest)
+			}
+
+			builder.WriteString(rest[:end])
+			i += end
+
+			continue
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+
+			// MySQL executable comment, eg: /*!50001 ... */
+			if strings.HasPrefix(rest, "/*!") || strings.HasPrefix(rest, "/*+") {
+				code = true
+			}
+
+			builder.WriteString(rest[:end+4])
+			i += end + 4
+			lineStart = false
+
+			continue
+		case c == '\'' || c == '"' || c == '`' || (c == '[' && driver == SQLServer):
+			end, err := quoteEnd(driver, rest)
+
+			if err != nil {
+				return nil, fmt.Errorf("%w at offset %d", err, i)
+			}
+
+			builder.WriteString(rest[:end])
+			i += end
+			code = true
+			lineStart = false
+
+			continue
+		case c == '$' && driver != MySQL && driver != SQLite && driver != SQLServer:
+			if m := dollarQuoteRegexp.FindString(rest); len(m) != 0 {
+				end := strings.Index(rest[len(m):], m)
+
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated dollar quote %s at offset %d", m, i)
+				}
+
+				n := len(m) + end + len(m)
+
+				builder.WriteString(rest[:n])
+				i += n
+				code = true
+				lineStart = false
+
+				continue
+			}
+		case driver == SQLite && isWordByte(c) && (i == 0 || !isWordByte(script[i-1])):
+			n := 1
+
+			for n < len(rest) && isWordByte(rest[n]) {
+				n++
+			}
+
+			word := strings.ToUpper(rest[:n])
+
+			if len(head) < 3 {
+				head = append(head, word)
+			}
+
+			if isCreateTrigger(head) {
+				switch word {
+				case "BEGIN", "CASE":
+					depth++
+				case "END":
+					if depth > 0 {
+						depth--
+					}
+				}
+			}
+
+			builder.WriteString(rest[:n])
+			i += n
+			code = true
+			lineStart = false
+
+			continue
+		}
+
+		builder.WriteByte(c)
+		i++
+
+		switch c {
+		case '\n':
+			lineStart = true
+		case ' ', '\t', '\r':
+		default:
+			code = true
+			lineStart = false
+		}
+	}
+
+	flush()
+
+	return stmts, nil
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+// isCreateTrigger reports whether the leading keywords are `CREATE [TEMP | TEMPORARY] TRIGGER`.
+func isCreateTrigger(head []string) bool {
+	if len(head) < 2 || head[0] != "CREATE" {
+		return false
+	}
+
+	if head[1] == "TEMP" || head[1] == "TEMPORARY" {
+		return len(head) > 2 && head[2] == "TRIGGER"
+	}
+
+	return head[1] == "TRIGGER"
+}
+
+// quoteEnd returns the end (exclusive) of the quoted string or identifier at the start of s.
+func quoteEnd(driver DBDriver, s string) (int, error) {
+	open := s[0]
+	closing := open
+
+	if open == '[' {
+		closing = ']'
+	}
+
+	// MySQL escapes the quote by backslash in the string
+	backslash := open != '`' && open != '[' && (driver == MySQL || len(driver) == 0)
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if backslash {
+				i++
+			}
+		case closing:
+			// the doubled quote is escaped, eg: 'it''s'
+			if i+1 < len(s) && s[i+1] == closing {
+				i++
+
+				continue
+			}
+
+			return i + 1, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unterminated quote %c", open)
+}
+
+// ScriptOption script option
+type ScriptOption func(o *scriptOptions)
+
+type scriptOptions struct {
+	tx bool
+}
+
+// WithScriptTx executes the statements in a transaction (if db is *sqlx.DB), all or nothing.
+// NOTE: The DDL of MySQL causes implicit commit, so it's not rolled back.
+func WithScriptTx() ScriptOption {
+	return func(o *scriptOptions) {
+		o.tx = true
+	}
+}
+
+// ExecScript executes the script of multiple statements (see `SplitSQL`) in order, eg: the bootstrap scripts.
+// It stops at the first failed statement and returns the error with the statement number.
+func ExecScript(ctx context.Context, db sqlx.ExtContext, script string, options ...ScriptOption) error {
+	o := new(scriptOptions)
+
+	for _, f := range options {
+		f(o)
+	}
+
+	stmts, err := SplitSQL(DBDriver(db.DriverName()), script)
+
+	if err != nil {
+		return err
+	}
+
+	if v, ok := db.(*sqlx.DB); ok && o.tx {
+		return DBTransaction(ctx, v, func(ctx context.Context, tx *sqlx.Tx) error {
+			return execScript(ctx, tx, stmts)
+		})
+	}
+
+	return execScript(ctx, db, stmts)
+}
+
+// ExecScriptFile executes the script file (eg: init.sql), see `ExecScript`.
+func ExecScriptFile(ctx context.Context, db sqlx.ExtContext, filename string, options ...ScriptOption) error {
+	b, err := os.ReadFile(filename)
+
+	if err != nil {
+		return err
+	}
+
+	return ExecScript(ctx, db, string(b), options...)
+}
+
+func execScript(ctx context.Context, db sqlx.ExecerContext, stmts []string) error {
+	for i, v := range stmts {
+		if _, err := db.ExecContext(ctx, v); err != nil {
+			return fmt.Errorf("script statement %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package yiigo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSQL(t *testing.T) {
+	stmts, err := SplitSQL(SQLite, `
+-- create table
+CREATE TABLE user (
+    id INTEGER PRIMARY KEY,
+    name TEXT DEFAULT 'a;b''c' /* ; */
+);
+
+-- only comment;
+INSERT INTO user (name) VALUES ("x;y"); INSERT INTO user (name) VALUES ('z')`)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"-- create table\nCREATE TABLE user (\n    id INTEGER PRIMARY KEY,\n    name TEXT DEFAULT 'a;b''c' /* ; */\n)",
+		"-- only comment;\nINSERT INTO user (name) VALUES (\"x;y\")",
+		"INSERT INTO user (name) VALUES ('z')",
+	}, stmts)
+
+	_, err = SplitSQL(SQLite, "SELECT 'a;")
+	assert.NotNil(t, err)
+}
+
+func TestSplitSQLMySQL(t *testing.T) {
+	stmts, err := SplitSQL(MySQL, `
+# users
+DROP PROCEDURE IF EXISTS add_user;
+DELIMITER //
+CREATE PROCEDURE add_user(IN n VARCHAR(32))
+BEGIN
+    INSERT INTO user (name) VALUES (n);
+    SELECT 'it\'s;ok';
+END //
+DELIMITER ;
+CALL add_user('a');`)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"# users\nDROP PROCEDURE IF EXISTS add_user",
+		"CREATE PROCEDURE add_user(IN n VARCHAR(32))\nBEGIN\n    INSERT INTO user (name) VALUES (n);\n    SELECT 'it\\'s;ok';\nEND",
+		"CALL add_user('a')",
+	}, stmts)
+}
+
+func TestSplitSQLPostgres(t *testing.T) {
+	stmts, err := SplitSQL(Postgres, `
+CREATE FUNCTION inc(i integer) RETURNS integer AS $body$
+BEGIN
+    RETURN i + 1;
+END;
+$body$ LANGUAGE plpgsql;
+SELECT inc($1);`)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"CREATE FUNCTION inc(i integer) RETURNS integer AS $body$\nBEGIN\n    RETURN i + 1;\nEND;\n$body$ LANGUAGE plpgsql",
+		"SELECT inc($1)",
+	}, stmts)
+}
+
+func TestSplitSQLiteTrigger(t *testing.T) {
+	stmts, err := SplitSQL(SQLite, `
+CREATE TEMP TRIGGER user_log AFTER UPDATE ON user
+BEGIN
+    INSERT INTO log (msg) VALUES ('end;');
+    UPDATE user SET level = CASE WHEN NEW.age > 18 THEN 1 ELSE 0 END WHERE id = NEW.id;
+END;
+SELECT endpoint FROM user;`)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"CREATE TEMP TRIGGER user_log AFTER UPDATE ON user\nBEGIN\n    INSERT INTO log (msg) VALUES ('end;');\n    UPDATE user SET level = CASE WHEN NEW.age > 18 THEN 1 ELSE 0 END WHERE id = NEW.id;\nEND",
+		"SELECT endpoint FROM user",
+	}, stmts)
+}
+
+func TestSplitSQLServer(t *testing.T) {
+	stmts, err := SplitSQL(SQLServer, `
+CREATE TABLE [a;b] (id INT)
+GO
+CREATE PROCEDURE p AS SELECT 1
+go
+`)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"CREATE TABLE [a;b] (id INT)",
+		"CREATE PROCEDURE p AS SELECT 1",
+	}, stmts)
+}
+
+func TestExecScript(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := OpenSQLiteMemory()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	script := `
+CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT);
+INSERT INTO user (name) VALUES ('a;b');
+INSERT INTO user (name) VALUES ('c');`
+
+	assert.Nil(t, ExecScript(ctx, db, script))
+
+	var count int
+
+	assert.Nil(t, db.GetContext(ctx, &count, "SELECT COUNT(*) FROM user"))
+	assert.Equal(t, 2, count)
+
+	// rolled back in the transaction
+	err = ExecScript(ctx, db, "INSERT INTO user (name) VALUES ('d');\nINSERT INTO nobody (name) VALUES ('e');", WithScriptTx())
+
+	assert.ErrorContains(t, err, "script statement 2")
+	assert.Nil(t, db.GetContext(ctx, &count, "SELECT COUNT(*) FROM user"))
+	assert.Equal(t, 2, count)
+
+	filename := filepath.Join(t.TempDir(), "seed.sql")
+
+	assert.Nil(t, os.WriteFile(filename, []byte("INSERT INTO user (name) VALUES ('f');"), 0644))
+	assert.Nil(t, ExecScriptFile(ctx, db, filename, WithScriptTx()))
+	assert.Nil(t, db.GetContext(ctx, &count, "SELECT COUNT(*) FROM user"))
+	assert.Equal(t, 3, count)
+}
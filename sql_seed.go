@@ -0,0 +1,307 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type fixture struct {
+	table     string
+	rows      any
+	dependsOn []string
+}
+
+// SeedOption seeder option
+type SeedOption func(s *Seeder)
+
+// WithSeedCreateTable creates the tables (if not exists) from the models (`db` and `ddl` tags) before seeding,
+// eg: the hermetic unit tests with `OpenSQLiteMemory`.
+func WithSeedCreateTable() SeedOption {
+	return func(s *Seeder) {
+		s.createTable = true
+	}
+}
+
+// WithSeedTableResolver specifies the TableResolver of the models, default: SnakeTableResolver.
+func WithSeedTableResolver(fn TableResolver) SeedOption {
+	return func(s *Seeder) {
+		s.resolver = fn
+	}
+}
+
+// Seeder loads the fixtures (model slices or []yiigo.X) into the tables for tests and local dev, eg:
+//
+//	err := yiigo.NewSeeder(db).
+//		Add("", []User{{ID: 1, Name: "yiigo"}}).
+//		Add("order", []yiigo.X{{"id": 1, "user_id": 1}}, "user").
+//		Seed(ctx)
+//
+// The tables are truncated in reverse dependency order, then loaded in dependency order.
+type Seeder struct {
+	db          *sqlx.DB
+	driver      DBDriver
+	resolver    TableResolver
+	createTable bool
+	fixtures    []*fixture
+	err         error
+}
+
+// NewSeeder returns new Seeder.
+func NewSeeder(db *sqlx.DB, options ...SeedOption) *Seeder {
+	s := &Seeder{
+		db:       db,
+		driver:   DBDriver(db.DriverName()),
+		resolver: SnakeTableResolver,
+	}
+
+	for _, f := range options {
+		f(s)
+	}
+
+	return s
+}
+
+// Add adds the fixture rows ([]struct, []*struct or []yiigo.X) of the table which depends on (references) the tables,
+// the table is resolved from the model like `Repo` if empty.
+func (s *Seeder) Add(table string, rows any, dependsOn ...string) *Seeder {
+	if s.err != nil {
+		return s
+	}
+
+	if len(table) == 0 {
+		t, ok := seedModel(rows)
+
+		if !ok {
+			s.err = fmt.Errorf("seed: table is required for %T", rows)
+
+			return s
+		}
+
+		table = tableOf(t, s.resolver)
+	}
+
+	for _, v := range s.fixtures {
+		if v.table == table {
+			s.err = fmt.Errorf("seed: duplicate table %s", table)
+
+			return s
+		}
+	}
+
+	s.fixtures = append(s.fixtures, &fixture{
+		table:     table,
+		rows:      rows,
+		dependsOn: dependsOn,
+	})
+
+	return s
+}
+
+// Seed truncates the tables and loads the fixtures.
+func (s *Seeder) Seed(ctx context.Context) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	fixtures, err := s.sort()
+
+	if err != nil {
+		return err
+	}
+
+	conn, err := s.db.Connx(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if s.createTable {
+		if err = s.createTables(ctx, conn, fixtures); err != nil {
+			return err
+		}
+	}
+
+	builder := NewSQLBuilder(s.driver)
+
+	// MySQL refuses to truncate the referenced table
+	if s.driver == MySQL {
+		if _, err = conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+			return err
+		}
+
+		defer func() {
+			if _, err := conn.ExecContext(context.Background(), "SET FOREIGN_KEY_CHECKS = 1"); err != nil {
+				logger.Error("err seed restore foreign key checks", zap.Error(err))
+			}
+		}()
+	}
+
+	for i := len(fixtures) - 1; i >= 0; i-- {
+		var options []TruncateOption
+
+		if s.driver == Postgres {
+			options = append(options, RestartIdentity(), Cascade())
+		}
+
+		query, err := builder.Wrap(Table(fixtures[i].table)).ToTruncate(ctx, options...)
+
+		if err != nil {
+			return err
+		}
+
+		if _, err = conn.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("seed %s: %w", fixtures[i].table, err)
+		}
+	}
+
+	// the truncate of MySQL causes implicit commit, so only the inserts are in the transaction
+	tx, err := conn.BeginTxx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	for _, v := range fixtures {
+		if rv := reflect.Indirect(reflect.ValueOf(v.rows)); rv.Kind() == reflect.Slice && rv.Len() == 0 {
+			continue
+		}
+
+		if _, err = builder.Wrap(Table(v.table)).BatchInsert(ctx, tx, v.rows); err != nil {
+			rollback(tx)
+
+			return fmt.Errorf("seed %s: %w", v.table, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// sort returns the fixtures in dependency order (topological sort), the dependency not added is ignored.
+func (s *Seeder) sort() ([]*fixture, error) {
+	fixtures := make(map[string]*fixture, len(s.fixtures))
+
+	for _, v := range s.fixtures {
+		fixtures[v.table] = v
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := make(map[string]int, len(s.fixtures))
+	sorted := make([]*fixture, 0, len(s.fixtures))
+
+	var visit func(f *fixture) error
+
+	visit = func(f *fixture) error {
+		switch state[f.table] {
+		case visiting:
+			return fmt.Errorf("seed: circular dependency of %s", f.table)
+		case visited:
+			return nil
+		}
+
+		state[f.table] = visiting
+
+		for _, dep := range f.dependsOn {
+			if v, ok := fixtures[dep]; ok {
+				if err := visit(v); err != nil {
+					return err
+				}
+			}
+		}
+
+		state[f.table] = visited
+
+		sorted = append(sorted, f)
+
+		return nil
+	}
+
+	// keep the order of adding for the independent fixtures
+	for _, v := range s.fixtures {
+		if err := visit(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+func (s *Seeder) createTables(ctx context.Context, conn *sqlx.Conn, fixtures []*fixture) error {
+	sb := NewSchemaBuilder(s.driver)
+
+	for _, v := range fixtures {
+		t, ok := seedModel(v.rows)
+
+		// the table of yiigo.X is expected to exist
+		if !ok {
+			continue
+		}
+
+		stmts, err := sb.CreateTableFrom(v.table, reflect.New(t).Interface(), func(t *TableDef) {
+			t.IfNotExists()
+		})
+
+		if err != nil {
+			return fmt.Errorf("seed %s: %w", v.table, err)
+		}
+
+		for _, stmt := range stmts {
+			if _, err = conn.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("seed %s: %w", v.table, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// seedModel returns the struct type of the rows ([]struct or []*struct).
+func seedModel(rows any) (reflect.Type, bool) {
+	t := reflect.TypeOf(rows)
+
+	if t == nil {
+		return nil, false
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Slice {
+		return nil, false
+	}
+
+	t = t.Elem()
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t, t.Kind() == reflect.Struct
+}
+
+// OpenSQLiteMemory returns the SQLite in-memory database for the hermetic unit tests,
+// which is limited to one connection since each connection of `:memory:` is a separate database.
+func OpenSQLiteMemory() (*sqlx.DB, error) {
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+
+	return db, nil
+}
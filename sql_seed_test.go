@@ -0,0 +1,108 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type seedUser struct {
+	ID   int64  `db:"id" ddl:"pk"`
+	Name string `db:"name" ddl:"size:32;notnull"`
+}
+
+func (seedUser) TableName() string {
+	return "user"
+}
+
+type seedOrder struct {
+	ID     int64 `db:"id" ddl:"pk"`
+	UserID int64 `db:"user_id" ddl:"index:idx_user"`
+	Amount int   `db:"amount"`
+}
+
+func TestSeeder(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := OpenSQLiteMemory()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	// the order depends on the user
+	err = NewSeeder(db, WithSeedCreateTable()).
+		Add("", []*seedOrder{{ID: 1, UserID: 1, Amount: 100}, {ID: 2, UserID: 2, Amount: 200}}, "user").
+		Add("", []seedUser{{ID: 1, Name: "foo"}, {ID: 2, Name: "bar"}}).
+		Seed(ctx)
+
+	assert.Nil(t, err)
+
+	var count int
+
+	assert.Nil(t, db.Get(&count, "SELECT COUNT(*) FROM seed_order o JOIN user u ON u.id = o.user_id"))
+	assert.Equal(t, 2, count)
+
+	// tables (and indexes) exist
+	err = NewSeeder(db, WithSeedCreateTable()).
+		Add("", []*seedOrder{{ID: 1, UserID: 1, Amount: 100}}, "user").
+		Add("", []seedUser{{ID: 1, Name: "foo"}}).
+		Seed(ctx)
+
+	assert.Nil(t, err)
+
+	// truncated and reloaded
+	err = NewSeeder(db).
+		Add("user", []X{{"id": 3, "name": "baz"}}).
+		Add("seed_order", []X{}, "user").
+		Seed(ctx)
+
+	assert.Nil(t, err)
+
+	names := make([]string, 0)
+
+	assert.Nil(t, db.Select(&names, "SELECT name FROM user"))
+	assert.Equal(t, []string{"baz"}, names)
+
+	assert.Nil(t, db.Get(&count, "SELECT COUNT(*) FROM seed_order"))
+	assert.Equal(t, 0, count)
+
+	// circular dependency
+	err = NewSeeder(db).Add("user", []X{}, "seed_order").Add("seed_order", []X{}, "user").Seed(ctx)
+	assert.NotNil(t, err)
+
+	// table is required
+	err = NewSeeder(db).Add("", []X{{"id": 1}}).Seed(ctx)
+	assert.NotNil(t, err)
+
+	// duplicate table
+	err = NewSeeder(db).Add("user", []X{}).Add("", []seedUser{}).Seed(ctx)
+	assert.NotNil(t, err)
+}
+
+func TestSeederSort(t *testing.T) {
+	db, err := OpenSQLiteMemory()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	s := NewSeeder(db).
+		Add("item", []X{}, "order", "product").
+		Add("order", []X{}, "user").
+		Add("product", []X{}).
+		Add("user", []X{})
+
+	fixtures, err := s.sort()
+
+	assert.Nil(t, err)
+
+	tables := make([]string, 0, len(fixtures))
+
+	for _, v := range fixtures {
+		tables = append(tables, v.table)
+	}
+
+	assert.Equal(t, []string{"user", "order", "product", "item"}, tables)
+}
@@ -0,0 +1,393 @@
+package yiigo
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrShardKey the shard key of the sharded table is missing or invalid.
+var ErrShardKey = errors.New("invalid shard key")
+
+var orRegexp = regexp.MustCompile(`(?i)\bOR\b`)
+
+// ShardStrategy returns the shard of the key.
+type ShardStrategy func(key any) (int, error)
+
+// ShardMod returns the strategy of `key % n`, the key expects integer (or numeric string).
+func ShardMod(n int) ShardStrategy {
+	return func(key any) (int, error) {
+		if n <= 0 {
+			return 0, fmt.Errorf("%w: invalid shard count %d", ErrShardKey, n)
+		}
+
+		i, err := shardInt(key)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if i < 0 {
+			i = -i
+		}
+
+		return int(i % int64(n)), nil
+	}
+}
+
+// ShardHash returns the strategy of `crc32(key) % n`, eg: the string key.
+func ShardHash(n int) ShardStrategy {
+	return func(key any) (int, error) {
+		if n <= 0 {
+			return 0, fmt.Errorf("%w: invalid shard count %d", ErrShardKey, n)
+		}
+
+		return int(crc32.ChecksumIEEE([]byte(fmt.Sprint(key))) % uint32(n)), nil
+	}
+}
+
+// ShardRange returns the strategy of the ascending bounds, the shard is the number of the bounds <= key,
+// eg: ShardRange(1000000, 2000000) returns 0 for [0, 1000000), 1 for [1000000, 2000000) and 2 for the rest.
+func ShardRange(bounds ...int64) ShardStrategy {
+	return func(key any) (int, error) {
+		i, err := shardInt(key)
+
+		if err != nil {
+			return 0, err
+		}
+
+		return sort.Search(len(bounds), func(n int) bool { return bounds[n] > i }), nil
+	}
+}
+
+// ShardDate returns the strategy of the date formatted by the layout, eg: ShardDate("200601") returns 202601 for 2026-01-15.
+// The key expects time.Time or string (eg: 2006-01-02 15:04:05, 2006-01-02, RFC3339).
+func ShardDate(layout string) ShardStrategy {
+	return func(key any) (int, error) {
+		var t time.Time
+
+		switch v := key.(type) {
+		case time.Time:
+			t = v
+		case *time.Time:
+			if v == nil {
+				return 0, fmt.Errorf("%w: nil time", ErrShardKey)
+			}
+
+			t = *v
+		case string:
+			var err error
+
+			for _, l := range []string{"2006-01-02 15:04:05", "2006-01-02", time.RFC3339} {
+				if t, err = time.ParseInLocation(l, v, time.Local); err == nil {
+					break
+				}
+			}
+
+			if err != nil {
+				return 0, fmt.Errorf("%w: %q", ErrShardKey, v)
+			}
+		default:
+			return 0, fmt.Errorf("%w: %T expects time", ErrShardKey, key)
+		}
+
+		return strconv.Atoi(t.Format(layout))
+	}
+}
+
+func shardInt(key any) (int64, error) {
+	v := reflect.ValueOf(key)
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.String:
+		i, err := strconv.ParseInt(v.String(), 10, 64)
+
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrShardKey, v.String())
+		}
+
+		return i, nil
+	}
+
+	return 0, fmt.Errorf("%w: %T expects integer", ErrShardKey, key)
+}
+
+// ShardRule the sharding rule of the table, eg:
+//
+//	yiigo.NewShardRule("orders", "user_id", yiigo.ShardMod(16)) // orders_00 ~ orders_15
+//	yiigo.NewShardRule("logs", "created_at", yiigo.ShardDate("200601")) // logs_202601
+type ShardRule struct {
+	table    string
+	column   string
+	strategy ShardStrategy
+	width    int
+	database func(shard int) string
+	pattern  *regexp.Regexp
+}
+
+// NewShardRule returns new ShardRule of the table which is sharded by the column.
+func NewShardRule(table, column string, strategy ShardStrategy) *ShardRule {
+	return &ShardRule{
+		table:    table,
+		column:   column,
+		strategy: strategy,
+		width:    2,
+		// eg: user_id = ?, o.user_id = ?
+		pattern: regexp.MustCompile(`(?:^|[\s(])(?:[A-Za-z_][A-Za-z0-9_$]*\.)?` + regexp.QuoteMeta(column) + `\s*=\s*\?`),
+	}
+}
+
+// Width specifies the zero-padded width of the shard suffix, default: 2 (eg: orders_07).
+func (r *ShardRule) Width(n int) *ShardRule {
+	r.width = n
+
+	return r
+}
+
+// Database specifies the database (the name registered by `Init`) of the shard, eg: 4 databases with 16 tables:
+// rule.Database(func(shard int) string { return fmt.Sprintf("order_%d", shard/4) }).
+func (r *ShardRule) Database(fn func(shard int) string) *ShardRule {
+	r.database = fn
+
+	return r
+}
+
+// Route returns the table (eg: orders_07) and the database (empty if not specified) of the key.
+func (r *ShardRule) Route(key any) (table, database string, err error) {
+	shard, err := r.strategy(key)
+
+	if err != nil {
+		return
+	}
+
+	table = fmt.Sprintf("%s_%0*d", r.table, r.width, shard)
+
+	if r.database != nil {
+		database = r.database(shard)
+	}
+
+	return
+}
+
+// WithSharding specifies the sharding rules, the builder rewrites the sharded table (eg: orders -> orders_07) by the shard key,
+// which is resolved in order: `ShardKey`, the column of the insert data, the `column = ?` bind of the `where` clause.
+// The statement of the sharded table without shard key returns ErrShardKey.
+// The executor (eg: Insert, Select) runs on the db of the shard database if `ShardRule.Database` is specified.
+// NOTE: Only the table of `Table` (`TableAs`) is sharded, the joined tables and unions are not.
+func WithSharding(rules ...*ShardRule) SQLBuilderOption {
+	return func(b *queryBuilder) {
+		if b.shards == nil {
+			b.shards = make(map[string]*ShardRule, len(rules))
+		}
+
+		for _, v := range rules {
+			b.shards[v.table] = v
+		}
+	}
+}
+
+// ShardKey specifies the shard key of the sharded table.
+func ShardKey(key any) QueryOption {
+	return func(w *queryWrapper) {
+		w.shardKey = key
+		w.shardSet = true
+	}
+}
+
+// shard returns the wrapper with the sharded table, data is the insert (batch insert) data.
+func (w *queryWrapper) shard(data any) (*queryWrapper, error) {
+	table, _, ok, err := w.shardRoute(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return w, nil
+	}
+
+	wrapper := *w
+	wrapper.table = table
+
+	return &wrapper, nil
+}
+
+// shardRoute returns the sharded table (with alias) and the database of the shard, ok is false if the table isn't sharded.
+func (w *queryWrapper) shardRoute(data any) (table, database string, ok bool, err error) {
+	if len(w.builder.shards) == 0 {
+		return
+	}
+
+	name, alias := strings.TrimSpace(w.table), ""
+
+	if m := tableRegexp.FindStringSubmatch(name); len(m) != 0 {
+		name, alias = m[1], name[len(m[1]):]
+	}
+
+	rule, ok := w.builder.shards[name]
+
+	if !ok {
+		return
+	}
+
+	key, found, err := w.shardKeyOf(rule, data)
+
+	if err != nil {
+		return
+	}
+
+	if !found {
+		err = fmt.Errorf("%w: %s requires %s", ErrShardKey, rule.table, rule.column)
+
+		return
+	}
+
+	if table, database, err = rule.Route(key); err != nil {
+		return
+	}
+
+	table += alias
+
+	return
+}
+
+// shardExecutor returns the db of the shard database (see `ShardRule.Database`) instead of db,
+// the transaction (`Tx`) must be of the shard database, and the `*sqlx.Tx` is used as is.
+func shardExecutor[T any](w *queryWrapper, db T, data any) (T, error) {
+	_, database, ok, err := w.shardRoute(data)
+
+	if err != nil || !ok || len(database) == 0 {
+		return db, err
+	}
+
+	v, found := dbmap.Load(database)
+
+	if !found {
+		return db, fmt.Errorf("%w: unknown db.%s of %s", ErrShardKey, database, w.table)
+	}
+
+	switch tx := any(db).(type) {
+	case *Tx:
+		if tx.db != v.(*sqlx.DB) {
+			return db, fmt.Errorf("%w: transaction is not of db.%s", ErrShardKey, database)
+		}
+
+		return db, nil
+	case *sqlx.Tx:
+		return db, nil
+	}
+
+	return v.(T), nil
+}
+
+func (w *queryWrapper) shardKeyOf(rule *ShardRule, data any) (any, bool, error) {
+	if w.shardSet {
+		return w.shardKey, true, nil
+	}
+
+	if data != nil {
+		return shardKeyOfData(rule, data)
+	}
+
+	clauses := make([]*SQLClause, 0, len(w.conds)+1)
+
+	if w.where != nil {
+		clauses = append(clauses, w.where)
+	}
+
+	clauses = append(clauses, w.conds...)
+
+	for _, v := range clauses {
+		// the condition combined by `OR` doesn't determine the shard
+		if orRegexp.MatchString(v.query) {
+			continue
+		}
+
+		loc := rule.pattern.FindStringIndex(v.query)
+
+		if loc == nil {
+			continue
+		}
+
+		if i := strings.Count(v.query[:loc[1]], "?") - 1; i < len(v.binds) {
+			return v.binds[i], true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// shardKeyOfData returns the shard key of the insert data, the rows of batch insert must be in the same shard.
+func shardKeyOfData(rule *ShardRule, data any) (any, bool, error) {
+	v := reflect.Indirect(reflect.ValueOf(data))
+
+	if v.Kind() != reflect.Slice {
+		return shardKeyOfRow(rule, v)
+	}
+
+	var (
+		key   any
+		shard = -1
+	)
+
+	for i := 0; i < v.Len(); i++ {
+		k, ok, err := shardKeyOfRow(rule, reflect.Indirect(v.Index(i)))
+
+		if err != nil || !ok {
+			return nil, ok, err
+		}
+
+		n, err := rule.strategy(k)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if shard >= 0 && n != shard {
+			return nil, false, fmt.Errorf("%w: rows across shards of %s", ErrShardKey, rule.table)
+		}
+
+		key, shard = k, n
+	}
+
+	return key, shard >= 0, nil
+}
+
+func shardKeyOfRow(rule *ShardRule, v reflect.Value) (any, bool, error) {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false, nil
+		}
+
+		mv := v.MapIndex(reflect.ValueOf(rule.column).Convert(v.Type().Key()))
+
+		if !mv.IsValid() {
+			return nil, false, nil
+		}
+
+		return mv.Interface(), true, nil
+	case reflect.Struct:
+		key, ok, err := columnKey(v, rule.column)
+
+		if err != nil {
+			return nil, false, nil
+		}
+
+		return key, ok, nil
+	}
+
+	return nil, false, nil
+}
@@ -0,0 +1,201 @@
+package yiigo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardStrategy(t *testing.T) {
+	n, err := ShardMod(16)(int64(23))
+	assert.Nil(t, err)
+	assert.Equal(t, 7, n)
+
+	n, err = ShardMod(16)("39")
+	assert.Nil(t, err)
+	assert.Equal(t, 7, n)
+
+	_, err = ShardMod(16)("abc")
+	assert.ErrorIs(t, err, ErrShardKey)
+
+	n, err = ShardHash(16)("yiigo")
+	assert.Nil(t, err)
+	assert.True(t, n >= 0 && n < 16)
+
+	m, _ := ShardHash(16)("yiigo")
+	assert.Equal(t, n, m)
+
+	for key, shard := range map[int64]int{0: 0, 999999: 0, 1000000: 1, 1999999: 1, 2000000: 2, 9000000: 2} {
+		n, err = ShardRange(1000000, 2000000)(key)
+		assert.Nil(t, err)
+		assert.Equal(t, shard, n, key)
+	}
+
+	n, err = ShardDate("200601")(time.Date(2026, 1, 15, 0, 0, 0, 0, time.Local))
+	assert.Nil(t, err)
+	assert.Equal(t, 202601, n)
+
+	n, err = ShardDate("200601")("2026-02-03 10:00:00")
+	assert.Nil(t, err)
+	assert.Equal(t, 202602, n)
+
+	_, err = ShardDate("200601")(20260203)
+	assert.ErrorIs(t, err, ErrShardKey)
+
+	_, err = ShardMod(0)(23)
+	assert.ErrorIs(t, err, ErrShardKey)
+
+	_, err = ShardHash(0)("yiigo")
+	assert.ErrorIs(t, err, ErrShardKey)
+}
+
+func TestShardRoute(t *testing.T) {
+	rule := NewShardRule("orders", "user_id", ShardMod(16)).Database(func(shard int) string {
+		return fmt.Sprintf("order_%d", shard/4)
+	})
+
+	table, db, err := rule.Route(23)
+	assert.Nil(t, err)
+	assert.Equal(t, "orders_07", table)
+	assert.Equal(t, "order_1", db)
+
+	table, db, err = NewShardRule("orders", "user_id", ShardMod(1024)).Width(4).Route(23)
+	assert.Nil(t, err)
+	assert.Equal(t, "orders_0023", table)
+	assert.Equal(t, "", db)
+}
+
+func TestSharding(t *testing.T) {
+	ctx := context.TODO()
+
+	builder := NewMySQLBuilder(WithSharding(
+		NewShardRule("orders", "user_id", ShardMod(16)),
+		NewShardRule("logs", "created_at", ShardDate("200601")),
+	))
+
+	// where
+	query, args, err := builder.Wrap(Table("orders"), Where("user_id = ? AND status = ?", 23, 1)).ToQuery(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM `orders_07` WHERE user_id = ? AND status = ?", query)
+	assert.Equal(t, []any{23, 1}, args)
+
+	query, _, err = builder.Wrap(TableAs("orders", "o"), Where("o.status = ?", 1), WhereClause(Col[int]("o.user_id").Eq(23))).ToQuery(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM `orders_07` AS `o` WHERE (o.status = ?) AND (o.user_id = ?)", query)
+
+	// the bind after the others
+	query, _, err = builder.Wrap(Table("orders"), Where("status = ? AND user_id = ?", 1, 39)).ToQuery(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM `orders_07` WHERE status = ? AND user_id = ?", query)
+
+	// or
+	_, _, err = builder.Wrap(Table("orders"), Where("user_id = ? OR status = ?", 23, 1)).ToQuery(ctx)
+	assert.ErrorIs(t, err, ErrShardKey)
+
+	// explicit
+	query, _, err = builder.Wrap(Table("orders"), Where("id = ?", 1), ShardKey(23)).ToQuery(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM `orders_07` WHERE id = ?", query)
+
+	// insert
+	query, _, err = builder.Wrap(Table("orders")).ToInsert(ctx, X{"user_id": 23, "amount": 100})
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `orders_07` (`amount`, `user_id`) VALUES (?, ?)", query)
+
+	type Order struct {
+		ID     int64 `db:"id,omitempty"`
+		UserID int64 `db:"user_id"`
+	}
+
+	query, _, err = builder.Wrap(Table("orders")).ToInsert(ctx, &Order{UserID: 23})
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `orders_07` (`user_id`) VALUES (?)", query)
+
+	query, _, err = builder.Wrap(Table("orders")).ToBatchInsert(ctx, []*Order{{UserID: 23}, {UserID: 39}})
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `orders_07` (`user_id`) VALUES (?), (?)", query)
+
+	_, _, err = builder.Wrap(Table("orders")).ToBatchInsert(ctx, []*Order{{UserID: 23}, {UserID: 24}})
+	assert.ErrorIs(t, err, ErrShardKey)
+
+	// update, delete
+	query, _, err = builder.Wrap(Table("orders"), Where("user_id = ? AND id = ?", 23, 1)).ToUpdate(ctx, X{"status": 2})
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE `orders_07` SET `status` = ? WHERE user_id = ? AND id = ?", query)
+
+	query, _, err = builder.Wrap(Table("orders"), Where("user_id = ? AND id = ?", 23, 1)).ToDelete(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE FROM `orders_07` WHERE user_id = ? AND id = ?", query)
+
+	_, err = builder.Wrap(Table("orders")).ToTruncate(ctx)
+	assert.ErrorIs(t, err, ErrShardKey)
+
+	// date
+	query, _, err = builder.Wrap(Table("logs")).ToInsert(ctx, X{"created_at": time.Date(2026, 1, 15, 0, 0, 0, 0, time.Local)})
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `logs_202601` (`created_at`) VALUES (?)", query)
+
+	// not sharded
+	query, _, err = builder.Wrap(Table("user"), Where("id = ?", 1)).ToQuery(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM `user` WHERE id = ?", query)
+}
+
+func TestShardDatabase(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, name := range []string{"shard_0", "shard_1"} {
+		db, err := OpenSQLiteMemory()
+
+		assert.Nil(t, err)
+
+		for i := 0; i < 4; i++ {
+			_, err = db.Exec(fmt.Sprintf("CREATE TABLE orders_%02d (id INTEGER PRIMARY KEY, user_id INTEGER)", i))
+			assert.Nil(t, err)
+		}
+
+		dbmap.Store(name, db)
+	}
+
+	defer func() {
+		for _, name := range []string{"shard_0", "shard_1"} {
+			DB(name).Close()
+			dbmap.Delete(name)
+		}
+	}()
+
+	builder := NewSQLiteBuilder(WithSharding(
+		NewShardRule("orders", "user_id", ShardMod(4)).Database(func(shard int) string {
+			return fmt.Sprintf("shard_%d", shard/2)
+		}),
+	))
+
+	// routed to shard_1 instead of the passed one
+	_, err := builder.Wrap(Table("orders")).Insert(ctx, DB("shard_0"), X{"user_id": 3})
+	assert.Nil(t, err)
+
+	var n int
+
+	assert.Nil(t, DB("shard_0").Get(&n, "SELECT COUNT(*) FROM orders_03"))
+	assert.Equal(t, 0, n)
+
+	assert.Nil(t, DB("shard_1").Get(&n, "SELECT COUNT(*) FROM orders_03"))
+	assert.Equal(t, 1, n)
+
+	err = builder.Wrap(Table("orders"), Select("COUNT(*)"), Where("user_id = ?", 3)).Get(ctx, DB("shard_0"), &n)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	// the transaction of the other database
+	err = Transact(ctx, DB("shard_0"), func(ctx context.Context, tx *Tx) error {
+		_, err := builder.Wrap(Table("orders")).Insert(ctx, tx, X{"user_id": 3})
+
+		return err
+	})
+
+	assert.ErrorIs(t, err, ErrShardKey)
+}
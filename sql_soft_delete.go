@@ -0,0 +1,84 @@
+package yiigo
+
+import (
+	"context"
+	"strings"
+)
+
+type trashedMode int
+
+const (
+	withoutTrashed trashedMode = iota
+	withTrashed
+	onlyTrashed
+)
+
+// WithSoftDelete enables the soft delete with the column, eg: yiigo.WithSoftDelete("deleted_at").
+// ToDelete becomes an update which sets the column to the current timestamp (see `WithTimestampFunc` and `WithDBTimestamp`),
+// ToQuery, ToUpdate and ToDelete exclude the soft deleted rows (`deleted_at IS NULL`) unless `WithTrashed` or `OnlyTrashed` is specified.
+// Use ToForceDelete to delete the rows permanently.
+func WithSoftDelete(column string) SQLBuilderOption {
+	return func(b *queryBuilder) {
+		b.softDelete = column
+	}
+}
+
+// WithTrashed includes the soft deleted rows.
+func WithTrashed() QueryOption {
+	return func(w *queryWrapper) {
+		w.trashed = withTrashed
+	}
+}
+
+// OnlyTrashed only includes the soft deleted rows.
+func OnlyTrashed() QueryOption {
+	return func(w *queryWrapper) {
+		w.trashed = onlyTrashed
+	}
+}
+
+// softDeleteClause returns the soft delete condition, eg: deleted_at IS NULL.
+func (w *queryWrapper) softDeleteClause() *SQLClause {
+	if len(w.builder.softDelete) == 0 {
+		return nil
+	}
+
+	switch w.trashed {
+	case withoutTrashed:
+		return Clause(w.softDeleteColumn() + " IS NULL")
+	case onlyTrashed:
+		return Clause(w.softDeleteColumn() + " IS NOT NULL")
+	}
+
+	return nil
+}
+
+// softDeleteColumn returns the soft delete column qualified by the table alias (or the table when joined).
+func (w *queryWrapper) softDeleteColumn() string {
+	column := w.builder.softDelete
+
+	if len(w.alias) != 0 {
+		return w.builder.quoteIdent(w.alias + "." + column)
+	}
+
+	if m := tableRegexp.FindStringSubmatch(strings.TrimSpace(w.table)); len(m) != 0 {
+		return w.builder.quoteIdent(m[3] + "." + column)
+	}
+
+	if len(w.joins) != 0 {
+		return w.builder.quoteIdent(w.builder.tableName(w.table) + "." + column)
+	}
+
+	return w.builder.quoteIdent(column)
+}
+
+// softDelete returns the update statement which sets the soft delete column.
+func (w *queryWrapper) softDelete(ctx context.Context) (sql string, args []any, err error) {
+	var value any = Clause(w.builder.nowExpr)
+
+	if len(w.builder.nowExpr) == 0 {
+		value = w.builder.now()
+	}
+
+	return w.ToUpdate(ctx, X{w.builder.softDelete: value})
+}
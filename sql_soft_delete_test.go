@@ -0,0 +1,72 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoftDelete(t *testing.T) {
+	ctx := context.TODO()
+
+	builder := NewMySQLBuilder(
+		WithSoftDelete("deleted_at"),
+		WithTimestampFunc(func() any { return int64(1680000000) }),
+	)
+
+	sql, args, err := builder.Wrap(
+		Table("user"),
+		Where("id = ?", 1),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM `user` WHERE (id = ?) AND (`deleted_at` IS NULL)", sql)
+	assert.Equal(t, []any{1}, args)
+
+	sql, _, err = builder.Wrap(Table("user"), WithTrashed()).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM `user`", sql)
+
+	sql, _, err = builder.Wrap(
+		TableAs("user", "u"),
+		Join("address", "u.id = address.user_id"),
+		OnlyTrashed(),
+	).ToQuery(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM `user` AS `u` INNER JOIN `address` ON u.id = address.user_id WHERE `u`.`deleted_at` IS NOT NULL", sql)
+
+	sql, args, err = builder.Wrap(
+		Table("user"),
+		Where("id = ?", 1),
+	).ToDelete(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE `user` SET `deleted_at` = ? WHERE (id = ?) AND (`deleted_at` IS NULL)", sql)
+	assert.Equal(t, []any{int64(1680000000), 1}, args)
+
+	sql, args, err = builder.Wrap(
+		Table("user"),
+		Where("id = ?", 1),
+	).ToForceDelete(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE FROM `user` WHERE id = ?", sql)
+	assert.Equal(t, []any{1}, args)
+
+	sql, _, err = builder.Wrap(Table("user"), OnlyTrashed()).ToForceDelete(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE FROM `user` WHERE `deleted_at` IS NOT NULL", sql)
+
+	sql, args, err = NewPGSQLBuilder(WithSoftDelete("deleted_at"), WithDBTimestamp()).Wrap(
+		Table("user"),
+		Where("id = ?", 1),
+	).ToDelete(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `UPDATE "user" SET "deleted_at" = CURRENT_TIMESTAMP WHERE (id = $1) AND ("deleted_at" IS NULL)`, sql)
+	assert.Equal(t, []any{1}, args)
+}
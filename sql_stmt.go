@@ -0,0 +1,233 @@
+package yiigo
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// StmtCache caches the prepared statements by the SQL, it implements sqlx.ExtContext,
+// so the statements built by the SQL builder with the same shape (table, columns, clauses) — which are the same SQL
+// with different binds — reuse the prepared statements, eg:
+//
+//	cache := yiigo.NewStmtCache(yiigo.DB(), 100)
+//	builder.Wrap(yiigo.Table("user"), yiigo.Where("id = ?", id)).Get(ctx, cache, user)
+type StmtCache struct {
+	db    *sqlx.DB
+	size  int
+	lru   *list.List
+	stmts map[string]*list.Element
+	mutex sync.Mutex
+}
+
+var _ sqlx.ExtContext = (*StmtCache)(nil)
+
+type stmtEntry struct {
+	query   string
+	stmt    *sqlx.Stmt
+	refs    int
+	evicted bool
+}
+
+// NewStmtCache returns a new StmtCache which keeps at most `size` prepared statements (LRU).
+func NewStmtCache(db *sqlx.DB, size int) *StmtCache {
+	if size <= 0 {
+		size = 100
+	}
+
+	return &StmtCache{
+		db:    db,
+		size:  size,
+		lru:   list.New(),
+		stmts: make(map[string]*list.Element, size),
+	}
+}
+
+// Prepare returns the cached prepared statement, or prepares and caches it.
+// The returned release func must be called when done with the statement,
+// the statement evicted from the cache is closed after all its users released it.
+func (c *StmtCache) Prepare(ctx context.Context, query string) (*sqlx.Stmt, func(), error) {
+	c.mutex.Lock()
+
+	if e, ok := c.stmts[query]; ok {
+		c.lru.MoveToFront(e)
+
+		entry := c.acquire(e)
+
+		c.mutex.Unlock()
+
+		return entry.stmt, c.releaser(entry), nil
+	}
+
+	c.mutex.Unlock()
+
+	stmt, err := c.db.PreparexContext(ctx, query)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// prepared by others concurrently
+	if e, ok := c.stmts[query]; ok {
+		stmt.Close()
+
+		c.lru.MoveToFront(e)
+
+		entry := c.acquire(e)
+
+		return entry.stmt, c.releaser(entry), nil
+	}
+
+	entry := &stmtEntry{
+		query: query,
+		stmt:  stmt,
+		refs:  1,
+	}
+
+	c.stmts[query] = c.lru.PushFront(entry)
+
+	for c.lru.Len() > c.size {
+		e := c.lru.Back()
+
+		c.lru.Remove(e)
+
+		evicted := e.Value.(*stmtEntry)
+
+		delete(c.stmts, evicted.query)
+
+		c.evict(evicted)
+	}
+
+	return stmt, c.releaser(entry), nil
+}
+
+func (c *StmtCache) acquire(e *list.Element) *stmtEntry {
+	entry := e.Value.(*stmtEntry)
+	entry.refs++
+
+	return entry
+}
+
+func (c *StmtCache) releaser(entry *stmtEntry) func() {
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+
+			entry.refs--
+
+			if entry.evicted && entry.refs == 0 {
+				entry.stmt.Close()
+			}
+		})
+	}
+}
+
+// evict closes the statement if not in use, otherwise it's closed by the last release.
+// Must be called with the mutex held.
+func (c *StmtCache) evict(entry *stmtEntry) error {
+	entry.evicted = true
+
+	if entry.refs != 0 {
+		return nil
+	}
+
+	return entry.stmt.Close()
+}
+
+// Len returns the number of the cached statements.
+func (c *StmtCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.lru.Len()
+}
+
+// Close closes all the cached statements, the ones in use are closed once released.
+func (c *StmtCache) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var err error
+
+	for _, e := range c.stmts {
+		if closeErr := c.evict(e.Value.(*stmtEntry)); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+
+	c.lru.Init()
+	c.stmts = make(map[string]*list.Element, c.size)
+
+	return err
+}
+
+func (c *StmtCache) DriverName() string {
+	return c.db.DriverName()
+}
+
+func (c *StmtCache) Rebind(query string) string {
+	return c.db.Rebind(query)
+}
+
+func (c *StmtCache) BindNamed(query string, arg any) (string, []any, error) {
+	return c.db.BindNamed(query, arg)
+}
+
+func (c *StmtCache) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	stmt, release, err := c.Prepare(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the rows keep the statement open until closed
+	defer release()
+
+	return stmt.QueryContext(ctx, args...)
+}
+
+func (c *StmtCache) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	stmt, release, err := c.Prepare(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	return stmt.QueryxContext(ctx, args...)
+}
+
+func (c *StmtCache) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	stmt, release, err := c.Prepare(ctx, query)
+
+	if err != nil {
+		// sqlx.Row returns the error on Scan
+		return c.db.QueryRowxContext(ctx, query, args...)
+	}
+
+	defer release()
+
+	return stmt.QueryRowxContext(ctx, args...)
+}
+
+func (c *StmtCache) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	stmt, release, err := c.Prepare(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	return stmt.ExecContext(ctx, args...)
+}
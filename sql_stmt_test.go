@@ -0,0 +1,98 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStmtCache(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT, age INTEGER)")
+	assert.Nil(t, err)
+
+	cache := NewStmtCache(db, 2)
+
+	defer cache.Close()
+
+	builder := NewSQLiteBuilder()
+
+	for _, name := range []string{"yiigo", "foo", "bar"} {
+		_, err = builder.Wrap(Table("user")).Insert(ctx, cache, X{"name": name, "age": 20})
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, 1, cache.Len())
+
+	for _, id := range []int{1, 2, 3} {
+		var name string
+
+		err = builder.Wrap(Table("user"), Select("name"), Where("id = ?", id)).Get(ctx, cache, &name)
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, 2, cache.Len())
+
+	rows, err := builder.Wrap(Table("user"), Where("id = ?", 1)).Update(ctx, cache, X{"age": 30})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), rows)
+
+	// LRU
+	assert.Equal(t, 2, cache.Len())
+
+	var users []string
+
+	err = builder.Wrap(Table("user"), Select("name"), OrderBy("id")).Select(ctx, cache, &users)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"yiigo", "foo", "bar"}, users)
+}
+
+func TestStmtCacheEvictInUse(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	cache := NewStmtCache(db, 1)
+
+	defer cache.Close()
+
+	stmt, release, err := cache.Prepare(ctx, "SELECT 1")
+	assert.Nil(t, err)
+
+	// evicts the statement in use
+	_, releaseOther, err := cache.Prepare(ctx, "SELECT 2")
+	assert.Nil(t, err)
+
+	releaseOther()
+
+	assert.Equal(t, 1, cache.Len())
+
+	var v int
+
+	assert.Nil(t, stmt.GetContext(ctx, &v))
+	assert.Equal(t, 1, v)
+
+	release()
+	release()
+
+	assert.NotNil(t, stmt.GetContext(ctx, &v))
+}
@@ -0,0 +1,246 @@
+package yiigo
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"sync"
+)
+
+type structField struct {
+	index  []int
+	column string
+	opts   tagOptions
+	typ    reflect.Type
+	ddl    string
+}
+
+type structFieldCandidate struct {
+	field *structField
+	depth int
+}
+
+// structFieldsCache caches the db fields per struct type (like encoding/json does).
+var structFieldsCache sync.Map // map[reflect.Type][]*structField
+
+// structFields returns the db fields of the struct type (cached),
+// the fields of anonymous embedded structs (without `db` tag) are flattened like sqlx does.
+// NOTE: The returned fields are shared and must not be modified.
+func structFields(t reflect.Type) []*structField {
+	if v, ok := structFieldsCache.Load(t); ok {
+		return v.([]*structField)
+	}
+
+	v, _ := structFieldsCache.LoadOrStore(t, typeFields(t))
+
+	return v.([]*structField)
+}
+
+func typeFields(t reflect.Type) []*structField {
+	candidates := make([]*structFieldCandidate, 0, t.NumField())
+
+	walkStructFields(t, nil, 0, &candidates)
+
+	// the shallower field hides the deeper one with the same column (like Go's selector rules)
+	depths := make(map[string]int, len(candidates))
+
+	for _, v := range candidates {
+		if d, ok := depths[v.field.column]; !ok || v.depth < d {
+			depths[v.field.column] = v.depth
+		}
+	}
+
+	fields := make([]*structField, 0, len(candidates))
+
+	for _, v := range candidates {
+		if depths[v.field.column] != v.depth {
+			continue
+		}
+
+		// only the first one is used if duplicated at the same depth
+		depths[v.field.column] = -1
+
+		fields = append(fields, v.field)
+	}
+
+	return fields
+}
+
+func walkStructFields(t reflect.Type, parent []int, depth int, candidates *[]*structFieldCandidate) {
+	for i := 0; i < t.NumField(); i++ {
+		fieldT := t.Field(i)
+		tag := fieldT.Tag.Get("db")
+
+		if tag == "-" {
+			continue
+		}
+
+		index := make([]int, len(parent)+1)
+
+		copy(index, parent)
+		index[len(parent)] = i
+
+		if fieldT.Anonymous && len(tag) == 0 {
+			ft := fieldT.Type
+
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				walkStructFields(ft, index, depth+1, candidates)
+
+				continue
+			}
+		}
+
+		// unexported
+		if len(fieldT.PkgPath) != 0 {
+			continue
+		}
+
+		name, opts := parseTag(tag)
+
+		if len(name) == 0 {
+			name = fieldT.Name
+		}
+
+		*candidates = append(*candidates, &structFieldCandidate{
+			field: &structField{
+				index:  index,
+				column: name,
+				opts:   opts,
+				typ:    fieldT.Type,
+				ddl:    fieldT.Tag.Get("ddl"),
+			},
+			depth: depth,
+		})
+	}
+}
+
+// fieldValue returns the field value by index, ok is false if the embedded struct pointer is nil.
+func fieldValue(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+// structColumns returns the columns and binds of the struct value.
+func structColumns(v reflect.Value) (columns []string, binds []any) {
+	fields := structFields(v.Type())
+
+	columns = make([]string, 0, len(fields))
+	binds = make([]any, 0, len(fields))
+
+	for _, f := range fields {
+		fieldV, ok := fieldValue(v, f.index)
+
+		if !ok {
+			continue
+		}
+
+		if f.opts.Contains("omitempty") && isEmptyValue(fieldV) {
+			continue
+		}
+
+		if f.opts.Contains("omitnil") && isNilValue(fieldV) {
+			continue
+		}
+
+		columns = append(columns, f.column)
+		binds = append(binds, bindValue(fieldV))
+	}
+
+	return
+}
+
+// isNilValue reports whether the value is SQL NULL, eg: nil pointer, sql.NullString{Valid: false}.
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	case reflect.Struct:
+		if valuer, ok := v.Interface().(driver.Valuer); ok {
+			dv, err := valuer.Value()
+
+			return err == nil && dv == nil
+		}
+	}
+
+	return false
+}
+
+// bindValue returns the bind value, nil pointers and invalid sql.NullX are bound as NULL.
+func bindValue(v reflect.Value) any {
+	if isNilValue(v) {
+		return nil
+	}
+
+	return v.Interface()
+}
+
+// structBatchColumns returns the columns and binds of the struct rows (with the same type).
+func structBatchColumns(rows []reflect.Value) (columns []string, binds []any) {
+	fields := structFields(rows[0].Type())
+
+	used := make([]*structField, 0, len(fields))
+
+	for _, f := range fields {
+		omitempty := f.opts.Contains("omitempty")
+		omitnil := f.opts.Contains("omitnil")
+
+		for _, row := range rows {
+			fieldV, ok := fieldValue(row, f.index)
+
+			if !ok {
+				continue
+			}
+
+			if omitempty && isEmptyValue(fieldV) {
+				continue
+			}
+
+			if omitnil && isNilValue(fieldV) {
+				continue
+			}
+
+			used = append(used, f)
+
+			break
+		}
+	}
+
+	columns = make([]string, 0, len(used))
+	binds = make([]any, 0, len(used)*len(rows))
+
+	for _, f := range used {
+		columns = append(columns, f.column)
+	}
+
+	for _, row := range rows {
+		for _, f := range used {
+			fieldV, ok := fieldValue(row, f.index)
+
+			if !ok {
+				binds = append(binds, nil)
+
+				continue
+			}
+
+			binds = append(binds, bindValue(fieldV))
+		}
+	}
+
+	return
+}
@@ -0,0 +1,90 @@
+package yiigo
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// WithStatementTimeout makes the executors set the server-side statement timeout from the deadline of the context,
+// so the work of the server is cancelled as well, not just the wait of the client:
+//   - MySQL: the optimizer hint `MAX_EXECUTION_TIME(ms)` of the SELECT statement (not supported for the writes and unions)
+//   - Postgres: `SET LOCAL statement_timeout = ms` before the statement in the transaction (*sqlx.Tx or *yiigo.Tx),
+//     which lasts until the end of the transaction; outside the transaction, the driver cancels the statement by the context.
+//
+// NOTE: SQLite and SQL Server are not supported.
+func WithStatementTimeout() SQLBuilderOption {
+	return func(b *queryBuilder) {
+		b.stmtTimeout = true
+	}
+}
+
+// timeout applies the statement timeout of the context deadline and returns the query to execute.
+func (w *queryWrapper) timeout(ctx context.Context, db any, kind StmtKind, query string) (string, error) {
+	if !w.builder.stmtTimeout {
+		return query, nil
+	}
+
+	deadline, ok := ctx.Deadline()
+
+	if !ok {
+		return query, nil
+	}
+
+	ms := time.Until(deadline).Milliseconds()
+
+	// the deadline exceeded is reported by the driver
+	if ms < 1 {
+		ms = 1
+	}
+
+	switch w.builder.driver {
+	case MySQL:
+		if kind == StmtQuery {
+			query = maxExecutionTime(query, ms)
+		}
+	case Postgres:
+		var tx *sqlx.Tx
+
+		switch v := db.(type) {
+		case *sqlx.Tx:
+			tx = v
+		case *Tx:
+			tx = v.Tx
+		}
+
+		if tx == nil {
+			break
+		}
+
+		if _, err := tx.ExecContext(ctx, "SET LOCAL statement_timeout = "+strconv.FormatInt(ms, 10)); err != nil {
+			return "", err
+		}
+	}
+
+	return query, nil
+}
+
+// maxExecutionTime adds the hint `MAX_EXECUTION_TIME` to the SELECT statement,
+// which is merged into the existing hint comment since MySQL only recognizes the first one.
+func maxExecutionTime(query string, ms int64) string {
+	prefix := leadingCommentRegexp.FindString(query)
+	body := query[len(prefix):]
+
+	// eg: (SELECT ...) UNION (SELECT ...)
+	if !strings.HasPrefix(body, "SELECT ") {
+		return query
+	}
+
+	hint := "MAX_EXECUTION_TIME(" + strconv.FormatInt(ms, 10) + ")"
+	body = body[len("SELECT "):]
+
+	if strings.HasPrefix(body, "/*+") {
+		return prefix + "SELECT /*+ " + hint + " " + strings.TrimLeft(body[len("/*+"):], " ")
+	}
+
+	return prefix + "SELECT /*+ " + hint + " */ " + body
+}
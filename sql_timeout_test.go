@@ -0,0 +1,70 @@
+package yiigo
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxExecutionTime(t *testing.T) {
+	assert.Equal(t, "SELECT /*+ MAX_EXECUTION_TIME(500) */ * FROM user WHERE id = ?",
+		maxExecutionTime("SELECT * FROM user WHERE id = ?", 500))
+
+	assert.Equal(t, "SELECT /*+ MAX_EXECUTION_TIME(500) INDEX(user idx_name) */ * FROM user",
+		maxExecutionTime("SELECT /*+ INDEX(user idx_name) */ * FROM user", 500))
+
+	assert.Equal(t, "/* action='list' */ SELECT /*+ MAX_EXECUTION_TIME(500) */ * FROM user",
+		maxExecutionTime("/* action='list' */ SELECT * FROM user", 500))
+
+	assert.Equal(t, "(SELECT * FROM user) UNION (SELECT * FROM admin)",
+		maxExecutionTime("(SELECT * FROM user) UNION (SELECT * FROM admin)", 500))
+}
+
+func TestStatementTimeout(t *testing.T) {
+	deadline, cancel := context.WithTimeout(context.TODO(), time.Minute)
+
+	defer cancel()
+
+	builder := NewMySQLBuilder(WithStatementTimeout())
+
+	query, args, err := builder.Wrap(Table("orders"), Where("id = ?", 1), OptimizerHint("INDEX(orders idx_id)")).ToQuery(deadline)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT /*+ INDEX(orders idx_id) */ * FROM `orders` WHERE id = ?", query)
+	assert.Equal(t, []any{1}, args)
+
+	w := builder.Wrap(Table("user")).(*queryWrapper)
+
+	// the hint is applied when executing, so the built statement (eg: the cache key) is stable
+	query, err = w.timeout(deadline, nil, StmtQuery, query)
+
+	assert.Nil(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^SELECT /\*\+ MAX_EXECUTION_TIME\(\d+\) INDEX\(orders idx_id\) \*/ \* FROM `+"`orders`"+` WHERE id = \?$`), query)
+
+	// without deadline
+	query, err = w.timeout(context.TODO(), nil, StmtQuery, "SELECT * FROM user")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM user", query)
+
+	// writes
+	query, err = w.timeout(deadline, nil, StmtUpdate, "UPDATE user SET name = ?")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE user SET name = ?", query)
+
+	// without the option
+	query, err = NewMySQLBuilder().Wrap(Table("user")).(*queryWrapper).timeout(deadline, nil, StmtQuery, "SELECT * FROM user")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM user", query)
+
+	// Postgres outside the transaction
+	query, err = NewPGSQLBuilder(WithStatementTimeout()).Wrap(Table("user")).(*queryWrapper).timeout(deadline, nil, StmtQuery, "SELECT * FROM user")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM user", query)
+}
@@ -0,0 +1,76 @@
+package yiigo
+
+import (
+	"time"
+)
+
+// WithTimestamps enables the auto timestamp columns: `createdAt` is set on insert (and batch insert),
+// `updatedAt` is set on update, the empty column name disables it.
+// The column is set only if it's absent from the data (eg: omitted by `omitempty`), and the value is time.Now() by default.
+// Use `WithTimestampFunc` or `WithDBTimestamp` to change the value.
+func WithTimestamps(createdAt, updatedAt string) SQLBuilderOption {
+	return func(b *queryBuilder) {
+		b.createdAt = createdAt
+		b.updatedAt = updatedAt
+	}
+}
+
+// WithTimestampFunc specifies the value of the auto timestamp columns, eg: func() any { return time.Now().Unix() }.
+func WithTimestampFunc(fn func() any) SQLBuilderOption {
+	return func(b *queryBuilder) {
+		b.nowFunc = fn
+		b.nowExpr = ""
+	}
+}
+
+// WithDBTimestamp uses the database side `CURRENT_TIMESTAMP` as the value of the auto timestamp columns.
+func WithDBTimestamp() SQLBuilderOption {
+	return func(b *queryBuilder) {
+		b.nowFunc = nil
+		b.nowExpr = "CURRENT_TIMESTAMP"
+	}
+}
+
+func (b *queryBuilder) now() any {
+	if b.nowFunc != nil {
+		return b.nowFunc()
+	}
+
+	return time.Now()
+}
+
+// timestamp appends the auto timestamp column to each of the rows if it's absent.
+func (b *queryBuilder) timestamp(column string, columns []string, exprs map[string]string, binds []any, rows int) ([]string, []any) {
+	if len(column) == 0 {
+		return columns, binds
+	}
+
+	for _, v := range columns {
+		if v == column {
+			return columns, binds
+		}
+	}
+
+	if len(b.nowExpr) != 0 {
+		exprs[column] = b.nowExpr
+
+		return append(columns, column), binds
+	}
+
+	now := b.now()
+
+	if rows <= 1 {
+		return append(columns, column), append(binds, now)
+	}
+
+	// batch insert, the binds are row by row
+	fieldNum := len(columns)
+	stamped := make([]any, 0, len(binds)+rows)
+
+	for i := 0; i < rows; i++ {
+		stamped = append(stamped, binds[i*fieldNum:(i+1)*fieldNum]...)
+		stamped = append(stamped, now)
+	}
+
+	return append(columns, column), stamped
+}
@@ -0,0 +1,67 @@
+package yiigo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimestamps(t *testing.T) {
+	ctx := context.TODO()
+
+	builder := NewMySQLBuilder(
+		WithTimestamps("created_at", "updated_at"),
+		WithTimestampFunc(func() any { return int64(1680000000) }),
+	)
+
+	type User struct {
+		Name      string `db:"name"`
+		CreatedAt int64  `db:"created_at,omitempty"`
+	}
+
+	sql, args, err := builder.Wrap(Table("user")).ToInsert(ctx, &User{Name: "yiigo"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `user` (`name`, `created_at`) VALUES (?, ?)", sql)
+	assert.Equal(t, []any{"yiigo", int64(1680000000)}, args)
+
+	// set by the data
+	sql, args, err = builder.Wrap(Table("user")).ToInsert(ctx, &User{Name: "yiigo", CreatedAt: 1})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `user` (`name`, `created_at`) VALUES (?, ?)", sql)
+	assert.Equal(t, []any{"yiigo", int64(1)}, args)
+
+	sql, args, err = builder.Wrap(Table("user")).ToBatchInsert(ctx, []User{{Name: "yiigo"}, {Name: "test"}})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "INSERT INTO `user` (`name`, `created_at`) VALUES (?, ?), (?, ?)", sql)
+	assert.Equal(t, []any{"yiigo", int64(1680000000), "test", int64(1680000000)}, args)
+
+	sql, args, err = builder.Wrap(
+		Table("product"),
+		Where("id = ?", 1),
+	).ToUpdate(ctx, X{"price": Clause("price * ?", 2)})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "UPDATE `product` SET `price` = price * ?, `updated_at` = ? WHERE id = ?", sql)
+	assert.Equal(t, []any{2, int64(1680000000), 1}, args)
+
+	builder = NewPGSQLBuilder(WithTimestamps("created_at", "updated_at"), WithDBTimestamp())
+
+	sql, args, err = builder.Wrap(Table("user")).ToBatchInsert(ctx, []X{{"name": "yiigo"}, {"name": "test"}})
+
+	assert.Nil(t, err)
+	assert.Equal(t, `INSERT INTO "user" ("name", "created_at") VALUES ($1, CURRENT_TIMESTAMP), ($2, CURRENT_TIMESTAMP)`, sql)
+	assert.Equal(t, []any{"yiigo", "test"}, args)
+
+	sql, args, err = builder.Wrap(
+		Table("user"),
+		Where("id = ?", 1),
+	).ToUpdate(ctx, X{"name": "yiigo"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, `UPDATE "user" SET "name" = $1, "updated_at" = CURRENT_TIMESTAMP WHERE id = $2`, sql)
+	assert.Equal(t, []any{"yiigo", 1}, args)
+}
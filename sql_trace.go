@@ -0,0 +1,99 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/shenghui0779/yiigo"
+
+var (
+	// eg: 'abc', 'it''s'
+	stringLiteralRegexp = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+	// eg: /* action='list' */
+	leadingCommentRegexp = regexp.MustCompile(`^\s*/\*.*?\*/\s*`)
+)
+
+// WithTracing records the OpenTelemetry span of the statements executed by the executors with the attributes:
+// db.system, db.operation, db.sql.table, db.statement (sanitized, the binds are never recorded) and db.rows,
+// the tracer provider defaults to otel.GetTracerProvider().
+// The span is the child of the span in the context passed to the executors.
+func WithTracing(tp ...trace.TracerProvider) SQLBuilderOption {
+	return func(b *queryBuilder) {
+		provider := otel.GetTracerProvider()
+
+		if len(tp) != 0 && tp[0] != nil {
+			provider = tp[0]
+		}
+
+		tracer := provider.Tracer(tracerName)
+		system := dbSystem(b.driver)
+
+		b.execHooks = append(b.execHooks, func(ctx context.Context, stmt *ExecutedStatement, next func(ctx context.Context) error) error {
+			name := string(stmt.Kind)
+
+			if len(stmt.Table) != 0 {
+				name += " " + stmt.Table
+			}
+
+			ctx, span := tracer.Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					system,
+					semconv.DBOperation(string(stmt.Kind)),
+					semconv.DBSQLTable(stmt.Table),
+					semconv.DBStatement(sanitizeSQL(stmt.SQL)),
+				),
+			)
+
+			defer span.End()
+
+			err := next(ctx)
+
+			span.SetAttributes(attribute.Int64("db.rows", stmt.Rows))
+
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			return err
+		})
+	}
+}
+
+func dbSystem(driver DBDriver) attribute.KeyValue {
+	switch driver {
+	case MySQL:
+		return semconv.DBSystemMySQL
+	case Postgres:
+		return semconv.DBSystemPostgreSQL
+	case SQLite:
+		return semconv.DBSystemSqlite
+	case SQLServer:
+		return semconv.DBSystemMSSQL
+	}
+
+	return semconv.DBSystemKey.String(string(driver))
+}
+
+// sanitizeSQL removes the leading comment (sqlcommenter) and replaces the string literals with `?`.
+func sanitizeSQL(query string) string {
+	query = leadingCommentRegexp.ReplaceAllString(query, "")
+
+	if strings.Contains(query, "'") {
+		query = stringLiteralRegexp.ReplaceAllString(query, "?")
+	}
+
+	return query
+}
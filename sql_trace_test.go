@@ -0,0 +1,88 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithTracing(t *testing.T) {
+	ctx := context.TODO()
+
+	db, err := OpenSQLiteMemory()
+
+	assert.Nil(t, err)
+
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT)")
+	assert.Nil(t, err)
+
+	recorder := tracetest.NewSpanRecorder()
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	builder := NewSQLiteBuilder(WithTracing(tp))
+
+	ctx, parent := tp.Tracer("test").Start(ctx, "parent")
+
+	_, err = builder.Wrap(Table("user")).BatchInsert(ctx, db, []X{{"name": "foo"}, {"name": "bar"}})
+	assert.Nil(t, err)
+
+	var name string
+
+	err = builder.Wrap(Table("user"), Select("name"), Where("name = 'baz' AND id = ?", 3)).Get(ctx, db, &name)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = builder.Wrap(Table("user"), Where("id = ?", 1)).Update(ctx, db, X{"unknown": 1})
+	assert.NotNil(t, err)
+
+	parent.End()
+
+	spans := recorder.Ended()
+
+	assert.Len(t, spans, 4)
+
+	attrs := func(s sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+		m := make(map[attribute.Key]attribute.Value)
+
+		for _, v := range s.Attributes() {
+			m[v.Key] = v.Value
+		}
+
+		return m
+	}
+
+	// insert
+	assert.Equal(t, "INSERT user", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+
+	m := attrs(spans[0])
+
+	assert.Equal(t, "sqlite", m["db.system"].AsString())
+	assert.Equal(t, "INSERT", m["db.operation"].AsString())
+	assert.Equal(t, "user", m["db.sql.table"].AsString())
+	assert.Equal(t, int64(2), m["db.rows"].AsInt64())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	// no rows isn't error
+	m = attrs(spans[1])
+
+	assert.Equal(t, `SELECT "name" FROM "user" WHERE name = ? AND id = ?`, m["db.statement"].AsString())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+
+	// error
+	assert.Equal(t, "UPDATE user", spans[2].Name())
+	assert.Equal(t, codes.Error, spans[2].Status().Code)
+}
+
+func TestSanitizeSQL(t *testing.T) {
+	assert.Equal(t, "SELECT * FROM user WHERE name = ? AND note = ? AND id = ?",
+		sanitizeSQL("/* action='list' */ SELECT * FROM user WHERE name = 'foo' AND note = 'it''s' AND id = ?"))
+}
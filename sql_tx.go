@@ -0,0 +1,375 @@
+package yiigo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Tx is the transaction which implements sqlx.ExtContext, so the SQLWrapper executes in the transaction, eg:
+// builder.Wrap(yiigo.Table("user")).Insert(ctx, tx, data).
+type Tx struct {
+	*sqlx.Tx
+
+	db         *sqlx.DB
+	driver     DBDriver
+	depth      int
+	onCommit   []func()
+	onRollback []func()
+	savepoints []*savepoint
+	mutex      sync.Mutex
+}
+
+// savepoint records the numbers of the callbacks registered before it.
+type savepoint struct {
+	name         string
+	commitMark   int
+	rollbackMark int
+}
+
+// OnCommit registers fn which is called after the transaction is committed, eg: publish events, invalidate caches.
+// The callbacks registered within the savepoint which is rolled back are discarded.
+func (tx *Tx) OnCommit(fn func()) {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	tx.onCommit = append(tx.onCommit, fn)
+}
+
+// OnRollback registers fn which is called after the transaction (or the savepoint it's registered within) is rolled back.
+// NOTE: It's called for each failed attempt when the transaction is retried.
+func (tx *Tx) OnRollback(fn func()) {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	tx.onRollback = append(tx.onRollback, fn)
+}
+
+// hooks returns the callbacks registered after the marks, and truncates them if discard is true.
+func (tx *Tx) hooks(commitMark, rollbackMark int, discard bool) (onCommit, onRollback []func()) {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	onCommit, onRollback = tx.onCommit[commitMark:], tx.onRollback[rollbackMark:]
+
+	if discard {
+		tx.onCommit, tx.onRollback = tx.onCommit[:commitMark:commitMark], tx.onRollback[:rollbackMark:rollbackMark]
+	}
+
+	return
+}
+
+// runTxHooks calls the callbacks in order, the panic is recovered to not affect the others.
+func runTxHooks(hooks []func()) {
+	for _, fn := range hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("db tx hook panic", zap.Any("error", r), zap.ByteString("stack", debug.Stack()))
+				}
+			}()
+
+			fn()
+		}()
+	}
+}
+
+type txCtxKey struct{}
+
+var savepointRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// TxFromContext returns the transaction in the context (inside `Transact`).
+func TxFromContext(ctx context.Context) (*Tx, bool) {
+	tx, ok := ctx.Value(txCtxKey{}).(*Tx)
+
+	return tx, ok
+}
+
+type txOptions struct {
+	retries int
+	backoff time.Duration
+	opts    *sql.TxOptions
+}
+
+// TxOption transaction option
+type TxOption func(o *txOptions)
+
+// WithTxRetry specifies the max retries on deadlock (serialization failure), default: 3.
+func WithTxRetry(n int) TxOption {
+	return func(o *txOptions) {
+		o.retries = n
+	}
+}
+
+// WithTxBackoff specifies the base backoff between retries, which doubles with jitter per retry, default: 20ms.
+func WithTxBackoff(d time.Duration) TxOption {
+	return func(o *txOptions) {
+		o.backoff = d
+	}
+}
+
+// WithTxOptions specifies the isolation level and read-only of the transaction.
+func WithTxOptions(opts *sql.TxOptions) TxOption {
+	return func(o *txOptions) {
+		o.opts = opts
+	}
+}
+
+// Transact executes fn in the transaction, which is committed if fn returns nil, otherwise rolled back.
+// The transaction is retried (fn is called again) on deadlock or serialization failure
+// (MySQL 1213, Postgres 40001 and 40P01) with backoff, so fn should be idempotent besides the db operations.
+// The nested `Transact` with the context passed to fn (of the same db) uses the savepoint of the outer transaction.
+func Transact(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context, tx *Tx) error, options ...TxOption) error {
+	// nested
+	if tx, ok := TxFromContext(ctx); ok && tx.db == db {
+		return tx.nested(ctx, fn)
+	}
+
+	o := &txOptions{
+		retries: 3,
+		backoff: 20 * time.Millisecond,
+	}
+
+	for _, f := range options {
+		f(o)
+	}
+
+	for i := 0; ; i++ {
+		err := transact(ctx, db, o.opts, fn)
+
+		if err == nil || i >= o.retries || !IsRetryableTxError(err) {
+			return err
+		}
+
+		// exponential backoff with jitter
+		d := o.backoff << i
+
+		d += time.Duration(rand.Int63n(int64(d)/2 + 1))
+
+		logger.Warn("db tx retry", zap.Int("retry", i+1), zap.Duration("backoff", d), zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(d):
+		}
+	}
+}
+
+func transact(ctx context.Context, db *sqlx.DB, opts *sql.TxOptions, fn func(ctx context.Context, tx *Tx) error) (err error) {
+	stx, err := db.BeginTxx(ctx, opts)
+
+	if err != nil {
+		return err
+	}
+
+	tx := &Tx{
+		Tx:     stx,
+		db:     db,
+		driver: DBDriver(db.DriverName()),
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("db tx panic", zap.Any("error", r), zap.ByteString("stack", debug.Stack()))
+
+			rollback(stx)
+
+			_, onRollback := tx.hooks(0, 0, false)
+
+			runTxHooks(onRollback)
+
+			err = fmt.Errorf("db tx panic: %v", r)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txCtxKey{}, tx), tx); err == nil {
+		err = stx.Commit()
+	}
+
+	onCommit, onRollback := tx.hooks(0, 0, false)
+
+	if err != nil {
+		rollback(stx)
+
+		runTxHooks(onRollback)
+
+		return err
+	}
+
+	runTxHooks(onCommit)
+
+	return nil
+}
+
+// nested executes fn within the savepoint of the transaction.
+func (tx *Tx) nested(ctx context.Context, fn func(ctx context.Context, tx *Tx) error) error {
+	tx.depth++
+
+	defer func() {
+		tx.depth--
+	}()
+
+	name := "yiigo_sp_" + strconv.Itoa(tx.depth)
+
+	if err := tx.Savepoint(ctx, name); err != nil {
+		return err
+	}
+
+	if err := fn(ctx, tx); err != nil {
+		if rbErr := tx.RollbackTo(ctx, name); rbErr != nil {
+			logger.Error("err db tx rollback to savepoint", zap.String("savepoint", name), zap.Error(rbErr))
+
+			return err
+		}
+
+		if relErr := tx.ReleaseSavepoint(ctx, name); relErr != nil {
+			logger.Error("err db tx release savepoint", zap.String("savepoint", name), zap.Error(relErr))
+		}
+
+		return err
+	}
+
+	return tx.ReleaseSavepoint(ctx, name)
+}
+
+// Savepoint creates the savepoint (the name expects identifier) within the transaction,
+// which is rolled back by `RollbackTo` to recover from the failure of the following steps, eg:
+//
+//	if err := tx.Savepoint(ctx, "step2"); err != nil {
+//		return err
+//	}
+//
+//	if err := step2(ctx, tx); err != nil {
+//		if err = tx.RollbackTo(ctx, "step2"); err != nil {
+//			return err
+//		}
+//	}
+func (tx *Tx) Savepoint(ctx context.Context, name string) error {
+	if !savepointRegexp.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidIdent, name)
+	}
+
+	query := "SAVEPOINT " + name
+
+	if tx.driver == SQLServer {
+		query = "SAVE TRANSACTION " + name
+	}
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	tx.savepoints = append(tx.savepoints, &savepoint{
+		name:         name,
+		commitMark:   len(tx.onCommit),
+		rollbackMark: len(tx.onRollback),
+	})
+
+	return nil
+}
+
+// RollbackTo rolls back the transaction to the savepoint, which remains and can be rolled back again.
+// The savepoints created after it are removed, the `OnCommit` callbacks registered after it are discarded
+// and the `OnRollback` callbacks registered after it are called.
+func (tx *Tx) RollbackTo(ctx context.Context, name string) error {
+	i := tx.savepointIndex(name)
+
+	if i < 0 {
+		return fmt.Errorf("savepoint %q not found", name)
+	}
+
+	query := "ROLLBACK TO SAVEPOINT " + name
+
+	if tx.driver == SQLServer {
+		query = "ROLLBACK TRANSACTION " + name
+	}
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	tx.mutex.Lock()
+
+	sp := tx.savepoints[i]
+
+	tx.savepoints = tx.savepoints[:i+1]
+
+	tx.mutex.Unlock()
+
+	_, onRollback := tx.hooks(sp.commitMark, sp.rollbackMark, true)
+
+	runTxHooks(onRollback)
+
+	return nil
+}
+
+// ReleaseSavepoint releases the savepoint and the savepoints created after it, the changes are kept.
+// NOTE: SQL Server doesn't release savepoint, so it only forgets the savepoint.
+func (tx *Tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	i := tx.savepointIndex(name)
+
+	if i < 0 {
+		return fmt.Errorf("savepoint %q not found", name)
+	}
+
+	if tx.driver != SQLServer {
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+			return err
+		}
+	}
+
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	tx.savepoints = tx.savepoints[:i]
+
+	return nil
+}
+
+// savepointIndex returns the index of the latest savepoint with the name, -1 if not found.
+func (tx *Tx) savepointIndex(name string) int {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	for i := len(tx.savepoints) - 1; i >= 0; i-- {
+		if tx.savepoints[i].name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// IsRetryableTxError reports whether the error is deadlock or serialization failure,
+// which the transaction can be retried, eg: MySQL 1213, Postgres 40001 and 40P01.
+func IsRetryableTxError(err error) bool {
+	var myErr *mysql.MySQLError
+
+	if errors.As(err, &myErr) {
+		return myErr.Number == 1213
+	}
+
+	var pgErr *pgconn.PgError
+
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+
+	return false
+}
@@ -0,0 +1,327 @@
+package yiigo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTxTestDB(t *testing.T) *sqlx.DB {
+	db, err := sqlx.Open(string(SQLite), ":memory:")
+
+	assert.Nil(t, err)
+
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec("CREATE TABLE user (id INTEGER PRIMARY KEY, name TEXT)")
+	assert.Nil(t, err)
+
+	return db
+}
+
+func txUsers(t *testing.T, db *sqlx.DB) []string {
+	names := make([]string, 0)
+
+	assert.Nil(t, db.Select(&names, "SELECT name FROM user ORDER BY id"))
+
+	return names
+}
+
+func TestTransact(t *testing.T) {
+	ctx := context.TODO()
+
+	db := newTxTestDB(t)
+
+	defer db.Close()
+
+	builder := NewSQLiteBuilder()
+
+	// commit
+	err := Transact(ctx, db, func(ctx context.Context, tx *Tx) error {
+		_, err := builder.Wrap(Table("user")).Insert(ctx, tx, X{"name": "foo"})
+
+		return err
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"foo"}, txUsers(t, db))
+
+	// rollback
+	errAbort := errors.New("abort")
+
+	err = Transact(ctx, db, func(ctx context.Context, tx *Tx) error {
+		if _, err := builder.Wrap(Table("user")).Insert(ctx, tx, X{"name": "bar"}); err != nil {
+			return err
+		}
+
+		return errAbort
+	})
+
+	assert.ErrorIs(t, err, errAbort)
+	assert.Equal(t, []string{"foo"}, txUsers(t, db))
+
+	// panic
+	err = Transact(ctx, db, func(ctx context.Context, tx *Tx) error {
+		if _, err := builder.Wrap(Table("user")).Insert(ctx, tx, X{"name": "bar"}); err != nil {
+			return err
+		}
+
+		panic("oops")
+	})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"foo"}, txUsers(t, db))
+
+	// nested
+	err = Transact(ctx, db, func(ctx context.Context, tx *Tx) error {
+		if _, err := builder.Wrap(Table("user")).Insert(ctx, tx, X{"name": "bar"}); err != nil {
+			return err
+		}
+
+		// partial rollback
+		nestedErr := Transact(ctx, db, func(ctx context.Context, inner *Tx) error {
+			assert.Same(t, tx, inner)
+
+			if _, err := builder.Wrap(Table("user")).Insert(ctx, inner, X{"name": "baz"}); err != nil {
+				return err
+			}
+
+			return errAbort
+		})
+
+		assert.ErrorIs(t, nestedErr, errAbort)
+
+		return Transact(ctx, db, func(ctx context.Context, inner *Tx) error {
+			_, err := builder.Wrap(Table("user")).Insert(ctx, inner, X{"name": "qux"})
+
+			return err
+		})
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"foo", "bar", "qux"}, txUsers(t, db))
+}
+
+func TestTransactRetry(t *testing.T) {
+	ctx := context.TODO()
+
+	db := newTxTestDB(t)
+
+	defer db.Close()
+
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+
+	attempts := 0
+
+	err := Transact(ctx, db, func(ctx context.Context, tx *Tx) error {
+		attempts++
+
+		if _, err := tx.ExecContext(ctx, "INSERT INTO user (name) VALUES (?)", fmt.Sprintf("user%d", attempts)); err != nil {
+			return err
+		}
+
+		if attempts < 3 {
+			return fmt.Errorf("insert order: %w", deadlock)
+		}
+
+		return nil
+	}, WithTxBackoff(time.Millisecond))
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []string{"user3"}, txUsers(t, db))
+
+	// exceeds the max retries
+	attempts = 0
+
+	err = Transact(ctx, db, func(ctx context.Context, tx *Tx) error {
+		attempts++
+
+		return deadlock
+	}, WithTxRetry(1), WithTxBackoff(time.Millisecond))
+
+	assert.ErrorIs(t, err, deadlock)
+	assert.Equal(t, 2, attempts)
+
+	// not retryable
+	attempts = 0
+
+	err = Transact(ctx, db, func(ctx context.Context, tx *Tx) error {
+		attempts++
+
+		return &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}
+	})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, attempts)
+
+	assert.True(t, IsRetryableTxError(&pgconn.PgError{Code: "40001"}))
+	assert.True(t, IsRetryableTxError(&pgconn.PgError{Code: "40P01"}))
+	assert.False(t, IsRetryableTxError(&pgconn.PgError{Code: "23505"}))
+	assert.False(t, IsRetryableTxError(errors.New("deadlock")))
+}
+
+func TestTxHooks(t *testing.T) {
+	ctx := context.TODO()
+
+	db := newTxTestDB(t)
+
+	defer db.Close()
+
+	events := make([]string, 0)
+
+	hook := func(s string) func() {
+		return func() {
+			events = append(events, s)
+		}
+	}
+
+	// commit
+	err := Transact(ctx, db, func(ctx context.Context, tx *Tx) error {
+		tx.OnCommit(hook("commit1"))
+		tx.OnRollback(hook("rollback1"))
+
+		// savepoint rolled back
+		_ = Transact(ctx, db, func(ctx context.Context, tx *Tx) error {
+			tx.OnCommit(hook("commit2"))
+			tx.OnRollback(hook("rollback2"))
+
+			return errors.New("abort")
+		})
+
+		assert.Equal(t, []string{"rollback2"}, events)
+
+		// savepoint released
+		return Transact(ctx, db, func(ctx context.Context, tx *Tx) error {
+			tx.OnCommit(hook("commit3"))
+			tx.OnCommit(func() { panic("oops") })
+			tx.OnCommit(hook("commit4"))
+
+			return nil
+		})
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"rollback2", "commit1", "commit3", "commit4"}, events)
+
+	// rollback
+	events = events[:0]
+
+	err = Transact(ctx, db, func(ctx context.Context, tx *Tx) error {
+		tx.OnCommit(hook("commit"))
+		tx.OnRollback(hook("rollback"))
+
+		return errors.New("abort")
+	})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"rollback"}, events)
+
+	// panic
+	events = events[:0]
+
+	err = Transact(ctx, db, func(ctx context.Context, tx *Tx) error {
+		tx.OnCommit(hook("commit"))
+		tx.OnRollback(hook("rollback"))
+
+		panic("oops")
+	})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"rollback"}, events)
+}
+
+func TestTxQueryCacheInvalidate(t *testing.T) {
+	ctx := context.TODO()
+
+	db := newTxTestDB(t)
+
+	defer db.Close()
+
+	cache := NewMemQueryCache()
+
+	builder := NewSQLiteBuilder(WithQueryCache(cache))
+
+	version := func() string {
+		v, err := cache.Get(ctx, queryCachePrefix+"version:user")
+
+		assert.Nil(t, err)
+
+		return string(v)
+	}
+
+	err := Transact(ctx, db, func(ctx context.Context, tx *Tx) error {
+		if _, err := builder.Wrap(Table("user")).Insert(ctx, tx, X{"name": "foo"}); err != nil {
+			return err
+		}
+
+		// not invalidated before commit
+		assert.Equal(t, "", version())
+
+		return nil
+	})
+
+	assert.Nil(t, err)
+
+	assert.Equal(t, "1", version())
+}
+
+func TestTxSavepoint(t *testing.T) {
+	ctx := context.TODO()
+
+	db := newTxTestDB(t)
+
+	defer db.Close()
+
+	events := make([]string, 0)
+
+	insert := func(tx *Tx, name string) {
+		_, err := tx.ExecContext(ctx, "INSERT INTO user (name) VALUES (?)", name)
+		assert.Nil(t, err)
+	}
+
+	err := Transact(ctx, db, func(ctx context.Context, tx *Tx) error {
+		insert(tx, "foo")
+
+		assert.ErrorIs(t, tx.Savepoint(ctx, "s1; DROP TABLE user"), ErrInvalidIdent)
+
+		assert.Nil(t, tx.Savepoint(ctx, "s1"))
+
+		insert(tx, "bar")
+
+		tx.OnCommit(func() { events = append(events, "commit_bar") })
+		tx.OnRollback(func() { events = append(events, "rollback_bar") })
+
+		assert.Nil(t, tx.Savepoint(ctx, "s2"))
+
+		insert(tx, "baz")
+
+		// s2 is removed
+		assert.Nil(t, tx.RollbackTo(ctx, "s1"))
+		assert.Equal(t, []string{"rollback_bar"}, events)
+		assert.NotNil(t, tx.RollbackTo(ctx, "s2"))
+
+		insert(tx, "qux")
+
+		// rollback again
+		assert.Nil(t, tx.RollbackTo(ctx, "s1"))
+
+		insert(tx, "quux")
+
+		assert.Nil(t, tx.ReleaseSavepoint(ctx, "s1"))
+		assert.NotNil(t, tx.RollbackTo(ctx, "s1"))
+
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"foo", "quux"}, txUsers(t, db))
+	assert.Equal(t, []string{"rollback_bar"}, events)
+}